@@ -0,0 +1,6 @@
+// Package downloaderpb holds the messages and gRPC stubs of the daemon's
+// control API, generated from downloader.proto. Frontends written in other
+// languages can generate theirs from the same file.
+package downloaderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative downloader.proto
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/mattn/go-isatty"
+)
+
+// confirmSize asks whether to download the initialized albums if they are
+// larger than the confirm_if_larger_than_mb setting, and returns whether
+// to go on. With yes (-yes), it always goes on; when stdin is not a
+// terminal, nobody can answer and the run is aborted.
+func confirmSize(out *output, manager *download.Manager, yes bool) bool {
+	exceeds, mb := manager.ExceedsSizeThreshold()
+	if !exceeds || yes {
+		return true
+	}
+
+	if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		fmt.Fprintln(os.Stderr, out.T("cli.too_large", mb))
+		return false
+	}
+
+	// Asked on stderr, so that the question is seen even if stdout is
+	// redirected or quiet
+	fmt.Fprint(os.Stderr, out.T("cli.confirm_size", len(manager.GetAlbumNames()), mb))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	// "y" or "yes", or the same in the output's language (cli.yes is its
+	// initial, e.g. "o" for "oui")
+	return strings.HasPrefix(answer, "y") || (answer != "" && strings.HasPrefix(answer, out.T("cli.yes")))
+}
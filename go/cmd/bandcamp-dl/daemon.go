@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/daemon"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/grpcapi"
+	"github.com/handiism/bandcamp-downloader/internal/sink"
+)
+
+// runDaemon implements the "daemon" subcommand, serving a download queue
+// on a Unix socket until interrupted.
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socketFlag := fs.String("socket", envOr("BANDCAMP_DL_SOCKET", daemon.DefaultSocketPath()), "Path of the control socket (env BANDCAMP_DL_SOCKET)")
+	configFlag := fs.String("config", envOr("BANDCAMP_DL_CONFIG", ""), "Path to config file, JSON, TOML or YAML (env BANDCAMP_DL_CONFIG)")
+	profileFlag := fs.String("profile", envOr("BANDCAMP_DL_PROFILE", ""), "Name of the config file's profile to use (env BANDCAMP_DL_PROFILE)")
+	httpFlag := fs.String("http", envOr("BANDCAMP_DL_HTTP", ""), "Address to serve /healthz and /metrics on, e.g. :8080 (env BANDCAMP_DL_HTTP)")
+	grpcFlag := fs.String("grpc", envOr("BANDCAMP_DL_GRPC", ""), "Address to serve the gRPC control API on, e.g. 127.0.0.1:50051 (env BANDCAMP_DL_GRPC)")
+	windowFlag := fs.String("window", "", "Only download between these hours of the day, e.g. 01:00-07:00 (or download_window)")
+	newOutput := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl daemon [options]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Downloads the jobs submitted with \"bandcamp-dl add\", one at a time.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out := newOutput()
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return exitFailure
+	}
+	if *windowFlag != "" {
+		settings.DownloadWindow = *windowFlag
+		if err := settings.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	l, err := daemon.Listen(*socketFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	defer os.Remove(*socketFlag)
+
+	sinks, err := sink.OpenAll(settings.ProgressSinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	defer sinks.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	printer := sinks.Wrap(out.progressPrinter())
+	server := daemon.NewServer(settings, func(job *daemon.Job, event download.ProgressEvent) {
+		event.Message = fmt.Sprintf("[job %d] %s", job.ID, event.Message)
+		printer(event)
+	})
+
+	if *httpFlag != "" {
+		httpServer := &http.Server{Addr: *httpFlag, Handler: server.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				cancel()
+			}
+		}()
+		defer httpServer.Close()
+		out.Printf("Serving /healthz and /metrics on %s\n", *httpFlag)
+	}
+
+	if *grpcFlag != "" {
+		grpcListener, err := net.Listen("tcp", *grpcFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+		grpcServer := grpcapi.NewServer(server)
+		go grpcServer.Serve(grpcListener)
+		// Stop rather than GracefulStop: Watch streams only end with their job
+		defer grpcServer.Stop()
+		out.Printf("Serving the gRPC API on %s\n", *grpcFlag)
+	}
+
+	out.Printf("Listening on %s\n", *socketFlag)
+	if settings.DownloadWindow != "" {
+		out.Printf("Downloading between %s\n", settings.DownloadWindow)
+	}
+	if err := server.Serve(ctx, l); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runAdd implements the "add" subcommand, queueing URLs in a running daemon.
+func runAdd(args []string) int {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	socketFlag := fs.String("socket", envOr("BANDCAMP_DL_SOCKET", daemon.DefaultSocketPath()), "Path of the daemon's control socket (env BANDCAMP_DL_SOCKET)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl add [options] <URL>...")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitFailure
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, err := daemon.NewClient(*socketFlag).Add(ctx, fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Printf("Queued job %d (%d URL(s))\n", job.ID, len(job.URLs))
+	return exitOK
+}
+
+// runStatus implements the "status" subcommand, listing a daemon's jobs.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	socketFlag := fs.String("socket", envOr("BANDCAMP_DL_SOCKET", daemon.DefaultSocketPath()), "Path of the daemon's control socket (env BANDCAMP_DL_SOCKET)")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jobs, err := daemon.NewClient(*socketFlag).Status(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs.")
+		return exitOK
+	}
+	for _, job := range jobs {
+		fmt.Printf("%4d  %-9s  %s\n", job.ID, job.State, job.Added.Format("2006-01-02 15:04"))
+		for _, u := range job.URLs {
+			fmt.Printf("        %s\n", u)
+		}
+		if job.Error != "" {
+			fmt.Printf("        error: %s\n", job.Error)
+		}
+		for _, u := range job.FailedURLs {
+			fmt.Printf("        failed: %s\n", u)
+		}
+	}
+	return exitOK
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// Exit codes of a download run, documented in the README so that scripts
+// can tell a partial failure from a total one.
+const (
+	exitOK        = 0   // every album was downloaded
+	exitFailure   = 1   // nothing could be downloaded, or invalid usage
+	exitPartial   = 2   // some albums or tracks failed
+	exitCancelled = 130 // interrupted by SIGINT/SIGTERM
+)
+
+// runExitCode returns the exit code summarizing the outcome of a run.
+func runExitCode(manager *download.Manager, cancelled bool) int {
+	if cancelled {
+		return exitCancelled
+	}
+	if len(manager.GetFailedURLs()) == 0 {
+		return exitOK
+	}
+	for _, p := range manager.GetProgressSnapshot() {
+		if p.State == download.AlbumCompleted || p.State == download.AlbumPartial {
+			return exitPartial
+		}
+	}
+	return exitFailure
+}
+
+// writeFailedURLs writes one URL per line to path, so that the failed
+// releases of a run can be retried with -url "$(cat path)". The file is
+// written even when nothing failed, to not leave a stale list behind.
+func writeFailedURLs(path string, urls []string) error {
+	var content string
+	if len(urls) > 0 {
+		content = strings.Join(urls, "\n") + "\n"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// resumeQueuePath returns the file that the failed URLs are written to
+// when max_total_bytes is set without -failed-urls-out, next to the run
+// history, so that the releases left by the byte budget are not lost.
+func resumeQueuePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bandcamp-downloader", "resume.txt"), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/handiism/bandcamp-downloader/internal/library"
+)
+
+// runExport implements the "export" subcommand, writing a catalog of the
+// downloaded albums as CSV or JSON.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	formatFlag := fs.String("format", "csv", "Output format: csv or json")
+	outputFlag := fs.String("o", "", "Output file (default: stdout)")
+	configFlag := fs.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := fs.String("profile", "", "Name of the config file's profile to use")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl export [options] [library-dir]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "The library directory defaults to the root of the configured downloads path.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var write func(io.Writer, []*library.Album) error
+	switch *formatFlag {
+	case "csv":
+		write = library.WriteCSV
+	case "json":
+		write = library.WriteJSON
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q, expected csv or json\n", *formatFlag)
+		return 1
+	}
+
+	root := fs.Arg(0)
+	if root == "" {
+		settings, err := loadSettings(*configFlag, *profileFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			return 1
+		}
+		root = settings.LibraryRoot()
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	albums, err := library.Scan(ctx, root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", root, err)
+		return 1
+	}
+
+	out := os.Stdout
+	if *outputFlag != "" {
+		out, err = os.Create(*outputFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", *outputFlag, err)
+			return 1
+		}
+		defer out.Close()
+	}
+
+	if err := write(out, albums); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing catalog: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
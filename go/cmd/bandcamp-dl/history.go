@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/history"
+)
+
+// runHistory implements the "history" subcommand, listing past runs.
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	limitFlag := fs.Int("n", 20, "Number of most recent runs to show (0 for all)")
+	fileFlag := fs.String("file", "", "Path to history file (default: user config directory)")
+	fs.Parse(args)
+
+	path, err := resolveHistoryPath(*fileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error locating history file: %v\n", err)
+		return 1
+	}
+
+	runs, err := history.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		return 1
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded yet.")
+		return 0
+	}
+
+	if *limitFlag > 0 && len(runs) > *limitFlag {
+		runs = runs[len(runs)-*limitFlag:]
+	}
+
+	for _, run := range runs {
+		status := ""
+		switch {
+		case run.Cancelled:
+			status = " [cancelled]"
+		case len(run.FailedAlbums) > 0:
+			status = fmt.Sprintf(" [%d failed]", len(run.FailedAlbums))
+		}
+
+		fmt.Printf("%s  %d album(s)  %d/%d files  %.2f MB  %s%s\n",
+			run.Date.Local().Format("2006-01-02 15:04"),
+			run.Albums,
+			run.Files,
+			run.TotalFiles,
+			float64(run.Bytes)/1024/1024,
+			run.Duration().Round(time.Second),
+			status,
+		)
+		if run.TracksDownloaded+run.TracksSkipped+run.TracksUnavailable+run.TracksFailed > 0 {
+			fmt.Printf("    tracks: %d downloaded, %d already present, %d unavailable, %d failed  %.2f MB/s\n",
+				run.TracksDownloaded, run.TracksSkipped, run.TracksUnavailable, run.TracksFailed, run.Speed()/1024/1024)
+		}
+		for _, u := range run.URLs {
+			fmt.Printf("    %s\n", u)
+		}
+		for _, album := range run.FailedAlbums {
+			fmt.Printf("    ✗ %s\n", album)
+		}
+	}
+
+	return 0
+}
+
+// recordRun appends the outcome of a download run to the history file.
+func recordRun(path string, start time.Time, urls string, manager *download.Manager, cancelled bool) error {
+	received, _, filesReceived, filesTotal := manager.GetProgress()
+	snapshot := manager.GetProgressSnapshot()
+
+	var failed []string
+	for _, p := range snapshot {
+		if p.State == download.AlbumFailed || p.State == download.AlbumPartial {
+			failed = append(failed, fmt.Sprintf("%s - %s", p.Artist, p.Title))
+		}
+	}
+
+	stats := manager.GetRunStats()
+	return history.Append(path, history.Run{
+		Date:              start,
+		DurationSeconds:   time.Since(start).Seconds(),
+		URLs:              splitURLs(urls),
+		Albums:            len(snapshot),
+		Files:             filesReceived,
+		TotalFiles:        filesTotal,
+		Bytes:             received,
+		TracksDownloaded:  stats.Downloaded,
+		TracksSkipped:     stats.Skipped,
+		TracksUnavailable: stats.Unavailable,
+		TracksFailed:      stats.Failed,
+		FailedAlbums:      failed,
+		Cancelled:         cancelled,
+	})
+}
+
+// resolveHistoryPath returns the given path, or the default history
+// location if it is empty.
+func resolveHistoryPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return history.DefaultPath()
+}
+
+// splitURLs splits a comma- or newline-separated URL list.
+func splitURLs(urls string) []string {
+	var result []string
+	for _, u := range strings.FieldsFunc(urls, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		if u = strings.TrimSpace(u); u != "" {
+			result = append(result, u)
+		}
+	}
+	return result
+}
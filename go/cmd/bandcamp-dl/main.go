@@ -6,136 +6,495 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
 	"github.com/handiism/bandcamp-downloader/internal/config"
 	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/purchases"
+	"github.com/handiism/bandcamp-downloader/internal/sink"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "retag":
+			os.Exit(runRetag(os.Args[2:]))
+		case "verify":
+			os.Exit(runVerify(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "playlists":
+			os.Exit(runPlaylists(os.Args[2:]))
+		case "daemon":
+			os.Exit(runDaemon(os.Args[2:]))
+		case "add":
+			os.Exit(runAdd(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		case "redeem":
+			os.Exit(runRedeem(os.Args[2:]))
+		case "search":
+			// The picked result is downloaded as if given on the command line
+			args, code := runSearch(os.Args[2:])
+			if args == nil {
+				os.Exit(code)
+			}
+			os.Args = append(os.Args[:1], args...)
+		}
+	}
+
 	// Command line flags
 	var (
 		urlsFlag        = flag.String("url", "", "Bandcamp URL(s) to download (comma-separated or newline-separated)")
 		outputFlag      = flag.String("output", "", "Output directory (overrides config)")
-		configFlag      = flag.String("config", "", "Path to config file")
+		configFlag      = flag.String("config", "", "Path to config file (JSON, TOML or YAML)")
+		profileFlag     = flag.String("profile", "", "Name of the config file's profile to use")
 		discographyFlag = flag.Bool("discography", false, "Download entire artist discography")
 		playlistFlag    = flag.Bool("playlist", false, "Create playlist file")
-		verboseFlag     = flag.Bool("verbose", false, "Show verbose output")
 		dryRunFlag      = flag.Bool("dry-run", false, "Parse URLs without downloading")
+		historyFlag     = flag.String("history-file", "", "Path to run history file (default: user config directory)")
+		noHistoryFlag   = flag.Bool("no-history", false, "Do not record this run in the history file")
+		refreshArtFlag  = flag.Bool("refresh-artwork", false, "Re-embed artwork in existing files if it changed on Bandcamp")
+		proxyFlag       = flag.String("proxy", "", "Proxy URL(s) to rotate through, comma-separated (http://, socks5://)")
+		ipv4Flag        = flag.Bool("4", false, "Connect over IPv4 only")
+		ipv6Flag        = flag.Bool("6", false, "Connect over IPv6 only")
+		dnsFlag         = flag.String("dns", "", "DNS server(s) to use, comma-separated (1.1.1.1, tls://..., https://...)")
+		caCertFlag      = flag.String("ca-cert", "", "PEM file of extra root certificates (e.g. corporate proxy CA)")
+		insecureFlag    = flag.Bool("insecure", false, "Disable TLS certificate verification (unsafe)")
+		metadataFlag    = flag.Bool("metadata", false, "Write a metadata.json in each album folder")
+		forceFlag       = flag.Bool("force", false, "Download releases whose artist asked not to be indexed or streamed")
+		failedOutFlag   = flag.String("failed-urls-out", "", "Write the URLs that failed to this file, one per line")
+		beetsStageFlag  = flag.String("beets-staging", "", "Download into this staging directory and list the albums in its beets-import.json")
+		beetsImportFlag = flag.Bool("beets-import", false, "Run \"beet import -q\" on each completed album (or beets_import_command)")
+		tagSourceFlag   = flag.Bool("tag-source", false, "Tag files with SOURCE=bandcamp and the release and track URLs")
+		tagFieldsFlag   = flag.String("tag", "", "Tag field actions, comma-separated field=modify|empty|keep (e.g. comments=keep,lyrics=empty)")
+		lbCheckFlag     = flag.Bool("listenbrainz-check", false, "Warn about albums whose listens ListenBrainz cannot map to MusicBrainz")
+		segmentsFlag    = flag.Int("segments", 0, "Download large tracks (e.g. hour-long mixes) as this many parallel ranges")
+		archiveFlag     = flag.String("archive", "", "Package completed albums into a zip: zip (replace the folder) or zip_keep (keep both)")
+		numberingFlag   = flag.String("numbering", "", "Number the tracks of multi-disc albums per disc or sequential across discs")
+		yesFlag         = flag.Bool("yes", false, "Download without asking for confirmation above confirm_if_larger_than_mb")
+		limitAlbumsFlag = flag.Int("limit-albums", 0, "Download at most this many albums, e.g. to sample a large label")
+		limitTracksFlag = flag.Int("limit-tracks", 0, "Download at most this many tracks in total")
+		sampleFlag      = flag.Int("sample", 0, "Download this many releases of each discography, picked at random")
+		seedFlag        = flag.Int64("seed", 0, "Seed of -sample, to pick the same releases again")
+		tagLimitFlag    = flag.Int("tag-limit", 0, "Download this many releases of a tag page (bandcamp.com/tag/...)")
+		tagSortFlag     = flag.String("tag-sort", "", "Order of the releases of a tag page: popular or new")
+		discoverFlag    = flag.String("discover", "", "Download the best-selling releases of a genre[/sub-genre] of Bandcamp Discover, e.g. electronic/house")
+		discoverFmtFlag = flag.String("discover-format", "", "Format of the -discover releases: all, digital, vinyl, cd or cassette")
+		discoverNFlag   = flag.Int("discover-limit", 0, "Download this many releases of -discover or a Discover page")
+		filterFlag      = flag.String("filter", "", "Only download the releases matching this expression, e.g. \"release_year >= 2020 && track_count > 3\"")
+		importFlag      = flag.String("import", "", "Download the releases of a Bandcamp purchases CSV export, a receipt .eml or a folder of receipts")
+		maxTotalFlag    = flag.Float64("max-total-mb", 0, "Stop starting tracks once this many MB were downloaded, leaving the rest for the next run")
+		idleTimeoutFlag = flag.Float64("idle-timeout", 0, "Abort and retry a track download when no data arrives for this many seconds, never if negative")
+		keepPartialFlag = flag.Bool("keep-partial", false, "Keep the partial files of interrupted downloads and resume them on the next run")
+		longPathsFlag   = flag.Bool("long-paths", false, "Do not truncate paths to 260 characters, using \\\\?\\ paths on Windows")
+		mobileAPIFlag   = flag.Bool("mobile-api", false, "Read releases and discographies from the API of Bandcamp's mobile app rather than their pages")
+		traceFlag       = flag.String("trace", "", "Record the HTTP requests and responses to this file, as a HAR (.har) or JSON Lines, for bug reports")
 	)
 
+	newOutput := addOutputFlags(flag.CommandLine)
+
 	flag.Parse()
+	out := newOutput()
 
 	// CLI mode - require URL
-	if *urlsFlag == "" && flag.NArg() == 0 {
+	if *urlsFlag == "" && flag.NArg() == 0 && *importFlag == "" && *discoverFlag == "" {
 		fmt.Println("Bandcamp Downloader - Download music from Bandcamp")
 		fmt.Println()
 		fmt.Println("Usage:")
 		fmt.Println("  bandcamp-dl -url <URL> [options]")
 		fmt.Println("  bandcamp-dl <URL> [options]")
+		fmt.Println("  bandcamp-dl -import <purchases.csv|receipts-dir> [options]")
+		fmt.Println("  bandcamp-dl -discover <genre[/sub-genre]> [-discover-format vinyl] [-discover-limit N] [options]")
+		fmt.Println("  bandcamp-dl search [-type all|artist|album|track] [-first] \"query\" [options]")
+		fmt.Println("  bandcamp-dl redeem [-format flac] [-cookies cookies.txt] <code|yum-URL>")
+		fmt.Println("  bandcamp-dl history [-n N]")
+		fmt.Println("  bandcamp-dl retag [-url <URL>] [library-dir]")
+		fmt.Println("  bandcamp-dl verify [-fix] <folder-or-url>")
+		fmt.Println("  bandcamp-dl export [-format csv|json] [library-dir]")
+		fmt.Println("  bandcamp-dl playlists [-format m3u|m3u8|pls|wpl|zpl] [-smart | -query expr [-name name]] [-o dir] [library-dir]")
+		fmt.Println("  bandcamp-dl daemon [-socket path]")
+		fmt.Println("  bandcamp-dl add [-socket path] <URL>...")
+		fmt.Println("  bandcamp-dl status [-socket path]")
 		fmt.Println()
 		fmt.Println("For interactive mode, use: bandcamp-tui")
 		fmt.Println()
 		flag.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
 
 	// Load config
-	settings := config.DefaultSettings()
-	if *configFlag != "" {
-		var err error
-		settings, err = config.Load(*configFlag)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-			os.Exit(1)
-		}
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(exitFailure)
 	}
+	out.setLanguage(settings.Language)
 
 	// Apply flags
 	if *outputFlag != "" {
 		settings.DownloadsPath = *outputFlag + "/{artist}/{album}"
 	}
+	if *beetsStageFlag != "" {
+		settings.DownloadsPath = filepath.Join(*beetsStageFlag, "{artist}", "{album}")
+		settings.BeetsManifest = true
+	}
+	if *beetsImportFlag && settings.BeetsImportCommand == "" {
+		settings.BeetsImportCommand = "beet import -q"
+	}
 	if *discographyFlag {
 		settings.DownloadArtistDiscography = true
 	}
 	if *playlistFlag {
 		settings.CreatePlaylist = true
 	}
+	if *metadataFlag {
+		settings.SaveMetadataJSON = true
+	}
+	if *forceFlag {
+		settings.IgnoreArtistRestrictions = true
+	}
+	if *segmentsFlag > 0 {
+		settings.ParallelSegments = *segmentsFlag
+	}
+	if *maxTotalFlag > 0 {
+		settings.MaxTotalBytes = int64(*maxTotalFlag * 1024 * 1024)
+	}
+	if *idleTimeoutFlag != 0 {
+		settings.DownloadIdleTimeout = *idleTimeoutFlag
+	}
+	if *keepPartialFlag {
+		settings.KeepPartialFiles = true
+	}
+	if *longPathsFlag {
+		settings.LongPaths = true
+	}
+	if *mobileAPIFlag {
+		settings.MobileAPI = true
+	}
+	if *archiveFlag != "" {
+		settings.AlbumArchive = *archiveFlag
+	}
+	if *numberingFlag != "" {
+		settings.TrackNumbering = *numberingFlag
+	}
+	if *limitAlbumsFlag != 0 {
+		settings.LimitAlbums = *limitAlbumsFlag
+	}
+	if *limitTracksFlag != 0 {
+		settings.LimitTracks = *limitTracksFlag
+	}
+	if *filterFlag != "" {
+		settings.Filter = *filterFlag
+	}
+	if *sampleFlag != 0 {
+		settings.SampleAlbums = *sampleFlag
+		settings.DownloadArtistDiscography = true
+	}
+	if *seedFlag != 0 {
+		settings.SampleSeed = *seedFlag
+	}
+	if *tagLimitFlag != 0 {
+		settings.TagLimit = *tagLimitFlag
+	}
+	if *tagSortFlag != "" {
+		settings.TagSort = *tagSortFlag
+	}
+	if *discoverFmtFlag != "" {
+		settings.DiscoverFormat = *discoverFmtFlag
+	}
+	if *discoverNFlag != 0 {
+		settings.DiscoverLimit = *discoverNFlag
+	}
+	if *tagSourceFlag {
+		settings.TagSource = true
+	}
+	if err := setTagFields(settings, *tagFieldsFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -tag: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	if *lbCheckFlag {
+		settings.ListenBrainzLookup = true
+	}
+	if *refreshArtFlag {
+		settings.RefreshEmbeddedArtwork = true
+	}
+	if *proxyFlag != "" {
+		settings.ProxyType = "manual"
+		settings.ProxyAddress = ""
+		settings.Proxies = strings.Split(*proxyFlag, ",")
+	}
+	switch {
+	case *ipv4Flag && *ipv6Flag:
+		fmt.Fprintln(os.Stderr, "Error: -4 and -6 are mutually exclusive")
+		os.Exit(exitFailure)
+	case *ipv4Flag:
+		settings.IPVersion = 4
+	case *ipv6Flag:
+		settings.IPVersion = 6
+	}
+	if *dnsFlag != "" {
+		settings.DNSServers = strings.Split(*dnsFlag, ",")
+	}
+	if *caCertFlag != "" {
+		settings.CACertFile = *caCertFlag
+	}
+	if *insecureFlag {
+		settings.InsecureSkipVerify = true
+	}
+	if err := settings.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	if settings.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "⚠️  Warning: TLS certificate verification is disabled, connections can be intercepted")
+	}
+
+	// The releases left by max_total_bytes are saved for the next run even
+	// without -failed-urls-out; a dry run leaves them as they are
+	failedOut := *failedOutFlag
+	if failedOut == "" && settings.MaxTotalBytes > 0 && !*dryRunFlag {
+		if failedOut, err = resumeQueuePath(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not locate the resume file: %v\n", err)
+		}
+	}
 
 	// Get URLs
 	urls := *urlsFlag
 	if urls == "" && flag.NArg() > 0 {
 		urls = flag.Arg(0)
 	}
+	if *importFlag != "" {
+		imported, err := purchases.ReadURLs(*importFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -import: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		out.Println(out.T("cli.imported", len(imported), *importFlag))
+		urls = strings.Join(append([]string{urls}, imported...), "\n")
+	}
+	if *discoverFlag != "" {
+		query, err := bandcamp.ParseDiscoverQuery(*discoverFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -discover: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		// The format of the settings applies to the page
+		urls = strings.Join([]string{urls, query.URL()}, "\n")
+	}
+
+	// Open the progress sinks, closed (flushed) on exit
+	sinks, err := sink.OpenAll(settings.ProgressSinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	// Record the requests for debugging, written on exit
+	var tracer *http.Tracer
+	if *traceFlag != "" {
+		if tracer, err = http.NewTracer(*traceFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			sinks.Close()
+			os.Exit(exitFailure)
+		}
+	}
+	exit := func(code int) {
+		sinks.Close()
+		if tracer != nil {
+			if err := tracer.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not write the trace: %v\n", err)
+			}
+		}
+		os.Exit(code)
+	}
 
 	// Handle interrupts
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signalContext()
 	defer cancel()
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigCh
-		fmt.Println("\nInterrupted, cancelling...")
-		cancel()
-	}()
-
 	// Create manager with progress callback
-	manager := download.NewManager(settings, func(event download.ProgressEvent) {
-		if event.Level == download.LevelVerbose && !*verboseFlag {
-			return
-		}
-
-		prefix := ""
-		switch event.Level {
-		case download.LevelError:
-			prefix = "❌ "
-		case download.LevelWarning:
-			prefix = "⚠️  "
-		case download.LevelSuccess:
-			prefix = "✅ "
-		case download.LevelInfo:
-			prefix = "ℹ️  "
-		default:
-			prefix = "   "
-		}
-
-		fmt.Println(prefix + event.Message)
-	})
+	manager := download.NewManager(settings, sinks.Wrap(out.progressPrinter()))
+	if tracer != nil {
+		manager.SetTracer(tracer)
+	}
 
 	// Initialize
-	fmt.Println("🎵 Bandcamp Downloader")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
+	out.Println(out.sym.Title + out.T("app.title"))
+	out.Println(out.sym.Rule)
+	out.Println()
 
 	if err := manager.Initialize(ctx, urls); err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing: %v\n", err)
-		os.Exit(1)
+		exit(exitFailure)
 	}
 
 	if *dryRunFlag {
-		fmt.Println("\n[Dry run - not downloading]")
-		return
+		out.Println("\n" + out.T("cli.dry_run"))
+		saveFailedURLs(failedOut, manager)
+		if len(manager.GetFailedURLs()) > 0 {
+			if len(manager.GetProgressSnapshot()) > 0 {
+				exit(exitPartial)
+			}
+			exit(exitFailure)
+		}
+		exit(exitOK)
+	}
+
+	if !confirmSize(out, manager, *yesFlag) {
+		out.Println(out.T("cli.aborted"))
+		exit(exitFailure)
 	}
 
 	// Start downloads
-	fmt.Println("\n📥 Starting downloads...")
-	fmt.Println()
+	out.Println("\n" + out.sym.Start + out.T("cli.starting"))
+	out.Println()
+
+	start := time.Now()
+	bar := startProgressBar(out, manager)
+	err = manager.StartDownloads(ctx)
+	bar.Stop()
+
+	if !*noHistoryFlag {
+		historyPath, herr := resolveHistoryPath(*historyFlag)
+		if herr == nil {
+			herr = recordRun(historyPath, start, urls, manager, ctx.Err() != nil)
+		}
+		if herr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record run history: %v\n", herr)
+		}
+	}
 
-	if err := manager.StartDownloads(ctx); err != nil {
+	saveFailedURLs(failedOut, manager)
+
+	if err != nil {
 		if ctx.Err() != nil {
-			fmt.Println("\nDownload cancelled.")
-			os.Exit(130)
+			fmt.Println("\n" + out.T("cli.cancelled"))
+			exit(exitCancelled)
 		}
 		fmt.Fprintf(os.Stderr, "Error during download: %v\n", err)
-		os.Exit(1)
+		exit(exitFailure)
+	}
+
+	_, _, filesReceived, filesTotal := manager.GetProgress()
+	bytes := manager.GetByteStats()
+	out.Println()
+	out.Println(out.sym.Rule)
+	out.Println(out.sym.Done + out.T("cli.complete", filesReceived, filesTotal, float64(bytes.Received)/1024/1024))
+	if bytes.Skipped > 0 {
+		out.Println("   " + out.T("cli.already_present", float64(bytes.Skipped)/1024/1024))
+	}
+	var videos int
+	for _, p := range manager.GetProgressSnapshot() {
+		videos += p.SkippedVideos
+	}
+	if videos > 0 {
+		out.Println("   " + out.T("cli.videos_skipped", videos))
+	}
+	if bytes.Total > 0 && bytes.Received+bytes.Skipped < bytes.Total {
+		out.Println("   " + out.T("cli.expected", float64(bytes.Total)/1024/1024))
+	}
+	if failed := manager.GetFailedURLs(); len(failed) > 0 {
+		out.Println("   " + out.T("cli.failed", len(failed)))
+		if failedOut != "" && *failedOutFlag == "" {
+			out.Println("   " + out.T("cli.resume", failedOut))
+		}
+	}
+	stats := manager.GetRunStats()
+	out.Println("   " + out.T("cli.tracks", stats.Downloaded, stats.Skipped, stats.Unavailable, stats.Failed))
+	if stats.DurationMismatches > 0 {
+		out.Println("   " + out.T("cli.truncated", stats.DurationMismatches))
+	}
+	out.Println("   " + out.T("cli.elapsed", stats.Elapsed.Round(time.Second), stats.Speed()/1024/1024))
+
+	exit(runExitCode(manager, ctx.Err() != nil))
+}
+
+// saveFailedURLs writes the failed URLs of the run to path, if not empty.
+func saveFailedURLs(path string, manager *download.Manager) {
+	if path == "" {
+		return
+	}
+	if err := writeFailedURLs(path, manager.GetFailedURLs()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write failed URLs: %v\n", err)
 	}
+}
+
+// loadSettings loads the config file at path, or the defaults if path is
+// empty, applies its profile named profile if not empty, then the
+// BANDCAMP_DL_* environment variables.
+func loadSettings(path, profile string) (*config.Settings, error) {
+	settings := config.DefaultSettings()
+	if path != "" {
+		var err error
+		if settings, err = config.Load(path); err != nil {
+			return nil, err
+		}
+	}
+	if profile != "" {
+		var err error
+		if settings, err = settings.WithProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := settings.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
 
-	received, total, filesReceived, filesTotal := manager.GetProgress()
-	fmt.Println()
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Printf("✨ Complete! Downloaded %d/%d files (%.2f MB)\n", filesReceived, filesTotal, float64(received)/1024/1024)
-	if total > 0 && received < total {
-		fmt.Printf("   (%.2f MB expected)\n", float64(total)/1024/1024)
+// setTagFields adds the field=action pairs of value, as given to the -tag
+// flag, to the tag field actions of settings. Unknown fields and actions
+// are reported by Settings.Validate.
+func setTagFields(settings *config.Settings, value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		field, action, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid entry %q, must be field=action", item)
+		}
+		if settings.TagFields == nil {
+			settings.TagFields = make(map[string]string)
+		}
+		settings.TagFields[strings.TrimSpace(field)] = strings.TrimSpace(action)
 	}
+	return nil
+}
+
+// envOr returns the value of the environment variable name, or def if it
+// is not set. It is used for the defaults of flags that can be configured
+// from the environment, e.g. in a container.
+func envOr(name, def string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return def
+}
+
+// signalContext returns a context that is cancelled on SIGINT or SIGTERM.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Println("\nInterrupted, cancelling...")
+		cancel()
+	}()
+
+	return ctx, cancel
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/handiism/bandcamp-downloader/internal/console"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/i18n"
+)
+
+// ANSI colors of the event levels.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiGreen  = "\x1b[32m"
+	ansiDim    = "\x1b[2m"
+)
+
+// output prints the CLI's messages according to the output flags.
+//
+// With quiet, only errors are printed. Colors are used when stdout is a
+// terminal supporting them, unless disabled with -no-color or the NO_COLOR
+// environment variable (https://no-color.org). Emoji and box-drawing
+// characters are replaced by plain ASCII with -ascii, e.g. for log files,
+// or when the terminal cannot display them (default Windows consoles).
+//
+// On a terminal, a progress bar can be shown below the log lines (see
+// startProgressBar).
+//
+// Messages are translated to the language of the environment, or of the
+// settings once loaded (see setLanguage).
+type output struct {
+	verbose  bool
+	quiet    bool
+	color    bool
+	terminal bool
+	unicode  bool
+	sym      console.Symbols
+	lang     *i18n.Catalog
+
+	// mu serializes writes to stdout between the log lines and the
+	// progress bar goroutine.
+	mu  sync.Mutex
+	bar *progressBar
+}
+
+// addOutputFlags registers the -verbose, -quiet, -no-color and -ascii flags
+// on fs. The returned function builds the output once fs is parsed.
+func addOutputFlags(fs *flag.FlagSet) func() *output {
+	verbose := fs.Bool("verbose", false, "Show verbose output")
+	quiet := fs.Bool("quiet", false, "Only print errors")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also set by NO_COLOR)")
+	ascii := fs.Bool("ascii", false, "Use plain ASCII instead of emoji and box-drawing characters")
+
+	return func() *output {
+		caps := console.Detect(os.Stdout)
+		o := &output{
+			verbose:  *verbose && !*quiet,
+			quiet:    *quiet,
+			color:    !*noColor && os.Getenv("NO_COLOR") == "" && caps.Color,
+			terminal: caps.Terminal,
+			unicode:  caps.Unicode && !*ascii,
+			sym:      caps.Symbols(),
+			lang:     i18n.New(""),
+		}
+		if *ascii {
+			o.sym = console.ASCIISymbols
+		}
+		return o
+	}
+}
+
+// setLanguage translates the messages to lang, the language setting.
+func (o *output) setLanguage(lang string) {
+	o.lang = i18n.New(lang)
+}
+
+// T returns the message of key in the output's language, see i18n.
+func (o *output) T(key string, args ...any) string {
+	return o.lang.T(key, args...)
+}
+
+// Println prints a line to stdout, unless quiet.
+func (o *output) Println(a ...any) {
+	if !o.quiet {
+		o.print(fmt.Sprintln(a...))
+	}
+}
+
+// Printf prints a formatted message to stdout, unless quiet.
+func (o *output) Printf(format string, a ...any) {
+	if !o.quiet {
+		o.print(fmt.Sprintf(format, a...))
+	}
+}
+
+// print writes s to stdout, above the progress bar if one is shown.
+func (o *output) print(s string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.bar != nil {
+		o.bar.clearLocked()
+	}
+	fmt.Print(s)
+}
+
+// progressPrinter returns a progress callback printing events to stdout.
+func (o *output) progressPrinter() func(download.ProgressEvent) {
+	return func(event download.ProgressEvent) {
+		switch {
+		case event.Level == download.LevelProgress,
+			event.Level == download.LevelVerbose && !o.verbose,
+			event.Level != download.LevelError && o.quiet:
+			return
+		}
+
+		var prefix, color string
+		switch event.Level {
+		case download.LevelError:
+			prefix, color = o.sym.Error, ansiRed
+		case download.LevelWarning:
+			prefix, color = o.sym.Warning, ansiYellow
+		case download.LevelSuccess:
+			prefix, color = o.sym.Success, ansiGreen
+		case download.LevelInfo:
+			prefix = o.sym.Info
+		default:
+			prefix, color = o.sym.Verbose, ansiDim
+		}
+
+		line := prefix + event.Message
+		if o.color && color != "" {
+			line = color + line + ansiReset
+		}
+		o.print(line + "\n")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/sink"
+)
+
+// runPlaylists implements the "playlists" subcommand, (re)writing the
+// playlists of the downloaded albums, or the smart playlists gathering the
+// tracks matching a query, from their files' tags.
+func runPlaylists(args []string) int {
+	fs := flag.NewFlagSet("playlists", flag.ExitOnError)
+	formatFlag := fs.String("format", "", "Playlist format: m3u, m3u8, pls, wpl or zpl (default: the configured format)")
+	smartFlag := fs.Bool("smart", false, "Write the configured smart playlists instead of the albums' playlists")
+	queryFlag := fs.String("query", "", "Write a smart playlist of the tracks matching this query (e.g. '\"ambient\" in genre && year >= 2010')")
+	nameFlag := fs.String("name", "", "Name of the -query playlist (default: the query)")
+	dirFlag := fs.String("o", "", "Folder of the smart playlists (default: smart_playlists_path, or Playlists in the library)")
+	configFlag := fs.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := fs.String("profile", "", "Name of the config file's profile to use")
+	newOutput := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl playlists [options] [library-dir]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "The playlists are written from the tags of the MP3 files, without")
+		fmt.Fprintln(fs.Output(), "network access. The library directory defaults to the root of the")
+		fmt.Fprintln(fs.Output(), "configured downloads path.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Query fields: artist, album_artist, album, title, genre, year,")
+		fmt.Fprintln(fs.Output(), "track_number, duration (seconds), bandcamp_url and path.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out := newOutput()
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	out.setLanguage(settings.Language)
+	switch *formatFlag {
+	case "":
+	case "m3u", "m3u8", "pls", "wpl", "zpl":
+		settings.PlaylistFormat = *formatFlag
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q, expected m3u, m3u8, pls, wpl or zpl\n", *formatFlag)
+		return 1
+	}
+
+	root := fs.Arg(0)
+	if root == "" {
+		root = settings.LibraryRoot()
+	}
+
+	smart := settings.SmartPlaylists
+	if *queryFlag != "" {
+		name := *nameFlag
+		if name == "" {
+			name = *queryFlag
+		}
+		smart = []config.SmartPlaylist{{Name: name, Query: *queryFlag}}
+		*smartFlag = true
+	}
+	if *smartFlag && len(smart) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no smart_playlists configured, use -query")
+		return 1
+	}
+	dir := *dirFlag
+	if dir == "" {
+		dir = settings.SmartPlaylistsDir()
+	}
+
+	sinks, err := sink.OpenAll(settings.ProgressSinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer sinks.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	manager := download.NewManager(settings, sinks.Wrap(out.progressPrinter()))
+	var written int
+	if *smartFlag {
+		written, err = manager.WriteSmartPlaylists(ctx, root, smart, dir)
+	} else {
+		written, err = manager.RegeneratePlaylists(ctx, root)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("\n" + out.T("playlists.cancelled"))
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", root, err)
+		return 1
+	}
+
+	out.Println("\n" + out.sym.Done + out.T("playlists.done", written))
+	return 0
+}
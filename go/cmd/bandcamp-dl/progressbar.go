@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+const (
+	// progressBarWidth is the number of cells of the bar itself.
+	progressBarWidth = 30
+
+	// progressBarInterval is how often the bar is redrawn.
+	progressBarInterval = 200 * time.Millisecond
+)
+
+// progressBar draws a single, in-place updated line with the overall
+// progress of the downloads: percent, files, speed and estimated time left.
+//
+// Log lines printed through output while the bar is running are written
+// above it; the bar is redrawn on the next tick.
+type progressBar struct {
+	out     *output
+	manager *download.Manager
+	stop    chan struct{}
+	done    chan struct{}
+
+	// Guarded by out.mu
+	shown   bool
+	lastLen int
+
+	// Only used by the drawing goroutine
+	lastBytes    int64
+	lastTick     time.Time
+	bytesPerSec  float64
+	haveEstimate bool
+}
+
+// startProgressBar starts drawing the progress of manager, and returns nil
+// if the output cannot show a progress bar (not a terminal, or quiet).
+func startProgressBar(out *output, manager *download.Manager) *progressBar {
+	if !out.terminal || out.quiet {
+		return nil
+	}
+
+	now := time.Now()
+	bar := &progressBar{
+		out:      out,
+		manager:  manager,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		lastTick: now,
+	}
+
+	out.mu.Lock()
+	out.bar = bar
+	out.mu.Unlock()
+
+	go bar.run()
+	return bar
+}
+
+// Stop stops the bar and erases it. It is safe to call on a nil bar.
+func (b *progressBar) Stop() {
+	if b == nil {
+		return
+	}
+	close(b.stop)
+	<-b.done
+
+	b.out.mu.Lock()
+	b.clearLocked()
+	b.out.bar = nil
+	b.out.mu.Unlock()
+}
+
+func (b *progressBar) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(progressBarInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			line := b.render(now)
+			b.out.mu.Lock()
+			b.drawLocked(line)
+			b.out.mu.Unlock()
+		}
+	}
+}
+
+// render returns the bar line for the manager's current progress.
+func (b *progressBar) render(now time.Time) string {
+	_, _, files, totalFiles := b.manager.GetProgress()
+	stats := b.manager.GetByteStats()
+
+	// Smooth the speed so the estimate does not jump around between ticks
+	if elapsed := now.Sub(b.lastTick).Seconds(); elapsed > 0 {
+		speed := float64(stats.Received-b.lastBytes) / elapsed
+		if b.haveEstimate {
+			b.bytesPerSec = 0.8*b.bytesPerSec + 0.2*speed
+		} else {
+			b.bytesPerSec = speed
+			b.haveEstimate = true
+		}
+	}
+	b.lastBytes = stats.Received
+	b.lastTick = now
+
+	var fraction float64
+	if stats.Total > 0 {
+		fraction = float64(stats.Received+stats.Skipped) / float64(stats.Total)
+	} else if totalFiles > 0 {
+		fraction = float64(files) / float64(totalFiles)
+	}
+	fraction = min(max(fraction, 0), 1)
+
+	return fmt.Sprintf("%s %3.0f%%  %d/%d files  %s  ETA %s",
+		renderBar(fraction, progressBarWidth, b.out.unicode),
+		fraction*100,
+		files, totalFiles,
+		formatSpeed(b.bytesPerSec),
+		formatETA(stats.Total-stats.Received-stats.Skipped, b.bytesPerSec),
+	)
+}
+
+// drawLocked replaces the bar line with line. out.mu must be held.
+func (b *progressBar) drawLocked(line string) {
+	n := len([]rune(line))
+	fmt.Print("\r" + line + strings.Repeat(" ", max(b.lastLen-n, 0)))
+	b.lastLen = n
+	b.shown = true
+}
+
+// clearLocked erases the bar line, leaving the cursor at its start.
+// out.mu must be held.
+func (b *progressBar) clearLocked() {
+	if !b.shown {
+		return
+	}
+	fmt.Print("\r" + strings.Repeat(" ", b.lastLen) + "\r")
+	b.shown = false
+}
+
+// renderBar returns a bar of width cells filled to fraction.
+func renderBar(fraction float64, width int, unicode bool) string {
+	full, empty := "#", "-"
+	if unicode {
+		full, empty = "█", "░"
+	}
+	filled := int(fraction * float64(width))
+	return "[" + strings.Repeat(full, filled) + strings.Repeat(empty, width-filled) + "]"
+}
+
+// formatSpeed returns a human-readable transfer rate.
+func formatSpeed(bytesPerSec float64) string {
+	switch {
+	case bytesPerSec >= 1024*1024:
+		return fmt.Sprintf("%.1f MB/s", bytesPerSec/1024/1024)
+	case bytesPerSec >= 1024:
+		return fmt.Sprintf("%.0f KB/s", bytesPerSec/1024)
+	default:
+		return fmt.Sprintf("%.0f B/s", bytesPerSec)
+	}
+}
+
+// formatETA returns the time needed to transfer remaining bytes at
+// bytesPerSec as "m:ss" (or "h:mm:ss"), or "--:--" if unknown.
+func formatETA(remaining int64, bytesPerSec float64) string {
+	if remaining <= 0 {
+		return "0:00"
+	}
+	if bytesPerSec < 1 {
+		return "--:--"
+	}
+
+	eta := time.Duration(float64(remaining) / bytesPerSec * float64(time.Second)).Round(time.Second)
+	h, m, s := int(eta.Hours()), int(eta.Minutes())%60, int(eta.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
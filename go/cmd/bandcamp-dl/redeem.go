@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/sink"
+)
+
+// runRedeem implements the "redeem" subcommand, redeeming a download code
+// to the account of the cookies file and downloading its release.
+func runRedeem(args []string) int {
+	fs := flag.NewFlagSet("redeem", flag.ExitOnError)
+	formatFlag := fs.String("format", "flac", "Download format: "+strings.Join(bandcamp.DownloadFormats, ", "))
+	cookiesFlag := fs.String("cookies", "", "Cookies file (cookies.txt) of a logged-in Bandcamp session (overrides config)")
+	outputFlag := fs.String("output", "", "Output directory (overrides config)")
+	configFlag := fs.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := fs.String("profile", "", "Name of the config file's profile to use")
+	newOutput := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl redeem [options] <code|yum-URL|download-page-URL>")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Codes are redeemed to the account logged in with the cookies file,")
+		fmt.Fprintln(fs.Output(), "then the release is downloaded in the chosen format.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out := newOutput()
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	out.setLanguage(settings.Language)
+	if *cookiesFlag != "" {
+		settings.CookiesFile = *cookiesFlag
+	}
+	if *outputFlag != "" {
+		settings.DownloadsPath = *outputFlag + "/{artist}/{album}"
+	}
+	if err := settings.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if settings.CookiesFile == "" {
+		fmt.Fprintln(os.Stderr, "Warning: no cookies file set (-cookies or cookies_file), codes can only be redeemed when logged in")
+	}
+
+	sinks, err := sink.OpenAll(settings.ProgressSinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer sinks.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	manager := download.NewManager(settings, sinks.Wrap(out.progressPrinter()))
+	result, err := manager.Redeem(ctx, fs.Arg(0), *formatFlag)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "Error redeeming %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+
+	if result.Files > 0 {
+		out.Printf("\n%s%s - %s (%s): %d files in %s\n", out.sym.Done, result.Artist, result.Title, result.Format, result.Files, result.Path)
+	} else {
+		out.Printf("\n%s%s - %s (%s): %s\n", out.sym.Done, result.Artist, result.Title, result.Format, result.Path)
+	}
+	return 0
+}
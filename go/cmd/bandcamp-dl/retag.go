@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/sink"
+)
+
+// runRetag implements the "retag" subcommand, rewriting tags and artwork
+// of already-downloaded tracks without downloading audio again.
+func runRetag(args []string) int {
+	fs := flag.NewFlagSet("retag", flag.ExitOnError)
+	urlsFlag := fs.String("url", "", "Bandcamp URL(s) whose downloaded tracks should be retagged")
+	configFlag := fs.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := fs.String("profile", "", "Name of the config file's profile to use")
+	tagFieldsFlag := fs.String("tag", "", "Tag field actions, comma-separated field=modify|empty|keep (e.g. comments=keep)")
+	newOutput := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl retag [-url <URL>] [options] [library-dir]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Files are matched by their configured path, or by the Bandcamp URL")
+		fmt.Fprintln(fs.Output(), "embedded in their tags when a library directory is given.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out := newOutput()
+
+	libraryPath := fs.Arg(0)
+	if *urlsFlag == "" && libraryPath == "" {
+		fs.Usage()
+		return 1
+	}
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	out.setLanguage(settings.Language)
+	if err = setTagFields(settings, *tagFieldsFlag); err == nil {
+		err = settings.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -tag: %v\n", err)
+		return 1
+	}
+
+	sinks, err := sink.OpenAll(settings.ProgressSinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer sinks.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	manager := download.NewManager(settings, sinks.Wrap(out.progressPrinter()))
+	retagged, err := manager.Retag(ctx, *urlsFlag, libraryPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("\n" + out.T("retag.cancelled"))
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "Error during retag: %v\n", err)
+		return 1
+	}
+
+	out.Println("\n" + out.sym.Done + out.T("retag.done", retagged))
+	return 0
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/mattn/go-isatty"
+)
+
+// runSearch implements the "search" subcommand, finding artists, albums
+// and tracks by name. The results are listed; with -first, or once one is
+// picked when stdin is a terminal, the arguments of the download of the
+// result are returned, for main to run it with the download options
+// following the query. Otherwise, the arguments are nil and main exits
+// with the returned code.
+func runSearch(args []string) ([]string, int) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	typeFlag := fs.String("type", "all", "Kind of results: all, artist, album or track")
+	limitFlag := fs.Int("n", 10, "Maximum number of results to list")
+	firstFlag := fs.Bool("first", false, "Download the first result without asking")
+	configFlag := fs.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := fs.String("profile", "", "Name of the config file's profile to use")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: bandcamp-dl search [options] "query" [download options]`)
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Lists the results and asks which one to download, if stdin is a terminal.")
+		fmt.Fprintln(fs.Output(), "The whole discography of an artist is downloaded.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return nil, 1
+	}
+	kind, err := bandcamp.ParseSearchKind(*typeFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 1
+	}
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return nil, 1
+	}
+
+	// Not signalContext, whose handler would outlive the search and
+	// report the interruption of the download twice
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := download.NewManager(settings, nil).Search(ctx, fs.Arg(0), kind)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching: %v\n", err)
+		return nil, 1
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No results for %q\n", fs.Arg(0))
+		return nil, 1
+	}
+	if *limitFlag > 0 && len(results) > *limitFlag {
+		results = results[:*limitFlag]
+	}
+
+	var picked *bandcamp.SearchResult
+	if *firstFlag {
+		picked = &results[0]
+		fmt.Fprintf(os.Stderr, "Downloading %s\n", picked)
+	} else {
+		for i, r := range results {
+			fmt.Printf("%2d. %s\n    %s\n", i+1, r, r.URL)
+		}
+		if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+			return nil, 0
+		}
+		if picked = askResult(results); picked == nil {
+			return nil, 0
+		}
+	}
+
+	downloadArgs := []string{"-url", picked.URL}
+	if picked.Kind == bandcamp.SearchArtist {
+		downloadArgs = append(downloadArgs, "-discography")
+	}
+	if *configFlag != "" {
+		downloadArgs = append(downloadArgs, "-config", *configFlag)
+	}
+	if *profileFlag != "" {
+		downloadArgs = append(downloadArgs, "-profile", *profileFlag)
+	}
+	return append(downloadArgs, fs.Args()[1:]...), 0
+}
+
+// askResult asks on stderr which of results to download, and returns it,
+// or nil if none is picked.
+func askResult(results []bandcamp.SearchResult) *bandcamp.SearchResult {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(os.Stderr, "Download which result? [1-%d, empty to quit] ", len(results))
+		answer, err := reader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return nil
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(results) {
+			return &results[n-1]
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/sink"
+)
+
+// runVerify implements the "verify" subcommand, comparing local files
+// against the current Bandcamp metadata.
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fixFlag := fs.Bool("fix", false, "Download missing or mismatched files")
+	qualityFlag := fs.Bool("quality", false, "Also report tracks at the 128 kbps of Bandcamp streams")
+	upgradeFormatFlag := fs.String("upgrade-format", "", "Format the purchased low-quality tracks are upgraded to with -fix (default from config)")
+	cookiesFlag := fs.String("cookies", "", "Cookies file (Netscape format) of the Bandcamp account whose purchases -fix upgrades")
+	configFlag := fs.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := fs.String("profile", "", "Name of the config file's profile to use")
+	newOutput := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bandcamp-dl verify [options] <folder-or-url>")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out := newOutput()
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	var urls, libraryPath string
+	target := fs.Arg(0)
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		urls = target
+	} else {
+		libraryPath = target
+	}
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	out.setLanguage(settings.Language)
+	if *qualityFlag {
+		settings.CheckQuality = true
+	}
+	if *upgradeFormatFlag != "" {
+		settings.UpgradeFormat = *upgradeFormatFlag
+	}
+	if *cookiesFlag != "" {
+		settings.CookiesFile = *cookiesFlag
+	}
+	if err := settings.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	sinks, err := sink.OpenAll(settings.ProgressSinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer sinks.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	manager := download.NewManager(settings, sinks.Wrap(out.progressPrinter()))
+	issues, err := manager.Verify(ctx, urls, libraryPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("\n" + out.T("verify.cancelled"))
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "Error during verify: %v\n", err)
+		return 1
+	}
+
+	out.Println()
+	if len(issues) == 0 {
+		out.Println(out.sym.Done + out.T("verify.ok"))
+		return 0
+	}
+
+	for _, issue := range issues {
+		line := fmt.Sprintf("  %s: %s", issue.Kind, issue.Path)
+		if issue.Detail != "" {
+			line += " (" + issue.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Println("\n" + out.T("verify.issues", len(issues)))
+
+	if !*fixFlag {
+		return 1
+	}
+
+	out.Println("\n" + out.sym.Start + out.T("verify.fixing"))
+	upgrades, err := manager.Fix(ctx, issues)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("\n" + out.T("verify.fix_cancelled"))
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "Error during fix: %v\n", err)
+		return 1
+	}
+
+	if len(upgrades) > 0 {
+		out.Println()
+		for _, u := range upgrades {
+			fmt.Printf("  %s: %s\n", u.Format, u.Path)
+		}
+		out.Println(out.sym.Done + out.T("verify.upgraded", len(upgrades)))
+	}
+	return 0
+}
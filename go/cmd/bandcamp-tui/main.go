@@ -1,15 +1,85 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/daemon"
+	"github.com/handiism/bandcamp-downloader/internal/history"
 	"github.com/handiism/bandcamp-downloader/internal/tui"
 )
 
 func main() {
-	if err := tui.Run(); err != nil {
+	configFlag := flag.String("config", "", "Path to config file (JSON, TOML or YAML)")
+	profileFlag := flag.String("profile", "", "Name of the config file's profile to use")
+	accessibleFlag := flag.Bool("accessible", false, "Screen-reader friendly mode: no animations, sequential log lines and announced state changes")
+	themeFlag := flag.String("theme", "", "Color theme: dark, light or no-color")
+	remoteFlag := flag.Bool("remote", false, "Submit downloads to a running daemon and follow them, instead of downloading in the TUI")
+	socketFlag := flag.String("socket", "", "Path of the daemon's control socket with -remote (env BANDCAMP_DL_SOCKET)")
+	flag.Parse()
+
+	settings, err := loadSettings(*configFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if *accessibleFlag {
+		settings.Accessible = true
+	}
+	if *themeFlag != "" {
+		settings.Theme = *themeFlag
+		if err := settings.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Without a config directory, the TUI runs without URL history
+	urlsPath, _ := history.URLsPath(*configFlag)
+
+	if *remoteFlag {
+		socket := *socketFlag
+		if socket == "" {
+			socket = os.Getenv("BANDCAMP_DL_SOCKET")
+		}
+		if socket == "" {
+			socket = daemon.DefaultSocketPath()
+		}
+		err = tui.RunRemote(settings, urlsPath, socket)
+	} else {
+		err = tui.Run(settings, urlsPath)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// loadSettings loads the config file at path, or the defaults if path is
+// empty, applies its profile named profile if not empty, then the
+// BANDCAMP_DL_* environment variables.
+func loadSettings(path, profile string) (*config.Settings, error) {
+	settings := config.DefaultSettings()
+	if path != "" {
+		var err error
+		if settings, err = config.Load(path); err != nil {
+			return nil, err
+		}
+	}
+	if profile != "" {
+		var err error
+		if settings, err = settings.WithProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := settings.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
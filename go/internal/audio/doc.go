@@ -13,7 +13,39 @@
 //   - Album Title, Track Title
 //   - Track Number, Year
 //   - Lyrics
-//   - Cover Art (embedded in MP3)
+//   - Composer, Publisher and ISRC, when the credits name them
+//   - Cover Art (embedded in MP3), as any picture type (TagConfig.PictureType),
+//     and more pictures such as a back cover (Picture)
+//   - Source: SOURCE=bandcamp and the release/track URLs (TagConfig.Source)
+//
+// Files that carry an ID3v1 tag keep its values: they are copied into the
+// ID3v2 frames of the fields that are not modified, and the ID3v1 tag can
+// then be removed (TagConfig.StripID3v1). ReadID3v1 reads it directly.
+//
+// The tag format is chosen by the file extension: FLAC files (.flac) get
+// Vorbis comments and a front cover PICTURE block, MP4 files (.m4a, .m4b,
+// .mp4) get iTunes metadata atoms, and other files ID3 tags. The Bandcamp
+// URL and source are written as BANDCAMP_URL and SOURCE comments, or as
+// freeform com.apple.iTunes items.
+//
+// Tags are never written in place: the tagged file is written and synced
+// to a temporary file next to it, which replaces it only once its audio
+// data is checked to be identical to the original's, so an interrupted
+// save cannot corrupt a track. ErrAudioChanged reports a failed check.
+//
+// # Reading Tags
+//
+// A Reader reads the tags and embedded pictures of MP3 files back into
+// model structures, e.g. to inspect a library without fetching anything:
+//
+//	reader := audio.NewReader()
+//	album, err := reader.ReadAlbum("/music/Artist/Album")
+//	for _, track := range album.Tracks {
+//	    fmt.Printf("%02d %s (%s)\n", track.Number, track.Title, track.PageURL())
+//	}
+//	cover, err := reader.ReadArtwork(album.Tracks[0].Path)
+//
+// ReadTagInfo reads only the main values, as plain strings and numbers.
 //
 // # Playlist Generation
 //
@@ -25,7 +57,27 @@
 //
 // Supported formats:
 //   - M3U (with optional extended info)
+//   - M3U8 (extended M3U in UTF-8)
 //   - PLS
 //   - WPL (Windows Media Player)
 //   - ZPL (Zune Media Player)
+//
+// CreatePlaylistFile applies the PlaylistOptions of the creator: the
+// character encoding (UTF-8 with or without a byte order mark, or
+// Windows-1252 for older Windows players) and CRLF line endings:
+//
+//	creator := audio.NewPlaylistCreator(audio.FormatM3U, true).
+//	    WithOptions(audio.PlaylistOptions{Encoding: audio.EncodingUTF8BOM, CRLF: true})
+//	data, err := creator.CreatePlaylistFile(album)
+//
+// In extended M3U and M3U8 playlists, PlaylistOptions.AlbumInfo adds the
+// #PLAYLIST, #EXTALB and #EXTART directives and PlaylistOptions.Artwork
+// an #EXTIMG directive naming the cover art.
+//
+// # Transcoding
+//
+// Transcode converts a file to one of TranscodeFormats with ffmpeg, which
+// must be installed, copying its tags:
+//
+//	err := audio.Transcode(ctx, "ffmpeg", "01 Song.mp3", "01 Song.opus", "opus", 96)
 package audio
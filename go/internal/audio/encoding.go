@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// PlaylistEncoding is the character encoding of playlist files.
+//
+// Playlists are UTF-8, which older Windows players read in the system
+// code page unless the file starts with a byte order mark, mangling the
+// titles and file names that are not ASCII.
+type PlaylistEncoding int
+
+const (
+	// EncodingUTF8 writes UTF-8 without a byte order mark.
+	EncodingUTF8 PlaylistEncoding = iota
+
+	// EncodingUTF8BOM writes UTF-8 starting with a byte order mark.
+	EncodingUTF8BOM
+
+	// EncodingWindows1252 writes Windows-1252 (Western European), for
+	// players that only read the system code page. Playlists with
+	// characters it lacks cannot be encoded.
+	EncodingWindows1252
+
+	// EncodingAuto writes UTF-8, with a byte order mark only if the
+	// playlist is not plain ASCII, so ASCII playlists stay readable by
+	// every player.
+	EncodingAuto
+)
+
+// playlistEncodings are the names of the encodings in settings.
+var playlistEncodings = []struct {
+	name     string
+	encoding PlaylistEncoding
+}{
+	{"utf-8", EncodingUTF8},
+	{"utf-8-bom", EncodingUTF8BOM},
+	{"windows-1252", EncodingWindows1252},
+	{"auto", EncodingAuto},
+}
+
+// utf8BOM is the byte order mark of UTF-8.
+const utf8BOM = "\uFEFF"
+
+// ErrUnencodable is returned by PlaylistOptions.Encode when the playlist
+// has characters its encoding cannot represent.
+var ErrUnencodable = errors.New("character not representable in the playlist encoding")
+
+// ParsePlaylistEncoding parses the name of a playlist encoding as written
+// in settings: "utf-8", "utf-8-bom", "windows-1252" or "auto". An empty
+// name is EncodingUTF8.
+func ParsePlaylistEncoding(name string) (PlaylistEncoding, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return EncodingUTF8, nil
+	}
+	var names []string
+	for _, e := range playlistEncodings {
+		if strings.EqualFold(name, e.name) {
+			return e.encoding, nil
+		}
+		names = append(names, e.name)
+	}
+	return 0, fmt.Errorf("invalid playlist encoding %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// String returns the name of the encoding in settings.
+func (e PlaylistEncoding) String() string {
+	for _, pe := range playlistEncodings {
+		if pe.encoding == e {
+			return pe.name
+		}
+	}
+	return fmt.Sprintf("PlaylistEncoding(%d)", int(e))
+}
+
+// Encode returns a playlist generated by PlaylistCreator.CreatePlaylist,
+// whose lines end with "\n", with the line endings and encoding of o.
+// Returns an error wrapping ErrUnencodable if the encoding lacks
+// characters of content.
+func (o PlaylistOptions) Encode(content string) ([]byte, error) {
+	if o.CRLF {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+
+	switch o.Encoding {
+	case EncodingUTF8BOM:
+		return []byte(utf8BOM + content), nil
+	case EncodingAuto:
+		if !isASCII(content) {
+			return []byte(utf8BOM + content), nil
+		}
+		return []byte(content), nil
+	case EncodingWindows1252:
+		out := make([]byte, 0, len(content))
+		for _, r := range content {
+			b, ok := charmap.Windows1252.EncodeRune(r)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q in Windows-1252", ErrUnencodable, r)
+			}
+			out = append(out, b)
+		}
+		return out, nil
+	default:
+		return []byte(content), nil
+	}
+}
+
+// isASCII reports whether s only has ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // image.DecodeConfig of JPEG artwork
+	_ "image/png"  // image.DecodeConfig of PNG artwork
+	"io"
+	"os"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// FLAC metadata block types.
+const (
+	flacPadding       = 1
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+// flacPaddingSize is the padding written after the metadata blocks, so
+// that players and taggers can later edit tags in place.
+const flacPaddingSize = 4096
+
+// vorbisKeys maps field names to the Vorbis comment keys written in FLAC
+// files.
+var vorbisKeys = map[string]string{
+	"artist":            "ARTIST",
+	"album_artist":      "ALBUMARTIST",
+	"album":             "ALBUM",
+	"year":              "DATE",
+	"date":              "DATE",
+	"track_number":      "TRACKNUMBER",
+	"disc_number":       "DISCNUMBER",
+	"title":             "TITLE",
+	"lyrics":            "LYRICS",
+	"comments":          "COMMENT",
+	"composer":          "COMPOSER",
+	"publisher":         "LABEL",
+	"isrc":              "ISRC",
+	"bandcamp_url":      bandcampURLDescription,
+	"bandcamp_track_id": bandcampTrackIDDescription,
+	"compilation":       "COMPILATION",
+	"source":            sourceDescription,
+}
+
+// flacBlock is a metadata block of a FLAC file.
+type flacBlock struct {
+	typ  byte
+	data []byte
+}
+
+// saveFLAC writes the tags of track to the FLAC file at its path, as Vorbis
+// comments and a PICTURE block, keeping the other metadata blocks.
+func (t *Tagger) saveFLAC(path string, track *model.Track, album *model.Album, pictures []Picture) error {
+	return rewriteFile(path, flacAudio, func(src *os.File, dst io.Writer) error {
+		r := bufio.NewReader(src)
+		blocks, err := readFLACMetadata(r)
+		if err != nil {
+			return err
+		}
+
+		comment := &vorbisComment{vendor: "bandcamp-downloader"}
+		var kept []flacBlock
+		for _, b := range blocks {
+			switch {
+			case b.typ == flacVorbisComment:
+				if comment, err = parseVorbisComment(b.data); err != nil {
+					return err
+				}
+			case b.typ == flacPadding:
+				// Written again after the other blocks
+			case b.typ == flacPicture && hasPictureType(pictures, flacPictureType(b.data)):
+				// Replaced by the new picture
+			default:
+				kept = append(kept, b)
+			}
+		}
+
+		if track != nil && t.config.ModifyTags {
+			for _, v := range t.tagValues(track, album) {
+				switch t.action(v.field) {
+				case TagEmpty:
+					comment.set(vorbisKeys[v.field], "")
+				case TagModify:
+					if v.value != "" {
+						comment.set(vorbisKeys[v.field], v.value)
+					}
+				}
+			}
+		}
+		kept = append(kept, flacBlock{typ: flacVorbisComment, data: comment.bytes()})
+		for _, p := range pictures {
+			kept = append(kept, flacBlock{typ: flacPicture, data: flacPictureBlock(p)})
+		}
+		kept = append(kept, flacBlock{typ: flacPadding, data: make([]byte, flacPaddingSize)})
+
+		if _, err := io.WriteString(dst, "fLaC"); err != nil {
+			return err
+		}
+		for i, b := range kept {
+			if len(b.data) >= 1<<24 {
+				return fmt.Errorf("FLAC metadata block of %d bytes is too large", len(b.data))
+			}
+			header := []byte{b.typ, byte(len(b.data) >> 16), byte(len(b.data) >> 8), byte(len(b.data))}
+			if i == len(kept)-1 {
+				header[0] |= 0x80
+			}
+			if _, err := dst.Write(header); err != nil {
+				return err
+			}
+			if _, err := dst.Write(b.data); err != nil {
+				return err
+			}
+		}
+		_, err = io.Copy(dst, r)
+		return err
+	})
+}
+
+// readFLACMetadata reads the metadata blocks of the FLAC stream r, leaving
+// r at the first audio frame.
+func readFLACMetadata(r io.Reader) ([]flacBlock, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != "fLaC" {
+		return nil, errors.New("not a FLAC file")
+	}
+
+	var blocks []flacBlock
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+		data := make([]byte, int(header[1])<<16|int(header[2])<<8|int(header[3]))
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, flacBlock{typ: header[0] & 0x7f, data: data})
+		if header[0]&0x80 != 0 {
+			return blocks, nil
+		}
+	}
+}
+
+// readFLACTagInfo reads the Vorbis comments of the FLAC file at path.
+func readFLACTagInfo(path string) (*TagInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	blocks, err := readFLACMetadata(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &vorbisComment{}
+	for _, block := range blocks {
+		if block.typ == flacVorbisComment {
+			if comment, err = parseVorbisComment(block.data); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return tagInfoOf(func(field string) string {
+		return comment.get(vorbisKeys[field])
+	}), nil
+}
+
+// flacAudio returns the audio frames of the FLAC file f, after its
+// metadata blocks.
+func flacAudio(f *os.File) (io.Reader, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r := io.NewSectionReader(f, 0, fi.Size())
+	if _, err := readFLACMetadata(r); err != nil {
+		return nil, err
+	}
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(f, offset, fi.Size()-offset), nil
+}
+
+// vorbisComment is the content of a VORBIS_COMMENT block.
+type vorbisComment struct {
+	vendor string
+
+	// entries are the comments, as "KEY=value".
+	entries []string
+}
+
+// parseVorbisComment decodes a VORBIS_COMMENT block, whose lengths are
+// little-endian, unlike the rest of FLAC.
+func parseVorbisComment(data []byte) (*vorbisComment, error) {
+	errInvalid := errors.New("invalid FLAC Vorbis comment block")
+	next := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(data)
+		if uint64(n) > uint64(len(data)-4) {
+			return "", false
+		}
+		s := string(data[4 : 4+n])
+		data = data[4+n:]
+		return s, true
+	}
+
+	vendor, ok := next()
+	if !ok || len(data) < 4 {
+		return nil, errInvalid
+	}
+	c := &vorbisComment{vendor: vendor}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	for i := uint32(0); i < count; i++ {
+		entry, ok := next()
+		if !ok {
+			return nil, errInvalid
+		}
+		c.entries = append(c.entries, entry)
+	}
+	return c, nil
+}
+
+// get returns the first value of key, compared case-insensitively.
+func (c *vorbisComment) get(key string) string {
+	for _, entry := range c.entries {
+		if k, v, _ := strings.Cut(entry, "="); strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// set replaces the values of key with value, or removes them if value is
+// empty.
+func (c *vorbisComment) set(key, value string) {
+	entries := c.entries[:0]
+	for _, entry := range c.entries {
+		if k, _, _ := strings.Cut(entry, "="); !strings.EqualFold(k, key) {
+			entries = append(entries, entry)
+		}
+	}
+	c.entries = entries
+	if value != "" {
+		c.entries = append(c.entries, key+"="+value)
+	}
+}
+
+// bytes encodes the block.
+func (c *vorbisComment) bytes() []byte {
+	var b bytes.Buffer
+	writeString := func(s string) {
+		binary.Write(&b, binary.LittleEndian, uint32(len(s)))
+		b.WriteString(s)
+	}
+	writeString(c.vendor)
+	binary.Write(&b, binary.LittleEndian, uint32(len(c.entries)))
+	for _, entry := range c.entries {
+		writeString(entry)
+	}
+	return b.Bytes()
+}
+
+// flacPictureType returns the picture type of a PICTURE block, the same
+// as in ID3 APIC frames.
+func flacPictureType(data []byte) byte {
+	if len(data) < 4 {
+		return 0
+	}
+	return byte(binary.BigEndian.Uint32(data))
+}
+
+// flacPictureBlock encodes p as a PICTURE block.
+func flacPictureBlock(p Picture) []byte {
+	artwork := p.Data
+	var width, height uint32
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(artwork)); err == nil {
+		width, height = uint32(cfg.Width), uint32(cfg.Height)
+	}
+	mime := imageMimeType(artwork)
+	description := pictureDescription(p.Type)
+
+	var b bytes.Buffer
+	for _, v := range []any{
+		uint32(p.Type),
+		uint32(len(mime)), []byte(mime),
+		uint32(len(description)), []byte(description),
+		width, height,
+		uint32(24), // color depth
+		uint32(0),  // number of colors, for indexed images
+		uint32(len(artwork)), artwork,
+	} {
+		binary.Write(&b, binary.BigEndian, v)
+	}
+	return b.Bytes()
+}
+
+// imageMimeType returns the MIME type of artwork, PNG or JPEG.
+func imageMimeType(artwork []byte) string {
+	if bytes.HasPrefix(artwork, []byte("\x89PNG")) {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,106 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// flacFile returns a FLAC file with a STREAMINFO block, a Vorbis comment
+// block with entries, a padding block and audioData.
+func flacFile(audioData []byte, entries ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write([]byte{0, 0, 0, 34})
+	b.Write(make([]byte, 34))
+	comment := (&vorbisComment{vendor: "encoder", entries: entries}).bytes()
+	b.Write([]byte{flacVorbisComment, 0, byte(len(comment) >> 8), byte(len(comment))})
+	b.Write(comment)
+	b.Write([]byte{0x80 | flacPadding, 0, 0, 16})
+	b.Write(make([]byte, 16))
+	b.Write(audioData)
+	return b.Bytes()
+}
+
+func TestSaveTags_FLAC(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Artist", "Album", "", time.Date(2020, 5, 17, 0, 0, 0, 0, time.UTC), &model.PathConfig{DownloadsPath: dir})
+	album.URL = "https://artist.bandcamp.com/album/album"
+	track := model.NewTrack(album, 1, 2, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.flac"})
+
+	audioData := []byte("\xff\xf8audio frames")
+	if err := os.WriteFile(track.Path, flacFile(audioData, "ARTIST=Old", "COMMENT=Kept", "Title=Old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultTagConfig()
+	cfg.Comments = TagDoNotModify
+	if err := NewTagger(cfg).SaveTags(track, album, []byte("jpeg")); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+
+	f, err := os.Open(track.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	blocks, err := readFLACMetadata(f)
+	if err != nil {
+		t.Fatalf("readFLACMetadata failed: %v", err)
+	}
+	if len(blocks) != 4 || blocks[0].typ != 0 || blocks[1].typ != flacVorbisComment || blocks[2].typ != flacPicture || blocks[3].typ != flacPadding {
+		t.Fatalf("got %d blocks, want STREAMINFO, VORBIS_COMMENT, PICTURE, PADDING", len(blocks))
+	}
+
+	comment, err := parseVorbisComment(blocks[1].data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"ARTIST":       "Artist",
+		"ALBUMARTIST":  "Artist",
+		"DISCNUMBER":   "1",
+		"TITLE":        "Title",
+		"ALBUM":        "Album",
+		"DATE":         "2020-05-17",
+		"TRACKNUMBER":  "2",
+		"COMMENT":      "Kept",
+		"BANDCAMP_URL": album.URL,
+	}
+	for key, want := range tests {
+		if got := comment.get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if len(comment.entries) != len(tests) {
+		t.Errorf("entries = %q, want one per key", comment.entries)
+	}
+
+	if got := flacPictureType(blocks[2].data); got != id3v2.PTFrontCover {
+		t.Errorf("picture type = %d, want %d", got, id3v2.PTFrontCover)
+	}
+	if pic := blocks[2].data; !bytes.HasSuffix(pic, []byte("jpeg")) || binary.BigEndian.Uint32(pic[len(pic)-8:]) != 4 {
+		t.Errorf("picture block does not end with the artwork")
+	}
+
+	content, err := os.ReadFile(track.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(content, audioData) {
+		t.Errorf("audio data not preserved")
+	}
+	info, err := ReadTagInfo(track.Path)
+	if err != nil {
+		t.Fatalf("ReadTagInfo failed: %v", err)
+	}
+	if info.Title != "Title" || info.AlbumArtist != "Artist" || info.Year != "2020" || info.TrackNumber != 2 || info.BandcampURL != album.URL {
+		t.Errorf("ReadTagInfo = %+v, want the values written", *info)
+	}
+}
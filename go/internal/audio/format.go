@@ -0,0 +1,197 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// fileFormat is the tag format of an audio file, chosen by its extension.
+type fileFormat int
+
+const (
+	formatID3 fileFormat = iota
+	formatFLAC
+	formatMP4
+
+	// formatOther is the format of files of other extensions, which the
+	// Tagger cannot tag.
+	formatOther
+)
+
+// formatOf returns the tag format of the audio file at path: ID3 for .mp3,
+// Vorbis comments for .flac, MP4 atoms for .m4a, .m4b and .mp4, and
+// formatOther otherwise.
+func formatOf(path string) fileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return formatID3
+	case ".flac":
+		return formatFLAC
+	case ".m4a", ".m4b", ".mp4":
+		return formatMP4
+	default:
+		return formatOther
+	}
+}
+
+// CanTag reports whether the Tagger writes the tags of files with the
+// extension ext, with or without the dot: those of a format of formatOf.
+func CanTag(ext string) bool {
+	return formatOf("."+strings.TrimPrefix(ext, ".")) != formatOther
+}
+
+// tagValue is the value of a field for the tag formats other than ID3.
+type tagValue struct {
+	// field is the name of the field in TagFields, or one of "bandcamp_url",
+	// "compilation" and "source", which are always written.
+	field string
+	value string
+}
+
+// tagValues returns the values SaveTags writes for track. Year and date
+// share a single field in Vorbis comments and MP4 atoms, so the date comes
+// last to take precedence. An empty value leaves the field unchanged.
+func (t *Tagger) tagValues(track *model.Track, album *model.Album) []tagValue {
+	var disc string
+	if track.DiscNumber > 0 {
+		disc = strconv.Itoa(track.DiscNumber)
+	}
+	values := []tagValue{
+		{"artist", track.ArtistName()},
+		{"album_artist", album.Artist},
+		{"album", album.Title},
+		{"year", album.ReleaseDate.Format("2006")},
+		{"date", album.ReleaseDate.Format("2006-01-02")},
+		{"track_number", strconv.Itoa(track.Number)},
+		{"disc_number", disc},
+		{"title", track.Title},
+		{"lyrics", track.Lyrics},
+		{"comments", ""},
+		{"composer", track.Composer},
+		{"publisher", album.Publisher},
+		{"isrc", track.ISRC},
+		{"bandcamp_url", album.URL},
+	}
+	if track.ID > 0 {
+		values = append(values, tagValue{"bandcamp_track_id", strconv.FormatInt(track.ID, 10)})
+	}
+	if album.Compilation {
+		values = append(values, tagValue{"compilation", "1"})
+	}
+	if t.config.Source {
+		values = append(values, tagValue{"source", "bandcamp"})
+	}
+	return values
+}
+
+// tagInfoOf returns the TagInfo of the values of a file whose tags are not
+// ID3, read by value from the fields of tagValues.
+func tagInfoOf(value func(field string) string) *TagInfo {
+	info := &TagInfo{
+		Artist:      value("artist"),
+		AlbumArtist: value("album_artist"),
+		Album:       value("album"),
+		Title:       value("title"),
+		BandcampURL: value("bandcamp_url"),
+		Source:      value("source"),
+	}
+	// The date may be "2020-05-17" or "2020"
+	if date := value("date"); len(date) >= 4 {
+		info.Year = date[:4]
+	}
+	// and the track number "3" or "3/10"
+	number, _, _ := strings.Cut(value("track_number"), "/")
+	info.TrackNumber, _ = strconv.Atoi(strings.TrimSpace(number))
+	info.BandcampTrackID, _ = strconv.ParseInt(strings.TrimSpace(value("bandcamp_track_id")), 10, 64)
+	return info
+}
+
+// action returns what SaveTags does with the field named field.
+func (t *Tagger) action(field string) TagEditAction {
+	if action := t.config.Action(field); action != nil {
+		return *action
+	}
+	return TagModify
+}
+
+// ErrAudioChanged is returned when rewriting the tags of a file would
+// change its audio data, in which case the file is left unchanged.
+var ErrAudioChanged = errors.New("audio data changed while writing tags, file left unchanged")
+
+// rewriteFile replaces the file at path with the output of rewrite, which
+// reads the current file from src. The new content is written and synced
+// to a temporary file in the same directory, which replaces the file once
+// complete, so a crash or a failure leaves the file unchanged. The audio
+// data of both files, located by audio, must be identical, otherwise
+// ErrAudioChanged is returned.
+func rewriteFile(path string, audio func(f *os.File) (io.Reader, error), rewrite func(src *os.File, dst io.Writer) error) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	err = rewrite(src, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = verifyAudio(src, tmp, audio)
+	}
+	if err == nil {
+		err = tmp.Chmod(fi.Mode())
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+// verifyAudio checks that the audio data of the files a and b, located by
+// audio, is identical.
+func verifyAudio(a, b *os.File, audio func(f *os.File) (io.Reader, error)) error {
+	var sums [2][]byte
+	for i, f := range []*os.File{a, b} {
+		r, err := audio(f)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+		sums[i] = h.Sum(nil)
+	}
+	if !bytes.Equal(sums[0], sums[1]) {
+		return ErrAudioChanged
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteFile_VerifiesAudio(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "track.mp3")
+	data := append([]byte("\xff\xfbaudio frames"), id3v1Tag("Title", "Artist", "Album", "2001", "", 1)...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		rewrite func(src *os.File, dst io.Writer) error
+		want    error
+	}{
+		{"tag only", func(src *os.File, dst io.Writer) error {
+			_, err := io.Copy(dst, io.NewSectionReader(src, 0, int64(len(data)-id3v1Size)))
+			return err
+		}, nil},
+		{"truncated audio", func(src *os.File, dst io.Writer) error {
+			_, err := io.Copy(dst, io.NewSectionReader(src, 0, 4))
+			return err
+		}, ErrAudioChanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := rewriteFile(path, mp3Audio, tt.rewrite); !errors.Is(err, tt.want) {
+				t.Errorf("rewriteFile() error = %v, want %v", err, tt.want)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("%d files in the directory, want the temporary file removed", len(entries))
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != nil && string(content) != string(data) {
+				t.Errorf("file changed after a failed rewrite")
+			}
+		})
+	}
+}
+
+func TestCanTag(t *testing.T) {
+	tests := map[string]bool{
+		"mp3":   true,
+		".MP3":  true,
+		"flac":  true,
+		".m4a":  true,
+		"mp4":   true,
+		"ogg":   false,
+		"wav":   false,
+		"":      false,
+		".part": false,
+	}
+	for ext, want := range tests {
+		if got := CanTag(ext); got != want {
+			t.Errorf("CanTag(%q) = %v, want %v", ext, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2"
+)
+
+// id3v1Size is the size of the ID3v1 tag at the end of an MP3 file.
+const id3v1Size = 128
+
+// ID3v1 holds the values of an ID3v1 (or ID3v1.1) tag, the fixed-size tag
+// older encoders append to MP3 files.
+type ID3v1 struct {
+	Title   string
+	Artist  string
+	Album   string
+	Year    string
+	Comment string
+
+	// Track is zero for ID3v1.0 tags, which have no track number.
+	Track int
+
+	// Genre is the index of the genre in the ID3v1 genre list.
+	Genre byte
+}
+
+// ReadID3v1 reads the ID3v1 tag of the MP3 file at path. Returns nil and
+// no error if the file has none.
+//
+// Example:
+//
+//	v1, err := ReadID3v1(track.Path)
+//	if v1 != nil {
+//	    fmt.Println(v1.Artist, v1.Title)
+//	}
+func ReadID3v1(path string) (*ID3v1, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readID3v1(f)
+}
+
+// readID3v1 reads the ID3v1 tag of the MP3 file f.
+func readID3v1(f *os.File) (*ID3v1, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < id3v1Size {
+		return nil, nil
+	}
+
+	buf := make([]byte, id3v1Size)
+	if _, err := f.ReadAt(buf, fi.Size()-id3v1Size); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return parseID3v1(buf), nil
+}
+
+// parseID3v1 decodes the 128 bytes of an ID3v1 tag, or returns nil if buf
+// does not start with the "TAG" marker.
+func parseID3v1(buf []byte) *ID3v1 {
+	if len(buf) != id3v1Size || !bytes.HasPrefix(buf, []byte("TAG")) {
+		return nil
+	}
+
+	v1 := &ID3v1{
+		Title:   latin1(buf[3:33]),
+		Artist:  latin1(buf[33:63]),
+		Album:   latin1(buf[63:93]),
+		Year:    latin1(buf[93:97]),
+		Comment: latin1(buf[97:127]),
+		Genre:   buf[127],
+	}
+	// ID3v1.1 stores the track number in the last byte of the comment,
+	// after a zero byte
+	if buf[125] == 0 && buf[126] != 0 {
+		v1.Comment = latin1(buf[97:125])
+		v1.Track = int(buf[126])
+	}
+	return v1
+}
+
+// latin1 decodes an ID3v1 field: ISO-8859-1 text padded with zero bytes
+// or spaces.
+func latin1(field []byte) string {
+	if i := bytes.IndexByte(field, 0); i >= 0 {
+		field = field[:i]
+	}
+	runes := make([]rune, len(field))
+	for i, b := range field {
+		runes[i] = rune(b)
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+// StripID3v1 removes the ID3v1 tag from the end of the MP3 file at path,
+// and reports whether it had one. Like tags, the file is rewritten through
+// a temporary copy.
+func StripID3v1(path string) (bool, error) {
+	v1, err := ReadID3v1(path)
+	if err != nil || v1 == nil {
+		return false, err
+	}
+
+	err = rewriteFile(path, mp3Audio, func(src *os.File, dst io.Writer) error {
+		fi, err := src.Stat()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, io.NewSectionReader(src, 0, fi.Size()-id3v1Size))
+		return err
+	})
+	return err == nil, err
+}
+
+// migrate copies the values of v1 into the ID3v2 frames of tag that are
+// empty, for the fields where keep returns true. Players that find an ID3v2
+// tag usually ignore the ID3v1 one, so writing an ID3v2 tag (e.g. only to
+// embed artwork) would otherwise hide the ID3v1 values.
+func (v1 *ID3v1) migrate(tag *id3v2.Tag, keep func(field string) bool) {
+	setText := func(field, id, value string) {
+		if value != "" && keep(field) && tag.GetTextFrame(id).Text == "" {
+			tag.AddTextFrame(id, id3v2.EncodingUTF8, value)
+		}
+	}
+	setText("title", "TIT2", v1.Title)
+	setText("artist", "TPE1", v1.Artist)
+	setText("album", "TALB", v1.Album)
+	setText("year", tag.CommonID("Year"), v1.Year)
+	if v1.Track > 0 {
+		setText("track_number", "TRCK", strconv.Itoa(v1.Track))
+	}
+
+	if v1.Comment != "" && keep("comments") && len(tag.GetFrames(tag.CommonID("Comments"))) == 0 {
+		tag.AddCommentFrame(id3v2.CommentFrame{
+			Encoding: id3v2.EncodingUTF8,
+			Language: "eng",
+			Text:     v1.Comment,
+		})
+	}
+}
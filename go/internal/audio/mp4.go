@@ -0,0 +1,467 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// mp4Keys maps field names to the items of the iTunes metadata list (ilst)
+// of MP4 files. Keys starting with "----:" are freeform items, named after
+// the rest of the key, in the com.apple.iTunes namespace.
+var mp4Keys = map[string]string{
+	"artist":            "\xa9ART",
+	"album_artist":      "aART",
+	"album":             "\xa9alb",
+	"year":              "\xa9day",
+	"date":              "\xa9day",
+	"track_number":      "trkn",
+	"disc_number":       "disk",
+	"title":             "\xa9nam",
+	"lyrics":            "\xa9lyr",
+	"comments":          "\xa9cmt",
+	"composer":          "\xa9wrt",
+	"publisher":         "----:LABEL",
+	"isrc":              "----:ISRC",
+	"bandcamp_url":      "----:" + bandcampURLDescription,
+	"bandcamp_track_id": "----:" + bandcampTrackIDDescription,
+	"compilation":       "cpil",
+	"source":            "----:" + sourceDescription,
+}
+
+// freeformPrefix is the prefix of the keys of freeform items in mp4Keys.
+const freeformPrefix = "----:"
+
+// Type indicators of the data atoms of ilst items.
+const (
+	mp4Implicit = 0
+	mp4UTF8     = 1
+	mp4JPEG     = 13
+	mp4PNG      = 14
+	mp4Integer  = 21
+)
+
+// mp4Atom is an atom (box) of an MP4 file. The containers on the path to
+// the metadata and to the chunk offsets are parsed into children, other
+// atoms keep their raw body.
+type mp4Atom struct {
+	typ string
+
+	// prefix is the version and flags of a container that is a full atom,
+	// before its children (meta).
+	prefix []byte
+
+	data     []byte
+	children []*mp4Atom
+}
+
+// mp4Containers are the atoms parsed into children.
+var mp4Containers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true,
+	"udta": true, "meta": true, "ilst": true,
+}
+
+// mp4TopAtom is the position of a top-level atom in an MP4 file.
+type mp4TopAtom struct {
+	typ    string
+	offset int64
+	size   int64
+
+	// header is the size of the atom's header, 8 or 16 bytes.
+	header int64
+}
+
+// saveMP4 writes the tags of track to the MP4 file at its path, as iTunes
+// metadata items. The chunk offsets are updated if the metadata precedes
+// the audio data.
+func (t *Tagger) saveMP4(path string, track *model.Track, album *model.Album, pictures []Picture) error {
+	return rewriteFile(path, mp4Audio, func(src *os.File, dst io.Writer) error {
+		tops, err := readMP4TopLevel(src)
+		if err != nil {
+			return err
+		}
+		var moovAtom *mp4TopAtom
+		mdatAfter := false
+		for i := range tops {
+			switch tops[i].typ {
+			case "moov":
+				moovAtom = &tops[i]
+			case "mdat":
+				mdatAfter = mdatAfter || moovAtom != nil
+			}
+		}
+		if moovAtom == nil {
+			return errors.New("not an MP4 file: no moov atom")
+		}
+
+		body := make([]byte, moovAtom.size-moovAtom.header)
+		if _, err := src.ReadAt(body, moovAtom.offset+moovAtom.header); err != nil {
+			return err
+		}
+		children, err := parseMP4Atoms(body, "moov")
+		if err != nil {
+			return err
+		}
+		moov := &mp4Atom{typ: "moov", children: children}
+
+		ilst := mp4MetadataList(moov)
+		if track != nil && t.config.ModifyTags {
+			for _, v := range t.tagValues(track, album) {
+				switch t.action(v.field) {
+				case TagEmpty:
+					ilst.setItem(mp4Keys[v.field], "")
+				case TagModify:
+					if v.value != "" {
+						ilst.setItem(mp4Keys[v.field], v.value)
+					}
+				}
+			}
+		}
+		if len(pictures) > 0 {
+			// MP4 pictures have no type: the cover art comes first
+			ilst.removeItems("covr")
+			covr := &mp4Atom{typ: "covr", children: []*mp4Atom{}}
+			for _, p := range pictures {
+				typ := uint32(mp4JPEG)
+				if imageMimeType(p.Data) == "image/png" {
+					typ = mp4PNG
+				}
+				covr.children = append(covr.children, mp4Data(typ, p.Data))
+			}
+			ilst.children = append(ilst.children, covr)
+		}
+
+		newMoov := moov.encode()
+		if delta := int64(len(newMoov)) - moovAtom.size; delta != 0 && mdatAfter {
+			if err := moov.shiftChunkOffsets(delta); err != nil {
+				return err
+			}
+			newMoov = moov.encode()
+		}
+
+		for _, top := range tops {
+			if top.typ == "moov" {
+				if _, err := dst.Write(newMoov); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := io.Copy(dst, io.NewSectionReader(src, top.offset, top.size)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readMP4TagInfo reads the metadata items of the MP4 file at path.
+func readMP4TagInfo(path string) (*TagInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tops, err := readMP4TopLevel(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var moov *mp4Atom
+	for _, top := range tops {
+		if top.typ != "moov" {
+			continue
+		}
+		body := make([]byte, top.size-top.header)
+		if _, err := f.ReadAt(body, top.offset+top.header); err != nil {
+			return nil, err
+		}
+		children, err := parseMP4Atoms(body, "moov")
+		if err != nil {
+			return nil, err
+		}
+		moov = &mp4Atom{typ: "moov", children: children}
+		break
+	}
+	if moov == nil {
+		return nil, errors.New("not an MP4 file: no moov atom")
+	}
+
+	ilst := mp4MetadataList(moov)
+	return tagInfoOf(func(field string) string {
+		key := mp4Keys[field]
+		for _, item := range ilst.children {
+			if item.itemKey() != key {
+				continue
+			}
+			value := item.itemValue()
+			if key == "trkn" || key == "disk" {
+				// Number and total, see setItem
+				if len(value) < 4 {
+					return ""
+				}
+				return strconv.Itoa(int(binary.BigEndian.Uint16(value[2:])))
+			}
+			return string(value)
+		}
+		return ""
+	}), nil
+}
+
+// readMP4TopLevel returns the top-level atoms of the MP4 file f.
+func readMP4TopLevel(f *os.File) ([]mp4TopAtom, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var tops []mp4TopAtom
+	for offset := int64(0); offset < fi.Size(); {
+		var header [16]byte
+		if _, err := f.ReadAt(header[:8], offset); err != nil {
+			return nil, fmt.Errorf("not an MP4 file: %w", err)
+		}
+		top := mp4TopAtom{typ: string(header[4:8]), offset: offset, size: int64(binary.BigEndian.Uint32(header[:])), header: 8}
+		switch top.size {
+		case 0:
+			top.size = fi.Size() - offset
+		case 1:
+			if _, err := f.ReadAt(header[8:], offset+8); err != nil {
+				return nil, err
+			}
+			top.size = int64(binary.BigEndian.Uint64(header[8:]))
+			top.header = 16
+		}
+		if top.size < top.header || offset+top.size > fi.Size() {
+			return nil, fmt.Errorf("not an MP4 file: invalid %q atom", top.typ)
+		}
+		tops = append(tops, top)
+		offset += top.size
+	}
+	return tops, nil
+}
+
+// mp4Audio returns the media data of the MP4 file f, the bodies of its
+// mdat atoms.
+func mp4Audio(f *os.File) (io.Reader, error) {
+	tops, err := readMP4TopLevel(f)
+	if err != nil {
+		return nil, err
+	}
+	var readers []io.Reader
+	for _, top := range tops {
+		if top.typ == "mdat" {
+			readers = append(readers, io.NewSectionReader(f, top.offset+top.header, top.size-top.header))
+		}
+	}
+	return io.MultiReader(readers...), nil
+}
+
+// parseMP4Atoms parses the atoms of data, the body of a parent atom.
+func parseMP4Atoms(data []byte, parent string) ([]*mp4Atom, error) {
+	var atoms []*mp4Atom
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("invalid MP4 atom in %q", parent)
+		}
+		size, header := uint64(binary.BigEndian.Uint32(data)), uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("invalid MP4 atom in %q", parent)
+			}
+			size, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid MP4 atom in %q", parent)
+		}
+
+		a := &mp4Atom{typ: string(data[4:8])}
+		body := data[header:size]
+		if mp4Containers[a.typ] || parent == "ilst" {
+			// meta is a full atom in MP4 files, but not in QuickTime ones,
+			// where its first child, hdlr, comes right away
+			if a.typ == "meta" && len(body) >= 8 && string(body[4:8]) != "hdlr" {
+				a.prefix, body = body[:4], body[4:]
+			}
+			children, err := parseMP4Atoms(body, a.typ)
+			if err != nil {
+				return nil, err
+			}
+			a.children = children
+		} else {
+			a.data = body
+		}
+		atoms = append(atoms, a)
+		data = data[size:]
+	}
+	return atoms, nil
+}
+
+// encode returns the atom with its header.
+func (a *mp4Atom) encode() []byte {
+	var body bytes.Buffer
+	body.Write(a.prefix)
+	if a.children != nil {
+		for _, c := range a.children {
+			body.Write(c.encode())
+		}
+	} else {
+		body.Write(a.data)
+	}
+
+	out := make([]byte, 8, 8+body.Len())
+	binary.BigEndian.PutUint32(out, uint32(8+body.Len()))
+	copy(out[4:], a.typ)
+	return append(out, body.Bytes()...)
+}
+
+// child returns the first child of type typ, adding an empty container if
+// there is none.
+func (a *mp4Atom) child(typ string) *mp4Atom {
+	for _, c := range a.children {
+		if c.typ == typ {
+			return c
+		}
+	}
+	c := &mp4Atom{typ: typ, children: []*mp4Atom{}}
+	a.children = append(a.children, c)
+	return c
+}
+
+// mp4MetadataList returns the moov/udta/meta/ilst atom of moov, adding the
+// missing atoms.
+func mp4MetadataList(moov *mp4Atom) *mp4Atom {
+	udta := moov.child("udta")
+	meta := udta.child("meta")
+	if len(meta.children) == 0 {
+		meta.prefix = make([]byte, 4)
+		// Handler of iTunes metadata: version and flags, pre_defined,
+		// "mdir", "appl", reserved bytes and an empty name
+		hdlr := make([]byte, 25)
+		copy(hdlr[8:], "mdirappl")
+		meta.children = append(meta.children, &mp4Atom{typ: "hdlr", data: hdlr})
+	}
+	return meta.child("ilst")
+}
+
+// itemKey returns the key of an ilst item as in mp4Keys.
+func (a *mp4Atom) itemKey() string {
+	if a.typ != "----" {
+		return a.typ
+	}
+	for _, c := range a.children {
+		if c.typ == "name" && len(c.data) >= 4 {
+			return freeformPrefix + string(c.data[4:])
+		}
+	}
+	return a.typ
+}
+
+// removeItems removes the items of the ilst atom a with the given key.
+func (a *mp4Atom) removeItems(key string) {
+	items := a.children[:0]
+	for _, item := range a.children {
+		if item.itemKey() != key {
+			items = append(items, item)
+		}
+	}
+	a.children = items
+}
+
+// setItem replaces the items of the ilst atom a with the given key by one
+// holding value, or removes them if value is empty.
+func (a *mp4Atom) setItem(key, value string) {
+	a.removeItems(key)
+	if value == "" {
+		return
+	}
+
+	item := &mp4Atom{typ: key}
+	switch {
+	case key == "trkn" || key == "disk":
+		// Number and total, then two reserved bytes for trkn only
+		n, _ := strconv.ParseUint(value, 10, 16)
+		payload := make([]byte, 6, 8)
+		binary.BigEndian.PutUint16(payload[2:], uint16(n))
+		if key == "trkn" {
+			payload = payload[:8]
+		}
+		item.children = []*mp4Atom{mp4Data(mp4Implicit, payload)}
+	case key == "cpil":
+		item.children = []*mp4Atom{mp4Data(mp4Integer, []byte{1})}
+	case strings.HasPrefix(key, freeformPrefix):
+		item.typ = "----"
+		item.children = []*mp4Atom{
+			{typ: "mean", data: append(make([]byte, 4), "com.apple.iTunes"...)},
+			{typ: "name", data: append(make([]byte, 4), key[len(freeformPrefix):]...)},
+			mp4Data(mp4UTF8, []byte(value)),
+		}
+	default:
+		item.children = []*mp4Atom{mp4Data(mp4UTF8, []byte(value))}
+	}
+	a.children = append(a.children, item)
+}
+
+// mp4Data returns the data atom of an ilst item.
+func mp4Data(typ uint32, payload []byte) *mp4Atom {
+	data := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(data, typ)
+	return &mp4Atom{typ: "data", data: append(data, payload...)}
+}
+
+// itemValue returns the payload of the data atom of an ilst item.
+func (a *mp4Atom) itemValue() []byte {
+	for _, c := range a.children {
+		if c.typ == "data" && len(c.data) >= 8 {
+			return c.data[8:]
+		}
+	}
+	return nil
+}
+
+// shiftChunkOffsets adds delta to the offsets of the stco and co64 atoms
+// under a, which point into the audio data.
+func (a *mp4Atom) shiftChunkOffsets(delta int64) error {
+	for _, c := range a.children {
+		switch c.typ {
+		case "stco", "co64":
+			width := 4
+			if c.typ == "co64" {
+				width = 8
+			}
+			if len(c.data) < 8 {
+				return fmt.Errorf("invalid %s atom", c.typ)
+			}
+			count := int(binary.BigEndian.Uint32(c.data[4:]))
+			if len(c.data) < 8+count*width {
+				return fmt.Errorf("invalid %s atom", c.typ)
+			}
+			for i := 0; i < count; i++ {
+				entry := c.data[8+i*width:]
+				if width == 4 {
+					offset := int64(binary.BigEndian.Uint32(entry)) + delta
+					if offset < 0 || offset > 1<<32-1 {
+						return errors.New("chunk offset out of range, the file needs co64 offsets")
+					}
+					binary.BigEndian.PutUint32(entry, uint32(offset))
+				} else {
+					binary.BigEndian.PutUint64(entry, uint64(int64(binary.BigEndian.Uint64(entry))+delta))
+				}
+			}
+		default:
+			if err := c.shiftChunkOffsets(delta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// mp4File returns an MP4 file whose moov atom, holding an ilst item with
+// an artist, precedes the mdat atom holding audioData, with one chunk
+// offset pointing at audioData.
+func mp4File(audioData []byte) []byte {
+	ftyp := (&mp4Atom{typ: "ftyp", data: []byte("M4A \x00\x00\x00\x00")}).encode()
+	stco := &mp4Atom{typ: "stco", data: make([]byte, 12)}
+	ilst := &mp4Atom{typ: "ilst", children: []*mp4Atom{}}
+	ilst.setItem("\xa9ART", "Old")
+	ilst.setItem("\xa9cmt", "Kept")
+	moov := &mp4Atom{typ: "moov", children: []*mp4Atom{
+		{typ: "mvhd", data: make([]byte, 100)},
+		{typ: "trak", children: []*mp4Atom{{typ: "mdia", children: []*mp4Atom{{typ: "minf", children: []*mp4Atom{{typ: "stbl", children: []*mp4Atom{stco}}}}}}}},
+		{typ: "udta", children: []*mp4Atom{{typ: "meta", prefix: make([]byte, 4), children: []*mp4Atom{ilst}}}},
+	}}
+
+	binary.BigEndian.PutUint32(stco.data[4:], 1)
+	binary.BigEndian.PutUint32(stco.data[8:], uint32(len(ftyp)+len(moov.encode())+8))
+	return bytes.Join([][]byte{ftyp, moov.encode(), (&mp4Atom{typ: "mdat", data: audioData}).encode()}, nil)
+}
+
+// readMP4Moov returns the parsed moov atom and the offset of the mdat body
+// of the MP4 file at path.
+func readMP4Moov(t *testing.T, path string) (*mp4Atom, int64) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tops, err := readMP4TopLevel(f)
+	if err != nil {
+		t.Fatalf("readMP4TopLevel failed: %v", err)
+	}
+
+	var moov *mp4Atom
+	var mdat int64
+	for _, top := range tops {
+		switch top.typ {
+		case "moov":
+			body := make([]byte, top.size-top.header)
+			if _, err := f.ReadAt(body, top.offset+top.header); err != nil {
+				t.Fatal(err)
+			}
+			children, err := parseMP4Atoms(body, "moov")
+			if err != nil {
+				t.Fatalf("parseMP4Atoms failed: %v", err)
+			}
+			moov = &mp4Atom{typ: "moov", children: children}
+		case "mdat":
+			mdat = top.offset + top.header
+		}
+	}
+	if moov == nil {
+		t.Fatal("no moov atom")
+	}
+	return moov, mdat
+}
+
+func TestSaveTags_MP4(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Artist", "Album", "", time.Date(2020, 5, 17, 0, 0, 0, 0, time.UTC), &model.PathConfig{DownloadsPath: dir})
+	album.URL = "https://artist.bandcamp.com/album/album"
+	album.Compilation = true
+	track := model.NewTrack(album, 1, 2, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.m4a"})
+
+	audioData := []byte("audio frames")
+	if err := os.WriteFile(track.Path, mp4File(audioData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultTagConfig()
+	cfg.Comments = TagDoNotModify
+	if err := NewTagger(cfg).SaveTags(track, album, []byte("\x89PNG")); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+
+	moov, mdat := readMP4Moov(t, track.Path)
+	items := make(map[string][]byte)
+	for _, item := range mp4MetadataList(moov).children {
+		if _, dup := items[item.itemKey()]; dup {
+			t.Errorf("duplicate %q item", item.itemKey())
+		}
+		items[item.itemKey()] = item.itemValue()
+	}
+	tests := map[string]string{
+		"\xa9ART":                               "Artist",
+		"aART":                                  "Artist",
+		"\xa9alb":                               "Album",
+		"\xa9nam":                               "Title",
+		"\xa9day":                               "2020-05-17",
+		"\xa9cmt":                               "Kept",
+		"trkn":                                  "\x00\x00\x00\x02\x00\x00\x00\x00",
+		"disk":                                  "\x00\x00\x00\x01\x00\x00",
+		"cpil":                                  "\x01",
+		"covr":                                  "\x89PNG",
+		freeformPrefix + bandcampURLDescription: album.URL,
+	}
+	for key, want := range tests {
+		if got := string(items[key]); got != want {
+			t.Errorf("%q = %q, want %q", key, got, want)
+		}
+	}
+
+	// The chunk offset follows the audio data, moved by the larger moov
+	stco := moov.child("trak").child("mdia").child("minf").child("stbl").child("stco")
+	if got := int64(binary.BigEndian.Uint32(stco.data[8:])); got != mdat {
+		t.Errorf("chunk offset = %d, want %d", got, mdat)
+	}
+	content, err := os.ReadFile(track.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content[mdat:], audioData) {
+		t.Errorf("audio data not preserved")
+	}
+	info, err := ReadTagInfo(track.Path)
+	if err != nil {
+		t.Fatalf("ReadTagInfo failed: %v", err)
+	}
+	if info.Title != "Title" || info.AlbumArtist != "Artist" || info.Year != "2020" || info.TrackNumber != 2 || info.BandcampURL != album.URL {
+		t.Errorf("ReadTagInfo = %+v, want the values written", *info)
+	}
+}
@@ -0,0 +1,159 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// mpegSearchSize is how far into the audio data of an MP3 file the first
+// frame is looked for, past padding or junk left by other taggers.
+const mpegSearchSize = 64 << 10
+
+// mpegBitrates are the bitrates, in kbps, of the bitrate indexes of Layer
+// III frame headers, for MPEG-1 and for MPEG-2 and 2.5.
+var mpegBitrates = [2][15]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// mpegSampleRates are the sample rates of MPEG-1 frames; MPEG-2 halves
+// them and MPEG-2.5 quarters them.
+var mpegSampleRates = [3]int{44100, 48000, 32000}
+
+// ErrNotMP3 is returned by ReadMP3Bitrate and ReadMP3Duration when no MPEG
+// Layer III frame is found in the file.
+var ErrNotMP3 = errors.New("no MP3 frame found")
+
+// ReadMP3Bitrate returns the bitrate of the MP3 file at path, in kbps. The
+// bitrate of VBR files is the average given by their Xing header, or that
+// of their first frame without one.
+//
+// Example:
+//
+//	kbps, err := ReadMP3Bitrate(track.Path)
+//	if err == nil && kbps <= 128 {
+//	    fmt.Println("Bandcamp stream quality:", track.Path)
+//	}
+func ReadMP3Bitrate(path string) (int, error) {
+	frame, _, err := readMPEGFrame(path)
+	if err != nil {
+		return 0, err
+	}
+	return frame.kbps, nil
+}
+
+// ReadMP3Duration returns the duration of the audio of the MP3 file at
+// path, in seconds: the number of frames of its Xing header times their
+// duration, or for files without one, the size of its audio data at the
+// bitrate of its first frame, which is exact for CBR files like the
+// Bandcamp streams.
+//
+// Example:
+//
+//	seconds, err := ReadMP3Duration(track.Path)
+//	if err == nil && seconds < track.Duration-2 {
+//	    fmt.Println("Truncated:", track.Path)
+//	}
+func ReadMP3Duration(path string) (float64, error) {
+	frame, size, err := readMPEGFrame(path)
+	if err != nil {
+		return 0, err
+	}
+	if frame.seconds > 0 {
+		return frame.seconds, nil
+	}
+	return float64(size) * 8 / float64(frame.kbps*1000), nil
+}
+
+// mpegFrame is what is read from the first frame of an MP3 file.
+type mpegFrame struct {
+	// kbps is the bitrate of the frame, or the average bitrate of its
+	// Xing header if it has one.
+	kbps int
+
+	// seconds is the duration given by the Xing header, or 0 if the frame
+	// has none.
+	seconds float64
+}
+
+// readMPEGFrame decodes the first Layer III frame of the MP3 file at path,
+// and returns it with the size of the audio data from its start.
+func readMPEGFrame(path string) (mpegFrame, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return mpegFrame{}, 0, err
+	}
+	defer f.Close()
+
+	start, end, err := mp3AudioRange(f)
+	if err != nil {
+		return mpegFrame{}, 0, err
+	}
+	buf := make([]byte, min(end-start, mpegSearchSize))
+	if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+		return mpegFrame{}, 0, err
+	}
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xff || buf[i+1]&0xe0 != 0xe0 {
+			continue
+		}
+		if frame, ok := decodeMPEGFrame(buf[i:]); ok {
+			return frame, end - start - int64(i), nil
+		}
+	}
+	return mpegFrame{}, 0, ErrNotMP3
+}
+
+// decodeMPEGFrame decodes the Layer III frame starting frame, with its
+// Xing header if it has one.
+func decodeMPEGFrame(frame []byte) (mpegFrame, bool) {
+	header := binary.BigEndian.Uint32(frame)
+	version := header >> 19 & 3 // 0: MPEG-2.5, 2: MPEG-2, 3: MPEG-1
+	layer := header >> 17 & 3   // 1: Layer III
+	bitrateIndex := header >> 12 & 15
+	sampleRateIndex := header >> 10 & 3
+	mono := header>>6&3 == 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	mpeg1 := version == 3
+	table, samples, sampleRate := 1, 576, mpegSampleRates[sampleRateIndex]/2
+	if mpeg1 {
+		table, samples, sampleRate = 0, 1152, mpegSampleRates[sampleRateIndex]
+	} else if version == 0 {
+		sampleRate /= 2
+	}
+	decoded := mpegFrame{kbps: mpegBitrates[table][bitrateIndex]}
+
+	// The Xing (VBR) or Info (CBR) header follows the side information of
+	// the first frame
+	sideInfo := 17
+	switch {
+	case mpeg1 && !mono:
+		sideInfo = 32
+	case !mpeg1 && mono:
+		sideInfo = 9
+	}
+	xing := frame[min(4+sideInfo, len(frame)):]
+	if len(xing) < 16 || (string(xing[:4]) != "Xing" && string(xing[:4]) != "Info") {
+		return decoded, true
+	}
+	flags := binary.BigEndian.Uint32(xing[4:])
+	if flags&1 == 0 {
+		return decoded, true
+	}
+	frames := int64(binary.BigEndian.Uint32(xing[8:]))
+	if frames == 0 {
+		return decoded, true
+	}
+	decoded.seconds = float64(frames*int64(samples)) / float64(sampleRate)
+	if flags&2 != 0 {
+		size := int64(binary.BigEndian.Uint32(xing[12:]))
+		decoded.kbps = int(float64(size)*8/decoded.seconds/1000 + 0.5)
+	}
+	return decoded, true
+}
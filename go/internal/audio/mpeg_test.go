@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMP3Bitrate(t *testing.T) {
+	// MPEG-1 Layer III, 44.1 kHz, stereo, at bitrate index 9 (128 kbps)
+	header := []byte{0xff, 0xfb, 0x90, 0x00}
+
+	xing := make([]byte, 4+32+16)
+	copy(xing, header)
+	copy(xing[36:], "Xing")
+	binary.BigEndian.PutUint32(xing[40:], 3)      // frames and bytes present
+	binary.BigEndian.PutUint32(xing[44:], 1000)   // frames: 26.12 seconds
+	binary.BigEndian.PutUint32(xing[48:], 626939) // bytes: 192 kbps
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"cbr", append(append([]byte{0, 0}, header...), make([]byte, 200)...), 128},
+		{"vbr", append(xing, make([]byte, 200)...), 192},
+		{"id3v2 tag", append(append([]byte("ID3\x03\x00\x00\x00\x00\x00\x04\xff\xfb\xff\xfb"), header...), make([]byte, 200)...), 128},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".mp3")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadMP3Bitrate(path)
+			if err != nil {
+				t.Fatalf("ReadMP3Bitrate failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ReadMP3Bitrate = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	path := filepath.Join(dir, "empty.mp3")
+	if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMP3Bitrate(path); err != ErrNotMP3 {
+		t.Errorf("ReadMP3Bitrate of an empty file = %v, want ErrNotMP3", err)
+	}
+}
+
+func TestReadMP3Duration(t *testing.T) {
+	// MPEG-1 Layer III, 44.1 kHz, stereo, at bitrate index 9 (128 kbps)
+	header := []byte{0xff, 0xfb, 0x90, 0x00}
+
+	xing := make([]byte, 4+32+16)
+	copy(xing, header)
+	copy(xing[36:], "Xing")
+	binary.BigEndian.PutUint32(xing[40:], 1)    // frames present
+	binary.BigEndian.PutUint32(xing[44:], 1000) // frames: 26.12 seconds
+
+	tests := []struct {
+		name string
+		data []byte
+		want float64
+	}{
+		// 16000 bytes at 128 kbps
+		{"cbr", append(append([]byte{0, 0}, header...), make([]byte, 16000-4)...), 1},
+		{"vbr", append(xing, make([]byte, 200)...), 1000 * 1152 / 44100.0},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".mp3")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadMP3Duration(path)
+			if err != nil {
+				t.Fatalf("ReadMP3Duration failed: %v", err)
+			}
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("ReadMP3Duration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
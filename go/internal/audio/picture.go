@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bogem/id3v2"
+)
+
+// Picture is an image embedded in tags, in addition to the cover art.
+//
+// Example:
+//
+//	back := Picture{Type: id3v2.PTBackCover, Data: jpegBytes}
+//	err := tagger.SaveTags(track, album, coverBytes, back)
+type Picture struct {
+	// Type is the ID3 picture type, e.g. id3v2.PTBackCover, also used by
+	// FLAC PICTURE blocks.
+	Type byte
+
+	// Data is the JPEG or PNG image.
+	Data []byte
+}
+
+// pictureTypes are the picture types accepted by ParsePictureType, with
+// the description of their ID3 frames, which must differ between the
+// pictures of a file.
+var pictureTypes = []struct {
+	name        string
+	typ         byte
+	description string
+}{
+	{"front_cover", id3v2.PTFrontCover, "Cover"},
+	{"back_cover", id3v2.PTBackCover, "Back cover"},
+	{"leaflet", id3v2.PTLeafletPage, "Leaflet"},
+	{"media", id3v2.PTMedia, "Media"},
+	{"lead_artist", id3v2.PTLeadArtistSoloist, "Lead artist"},
+	{"artist", id3v2.PTArtistPerformer, "Artist"},
+	{"band", id3v2.PTBandOrchestra, "Band"},
+	{"illustration", id3v2.PTIllustration, "Illustration"},
+	{"band_logo", id3v2.PTBandArtistLogotype, "Band logo"},
+	{"publisher_logo", id3v2.PTPublisherStudioLogotype, "Publisher logo"},
+	{"other", id3v2.PTOther, "Other"},
+}
+
+// ParsePictureType parses the name of a picture type as written in
+// settings, e.g. "front_cover", "back_cover", "media" or "artist".
+func ParsePictureType(name string) (byte, error) {
+	var names []string
+	for _, pt := range pictureTypes {
+		if strings.EqualFold(strings.TrimSpace(name), pt.name) {
+			return pt.typ, nil
+		}
+		names = append(names, pt.name)
+	}
+	return 0, fmt.Errorf("invalid picture type %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// pictureDescription returns the description of the ID3 frame of a
+// picture of type typ.
+func pictureDescription(typ byte) string {
+	for _, pt := range pictureTypes {
+		if pt.typ == typ {
+			return pt.description
+		}
+	}
+	return fmt.Sprintf("Picture %d", typ)
+}
+
+// pictures returns the pictures to embed: artwork, as the picture type of
+// the configuration, if not nil, followed by extra.
+func (t *Tagger) pictures(artwork []byte, extra []Picture) []Picture {
+	var pictures []Picture
+	if artwork != nil {
+		pictures = append(pictures, Picture{Type: t.config.PictureType, Data: artwork})
+	}
+	for _, p := range extra {
+		if p.Data != nil {
+			pictures = append(pictures, p)
+		}
+	}
+	return pictures
+}
+
+// hasPictureType reports whether one of pictures is of type typ.
+func hasPictureType(pictures []Picture, typ byte) bool {
+	for _, p := range pictures {
+		if p.Type == typ {
+			return true
+		}
+	}
+	return false
+}
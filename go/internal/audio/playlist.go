@@ -13,6 +13,7 @@ import (
 //
 // Each format has different features and compatibility:
 //   - M3U: Simple text format, widely supported
+//   - M3U8: M3U in UTF-8, always extended
 //   - PLS: INI-style format, used by Winamp
 //   - WPL: XML format, Windows Media Player
 //   - ZPL: XML format, Zune/Groove Music
@@ -34,6 +35,9 @@ const (
 	// FormatZPL creates .zpl files (Zune/Groove Music).
 	// XML-based SMIL format with extended metadata.
 	FormatZPL
+
+	// FormatM3U8 creates .m3u8 files: extended M3U, always in UTF-8.
+	FormatM3U8
 )
 
 // PlaylistCreator generates playlist files in various formats.
@@ -56,6 +60,33 @@ const (
 type PlaylistCreator struct {
 	format   PlaylistFormat
 	extended bool // For M3U: include EXTINF lines with duration/title
+	options  PlaylistOptions
+}
+
+// PlaylistOptions are the options of the files written by a
+// PlaylistCreator's CreatePlaylistFile.
+type PlaylistOptions struct {
+	// Encoding is the character encoding of the file, UTF-8 by default.
+	Encoding PlaylistEncoding
+
+	// CRLF ends the lines with "\r\n", as some Windows players expect,
+	// instead of "\n".
+	CRLF bool
+
+	// AlbumInfo adds the #PLAYLIST, #EXTALB and #EXTART directives, with
+	// the album's title and artist, to extended M3U and M3U8 playlists.
+	AlbumInfo bool
+
+	// Artwork adds an #EXTIMG directive with the file name of the album's
+	// cover art to extended M3U and M3U8 playlists. Set it only when the
+	// cover art is saved in the album folder.
+	Artwork bool
+
+	// Dir is the folder of a playlist written outside the album folder,
+	// e.g. one gathering tracks of several albums: its files are then
+	// listed by their path relative to Dir. Empty lists them by file
+	// name, for a playlist in the album folder.
+	Dir string
 }
 
 // NewPlaylistCreator creates a new PlaylistCreator.
@@ -71,6 +102,42 @@ func NewPlaylistCreator(format PlaylistFormat, extended bool) *PlaylistCreator {
 	}
 }
 
+// WithOptions sets the options of the files written by
+// CreatePlaylistFile and returns p.
+//
+// Example:
+//
+//	creator := NewPlaylistCreator(FormatM3U, true).WithOptions(PlaylistOptions{Encoding: EncodingAuto, CRLF: true})
+func (p *PlaylistCreator) WithOptions(options PlaylistOptions) *PlaylistCreator {
+	p.options = options
+	return p
+}
+
+// CreatePlaylistFile generates the playlist of an album like
+// CreatePlaylist, with the line endings and encoding of the creator's
+// PlaylistOptions. Returns an error wrapping ErrUnencodable if the
+// encoding lacks characters of the playlist.
+//
+// Example:
+//
+//	data, err := creator.CreatePlaylistFile(album)
+//	if err == nil {
+//	    err = os.WriteFile(album.PlaylistPath, data, 0644)
+//	}
+func (p *PlaylistCreator) CreatePlaylistFile(album *model.Album) ([]byte, error) {
+	options := p.options
+	if p.format == FormatM3U8 && options.Encoding == EncodingWindows1252 {
+		// M3U8 files are UTF-8 by definition
+		options.Encoding = EncodingUTF8
+	}
+	return options.Encode(p.CreatePlaylist(album))
+}
+
+// Options returns the options set with WithOptions.
+func (p *PlaylistCreator) Options() PlaylistOptions {
+	return p.options
+}
+
 // CreatePlaylist generates playlist content for an album.
 //
 // Returns the playlist as a string, ready to be written to a file.
@@ -84,7 +151,9 @@ func NewPlaylistCreator(format PlaylistFormat, extended bool) *PlaylistCreator {
 func (p *PlaylistCreator) CreatePlaylist(album *model.Album) string {
 	switch p.format {
 	case FormatM3U:
-		return p.createM3U(album)
+		return p.createM3U(album, p.extended)
+	case FormatM3U8:
+		return p.createM3U(album, true)
 	case FormatPLS:
 		return p.createPLS(album)
 	case FormatWPL:
@@ -92,7 +161,7 @@ func (p *PlaylistCreator) CreatePlaylist(album *model.Album) string {
 	case FormatZPL:
 		return p.createZPL(album)
 	default:
-		return p.createM3U(album)
+		return p.createM3U(album, p.extended)
 	}
 }
 
@@ -103,24 +172,41 @@ func (p *PlaylistCreator) CreatePlaylist(album *model.Album) string {
 //	filename1.mp3
 //	filename2.mp3
 //
-// Extended M3U format (when extended=true):
+// Extended M3U format (when extended=true), with the album directives of
+// PlaylistOptions.AlbumInfo and PlaylistOptions.Artwork:
 //
 //	#EXTM3U
+//	#PLAYLIST:Album
+//	#EXTALB:Album
+//	#EXTART:Artist
+//	#EXTIMG:Album.jpg
 //	#EXTINF:180,Artist - Title
 //	filename1.mp3
-func (p *PlaylistCreator) createM3U(album *model.Album) string {
+func (p *PlaylistCreator) createM3U(album *model.Album, extended bool) string {
 	var sb strings.Builder
 
-	if p.extended {
+	if extended {
 		sb.WriteString("#EXTM3U\n")
+		if p.options.AlbumInfo {
+			sb.WriteString(fmt.Sprintf("#PLAYLIST:%s\n", album.Title))
+			sb.WriteString(fmt.Sprintf("#EXTALB:%s\n", album.Title))
+			sb.WriteString(fmt.Sprintf("#EXTART:%s\n", album.Artist))
+		}
+		if p.options.Artwork && album.ArtworkPath != "" {
+			sb.WriteString(fmt.Sprintf("#EXTIMG:%s\n", p.entryPath(album.ArtworkPath)))
+		}
 	}
 
 	for _, track := range album.Tracks {
-		if p.extended {
+		if extended {
+			// -1 is the length of tracks whose duration is unknown
 			duration := int(track.Duration)
-			sb.WriteString(fmt.Sprintf("#EXTINF:%d,%s - %s\n", duration, album.Artist, track.Title))
+			if track.Duration <= 0 {
+				duration = -1
+			}
+			sb.WriteString(fmt.Sprintf("#EXTINF:%d,%s - %s\n", duration, track.ArtistName(), track.Title))
 		}
-		sb.WriteString(filepath.Base(track.Path) + "\n")
+		sb.WriteString(p.entryPath(track.Path) + "\n")
 	}
 
 	return sb.String()
@@ -143,7 +229,7 @@ func (p *PlaylistCreator) createPLS(album *model.Album) string {
 
 	for i, track := range album.Tracks {
 		idx := i + 1
-		sb.WriteString(fmt.Sprintf("File%d=%s\n", idx, filepath.Base(track.Path)))
+		sb.WriteString(fmt.Sprintf("File%d=%s\n", idx, p.entryPath(track.Path)))
 		sb.WriteString(fmt.Sprintf("Title%d=%s\n", idx, track.Title))
 		sb.WriteString(fmt.Sprintf("Length%d=%d\n", idx, int(track.Duration)))
 	}
@@ -169,7 +255,7 @@ func (p *PlaylistCreator) createWPL(album *model.Album) string {
 	sb.WriteString("    <seq>\n")
 
 	for _, track := range album.Tracks {
-		sb.WriteString(fmt.Sprintf("      <media src=\"%s\"/>\n", escapeXML(filepath.Base(track.Path))))
+		sb.WriteString(fmt.Sprintf("      <media src=\"%s\"/>\n", escapeXML(p.entryPath(track.Path))))
 	}
 
 	sb.WriteString("    </seq>\n")
@@ -199,11 +285,11 @@ func (p *PlaylistCreator) createZPL(album *model.Album) string {
 	for _, track := range album.Tracks {
 		duration := time.Duration(track.Duration * float64(time.Second))
 		sb.WriteString(fmt.Sprintf("      <media src=\"%s\" albumTitle=\"%s\" albumArtist=\"%s\" trackTitle=\"%s\" trackArtist=\"%s\" duration=\"%d\"/>\n",
-			escapeXML(filepath.Base(track.Path)),
+			escapeXML(p.entryPath(track.Path)),
 			escapeXML(album.Title),
 			escapeXML(album.Artist),
 			escapeXML(track.Title),
-			escapeXML(album.Artist),
+			escapeXML(track.ArtistName()),
 			int(duration.Milliseconds())))
 	}
 
@@ -214,6 +300,18 @@ func (p *PlaylistCreator) createZPL(album *model.Album) string {
 	return sb.String()
 }
 
+// entryPath returns how the playlist refers to the file at path.
+func (p *PlaylistCreator) entryPath(path string) string {
+	if p.options.Dir == "" {
+		return filepath.Base(path)
+	}
+	rel, err := filepath.Rel(p.options.Dir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
 // escapeXML escapes special XML characters in a string.
 //
 // Replaces: & < > " '
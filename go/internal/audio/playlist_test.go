@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -34,6 +35,32 @@ func TestPlaylistCreator_M3UExtended(t *testing.T) {
 	}
 }
 
+func TestPlaylistCreator_M3U8(t *testing.T) {
+	album := createTestAlbum()
+	album.ArtworkURL = "https://f4.bcbits.com/img/a1_10.jpg"
+	album.ArtworkPath = "/music/Test Artist/Test Album/Test Album.jpg"
+
+	content := NewPlaylistCreator(FormatM3U8, false).CreatePlaylist(album)
+	if !strings.HasPrefix(content, "#EXTM3U\n#EXTINF:") {
+		t.Errorf("M3U8 should be extended without album directives by default, got %q", content)
+	}
+
+	creator := NewPlaylistCreator(FormatM3U8, false).WithOptions(PlaylistOptions{AlbumInfo: true, Artwork: true, Encoding: EncodingWindows1252})
+	data, err := creator.CreatePlaylistFile(album)
+	if err != nil {
+		t.Fatalf("CreatePlaylistFile failed: %v", err)
+	}
+	want := "#EXTM3U\n#PLAYLIST:Test Album\n#EXTALB:Test Album\n#EXTART:Test Artist\n#EXTIMG:Test Album.jpg\n#EXTINF:180,"
+	if !strings.HasPrefix(string(data), want) {
+		t.Errorf("M3U8 = %q, want it to start with %q", data, want)
+	}
+
+	// Plain M3U has no room for directives
+	if content := NewPlaylistCreator(FormatM3U, false).WithOptions(PlaylistOptions{AlbumInfo: true}).CreatePlaylist(album); strings.Contains(content, "#") {
+		t.Errorf("plain M3U = %q, want no directives", content)
+	}
+}
+
 func TestPlaylistCreator_PLS(t *testing.T) {
 	album := createTestAlbum()
 	creator := NewPlaylistCreator(FormatPLS, false)
@@ -107,6 +134,53 @@ func TestPlaylistCreator_XMLEscape(t *testing.T) {
 	}
 }
 
+func TestPlaylistOptions_Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		options PlaylistOptions
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "utf-8", content: "Café\n", want: "Café\n"},
+		{name: "bom", options: PlaylistOptions{Encoding: EncodingUTF8BOM}, content: "a\n", want: "\xef\xbb\xbfa\n"},
+		{name: "auto ascii", options: PlaylistOptions{Encoding: EncodingAuto}, content: "a\n", want: "a\n"},
+		{name: "auto non-ascii", options: PlaylistOptions{Encoding: EncodingAuto}, content: "é\n", want: "\xef\xbb\xbfé\n"},
+		{name: "windows-1252", options: PlaylistOptions{Encoding: EncodingWindows1252}, content: "Café €\n", want: "Caf\xe9 \x80\n"},
+		{name: "windows-1252 unencodable", options: PlaylistOptions{Encoding: EncodingWindows1252}, content: "東京\n", wantErr: true},
+		{name: "crlf", options: PlaylistOptions{CRLF: true}, content: "a\nb\n", want: "a\r\nb\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.options.Encode(tt.content)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnencodable) {
+					t.Fatalf("Encode() error = %v, want ErrUnencodable", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode() failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaylistEncoding(t *testing.T) {
+	for _, name := range []string{"", "utf-8", "UTF-8-BOM", "windows-1252", "auto"} {
+		if _, err := ParsePlaylistEncoding(name); err != nil {
+			t.Errorf("ParsePlaylistEncoding(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParsePlaylistEncoding("latin1"); err == nil {
+		t.Error("ParsePlaylistEncoding(latin1) succeeded")
+	}
+}
+
 func createTestAlbum() *model.Album {
 	albumCfg := &model.PathConfig{
 		DownloadsPath:          "/music/{artist}/{album}",
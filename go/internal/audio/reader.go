@@ -0,0 +1,187 @@
+package audio
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bogem/id3v2"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// ErrNoTracks is returned by Reader.ReadAlbum when a folder has no MP3
+// file whose tags can be read.
+var ErrNoTracks = errors.New("no readable MP3 file")
+
+// Reader reads the ID3 tags and embedded pictures of MP3 files back into
+// model structures, the reverse of Tagger, so downloaded files can be
+// inspected, matched with their release or listed in playlists without
+// fetching anything. A Reader is safe for concurrent use.
+//
+// Example:
+//
+//	reader := audio.NewReader()
+//	track, err := reader.ReadTrack("/music/Artist/Album/01 Artist - Title.mp3")
+//	fmt.Println(track.Album.Title, track.Number, track.Title)
+//	cover, err := reader.ReadArtwork(track.Path)
+type Reader struct{}
+
+// NewReader creates a new Reader.
+func NewReader() *Reader {
+	return &Reader{}
+}
+
+// ReadTrack reads the tags of the MP3 file at path into a track, whose
+// Album holds the album values of the tags: artist (TPE2, or TPE1),
+// title, release date, Bandcamp URL, publisher and compilation flag. The
+// album's Path is the file's folder. Values missing from the ID3v2 tag
+// are read from the ID3v1 tag, if any. Pictures are not read; see
+// ReadPictures.
+func (r *Reader) ReadTrack(path string) (*model.Track, error) {
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: textFrames})
+	if err != nil {
+		return nil, err
+	}
+	defer tag.Close()
+
+	info := newTagInfo(tag)
+	if v1, err := ReadID3v1(path); err == nil && v1 != nil {
+		info.fillFrom(v1)
+	}
+
+	album := &model.Album{
+		URL:         cmp.Or(info.BandcampURL, info.SourceURL),
+		Artist:      cmp.Or(info.AlbumArtist, info.Artist),
+		Title:       info.Album,
+		ReleaseDate: releaseDate(tag.GetTextFrame("TDRC").Text, info.Year),
+		Compilation: strings.TrimSpace(tag.GetTextFrame("TCMP").Text) == "1",
+		Publisher:   tag.GetTextFrame("TPUB").Text,
+		Path:        filepath.Dir(path),
+	}
+
+	track := &model.Track{
+		Album:    album,
+		ID:       info.BandcampTrackID,
+		Number:   info.TrackNumber,
+		Title:    info.Title,
+		Artist:   info.Artist,
+		Duration: info.Duration,
+		Composer: tag.GetTextFrame("TCOM").Text,
+		ISRC:     tag.GetTextFrame("TSRC").Text,
+		Ext:      strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")),
+		Path:     path,
+	}
+	// TPOS may be "1" or "1/2"
+	if _, err := fmt.Sscanf(tag.GetTextFrame("TPOS").Text, "%d", &track.DiscNumber); err != nil {
+		track.DiscNumber = 0
+	}
+	for _, f := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+		if uslf, ok := f.(id3v2.UnsynchronisedLyricsFrame); ok && uslf.Lyrics != "" {
+			track.Lyrics = uslf.Lyrics
+			break
+		}
+	}
+	for _, f := range tag.GetFrames("WOAF") {
+		if uf, ok := f.(id3v2.UnknownFrame); ok {
+			track.URL = string(uf.Body)
+		}
+	}
+
+	album.Tracks = []*model.Track{track}
+	return track, nil
+}
+
+// ReadAlbum reads the tags of the MP3 files of the folder dir, not of its
+// subfolders, into an album. The album values are those of its first
+// track; its tracks are sorted by disc and track number. Files whose tags
+// cannot be read are skipped, and ErrNoTracks is returned if none can.
+func (r *Reader) ReadAlbum(dir string) (*model.Album, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []*model.Track
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".mp3") {
+			continue
+		}
+		track, err := r.ReadTrack(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		tracks = append(tracks, track)
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoTracks, dir)
+	}
+
+	slices.SortStableFunc(tracks, func(a, b *model.Track) int {
+		return cmp.Or(cmp.Compare(a.DiscNumber, b.DiscNumber), cmp.Compare(a.Number, b.Number))
+	})
+	album := tracks[0].Album
+	album.Tracks = tracks
+	for _, track := range tracks {
+		track.Album = album
+	}
+	return album, nil
+}
+
+// ReadPictures returns the pictures embedded in the MP3 file at path
+// (APIC frames), in the order of the tag. Returns no pictures and no
+// error if it has none.
+func (r *Reader) ReadPictures(path string) ([]Picture, error) {
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: []string{"Attached picture"}})
+	if err != nil {
+		return nil, err
+	}
+	defer tag.Close()
+
+	var pictures []Picture
+	for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		if pf, ok := f.(id3v2.PictureFrame); ok && len(pf.Picture) > 0 {
+			pictures = append(pictures, Picture{Type: pf.PictureType, Data: pf.Picture})
+		}
+	}
+	return pictures, nil
+}
+
+// ReadArtwork returns the front cover embedded in the MP3 file at path, or
+// its first picture if none is a front cover, e.g. when the artwork was
+// saved as another picture type (TagConfig.PictureType). Returns nil and
+// no error if the file has no picture.
+func (r *Reader) ReadArtwork(path string) ([]byte, error) {
+	pictures, err := r.ReadPictures(path)
+	if err != nil || len(pictures) == 0 {
+		return nil, err
+	}
+	for _, p := range pictures {
+		if p.Type == id3v2.PTFrontCover {
+			return p.Data, nil
+		}
+	}
+	return pictures[0].Data, nil
+}
+
+// releaseDate returns the date of a TDRC frame ("2006-01-02", "2006-01"
+// or "2006", possibly followed by a time), or else the year of a TYER
+// frame. Returns the zero time if neither is a date.
+func releaseDate(tdrc, year string) time.Time {
+	tdrc = strings.TrimSpace(tdrc)
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if len(tdrc) >= len(layout) {
+			if t, err := time.Parse(layout, tdrc[:len(layout)]); err == nil {
+				return t
+			}
+		}
+	}
+	if t, err := time.Parse("2006", strings.TrimSpace(year)); err == nil {
+		return t
+	}
+	return time.Time{}
+}
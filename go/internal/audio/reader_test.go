@@ -0,0 +1,95 @@
+package audio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestReader(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Various", "Album", "", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC), &model.PathConfig{DownloadsPath: dir})
+	album.URL = "https://label.bandcamp.com/album/album"
+	album.Publisher = "Label"
+	album.Compilation = true
+	trackCfg := &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}
+	album.Tracks = []*model.Track{
+		model.NewTrack(album, 2, 1, "Second", 200, "", "", trackCfg),
+		model.NewTrack(album, 1, 3, "First", 180, "La la", "", trackCfg),
+	}
+	first := album.Tracks[1]
+	first.ID = 42
+	first.Artist = "Guest"
+	first.Composer = "Jane Doe"
+	first.ISRC = "USS1Z9900001"
+	first.URL = "/track/first"
+
+	cfg := DefaultTagConfig()
+	cfg.Source = true
+	tagger := NewTagger(cfg)
+	for _, track := range album.Tracks {
+		if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := tagger.SaveTags(track, album, []byte("cover"), Picture{Type: id3v2.PTBackCover, Data: []byte("back")}); err != nil {
+			t.Fatalf("SaveTags failed: %v", err)
+		}
+	}
+
+	reader := NewReader()
+	got, err := reader.ReadTrack(first.Path)
+	if err != nil {
+		t.Fatalf("ReadTrack failed: %v", err)
+	}
+	if got.ID != 42 || got.Number != 3 || got.DiscNumber != 1 || got.Title != "First" || got.Artist != "Guest" ||
+		got.Duration != 180 || got.Lyrics != "La la" || got.Composer != "Jane Doe" || got.ISRC != "USS1Z9900001" ||
+		got.URL != "https://label.bandcamp.com/track/first" || got.Ext != "mp3" || got.Path != first.Path {
+		t.Errorf("ReadTrack() = %+v", got)
+	}
+	a := got.Album
+	if a.Artist != "Various" || a.Title != "Album" || a.URL != album.URL || a.Publisher != "Label" ||
+		!a.Compilation || !a.ReleaseDate.Equal(album.ReleaseDate) || a.Path != dir {
+		t.Errorf("ReadTrack().Album = %+v", a)
+	}
+
+	read, err := reader.ReadAlbum(dir)
+	if err != nil {
+		t.Fatalf("ReadAlbum failed: %v", err)
+	}
+	if len(read.Tracks) != 2 || read.Tracks[0].Title != "First" || read.Tracks[1].Title != "Second" || read.Tracks[1].Album != read {
+		t.Errorf("ReadAlbum() tracks = %v, %v", read.Tracks[0], read.Tracks[1])
+	}
+
+	pictures, err := reader.ReadPictures(first.Path)
+	if err != nil {
+		t.Fatalf("ReadPictures failed: %v", err)
+	}
+	if len(pictures) != 2 {
+		t.Fatalf("ReadPictures() = %d pictures, want 2", len(pictures))
+	}
+	artwork, err := reader.ReadArtwork(first.Path)
+	if err != nil || string(artwork) != "cover" {
+		t.Errorf("ReadArtwork() = %q, %v; want cover", artwork, err)
+	}
+
+	if _, err := reader.ReadAlbum(t.TempDir()); !errors.Is(err, ErrNoTracks) {
+		t.Errorf("ReadAlbum(empty folder) error = %v, want ErrNoTracks", err)
+	}
+}
+
+func TestReader_ReadArtwork_None(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	artwork, err := NewReader().ReadArtwork(path)
+	if artwork != nil || err != nil {
+		t.Errorf("ReadArtwork() = %q, %v; want nil, nil", artwork, err)
+	}
+}
@@ -1,13 +1,31 @@
 package audio
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/bogem/id3v2"
 	"github.com/handiism/bandcamp-downloader/internal/model"
 )
 
+// bandcampURLDescription is the TXXX frame description under which the
+// album's Bandcamp URL is stored, so existing files can be matched back
+// to their release.
+const bandcampURLDescription = "BANDCAMP_URL"
+
+// bandcampTrackIDDescription is the TXXX frame description under which the
+// track's Bandcamp ID is stored, so an existing file can be recognized as
+// the track whatever its size or path.
+const bandcampTrackIDDescription = "BANDCAMP_TRACK_ID"
+
+// sourceDescription is the TXXX frame description naming the store the
+// file comes from, written when TagConfig.Source is set.
+const sourceDescription = "SOURCE"
+
 // TagEditAction defines how to handle individual ID3 tags.
 //
 // Each tag field can be configured independently to determine whether
@@ -25,6 +43,43 @@ const (
 	TagDoNotModify
 )
 
+// ParseTagEditAction parses the name of an action as written in settings:
+// "modify", "empty" or "keep" (TagDoNotModify).
+func ParseTagEditAction(s string) (TagEditAction, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "modify":
+		return TagModify, nil
+	case "empty":
+		return TagEmpty, nil
+	case "keep":
+		return TagDoNotModify, nil
+	default:
+		return 0, fmt.Errorf("invalid tag action %q, must be modify, empty or keep", s)
+	}
+}
+
+// String returns the name of the action accepted by ParseTagEditAction.
+func (a TagEditAction) String() string {
+	switch a {
+	case TagModify:
+		return "modify"
+	case TagEmpty:
+		return "empty"
+	case TagDoNotModify:
+		return "keep"
+	default:
+		return "TagEditAction(" + strconv.Itoa(int(a)) + ")"
+	}
+}
+
+// TagFields lists the field names accepted by TagConfig.Action, in the
+// order of the TagConfig fields.
+var TagFields = []string{
+	"artist", "album_artist", "album", "year", "date",
+	"track_number", "disc_number", "title", "lyrics", "comments",
+	"composer", "publisher", "isrc",
+}
+
 // TagConfig holds tagging configuration for each ID3 field.
 //
 // This allows fine-grained control over which tags are modified
@@ -75,6 +130,31 @@ type TagConfig struct {
 
 	// Comments controls the COMM (Comments) frame.
 	Comments TagEditAction
+
+	// Composer controls the TCOM (Composer) frame, found in the credits.
+	Composer TagEditAction
+
+	// Publisher controls the TPUB (Publisher) frame, from the credits or
+	// the label.
+	Publisher TagEditAction
+
+	// ISRC controls the TSRC (International Standard Recording Code)
+	// frame, found in the credits.
+	ISRC TagEditAction
+
+	// Source writes a TXXX "SOURCE=bandcamp" frame, the release URL in the
+	// WOAS (official audio source webpage) frame and the track URL in the
+	// WOAF (official audio file webpage) frame, which scrobblers and
+	// library tools use to match obscure releases.
+	Source bool
+
+	// StripID3v1 removes the ID3v1 tag at the end of the file once its
+	// values are migrated, so players do not show outdated values from it.
+	StripID3v1 bool
+
+	// PictureType is the ID3 picture type the cover art is embedded as,
+	// id3v2.PTFrontCover by default.
+	PictureType byte
 }
 
 // DefaultTagConfig returns the default tag configuration.
@@ -94,6 +174,50 @@ func DefaultTagConfig() *TagConfig {
 		TrackTitle:  TagModify,
 		Lyrics:      TagModify,
 		Comments:    TagEmpty,
+		Composer:    TagModify,
+		Publisher:   TagModify,
+		ISRC:        TagModify,
+		PictureType: id3v2.PTFrontCover,
+	}
+}
+
+// Action returns the action of the field named name, one of TagFields, so
+// the fields can be configured by name. Returns nil for an unknown name.
+//
+// Example:
+//
+//	cfg := DefaultTagConfig()
+//	*cfg.Action("comments") = TagDoNotModify
+func (c *TagConfig) Action(name string) *TagEditAction {
+	switch name {
+	case "artist":
+		return &c.Artist
+	case "album_artist":
+		return &c.AlbumArtist
+	case "album":
+		return &c.Album
+	case "year":
+		return &c.Year
+	case "date":
+		return &c.Date
+	case "track_number":
+		return &c.TrackNumber
+	case "disc_number":
+		return &c.DiscNumber
+	case "title":
+		return &c.TrackTitle
+	case "lyrics":
+		return &c.Lyrics
+	case "comments":
+		return &c.Comments
+	case "composer":
+		return &c.Composer
+	case "publisher":
+		return &c.Publisher
+	case "isrc":
+		return &c.ISRC
+	default:
+		return nil
 	}
 }
 
@@ -105,6 +229,10 @@ func DefaultTagConfig() *TagConfig {
 //   - Lyrics (unsynchronized)
 //   - Cover Art (attached picture)
 //
+// Files are tagged according to their extension: .flac files get Vorbis
+// comments and a PICTURE block, .m4a, .m4b and .mp4 files get iTunes
+// metadata atoms, with the same TagConfig actions.
+//
 // Example:
 //
 //	tagger := NewTagger(DefaultTagConfig())
@@ -133,13 +261,17 @@ func NewTagger(config *TagConfig) *Tagger {
 // This method:
 //  1. Opens the existing MP3 file (or creates empty tags if none exist)
 //  2. Updates string tags based on TagConfig settings
-//  3. Embeds cover art if artwork bytes are provided
-//  4. Saves the modified tags to the file
+//  3. Copies the values of an ID3v1 tag into the empty ID3v2 frames of
+//     the fields that are not modified (every field if ModifyTags is off)
+//  4. Embeds cover art if artwork bytes are provided
+//  5. Saves the modified tags to the file, and removes the ID3v1 tag if
+//     StripID3v1 is set
 //
 // Parameters:
 //   - track: The track being tagged (provides title, lyrics, file path)
 //   - album: The album (provides artist, title, release date)
 //   - artwork: JPEG image bytes for cover art (nil to skip artwork)
+//   - extra: more pictures to embed, e.g. a back cover
 //
 // Returns an error if the file cannot be opened or saved.
 //
@@ -147,27 +279,126 @@ func NewTagger(config *TagConfig) *Tagger {
 //
 //	tagger := NewTagger(DefaultTagConfig())
 //	err := tagger.SaveTags(track, album, jpegBytes)
-func (t *Tagger) SaveTags(track *model.Track, album *model.Album, artwork []byte) error {
-	tag, err := id3v2.Open(track.Path, id3v2.Options{Parse: true})
-	if err != nil {
-		// If file doesn't have tags, create new
-		if os.IsNotExist(err) {
-			tag = id3v2.NewEmptyTag()
-		} else {
+func (t *Tagger) SaveTags(track *model.Track, album *model.Album, artwork []byte, extra ...Picture) error {
+	pictures := t.pictures(artwork, extra)
+	switch formatOf(track.Path) {
+	case formatFLAC:
+		return t.saveFLAC(track.Path, track, album, pictures)
+	case formatMP4:
+		return t.saveMP4(track.Path, track, album, pictures)
+	}
+
+	return t.saveID3(track.Path, t.config.StripID3v1, func(tag *id3v2.Tag, v1 *ID3v1) {
+		if t.config.ModifyTags {
+			t.updateStringTags(tag, track, album)
+		}
+		if v1 != nil {
+			v1.migrate(tag, t.keeps)
+		}
+		if len(pictures) > 0 {
+			updateArtwork(tag, pictures)
+		}
+	})
+}
+
+// saveID3 rewrites the ID3v2 tag of the MP3 file at path after update
+// changed it, and removes its ID3v1 tag if stripV1 is set.
+func (t *Tagger) saveID3(path string, stripV1 bool, update func(tag *id3v2.Tag, v1 *ID3v1)) error {
+	return rewriteFile(path, mp3Audio, func(src *os.File, dst io.Writer) error {
+		tag, err := id3v2.ParseReader(io.NewSectionReader(src, 0, 1<<62), id3v2.Options{Parse: true})
+		if err != nil {
+			return err
+		}
+		v1, err := readID3v1(src)
+		if err != nil {
+			return err
+		}
+		update(tag, v1)
+
+		if _, err := tag.WriteTo(dst); err != nil {
 			return err
 		}
+		start, end, err := mp3AudioRange(src)
+		if err != nil {
+			return err
+		}
+		if v1 != nil && !stripV1 {
+			end += id3v1Size
+		}
+		_, err = io.Copy(dst, io.NewSectionReader(src, start, end-start))
+		return err
+	})
+}
+
+// mp3AudioRange returns the start and end offsets of the audio frames of
+// the MP3 file f, between its ID3v2 and ID3v1 tags.
+func mp3AudioRange(f *os.File) (start, end int64, err error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
 	}
-	defer tag.Close()
+	end = fi.Size()
+
+	var header [10]byte
+	if _, err := f.ReadAt(header[:], 0); err == nil && string(header[:3]) == "ID3" {
+		// The size is a syncsafe integer, 7 bits per byte, excluding the
+		// header and the footer
+		size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+		start = 10 + size
+		if header[5]&0x10 != 0 {
+			start += 10
+		}
+	}
+	v1, err := readID3v1(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	if v1 != nil {
+		end -= id3v1Size
+	}
+	if start > end {
+		return 0, 0, errors.New("invalid ID3v2 tag size")
+	}
+	return start, end, nil
+}
+
+// mp3Audio returns the audio frames of the MP3 file f.
+func mp3Audio(f *os.File) (io.Reader, error) {
+	start, end, err := mp3AudioRange(f)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(f, start, end-start), nil
+}
 
-	if t.config.ModifyTags {
-		t.updateStringTags(tag, track, album)
+// keeps reports whether SaveTags leaves the field named field unchanged.
+func (t *Tagger) keeps(field string) bool {
+	if !t.config.ModifyTags {
+		return true
 	}
+	action := t.config.Action(field)
+	return action != nil && *action == TagDoNotModify
+}
 
-	if artwork != nil {
-		t.updateArtwork(tag, artwork)
+// SaveArtwork replaces the embedded cover art of the MP3 file at path,
+// leaving every other frame untouched. Use it to refresh the artwork of
+// already-tagged files when the album art changed on Bandcamp.
+//
+// Example:
+//
+//	err := tagger.SaveArtwork(track.Path, jpegBytes)
+func (t *Tagger) SaveArtwork(path string, artwork []byte, extra ...Picture) error {
+	pictures := t.pictures(artwork, extra)
+	switch formatOf(path) {
+	case formatFLAC:
+		return t.saveFLAC(path, nil, nil, pictures)
+	case formatMP4:
+		return t.saveMP4(path, nil, nil, pictures)
 	}
 
-	return tag.Save()
+	return t.saveID3(path, false, func(tag *id3v2.Tag, _ *ID3v1) {
+		updateArtwork(tag, pictures)
+	})
 }
 
 // updateStringTags updates text-based ID3 frames based on configuration.
@@ -177,7 +408,7 @@ func (t *Tagger) updateStringTags(tag *id3v2.Tag, track *model.Track, album *mod
 	case TagEmpty:
 		tag.SetArtist("")
 	case TagModify:
-		tag.SetArtist(album.Artist)
+		tag.SetArtist(track.ArtistName())
 	}
 
 	// Album (TALB)
@@ -254,22 +485,233 @@ func (t *Tagger) updateStringTags(tag *id3v2.Tag, track *model.Track, album *mod
 		}
 	}
 
+	// Composer (TCOM), Publisher (TPUB) and ISRC (TSRC), only written when
+	// the credits name them
+	setCreditFrame(tag, "TCOM", t.config.Composer, track.Composer)
+	setCreditFrame(tag, "TPUB", t.config.Publisher, album.Publisher)
+	setCreditFrame(tag, "TSRC", t.config.ISRC, track.ISRC)
+
+	// Length (TLEN) in milliseconds, so library tools can read durations
+	if track.Duration > 0 {
+		tag.AddTextFrame("TLEN", id3v2.EncodingUTF8, strconv.Itoa(int(track.Duration*1000)))
+	}
+
+	// Compilation flag (TCMP) - iTunes extension understood by most players
+	if album.Compilation {
+		tag.AddTextFrame("TCMP", id3v2.EncodingUTF8, "1")
+	}
+
+	// Bandcamp URL (TXXX) - lets retag match files back to their release
+	if album.URL != "" {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: bandcampURLDescription,
+			Value:       album.URL,
+		})
+	}
+
+	// Bandcamp track ID (TXXX) - lets downloads recognize existing files
+	if track.ID > 0 {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: bandcampTrackIDDescription,
+			Value:       strconv.FormatInt(track.ID, 10),
+		})
+	}
+
+	if t.config.Source {
+		updateSourceFrames(tag, track, album)
+	}
+
 	// Genre - always clear as Bandcamp doesn't provide genre info
 	tag.SetGenre("")
 }
 
-// updateArtwork embeds cover art as an attached picture frame.
-func (t *Tagger) updateArtwork(tag *id3v2.Tag, artwork []byte) {
-	// Remove any existing cover pictures
-	tag.DeleteFrames(tag.CommonID("Attached picture"))
+// setCreditFrame applies action to the text frame id. Values found in
+// credits are often missing, so TagModify leaves the frame unchanged when
+// value is empty.
+func setCreditFrame(tag *id3v2.Tag, id string, action TagEditAction, value string) {
+	switch action {
+	case TagEmpty:
+		tag.DeleteFrames(id)
+	case TagModify:
+		if value != "" {
+			tag.AddTextFrame(id, id3v2.EncodingUTF8, value)
+		}
+	}
+}
 
-	// Add new artwork as front cover (APIC frame)
-	pic := id3v2.PictureFrame{
+// updateSourceFrames writes the SOURCE, WOAS and WOAF frames.
+func updateSourceFrames(tag *id3v2.Tag, track *model.Track, album *model.Album) {
+	tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
 		Encoding:    id3v2.EncodingUTF8,
-		MimeType:    "image/jpeg",
-		PictureType: id3v2.PTFrontCover,
-		Description: "Cover",
-		Picture:     artwork,
+		Description: sourceDescription,
+		Value:       "bandcamp",
+	})
+	setURLFrame(tag, "WOAS", album.URL)
+	setURLFrame(tag, "WOAF", track.PageURL())
+}
+
+// setURLFrame replaces the URL link frame id (W***) with url, or removes it
+// if url is empty. The id3v2 library has no type for these frames, whose
+// body is the ISO-8859-1 URL.
+func setURLFrame(tag *id3v2.Tag, id, url string) {
+	tag.DeleteFrames(id)
+	if url != "" {
+		tag.AddFrame(id, id3v2.UnknownFrame{Body: []byte(url)})
+	}
+}
+
+// updateArtwork embeds pictures as attached picture frames (APIC),
+// replacing the existing pictures of the same types.
+func updateArtwork(tag *id3v2.Tag, pictures []Picture) {
+	id := tag.CommonID("Attached picture")
+	existing := tag.GetFrames(id)
+	tag.DeleteFrames(id)
+	for _, f := range existing {
+		if pf, ok := f.(id3v2.PictureFrame); ok && !hasPictureType(pictures, pf.PictureType) {
+			tag.AddAttachedPicture(pf)
+		}
+	}
+
+	for _, p := range pictures {
+		tag.AddAttachedPicture(id3v2.PictureFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			MimeType:    imageMimeType(p.Data),
+			PictureType: p.Type,
+			Description: pictureDescription(p.Type),
+			Picture:     p.Data,
+		})
+	}
+}
+
+// TagInfo holds the tag values read back from an MP3 file.
+type TagInfo struct {
+	Artist      string
+	AlbumArtist string
+	Album       string
+	Title       string
+	Year        string
+
+	// Genre is the text of the TCON frame, set by other tools: SaveTags
+	// clears it, since Bandcamp has no genres.
+	Genre string
+
+	// TrackNumber is zero if the file has no usable TRCK frame.
+	TrackNumber int
+
+	// Duration is the track length in seconds from the TLEN frame,
+	// or zero if the file has none.
+	Duration float64
+
+	// BandcampURL is the album URL written by SaveTags, or empty if the
+	// file was not tagged by this tool.
+	BandcampURL string
+
+	// BandcampTrackID is the track ID written by SaveTags, or zero if the
+	// file has none.
+	BandcampTrackID int64
+
+	// Source is the value of the TXXX SOURCE frame, e.g. "bandcamp".
+	Source string
+
+	// SourceURL is the release URL of the WOAS frame.
+	SourceURL string
+}
+
+// ReadTagInfo reads the main tag values of an audio file: the ID3 tags of
+// MP3 files, whose values missing from the ID3v2 tag are read from the
+// ID3v1 tag, if any, the Vorbis comments of FLAC files and the metadata
+// items of MP4 files.
+func ReadTagInfo(path string) (*TagInfo, error) {
+	switch formatOf(path) {
+	case formatFLAC:
+		return readFLACTagInfo(path)
+	case formatMP4:
+		return readMP4TagInfo(path)
+	}
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: textFrames})
+	if err != nil {
+		return nil, err
+	}
+	defer tag.Close()
+
+	info := newTagInfo(tag)
+
+	// Files with only an ID3v1 tag, or values missing from the ID3v2 one
+	if v1, err := ReadID3v1(path); err == nil && v1 != nil {
+		info.fillFrom(v1)
+	}
+
+	return info, nil
+}
+
+// textFrames are the frames ReadTagInfo and Reader.ReadTrack parse,
+// skipping the pictures.
+var textFrames = []string{
+	"TPE1", "TPE2", "TALB", "TIT2", "TYER", "TDRC", "TCON", "TRCK", "TPOS", "TLEN",
+	"TCMP", "TCOM", "TPUB", "TSRC", "USLT", "TXXX", "WOAS", "WOAF",
+}
+
+// newTagInfo returns the values of the frames of tag read by ReadTagInfo.
+func newTagInfo(tag *id3v2.Tag) *TagInfo {
+	info := &TagInfo{
+		Artist:      tag.Artist(),
+		AlbumArtist: tag.GetTextFrame("TPE2").Text,
+		Album:       tag.Album(),
+		Title:       tag.Title(),
+		Year:        tag.Year(),
+		Genre:       tag.Genre(),
+	}
+
+	for _, f := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		udtf, ok := f.(id3v2.UserDefinedTextFrame)
+		if !ok {
+			continue
+		}
+		switch udtf.Description {
+		case bandcampURLDescription:
+			info.BandcampURL = udtf.Value
+		case bandcampTrackIDDescription:
+			info.BandcampTrackID, _ = strconv.ParseInt(strings.TrimSpace(udtf.Value), 10, 64)
+		case sourceDescription:
+			info.Source = udtf.Value
+		}
+	}
+	for _, f := range tag.GetFrames("WOAS") {
+		if uf, ok := f.(id3v2.UnknownFrame); ok {
+			info.SourceURL = string(uf.Body)
+		}
+	}
+
+	// TRCK may be "3" or "3/10"
+	trck, _, _ := strings.Cut(tag.GetTextFrame("TRCK").Text, "/")
+	info.TrackNumber, _ = strconv.Atoi(strings.TrimSpace(trck))
+
+	if ms, err := strconv.Atoi(strings.TrimSpace(tag.GetTextFrame("TLEN").Text)); err == nil {
+		info.Duration = float64(ms) / 1000
+	}
+
+	return info
+}
+
+// fillFrom sets the empty values of info from an ID3v1 tag.
+func (info *TagInfo) fillFrom(v1 *ID3v1) {
+	for _, f := range []struct {
+		value *string
+		v1    string
+	}{
+		{&info.Artist, v1.Artist},
+		{&info.Album, v1.Album},
+		{&info.Title, v1.Title},
+		{&info.Year, v1.Year},
+	} {
+		if *f.value == "" {
+			*f.value = f.v1
+		}
+	}
+	if info.TrackNumber == 0 {
+		info.TrackNumber = v1.Track
 	}
-	tag.AddAttachedPicture(pic)
 }
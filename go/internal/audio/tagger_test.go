@@ -0,0 +1,269 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestSaveTags_ReadTagInfo(t *testing.T) {
+	dir := t.TempDir()
+
+	albumCfg := &model.PathConfig{
+		DownloadsPath:          dir,
+		CoverArtFileNameFormat: "{album}",
+		PlaylistFileNameFormat: "{album}",
+	}
+	trackCfg := &model.TrackConfig{
+		FileNameFormat: "{tracknum} {title}.mp3",
+	}
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), albumCfg)
+	album.URL = "https://artist.bandcamp.com/album/album"
+	track := model.NewTrack(album, 1, 7, "Title", 180, "", "http://example.com/7.mp3", trackCfg)
+
+	if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewTagger(DefaultTagConfig()).SaveTags(track, album, nil); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+
+	info, err := ReadTagInfo(filepath.Join(dir, "07 Title.mp3"))
+	if err != nil {
+		t.Fatalf("ReadTagInfo failed: %v", err)
+	}
+	if info.BandcampURL != album.URL {
+		t.Errorf("BandcampURL = %q, want %q", info.BandcampURL, album.URL)
+	}
+	if info.TrackNumber != 7 {
+		t.Errorf("TrackNumber = %d, want 7", info.TrackNumber)
+	}
+	if info.Duration != 180 {
+		t.Errorf("Duration = %v, want 180", info.Duration)
+	}
+	if info.Artist != "Artist" || info.Album != "Album" || info.Title != "Title" {
+		t.Errorf("got %q/%q/%q, want Artist/Album/Title", info.Artist, info.Album, info.Title)
+	}
+}
+
+func TestSaveTags_Source(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), &model.PathConfig{DownloadsPath: dir})
+	album.URL = "https://artist.bandcamp.com/album/album"
+	track := model.NewTrack(album, 1, 1, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.mp3"})
+	track.URL = "/track/title"
+
+	if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultTagConfig()
+	cfg.Source = true
+	tagger := NewTagger(cfg)
+	// Tag twice, as when retagging, to check the frames are replaced
+	for i := 0; i < 2; i++ {
+		if err := tagger.SaveTags(track, album, nil); err != nil {
+			t.Fatalf("SaveTags failed: %v", err)
+		}
+	}
+
+	info, err := ReadTagInfo(track.Path)
+	if err != nil {
+		t.Fatalf("ReadTagInfo failed: %v", err)
+	}
+	if info.Source != "bandcamp" {
+		t.Errorf("Source = %q, want bandcamp", info.Source)
+	}
+	if info.SourceURL != album.URL {
+		t.Errorf("SourceURL = %q, want %q", info.SourceURL, album.URL)
+	}
+
+	tag, err := id3v2.Open(track.Path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	woaf := tag.GetFrames("WOAF")
+	if len(woaf) != 1 {
+		t.Fatalf("%d WOAF frames, want 1", len(woaf))
+	}
+	if got := string(woaf[0].(id3v2.UnknownFrame).Body); got != "https://artist.bandcamp.com/track/title" {
+		t.Errorf("WOAF = %q", got)
+	}
+}
+
+func TestSaveTags_Credits(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), &model.PathConfig{DownloadsPath: dir})
+	album.Publisher = "Label"
+	track := model.NewTrack(album, 1, 1, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.mp3"})
+	track.Composer = "Jane Doe"
+	track.ISRC = "USS1Z9900001"
+
+	if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewTagger(DefaultTagConfig()).SaveTags(track, album, nil); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+
+	// Retag without the ISRC, which must be kept, and emptying the publisher
+	cfg := DefaultTagConfig()
+	cfg.Publisher = TagEmpty
+	track.ISRC = ""
+	if err := NewTagger(cfg).SaveTags(track, album, nil); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+
+	tag, err := id3v2.Open(track.Path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	for id, want := range map[string]string{"TCOM": "Jane Doe", "TPUB": "", "TSRC": "USS1Z9900001"} {
+		if got := tag.GetTextFrame(id).Text; got != want {
+			t.Errorf("%s = %q, want %q", id, got, want)
+		}
+	}
+}
+
+// id3v1Tag returns an ID3v1.1 tag with the given values.
+func id3v1Tag(title, artist, album, year, comment string, track byte) []byte {
+	buf := make([]byte, id3v1Size)
+	copy(buf, "TAG")
+	copy(buf[3:33], title)
+	copy(buf[33:63], artist)
+	copy(buf[63:93], album)
+	copy(buf[93:97], year)
+	copy(buf[97:125], comment)
+	buf[126] = track
+	buf[127] = 255
+	return buf
+}
+
+func TestSaveTags_ID3v1(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), &model.PathConfig{DownloadsPath: dir})
+	track := model.NewTrack(album, 1, 1, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.mp3"})
+
+	audioData := []byte("\xff\xfbaudio frames")
+	data := append(append([]byte{}, audioData...), id3v1Tag("Old Title", "Old Artist", "Old Album", "1999", "Ripped by me", 3)...)
+	if err := os.WriteFile(track.Path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// With tags not modified, the ID3v1 values are migrated and kept
+	cfg := DefaultTagConfig()
+	cfg.ModifyTags = false
+	if err := NewTagger(cfg).SaveTags(track, album, []byte("jpeg")); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+	tag, err := id3v2.Open(track.Path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Title() != "Old Title" || tag.Artist() != "Old Artist" || tag.Album() != "Old Album" || tag.Year() != "1999" {
+		t.Errorf("migrated %q/%q/%q/%q", tag.Title(), tag.Artist(), tag.Album(), tag.Year())
+	}
+	if got := tag.GetTextFrame("TRCK").Text; got != "3" {
+		t.Errorf("TRCK = %q, want 3", got)
+	}
+	if comments := tag.GetFrames(tag.CommonID("Comments")); len(comments) != 1 || comments[0].(id3v2.CommentFrame).Text != "Ripped by me" {
+		t.Errorf("comments = %v", comments)
+	}
+	tag.Close()
+	if v1, err := ReadID3v1(track.Path); err != nil || v1 == nil || v1.Title != "Old Title" || v1.Track != 3 {
+		t.Errorf("ReadID3v1() = %+v, %v, want the tag kept", v1, err)
+	}
+
+	// Modified fields replace the ID3v1 values, kept ones are migrated,
+	// and the ID3v1 tag is removed
+	cfg = DefaultTagConfig()
+	cfg.Comments = TagDoNotModify
+	cfg.StripID3v1 = true
+	if err := os.WriteFile(track.Path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewTagger(cfg).SaveTags(track, album, nil); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+	if v1, err := ReadID3v1(track.Path); err != nil || v1 != nil {
+		t.Errorf("ReadID3v1() = %+v, %v, want no tag", v1, err)
+	}
+	content, err := os.ReadFile(track.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(content), string(audioData)) {
+		t.Errorf("audio data not preserved")
+	}
+	info, err := ReadTagInfo(track.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Title" || info.Artist != "Artist" || info.TrackNumber != 1 {
+		t.Errorf("ReadTagInfo() = %+v", info)
+	}
+}
+
+func TestReadTagInfo_ID3v1Only(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.mp3")
+	data := append([]byte("\xff\xfbaudio frames"), id3v1Tag("Title", "Artist", "Album", "2001", "", 0)...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ReadTagInfo(path)
+	if err != nil {
+		t.Fatalf("ReadTagInfo failed: %v", err)
+	}
+	if info.Title != "Title" || info.Artist != "Artist" || info.Album != "Album" || info.Year != "2001" || info.TrackNumber != 0 {
+		t.Errorf("ReadTagInfo() = %+v", info)
+	}
+}
+
+func TestSaveTags_Pictures(t *testing.T) {
+	dir := t.TempDir()
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), &model.PathConfig{DownloadsPath: dir})
+	track := model.NewTrack(album, 1, 1, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.mp3"})
+	if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultTagConfig()
+	cfg.PictureType = id3v2.PTMedia
+	tagger := NewTagger(cfg)
+	if err := tagger.SaveTags(track, album, []byte("cover"), Picture{Type: id3v2.PTBackCover, Data: []byte("back")}); err != nil {
+		t.Fatalf("SaveTags failed: %v", err)
+	}
+	// Refreshing the artwork keeps the back cover
+	if err := tagger.SaveArtwork(track.Path, []byte("new cover")); err != nil {
+		t.Fatalf("SaveArtwork failed: %v", err)
+	}
+
+	tag, err := id3v2.Open(track.Path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	pictures := make(map[byte]string)
+	for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		pf := f.(id3v2.PictureFrame)
+		pictures[pf.PictureType] = string(pf.Picture)
+	}
+	want := map[byte]string{id3v2.PTMedia: "new cover", id3v2.PTBackCover: "back"}
+	if len(pictures) != len(want) || pictures[id3v2.PTMedia] != want[id3v2.PTMedia] || pictures[id3v2.PTBackCover] != want[id3v2.PTBackCover] {
+		t.Errorf("pictures = %q, want %q", pictures, want)
+	}
+}
@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// transcoder is the ffmpeg encoder of a transcode format and the extension
+// of its files.
+type transcoder struct {
+	encoder string
+	ext     string
+}
+
+// transcoders maps the formats of TranscodeFormats to their encoder.
+var transcoders = map[string]transcoder{
+	"aac":    {"aac", "m4a"},
+	"flac":   {"flac", "flac"},
+	"mp3":    {"libmp3lame", "mp3"},
+	"opus":   {"libopus", "opus"},
+	"vorbis": {"libvorbis", "ogg"},
+}
+
+// TranscodeFormats are the formats Transcode converts files to.
+var TranscodeFormats = []string{"aac", "flac", "mp3", "opus", "vorbis"}
+
+// TranscodeExtension returns the extension, without the dot, of the files
+// of a format of TranscodeFormats, or "" for another format.
+func TranscodeExtension(format string) string {
+	return transcoders[format].ext
+}
+
+// transcodeArgs returns the command line converting src to dest in format
+// at kbps: the words of command (the ffmpeg executable, with options of
+// its own) followed by the options of the conversion. The tags of src are
+// copied, and its pictures dropped, which not every format can hold.
+func transcodeArgs(command, format string, kbps int, src, dest string) []string {
+	args := strings.Fields(command)
+	args = append(args, "-nostdin", "-y", "-v", "error", "-i", src, "-map_metadata", "0", "-vn", "-c:a", transcoders[format].encoder)
+	if kbps > 0 && format != "flac" {
+		args = append(args, "-b:a", strconv.Itoa(kbps)+"k")
+	}
+	return append(args, dest)
+}
+
+// Transcode converts the audio file src to dest in format (see
+// TranscodeFormats) at kbps, or the encoder's default bitrate if 0, by
+// running command, e.g. "ffmpeg". The tags of src are copied, but not its
+// pictures.
+//
+// Example:
+//
+//	err := audio.Transcode(ctx, "ffmpeg", "01 Song.mp3", "01 Song.opus", "opus", 96)
+func Transcode(ctx context.Context, command, src, dest, format string, kbps int) error {
+	if _, ok := transcoders[format]; !ok {
+		return fmt.Errorf("unknown transcode format %q, must be one of %s", format, strings.Join(TranscodeFormats, ", "))
+	}
+	if strings.TrimSpace(command) == "" {
+		return errors.New("no transcode command")
+	}
+	args := transcodeArgs(command, format, kbps, src, dest)
+
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		if detail := strings.TrimSpace(string(output)); detail != "" {
+			if i := strings.LastIndex(detail, "\n"); i >= 0 {
+				detail = detail[i+1:]
+			}
+			err = fmt.Errorf("%w: %s", err, detail)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranscodeArgs(t *testing.T) {
+	got := transcodeArgs("ffmpeg -hide_banner", "opus", 96, "01 Song.mp3", "01 Song.opus")
+	want := []string{"ffmpeg", "-hide_banner", "-nostdin", "-y", "-v", "error", "-i", "01 Song.mp3", "-map_metadata", "0", "-vn", "-c:a", "libopus", "-b:a", "96k", "01 Song.opus"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transcodeArgs(opus) = %q, want %q", got, want)
+	}
+
+	// FLAC is lossless: the bitrate does not apply
+	got = transcodeArgs("ffmpeg", "flac", 96, "a.wav", "a.flac")
+	want = []string{"ffmpeg", "-nostdin", "-y", "-v", "error", "-i", "a.wav", "-map_metadata", "0", "-vn", "-c:a", "flac", "a.flac"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transcodeArgs(flac) = %q, want %q", got, want)
+	}
+}
+
+func TestTranscodeExtension(t *testing.T) {
+	for _, format := range TranscodeFormats {
+		if TranscodeExtension(format) == "" {
+			t.Errorf("TranscodeExtension(%q) = \"\"", format)
+		}
+	}
+	if got := TranscodeExtension("wav"); got != "" {
+		t.Errorf("TranscodeExtension(wav) = %q, want \"\"", got)
+	}
+}
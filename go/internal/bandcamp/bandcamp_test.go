@@ -1,6 +1,17 @@
 package bandcamp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/handiism/bandcamp-downloader/internal/model"
@@ -100,12 +111,15 @@ func TestParser_ParseAlbumPage(t *testing.T) {
 	// Use inline mock HTML since test files are discography pages, not album pages
 	mockHTML := `<html>
 	<script data-tralbum="{
-		&quot;current&quot;:{&quot;title&quot;:&quot;Test Album&quot;,&quot;release_date&quot;:&quot;01 Jan 2023 00:00:00 GMT&quot;},
+		&quot;current&quot;:{&quot;title&quot;:&quot;Test Album&quot;,&quot;release_date&quot;:&quot;01 Jan 2023 00:00:00 GMT&quot;,&quot;about&quot;:&quot;Recorded live.&quot;,&quot;credits&quot;:null},
+		&quot;id&quot;:2468013579,
 		&quot;artist&quot;:&quot;Test Artist&quot;,
 		&quot;art_id&quot;:1234567890,
 		&quot;trackinfo&quot;:[
-			{&quot;track_num&quot;:1,&quot;title&quot;:&quot;First Track&quot;,&quot;duration&quot;:180.5,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/1.mp3&quot;}},
-			{&quot;track_num&quot;:2,&quot;title&quot;:&quot;Second Track&quot;,&quot;duration&quot;:200.0,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/2.mp3&quot;}}
+			{&quot;track_num&quot;:1,&quot;title&quot;:&quot;First Track&quot;,&quot;duration&quot;:180.5,&quot;title_link&quot;:&quot;/track/first-track&quot;,&quot;has_info&quot;:true,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/1.mp3&quot;}},
+			{&quot;track_num&quot;:2,&quot;title&quot;:&quot;Second Track&quot;,&quot;duration&quot;:200.0,&quot;streaming&quot;:0,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/2.mp3&quot;}},
+			{&quot;track_num&quot;:3,&quot;title&quot;:&quot;Music Video&quot;,&quot;duration&quot;:210.0,&quot;file&quot;:null,&quot;video_source_type&quot;:&quot;youtube&quot;,&quot;video_id&quot;:123},
+			{&quot;track_num&quot;:4,&quot;title&quot;:&quot;Bonus Track&quot;,&quot;duration&quot;:190.0,&quot;file&quot;:null}
 		]
 	}"></script>
 	</html>`
@@ -133,16 +147,87 @@ func TestParser_ParseAlbumPage(t *testing.T) {
 	if album.Title != "Test Album" {
 		t.Errorf("Title = %q, want %q", album.Title, "Test Album")
 	}
+	if album.ID != 2468013579 {
+		t.Errorf("ID = %d, want %d", album.ID, 2468013579)
+	}
 	if len(album.Tracks) != 2 {
 		t.Errorf("Track count = %d, want 2", len(album.Tracks))
 	}
 	if album.Tracks[0].Title != "First Track" {
 		t.Errorf("Track[0].Title = %q, want %q", album.Tracks[0].Title, "First Track")
 	}
+	if len(album.SkippedVideos) != 1 || album.SkippedVideos[0] != "Music Video" {
+		t.Errorf("SkippedVideos = %q, want [Music Video]", album.SkippedVideos)
+	}
+	if len(album.UnavailableTracks) != 1 || album.UnavailableTracks[0] != "Bonus Track" {
+		t.Errorf("UnavailableTracks = %q, want [Bonus Track]", album.UnavailableTracks)
+	}
+	if album.About != "Recorded live." || album.Credits != "" {
+		t.Errorf("About = %q, Credits = %q", album.About, album.Credits)
+	}
+	if album.Tracks[0].URL != "/track/first-track" || !album.Tracks[0].HasInfo || album.Tracks[1].HasInfo {
+		t.Errorf("Track URL/HasInfo = %q/%v, %v", album.Tracks[0].URL, album.Tracks[0].HasInfo, album.Tracks[1].HasInfo)
+	}
+
+	if len(album.Restrictions) != 1 || album.Restrictions[0] != "streaming is disabled for 1 track(s)" {
+		t.Errorf("Restrictions = %q, want [streaming is disabled for 1 track(s)]", album.Restrictions)
+	}
 
 	t.Logf("Parsed album: %s - %s (%d tracks)", album.Artist, album.Title, len(album.Tracks))
 }
 
+func TestParser_ParseAlbumPage_Discs(t *testing.T) {
+	mockHTML := `<script data-tralbum="{
+		&quot;current&quot;:{&quot;title&quot;:&quot;Double Album&quot;},
+		&quot;artist&quot;:&quot;Artist&quot;,
+		&quot;trackinfo&quot;:[
+			{&quot;track_num&quot;:1,&quot;title&quot;:&quot;A&quot;,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/1.mp3&quot;}},
+			{&quot;track_num&quot;:2,&quot;title&quot;:&quot;B&quot;,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/2.mp3&quot;}},
+			{&quot;track_num&quot;:1,&quot;title&quot;:&quot;C&quot;,&quot;file&quot;:{&quot;mp3-128&quot;:&quot;https://example.com/3.mp3&quot;}}
+		]
+	}"></script>`
+
+	pathCfg := &model.PathConfig{DownloadsPath: "/tmp/test/{album}"}
+	tests := []struct {
+		numbering string
+		wantDiscs []int
+		wantNums  []int
+		wantName  string
+	}{
+		{model.NumberingDisc, []int{1, 1, 2}, []int{1, 2, 1}, "2-01 C.mp3"},
+		{model.NumberingSequential, []int{1, 1, 2}, []int{1, 2, 3}, "2-03 C.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.numbering, func(t *testing.T) {
+			trackCfg := &model.TrackConfig{FileNameFormat: "{disc}-{tracknum} {title}.mp3", Numbering: tt.numbering}
+			album, err := NewParser(pathCfg, trackCfg).ParseAlbumPage(mockHTML)
+			if err != nil {
+				t.Fatalf("ParseAlbumPage failed: %v", err)
+			}
+			if len(album.Tracks) != 3 {
+				t.Fatalf("Track count = %d, want 3", len(album.Tracks))
+			}
+			for i, track := range album.Tracks {
+				if track.DiscNumber != tt.wantDiscs[i] || track.Number != tt.wantNums[i] {
+					t.Errorf("track %d = disc %d, number %d, want %d, %d", i, track.DiscNumber, track.Number, tt.wantDiscs[i], tt.wantNums[i])
+				}
+			}
+			if got := filepath.Base(album.Tracks[2].Path); got != tt.wantName {
+				t.Errorf("file name = %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestParser_NoTracks(t *testing.T) {
+	html := `<script data-tralbum="{&quot;current&quot;:{&quot;title&quot;:&quot;Tote Bag Bundle&quot;},&quot;id&quot;:1,&quot;trackinfo&quot;:[]}"></script>`
+	parser := NewParser(&model.PathConfig{DownloadsPath: "/tmp/{album}"}, &model.TrackConfig{FileNameFormat: "{title}.mp3"})
+	if _, err := parser.ParseAlbumPage(html); !errors.Is(err, ErrNoTracks) {
+		t.Errorf("ParseAlbumPage error = %v, want ErrNoTracks", err)
+	}
+}
+
 func TestExtractAlbumData(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -201,3 +286,827 @@ func TestFixJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractSiteName(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "label site",
+			html: `<head><meta property="og:site_name" content="Professor Wax &amp; The Crate Diggers"></head>`,
+			want: "Professor Wax & The Crate Diggers",
+		},
+		{
+			name: "missing meta tag",
+			html: `<head><title>Album</title></head>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractSiteName(tt.html); got != tt.want {
+				t.Errorf("extractSiteName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNoIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want bool
+	}{
+		{"default bandcamp tag", `<meta name="robots" content="max-image-preview:large">`, false},
+		{"noindex", `<meta name="robots" content="noindex, nofollow">`, true},
+		{"none", `<META NAME="robots" CONTENT="None">`, true},
+		{"nofollow only", `<meta name="robots" content="nofollow">`, false},
+		{"missing meta tag", `<head><title>Album</title></head>`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNoIndex(tt.html); got != tt.want {
+				t.Errorf("isNoIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPageURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantKind string
+	}{
+		{"https://artist.bandcamp.com/album/name", ""},
+		{"https://artist.bandcamp.com/track/name", ""},
+		{"https://artist.bandcamp.com", ""},
+		{"https://artist.bandcamp.com/music", ""},
+		{"https://artist.bandcamp.com/merch", "merch"},
+		{"https://artist.bandcamp.com/merch/t-shirt", "merch"},
+		{"https://artist.bandcamp.com/community", "community"},
+		{"https://artist.bandcamp.com/video/clip", "video"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := CheckPageURL(tt.url)
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var pageErr *UnsupportedPageError
+			if !errors.As(err, &pageErr) {
+				t.Fatalf("expected *UnsupportedPageError, got %v", err)
+			}
+			if pageErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", pageErr.Kind, tt.wantKind)
+			}
+			if pageErr.SuggestedURL != "https://artist.bandcamp.com/music" {
+				t.Errorf("SuggestedURL = %q", pageErr.SuggestedURL)
+			}
+		})
+	}
+}
+
+func TestStreamMirrors(t *testing.T) {
+	streamURL := "https://t4.bcbits.com/stream/d1005929f265384d797d44e5ac5aaae9/mp3-128/2904036624?p=0&ts=1764623802&token=abc"
+
+	mirrors := StreamMirrors(streamURL)
+	want := []string{
+		"https://t1.bcbits.com/stream/d1005929f265384d797d44e5ac5aaae9/mp3-128/2904036624?p=0&ts=1764623802&token=abc",
+		"https://t2.bcbits.com/stream/d1005929f265384d797d44e5ac5aaae9/mp3-128/2904036624?p=0&ts=1764623802&token=abc",
+		"https://t3.bcbits.com/stream/d1005929f265384d797d44e5ac5aaae9/mp3-128/2904036624?p=0&ts=1764623802&token=abc",
+	}
+	if len(mirrors) != len(want) {
+		t.Fatalf("StreamMirrors = %v, want %v", mirrors, want)
+	}
+	for i := range want {
+		if mirrors[i] != want[i] {
+			t.Errorf("mirrors[%d] = %q, want %q", i, mirrors[i], want[i])
+		}
+	}
+
+	if mirrors := StreamMirrors("https://f4.bcbits.com/img/a123_10.jpg"); mirrors != nil {
+		t.Errorf("StreamMirrors(artwork) = %v, want nil", mirrors)
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{
+			name: "json-ld keywords",
+			html: `<script type="application/ld+json">
+				{"@type":"MusicAlbum","keywords":["90s Golden Age Hip-Hop","Hip-Hop/Rap","Long Branch"]}
+			</script>`,
+			want: []string{"90s Golden Age Hip-Hop", "Hip-Hop/Rap", "Long Branch"},
+		},
+		{
+			name: "json-ld keyword string",
+			html: `<script type="application/ld+json">{"keywords":"ambient, drone"}</script>`,
+			want: []string{"ambient", "drone"},
+		},
+		{
+			name: "tag links",
+			html: `<div class="tralbum-tags">
+				<a class="tag" href="https://bandcamp.com/discover/ambient?from=tralbum"
+					>ambient</a>
+				<a class="tag" href="https://bandcamp.com/discover/r-b?from=tralbum">R&amp;B</a>
+			</div>`,
+			want: []string{"ambient", "R&B"},
+		},
+		{
+			name: "no tags",
+			html: `<html></html>`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractTags(tt.html)
+			if len(got) != len(tt.want) {
+				t.Fatalf("extractTags = %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("tag[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCredits(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Credits
+	}{
+		{
+			name: "labeled lines",
+			text: "Mixed by Someone\nComposed by Jane Doe.\nPublished by Tiny Songs (ASCAP)\nISRC: US-S1Z-99-00001",
+			want: Credits{Composer: "Jane Doe", Publisher: "Tiny Songs (ASCAP)", ISRC: "USS1Z9900001"},
+		},
+		{
+			name: "colon forms",
+			text: "composer: John Roe\npublisher: Roe Music\nisrc GBAYE0601498",
+			want: Credits{Composer: "John Roe", Publisher: "Roe Music", ISRC: "GBAYE0601498"},
+		},
+		{
+			name: "words and music",
+			text: "Words and music by Ann Poe",
+			want: Credits{Composer: "Ann Poe"},
+		},
+		{
+			name: "unrelated credits",
+			text: "Mastered by Someone\nPhoto by Someone Else\nReleased by the artist",
+			want: Credits{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseCredits(tt.text); got != tt.want {
+				t.Errorf("ParseCredits = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCredits(t *testing.T) {
+	album := &model.Album{
+		Artist:  "Artist",
+		Label:   "Label",
+		Credits: "Music by Jane Doe\nISRC: USS1Z9900001",
+		Tracks:  []*model.Track{{Title: "Single"}},
+	}
+	applyCredits(album)
+	if album.Publisher != "Label" {
+		t.Errorf("Publisher = %q, want the label", album.Publisher)
+	}
+	if track := album.Tracks[0]; track.Composer != "Jane Doe" || track.ISRC != "USS1Z9900001" {
+		t.Errorf("Composer/ISRC = %q/%q", track.Composer, track.ISRC)
+	}
+
+	// The ISRC of an album does not identify any of its tracks
+	album = &model.Album{
+		Artist:  "Artist",
+		Label:   "artist",
+		Credits: "ISRC: USS1Z9900001",
+		Tracks:  []*model.Track{{Title: "One"}, {Title: "Two"}},
+	}
+	applyCredits(album)
+	if album.Publisher != "" || album.Tracks[0].ISRC != "" {
+		t.Errorf("Publisher/ISRC = %q/%q, want empty", album.Publisher, album.Tracks[0].ISRC)
+	}
+}
+
+func TestParser_LocalizedPages(t *testing.T) {
+	tests := []struct {
+		file        string
+		wantTitle   string
+		wantLabel   string
+		wantCredits string
+		wantTags    []string
+		wantLyrics  []string
+		wantSingle  bool
+	}{
+		{
+			file:        "album_de.html",
+			wantTitle:   "Beton und Seele",
+			wantLabel:   "Professor Wax & The Crate Diggers",
+			wantCredits: "Aufgenommen in Long Branch, New Jersey.",
+			wantTags:    []string{"Hip-Hop/Rap", "Boom Bap", "Long Branch"},
+			wantLyrics:  []string{"Noch ein Tag, die Stadt erwacht\nDer Beton glänzt nach der Nacht", ""},
+		},
+		{
+			file:        "track_ja.html",
+			wantTitle:   "夜明けの歌",
+			wantLabel:   "山田レコード",
+			wantCredits: "作詞・作曲：山田",
+			wantTags:    []string{"シティポップ", "東京"},
+			wantLyrics:  []string{"夜が明ける\n街が目を覚ます"},
+			wantSingle:  true,
+		},
+	}
+
+	pathCfg := &model.PathConfig{
+		DownloadsPath:          "/tmp/test/{artist}/{album}",
+		CoverArtFileNameFormat: "{album}",
+		PlaylistFileNameFormat: "{album}",
+		PlaylistFormat:         model.PlaylistFormatM3U,
+	}
+	trackCfg := &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}
+	parser := NewParser(pathCfg, trackCfg)
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			page, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			album, err := parser.ParseAlbumPage(string(page))
+			if err != nil {
+				t.Fatalf("ParseAlbumPage failed: %v", err)
+			}
+
+			if album.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", album.Title, tt.wantTitle)
+			}
+			if album.Label != tt.wantLabel {
+				t.Errorf("Label = %q, want %q", album.Label, tt.wantLabel)
+			}
+			if album.Credits != tt.wantCredits {
+				t.Errorf("Credits = %q, want %q", album.Credits, tt.wantCredits)
+			}
+			if album.Single != tt.wantSingle {
+				t.Errorf("Single = %v, want %v", album.Single, tt.wantSingle)
+			}
+			if strings.Join(album.Tags, "|") != strings.Join(tt.wantTags, "|") {
+				t.Errorf("Tags = %q, want %q", album.Tags, tt.wantTags)
+			}
+			if len(album.Tracks) != len(tt.wantLyrics) {
+				t.Fatalf("Track count = %d, want %d", len(album.Tracks), len(tt.wantLyrics))
+			}
+			for i, want := range tt.wantLyrics {
+				if got := album.Tracks[i].Lyrics; got != want {
+					t.Errorf("Tracks[%d].Lyrics = %q, want %q", i, got, want)
+				}
+			}
+			if len(album.Restrictions) != 0 {
+				t.Errorf("Restrictions = %q, want none", album.Restrictions)
+			}
+		})
+	}
+}
+
+// ldOnlyPage is an album page whose data-tralbum attribute was renamed,
+// so only its structured data can be read.
+const ldOnlyPage = `<html><head>
+<meta property="og:site_name" content="Label">
+<script type="application/ld+json">
+{"@type":"MusicAlbum","@id":"https://label.bandcamp.com/album/night","name":"Night","byArtist":{"@type":"MusicGroup","name":"Various"},
+"datePublished":"14 Mar 2021 00:00:00 GMT","description":"Late music.","keywords":["ambient"],
+"additionalProperty":[{"@type":"PropertyValue","name":"item_id","value":2468013579},{"@type":"PropertyValue","name":"art_id","value":1234567890}],
+"track":{"@type":"ItemList","itemListElement":[
+{"@type":"ListItem","position":1,"item":{"@type":"MusicRecording","@id":"https://label.bandcamp.com/track/dusk","name":"Dusk","duration":"P00H03M20S","byArtist":{"name":"Guest"},
+"additionalProperty":[{"name":"track_id","value":11},{"name":"file_mp3-128","value":"https://t4.bcbits.com/stream/a/mp3-128/11"}]}},
+{"@type":"ListItem","position":2,"item":{"@type":"MusicRecording","name":"Dawn","duration":"PT1M30.5S","byArtist":{"name":"Various"},
+"additionalProperty":[{"name":"track_id","value":12},{"name":"duration_secs","value":90.25}]}}]}}
+</script></head>
+<body><div data-tralbum-moved="{}"></div></body></html>`
+
+func TestStrategyChain(t *testing.T) {
+	chain := NewStrategyChain()
+	var results []string
+	chain.OnResult = func(strategy string, err error) {
+		results = append(results, fmt.Sprintf("%s:%v", strategy, err == nil))
+	}
+
+	page, err := chain.Read(context.Background(), ldOnlyPage)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if page.Strategy != StrategyJSONLD || !page.FellBack || strings.Join(results, ",") != "tralbum:false,json-ld:true" {
+		t.Errorf("Strategy = %q, FellBack = %v, results = %v", page.Strategy, page.FellBack, results)
+	}
+	if page, err := NewStrategyChain(JSONLDStrategy{}, TralbumStrategy{}).Read(context.Background(), ldOnlyPage); err != nil || page.FellBack {
+		t.Errorf("Read with json-ld first = %+v, %v, want read without falling back", page, err)
+	}
+
+	album := NewParser(&model.PathConfig{DownloadsPath: "/tmp/{album}"}, &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}).ToAlbum(page)
+	if album.ID != 2468013579 || album.Artist != "Various" || album.Title != "Night" || album.About != "Late music." ||
+		album.Label != "Label" || album.ArtID != 1234567890 || album.ReleaseDate.Year() != 2021 || album.Single ||
+		strings.Join(album.Tags, ",") != "ambient" {
+		t.Errorf("album = %+v", album)
+	}
+	if len(album.Tracks) != 1 || len(album.UnavailableTracks) != 1 || album.UnavailableTracks[0] != "Dawn" {
+		t.Fatalf("tracks = %v, unavailable = %q", album.Tracks, album.UnavailableTracks)
+	}
+	track := album.Tracks[0]
+	if track.ID != 11 || track.Number != 1 || track.Title != "Dusk" || track.Artist != "Guest" || track.Duration != 200 ||
+		track.Mp3URL != "https://t4.bcbits.com/stream/a/mp3-128/11" || track.URL != "/track/dusk" {
+		t.Errorf("track = %+v", track)
+	}
+
+	// Without streams in the structured data, no parser can read the page
+	noStreams := strings.ReplaceAll(ldOnlyPage, "file_mp3-128", "file_flac")
+	_, err = chain.Read(context.Background(), noStreams)
+	var perr *ParseError
+	if !errors.As(err, &perr) || len(perr.Errs) != 2 {
+		t.Errorf("Read(no streams) error = %v, want a ParseError of both parsers", err)
+	}
+
+	// A page read without tracks is not tried with the other parsers
+	results = nil
+	bundle := `<script data-tralbum="{&quot;current&quot;:{&quot;title&quot;:&quot;Bundle&quot;},&quot;trackinfo&quot;:[]}"></script>`
+	if _, err := chain.Read(context.Background(), bundle); !errors.Is(err, ErrNoTracks) || len(results) != 1 {
+		t.Errorf("Read(bundle) error = %v after %v, want ErrNoTracks from tralbum", err, results)
+	}
+
+	stats := chain.Stats()
+	if len(stats) != 2 || stats[0].Attempts != 3 || stats[0].Successes != 1 || stats[0].Failures != 2 ||
+		stats[1].Attempts != 2 || stats[1].Successes != 1 || stats[1].LastError != "the structured data has no stream URL" {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestNewStrategies(t *testing.T) {
+	strategies, err := NewStrategies("json-ld", "tralbum")
+	if err != nil || len(strategies) != 2 || strategies[0].Name() != StrategyJSONLD {
+		t.Errorf("NewStrategies() = %v, %v", strategies, err)
+	}
+	if _, err := NewStrategies("html"); err == nil {
+		t.Error("NewStrategies(html) succeeded, want an error")
+	}
+}
+
+func TestParseISODuration(t *testing.T) {
+	tests := map[string]float64{
+		"P00H03M20S": 200,
+		"PT1H2M3S":   3723,
+		"PT45.5S":    45.5,
+		"3:20":       0,
+		"":           0,
+	}
+	for in, want := range tests {
+		if got := parseISODuration(in); got != want {
+			t.Errorf("parseISODuration(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+// fakeAPIClient serves the responses of the APIs by URL, or by path
+// without the query for the mobile API.
+type fakeAPIClient struct {
+	responses map[string]string
+	requests  []string
+}
+
+func (c *fakeAPIClient) Get(_ context.Context, url string) ([]byte, error) {
+	c.requests = append(c.requests, url)
+	if resp, ok := c.responses[url]; ok {
+		return []byte(resp), nil
+	}
+	path, _, _ := strings.Cut(strings.TrimPrefix(url, MobileAPIURL), "?")
+	return []byte(c.responses[path]), nil
+}
+
+func (c *fakeAPIClient) PostJSON(ctx context.Context, url string, body any) ([]byte, error) {
+	data, _ := json.Marshal(body)
+	return c.Get(ctx, url+"?"+string(data))
+}
+
+func TestMobileStrategy(t *testing.T) {
+	client := &fakeAPIClient{responses: map[string]string{
+		"/tralbum_details": `{"id":2892251056,"type":"a","title":"Night","bandcamp_url":"https://label.bandcamp.com/album/night",
+			"art_id":1234567890,"tralbum_artist":"Various","band":{"band_id":42,"name":"Label"},"release_date":1615680000,
+			"about":"Late music.","tags":[{"name":"ambient"}],"tracks":[
+			{"track_id":11,"title":"Dusk","track_num":1,"duration":200,"streaming_url":{"mp3-128":"https://t4.bcbits.com/stream/a/mp3-128/11"},"is_streamable":true,"band_name":"Guest"},
+			{"track_id":12,"title":"Dawn","track_num":2,"duration":90,"is_streamable":false,"band_name":"Various"}]}`,
+	}}
+	api := NewMobileAPI(client, "")
+	html := `<meta name="bc-page-properties" content="{&quot;item_type&quot;:&quot;a&quot;,&quot;item_id&quot;:2892251056}">
+<div data-band="{&quot;id&quot;:42,&quot;name&quot;:&quot;Label&quot;}"></div>`
+
+	page, err := NewStrategyChain(MobileStrategy{API: api}, TralbumStrategy{}).Read(context.Background(), html)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(client.requests) != 1 || !strings.Contains(client.requests[0], "band_id=42&tralbum_id=2892251056&tralbum_type=a") {
+		t.Errorf("requests = %v", client.requests)
+	}
+	album := NewParser(&model.PathConfig{DownloadsPath: "/tmp/{album}"}, &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}).ToAlbum(page)
+	if page.Strategy != StrategyMobileAPI || album.ID != 2892251056 || album.Title != "Night" || album.Artist != "Various" ||
+		album.Label != "Label" || album.ArtID != 1234567890 || album.ReleaseDate.Format("2006-01-02") != "2021-03-14" ||
+		strings.Join(album.Tags, ",") != "ambient" {
+		t.Errorf("album = %+v", album)
+	}
+	if len(album.Tracks) != 1 || album.Tracks[0].Artist != "Guest" || album.Tracks[0].Duration != 200 ||
+		len(album.UnavailableTracks) != 1 || len(album.Restrictions) != 0 {
+		t.Errorf("tracks = %v, unavailable = %q, restrictions = %q", album.Tracks, album.UnavailableTracks, album.Restrictions)
+	}
+
+	client.responses["/tralbum_details"] = `{"error":true,"error_message":"No such tralbum"}`
+	if _, _, err := api.ReadRelease(context.Background(), PageIDs{BandID: 42, ItemID: 1, ItemType: "track"}); err == nil || !strings.Contains(err.Error(), "No such tralbum") {
+		t.Errorf("ReadRelease error = %v, want the API's error", err)
+	}
+
+	client.responses["/band_details"] = `{"id":42,"name":"Label","discography":[{"item_id":1,"item_type":"album","band_id":42,"title":"Night"}]}`
+	band, err := api.BandDetails(context.Background(), 42)
+	if err != nil || len(band.Discography) != 1 || client.requests[len(client.requests)-1] != MobileAPIURL+`/band_details?{"band_id":42}` {
+		t.Errorf("BandDetails() = %+v, %v after %v", band, err, client.requests)
+	}
+}
+
+func TestReadPageIDs(t *testing.T) {
+	music, err := os.ReadFile(filepath.Join("testdata", "mstrvlk.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := ReadPageIDs(string(music)); err != nil || ids.BandID != 2795129958 || ids.ItemID != 0 {
+		t.Errorf("ReadPageIDs(music page) = %+v, %v", ids, err)
+	}
+
+	tralbum := `<script data-tralbum="{&quot;id&quot;:7,&quot;item_type&quot;:&quot;track&quot;,&quot;current&quot;:{&quot;band_id&quot;:42}}"></script>`
+	if ids, err := ReadPageIDs(tralbum); err != nil || ids != (PageIDs{BandID: 42, ItemID: 7, ItemType: "track"}) {
+		t.Errorf("ReadPageIDs(tralbum) = %+v, %v", ids, err)
+	}
+
+	if _, err := ReadPageIDs("<html></html>"); err == nil {
+		t.Error("ReadPageIDs(no IDs) succeeded, want an error")
+	}
+}
+
+func TestSearcher(t *testing.T) {
+	client := &fakeAPIClient{responses: map[string]string{
+		SearchURL: `{"auto":{"results":[
+			{"type":"b","id":1,"name":"Night Owl","item_url_root":"https://nightowl.bandcamp.com","is_label":false},
+			{"type":"a","id":2,"name":"Night","band_name":"Various","item_url_path":"https://label.bandcamp.com/album/night"},
+			{"type":"f","id":3,"name":"a fan","item_url_root":"https://bandcamp.com/fan"},
+			{"type":"t","id":4,"name":"Dusk","band_name":"Guest","album_name":"Night","item_url_path":"https://label.bandcamp.com/track/dusk"}]}}`,
+	}}
+	searcher := NewSearcher(client, "")
+
+	results, err := searcher.Search(context.Background(), " night ", SearchAll)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	want := []SearchResult{
+		{Kind: SearchArtist, ID: 1, Name: "Night Owl", URL: "https://nightowl.bandcamp.com/music"},
+		{Kind: SearchAlbum, ID: 2, Name: "Night", Artist: "Various", URL: "https://label.bandcamp.com/album/night"},
+		{Kind: SearchTrack, ID: 4, Name: "Dusk", Artist: "Guest", Album: "Night", URL: "https://label.bandcamp.com/track/dusk"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Search = %+v, want %+v", results, want)
+	}
+	if got := results[2].String(); got != "track: Dusk by Guest (from Night)" {
+		t.Errorf("String = %q", got)
+	}
+	if !strings.Contains(client.requests[0], `"search_filter":""`) || !strings.Contains(client.requests[0], `"search_text":"night"`) {
+		t.Errorf("request = %s", client.requests[0])
+	}
+
+	results, err = searcher.Search(context.Background(), "night", SearchAlbum)
+	if err != nil || len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("Search(album) = %+v, %v", results, err)
+	}
+	if !strings.Contains(client.requests[1], `"search_filter":"a"`) {
+		t.Errorf("request = %s", client.requests[1])
+	}
+
+	if _, err := searcher.Search(context.Background(), "  ", SearchAll); err == nil {
+		t.Error("Search(empty) succeeded, want an error")
+	}
+}
+
+func TestParseSearchKind(t *testing.T) {
+	for in, want := range map[string]SearchKind{"": SearchAll, "Artist": SearchArtist, " track ": SearchTrack} {
+		if got, err := ParseSearchKind(in); err != nil || got != want {
+			t.Errorf("ParseSearchKind(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseSearchKind("fan"); err == nil {
+		t.Error("ParseSearchKind(fan) succeeded, want an error")
+	}
+}
+
+// fakeTagFeed serves pages of the tag feed, two items each.
+type fakeTagFeed struct {
+	items    []string
+	requests []map[string]any
+}
+
+func (f *fakeTagFeed) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("unexpected GET")
+}
+
+func (f *fakeTagFeed) PostJSON(_ context.Context, _ string, body any) ([]byte, error) {
+	data, _ := json.Marshal(body)
+	var req map[string]any
+	json.Unmarshal(data, &req)
+	f.requests = append(f.requests, req)
+
+	page := int(req["page"].(float64))
+	var items []string
+	for i := (page - 1) * 2; i < len(f.items) && i < page*2; i++ {
+		items = append(items, f.items[i])
+	}
+	more := page*2 < len(f.items)
+	return []byte(fmt.Sprintf(`{"ok":true,"more_available":%t,"items":[%s]}`, more, strings.Join(items, ","))), nil
+}
+
+func TestTagFeed(t *testing.T) {
+	feed := &fakeTagFeed{items: []string{
+		`{"tralbum_type":"a","tralbum_url":"https://a.bandcamp.com/album/one","title":"One","artist":"A"}`,
+		`{"tralbum_type":"t","tralbum_url":"https://b.bandcamp.com/track/two","title":"Two","artist":"B"}`,
+		`{"tralbum_type":"a","tralbum_url":"https://a.bandcamp.com/album/one","title":"One","artist":"A"}`,
+		`{"tralbum_type":"a","tralbum_url":"https://c.bandcamp.com/album/three","title":"Three","artist":"C"}`,
+		`{"tralbum_type":"a","tralbum_url":"https://d.bandcamp.com/album/four","title":"Four","artist":"D"}`,
+	}}
+
+	releases, err := NewTagFeed(feed, "").Releases(context.Background(), "ambient", TagSortNew, 3)
+	if err != nil {
+		t.Fatalf("Releases failed: %v", err)
+	}
+	want := []FeedRelease{
+		{URL: "https://a.bandcamp.com/album/one", Title: "One", Artist: "A", ItemType: "album"},
+		{URL: "https://b.bandcamp.com/track/two", Title: "Two", Artist: "B", ItemType: "track"},
+		{URL: "https://c.bandcamp.com/album/three", Title: "Three", Artist: "C", ItemType: "album"},
+	}
+	if !reflect.DeepEqual(releases, want) {
+		t.Errorf("Releases = %+v, want %+v", releases, want)
+	}
+	if len(feed.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(feed.requests))
+	}
+	filters := feed.requests[0]["filters"].(map[string]any)
+	if filters["sort"] != "date" || !reflect.DeepEqual(filters["tags"], []any{"ambient"}) {
+		t.Errorf("filters = %v", filters)
+	}
+
+	// All pages are read if needed, and no more
+	feed.requests = nil
+	releases, err = NewTagFeed(feed, "").Releases(context.Background(), "ambient", "", 10)
+	if err != nil || len(releases) != 4 || len(feed.requests) != 3 {
+		t.Errorf("Releases = %d releases in %d requests, %v; want 4 in 3", len(releases), len(feed.requests), err)
+	}
+	if sort := feed.requests[0]["filters"].(map[string]any)["sort"]; sort != "pop" {
+		t.Errorf("default sort = %v, want pop", sort)
+	}
+
+	if _, err := NewTagFeed(&fakeTagFeed{}, "").Releases(context.Background(), "none", "", 10); !errors.Is(err, ErrNoAlbumFound) {
+		t.Errorf("Releases(empty) error = %v, want ErrNoAlbumFound", err)
+	}
+	if _, err := NewTagFeed(feed, "").Releases(context.Background(), "ambient", "oldest", 10); err == nil {
+		t.Error("Releases(unknown sort) succeeded, want an error")
+	}
+}
+
+func TestTagFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://bandcamp.com/tag/ambient":               "ambient",
+		"https://bandcamp.com/tag/Dark-Ambient/?tab=all": "dark-ambient",
+		"https://www.bandcamp.com/tag/jazz":              "jazz",
+		"https://artist.bandcamp.com/tag/ambient":        "",
+		"https://bandcamp.com/tag/":                      "",
+		"https://bandcamp.com/discover/ambient":          "",
+		"https://artist.bandcamp.com/album/tag":          "",
+	}
+	for in, want := range tests {
+		tag, ok := TagFromURL(in)
+		if tag != want || ok != (want != "") {
+			t.Errorf("TagFromURL(%q) = %q, %v, want %q", in, tag, ok, want)
+		}
+	}
+}
+
+func TestDiscoverFeed(t *testing.T) {
+	client := &fakeAPIClient{responses: map[string]string{}}
+	pages := []string{
+		`{"items":[
+			{"type":"a","primary_text":"One","secondary_text":"A","url_hints":{"subdomain":"a","slug":"one","item_type":"a"}},
+			{"type":"t","primary_text":"Two","secondary_text":"B","url_hints":{"subdomain":"b","custom_domain":"music.b.com","slug":"two","item_type":"t"}},
+			{"type":"a","primary_text":"Merch","url_hints":{"subdomain":"c","slug":"","item_type":"a"}}]}`,
+		`{"items":[
+			{"type":"a","primary_text":"One","secondary_text":"A","url_hints":{"subdomain":"a","slug":"one","item_type":"a"}},
+			{"type":"a","primary_text":"Three","secondary_text":"C","url_hints":{"subdomain":"c","slug":"three","item_type":"a"}}]}`,
+		`{"items":[]}`,
+	}
+	for i, page := range pages {
+		query := url.Values{"f": {"vinyl"}, "g": {"electronic"}, "gn": {"0"}, "p": {strconv.Itoa(i)}, "s": {"top"}, "t": {"house"}, "w": {"0"}}
+		client.responses[DiscoverFeedURL+"?"+query.Encode()] = page
+	}
+	feed := NewDiscoverFeed(client, "")
+	q := DiscoverQuery{Genre: "electronic", SubGenre: "house", Format: "vinyl"}
+
+	releases, err := feed.Releases(context.Background(), q, 10)
+	if err != nil {
+		t.Fatalf("Releases failed: %v", err)
+	}
+	want := []FeedRelease{
+		{URL: "https://a.bandcamp.com/album/one", Title: "One", Artist: "A", ItemType: "album"},
+		{URL: "https://music.b.com/track/two", Title: "Two", Artist: "B", ItemType: "track"},
+		{URL: "https://c.bandcamp.com/album/three", Title: "Three", Artist: "C", ItemType: "album"},
+	}
+	if !reflect.DeepEqual(releases, want) {
+		t.Errorf("Releases = %+v, want %+v", releases, want)
+	}
+	if len(client.requests) != 3 {
+		t.Errorf("requests = %d, want 3", len(client.requests))
+	}
+
+	client.requests = nil
+	if releases, err := feed.Releases(context.Background(), q, 2); err != nil || len(releases) != 2 || len(client.requests) != 1 {
+		t.Errorf("Releases(limit 2) = %d releases in %d requests, %v", len(releases), len(client.requests), err)
+	}
+	if _, err := feed.Releases(context.Background(), DiscoverQuery{Genre: "rock", Format: "8-track"}, 10); err == nil {
+		t.Error("Releases(unknown format) succeeded, want an error")
+	}
+}
+
+func TestDiscoverFromURL(t *testing.T) {
+	tests := map[string]*DiscoverQuery{
+		"https://bandcamp.com/discover/electronic/house?format=Vinyl": {Genre: "electronic", SubGenre: "house", Format: "vinyl"},
+		"https://bandcamp.com/discover/jazz/":                         {Genre: "jazz"},
+		"https://bandcamp.com/discover":                               {Genre: "all"},
+		"https://bandcamp.com/discovery":                              nil,
+		"https://bandcamp.com/discover/a/b/c":                         nil,
+		"https://artist.bandcamp.com/discover/jazz":                   nil,
+	}
+	for in, want := range tests {
+		q, ok := DiscoverFromURL(in)
+		if ok != (want != nil) || (want != nil && q != *want) {
+			t.Errorf("DiscoverFromURL(%q) = %+v, %v, want %+v", in, q, ok, want)
+		}
+	}
+
+	q, err := ParseDiscoverQuery("Electronic/House")
+	if err != nil {
+		t.Fatalf("ParseDiscoverQuery failed: %v", err)
+	}
+	q.Format = "cassette"
+	if got, ok := DiscoverFromURL(q.URL()); !ok || got != q {
+		t.Errorf("DiscoverFromURL(%s) = %+v, want %+v", q.URL(), got, q)
+	}
+	if _, err := ParseDiscoverQuery(""); err == nil {
+		t.Error("ParseDiscoverQuery(\"\") succeeded, want an error")
+	}
+}
+
+func TestCodeFromInput(t *testing.T) {
+	tests := map[string]string{
+		"ab3d-9xyz":   "ab3d-9xyz",
+		" AB3D-9XYZ ": "ab3d-9xyz",
+		"https://bandcamp.com/yum?code=ab3d-9xyz":     "ab3d-9xyz",
+		"https://bandcamp.com/yum/?code=AB3D-9XYZ":    "ab3d-9xyz",
+		"https://bandcamp.com/yum":                    "",
+		"ab3d-9xyz-1":                                 "",
+		"https://artist.bandcamp.com/album/ab3d-9xyz": "",
+	}
+	for in, want := range tests {
+		code, ok := CodeFromInput(in)
+		if code != want || ok != (want != "") {
+			t.Errorf("CodeFromInput(%q) = %q, %v, want %q", in, code, ok, want)
+		}
+	}
+
+	if !IsDownloadPageURL("https://bandcamp.com/download?id=1&sig=abc") || IsDownloadPageURL("https://artist.bandcamp.com/download") {
+		t.Error("IsDownloadPageURL mismatch")
+	}
+	var pageErr *DownloadPageError
+	if err := CheckPageURL("https://bandcamp.com/yum?code=ab3d-9xyz"); !errors.As(err, &pageErr) {
+		t.Errorf("CheckPageURL(yum) = %v, want a *DownloadPageError", err)
+	}
+}
+
+func TestRedeemer(t *testing.T) {
+	client := &fakeAPIClient{responses: map[string]string{
+		CodeVerifyURL: `{"ok":true,"redirect_url":"https://bandcamp.com/download?id=1&sig=abc"}`,
+	}}
+	redeemer := NewRedeemer(client, "")
+
+	pageURL, err := redeemer.Redeem(context.Background(), "ab3d-9xyz")
+	if err != nil || pageURL != "https://bandcamp.com/download?id=1&sig=abc" {
+		t.Errorf("Redeem = %q, %v", pageURL, err)
+	}
+	if len(client.requests) != 1 || !strings.Contains(client.requests[0], `"code":"ab3d-9xyz"`) {
+		t.Errorf("requests = %v", client.requests)
+	}
+
+	client.responses[CodeVerifyURL] = `{"ok":false,"errors":[{"reason":"notfound"}]}`
+	if _, err := redeemer.Redeem(context.Background(), "ab3d-9xyz"); !errors.Is(err, ErrCodeRejected) || !strings.Contains(err.Error(), "notfound") {
+		t.Errorf("Redeem(rejected) = %v, want ErrCodeRejected", err)
+	}
+}
+
+func TestReadDownloadPage(t *testing.T) {
+	blob := `{"digital_items":[{"title":"Night","artist":"Various","type":"a","downloads":{` +
+		`"vorbis":{"url":"https://popplers5.bandcamp.com/download/album?enc=vorbis&id=1","size_mb":"80.1MB"},` +
+		`"flac":{"url":"https://popplers5.bandcamp.com/download/album?enc=flac&id=1","size_mb":"312.4MB"},` +
+		`"mp3-320":{"url":"https://popplers5.bandcamp.com/download/album?enc=mp3-320&id=1","size_mb":"120MB"},` +
+		`"opus":{"url":"https://popplers5.bandcamp.com/download/album?enc=opus&id=1","size_mb":"60MB"}}}]}`
+	htmlContent := `<div id="pagedata" data-blob="` + html.EscapeString(blob) + `"></div>`
+
+	page, err := ReadDownloadPage(htmlContent)
+	if err != nil {
+		t.Fatalf("ReadDownloadPage failed: %v", err)
+	}
+	if page.Title != "Night" || page.Artist != "Various" || page.ItemType != "album" || page.Formats["flac"].Size != "312.4MB" {
+		t.Errorf("page = %+v", page)
+	}
+	if got := strings.Join(page.FormatNames(), ","); got != "flac,mp3-320,vorbis,opus" {
+		t.Errorf("FormatNames = %s", got)
+	}
+	if page.Extension("flac") != ".zip" {
+		t.Errorf("Extension(flac) = %s, want .zip for an album", page.Extension("flac"))
+	}
+	page.ItemType = "track"
+	if page.Extension("alac") != ".m4a" {
+		t.Errorf("Extension(alac) = %s, want .m4a", page.Extension("alac"))
+	}
+
+	if _, err := ReadDownloadPage(`<div id="pagedata" data-blob="{&quot;digital_items&quot;:[]}"></div>`); err == nil {
+		t.Error("ReadDownloadPage(no release) succeeded, want an error")
+	}
+}
+
+func TestPrepareDownload(t *testing.T) {
+	formatURL := "https://popplers5.bandcamp.com/download/album?enc=flac&id=1"
+	statURL := "https://popplers5.bandcamp.com/statdownload/album?.vrs=1&enc=flac&id=1"
+	client := &fakeAPIClient{responses: map[string]string{
+		statURL: `{"result":"ok","download_url":"https://p4.bcbits.com/download/album/1/flac.zip"}`,
+	}}
+
+	fileURL, err := PrepareDownload(context.Background(), client, formatURL)
+	if err != nil || fileURL != "https://p4.bcbits.com/download/album/1/flac.zip" {
+		t.Errorf("PrepareDownload = %q, %v", fileURL, err)
+	}
+
+	client.responses[statURL] = `{"result":"err","errortext":"expired"}`
+	if _, err := PrepareDownload(context.Background(), client, formatURL); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("PrepareDownload(err) = %v, want the error text", err)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	client := &fakeAPIClient{responses: map[string]string{
+		CollectionURL + "/fan/2/collection_summary": `{"fan_id":42}`,
+		CollectionURL + "/fancollection/1/collection_items": `{"items":[` +
+			`{"tralbum_type":"a","tralbum_id":1,"item_url":"https://artist.bandcamp.com/album/night","sale_item_type":"p","sale_item_id":10},` +
+			`{"tralbum_type":"t","tralbum_id":2,"item_url":"https://artist.bandcamp.com/track/day","sale_item_type":"r","sale_item_id":20}],` +
+			`"redownload_urls":{"p10":"https://bandcamp.com/download?id=10&sig=abc"},"more_available":false}`,
+	}}
+
+	purchases, err := NewCollection(client, "").Purchases(context.Background())
+	if err != nil {
+		t.Fatalf("Purchases failed: %v", err)
+	}
+	want := []Purchase{
+		{ItemType: "album", ItemID: 1, URL: "https://artist.bandcamp.com/album/night", DownloadPageURL: "https://bandcamp.com/download?id=10&sig=abc"},
+		{ItemType: "track", ItemID: 2, URL: "https://artist.bandcamp.com/track/day"},
+	}
+	if !reflect.DeepEqual(purchases, want) {
+		t.Errorf("Purchases = %+v, want %+v", purchases, want)
+	}
+	if len(client.requests) != 2 || !strings.Contains(client.requests[1], `"fan_id":42`) {
+		t.Errorf("requests = %v", client.requests)
+	}
+
+	client.responses[CollectionURL+"/fan/2/collection_summary"] = `{}`
+	if _, err := NewCollection(client, "").Purchases(context.Background()); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("Purchases without a session = %v, want ErrNotLoggedIn", err)
+	}
+}
@@ -0,0 +1,126 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CollectionURL is the base URL of the APIs of the collection of a fan
+// account, the releases it bought.
+const CollectionURL = "https://bandcamp.com/api"
+
+// collectionPageSize is the number of items asked for per page of the
+// collection.
+const collectionPageSize = 100
+
+// ErrNotLoggedIn is returned by Collection.Purchases when the requests
+// are not sent with the session of a logged-in account.
+var ErrNotLoggedIn = errors.New("not logged in to a Bandcamp account")
+
+// Purchase is a release of the collection of an account, with the page
+// its downloads are on.
+type Purchase struct {
+	// ItemType is "album" or "track".
+	ItemType string
+	ItemID   int64
+
+	// URL is the page of the release.
+	URL string
+
+	// DownloadPageURL is the download page of the release (see
+	// ReadDownloadPage), "" if the account has none for it, e.g. for a
+	// release it follows without having bought it.
+	DownloadPageURL string
+}
+
+// Collection reads the purchases of the account logged in: the client
+// must send the cookies of its session.
+//
+// Example:
+//
+//	purchases, err := bandcamp.NewCollection(client, "").Purchases(ctx)
+//	for _, p := range purchases {
+//	    html, err := client.GetString(ctx, p.DownloadPageURL)
+//	    page, err := bandcamp.ReadDownloadPage(html)
+//	}
+type Collection struct {
+	client  APIClient
+	baseURL string
+}
+
+// NewCollection returns a client of the collection APIs at baseURL, or
+// at CollectionURL if baseURL is "", making its requests with client.
+func NewCollection(client APIClient, baseURL string) *Collection {
+	if baseURL == "" {
+		baseURL = CollectionURL
+	}
+	return &Collection{client: client, baseURL: baseURL}
+}
+
+// collectionSummary is the response of the summary of the account
+// logged in.
+type collectionSummary struct {
+	FanID int64 `json:"fan_id"`
+}
+
+// collectionItems is a page of the items of a collection.
+type collectionItems struct {
+	Items []struct {
+		TralbumType  string `json:"tralbum_type"` // "a" or "t"
+		TralbumID    int64  `json:"tralbum_id"`
+		ItemURL      string `json:"item_url"`
+		SaleItemType string `json:"sale_item_type"`
+		SaleItemID   int64  `json:"sale_item_id"`
+	} `json:"items"`
+
+	// RedownloadURLs are the download pages of the items, keyed by
+	// sale item type and ID, e.g. "p123".
+	RedownloadURLs map[string]string `json:"redownload_urls"`
+	MoreAvailable  bool              `json:"more_available"`
+	LastToken      string            `json:"last_token"`
+}
+
+// Purchases returns the releases of the collection of the account logged
+// in, newest first. Returns ErrNotLoggedIn without a session.
+func (c *Collection) Purchases(ctx context.Context) ([]Purchase, error) {
+	data, err := c.client.Get(ctx, c.baseURL+"/fan/2/collection_summary")
+	if err != nil {
+		return nil, err
+	}
+	var summary collectionSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("invalid collection summary: %w", err)
+	}
+	if summary.FanID == 0 {
+		return nil, ErrNotLoggedIn
+	}
+
+	var purchases []Purchase
+	token := fmt.Sprintf("%d::a::", time.Now().Unix())
+	for {
+		body := map[string]any{"fan_id": summary.FanID, "older_than_token": token, "count": collectionPageSize}
+		data, err := c.client.PostJSON(ctx, c.baseURL+"/fancollection/1/collection_items", body)
+		if err != nil {
+			return nil, err
+		}
+		var page collectionItems
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("invalid collection items: %w", err)
+		}
+		for _, item := range page.Items {
+			purchase := Purchase{ItemType: "album", ItemID: item.TralbumID, URL: item.ItemURL}
+			if item.TralbumType == "t" {
+				purchase.ItemType = "track"
+			}
+			purchase.DownloadPageURL = page.RedownloadURLs[fmt.Sprintf("%s%d", item.SaleItemType, item.SaleItemID)]
+			purchases = append(purchases, purchase)
+		}
+		if !page.MoreAvailable || page.LastToken == "" || page.LastToken == token {
+			return purchases, nil
+		}
+		token = page.LastToken
+	}
+}
@@ -0,0 +1,80 @@
+package bandcamp
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// Credits holds the tag values found in the free-form credits of a release
+// or track by ParseCredits. Empty fields were not found.
+type Credits struct {
+	Composer  string
+	Publisher string
+
+	// ISRC is the International Standard Recording Code, without hyphens.
+	ISRC string
+}
+
+var (
+	// composerPattern matches lines like "Composed by X", "Music by X",
+	// "Written and composed by X", "Words and music by X" or "Composer: X".
+	composerPattern = regexp.MustCompile(`(?im)^[ \t]*(?:(?:words|lyrics)[ \t]+(?:and|&)[ \t]+)?(?:music|written|composed|composers?)(?:[ \t]+(?:and|&)[ \t]+(?:lyrics|words|composed|arranged))?(?:[ \t]+by[ \t]*:?|[ \t]*:)[ \t]*(.+)$`)
+
+	// publisherPattern matches lines like "Published by X" or "Publisher: X".
+	publisherPattern = regexp.MustCompile(`(?im)^[ \t]*(?:published[ \t]+by[ \t]*:?|publish(?:er|ing)[ \t]*:)[ \t]*(.+)$`)
+
+	// isrcPattern matches an ISRC after the "ISRC" label, with or without
+	// hyphens, e.g. "ISRC: US-S1Z-99-00001".
+	isrcPattern = regexp.MustCompile(`(?i)\bISRC\b[ \t:#]*([A-Z]{2}-?[A-Z0-9]{3}-?[0-9]{2}-?[0-9]{5})\b`)
+)
+
+// ParseCredits looks for the composer, publisher and ISRC in credits text.
+// Credits are written freely by artists, in any language, so only the
+// common English forms are recognized.
+//
+// Example:
+//
+//	c := bandcamp.ParseCredits("Composed by Jane Doe\nISRC: US-S1Z-99-00001")
+//	// c.Composer = "Jane Doe", c.ISRC = "USS1Z9900001"
+func ParseCredits(text string) Credits {
+	var c Credits
+	if m := composerPattern.FindStringSubmatch(text); m != nil {
+		c.Composer = creditValue(m[1])
+	}
+	if m := publisherPattern.FindStringSubmatch(text); m != nil {
+		c.Publisher = creditValue(m[1])
+	}
+	if m := isrcPattern.FindStringSubmatch(text); m != nil {
+		c.ISRC = strings.ToUpper(strings.ReplaceAll(m[1], "-", ""))
+	}
+	return c
+}
+
+// creditValue trims the punctuation ending a credit line.
+func creditValue(s string) string {
+	return strings.TrimRight(strings.TrimSpace(s), ".,;")
+}
+
+// applyCredits sets the publisher of album and the composer and ISRC of
+// its tracks from its credits. The album's composer applies to every track,
+// its ISRC only to a single track. Without a publisher in the credits, the
+// label is the publisher of releases on a label's site.
+func applyCredits(album *model.Album) {
+	c := ParseCredits(album.Credits)
+
+	album.Publisher = c.Publisher
+	if album.Publisher == "" && album.Label != "" && !strings.EqualFold(album.Label, album.Artist) {
+		album.Publisher = album.Label
+	}
+
+	for _, track := range album.Tracks {
+		if track.Composer == "" {
+			track.Composer = c.Composer
+		}
+	}
+	if len(album.Tracks) == 1 && album.Tracks[0].ISRC == "" {
+		album.Tracks[0].ISRC = c.ISRC
+	}
+}
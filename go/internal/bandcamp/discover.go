@@ -0,0 +1,210 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DiscoverFeedURL is the URL of the feed of Bandcamp Discover, listing the
+// best-selling releases of a genre.
+const DiscoverFeedURL = "https://bandcamp.com/api/discover/3/get_web"
+
+// DiscoverFormats are the formats Discover can be restricted to; "all"
+// lists the releases of any format.
+var DiscoverFormats = []string{"all", "digital", "vinyl", "cd", "cassette"}
+
+// CheckDiscoverFormat returns an error if format is not one of
+// DiscoverFormats; "" is "all".
+func CheckDiscoverFormat(format string) error {
+	if format == "" || slices.Contains(DiscoverFormats, format) {
+		return nil
+	}
+	return fmt.Errorf("unknown discover format %q, must be one of %s", format, strings.Join(DiscoverFormats, ", "))
+}
+
+// DiscoverQuery selects the releases of Discover: those of Genre ("all"
+// for any), of its SubGenre if not "", in Format (see DiscoverFormats) if
+// not "".
+type DiscoverQuery struct {
+	Genre    string
+	SubGenre string
+	Format   string
+}
+
+// ParseDiscoverQuery parses a query written "genre[/sub-genre]", e.g.
+// "electronic/house".
+func ParseDiscoverQuery(s string) (DiscoverQuery, error) {
+	genre, subGenre, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "/")
+	if genre == "" || strings.Contains(subGenre, "/") {
+		return DiscoverQuery{}, fmt.Errorf("invalid discover query %q, must be genre or genre/sub-genre", s)
+	}
+	return DiscoverQuery{Genre: genre, SubGenre: subGenre}, nil
+}
+
+// URL returns the URL of the Discover page of the query, which
+// DiscoverFromURL reads back.
+func (q DiscoverQuery) URL() string {
+	u := url.URL{Scheme: "https", Host: "bandcamp.com", Path: "/discover/" + q.Genre}
+	if q.SubGenre != "" {
+		u.Path += "/" + q.SubGenre
+	}
+	if q.Format != "" {
+		u.RawQuery = url.Values{"format": {q.Format}}.Encode()
+	}
+	return u.String()
+}
+
+// DiscoverFromURL returns the query of a Discover page URL, e.g.
+// https://bandcamp.com/discover/electronic/house?format=vinyl, and
+// whether rawURL is one. https://bandcamp.com/discover is the query of
+// all genres.
+func DiscoverFromURL(rawURL string) (DiscoverQuery, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return DiscoverQuery{}, false
+	}
+	if host := strings.ToLower(u.Hostname()); host != "bandcamp.com" && host != "www.bandcamp.com" {
+		return DiscoverQuery{}, false
+	}
+	path := strings.Trim(u.Path, "/")
+	rest, ok := strings.CutPrefix(path, "discover")
+	if !ok || (rest != "" && rest[0] != '/') {
+		return DiscoverQuery{}, false
+	}
+	rest = strings.TrimPrefix(rest, "/")
+	if rest == "" {
+		rest = "all"
+	}
+	q, err := ParseDiscoverQuery(rest)
+	if err != nil {
+		return DiscoverQuery{}, false
+	}
+	q.Format = strings.ToLower(u.Query().Get("format"))
+	return q, true
+}
+
+// DiscoverFeed lists the best-selling releases of a genre from the feed of
+// Bandcamp Discover.
+//
+// Example:
+//
+//	feed := bandcamp.NewDiscoverFeed(client, "")
+//	releases, err := feed.Releases(ctx, bandcamp.DiscoverQuery{Genre: "electronic", SubGenre: "house", Format: "vinyl"}, 20)
+type DiscoverFeed struct {
+	client APIClient
+	url    string
+}
+
+// NewDiscoverFeed returns a client of the Discover feed at url, or at
+// DiscoverFeedURL if url is "", making its requests with client.
+func NewDiscoverFeed(client APIClient, url string) *DiscoverFeed {
+	if url == "" {
+		url = DiscoverFeedURL
+	}
+	return &DiscoverFeed{client: client, url: url}
+}
+
+// discoverFeedPage is a page of the Discover feed.
+type discoverFeedPage struct {
+	Error        bool   `json:"error"`
+	ErrorMessage string `json:"error_message"`
+	Items        []struct {
+		Type          string `json:"type"`           // "a" or "t"
+		PrimaryText   string `json:"primary_text"`   // title
+		SecondaryText string `json:"secondary_text"` // artist
+		URLHints      struct {
+			Subdomain    string `json:"subdomain"`
+			CustomDomain string `json:"custom_domain"`
+			Slug         string `json:"slug"`
+			ItemType     string `json:"item_type"`
+		} `json:"url_hints"`
+	} `json:"items"`
+}
+
+// Releases returns the first limit best-selling releases of the query,
+// reading as many pages of the feed as needed. Returns ErrNoAlbumFound if
+// the query has no release.
+func (f *DiscoverFeed) Releases(ctx context.Context, q DiscoverQuery, limit int) ([]FeedRelease, error) {
+	if err := CheckDiscoverFormat(q.Format); err != nil {
+		return nil, err
+	}
+	genre, format := q.Genre, q.Format
+	if genre == "" {
+		genre = "all"
+	}
+	if format == "" {
+		format = "all"
+	}
+
+	var releases []FeedRelease
+	seen := make(map[string]bool)
+	for page := 0; limit <= 0 || len(releases) < limit; page++ {
+		query := url.Values{
+			"g":  {genre},
+			"s":  {"top"},
+			"p":  {strconv.Itoa(page)},
+			"gn": {"0"},
+			"f":  {format},
+			"w":  {"0"},
+		}
+		if q.SubGenre != "" {
+			query.Set("t", q.SubGenre)
+		}
+		data, err := f.client.Get(ctx, f.url+"?"+query.Encode())
+		if err != nil {
+			return releases, err
+		}
+		var resp discoverFeedPage
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return releases, fmt.Errorf("invalid discover feed response: %w", err)
+		}
+		if resp.Error {
+			if resp.ErrorMessage == "" {
+				resp.ErrorMessage = "unknown error"
+			}
+			return releases, fmt.Errorf("discover feed error: %s", resp.ErrorMessage)
+		}
+		added := 0
+		for _, item := range resp.Items {
+			hints := item.URLHints
+			host := hints.CustomDomain
+			if host == "" && hints.Subdomain != "" {
+				host = hints.Subdomain + ".bandcamp.com"
+			}
+			itemType, err := mobileItemType(hints.ItemType)
+			if err != nil {
+				itemType, err = mobileItemType(item.Type)
+			}
+			if host == "" || hints.Slug == "" || err != nil {
+				continue
+			}
+			release := FeedRelease{Title: item.PrimaryText, Artist: item.SecondaryText, ItemType: "album"}
+			if itemType == "t" {
+				release.ItemType = "track"
+			}
+			release.URL = "https://" + host + "/" + release.ItemType + "/" + hints.Slug
+			if seen[release.URL] {
+				continue
+			}
+			seen[release.URL] = true
+			releases = append(releases, release)
+			added++
+			if len(releases) == limit {
+				break
+			}
+		}
+		// The last page is empty, or repeats the previous ones
+		if added == 0 {
+			break
+		}
+	}
+	if len(releases) == 0 {
+		return nil, ErrNoAlbumFound
+	}
+	return releases, nil
+}
@@ -17,6 +17,82 @@
 //	}
 //	fmt.Printf("Album: %s by %s\n", album.Title, album.Artist)
 //
+// Parsing only relies on structured data (the data-tralbum JSON, the
+// JSON-LD and meta tags), never on visible labels or the layout of the
+// lyrics and credits sections, so pages displayed in any language parse
+// the same.
+//
+// ParseAlbumPage is ReadAlbumPage, which reads the page into an AlbumPage
+// that can be cached as JSON, followed by ToAlbum, which applies the path
+// settings:
+//
+//	page, err := bandcamp.ReadAlbumPage(htmlContent)
+//	// ... later, maybe with other settings
+//	album := parser.ToAlbum(page)
+//
+// # Page Parsers
+//
+// ReadAlbumPage tries the strategies of a StrategyChain in turn: the
+// data-tralbum JSON (TralbumStrategy), then the JSON-LD structured data
+// (JSONLDStrategy). A change of Bandcamp's markup breaking one of them
+// does not break parsing, and the chain's statistics tell which one fails:
+//
+//	chain := bandcamp.NewStrategyChain(bandcamp.TralbumStrategy{}, bandcamp.JSONLDStrategy{})
+//	page, err := chain.Read(ctx, htmlContent)
+//	for _, s := range chain.Stats() {
+//	    fmt.Println(s.Name, s.Successes, s.Failures, s.LastError)
+//	}
+//
+// # Mobile API
+//
+// MobileAPI is a client of the JSON API of Bandcamp's mobile app, serving
+// accounts (BandDetails) and releases (TralbumDetails) by ID, which
+// ReadPageIDs finds on their pages. MobileStrategy is a Strategy reading
+// releases from it:
+//
+//	api := bandcamp.NewMobileAPI(httpClient, "")
+//	chain := bandcamp.NewStrategyChain(bandcamp.MobileStrategy{API: api}, bandcamp.TralbumStrategy{})
+//
+// # Search
+//
+// Searcher finds artists, albums and tracks by name with the search API of
+// Bandcamp's search box; the URLs of its results are those of their pages:
+//
+//	results, err := bandcamp.NewSearcher(httpClient, "").Search(ctx, "query", bandcamp.SearchAlbum)
+//
+// # Tag Pages
+//
+// TagFeed lists the releases of a tag page (bandcamp.com/tag/...), whose
+// tag TagFromURL reads, from the paginated feed the page loads:
+//
+//	if tag, ok := bandcamp.TagFromURL(url); ok {
+//	    releases, err := bandcamp.NewTagFeed(httpClient, "").Releases(ctx, tag, bandcamp.TagSortPopular, 50)
+//	}
+//
+// # Discover
+//
+// DiscoverFeed lists the best-selling releases of a genre, sub-genre and
+// format of Bandcamp Discover, whose Discover page URLs DiscoverFromURL
+// reads:
+//
+//	releases, err := bandcamp.NewDiscoverFeed(httpClient, "").Releases(ctx, bandcamp.DiscoverQuery{Genre: "electronic", SubGenre: "house"}, 20)
+//
+// # Download Codes
+//
+// Redeemer redeems a download code (CodeFromInput reads it from a
+// bandcamp.com/yum URL) to the logged-in account of the client's cookies,
+// leading to a download page. ReadDownloadPage reads that page, and
+// PrepareDownload the URL of the file of a format:
+//
+//	pageURL, err := bandcamp.NewRedeemer(httpClient, "").Redeem(ctx, code)
+//	page, err := bandcamp.ReadDownloadPage(pageHTML)
+//	fileURL, err := bandcamp.PrepareDownload(ctx, httpClient, page.Formats["flac"].URL)
+//
+// The releases bought by the account have download pages too, which
+// Collection lists along with their IDs and URLs:
+//
+//	purchases, err := bandcamp.NewCollection(httpClient, "").Purchases(ctx)
+//
 // # Discography Extraction
 //
 // Use Discography to find all album URLs from an artist's music page:
@@ -30,6 +106,26 @@
 //	    fmt.Println(url) // e.g., "/album/my-album"
 //	}
 //
+// # Non-Music Pages
+//
+// CheckPageURL detects merch, community, video and live stream URLs and
+// returns an *UnsupportedPageError suggesting the artist's music page, or a
+// *DownloadPageError for download codes and download pages:
+//
+//	if err := bandcamp.CheckPageURL(url); err != nil {
+//	    log.Fatal(err) // "... is a merch page, not a music page; try https://artist.bandcamp.com/music instead"
+//	}
+//
+// # Stream Hosts
+//
+// mp3-128 streams are served from numbered hosts (t1.bcbits.com to
+// t4.bcbits.com) that accept the same paths and tokens. StreamMirrors
+// returns the alternates of a stream URL, to try when one host refuses:
+//
+//	for _, mirror := range bandcamp.StreamMirrors(track.Mp3URL) {
+//	    // retry the download against mirror
+//	}
+//
 // # Bandcamp Data Format
 //
 // Bandcamp embeds album data as JSON in the HTML page within a
@@ -3,6 +3,7 @@ package dto
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/handiism/bandcamp-downloader/internal/model"
@@ -50,26 +51,52 @@ func (bt *BandcampTime) UnmarshalJSON(data []byte) error {
 
 // JSONAlbum represents the deserialized album data from Bandcamp's HTML.
 type JSONAlbum struct {
+	ID          int64          `json:"id"`
 	AlbumData   *JSONAlbumData `json:"current"`
 	ArtID       *int64         `json:"art_id"`
 	Artist      string         `json:"artist"`
 	ReleaseDate *BandcampTime  `json:"album_release_date"`
 	Tracks      []JSONTrack    `json:"trackinfo"`
+
+	// ItemType is "album" or "track" (a single), as is the type of
+	// AlbumData.
+	ItemType string `json:"item_type"`
+
+	// Label, Tags and NoIndex are not part of the tralbum JSON; the parser
+	// fills them from the page's site name, structured data and robots meta
+	// tag before conversion.
+	Label   string   `json:"-"`
+	Tags    []string `json:"-"`
+	NoIndex bool     `json:"-"`
 }
 
 // JSONAlbumData contains album metadata.
 type JSONAlbumData struct {
 	AlbumTitle  string        `json:"title"`
+	About       string        `json:"about"`
+	Credits     string        `json:"credits"`
 	ReleaseDate *BandcampTime `json:"release_date"`
 	PublishDate *BandcampTime `json:"publish_date"`
+	Type        string        `json:"type"`
+}
+
+// IsSingle reports whether the release is a track page rather than an
+// album page.
+func (ja *JSONAlbum) IsSingle() bool {
+	if ja.ItemType != "" {
+		return ja.ItemType == "track"
+	}
+	return ja.AlbumData != nil && ja.AlbumData.Type == "track"
 }
 
 // ToAlbum converts JSONAlbum to a model.Album.
 func (ja *JSONAlbum) ToAlbum(pathCfg *model.PathConfig, trackCfg *model.TrackConfig) *model.Album {
 	// Build artwork URL
 	var artworkURL string
+	var artID int64
 	if ja.ArtID != nil {
-		artworkURL = fmt.Sprintf("%s%010d%s", artworkURLStart, *ja.ArtID, artworkURLEnd)
+		artID = *ja.ArtID
+		artworkURL = fmt.Sprintf("%s%010d%s", artworkURLStart, artID, artworkURLEnd)
 	}
 
 	// Determine release date with fallbacks
@@ -82,22 +109,74 @@ func (ja *JSONAlbum) ToAlbum(pathCfg *model.PathConfig, trackCfg *model.TrackCon
 		releaseDate = ja.AlbumData.PublishDate.Time
 	}
 
-	title := ""
+	var title, about, credits string
 	if ja.AlbumData != nil {
 		title = ja.AlbumData.AlbumTitle
+		about = strings.TrimSpace(ja.AlbumData.About)
+		credits = strings.TrimSpace(ja.AlbumData.Credits)
 	}
 
-	album := model.NewAlbum(ja.Artist, title, artworkURL, releaseDate, pathCfg)
+	// Detect compilations from the per-track artists
+	trackArtists := make([]string, 0, len(ja.Tracks))
+	for _, jt := range ja.Tracks {
+		trackArtists = append(trackArtists, jt.Artist)
+	}
 
-	// Convert tracks (skip those without files)
-	// TODO: Handle multiple discs. For now, always assume disc 1.
-	discNumber := 1
+	album := &model.Album{
+		ID:          ja.ID,
+		Artist:      ja.Artist,
+		Title:       title,
+		Label:       ja.Label,
+		ArtworkURL:  artworkURL,
+		ArtID:       artID,
+		ReleaseDate: releaseDate,
+		Compilation: model.IsCompilation(trackArtists),
+		Single:      ja.IsSingle(),
+		About:       about,
+		Credits:     credits,
+		Tags:        ja.Tags,
+	}
+	album.ComputePaths(pathCfg)
+
+	// Convert tracks (skip video items and those without files, listed in
+	// the album). Bandcamp
+	// has no disc numbers, but the track numbers of multi-disc releases
+	// restart on each disc.
+	discNumber, lastNumber := 1, 0
+	var streamingDisabled int
 	for _, jt := range ja.Tracks {
-		if jt.File != nil {
+		if !jt.IsVideo() && jt.Number != nil {
+			if *jt.Number <= lastNumber {
+				discNumber++
+			}
+			lastNumber = *jt.Number
+		}
+		switch {
+		case jt.IsVideo():
+			album.SkippedVideos = append(album.SkippedVideos, jt.Title)
+		case jt.HasMp3():
 			track := jt.ToTrack(album, discNumber, trackCfg)
 			album.Tracks = append(album.Tracks, track)
+		default:
+			album.UnavailableTracks = append(album.UnavailableTracks, jt.Title)
+		}
+		if !jt.IsVideo() && jt.StreamingDisabled() {
+			streamingDisabled++
+		}
+	}
+	if trackCfg.Numbering == model.NumberingSequential && discNumber > 1 {
+		for i, track := range album.Tracks {
+			track.Number = i + 1
+			track.ComputePath(trackCfg)
 		}
 	}
 
+	if ja.NoIndex {
+		album.Restrictions = append(album.Restrictions, "the page asks not to be indexed (noindex)")
+	}
+	if streamingDisabled > 0 {
+		album.Restrictions = append(album.Restrictions, fmt.Sprintf("streaming is disabled for %d track(s)", streamingDisabled))
+	}
+
 	return album
 }
@@ -8,11 +8,38 @@ import (
 
 // JSONTrack represents a track from Bandcamp's JSON data.
 type JSONTrack struct {
+	ID       int64        `json:"track_id"`
 	Duration float64      `json:"duration"`
 	File     *JSONMp3File `json:"file"`
 	Lyrics   string       `json:"lyrics"`
 	Number   *int         `json:"track_num"`
 	Title    string       `json:"title"`
+	Artist   string       `json:"artist"`
+	Link     string       `json:"title_link"`
+	HasInfo  bool         `json:"has_info"`
+
+	// Streaming is 0 when the artist disabled streaming of the track.
+	Streaming *int `json:"streaming"`
+
+	// VideoSourceType and VideoID are set on video items of the player
+	// (e.g. "youtube"), which have no MP3 stream of their own.
+	VideoSourceType *string `json:"video_source_type"`
+	VideoID         *int64  `json:"video_id"`
+}
+
+// StreamingDisabled reports whether the artist disabled streaming of the track.
+func (jt *JSONTrack) StreamingDisabled() bool {
+	return jt.Streaming != nil && *jt.Streaming == 0
+}
+
+// IsVideo reports whether the entry is a video item rather than a track.
+func (jt *JSONTrack) IsVideo() bool {
+	return (jt.VideoSourceType != nil && *jt.VideoSourceType != "") || jt.VideoID != nil
+}
+
+// HasMp3 reports whether the entry has an MP3 stream to download.
+func (jt *JSONTrack) HasMp3() bool {
+	return jt.File != nil && jt.File.URL != ""
 }
 
 // JSONMp3File represents the MP3 file info.
@@ -34,5 +61,26 @@ func (jt *JSONTrack) ToTrack(album *model.Album, discNumber int, cfg *model.Trac
 		number = *jt.Number
 	}
 
-	return model.NewTrack(album, discNumber, number, jt.Title, jt.Duration, jt.Lyrics, mp3URL, cfg)
+	// Compilation tracks carry their own artist
+	artist := jt.Artist
+	if artist == "" {
+		artist = album.Artist
+	}
+
+	track := &model.Track{
+		Album:      album,
+		ID:         jt.ID,
+		DiscNumber: discNumber,
+		Number:     number,
+		Title:      jt.Title,
+		Artist:     artist,
+		Duration:   jt.Duration,
+		Lyrics:     jt.Lyrics,
+		URL:        jt.Link,
+		HasInfo:    jt.HasInfo,
+		Mp3URL:     mp3URL,
+	}
+	track.ComputePath(cfg)
+
+	return track
 }
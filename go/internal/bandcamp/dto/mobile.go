@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"strings"
+	"time"
+)
+
+// MobileError is the error of a mobile API response, which is served with
+// a 200 status.
+type MobileError struct {
+	Error   bool   `json:"error"`
+	Message string `json:"error_message"`
+}
+
+// MobileBand is the band_details response of the mobile API: an artist or
+// label account and its discography.
+type MobileBand struct {
+	MobileError
+	ID          int64                   `json:"id"`
+	Name        string                  `json:"name"`
+	BandcampURL string                  `json:"bandcamp_url"`
+	Discography []MobileDiscographyItem `json:"discography"`
+}
+
+// MobileDiscographyItem is a release of a MobileBand.
+type MobileDiscographyItem struct {
+	ItemID     int64  `json:"item_id"`
+	ItemType   string `json:"item_type"` // "album" or "track"
+	BandID     int64  `json:"band_id"`
+	Title      string `json:"title"`
+	ArtistName string `json:"artist_name"`
+}
+
+// MobileTralbum is the tralbum_details response of the mobile API: an
+// album or a track release.
+type MobileTralbum struct {
+	MobileError
+	ID            int64  `json:"id"`
+	Type          string `json:"type"` // "a" or "t"
+	Title         string `json:"title"`
+	BandcampURL   string `json:"bandcamp_url"`
+	ArtID         *int64 `json:"art_id"`
+	TralbumArtist string `json:"tralbum_artist"`
+	Band          struct {
+		ID   int64  `json:"band_id"`
+		Name string `json:"name"`
+	} `json:"band"`
+	ReleaseDate int64  `json:"release_date"` // Unix time
+	About       string `json:"about"`
+	Credits     string `json:"credits"`
+	Tags        []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	Tracks []MobileTrack `json:"tracks"`
+}
+
+// MobileTrack is a track of a MobileTralbum.
+type MobileTrack struct {
+	ID           int64             `json:"track_id"`
+	Title        string            `json:"title"`
+	Number       *int              `json:"track_num"`
+	Duration     float64           `json:"duration"`
+	StreamingURL map[string]string `json:"streaming_url"`
+	BandName     string            `json:"band_name"`
+}
+
+// ToJSONAlbum converts the release into the tralbum JSON of its page, with
+// its Label and Tags set as the parser sets them from the page.
+func (mt *MobileTralbum) ToJSONAlbum() JSONAlbum {
+	album := JSONAlbum{
+		ID:     mt.ID,
+		ArtID:  mt.ArtID,
+		Artist: mt.TralbumArtist,
+		AlbumData: &JSONAlbumData{
+			AlbumTitle: mt.Title,
+			About:      mt.About,
+			Credits:    mt.Credits,
+		},
+		ItemType: "album",
+		Label:    mt.Band.Name,
+	}
+	if album.Artist == "" {
+		album.Artist = mt.Band.Name
+	}
+	if mt.Type == "t" {
+		album.ItemType = "track"
+	}
+	if mt.ReleaseDate > 0 {
+		album.ReleaseDate = &BandcampTime{Time: time.Unix(mt.ReleaseDate, 0).UTC()}
+	}
+	for _, tag := range mt.Tags {
+		album.Tags = append(album.Tags, tag.Name)
+	}
+
+	for _, t := range mt.Tracks {
+		track := JSONTrack{
+			ID:       t.ID,
+			Duration: t.Duration,
+			Number:   t.Number,
+			Title:    t.Title,
+		}
+		if stream := t.StreamingURL["mp3-128"]; stream != "" {
+			track.File = &JSONMp3File{URL: stream}
+		}
+		// Compilation tracks carry their own artist
+		if t.BandName != "" && !strings.EqualFold(t.BandName, album.Artist) {
+			track.Artist = t.BandName
+		}
+		album.Tracks = append(album.Tracks, track)
+	}
+	return album
+}
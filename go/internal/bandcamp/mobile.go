@@ -0,0 +1,230 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp/dto"
+)
+
+// MobileAPIURL is the base URL of the API of Bandcamp's mobile app.
+const MobileAPIURL = "https://bandcamp.com/api/mobile/24"
+
+// StrategyMobileAPI is the name of MobileStrategy.
+const StrategyMobileAPI = "mobile-api"
+
+// APIClient makes the requests of the API clients of this package,
+// MobileAPI and Searcher; *http.Client is one.
+type APIClient interface {
+	Get(ctx context.Context, url string) ([]byte, error)
+	PostJSON(ctx context.Context, url string, body any) ([]byte, error)
+}
+
+// MobileAPI is a client of the public API of Bandcamp's mobile app, which
+// serves the data of accounts and releases as JSON by ID. Unlike the
+// pages, its responses do not change with the site's design, so it is a
+// more robust source of metadata; the pages are only needed for the IDs.
+//
+// Example:
+//
+//	api := bandcamp.NewMobileAPI(client, "")
+//	band, err := api.BandDetails(ctx, 2795129958)
+//	for _, item := range band.Discography {
+//	    page, err := api.ReadRelease(ctx, bandcamp.PageIDs{BandID: band.ID, ItemID: item.ItemID, ItemType: item.ItemType})
+//	}
+type MobileAPI struct {
+	client  APIClient
+	baseURL string
+}
+
+// NewMobileAPI returns a client of the mobile API at baseURL, or at
+// MobileAPIURL if baseURL is "", making its requests with client.
+func NewMobileAPI(client APIClient, baseURL string) *MobileAPI {
+	if baseURL == "" {
+		baseURL = MobileAPIURL
+	}
+	return &MobileAPI{client: client, baseURL: baseURL}
+}
+
+// BandDetails returns the account bandID, with its discography.
+func (a *MobileAPI) BandDetails(ctx context.Context, bandID int64) (*dto.MobileBand, error) {
+	data, err := a.client.PostJSON(ctx, a.baseURL+"/band_details", map[string]int64{"band_id": bandID})
+	if err != nil {
+		return nil, err
+	}
+	band := &dto.MobileBand{}
+	if err := decodeMobile(data, band, &band.MobileError); err != nil {
+		return nil, fmt.Errorf("band details of %d: %w", bandID, err)
+	}
+	return band, nil
+}
+
+// TralbumDetails returns the release itemID of the account bandID, of
+// type "album" or "track" (or "a" and "t", as the API has them).
+func (a *MobileAPI) TralbumDetails(ctx context.Context, bandID, itemID int64, itemType string) (*dto.MobileTralbum, error) {
+	tralbumType, err := mobileItemType(itemType)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{
+		"band_id":      {strconv.FormatInt(bandID, 10)},
+		"tralbum_id":   {strconv.FormatInt(itemID, 10)},
+		"tralbum_type": {tralbumType},
+	}
+	data, err := a.client.Get(ctx, a.baseURL+"/tralbum_details?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	tralbum := &dto.MobileTralbum{}
+	if err := decodeMobile(data, tralbum, &tralbum.MobileError); err != nil {
+		return nil, fmt.Errorf("details of %s %d: %w", itemType, itemID, err)
+	}
+	return tralbum, nil
+}
+
+// ReadRelease reads the release identified by ids into the data of its
+// page, as ReadAlbumPage would. The URL of the page is returned along with
+// it. Returns ErrNoTracks if the release has no tracks.
+func (a *MobileAPI) ReadRelease(ctx context.Context, ids PageIDs) (*AlbumPage, string, error) {
+	tralbum, err := a.TralbumDetails(ctx, ids.BandID, ids.ItemID, ids.ItemType)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tralbum.Tracks) == 0 {
+		return nil, tralbum.BandcampURL, ErrNoTracks
+	}
+	album := tralbum.ToJSONAlbum()
+	page := &AlbumPage{Album: album, Label: album.Label, Tags: album.Tags, Strategy: StrategyMobileAPI}
+	return page, tralbum.BandcampURL, nil
+}
+
+// decodeMobile decodes a response of the API into v, returning the error
+// the API answered with, if any, which apiErr is the part of v for.
+func decodeMobile(data []byte, v any, apiErr *dto.MobileError) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid mobile API response: %w", err)
+	}
+	if apiErr.Error {
+		if apiErr.Message == "" {
+			apiErr.Message = "unknown error"
+		}
+		return fmt.Errorf("mobile API error: %s", apiErr.Message)
+	}
+	return nil
+}
+
+// mobileItemType returns the tralbum_type of the API for an item type.
+func mobileItemType(itemType string) (string, error) {
+	switch itemType {
+	case "album", "a":
+		return "a", nil
+	case "track", "t":
+		return "t", nil
+	default:
+		return "", fmt.Errorf("unsupported item type %q", itemType)
+	}
+}
+
+// PageIDs are the IDs of a page's account and item, by which the mobile
+// API serves them.
+type PageIDs struct {
+	BandID int64
+
+	// ItemID and ItemType identify the album ("album" or "a") or track
+	// ("track" or "t") of a release page. Account pages have no item.
+	ItemID   int64
+	ItemType string
+}
+
+var (
+	dataBandRegex       = regexp.MustCompile(`data-band="([^"]*)"`)
+	pagePropertiesRegex = regexp.MustCompile(`<meta name="bc-page-properties" content="([^"]*)"`)
+)
+
+// ReadPageIDs reads the IDs of a page: the account from its data-band
+// attribute or data-tralbum JSON, and the release from its
+// bc-page-properties meta tag or data-tralbum JSON. Returns an error if
+// the account cannot be found.
+func ReadPageIDs(htmlContent string) (PageIDs, error) {
+	var ids PageIDs
+
+	if match := dataBandRegex.FindStringSubmatch(htmlContent); match != nil {
+		var band struct {
+			ID int64 `json:"id"`
+		}
+		if json.Unmarshal([]byte(html.UnescapeString(match[1])), &band) == nil {
+			ids.BandID = band.ID
+		}
+	}
+	if match := pagePropertiesRegex.FindStringSubmatch(htmlContent); match != nil {
+		var props struct {
+			ItemType string `json:"item_type"`
+			ItemID   int64  `json:"item_id"`
+		}
+		if json.Unmarshal([]byte(html.UnescapeString(match[1])), &props) == nil {
+			if _, err := mobileItemType(props.ItemType); err == nil {
+				ids.ItemID, ids.ItemType = props.ItemID, props.ItemType
+			}
+		}
+	}
+
+	if ids.BandID == 0 || ids.ItemID == 0 {
+		if data, err := extractAlbumData(htmlContent); err == nil {
+			var tralbum struct {
+				ID       int64  `json:"id"`
+				ItemType string `json:"item_type"`
+				Current  struct {
+					BandID int64 `json:"band_id"`
+				} `json:"current"`
+			}
+			if json.Unmarshal([]byte(fixJSON(data)), &tralbum) == nil {
+				if ids.BandID == 0 {
+					ids.BandID = tralbum.Current.BandID
+				}
+				if _, err := mobileItemType(tralbum.ItemType); ids.ItemID == 0 && err == nil {
+					ids.ItemID, ids.ItemType = tralbum.ID, tralbum.ItemType
+				}
+			}
+		}
+	}
+
+	if ids.BandID == 0 {
+		return ids, errors.New("could not find the account ID in HTML")
+	}
+	return ids, nil
+}
+
+// MobileStrategy reads release pages from the mobile API: only the IDs of
+// the release are read from the page, so changes of its markup hardly
+// matter. It makes a request for each page, and is only used when
+// enabled.
+type MobileStrategy struct {
+	API *MobileAPI
+}
+
+// Name implements Strategy.
+func (MobileStrategy) Name() string { return StrategyMobileAPI }
+
+// Read implements Strategy.
+func (s MobileStrategy) Read(ctx context.Context, htmlContent string) (*AlbumPage, error) {
+	ids, err := ReadPageIDs(htmlContent)
+	if err != nil {
+		return nil, err
+	}
+	if ids.ItemID == 0 {
+		return nil, errors.New("could not find the release ID in HTML")
+	}
+	page, _, err := s.API.ReadRelease(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	page.NoIndex = isNoIndex(htmlContent)
+	page.StructuredData = extractStructuredData(htmlContent)
+	return page, nil
+}
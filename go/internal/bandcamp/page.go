@@ -0,0 +1,83 @@
+package bandcamp
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// nonMusicSections are the Bandcamp site sections that never contain
+// album data, keyed by their first path segment.
+var nonMusicSections = map[string]string{
+	"merch":     "merch",
+	"community": "community",
+	"video":     "video",
+	"live":      "live stream",
+}
+
+// UnsupportedPageError is returned when a URL points to a Bandcamp page
+// that does not contain music, such as a merch or community page.
+//
+// SuggestedURL is the artist's music page, which lists the releases that
+// can be downloaded.
+//
+// Example:
+//
+//	var pageErr *bandcamp.UnsupportedPageError
+//	if errors.As(err, &pageErr) {
+//	    fmt.Println("Try", pageErr.SuggestedURL)
+//	}
+type UnsupportedPageError struct {
+	// URL is the URL that was given.
+	URL string
+
+	// Kind describes the page type, e.g. "merch" or "community".
+	Kind string
+
+	// SuggestedURL is a URL that is likely what the user meant.
+	SuggestedURL string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedPageError) Error() string {
+	return fmt.Sprintf("%s is a %s page, not a music page; try %s instead", e.URL, e.Kind, e.SuggestedURL)
+}
+
+// DownloadPageError is returned when a URL points to the redemption page
+// of a download code or to a download page, which need the session of a
+// logged-in account and are read by ReadDownloadPage rather than the
+// Parser (see Redeemer).
+type DownloadPageError struct {
+	URL string
+}
+
+// Error implements the error interface.
+func (e *DownloadPageError) Error() string {
+	return fmt.Sprintf("%s is a download code or download page, not a music page; redeem it instead", e.URL)
+}
+
+// CheckPageURL returns an *UnsupportedPageError if rawURL points to a
+// Bandcamp page that does not contain music (merch, community, video or
+// live stream pages), or a *DownloadPageError for code redemption and
+// download pages. It returns nil for any other URL.
+func CheckPageURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	if _, ok := CodeFromInput(rawURL); ok || IsDownloadPageURL(rawURL) {
+		return &DownloadPageError{URL: rawURL}
+	}
+
+	section, _, _ := strings.Cut(strings.TrimPrefix(parsedURL.Path, "/"), "/")
+	kind, ok := nonMusicSections[strings.ToLower(section)]
+	if !ok {
+		return nil
+	}
+
+	return &UnsupportedPageError{
+		URL:          rawURL,
+		Kind:         kind,
+		SuggestedURL: fmt.Sprintf("%s://%s/music", parsedURL.Scheme, parsedURL.Host),
+	}
+}
@@ -1,7 +1,9 @@
 package bandcamp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"regexp"
@@ -11,6 +13,10 @@ import (
 	"github.com/handiism/bandcamp-downloader/internal/model"
 )
 
+// ErrNoTracks is returned by ParseAlbumPage for items without any track,
+// such as the merch bundles listed on some labels' music pages.
+var ErrNoTracks = errors.New("item has no tracks")
+
 // Parser extracts album information from Bandcamp HTML pages.
 //
 // Bandcamp embeds album data as JSON within the HTML page in a data-tralbum
@@ -59,8 +65,9 @@ func NewParser(pathCfg *model.PathConfig, trackCfg *model.TrackConfig) *Parser {
 // This method performs the following steps:
 //  1. Extracts the data-tralbum JSON from the HTML
 //  2. Fixes malformed JSON (e.g., URL concatenation issues)
-//  3. Deserializes JSON into album/track data
-//  4. Extracts lyrics from HTML elements (if available)
+//  3. Deserializes JSON into album/track data, or else reads it from the
+//     page's structured data (see StrategyChain)
+//  4. Reads lyrics and tags from the page's structured data (JSON-LD)
 //  5. Computes file paths based on configuration
 //
 // The HTML should be the full page source from a Bandcamp URL like:
@@ -68,8 +75,9 @@ func NewParser(pathCfg *model.PathConfig, trackCfg *model.TrackConfig) *Parser {
 //   - https://artist.bandcamp.com/track/track-name
 //
 // Returns an error if:
-//   - The data-tralbum attribute cannot be found
-//   - The JSON is malformed and cannot be parsed
+//   - Neither the data-tralbum attribute nor structured data with streams
+//     can be read (*ParseError)
+//   - The item has no tracks at all (ErrNoTracks)
 //
 // Example:
 //
@@ -78,27 +86,58 @@ func NewParser(pathCfg *model.PathConfig, trackCfg *model.TrackConfig) *Parser {
 //	    return fmt.Errorf("failed to parse album: %w", err)
 //	}
 func (p *Parser) ParseAlbumPage(htmlContent string) (*model.Album, error) {
-	// Extract the data-tralbum JSON
-	albumData, err := extractAlbumData(htmlContent)
+	page, err := ReadAlbumPage(htmlContent)
 	if err != nil {
-		return nil, fmt.Errorf("could not retrieve album data: %w", err)
+		return nil, err
 	}
+	return p.ToAlbum(page), nil
+}
 
-	// Fix malformed JSON
-	albumData = fixJSON(albumData)
+// AlbumPage is the data read from an album or track page, from which
+// ToAlbum builds the album. It does not depend on the path settings, so
+// it can be cached (see encoding/json) and turned into albums later.
+type AlbumPage struct {
+	Album          dto.JSONAlbum   `json:"album"`
+	Label          string          `json:"label,omitempty"`
+	Tags           []string        `json:"tags,omitempty"`
+	NoIndex        bool            `json:"noindex,omitempty"`
+	StructuredData *structuredData `json:"structured_data,omitempty"`
 
-	// Deserialize JSON
-	var jsonAlbum dto.JSONAlbum
-	if err := json.Unmarshal([]byte(albumData), &jsonAlbum); err != nil {
-		return nil, fmt.Errorf("failed to parse album JSON: %w", err)
-	}
+	// Strategy is the name of the Strategy that read the page.
+	Strategy string `json:"strategy,omitempty"`
+
+	// FellBack reports that the strategies before Strategy in the
+	// StrategyChain failed to read the page, e.g. after a change of
+	// Bandcamp's markup. It is not kept in the JSON of cached pages.
+	FellBack bool `json:"-"`
+}
 
+// ReadAlbumPage reads the data of an album or track page HTML with the
+// default strategies (see StrategyChain), the first steps of
+// ParseAlbumPage. It returns the same errors.
+func ReadAlbumPage(htmlContent string) (*AlbumPage, error) {
+	return NewStrategyChain().Read(context.Background(), htmlContent)
+}
+
+// ToAlbum builds the album of a page read by ReadAlbumPage, with the
+// parser's path settings.
+func (p *Parser) ToAlbum(page *AlbumPage) *model.Album {
+	jsonAlbum := page.Album
+	jsonAlbum.Label = page.Label
+	jsonAlbum.Tags = page.Tags
+	jsonAlbum.NoIndex = page.NoIndex
 	album := jsonAlbum.ToAlbum(p.pathConfig, p.trackConfig)
 
-	// Extract lyrics from HTML
-	p.extractLyrics(htmlContent, album)
+	// Only structured data is used, as the page's markup and labels
+	// change with the display language
+	ld := page.StructuredData
+	applyLyrics(ld, album)
+	if album.Credits == "" && ld != nil {
+		album.Credits = strings.TrimSpace(ld.CreditText)
+	}
+	applyCredits(album)
 
-	return album, nil
+	return album
 }
 
 // extractAlbumData extracts the data-tralbum JSON string from HTML.
@@ -131,6 +170,187 @@ func extractAlbumData(htmlContent string) (string, error) {
 	return html.UnescapeString(albumData), nil
 }
 
+// extractSiteName extracts the name of the Bandcamp site hosting the page.
+//
+// Bandcamp sets the og:site_name meta tag to the name of the band or label
+// account the page belongs to:
+//
+//	<meta property="og:site_name" content="Label Name">
+//
+// For releases published by a label, this is the label name, while the
+// album's artist field holds the actual artist. Returns an empty string if
+// the tag cannot be found.
+func extractSiteName(htmlContent string) string {
+	re := regexp.MustCompile(`<meta property="og:site_name" content="([^"]*)"`)
+	match := re.FindStringSubmatch(htmlContent)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(match[1]))
+}
+
+// isNoIndex reports whether the page's robots meta tag asks search engines
+// and other robots not to index it:
+//
+//	<meta name="robots" content="noindex, nofollow">
+//
+// Bandcamp pages carry a robots tag by default (e.g. "max-image-preview:large"),
+// so only the "noindex" and "none" directives count.
+func isNoIndex(htmlContent string) bool {
+	re := regexp.MustCompile(`(?i)<meta\s+name="robots"\s+content="([^"]*)"`)
+	for _, match := range re.FindAllStringSubmatch(htmlContent, -1) {
+		for _, directive := range strings.Split(match[1], ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "noindex", "none":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// structuredData is the subset of a page's JSON-LD (schema.org MusicAlbum
+// or MusicRecording) used by the parser. The fields of the recording are
+// those of track pages.
+type structuredData struct {
+	ldRecording
+	Type          string          `json:"@type"`
+	Description   string          `json:"description,omitempty"`
+	DatePublished string          `json:"datePublished,omitempty"`
+	Keywords      json.RawMessage `json:"keywords"`
+	CreditText    string          `json:"creditText"`
+	Track         *struct {
+		ItemListElement []struct {
+			Position int         `json:"position"`
+			Item     ldRecording `json:"item"`
+		} `json:"itemListElement"`
+	} `json:"track"`
+}
+
+// ldRecording is a MusicRecording: the item of a track page, or a track
+// of an album page.
+type ldRecording struct {
+	ID                 string         `json:"@id,omitempty"`
+	Name               string         `json:"name,omitempty"`
+	Duration           string         `json:"duration,omitempty"`
+	ByArtist           *ldArtist      `json:"byArtist,omitempty"`
+	AdditionalProperty ldProperties   `json:"additionalProperty,omitempty"`
+	RecordingOf        *ldComposition `json:"recordingOf"`
+}
+
+// ldArtist is the MusicGroup or Person a release or recording is by.
+type ldArtist struct {
+	Name string `json:"name"`
+}
+
+// name returns the artist's name, or "" if a is nil.
+func (a *ldArtist) name() string {
+	if a == nil {
+		return ""
+	}
+	return a.Name
+}
+
+// ldProperties are the PropertyValues of an additionalProperty, where
+// Bandcamp lists the IDs and streams of the tralbum JSON:
+//
+//	"additionalProperty": [{"@type": "PropertyValue", "name": "track_id", "value": 123}, ...]
+type ldProperties []struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// number returns the numeric value of the property name.
+func (p ldProperties) number(name string) (float64, bool) {
+	for _, prop := range p {
+		var v float64
+		if prop.Name == name && json.Unmarshal(prop.Value, &v) == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// string returns the string value of the property name, or "".
+func (p ldProperties) string(name string) string {
+	for _, prop := range p {
+		var v string
+		if prop.Name == name && json.Unmarshal(prop.Value, &v) == nil {
+			return v
+		}
+	}
+	return ""
+}
+
+// ldComposition is the MusicComposition a recording is of.
+type ldComposition struct {
+	Lyrics *struct {
+		Text string `json:"text"`
+	} `json:"lyrics"`
+}
+
+// lyrics returns the composition's lyrics, or "" if it has none.
+func (c *ldComposition) lyrics() string {
+	if c == nil || c.Lyrics == nil {
+		return ""
+	}
+	return strings.TrimSpace(c.Lyrics.Text)
+}
+
+// extractStructuredData parses the page's JSON-LD structured data:
+//
+//	<script type="application/ld+json">{"@type": "MusicAlbum", ...}</script>
+//
+// Returns nil if the page has none or it cannot be parsed.
+func extractStructuredData(htmlContent string) *structuredData {
+	re := regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+	match := re.FindStringSubmatch(htmlContent)
+	if match == nil {
+		return nil
+	}
+
+	var ld structuredData
+	if err := json.Unmarshal([]byte(match[1]), &ld); err != nil {
+		return nil
+	}
+	return &ld
+}
+
+// extractTags extracts the release's tags from the page.
+//
+// Tags are read from the "keywords" of the page's JSON-LD structured data,
+// which is the same whatever the display language:
+//
+//	<script type="application/ld+json">{..., "keywords": ["ambient", "Berlin"]}</script>
+//
+// If the page has no structured data, the tag links of the tags section are
+// used instead. Returns nil if the release has no tags.
+func extractTags(htmlContent string) []string {
+	if ld := extractStructuredData(htmlContent); ld != nil && len(ld.Keywords) > 0 {
+		var tags []string
+		if err := json.Unmarshal(ld.Keywords, &tags); err == nil {
+			return tags
+		}
+		// Keywords can also be a single comma-separated string
+		var keywords string
+		if err := json.Unmarshal(ld.Keywords, &keywords); err == nil && keywords != "" {
+			for _, tag := range strings.Split(keywords, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			return tags
+		}
+	}
+
+	tagRegex := regexp.MustCompile(`<a class="tag"[^>]*>\s*([^<]*?)\s*</a>`)
+	var tags []string
+	for _, match := range tagRegex.FindAllStringSubmatch(htmlContent, -1) {
+		tags = append(tags, html.UnescapeString(match[1]))
+	}
+	return tags
+}
+
 // fixJSON fixes malformed JSON from Bandcamp pages.
 //
 // Some Bandcamp pages have JavaScript-style URL concatenation in the JSON:
@@ -147,37 +367,35 @@ func fixJSON(albumData string) string {
 	return re.ReplaceAllString(albumData, "${1}${2}")
 }
 
-// extractLyrics extracts lyrics from the HTML and updates track Lyrics fields.
+// applyLyrics sets the tracks' lyrics from the page's structured data.
 //
-// Bandcamp displays lyrics in elements with IDs like "lyrics_row_1", "lyrics_row_2", etc.
-// This method finds these elements and extracts the text content, stripping HTML tags.
-func (p *Parser) extractLyrics(htmlContent string, album *model.Album) {
-	for _, track := range album.Tracks {
-		lyricsID := fmt.Sprintf(`id="lyrics_row_%d"`, track.Number)
-		startIdx := strings.Index(htmlContent, lyricsID)
-		if startIdx == -1 {
-			continue
-		}
-
-		// Find the lyrics content within the element
-		remaining := htmlContent[startIdx:]
+// Lyrics are read from the JSON-LD of the page, which has the same shape
+// whatever the display language, unlike the lyrics rows of the track
+// table. Album pages list them per track position:
+//
+//	"track": {"itemListElement": [{"position": 1, "item": {"recordingOf": {"lyrics": {"text": "..."}}}}]}
+//
+// while track pages describe the recording itself. Tracks keep the lyrics
+// of the tralbum JSON when the structured data has none.
+func applyLyrics(ld *structuredData, album *model.Album) {
+	if ld == nil {
+		return
+	}
 
-		// Look for the lyrics text between tags
-		contentStart := strings.Index(remaining, ">")
-		if contentStart == -1 {
-			continue
+	lyrics := make(map[int]string)
+	if ld.Track != nil {
+		for _, element := range ld.Track.ItemListElement {
+			if text := element.Item.RecordingOf.lyrics(); text != "" {
+				lyrics[element.Position] = text
+			}
 		}
+	} else if text := ld.RecordingOf.lyrics(); text != "" && len(album.Tracks) == 1 {
+		lyrics[album.Tracks[0].Number] = text
+	}
 
-		// Simple extraction - find text content
-		contentEnd := strings.Index(remaining[contentStart:], "</div>")
-		if contentEnd == -1 {
-			continue
+	for _, track := range album.Tracks {
+		if text, ok := lyrics[track.Number]; ok {
+			track.Lyrics = text
 		}
-
-		lyricsHTML := remaining[contentStart+1 : contentStart+contentEnd]
-		// Strip HTML tags and clean up
-		tagRegex := regexp.MustCompile(`<[^>]*>`)
-		lyrics := tagRegex.ReplaceAllString(lyricsHTML, "")
-		track.Lyrics = strings.TrimSpace(html.UnescapeString(lyrics))
 	}
 }
@@ -0,0 +1,276 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CodeVerifyURL is the URL the redemption page (bandcamp.com/yum) sends
+// download codes to.
+const CodeVerifyURL = "https://bandcamp.com/api/codes/1/verify"
+
+// DownloadFormats are the formats of the downloads of a download page,
+// lossless first.
+var DownloadFormats = []string{"flac", "alac", "wav", "aiff-lossless", "mp3-320", "mp3-v0", "aac-hi", "vorbis"}
+
+// downloadExtensions are the file extensions of the track downloads of
+// each format; albums are zip archives.
+var downloadExtensions = map[string]string{
+	"flac":          ".flac",
+	"alac":          ".m4a",
+	"wav":           ".wav",
+	"aiff-lossless": ".aiff",
+	"mp3-320":       ".mp3",
+	"mp3-v0":        ".mp3",
+	"aac-hi":        ".m4a",
+	"vorbis":        ".ogg",
+}
+
+// codeRegex matches a download code, e.g. "ab3d-9xyz".
+var codeRegex = regexp.MustCompile(`^[a-z0-9]{4}-[a-z0-9]{4}$`)
+
+// CodeFromInput returns the download code of input, a code or a
+// redemption page URL with its code (https://bandcamp.com/yum?code=...),
+// and whether input is one.
+func CodeFromInput(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	if code := strings.ToLower(input); codeRegex.MatchString(code) {
+		return code, true
+	}
+	u, err := url.Parse(input)
+	if err != nil || strings.TrimSuffix(u.Path, "/") != "/yum" {
+		return "", false
+	}
+	code := strings.ToLower(strings.TrimSpace(u.Query().Get("code")))
+	return code, code != ""
+}
+
+// IsDownloadPageURL returns whether rawURL is a download page, where a
+// redeemed code or a purchase leads to, e.g.
+// https://bandcamp.com/download?id=...&sig=....
+func IsDownloadPageURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return (host == "bandcamp.com" || host == "www.bandcamp.com") && strings.TrimSuffix(u.Path, "/") == "/download"
+}
+
+// Redeemer redeems download codes, as the redemption page does. Codes are
+// redeemed to the account logged in: the client must send the cookies of
+// its session.
+//
+// Example:
+//
+//	redeemer := bandcamp.NewRedeemer(client, "")
+//	pageURL, err := redeemer.Redeem(ctx, "ab3d-9xyz")
+//	html, err := client.GetString(ctx, pageURL)
+//	page, err := bandcamp.ReadDownloadPage(html)
+type Redeemer struct {
+	client APIClient
+	url    string
+}
+
+// NewRedeemer returns a client redeeming codes at url, or at
+// CodeVerifyURL if url is "", making its requests with client.
+func NewRedeemer(client APIClient, url string) *Redeemer {
+	if url == "" {
+		url = CodeVerifyURL
+	}
+	return &Redeemer{client: client, url: url}
+}
+
+// ErrCodeRejected is returned by Redeem when the code is not accepted:
+// unknown, already redeemed by another account, or sent without a
+// logged-in session.
+var ErrCodeRejected = errors.New("download code rejected")
+
+// verifyResponse is the response of the code verification.
+type verifyResponse struct {
+	OK          bool   `json:"ok"`
+	RedirectURL string `json:"redirect_url"`
+	Errors      []struct {
+		Reason string `json:"reason"`
+	} `json:"errors"`
+}
+
+// Redeem redeems code and returns the URL of the download page of its
+// release. Returns an error wrapping ErrCodeRejected if the code is not
+// accepted.
+func (r *Redeemer) Redeem(ctx context.Context, code string) (string, error) {
+	body := map[string]any{
+		"code":          code,
+		"is_corp":       true,
+		"fan_logged_in": true,
+		"is_https":      true,
+	}
+	data, err := r.client.PostJSON(ctx, r.url, body)
+	if err != nil {
+		return "", err
+	}
+	var resp verifyResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("invalid code verification response: %w", err)
+	}
+	if !resp.OK || resp.RedirectURL == "" {
+		reasons := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			reasons = append(reasons, e.Reason)
+		}
+		if len(reasons) == 0 {
+			return "", fmt.Errorf("%w: %s", ErrCodeRejected, code)
+		}
+		return "", fmt.Errorf("%w: %s (%s)", ErrCodeRejected, code, strings.Join(reasons, ", "))
+	}
+	return resp.RedirectURL, nil
+}
+
+// DownloadPage is the data of a download page: the release and the URL
+// of its download in each format.
+type DownloadPage struct {
+	Title  string
+	Artist string
+
+	// ItemType is "album" or "track".
+	ItemType string
+
+	// Formats are the downloads of the release by format (see
+	// DownloadFormats).
+	Formats map[string]DownloadFormat
+}
+
+// DownloadFormat is a download of a DownloadPage.
+type DownloadFormat struct {
+	URL string
+
+	// Size is the size as the page shows it, e.g. "312.4MB".
+	Size string
+}
+
+// FormatNames returns the formats of the page, in the order of
+// DownloadFormats, then the unknown ones by name.
+func (p *DownloadPage) FormatNames() []string {
+	var names, unknown []string
+	for _, format := range DownloadFormats {
+		if _, ok := p.Formats[format]; ok {
+			names = append(names, format)
+		}
+	}
+	for format := range p.Formats {
+		if _, ok := downloadExtensions[format]; !ok {
+			unknown = append(unknown, format)
+		}
+	}
+	sort.Strings(unknown)
+	return append(names, unknown...)
+}
+
+// Extension returns the file extension of the download of the page in
+// format: ".zip" for albums, or that of the audio files for tracks.
+func (p *DownloadPage) Extension(format string) string {
+	if p.ItemType == "album" {
+		return ".zip"
+	}
+	if ext, ok := downloadExtensions[format]; ok {
+		return ext
+	}
+	return ".bin"
+}
+
+// pageDataRegex matches the data of a download page, in the data-blob
+// attribute of its pagedata element.
+var pageDataRegex = regexp.MustCompile(`id="pagedata"[^>]*data-blob="([^"]*)"`)
+
+// ReadDownloadPage reads a download page, a page type of its own, unlike
+// the album and track pages the Parser reads. Returns an error if the page
+// has no download, e.g. when it was not fetched with the session of the
+// account the release belongs to.
+func ReadDownloadPage(htmlContent string) (*DownloadPage, error) {
+	match := pageDataRegex.FindStringSubmatch(htmlContent)
+	if match == nil {
+		return nil, errors.New("could not find the download data in HTML")
+	}
+	var blob struct {
+		DigitalItems []struct {
+			Title     string `json:"title"`
+			Artist    string `json:"artist"`
+			Type      string `json:"type"`
+			Downloads map[string]struct {
+				URL    string `json:"url"`
+				SizeMB string `json:"size_mb"`
+			} `json:"downloads"`
+		} `json:"digital_items"`
+	}
+	if err := json.Unmarshal([]byte(html.UnescapeString(match[1])), &blob); err != nil {
+		return nil, fmt.Errorf("failed to parse download data: %w", err)
+	}
+	if len(blob.DigitalItems) == 0 {
+		return nil, errors.New("the download page has no release")
+	}
+
+	item := blob.DigitalItems[0]
+	page := &DownloadPage{Title: item.Title, Artist: item.Artist, ItemType: "album", Formats: make(map[string]DownloadFormat)}
+	if item.Type == "track" || item.Type == "t" {
+		page.ItemType = "track"
+	}
+	for format, download := range item.Downloads {
+		if download.URL != "" {
+			page.Formats[format] = DownloadFormat{URL: download.URL, Size: download.SizeMB}
+		}
+	}
+	if len(page.Formats) == 0 {
+		return nil, fmt.Errorf("the download page of %s has no download", page.Title)
+	}
+	return page, nil
+}
+
+// statResponse is the response of the status of a download, which is
+// prepared (zipped) by Bandcamp before it can be fetched.
+type statResponse struct {
+	Result      string `json:"result"`
+	DownloadURL string `json:"download_url"`
+	ErrorText   string `json:"errortext"`
+}
+
+// PrepareDownload asks Bandcamp to prepare the download of a format, and
+// returns the URL of its file: that given by the status of the download,
+// or formatURL itself if the status has none. The download is often
+// ready at once; otherwise it is an error to retry later.
+func PrepareDownload(ctx context.Context, client APIClient, formatURL string) (string, error) {
+	statURL, err := url.Parse(strings.Replace(formatURL, "/download/", "/statdownload/", 1))
+	if err != nil {
+		return "", err
+	}
+	query := statURL.Query()
+	query.Set(".vrs", "1")
+	statURL.RawQuery = query.Encode()
+
+	data, err := client.Get(ctx, statURL.String())
+	if err != nil {
+		return "", err
+	}
+	var stat statResponse
+	if err := json.Unmarshal(data, &stat); err != nil {
+		// Not every download has a status
+		return formatURL, nil
+	}
+	switch {
+	case stat.DownloadURL != "":
+		return stat.DownloadURL, nil
+	case stat.Result == "err":
+		if stat.ErrorText == "" {
+			stat.ErrorText = "the download is not ready, try again later"
+		}
+		return "", fmt.Errorf("download not available: %s", stat.ErrorText)
+	default:
+		return formatURL, nil
+	}
+}
@@ -0,0 +1,179 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SearchURL is the URL of the search API of Bandcamp's search box.
+const SearchURL = "https://bandcamp.com/api/bcsearch_public_api/1/autocomplete_elastic"
+
+// SearchKind restricts a search to a kind of result.
+type SearchKind string
+
+// Kinds of search results.
+const (
+	SearchAll    SearchKind = "all"
+	SearchArtist SearchKind = "artist"
+	SearchAlbum  SearchKind = "album"
+	SearchTrack  SearchKind = "track"
+)
+
+// ParseSearchKind parses the name of a SearchKind; "" is SearchAll.
+func ParseSearchKind(s string) (SearchKind, error) {
+	switch kind := SearchKind(strings.ToLower(strings.TrimSpace(s))); kind {
+	case "":
+		return SearchAll, nil
+	case SearchAll, SearchArtist, SearchAlbum, SearchTrack:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("invalid search type %q, must be all, artist, album or track", s)
+	}
+}
+
+// filter returns the search_filter of the API for the kind.
+func (k SearchKind) filter() string {
+	switch k {
+	case SearchArtist:
+		return "b"
+	case SearchAlbum:
+		return "a"
+	case SearchTrack:
+		return "t"
+	default:
+		return ""
+	}
+}
+
+// SearchResult is an artist (or label), album or track found by a search.
+type SearchResult struct {
+	Kind SearchKind
+	ID   int64
+
+	// Name is the name of the artist, or the title of the album or track.
+	Name string
+
+	// Artist is the artist of an album or track, and Album the album of a
+	// track, if any.
+	Artist string
+	Album  string
+
+	// URL is the page of the result: the music page of an artist.
+	URL string
+
+	// Label is true if the artist is a label account.
+	Label bool
+}
+
+// String returns the result as listed to pick from, e.g.
+// "album: Night by Various".
+func (r SearchResult) String() string {
+	kind := string(r.Kind)
+	if r.Label {
+		kind = "label"
+	}
+	s := kind + ": " + r.Name
+	if r.Artist != "" {
+		s += " by " + r.Artist
+	}
+	if r.Album != "" {
+		s += " (from " + r.Album + ")"
+	}
+	return s
+}
+
+// Searcher finds artists, albums and tracks by name with the search API
+// of Bandcamp's search box, so they can be downloaded without copying
+// their URL.
+//
+// Example:
+//
+//	searcher := bandcamp.NewSearcher(client, "")
+//	results, err := searcher.Search(ctx, "boards of canada", bandcamp.SearchArtist)
+//	for _, r := range results {
+//	    fmt.Println(r, r.URL)
+//	}
+type Searcher struct {
+	client APIClient
+	url    string
+}
+
+// NewSearcher returns a client of the search API at url, or at SearchURL
+// if url is "", making its requests with client.
+func NewSearcher(client APIClient, url string) *Searcher {
+	if url == "" {
+		url = SearchURL
+	}
+	return &Searcher{client: client, url: url}
+}
+
+// searchResponse is the response of the search API.
+type searchResponse struct {
+	Auto struct {
+		Results []struct {
+			Type        string `json:"type"` // "b", "a", "t" or "f" (fan)
+			ID          int64  `json:"id"`
+			Name        string `json:"name"`
+			BandName    string `json:"band_name"`
+			AlbumName   string `json:"album_name"`
+			ItemURLRoot string `json:"item_url_root"`
+			ItemURLPath string `json:"item_url_path"`
+			IsLabel     bool   `json:"is_label"`
+		} `json:"results"`
+	} `json:"auto"`
+}
+
+// Search returns the results of kind found for query, best first. Fans
+// are never returned, as they have nothing to download.
+func (s *Searcher) Search(ctx context.Context, query string, kind SearchKind) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("empty search query")
+	}
+	body := map[string]any{
+		"search_text":   query,
+		"search_filter": kind.filter(),
+		"full_page":     false,
+	}
+	data, err := s.client.PostJSON(ctx, s.url, body)
+	if err != nil {
+		return nil, err
+	}
+	var resp searchResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid search response: %w", err)
+	}
+
+	var results []SearchResult
+	for _, r := range resp.Auto.Results {
+		result := SearchResult{ID: r.ID, Name: r.Name, Label: r.IsLabel}
+		switch r.Type {
+		case "b":
+			result.Kind = SearchArtist
+			// The music page lists the whole discography
+			result.URL = strings.TrimSuffix(r.ItemURLRoot, "/") + "/music"
+		case "a":
+			result.Kind = SearchAlbum
+			result.Artist = r.BandName
+			result.URL = r.ItemURLPath
+		case "t":
+			result.Kind = SearchTrack
+			result.Artist = r.BandName
+			result.Album = r.AlbumName
+			result.URL = r.ItemURLPath
+		default:
+			continue
+		}
+		if result.URL == "" || result.URL == "/music" {
+			continue
+		}
+		if kind != SearchAll && result.Kind != kind {
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
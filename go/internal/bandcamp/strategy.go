@@ -0,0 +1,355 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp/dto"
+)
+
+// Names of the strategies, as listed in the page_parsers setting.
+const (
+	StrategyTralbum = "tralbum"
+	StrategyJSONLD  = "json-ld"
+)
+
+// DefaultStrategyNames are the strategies tried by default, in order.
+var DefaultStrategyNames = []string{StrategyTralbum, StrategyJSONLD}
+
+// Strategy is one way of reading the data of an album or track page.
+// Bandcamp changes its markup from time to time; a StrategyChain tries
+// several strategies in turn, so a change breaking one of them does not
+// break parsing altogether.
+type Strategy interface {
+	// Name identifies the strategy in errors and telemetry.
+	Name() string
+
+	// Read reads the page. It returns ErrNoTracks if the page was read
+	// but has no tracks, and another error if the data it reads is not
+	// on the page.
+	Read(ctx context.Context, htmlContent string) (*AlbumPage, error)
+}
+
+// NewStrategies returns the strategies of the given names, in order: see
+// DefaultStrategyNames for the known names.
+func NewStrategies(names ...string) ([]Strategy, error) {
+	strategies := make([]Strategy, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case StrategyTralbum:
+			strategies = append(strategies, TralbumStrategy{})
+		case StrategyJSONLD:
+			strategies = append(strategies, JSONLDStrategy{})
+		default:
+			return nil, fmt.Errorf("unknown page parser %q, must be one of %s", name, strings.Join(DefaultStrategyNames, ", "))
+		}
+	}
+	return strategies, nil
+}
+
+// TralbumStrategy reads the data-tralbum JSON of the page, the most
+// complete source, which the player of the page is built from.
+type TralbumStrategy struct{}
+
+// Name implements Strategy.
+func (TralbumStrategy) Name() string { return StrategyTralbum }
+
+// Read implements Strategy.
+func (TralbumStrategy) Read(_ context.Context, htmlContent string) (*AlbumPage, error) {
+	// Extract the data-tralbum JSON
+	albumData, err := extractAlbumData(htmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve album data: %w", err)
+	}
+
+	// Fix malformed JSON
+	albumData = fixJSON(albumData)
+
+	// Deserialize JSON
+	page := &AlbumPage{}
+	if err := json.Unmarshal([]byte(albumData), &page.Album); err != nil {
+		return nil, fmt.Errorf("failed to parse album JSON: %w", err)
+	}
+	if len(page.Album.Tracks) == 0 {
+		return nil, ErrNoTracks
+	}
+	readPageDetails(page, htmlContent)
+	return page, nil
+}
+
+// JSONLDStrategy reads the JSON-LD structured data of the page, which
+// search engines rely on and so changes seldom. Bandcamp lists the streams
+// of the tracks in their additionalProperty values; pages whose structured
+// data has no stream are not read, as their tracks could not be
+// downloaded.
+type JSONLDStrategy struct{}
+
+// Name implements Strategy.
+func (JSONLDStrategy) Name() string { return StrategyJSONLD }
+
+// Read implements Strategy.
+func (JSONLDStrategy) Read(_ context.Context, htmlContent string) (*AlbumPage, error) {
+	ld := extractStructuredData(htmlContent)
+	if ld == nil {
+		return nil, errors.New("could not find structured data in HTML")
+	}
+	album, err := ld.toJSONAlbum()
+	if err != nil {
+		return nil, err
+	}
+	page := &AlbumPage{Album: album}
+	readPageDetails(page, htmlContent)
+	return page, nil
+}
+
+// readPageDetails fills the values of page read from the page's meta tags
+// and structured data, whatever the strategy.
+func readPageDetails(page *AlbumPage, htmlContent string) {
+	page.StructuredData = extractStructuredData(htmlContent)
+	page.Label = extractSiteName(htmlContent)
+	page.Tags = extractTags(htmlContent)
+	page.NoIndex = isNoIndex(htmlContent)
+}
+
+// StrategyChain reads pages with the first of its strategies that can,
+// and keeps statistics of each, to tell when Bandcamp's markup changed.
+// A StrategyChain is safe for concurrent use.
+//
+// Example:
+//
+//	chain := bandcamp.NewStrategyChain(bandcamp.TralbumStrategy{}, bandcamp.JSONLDStrategy{})
+//	chain.OnResult = func(strategy string, err error) { log.Printf("%s: %v", strategy, err) }
+//	page, err := chain.Read(ctx, htmlContent)
+//	fmt.Println(page.Strategy) // "tralbum"
+type StrategyChain struct {
+	// OnResult, if set, is called after each strategy tried, with the
+	// error it returned (nil or ErrNoTracks when it read the page).
+	OnResult func(strategy string, err error)
+
+	strategies []Strategy
+
+	mu    sync.Mutex
+	stats []StrategyStats
+}
+
+// StrategyStats are the results of a strategy of a StrategyChain.
+type StrategyStats struct {
+	Name string
+
+	// Attempts counts the pages the strategy was tried on, of which it
+	// read Successes (with or without tracks) and failed on Failures.
+	Attempts  int
+	Successes int
+	Failures  int
+
+	// LastError is the error of the last failure, or "".
+	LastError string
+}
+
+// NewStrategyChain returns a chain trying strategies in order, or the
+// default ones if none is given.
+func NewStrategyChain(strategies ...Strategy) *StrategyChain {
+	if len(strategies) == 0 {
+		strategies, _ = NewStrategies(DefaultStrategyNames...)
+	}
+	c := &StrategyChain{strategies: strategies, stats: make([]StrategyStats, len(strategies))}
+	for i, s := range strategies {
+		c.stats[i].Name = s.Name()
+	}
+	return c
+}
+
+// Read reads the page with the first strategy that can, whose name is set
+// in the page's Strategy, and sets FellBack if it is not the first. A page read without tracks is not tried with
+// the other strategies: ErrNoTracks is returned. If no strategy can read
+// the page, a *ParseError with the error of each is returned.
+func (c *StrategyChain) Read(ctx context.Context, htmlContent string) (*AlbumPage, error) {
+	perr := &ParseError{}
+	for i, s := range c.strategies {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := s.Read(ctx, htmlContent)
+		c.record(i, err)
+		if c.OnResult != nil {
+			c.OnResult(s.Name(), err)
+		}
+		if err == nil {
+			page.Strategy = s.Name()
+			page.FellBack = i > 0
+			return page, nil
+		}
+		if errors.Is(err, ErrNoTracks) {
+			return nil, err
+		}
+		perr.Strategies = append(perr.Strategies, s.Name())
+		perr.Errs = append(perr.Errs, err)
+	}
+	if len(perr.Errs) == 0 {
+		return nil, errors.New("no page parser")
+	}
+	return nil, perr
+}
+
+// record counts the result of the strategy at index i.
+func (c *StrategyChain) record(i int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := &c.stats[i]
+	stats.Attempts++
+	if err == nil || errors.Is(err, ErrNoTracks) {
+		stats.Successes++
+	} else {
+		stats.Failures++
+		stats.LastError = err.Error()
+	}
+}
+
+// Stats returns the statistics of the strategies, in the chain's order.
+func (c *StrategyChain) Stats() []StrategyStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]StrategyStats(nil), c.stats...)
+}
+
+// ParseError is returned when no strategy of a StrategyChain could read a
+// page. It unwraps to the errors of the strategies.
+type ParseError struct {
+	Strategies []string
+	Errs       []error
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	parts := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		parts[i] = fmt.Sprintf("%s: %v", e.Strategies[i], err)
+	}
+	return "no page parser could read the page (" + strings.Join(parts, "; ") + ")"
+}
+
+// Unwrap returns the errors of the strategies.
+func (e *ParseError) Unwrap() []error {
+	return e.Errs
+}
+
+// toJSONAlbum converts the structured data of an album (MusicAlbum) or
+// track (MusicRecording) page into the tralbum JSON it mirrors.
+func (ld *structuredData) toJSONAlbum() (dto.JSONAlbum, error) {
+	album := dto.JSONAlbum{
+		Artist:    ld.ByArtist.name(),
+		AlbumData: &dto.JSONAlbumData{AlbumTitle: ld.Name, About: ld.Description, Credits: ld.CreditText},
+	}
+	if id, ok := ld.AdditionalProperty.number("item_id"); ok {
+		album.ID = int64(id)
+	}
+	if id, ok := ld.AdditionalProperty.number("art_id"); ok {
+		artID := int64(id)
+		album.ArtID = &artID
+	}
+	if date, ok := parseBandcampTime(ld.DatePublished); ok {
+		album.ReleaseDate = date
+	}
+
+	switch ld.Type {
+	case "MusicAlbum":
+		album.ItemType = "album"
+		if ld.Track != nil {
+			for _, element := range ld.Track.ItemListElement {
+				album.Tracks = append(album.Tracks, element.Item.toJSONTrack(element.Position, album.Artist))
+			}
+		}
+	case "MusicRecording":
+		album.ItemType = "track"
+		album.Tracks = append(album.Tracks, ld.ldRecording.toJSONTrack(0, album.Artist))
+	default:
+		return album, fmt.Errorf("unsupported structured data type %q", ld.Type)
+	}
+	if len(album.Tracks) == 0 {
+		return album, ErrNoTracks
+	}
+
+	var streams int
+	for _, track := range album.Tracks {
+		if track.HasMp3() {
+			streams++
+		}
+	}
+	if streams == 0 {
+		return album, errors.New("the structured data has no stream URL")
+	}
+	return album, nil
+}
+
+// toJSONTrack converts a recording of the structured data, at position in
+// the album's track list (0 on track pages), into the track of the
+// tralbum JSON it mirrors. Its artist is only set if it is not
+// albumArtist, as in the tralbum JSON.
+func (rec *ldRecording) toJSONTrack(position int, albumArtist string) dto.JSONTrack {
+	track := dto.JSONTrack{Title: rec.Name}
+	if id, ok := rec.AdditionalProperty.number("track_id"); ok {
+		track.ID = int64(id)
+	}
+	if n, ok := rec.AdditionalProperty.number("tracknum"); ok {
+		number := int(n)
+		track.Number = &number
+	} else if position > 0 {
+		track.Number = &position
+	}
+	if seconds, ok := rec.AdditionalProperty.number("duration_secs"); ok {
+		track.Duration = seconds
+	} else {
+		track.Duration = parseISODuration(rec.Duration)
+	}
+	if stream := rec.AdditionalProperty.string("file_mp3-128"); stream != "" {
+		track.File = &dto.JSONMp3File{URL: stream}
+	}
+	if u, err := url.Parse(rec.ID); err == nil && strings.HasPrefix(u.Path, "/track/") {
+		track.Link = u.Path
+	}
+	if artist := rec.ByArtist.name(); artist != albumArtist {
+		track.Artist = artist
+	}
+	return track
+}
+
+// parseBandcampTime parses a date of the structured data, in Bandcamp's
+// format ("14 Mar 2021 00:00:00 GMT") or RFC 3339.
+func parseBandcampTime(s string) (*dto.BandcampTime, bool) {
+	if s == "" {
+		return nil, false
+	}
+	var t dto.BandcampTime
+	if err := t.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+// isoDurationRegex matches the ISO 8601 durations of the structured data:
+// "P00H03M20S" as Bandcamp writes them, or the standard "PT3M20S".
+var isoDurationRegex = regexp.MustCompile(`^PT?(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+
+// parseISODuration returns the seconds of an ISO 8601 duration, or 0 if s
+// is not one.
+func parseISODuration(s string) float64 {
+	match := isoDurationRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if v, err := strconv.ParseFloat(match[i+1], 64); err == nil {
+			d += time.Duration(v * float64(unit))
+		}
+	}
+	return d.Seconds()
+}
@@ -0,0 +1,54 @@
+package bandcamp
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// streamHostCount is the number of numbered stream hosts (t1 to t4) that
+// Bandcamp serves mp3-128 streams from.
+const streamHostCount = 4
+
+// streamHostRegex matches numbered stream hosts such as t4.bcbits.com.
+var streamHostRegex = regexp.MustCompile(`^t(\d+)\.bcbits\.com$`)
+
+// StreamMirrors returns alternate URLs for a Bandcamp stream URL, served by
+// the other numbered stream hosts (t1.bcbits.com to t4.bcbits.com).
+//
+// The hosts share the same paths and tokens, so when one regional host
+// refuses a request, the others can be tried before giving up. Returns nil
+// if streamURL is not on a numbered stream host.
+//
+// Example:
+//
+//	mirrors := bandcamp.StreamMirrors("https://t4.bcbits.com/stream/abc/mp3-128/123?token=x")
+//	// https://t1.bcbits.com/stream/abc/mp3-128/123?token=x, t2..., t3...
+func StreamMirrors(streamURL string) []string {
+	parsedURL, err := url.Parse(streamURL)
+	if err != nil {
+		return nil
+	}
+
+	match := streamHostRegex.FindStringSubmatch(parsedURL.Hostname())
+	if match == nil {
+		return nil
+	}
+	current, _ := strconv.Atoi(match[1])
+
+	var mirrors []string
+	for n := 1; n <= streamHostCount; n++ {
+		if n == current {
+			continue
+		}
+		mirror := *parsedURL
+		mirror.Host = fmt.Sprintf("t%d.bcbits.com", n)
+		if port := parsedURL.Port(); port != "" {
+			mirror.Host += ":" + port
+		}
+		mirrors = append(mirrors, mirror.String())
+	}
+
+	return mirrors
+}
@@ -0,0 +1,160 @@
+package bandcamp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// TagFeedURL is the URL of the feed listing the releases of a tag page,
+// page after page as it is scrolled.
+const TagFeedURL = "https://bandcamp.com/api/hub/2/dig_deeper"
+
+// Orders of the releases of a tag.
+const (
+	TagSortPopular = "popular" // best-selling first
+	TagSortNew     = "new"     // latest first
+)
+
+// tagSorts are the sort values of the feed, by order.
+var tagSorts = map[string]string{
+	TagSortPopular: "pop",
+	TagSortNew:     "date",
+}
+
+// CheckTagSort returns an error if sort is not an order of the releases of
+// a tag; "" is TagSortPopular.
+func CheckTagSort(sort string) error {
+	if _, ok := tagSorts[sort]; !ok && sort != "" {
+		return fmt.Errorf("unknown tag sort %q, must be %s or %s", sort, TagSortPopular, TagSortNew)
+	}
+	return nil
+}
+
+// TagFromURL returns the tag of a tag page URL, e.g. "ambient" for
+// https://bandcamp.com/tag/ambient, and whether rawURL is one.
+func TagFromURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	if host := strings.ToLower(u.Hostname()); host != "bandcamp.com" && host != "www.bandcamp.com" {
+		return "", false
+	}
+	tag, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/tag/")
+	if !ok || tag == "" || strings.Contains(tag, "/") {
+		return "", false
+	}
+	return strings.ToLower(tag), true
+}
+
+// FeedRelease is a release listed by a TagFeed or a DiscoverFeed.
+type FeedRelease struct {
+	URL    string
+	Title  string
+	Artist string
+
+	// ItemType is "album" or "track".
+	ItemType string
+}
+
+// TagFeed lists the releases of Bandcamp's tag pages (bandcamp.com/tag/...)
+// from the JSON feed the pages load as they are scrolled.
+//
+// Example:
+//
+//	feed := bandcamp.NewTagFeed(client, "")
+//	releases, err := feed.Releases(ctx, "ambient", bandcamp.TagSortNew, 50)
+//	for _, r := range releases {
+//	    fmt.Println(r.Artist, "-", r.Title, r.URL)
+//	}
+type TagFeed struct {
+	client APIClient
+	url    string
+}
+
+// NewTagFeed returns a client of the tag feed at url, or at TagFeedURL if
+// url is "", making its requests with client.
+func NewTagFeed(client APIClient, url string) *TagFeed {
+	if url == "" {
+		url = TagFeedURL
+	}
+	return &TagFeed{client: client, url: url}
+}
+
+// tagFeedPage is a page of the tag feed.
+type tagFeedPage struct {
+	OK            bool   `json:"ok"`
+	ErrorMessage  string `json:"error_message"`
+	MoreAvailable bool   `json:"more_available"`
+	Items         []struct {
+		TralbumType string `json:"tralbum_type"` // "a" or "t"
+		TralbumURL  string `json:"tralbum_url"`
+		Title       string `json:"title"`
+		Artist      string `json:"artist"`
+	} `json:"items"`
+}
+
+// Releases returns the first limit releases of tag in order sort (see
+// CheckTagSort), reading as many pages of the feed as needed. Returns
+// ErrNoAlbumFound if the tag has no release.
+func (f *TagFeed) Releases(ctx context.Context, tag, sort string, limit int) ([]FeedRelease, error) {
+	if err := CheckTagSort(sort); err != nil {
+		return nil, err
+	}
+	if sort == "" {
+		sort = TagSortPopular
+	}
+
+	var releases []FeedRelease
+	seen := make(map[string]bool)
+	for page := 1; limit <= 0 || len(releases) < limit; page++ {
+		body := map[string]any{
+			"filters": map[string]any{
+				"format":   "all",
+				"location": 0,
+				"sort":     tagSorts[sort],
+				"tags":     []string{tag},
+			},
+			"page": page,
+		}
+		data, err := f.client.PostJSON(ctx, f.url, body)
+		if err != nil {
+			return releases, err
+		}
+		var resp tagFeedPage
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return releases, fmt.Errorf("invalid tag feed response: %w", err)
+		}
+		if !resp.OK {
+			if resp.ErrorMessage == "" {
+				resp.ErrorMessage = "unknown error"
+			}
+			return releases, fmt.Errorf("tag feed error: %s", resp.ErrorMessage)
+		}
+
+		for _, item := range resp.Items {
+			if item.TralbumURL == "" || seen[item.TralbumURL] {
+				continue
+			}
+			seen[item.TralbumURL] = true
+			release := FeedRelease{URL: item.TralbumURL, Title: item.Title, Artist: item.Artist, ItemType: "album"}
+			if item.TralbumType == "t" {
+				release.ItemType = "track"
+			}
+			releases = append(releases, release)
+			if len(releases) == limit {
+				break
+			}
+		}
+		if !resp.MoreAvailable || len(resp.Items) == 0 {
+			break
+		}
+	}
+	if len(releases) == 0 {
+		return nil, ErrNoAlbumFound
+	}
+	return releases, nil
+}
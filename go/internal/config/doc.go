@@ -1,9 +1,10 @@
 // Package config provides configuration management for bandcamp-downloader.
 //
 // This package handles:
-//   - Loading and saving settings from JSON files
+//   - Loading and saving settings from JSON, TOML or YAML files
 //   - Default configuration values
-//   - Conversion to PathConfig and TrackConfig for other packages
+//   - Conversion to PathConfig, TrackConfig and http.ClientConfig for other packages
+//   - Validation of proxy and network settings
 //
 // # Default Settings
 //
@@ -21,11 +22,49 @@
 //	    // Uses defaults if file doesn't exist
 //	}
 //
+// Files ending in .toml, .yaml or .yml are read as TOML or YAML, with the
+// same keys as JSON; Save writes the format of the path's extension.
+//
+// # Sections
+//
+// Settings embeds one struct per section (Paths, Concurrency, Network,
+// Artwork, Tags, Playlist, Download, Integrations and UI), each a nested
+// object of the JSON file. Their fields are promoted, so code can use
+// either settings.Network.IPVersion or settings.IPVersion. Files in the
+// flat layout of earlier versions, with every key at the top level, are
+// read as well; Save always writes the sections.
+//
+// # Overrides
+//
+// "overrides" lists blocks of settings applied to the releases matching an
+// artist domain or URL pattern (see Override). For returns the settings of
+// a release:
+//
+//	albumSettings, err := settings.For(albumURL)
+//	// albumSettings == settings if no override matches
+//
+// # Profiles
+//
+// "profiles" holds named blocks of settings; WithProfile returns the
+// settings with one of them applied, as selected by the -profile flag:
+//
+//	settings, err = settings.WithProfile("archive")
+//
 // # Saving Settings
 //
 //	settings.DownloadsPath = "/custom/path/{artist}/{album}"
 //	err := settings.Save("/path/to/config.json")
 //
+// # Environment Variables
+//
+// ApplyEnv overrides settings with BANDCAMP_DL_<JSON KEY> environment
+// variables, e.g. to configure a container without a config file:
+//
+//	// BANDCAMP_DL_DOWNLOADS_PATH=/music/{artist}/{album}
+//	if err := settings.ApplyEnv(); err != nil {
+//	    // e.g. "BANDCAMP_DL_MAX_CONCURRENT_TRACKS: invalid integer"
+//	}
+//
 // # Configuration Options
 //
 // Settings includes options for:
@@ -34,6 +73,45 @@
 //   - Retry behavior
 //   - Cover art handling
 //   - Playlist generation
-//   - ID3 tag modification
-//   - Proxy configuration
+//   - ID3 tag modification, including the source frames for scrobblers
+//   - ListenBrainz mapping checks
+//   - Proxy configuration (system, none, or manual with rotation)
+//   - IP version and DNS resolver
+//   - TLS root certificates
+//   - Skipping releases the artist asked not to be indexed or streamed
+//   - Progress sinks: log file, syslog or webhook
+//   - Zip archives of completed albums
+//   - Staging for beets imports (manifest and import command)
+//
+// # Proxies
+//
+// With "proxy_type": "manual", requests are rotated across the configured
+// proxies, which may be HTTP or SOCKS5. A proxy that fails is skipped for
+// "proxy_cooldown" seconds:
+//
+//	settings.ProxyType = "manual"
+//	settings.Proxies = []string{"socks5://127.0.0.1:1080", "http://10.0.0.2:3128"}
+//	if err := settings.Validate(); err != nil {
+//	    // invalid proxy URL
+//	}
+//
+// # Network
+//
+// "ip_version" forces IPv4 (4) or IPv6 (6) connections, and "dns_servers"
+// replaces the system resolver with plain DNS, DNS over TLS (tls://) or
+// DNS over HTTPS (https://) servers:
+//
+//	settings.IPVersion = 4
+//	settings.DNSServers = []string{"https://cloudflare-dns.com/dns-query"}
+//
+// # Progress Sinks
+//
+// "progress_sinks" sends the progress messages to other destinations as
+// well as the console (see the sink package):
+//
+//	settings.ProgressSinks = []string{
+//	    "/var/log/bandcamp-dl.log",
+//	    "syslog:",
+//	    "https://discord.com/api/webhooks/...",
+//	}
 package config
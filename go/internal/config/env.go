@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables read by ApplyEnv.
+const EnvPrefix = "BANDCAMP_DL_"
+
+// ApplyEnv overrides settings with environment variables named after their
+// JSON keys in upper case, prefixed with EnvPrefix, whatever their section:
+//
+//	BANDCAMP_DL_DOWNLOADS_PATH=/music/{artist}/{album}
+//	BANDCAMP_DL_MAX_CONCURRENT_TRACKS=4
+//	BANDCAMP_DL_SAVE_COVER_ART_IN_FOLDER=true
+//	BANDCAMP_DL_DNS_SERVERS=1.1.1.1,8.8.8.8
+//
+// Booleans accept the values of strconv.ParseBool, lists are
+// comma-separated, and maps are comma-separated key=value pairs, e.g.
+// BANDCAMP_DL_TAG_FIELDS=comments=keep,lyrics=keep. Variables that are not
+// set leave the setting unchanged, while set but empty variables clear it.
+// This allows configuring the downloader without a config file, e.g. in a
+// container.
+//
+// Returns an error naming the variable if a value cannot be parsed.
+func (s *Settings) ApplyEnv() error {
+	return applyEnv(reflect.ValueOf(s).Elem())
+}
+
+// applyEnv applies the environment variables to the fields of the struct
+// v, recursing into the sections of Settings.
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Anonymous && t.Field(i).Type.Kind() == reflect.Struct {
+			if err := applyEnv(v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := EnvPrefix + strings.ToUpper(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setField parses value into field according to its kind.
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			field.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", field.Type())
+		}
+		entries := make(map[string]string)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid entry %q, must be key=value", item)
+			}
+			entries[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+		field.Set(reflect.ValueOf(entries))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
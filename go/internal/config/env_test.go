@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("BANDCAMP_DL_DOWNLOADS_PATH", "/music/{artist}/{album}")
+	t.Setenv("BANDCAMP_DL_MAX_CONCURRENT_TRACKS", "4")
+	t.Setenv("BANDCAMP_DL_DOWNLOAD_RETRY_COOLDOWN", "0.5")
+	t.Setenv("BANDCAMP_DL_SAVE_COVER_ART_IN_FOLDER", "true")
+	t.Setenv("BANDCAMP_DL_SAVE_COVER_ART_IN_TAGS", "")
+	t.Setenv("BANDCAMP_DL_DNS_SERVERS", "1.1.1.1, 8.8.8.8,")
+	t.Setenv("BANDCAMP_DL_TAG_FIELDS", "comments=keep, lyrics = empty")
+	t.Setenv("BANDCAMP_DL_LANGUAGE", "fr")
+
+	s := DefaultSettings()
+	if err := s.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	if s.DownloadsPath != "/music/{artist}/{album}" {
+		t.Errorf("DownloadsPath = %q", s.DownloadsPath)
+	}
+	if s.MaxConcurrentTracksDownload != 4 {
+		t.Errorf("MaxConcurrentTracksDownload = %d, want 4", s.MaxConcurrentTracksDownload)
+	}
+	if s.DownloadRetryCooldown != 0.5 {
+		t.Errorf("DownloadRetryCooldown = %v, want 0.5", s.DownloadRetryCooldown)
+	}
+	if !s.SaveCoverArtInFolder || s.SaveCoverArtInTags {
+		t.Errorf("SaveCoverArtInFolder = %v, SaveCoverArtInTags = %v", s.SaveCoverArtInFolder, s.SaveCoverArtInTags)
+	}
+	if strings.Join(s.DNSServers, "|") != "1.1.1.1|8.8.8.8" {
+		t.Errorf("DNSServers = %q", s.DNSServers)
+	}
+	if len(s.TagFields) != 2 || s.TagFields["comments"] != "keep" || s.TagFields["lyrics"] != "empty" {
+		t.Errorf("TagFields = %v", s.TagFields)
+	}
+	if s.Language != "fr" {
+		t.Errorf("Language = %q, want fr", s.Language)
+	}
+
+	// Unset variables keep the defaults
+	if s.FileNameFormat != DefaultSettings().FileNameFormat {
+		t.Errorf("FileNameFormat = %q, want default", s.FileNameFormat)
+	}
+}
+
+func TestApplyEnv_Invalid(t *testing.T) {
+	t.Setenv("BANDCAMP_DL_MAX_CONCURRENT_ALBUMS", "many")
+
+	err := DefaultSettings().ApplyEnv()
+	if err == nil || !strings.Contains(err.Error(), "BANDCAMP_DL_MAX_CONCURRENT_ALBUMS") {
+		t.Errorf("ApplyEnv() error = %v, want one naming the variable", err)
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// format is the encoding of a config file, chosen by its extension.
+type format int
+
+const (
+	formatJSON format = iota
+	formatTOML
+	formatYAML
+)
+
+// formatOf returns the format of the config file at path: TOML for .toml,
+// YAML for .yaml and .yml, JSON otherwise.
+func formatOf(path string) format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return formatTOML
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatJSON
+	}
+}
+
+// toJSON converts a config file in format f to JSON. TOML and YAML files
+// use the same keys as JSON ones, so every format is decoded with the JSON
+// tags of Settings, including the flat layout of earlier versions.
+func toJSON(data []byte, f format) ([]byte, error) {
+	var doc map[string]any
+	switch f {
+	case formatTOML:
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case formatYAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// fromJSON converts the JSON encoding of settings to format f.
+func fromJSON(data []byte, f format) ([]byte, error) {
+	if f == formatJSON {
+		return data, nil
+	}
+
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	normalize(doc)
+
+	var buf bytes.Buffer
+	switch f {
+	case formatTOML:
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = ""
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	case formatYAML:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// normalize prepares a decoded JSON document for encoding in another
+// format, in place: null values, e.g. of empty lists, which TOML cannot
+// represent, are removed, and numbers become integers when they are, so
+// they are not written as 10.0.
+func normalize(doc map[string]any) {
+	for key, value := range doc {
+		switch v := value.(type) {
+		case nil:
+			delete(doc, key)
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				doc[key] = n
+			} else if f, err := v.Float64(); err == nil {
+				doc[key] = f
+			}
+		case map[string]any:
+			normalize(v)
+		}
+	}
+}
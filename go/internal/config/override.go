@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Override is a block of settings applied to the releases it matches,
+// over the global settings:
+//
+//	"overrides": [
+//	  {
+//	    "match": "fieldrecordings.bandcamp.com",
+//	    "paths": {"downloads_path": "/music/Field Recordings/{artist}/{album}"}
+//	  },
+//	  {
+//	    "match": "https://*.bandcamp.com/album/live-*",
+//	    "playlist": {"create_playlist": true}
+//	  }
+//	]
+//
+// The block holds settings in the same layout as the config file, sections
+// or flat keys, next to its "match" key.
+type Override struct {
+	// Match is the artist domain the override applies to, e.g.
+	// "label.bandcamp.com" or a custom domain, or a pattern of release
+	// URLs where * matches any characters.
+	Match string
+
+	// raw is the block as read from the config file.
+	raw json.RawMessage
+}
+
+// UnmarshalJSON reads an override block, which must have a "match" key.
+func (o *Override) UnmarshalJSON(data []byte) error {
+	var block struct {
+		Match string `json:"match"`
+	}
+	if err := json.Unmarshal(data, &block); err != nil {
+		return err
+	}
+	if strings.TrimSpace(block.Match) == "" {
+		return errors.New(`override without a "match" key`)
+	}
+	o.Match = strings.TrimSpace(block.Match)
+	o.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON writes the override block as it was read.
+func (o Override) MarshalJSON() ([]byte, error) {
+	if o.raw != nil {
+		return o.raw, nil
+	}
+	return json.Marshal(map[string]string{"match": o.Match})
+}
+
+// Matches reports whether the override applies to the release at rawURL.
+func (o *Override) Matches(rawURL string) bool {
+	if !strings.ContainsAny(o.Match, "/*") {
+		u, err := url.Parse(rawURL)
+		return err == nil && strings.EqualFold(u.Hostname(), o.Match)
+	}
+
+	parts := strings.Split(o.Match, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re, err := regexp.Compile("(?i)^" + strings.Join(parts, ".*") + "$")
+	return err == nil && re.MatchString(strings.TrimSuffix(rawURL, "/"))
+}
+
+// For returns the settings to download the release at rawURL with: s, or
+// a copy of s with every matching override applied in order.
+//
+// Example:
+//
+//	albumSettings, err := settings.For("https://label.bandcamp.com/album/name")
+func (s *Settings) For(rawURL string) (*Settings, error) {
+	var matching []*Override
+	for i := range s.Overrides {
+		if s.Overrides[i].Matches(rawURL) {
+			matching = append(matching, &s.Overrides[i])
+		}
+	}
+	if len(matching) == 0 {
+		return s, nil
+	}
+	return s.merge(matching)
+}
+
+// merge returns a copy of s, without its overrides, with overrides applied
+// in order.
+func (s *Settings) merge(overrides []*Override) (*Settings, error) {
+	merged, err := s.clone()
+	if err != nil {
+		return nil, err
+	}
+	merged.Overrides = nil
+
+	for _, o := range overrides {
+		if err := json.Unmarshal(o.raw, merged); err != nil {
+			return nil, fmt.Errorf("override %q: %w", o.Match, err)
+		}
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("override %q: %w", overrides[len(overrides)-1].Match, err)
+	}
+	return merged, nil
+}
+
+// clone returns a deep copy of s, made through JSON so that the lists of s
+// are not shared.
+func (s *Settings) clone() (*Settings, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	c := &Settings{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
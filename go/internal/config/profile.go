@@ -0,0 +1,55 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithProfile returns a copy of s with the profile name applied: a named
+// block of settings from "profiles", in the same layout as the config file,
+// e.g. to switch between an archival and a portable setup:
+//
+//	"profiles": {
+//	  "archive": {"download": {"download_format": "flac", "save_metadata_json": true, "save_track_info": "sidecar"}},
+//	  "phone": {"download": {"transcode_format": "opus", "transcode_bitrate": 96}, "artwork": {"cover_art_in_folder_max_size": 300}}
+//	}
+//
+// The copy keeps the overrides of s, which still apply over the profile.
+//
+// Example:
+//
+//	settings, err = settings.WithProfile("phone")
+func (s *Settings) WithProfile(name string) (*Settings, error) {
+	raw, ok := s.Profiles[name]
+	if !ok {
+		if len(s.Profiles) == 0 {
+			return nil, fmt.Errorf("unknown profile %q, the config file has no profiles", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q, must be one of %s", name, strings.Join(s.ProfileNames(), ", "))
+	}
+
+	merged, err := s.clone()
+	if err != nil {
+		return nil, err
+	}
+	merged.Profiles = nil
+	if err := json.Unmarshal(raw, merged); err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	return merged, nil
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func (s *Settings) ProfileNames() []string {
+	names := make([]string, 0, len(s.Profiles))
+	for name := range s.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
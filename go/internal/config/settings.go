@@ -2,30 +2,173 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/console"
+	"github.com/handiism/bandcamp-downloader/internal/filter"
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/i18n"
+	ioutils "github.com/handiism/bandcamp-downloader/internal/io"
+	"github.com/handiism/bandcamp-downloader/internal/library"
 	"github.com/handiism/bandcamp-downloader/internal/model"
+	"github.com/handiism/bandcamp-downloader/internal/schedule"
 )
 
-// Settings holds all configuration options.
+// Settings holds all configuration options, grouped in sections that are
+// nested objects of the JSON file:
+//
+//	{
+//	  "paths": {"downloads_path": "/music/{artist}/{album}"},
+//	  "network": {"ip_version": 4}
+//	}
+//
+// The sections are embedded, so their fields are also accessible directly,
+// e.g. settings.IPVersion. Files written before sections existed, with every
+// key at the top level, are still read (see UnmarshalJSON).
 type Settings struct {
-	// Download settings
-	DownloadsPath               string  `json:"downloads_path"`
-	MaxConcurrentAlbumsDownload int     `json:"max_concurrent_albums"`
-	MaxConcurrentTracksDownload int     `json:"max_concurrent_tracks"`
-	DownloadMaxRetries          int     `json:"download_max_retries"`
-	DownloadRetryCooldown       float64 `json:"download_retry_cooldown"`
-	DownloadRetryExponent       float64 `json:"download_retry_exponent"`
-	AllowedFileSizeDifference   float64 `json:"allowed_file_size_difference"`
-	DownloadArtistDiscography   bool    `json:"download_artist_discography"`
-
-	// File naming
+	Paths        `json:"paths"`
+	Concurrency  `json:"concurrency"`
+	Network      `json:"network"`
+	Artwork      `json:"artwork"`
+	Tags         `json:"tags"`
+	Playlist     `json:"playlist"`
+	Download     `json:"download"`
+	Integrations `json:"integrations"`
+	UI           `json:"ui"`
+
+	// Overrides are blocks of settings applied to the releases matching an
+	// artist domain or URL pattern, see For.
+	Overrides []Override `json:"overrides,omitempty"`
+
+	// Profiles are named blocks of settings, selected with WithProfile.
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+}
+
+// Paths holds where and under which names albums are saved.
+type Paths struct {
+	DownloadsPath          string `json:"downloads_path"`
 	FileNameFormat         string `json:"file_name_format"`
 	CoverArtFileNameFormat string `json:"cover_art_file_name_format"`
 	PlaylistFileNameFormat string `json:"playlist_file_name_format"`
 
-	// Cover art settings
+	// Singles (releases of a /track/ page) are saved under
+	// SinglesDownloadsPath with SinglesFileNameFormat, e.g.
+	// "/music/{artist}/Singles" and "{title}.{ext}"; "" uses DownloadsPath
+	// and FileNameFormat.
+	SinglesDownloadsPath  string `json:"singles_downloads_path"`
+	SinglesFileNameFormat string `json:"singles_file_name_format"`
+
+	// Compilations
+	VariousArtistsFolder bool   `json:"various_artists_folder"`
+	VariousArtistsName   string `json:"various_artists_name"`
+
+	// TrackNumbering numbers the tracks of multi-disc albums per "disc",
+	// for {disc}-{tracknum} file names, or "sequential" across discs.
+	TrackNumbering string `json:"track_numbering"`
+
+	// FallbackDownloadsPath is the folder albums are downloaded under when
+	// their folder cannot be written to (e.g. a read-only or unmounted
+	// drive), in place of the library root; "" fails the album instead.
+	FallbackDownloadsPath string `json:"fallback_downloads_path"`
+
+	// LinkViews are other layouts of the library, as folder patterns with
+	// the placeholders of DownloadsPath and {genre} (each tag of the
+	// release), e.g. "/music/by-year/{year}/{artist} - {album}". Completed
+	// albums are linked there: as a symlink to the album folder with
+	// LinkMode "symlink", or as a folder of hardlinks to its files with
+	// "hardlink".
+	LinkViews []string `json:"link_views"`
+	LinkMode  string   `json:"link_mode"`
+
+	// LongPaths stops truncating paths to the Windows MAX_PATH limit of
+	// 260 characters, and uses \\?\ paths on Windows, which are not
+	// limited, for deep library structures.
+	LongPaths bool `json:"long_paths"`
+}
+
+// Concurrency holds how many downloads run in parallel.
+type Concurrency struct {
+	MaxConcurrentAlbumsDownload int `json:"max_concurrent_albums"`
+	MaxConcurrentTracksDownload int `json:"max_concurrent_tracks"`
+
+	// Segmented downloads: tracks of at least ParallelSegmentsMinSize MB
+	// are downloaded as ParallelSegments byte ranges in parallel, if the
+	// server supports ranges. 0 or 1 disables it.
+	ParallelSegments        int     `json:"parallel_segments"`
+	ParallelSegmentsMinSize float64 `json:"parallel_segments_min_size"`
+}
+
+// Network holds the options of the HTTP client: retries, proxies,
+// resolver, timeouts and TLS.
+type Network struct {
+	// Retry policy of every request: the delay before retry n is
+	// DownloadRetryCooldown * DownloadRetryExponent^n seconds, capped to
+	// DownloadRetryMaxCooldown and randomized by ±DownloadRetryJitter.
+	// DownloadRetryStatuses lists the retried status codes (429) or
+	// classes (5xx).
+	DownloadMaxRetries       int      `json:"download_max_retries"`
+	DownloadRetryCooldown    float64  `json:"download_retry_cooldown"`
+	DownloadRetryExponent    float64  `json:"download_retry_exponent"`
+	DownloadRetryMaxCooldown float64  `json:"download_retry_max_cooldown"`
+	DownloadRetryJitter      float64  `json:"download_retry_jitter"`
+	DownloadRetryStatuses    []string `json:"download_retry_statuses"`
+
+	// Circuit breaker: after CircuitBreakerThreshold consecutive failures
+	// of a host (0 disables it), its requests are paused for
+	// CircuitBreakerCooldown seconds.
+	CircuitBreakerThreshold int     `json:"circuit_breaker_threshold"`
+	CircuitBreakerCooldown  float64 `json:"circuit_breaker_cooldown"`
+
+	// Proxy settings. With ProxyType "manual", requests are rotated across
+	// ProxyAddress:ProxyPort and every entry of Proxies (http://, https://
+	// or socks5:// URLs); a failed proxy is skipped for ProxyCooldown seconds.
+	ProxyType     string   `json:"proxy_type"` // none, system, manual
+	ProxyAddress  string   `json:"proxy_address"`
+	ProxyPort     int      `json:"proxy_port"`
+	Proxies       []string `json:"proxies"`
+	ProxyCooldown float64  `json:"proxy_cooldown"`
+
+	// IPVersion forces IPv4 (4) or IPv6 (6), 0 for both. DNSServers
+	// replaces the system resolver, e.g. "1.1.1.1", "tls://dns.google" or
+	// "https://cloudflare-dns.com/dns-query".
+	IPVersion  int      `json:"ip_version"`
+	DNSServers []string `json:"dns_servers"`
+
+	// Timeouts, in seconds, 0 for the default. RequestTimeout bounds page,
+	// artwork and size requests; track downloads have no overall deadline
+	// and are only aborted when no data arrives for DownloadIdleTimeout,
+	// never if it is negative.
+	RequestTimeout        float64 `json:"request_timeout"`
+	ConnectTimeout        float64 `json:"connect_timeout"`
+	TLSHandshakeTimeout   float64 `json:"tls_handshake_timeout"`
+	ResponseHeaderTimeout float64 `json:"response_header_timeout"`
+	DownloadIdleTimeout   float64 `json:"download_idle_timeout"`
+
+	// TLS settings. CACertFile is a PEM file of extra root certificates,
+	// e.g. of a TLS-intercepting corporate proxy. InsecureSkipVerify turns
+	// certificate verification off and should only be a last resort.
+	CACertFile         string `json:"ca_cert_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+
+	// CookiesFile is a cookies.txt file (Netscape format) exported from a
+	// browser logged in to Bandcamp, whose session is needed to redeem
+	// download codes. "" sends no cookie.
+	CookiesFile string `json:"cookies_file"`
+}
+
+// Artwork holds how cover art is saved in album folders and tags.
+type Artwork struct {
 	SaveCoverArtInFolder    bool `json:"save_cover_art_in_folder"`
 	SaveCoverArtInTags      bool `json:"save_cover_art_in_tags"`
 	CoverArtInFolderResize  bool `json:"cover_art_in_folder_resize"`
@@ -33,57 +176,383 @@ type Settings struct {
 	CoverArtInTagsResize    bool `json:"cover_art_in_tags_resize"`
 	CoverArtInTagsMaxSize   int  `json:"cover_art_in_tags_max_size"`
 	ConvertCoverArtToJPG    bool `json:"convert_cover_art_to_jpg"`
+	CoverArtJPEGQuality     int  `json:"cover_art_jpeg_quality"` // 1-100
+
+	// Artwork cache: ArtworkCacheDir is where downloaded artwork is kept with
+	// its ETag/Last-Modified, empty to disable. RefreshEmbeddedArtwork
+	// re-embeds artwork in existing files when it changed on Bandcamp.
+	ArtworkCacheDir        string `json:"artwork_cache_dir"`
+	RefreshEmbeddedArtwork bool   `json:"refresh_embedded_artwork"`
 
-	// Playlist settings
+	// Embedded pictures: CoverArtPictureType is the picture type the cover
+	// art is embedded as (front_cover, media, artist, ...). ExtraPictureURL
+	// is a second image, e.g. a back cover, embedded as ExtraPictureType;
+	// it is usually set in a per-release override.
+	CoverArtPictureType string `json:"cover_art_picture_type"`
+	ExtraPictureURL     string `json:"extra_picture_url"`
+	ExtraPictureType    string `json:"extra_picture_type"`
+}
+
+// Tags holds how the downloaded files are tagged. TagSource writes a TXXX
+// SOURCE=bandcamp frame and the release and track URLs (WOAS, WOAF), to
+// help scrobblers match releases.
+//
+// TagFields sets what is done with each field when ModifyTags is on, by
+// field name (see audio.TagFields): "modify" writes the Bandcamp value,
+// "empty" clears it and "keep" leaves the existing value. Fields that are
+// not listed are modified, except comments, which are cleared.
+//
+// The values of an ID3v1 tag are copied into the ID3v2 tag for the fields
+// that are not modified; StripID3v1 then removes the ID3v1 tag.
+type Tags struct {
+	ModifyTags bool              `json:"modify_tags"`
+	TagSource  bool              `json:"tag_source"`
+	TagFields  map[string]string `json:"tag_fields"`
+	StripID3v1 bool              `json:"strip_id3v1"`
+}
+
+// Playlist holds the playlist created in each album folder.
+type Playlist struct {
 	CreatePlaylist bool   `json:"create_playlist"`
-	PlaylistFormat string `json:"playlist_format"` // m3u, pls, wpl, zpl
+	PlaylistFormat string `json:"playlist_format"` // m3u, m3u8, pls, wpl, zpl
 	M3UExtended    bool   `json:"m3u_extended"`
 
-	// Tag settings
-	ModifyTags bool `json:"modify_tags"`
+	// M3UAlbumInfo adds the #PLAYLIST, #EXTALB and #EXTART directives
+	// with the album's title and artist to extended M3U and M3U8
+	// playlists, and M3UArtwork an #EXTIMG directive with the cover art
+	// saved in the folder (see Artwork.SaveCoverArtInFolder).
+	M3UAlbumInfo bool `json:"m3u_album_info"`
+	M3UArtwork   bool `json:"m3u_artwork"`
+
+	// PlaylistEncoding is the character encoding of the playlist files:
+	// "utf-8", "utf-8-bom", "windows-1252" or "auto" (a byte order mark
+	// only when the playlist is not ASCII); see audio.PlaylistEncoding.
+	// PlaylistLineEndings is "lf" or "crlf".
+	PlaylistEncoding    string `json:"playlist_encoding"`
+	PlaylistLineEndings string `json:"playlist_line_endings"`
+
+	// SmartPlaylists gather the tracks of the whole library matching a
+	// query, written by the "playlists -smart" subcommand to
+	// SmartPlaylistsPath, or to a "Playlists" folder of the library root
+	// if it is empty.
+	SmartPlaylists     []SmartPlaylist `json:"smart_playlists,omitempty"`
+	SmartPlaylistsPath string          `json:"smart_playlists_path"`
+}
 
-	// Proxy settings
-	ProxyType    string `json:"proxy_type"` // none, system, manual
-	ProxyAddress string `json:"proxy_address"`
-	ProxyPort    int    `json:"proxy_port"`
+// SmartPlaylist is a playlist of the tracks of the library for which
+// Query, an expression over library.TrackFields, is true.
+type SmartPlaylist struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// Download holds what is downloaded and what is saved along with it.
+type Download struct {
+	AllowedFileSizeDifference float64 `json:"allowed_file_size_difference"`
+	DownloadArtistDiscography bool    `json:"download_artist_discography"`
+
+	// IgnoreArtistRestrictions downloads releases whose artist asked for
+	// them not to be indexed or streamed, which are skipped otherwise.
+	IgnoreArtistRestrictions bool `json:"ignore_artist_restrictions"`
+
+	// Liner notes: SaveTrackInfo saves the tracks' descriptions and credits
+	// as "sidecar" <track>.txt files, in a per-album "readme" (README.txt),
+	// or not at all ("none").
+	SaveTrackInfo string `json:"save_track_info"`
+
+	// SaveMetadataJSON writes a metadata.json describing the release
+	// (tags, about, credits, track list...) in each album folder.
+	SaveMetadataJSON bool `json:"save_metadata_json"`
+
+	// AlbumArchive packages each completed album folder into a zip next to
+	// it: "zip" replaces the folder with the archive, "zip_keep" keeps both,
+	// and "none" disables archiving.
+	AlbumArchive string `json:"album_archive"`
+
+	// CheckQuality makes Verify report the tracks whose MP3 files are at
+	// most the 128 kbps of Bandcamp streams, as candidates for an upgrade.
+	// Fix upgrades those bought by the account of Network.CookiesFile to
+	// UpgradeFormat, from the download pages of its purchases.
+	CheckQuality bool `json:"check_quality"`
+
+	// UpgradeFormat is the format of the purchases the low-quality tracks
+	// are upgraded to (see bandcamp.DownloadFormats). The MP3 formats keep
+	// the name of the files; the others keep it with their own extension.
+	UpgradeFormat string `json:"upgrade_format"`
+
+	// DownloadFormat is the format of the releases bought by the account
+	// of Network.CookiesFile (see bandcamp.DownloadFormats): once the
+	// streams of such an album are downloaded, they are replaced with the
+	// files of its download page in this format, as Fix upgrades tracks.
+	// The releases not bought keep their streams, as they all do with "".
+	DownloadFormat string `json:"download_format"`
+
+	// TranscodeFormat converts the tracks of each completed album to this
+	// format (see audio.TranscodeFormats) by running TranscodeCommand,
+	// e.g. "opus" at a TranscodeBitrate of 96 kbps for a phone; a bitrate
+	// of 0 is the encoder's default. The tags are copied, the pictures
+	// not. Tracks already in the format are left as they are, as all are
+	// with "".
+	TranscodeFormat  string `json:"transcode_format"`
+	TranscodeBitrate int    `json:"transcode_bitrate"`
+	TranscodeCommand string `json:"transcode_command"`
+
+	// CheckDuration compares the duration of each downloaded MP3 with the
+	// one of the release's metadata, to catch streams cut short: "warn"
+	// reports the tracks that are off, "redownload" also downloads them
+	// once more, and "off" disables the check.
+	CheckDuration string `json:"check_duration"`
+
+	// PageParsers are the ways of reading release pages tried in turn
+	// (see bandcamp.StrategyChain), so a change of Bandcamp's markup
+	// breaking one does not stop downloads: "tralbum" (the player's JSON)
+	// and "json-ld" (the structured data for search engines).
+	PageParsers []string `json:"page_parsers"`
+
+	// MobileAPI reads releases from the API of Bandcamp's mobile app
+	// before trying the PageParsers, and discographies from the account's
+	// details rather than its music page, whose releases are then not
+	// fetched at all.
+	MobileAPI bool `json:"mobile_api"`
+
+	// ConfirmIfLargerThanMB makes the CLI and TUI ask for confirmation
+	// before downloading more than this many MB in a run, e.g. after
+	// pasting the URL of a label with hundreds of releases; 0 never asks.
+	ConfirmIfLargerThanMB int `json:"confirm_if_larger_than_mb"`
+
+	// LimitAlbums and LimitTracks cap how many releases and tracks a run
+	// downloads, in the order they were found, e.g. to sample a large
+	// label; 0 is no limit.
+	LimitAlbums int `json:"limit_albums"`
+	LimitTracks int `json:"limit_tracks"`
+
+	// SampleAlbums downloads only this many releases of each discography,
+	// picked at random, to explore a label without mirroring it; 0
+	// downloads them all. SampleSeed, when not 0, picks the same sample
+	// on every run.
+	SampleAlbums int   `json:"sample_albums"`
+	SampleSeed   int64 `json:"sample_seed"`
+
+	// Tag pages (bandcamp.com/tag/...): TagLimit is how many of the
+	// releases of a tag are downloaded, in the order TagSort, "popular"
+	// (best-selling first) or "new" (latest first).
+	TagLimit int    `json:"tag_limit"`
+	TagSort  string `json:"tag_sort"`
+
+	// Discover pages (bandcamp.com/discover/genre/sub-genre):
+	// DiscoverLimit is how many of the best-selling releases are
+	// downloaded, in DiscoverFormat ("all", "digital", "vinyl", "cd" or
+	// "cassette") unless the page's URL has a format.
+	DiscoverLimit  int    `json:"discover_limit"`
+	DiscoverFormat string `json:"discover_format"`
+
+	// Filter is an expression over the metadata of each release (see
+	// package filter), e.g. "release_year >= 2020 && track_count > 3";
+	// only the releases it is true for are downloaded. "" downloads all.
+	Filter string `json:"filter"`
+
+	// Album cache: AlbumCacheDir keeps what was read from release pages,
+	// keyed by item ID, so that runs within AlbumCacheTTL hours of the
+	// last fetch (verify runs, a label queued again) neither fetch nor
+	// parse them again. A TTL of 0 disables the cache.
+	AlbumCacheDir string  `json:"album_cache_dir"`
+	AlbumCacheTTL float64 `json:"album_cache_ttl"`
+
+	// Tracks are downloaded to <file>.part and renamed when complete.
+	// KeepPartialFiles keeps the .part of a canceled or failed download,
+	// which the next run resumes from, rather than deleting it. Segmented
+	// downloads (ParallelSegments) are never resumed, their .part being
+	// written out of order.
+	KeepPartialFiles bool `json:"keep_partial_files"`
+
+	// MaxTotalBytes caps the bytes a run downloads, for metered
+	// connections: once reached, no new track is started, the tracks
+	// downloading are finished, and the releases left are among the
+	// failed URLs of the run, which bandcamp-dl saves to its resume file
+	// to resume with the next; 0 is no cap.
+	MaxTotalBytes int64 `json:"max_total_bytes"`
+
+	// DownloadWindow restricts the daemon's downloads to the hours of
+	// each day written "HH:MM-HH:MM" (see package schedule), e.g.
+	// "01:00-07:00": outside of it, jobs wait in the queue and the running
+	// one is stopped, to resume when the window opens again. "" downloads
+	// at any time.
+	DownloadWindow string `json:"download_window"`
+}
+
+// Integrations holds the hand-offs to other tools and services.
+type Integrations struct {
+	// ListenBrainzLookup checks whether ListenBrainz maps the listens of
+	// each downloaded album to MusicBrainz, and warns about those it cannot.
+	ListenBrainzLookup bool `json:"listenbrainz_lookup"`
+
+	// Beets integration. BeetsManifest adds the downloaded albums to a
+	// beets-import.json in the library root, with the Bandcamp URL to use
+	// as beets' search ID. BeetsImportCommand, e.g. "beet import -q", is
+	// run on each completed album with "--search-id <url> <folder>" added.
+	BeetsManifest      bool   `json:"beets_manifest"`
+	BeetsImportCommand string `json:"beets_import_command"`
+
+	// ProgressSinks are extra destinations of the progress messages, in
+	// addition to the console: a log file path (or "file:<path>"),
+	// "syslog:" for the local syslog (or "syslog://host:514" for a remote
+	// one), or an http(s):// webhook URL receiving JSON events.
+	ProgressSinks []string `json:"progress_sinks"`
+}
+
+// UI holds how the CLI and TUI present themselves.
+type UI struct {
+	// Language of the CLI and TUI messages (en, fr, de or es), or "" for
+	// the language of the environment (LANG), see the i18n package.
+	Language string `json:"language"`
+
+	// Accessible makes the TUI screen-reader friendly: no animations,
+	// log lines printed one after another and state changes announced.
+	Accessible bool `json:"accessible"`
+
+	// URLHistorySize is how many of the URLs last entered in the TUI are
+	// kept, recalled with the up and down arrows; 0 disables the history.
+	URLHistorySize int `json:"url_history_size"`
+
+	// Theme is the TUI's built-in color theme (dark, light or no-color),
+	// or "" for dark, or no-color if NO_COLOR is set. ThemeColors
+	// overrides some of its colors by name, e.g. {"title": "#000080"}.
+	Theme       string            `json:"theme"`
+	ThemeColors map[string]string `json:"theme_colors"`
 }
 
 // DefaultSettings returns settings with default values.
 func DefaultSettings() *Settings {
 	homeDir, _ := os.UserHomeDir()
 	return &Settings{
-		DownloadsPath:               filepath.Join(homeDir, "Music", "Bandcamp", "{artist}", "{album}"),
-		MaxConcurrentAlbumsDownload: 1,
-		MaxConcurrentTracksDownload: 10,
-		DownloadMaxRetries:          7,
-		DownloadRetryCooldown:       0.2,
-		DownloadRetryExponent:       4.0,
-		AllowedFileSizeDifference:   0.05,
-		DownloadArtistDiscography:   false,
+		Paths: Paths{
+			DownloadsPath:          filepath.Join(homeDir, "Music", "Bandcamp", "{artist}", "{album}"),
+			FileNameFormat:         "{tracknum} {artist} - {title}.{ext}",
+			CoverArtFileNameFormat: "{album}",
+			PlaylistFileNameFormat: "{album}",
+
+			VariousArtistsFolder: false,
+			VariousArtistsName:   "Various Artists",
+			TrackNumbering:       model.NumberingDisc,
+			LinkMode:             "symlink",
+		},
+		Concurrency: Concurrency{
+			MaxConcurrentAlbumsDownload: 1,
+			MaxConcurrentTracksDownload: 10,
+
+			ParallelSegments:        0,
+			ParallelSegmentsMinSize: 50,
+		},
+		Network: Network{
+			DownloadMaxRetries:       7,
+			DownloadRetryCooldown:    0.2,
+			DownloadRetryExponent:    4.0,
+			DownloadRetryMaxCooldown: 60,
+			DownloadRetryJitter:      0.2,
+			DownloadRetryStatuses:    []string{"408", "429", "5xx"},
 
-		FileNameFormat:         "{tracknum} {artist} - {title}.mp3",
-		CoverArtFileNameFormat: "{album}",
-		PlaylistFileNameFormat: "{album}",
+			CircuitBreakerThreshold: 10,
+			CircuitBreakerCooldown:  60,
 
-		SaveCoverArtInFolder:    false,
-		SaveCoverArtInTags:      true,
-		CoverArtInFolderResize:  false,
-		CoverArtInFolderMaxSize: 1000,
-		CoverArtInTagsResize:    true,
-		CoverArtInTagsMaxSize:   1000,
-		ConvertCoverArtToJPG:    true,
+			ProxyType:     "system",
+			ProxyCooldown: 60,
 
-		CreatePlaylist: false,
-		PlaylistFormat: "m3u",
-		M3UExtended:    true,
+			RequestTimeout:        60,
+			ConnectTimeout:        30,
+			TLSHandshakeTimeout:   10,
+			ResponseHeaderTimeout: 60,
+			DownloadIdleTimeout:   60,
+		},
+		Artwork: Artwork{
+			SaveCoverArtInFolder:    false,
+			SaveCoverArtInTags:      true,
+			CoverArtInFolderResize:  false,
+			CoverArtInFolderMaxSize: 1000,
+			CoverArtInTagsResize:    true,
+			CoverArtInTagsMaxSize:   1000,
+			ConvertCoverArtToJPG:    true,
+			CoverArtJPEGQuality:     90,
+
+			ArtworkCacheDir:        defaultCacheDir("artwork"),
+			RefreshEmbeddedArtwork: false,
+
+			CoverArtPictureType: "front_cover",
+			ExtraPictureType:    "back_cover",
+		},
+		Tags: Tags{
+			ModifyTags: true,
+		},
+		Playlist: Playlist{
+			CreatePlaylist:      false,
+			PlaylistFormat:      "m3u",
+			M3UExtended:         true,
+			PlaylistEncoding:    "utf-8",
+			PlaylistLineEndings: "lf",
+		},
+		Download: Download{
+			AllowedFileSizeDifference: 0.05,
+			DownloadArtistDiscography: false,
+			IgnoreArtistRestrictions:  false,
+
+			SaveTrackInfo:    "none",
+			SaveMetadataJSON: false,
+			AlbumArchive:     "none",
+			CheckDuration:    "warn",
+			UpgradeFormat:    "mp3-320",
+			TranscodeCommand: "ffmpeg",
+			PageParsers:      []string{bandcamp.StrategyTralbum, bandcamp.StrategyJSONLD},
+
+			TagLimit: 50,
+			TagSort:  bandcamp.TagSortPopular,
+
+			DiscoverLimit:  20,
+			DiscoverFormat: "all",
+
+			AlbumCacheDir: defaultCacheDir("albums"),
+			AlbumCacheTTL: 0,
+		},
+		UI: UI{
+			URLHistorySize: 50,
+		},
+	}
+}
+
+// defaultCacheDir returns the cache folder named name under the user's
+// cache directory, or "" (no cache) if it cannot be determined.
+func defaultCacheDir(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "bandcamp-downloader", name)
+}
 
-		ModifyTags: true,
+// sections is Settings without its UnmarshalJSON method.
+type sections Settings
 
-		ProxyType: "system",
+// UnmarshalJSON reads settings in the sectioned layout, as well as in the
+// flat layout of files written by earlier versions, where every key was at
+// the top level. If a key is given both ways, the sectioned one wins.
+func (s *Settings) UnmarshalJSON(data []byte) error {
+	flat := struct {
+		*Paths
+		*Concurrency
+		*Network
+		*Artwork
+		*Tags
+		*Playlist
+		*Download
+		*Integrations
+		*UI
+	}{&s.Paths, &s.Concurrency, &s.Network, &s.Artwork, &s.Tags, &s.Playlist, &s.Download, &s.Integrations, &s.UI}
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return err
 	}
+	return json.Unmarshal(data, (*sections)(s))
 }
 
-// Load reads settings from a JSON file.
+// Load reads settings from a JSON, TOML (.toml) or YAML (.yaml, .yml)
+// file, chosen by its extension. The keys are the same in every format.
 func Load(path string) (*Settings, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -93,15 +562,33 @@ func Load(path string) (*Settings, error) {
 		return nil, err
 	}
 
+	data, err = toJSON(data, formatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	settings := DefaultSettings()
 	if err := json.Unmarshal(data, settings); err != nil {
 		return nil, err
 	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	for i := range settings.Overrides {
+		if _, err := settings.merge([]*Override{&settings.Overrides[i]}); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range settings.ProfileNames() {
+		if _, err := settings.WithProfile(name); err != nil {
+			return nil, err
+		}
+	}
 
 	return settings, nil
 }
 
-// Save writes settings to a JSON file.
+// Save writes settings to a file in the format of its extension, like
+// Load. Comments of an existing TOML or YAML file are not preserved.
 func (s *Settings) Save(path string) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -112,10 +599,323 @@ func (s *Settings) Save(path string) error {
 	if err != nil {
 		return err
 	}
+	data, err = fromJSON(data, formatOf(path))
+	if err != nil {
+		return err
+	}
 
 	return os.WriteFile(path, data, 0644)
 }
 
+// LibraryRoot returns the fixed part of DownloadsPath, before the first
+// placeholder, which is the root folder all albums are downloaded under.
+func (s *Settings) LibraryRoot() string {
+	root := s.DownloadsPath
+	if i := strings.Index(root, "{"); i >= 0 {
+		root = filepath.Dir(root[:i+1])
+	}
+	if s.LongPaths {
+		// In the form of the album folders, which are below it
+		return ioutils.LongPath(filepath.Clean(root))
+	}
+	return filepath.Clean(root)
+}
+
+// SmartPlaylistsDir returns the folder of the smart playlists:
+// SmartPlaylistsPath, or the "Playlists" folder of LibraryRoot.
+func (s *Settings) SmartPlaylistsDir() string {
+	if s.SmartPlaylistsPath != "" {
+		return filepath.Clean(s.SmartPlaylistsPath)
+	}
+	return filepath.Join(s.LibraryRoot(), "Playlists")
+}
+
+// Validate checks the settings that cannot be used as-is: the artwork
+// quality and the proxy and network configuration.
+func (s *Settings) Validate() error {
+	if s.CoverArtJPEGQuality < 1 || s.CoverArtJPEGQuality > 100 {
+		return fmt.Errorf("invalid cover_art_jpeg_quality %d, must be between 1 and 100", s.CoverArtJPEGQuality)
+	}
+
+	switch s.SaveTrackInfo {
+	case "", "none", "sidecar", "readme":
+	default:
+		return fmt.Errorf("invalid save_track_info %q, must be none, sidecar or readme", s.SaveTrackInfo)
+	}
+
+	if s.Language != "" && !i18n.Supported(s.Language) {
+		return fmt.Errorf("invalid language %q, must be one of %s", s.Language, strings.Join(i18n.Languages(), ", "))
+	}
+
+	if _, err := console.NewTheme(s.Theme, s.ThemeColors); err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
+
+	if s.ConfirmIfLargerThanMB < 0 {
+		return fmt.Errorf("invalid confirm_if_larger_than_mb %d, must be 0 (disabled) or more", s.ConfirmIfLargerThanMB)
+	}
+	if s.LimitAlbums < 0 {
+		return fmt.Errorf("invalid limit_albums %d, must be 0 (no limit) or more", s.LimitAlbums)
+	}
+	if s.LimitTracks < 0 {
+		return fmt.Errorf("invalid limit_tracks %d, must be 0 (no limit) or more", s.LimitTracks)
+	}
+	if s.Filter != "" {
+		if _, err := filter.Parse(s.Filter); err != nil {
+			return fmt.Errorf("invalid filter %q: %v", s.Filter, err)
+		}
+	}
+	if s.MaxTotalBytes < 0 {
+		return fmt.Errorf("invalid max_total_bytes %d, must be 0 (no limit) or more", s.MaxTotalBytes)
+	}
+	if _, err := schedule.Parse(s.DownloadWindow); err != nil {
+		return fmt.Errorf("invalid download_window %q: %v", s.DownloadWindow, err)
+	}
+	if s.AlbumCacheTTL < 0 {
+		return fmt.Errorf("invalid album_cache_ttl %g, must be 0 (disabled) or more", s.AlbumCacheTTL)
+	}
+	if s.SampleAlbums < 0 {
+		return fmt.Errorf("invalid sample_albums %d, must be 0 (disabled) or more", s.SampleAlbums)
+	}
+	if s.TagLimit < 1 {
+		return fmt.Errorf("invalid tag_limit %d, must be 1 or more", s.TagLimit)
+	}
+	if err := bandcamp.CheckTagSort(s.TagSort); err != nil {
+		return fmt.Errorf("invalid tag_sort %q, must be %s or %s", s.TagSort, bandcamp.TagSortPopular, bandcamp.TagSortNew)
+	}
+	if s.DiscoverLimit < 1 {
+		return fmt.Errorf("invalid discover_limit %d, must be 1 or more", s.DiscoverLimit)
+	}
+	if err := bandcamp.CheckDiscoverFormat(s.DiscoverFormat); err != nil {
+		return fmt.Errorf("invalid discover_format %q, must be one of %s", s.DiscoverFormat, strings.Join(bandcamp.DiscoverFormats, ", "))
+	}
+
+	switch s.LinkMode {
+	case "", "symlink", "hardlink":
+	default:
+		return fmt.Errorf("invalid link_mode %q, must be symlink or hardlink", s.LinkMode)
+	}
+
+	if s.URLHistorySize < 0 {
+		return fmt.Errorf("invalid url_history_size %d, must be 0 (disabled) or more", s.URLHistorySize)
+	}
+
+	switch s.TrackNumbering {
+	case "", model.NumberingDisc, model.NumberingSequential:
+	default:
+		return fmt.Errorf("invalid track_numbering %q, must be disc or sequential", s.TrackNumbering)
+	}
+
+	if s.ParallelSegments < 0 || s.ParallelSegments > 16 {
+		return fmt.Errorf("invalid parallel_segments %d, must be between 0 and 16", s.ParallelSegments)
+	}
+
+	if s.DownloadRetryJitter < 0 || s.DownloadRetryJitter > 1 {
+		return fmt.Errorf("invalid download_retry_jitter %v, must be between 0 and 1", s.DownloadRetryJitter)
+	}
+	if s.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("invalid circuit_breaker_threshold %d, must be 0 (disabled) or more", s.CircuitBreakerThreshold)
+	}
+	for _, status := range s.DownloadRetryStatuses {
+		if err := http.ValidateRetryStatus(status); err != nil {
+			return err
+		}
+	}
+
+	for name, pictureType := range map[string]string{
+		"cover_art_picture_type": s.CoverArtPictureType,
+		"extra_picture_type":     s.ExtraPictureType,
+	} {
+		if _, err := audio.ParsePictureType(pictureType); pictureType != "" && err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if s.ExtraPictureURL != "" {
+		if u, err := url.Parse(s.ExtraPictureURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid extra_picture_url %q, must be an http or https URL", s.ExtraPictureURL)
+		}
+	}
+
+	if _, err := audio.ParsePlaylistEncoding(s.PlaylistEncoding); err != nil {
+		return fmt.Errorf("playlist_encoding: %w", err)
+	}
+	for _, playlist := range s.SmartPlaylists {
+		if strings.TrimSpace(playlist.Name) == "" {
+			return fmt.Errorf("invalid smart_playlists entry with query %q: no name", playlist.Query)
+		}
+		if _, err := library.ParseQuery(playlist.Query); err != nil {
+			return fmt.Errorf("invalid smart_playlists query %q of %s: %v", playlist.Query, playlist.Name, err)
+		}
+	}
+	switch s.PlaylistLineEndings {
+	case "", "lf", "crlf":
+	default:
+		return fmt.Errorf("invalid playlist_line_endings %q, must be lf or crlf", s.PlaylistLineEndings)
+	}
+
+	for field, action := range s.TagFields {
+		if audio.DefaultTagConfig().Action(field) == nil {
+			return fmt.Errorf("invalid tag_fields field %q, must be one of %s", field, strings.Join(audio.TagFields, ", "))
+		}
+		if _, err := audio.ParseTagEditAction(action); err != nil {
+			return fmt.Errorf("tag_fields %s: %w", field, err)
+		}
+	}
+
+	switch s.AlbumArchive {
+	case "", "none", "zip", "zip_keep":
+	default:
+		return fmt.Errorf("invalid album_archive %q, must be none, zip or zip_keep", s.AlbumArchive)
+	}
+	switch s.CheckDuration {
+	case "", "off", "warn", "redownload":
+	default:
+		return fmt.Errorf("invalid check_duration %q, must be off, warn or redownload", s.CheckDuration)
+	}
+	if s.UpgradeFormat != "" && !slices.Contains(bandcamp.DownloadFormats, s.UpgradeFormat) {
+		return fmt.Errorf("invalid upgrade_format %q, must be one of %s", s.UpgradeFormat, strings.Join(bandcamp.DownloadFormats, ", "))
+	}
+	if s.DownloadFormat != "" && !slices.Contains(bandcamp.DownloadFormats, s.DownloadFormat) {
+		return fmt.Errorf("invalid download_format %q, must be one of %s", s.DownloadFormat, strings.Join(bandcamp.DownloadFormats, ", "))
+	}
+	if s.TranscodeFormat != "" && !slices.Contains(audio.TranscodeFormats, s.TranscodeFormat) {
+		return fmt.Errorf("invalid transcode_format %q, must be one of %s", s.TranscodeFormat, strings.Join(audio.TranscodeFormats, ", "))
+	}
+	if s.TranscodeBitrate < 0 {
+		return fmt.Errorf("invalid transcode_bitrate %d, must be 0 (the encoder's default) or more", s.TranscodeBitrate)
+	}
+	if s.TranscodeFormat != "" && strings.TrimSpace(s.TranscodeCommand) == "" {
+		return errors.New("transcode_format needs a transcode_command, e.g. ffmpeg")
+	}
+
+	if _, err := bandcamp.NewStrategies(s.PageParsers...); err != nil {
+		return fmt.Errorf("page_parsers: %w", err)
+	}
+
+	for name, timeout := range map[string]float64{
+		"request_timeout":         s.RequestTimeout,
+		"connect_timeout":         s.ConnectTimeout,
+		"tls_handshake_timeout":   s.TLSHandshakeTimeout,
+		"response_header_timeout": s.ResponseHeaderTimeout,
+	} {
+		if timeout < 0 {
+			return fmt.Errorf("invalid %s %v, must be 0 (default) or more seconds", name, timeout)
+		}
+	}
+
+	switch s.IPVersion {
+	case 0, 4, 6:
+	default:
+		return fmt.Errorf("invalid ip_version %d, must be 0, 4 or 6", s.IPVersion)
+	}
+	for _, server := range s.DNSServers {
+		if _, err := http.ParseDNSServer(server); err != nil {
+			return err
+		}
+	}
+	if s.CACertFile != "" {
+		if _, err := http.LoadCAFile(s.CACertFile); err != nil {
+			return fmt.Errorf("ca_cert_file: %w", err)
+		}
+	}
+	if s.CookiesFile != "" {
+		if _, err := http.LoadCookies(s.CookiesFile); err != nil {
+			return fmt.Errorf("cookies_file: %w", err)
+		}
+	}
+
+	switch s.ProxyType {
+	case "", "none", "system":
+		return nil
+	case "manual":
+		proxies := s.proxyAddresses()
+		if len(proxies) == 0 {
+			return errors.New("proxy_type is manual but no proxy is configured")
+		}
+		for _, p := range proxies {
+			if _, err := http.ParseProxyURL(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown proxy_type %q", s.ProxyType)
+	}
+}
+
+// proxyAddresses returns ProxyAddress:ProxyPort followed by Proxies.
+func (s *Settings) proxyAddresses() []string {
+	var proxies []string
+	if s.ProxyAddress != "" {
+		addr := s.ProxyAddress
+		if s.ProxyPort > 0 {
+			addr = net.JoinHostPort(strings.TrimSuffix(addr, "/"), strconv.Itoa(s.ProxyPort))
+			if u, err := url.Parse(s.ProxyAddress); err == nil && u.Host != "" {
+				// Keep the scheme of addresses like socks5://host
+				u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(s.ProxyPort))
+				addr = u.String()
+			}
+		}
+		proxies = append(proxies, addr)
+	}
+	return append(proxies, s.Proxies...)
+}
+
+// ToClientConfig converts settings to the HTTP client configuration.
+// Invalid proxies, DNS servers and CA files are skipped; use Validate to
+// report them.
+func (s *Settings) ToClientConfig() *http.ClientConfig {
+	cfg := &http.ClientConfig{
+		Timeout:               seconds(s.RequestTimeout),
+		ConnectTimeout:        seconds(s.ConnectTimeout),
+		TLSHandshakeTimeout:   seconds(s.TLSHandshakeTimeout),
+		ResponseHeaderTimeout: seconds(s.ResponseHeaderTimeout),
+		IdleTimeout:           seconds(s.DownloadIdleTimeout),
+		UseEnvironmentProxy:   s.ProxyType == "" || s.ProxyType == "system",
+		ProxyCooldown:         seconds(s.ProxyCooldown),
+		IPVersion:             s.IPVersion,
+		InsecureSkipVerify:    s.InsecureSkipVerify,
+		Segments:              s.ParallelSegments,
+		SegmentMinSize:        int64(s.ParallelSegmentsMinSize * 1024 * 1024),
+		Retry: &http.RetryPolicy{
+			MaxAttempts:   s.DownloadMaxRetries,
+			BaseDelay:     seconds(s.DownloadRetryCooldown),
+			Multiplier:    s.DownloadRetryExponent,
+			MaxDelay:      seconds(s.DownloadRetryMaxCooldown),
+			Jitter:        s.DownloadRetryJitter,
+			RetryStatuses: s.DownloadRetryStatuses,
+		},
+		Breaker: &http.BreakerConfig{
+			Threshold: s.CircuitBreakerThreshold,
+			Cooldown:  seconds(s.CircuitBreakerCooldown),
+		},
+	}
+	if s.CACertFile != "" {
+		cfg.RootCAs, _ = http.LoadCAFile(s.CACertFile)
+	}
+	if s.CookiesFile != "" {
+		cfg.Jar, _ = http.LoadCookies(s.CookiesFile)
+	}
+	for _, server := range s.DNSServers {
+		if u, err := http.ParseDNSServer(server); err == nil {
+			cfg.DNSServers = append(cfg.DNSServers, u)
+		}
+	}
+	if s.ProxyType == "manual" {
+		for _, p := range s.proxyAddresses() {
+			if u, err := http.ParseProxyURL(p); err == nil {
+				cfg.Proxies = append(cfg.Proxies, u)
+			}
+		}
+	}
+	return cfg
+}
+
+// seconds converts a duration in seconds from the settings.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
 // ToPathConfig converts settings to PathConfig.
 func (s *Settings) ToPathConfig() *model.PathConfig {
 	var pf model.PlaylistFormat
@@ -128,21 +928,53 @@ func (s *Settings) ToPathConfig() *model.PathConfig {
 		pf = model.PlaylistFormatWPL
 	case "zpl":
 		pf = model.PlaylistFormatZPL
+	case "m3u8":
+		pf = model.PlaylistFormatM3U8
 	default:
 		pf = model.PlaylistFormatM3U
 	}
 
-	return &model.PathConfig{
+	cfg := &model.PathConfig{
 		DownloadsPath:          s.DownloadsPath,
 		CoverArtFileNameFormat: s.CoverArtFileNameFormat,
 		PlaylistFileNameFormat: s.PlaylistFileNameFormat,
 		PlaylistFormat:         pf,
+		SinglesDownloadsPath:   s.SinglesDownloadsPath,
+		LongPaths:              s.LongPaths,
+	}
+	if s.VariousArtistsFolder {
+		cfg.VariousArtistsName = s.VariousArtistsName
+	}
+
+	return cfg
+}
+
+// ToTagConfig converts settings to TagConfig. Fields with an invalid action
+// and an invalid picture type, which Validate reports, keep their default.
+func (s *Settings) ToTagConfig() *audio.TagConfig {
+	cfg := audio.DefaultTagConfig()
+	cfg.ModifyTags = s.ModifyTags
+	cfg.Source = s.TagSource
+	cfg.StripID3v1 = s.StripID3v1
+	if pictureType, err := audio.ParsePictureType(s.CoverArtPictureType); err == nil {
+		cfg.PictureType = pictureType
+	}
+	for field, value := range s.TagFields {
+		action, err := audio.ParseTagEditAction(value)
+		if target := cfg.Action(field); target != nil && err == nil {
+			*target = action
+		}
 	}
+	return cfg
 }
 
 // ToTrackConfig converts settings to TrackConfig.
 func (s *Settings) ToTrackConfig() *model.TrackConfig {
 	return &model.TrackConfig{
-		FileNameFormat: s.FileNameFormat,
+		FileNameFormat:         s.FileNameFormat,
+		SinglesFileNameFormat:  s.SinglesFileNameFormat,
+		CompilationTrackArtist: s.VariousArtistsFolder,
+		Numbering:              s.TrackNumbering,
+		LongPaths:              s.LongPaths,
 	}
 }
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+)
+
+func TestLoad_Sections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+  "paths": {"downloads_path": "/music/{artist}/{album}"},
+  "network": {"ip_version": 4, "dns_servers": ["1.1.1.1"]},
+  "concurrency": {"max_concurrent_tracks": 3}
+}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if s.DownloadsPath != "/music/{artist}/{album}" || s.IPVersion != 4 || s.MaxConcurrentTracksDownload != 3 {
+		t.Errorf("Load() = %+v", s)
+	}
+	if s.FileNameFormat != DefaultSettings().FileNameFormat {
+		t.Errorf("FileNameFormat = %q, want default", s.FileNameFormat)
+	}
+}
+
+func TestLoad_FlatLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+  "downloads_path": "/old/{artist}/{album}",
+  "ip_version": 6,
+  "modify_tags": false,
+  "network": {"ip_version": 4}
+}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if s.DownloadsPath != "/old/{artist}/{album}" || s.ModifyTags {
+		t.Errorf("flat keys were not read: %+v", s)
+	}
+	if s.IPVersion != 4 {
+		t.Errorf("IPVersion = %d, want the sectioned value 4", s.IPVersion)
+	}
+}
+
+func TestSettings_SaveSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	s := DefaultSettings()
+	s.PlaylistFormat = "pls"
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["playlist_format"]; ok {
+		t.Error("Save wrote a flat key")
+	}
+	if !strings.Contains(string(raw["playlist"]), `"pls"`) {
+		t.Errorf("playlist section = %s", raw["playlist"])
+	}
+
+	loaded, err := Load(path)
+	if err != nil || loaded.PlaylistFormat != "pls" {
+		t.Errorf("Load() of the saved file = %v, %v", loaded, err)
+	}
+}
+
+func TestLoad_Formats(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"config.toml", `
+# Downloads go to the NAS
+[paths]
+downloads_path = "/nas/{artist}/{album}"
+
+[network]
+ip_version = 4
+dns_servers = ["1.1.1.1"]
+download_retry_cooldown = 0.5
+`},
+		{"config.yaml", `
+# Downloads go to the NAS
+paths:
+  downloads_path: /nas/{artist}/{album}
+network:
+  ip_version: 4
+  dns_servers: [1.1.1.1]
+  download_retry_cooldown: 0.5
+`},
+		{"config.yml", `
+downloads_path: /nas/{artist}/{album}
+ip_version: 4
+dns_servers: [1.1.1.1]
+download_retry_cooldown: 0.5
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if s.DownloadsPath != "/nas/{artist}/{album}" || s.IPVersion != 4 || s.DownloadRetryCooldown != 0.5 {
+				t.Errorf("Load() = %+v", s)
+			}
+			if len(s.DNSServers) != 1 || s.DNSServers[0] != "1.1.1.1" {
+				t.Errorf("DNSServers = %q", s.DNSServers)
+			}
+
+			// Saving in the same format round-trips
+			s.PlaylistFormat = "pls"
+			if err := s.Save(path); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load of the saved file failed: %v", err)
+			}
+			if loaded.PlaylistFormat != "pls" || loaded.DownloadsPath != s.DownloadsPath || loaded.ModifyTags != s.ModifyTags {
+				t.Errorf("round-trip = %+v", loaded)
+			}
+		})
+	}
+}
+
+func TestOverride_Matches(t *testing.T) {
+	tests := []struct {
+		match string
+		url   string
+		want  bool
+	}{
+		{"label.bandcamp.com", "https://label.bandcamp.com/album/name", true},
+		{"label.bandcamp.com", "https://LABEL.bandcamp.com/track/name", true},
+		{"label.bandcamp.com", "https://other.bandcamp.com/album/name", false},
+		{"music.example.com", "https://music.example.com/album/name", true},
+		{"https://*.bandcamp.com/album/live-*", "https://artist.bandcamp.com/album/live-at-home", true},
+		{"https://*.bandcamp.com/album/live-*", "https://artist.bandcamp.com/album/studio", false},
+		{"*/album/field-*", "https://label.bandcamp.com/album/field-01/", true},
+	}
+	for _, tt := range tests {
+		o := Override{Match: tt.match}
+		if got := o.Matches(tt.url); got != tt.want {
+			t.Errorf("Override{%q}.Matches(%q) = %v, want %v", tt.match, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSettings_For(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+  "paths": {"downloads_path": "/music/{artist}/{album}"},
+  "network": {"dns_servers": ["1.1.1.1"]},
+  "overrides": [
+    {"match": "field.bandcamp.com", "paths": {"downloads_path": "/field/{album}"}, "network": {"dns_servers": ["9.9.9.9"]}},
+    {"match": "*/album/live-*", "create_playlist": true}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	same, err := s.For("https://other.bandcamp.com/album/name")
+	if err != nil || same != s {
+		t.Errorf("For() of an unmatched URL = %p, %v; want the settings themselves", same, err)
+	}
+
+	got, err := s.For("https://field.bandcamp.com/album/live-birds")
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	if got.DownloadsPath != "/field/{album}" || !got.CreatePlaylist || got.DNSServers[0] != "9.9.9.9" {
+		t.Errorf("For() = %+v, want both overrides applied", got)
+	}
+	if got.FileNameFormat != s.FileNameFormat || len(got.Overrides) != 0 {
+		t.Errorf("For() did not keep the other settings")
+	}
+	if s.DownloadsPath != "/music/{artist}/{album}" || s.DNSServers[0] != "1.1.1.1" || s.CreatePlaylist {
+		t.Errorf("For() modified the global settings: %+v", s)
+	}
+
+	// Invalid overrides are reported by Load
+	bad := `{"overrides": [{"match": "a.bandcamp.com", "playlist": {"create_playlist": "yes"}}]}`
+	if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted an invalid override")
+	}
+}
+
+func TestSettings_WithProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+paths:
+  downloads_path: /music/{artist}/{album}
+profiles:
+  archive:
+    download:
+      download_format: flac
+      save_metadata_json: true
+      save_track_info: sidecar
+  phone:
+    paths:
+      downloads_path: /sdcard/Music/{artist}/{album}
+    download:
+      transcode_format: opus
+      transcode_bitrate: 96
+    artwork:
+      cover_art_in_tags_max_size: 300
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	phone, err := s.WithProfile("phone")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+	if phone.DownloadsPath != "/sdcard/Music/{artist}/{album}" || phone.CoverArtInTagsMaxSize != 300 || phone.SaveMetadataJSON ||
+		phone.TranscodeFormat != "opus" || phone.TranscodeBitrate != 96 || phone.TranscodeCommand != "ffmpeg" || phone.DownloadFormat != "" {
+		t.Errorf("WithProfile(phone) = %+v", phone)
+	}
+	if s.DownloadsPath != "/music/{artist}/{album}" {
+		t.Errorf("WithProfile modified the settings: %q", s.DownloadsPath)
+	}
+
+	archive, err := s.WithProfile("archive")
+	if err != nil || !archive.SaveMetadataJSON || archive.SaveTrackInfo != "sidecar" || archive.DownloadFormat != "flac" || archive.TranscodeFormat != "" {
+		t.Errorf("WithProfile(archive) = %+v, %v", archive, err)
+	}
+
+	_, err = s.WithProfile("car")
+	if err == nil || !strings.Contains(err.Error(), "archive, phone") {
+		t.Errorf("WithProfile(car) error = %v, want one listing the profiles", err)
+	}
+
+	// Profiles are checked when the file is loaded
+	data += "  tape:\n    download:\n      transcode_format: cassette\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "transcode_format") {
+		t.Errorf("Load error = %v, want an invalid transcode_format", err)
+	}
+}
+
+func TestSettings_ToTagConfig(t *testing.T) {
+	s := DefaultSettings()
+	s.TagSource = true
+	s.TagFields = map[string]string{"comments": "keep", "album_artist": "empty", "title": "Modify"}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	cfg := s.ToTagConfig()
+	if !cfg.ModifyTags || !cfg.Source {
+		t.Errorf("ModifyTags = %v, Source = %v, want true", cfg.ModifyTags, cfg.Source)
+	}
+	tests := []struct {
+		field string
+		want  audio.TagEditAction
+	}{
+		{"comments", audio.TagDoNotModify},
+		{"album_artist", audio.TagEmpty},
+		{"title", audio.TagModify},
+		{"artist", audio.TagModify},
+		{"lyrics", audio.TagModify},
+	}
+	for _, tt := range tests {
+		if got := *cfg.Action(tt.field); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+
+	s.CoverArtPictureType = "media"
+	if got := s.ToTagConfig().PictureType; got != 6 {
+		t.Errorf("PictureType = %d, want 6 (media)", got)
+	}
+	s.ExtraPictureType = "poster"
+	if err := s.Validate(); err == nil {
+		t.Errorf("Validate(extra_picture_type poster) succeeded, want an error")
+	}
+	s.ExtraPictureType = "back_cover"
+
+	for _, fields := range []map[string]string{{"genre": "modify"}, {"comments": "delete"}} {
+		s.TagFields = fields
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%v) succeeded, want an error", fields)
+		}
+	}
+}
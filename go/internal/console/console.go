@@ -0,0 +1,106 @@
+package console
+
+import (
+	"os"
+	"strings"
+)
+
+// Capabilities describes what a terminal can display.
+type Capabilities struct {
+	// Terminal is true if f is an interactive terminal rather than a file
+	// or pipe, so that a line can be redrawn in place with "\r".
+	Terminal bool
+
+	// Unicode is true if UTF-8 text, including emoji and box-drawing
+	// characters, is rendered correctly.
+	Unicode bool
+
+	// Color is true if ANSI escape sequences are interpreted.
+	Color bool
+}
+
+// Detect returns the capabilities of the terminal f is attached to.
+//
+// See the package documentation for the rules on each platform.
+func Detect(f *os.File) Capabilities {
+	return detect(f)
+}
+
+// Symbols returns UnicodeSymbols if the terminal handles Unicode, and
+// ASCIISymbols otherwise.
+func (c Capabilities) Symbols() Symbols {
+	if c.Unicode {
+		return UnicodeSymbols
+	}
+	return ASCIISymbols
+}
+
+// Symbols are the decorations of the CLI and TUI output.
+type Symbols struct {
+	// Error, Warning, Success, Info and Verbose prefix log lines by level,
+	// padded so that messages line up.
+	Error, Warning, Success, Info, Verbose string
+
+	// Title, Start and Done decorate headings and summaries.
+	Title, Start, Done string
+
+	// Rule is a horizontal separator line.
+	Rule string
+
+	// Bullet, Check, Cross, Arrow and Note mark list items, and Checked
+	// fills the box of an enabled option.
+	Bullet, Check, Cross, Arrow, Note, Checked string
+
+	// Separator joins the entries of a single-line help text.
+	Separator string
+}
+
+// UnicodeSymbols use emoji and box-drawing characters.
+var UnicodeSymbols = Symbols{
+	Error:     "❌ ",
+	Warning:   "⚠️  ",
+	Success:   "✅ ",
+	Info:      "ℹ️  ",
+	Verbose:   "   ",
+	Title:     "🎵 ",
+	Start:     "📥 ",
+	Done:      "✨ ",
+	Rule:      strings.Repeat("━", 40),
+	Bullet:    "•",
+	Check:     "✓",
+	Cross:     "✗",
+	Arrow:     "›",
+	Note:      "♪",
+	Checked:   "×",
+	Separator: " • ",
+}
+
+// ASCIISymbols only use printable ASCII characters.
+var ASCIISymbols = Symbols{
+	Error:     "[ERROR] ",
+	Warning:   "[WARN]  ",
+	Success:   "[OK]    ",
+	Info:      "[INFO]  ",
+	Verbose:   "        ",
+	Rule:      strings.Repeat("-", 40),
+	Bullet:    "*",
+	Check:     "+",
+	Cross:     "x",
+	Arrow:     ">",
+	Note:      "*",
+	Checked:   "x",
+	Separator: " | ",
+}
+
+// localeIsUTF8 reports whether the locale of the environment uses UTF-8,
+// from the first of LC_ALL, LC_CTYPE and LANG that is set. An environment
+// without locale is assumed to be UTF-8, as on most modern systems.
+func localeIsUTF8() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return true
+}
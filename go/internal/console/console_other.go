@@ -0,0 +1,20 @@
+//go:build !windows
+
+package console
+
+import (
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+func detect(f *os.File) Capabilities {
+	if !isatty.IsTerminal(f.Fd()) {
+		return Capabilities{Unicode: true}
+	}
+	return Capabilities{
+		Terminal: true,
+		Unicode:  localeIsUTF8(),
+		Color:    os.Getenv("TERM") != "dumb",
+	}
+}
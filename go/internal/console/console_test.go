@@ -0,0 +1,94 @@
+package console
+
+import (
+	"testing"
+)
+
+func TestLocaleIsUTF8(t *testing.T) {
+	tests := []struct {
+		lcAll, lcCtype, lang string
+		want                 bool
+	}{
+		{"", "", "", true},
+		{"", "", "en_US.UTF-8", true},
+		{"", "", "de_DE.utf8", true},
+		{"", "", "C", false},
+		{"", "C.UTF-8", "C", true},
+		{"POSIX", "", "en_US.UTF-8", false},
+		{"", "", "ja_JP.eucJP", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lcAll+"|"+tt.lcCtype+"|"+tt.lang, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_CTYPE", tt.lcCtype)
+			t.Setenv("LANG", tt.lang)
+			if got := localeIsUTF8(); got != tt.want {
+				t.Errorf("localeIsUTF8() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestASCIISymbols(t *testing.T) {
+	symbols := []string{
+		ASCIISymbols.Error, ASCIISymbols.Warning, ASCIISymbols.Success, ASCIISymbols.Info, ASCIISymbols.Verbose,
+		ASCIISymbols.Title, ASCIISymbols.Start, ASCIISymbols.Done, ASCIISymbols.Rule,
+		ASCIISymbols.Bullet, ASCIISymbols.Check, ASCIISymbols.Cross, ASCIISymbols.Arrow, ASCIISymbols.Note,
+		ASCIISymbols.Checked, ASCIISymbols.Separator,
+	}
+	for _, s := range symbols {
+		for _, r := range s {
+			if r < 0x20 || r > 0x7e {
+				t.Errorf("ASCII symbol %q contains %q", s, r)
+			}
+		}
+	}
+
+	if got := (Capabilities{Unicode: false}).Symbols(); got != ASCIISymbols {
+		t.Error("Symbols() without Unicode should be ASCIISymbols")
+	}
+}
+
+func TestNewTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	tests := []struct {
+		name    string
+		colors  map[string]string
+		want    Theme
+		wantErr bool
+	}{
+		{name: "", want: Themes["dark"]},
+		{name: "no-color", want: Theme{}},
+		{name: "no-color", colors: map[string]string{"error": "9"}, want: Theme{Error: "9"}},
+		{name: "no-color", colors: map[string]string{"title": "#abc", "progress_end": "#A0B0C0"}, want: Theme{Title: "#abc", ProgressEnd: "#A0B0C0"}},
+		{name: "solarized", wantErr: true},
+		{name: "dark", colors: map[string]string{"background": "#000000"}, wantErr: true},
+		{name: "dark", colors: map[string]string{"title": "red"}, wantErr: true},
+		{name: "dark", colors: map[string]string{"title": "256"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTheme(tt.name, tt.colors)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTheme(%q, %v) error = %v, wantErr %v", tt.name, tt.colors, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("NewTheme(%q, %v) = %+v, want %+v", tt.name, tt.colors, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTheme_NoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if got, _ := NewTheme("", nil); got != Themes["no-color"] {
+		t.Errorf("NewTheme with NO_COLOR = %+v, want no colors", got)
+	}
+	if got, _ := NewTheme("light", nil); got != Themes["light"] {
+		t.Errorf("NewTheme(light) with NO_COLOR = %+v, want the light theme", got)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package console
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// utf8CodePage is the Windows code page identifier of UTF-8.
+const utf8CodePage = 65001
+
+func detect(f *os.File) Capabilities {
+	handle := windows.Handle(f.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console: redirected to a file or pipe, or a terminal
+		// emulator such as mintty that handles UTF-8 itself
+		return Capabilities{Unicode: true}
+	}
+
+	caps := Capabilities{
+		Terminal: true,
+		Color:    windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil,
+	}
+	if os.Getenv("WT_SESSION") != "" {
+		caps.Unicode = true
+	} else if cp, err := windows.GetConsoleOutputCP(); err == nil && cp == utf8CodePage {
+		caps.Unicode = true
+	}
+	return caps
+}
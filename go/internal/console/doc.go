@@ -0,0 +1,33 @@
+// Package console detects what the terminal can display and provides the
+// symbols the CLI and TUI decorate their output with.
+//
+// Default Windows consoles use a legacy code page and render UTF-8 emoji
+// and box-drawing characters as mojibake. Detect reports whether the
+// terminal handles Unicode and ANSI colors, and Symbols picks matching
+// decorations, falling back to plain ASCII:
+//
+//	caps := console.Detect(os.Stdout)
+//	sym := caps.Symbols()
+//	fmt.Println(sym.Title + "Bandcamp Downloader")
+//	fmt.Println(sym.Rule)
+//
+// # Detection
+//
+// On Windows, Unicode is assumed in Windows Terminal and on consoles using
+// the UTF-8 code page (65001), and colors are available once virtual
+// terminal processing could be enabled on the console. Elsewhere, Unicode
+// follows the locale (LC_ALL, LC_CTYPE, LANG) and colors are available on
+// terminals other than TERM=dumb.
+//
+// Output redirected to a file or pipe is not a terminal: colors are off,
+// and text is written as UTF-8.
+//
+// # Themes
+//
+// The TUI colors come from a Theme: one of the built-in Themes (dark,
+// light and no-color), with some colors optionally overridden by name:
+//
+//	theme, err := console.NewTheme(settings.Theme, settings.ThemeColors)
+//
+// Without a theme name, NO_COLOR selects no-color.
+package console
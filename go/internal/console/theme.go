@@ -0,0 +1,155 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Theme is the color palette of the TUI. Colors are hex codes ("#FF6B6B")
+// or ANSI color numbers ("0" to "255"); an empty color leaves the text in
+// the terminal's default color.
+type Theme struct {
+	// Title colors the heading and the spinner, Subtitle the prompts,
+	// Album the album names, and Border the summary box.
+	Title, Subtitle, Album, Border string
+
+	// Success, Error, Warning, Info and Dim color log lines by level; Dim
+	// also colors the help line.
+	Success, Error, Warning, Info, Dim string
+
+	// ProgressStart and ProgressEnd are the ends of the progress bar's
+	// gradient.
+	ProgressStart, ProgressEnd string
+}
+
+// Themes are the built-in themes, by name. "dark" suits terminals with a
+// dark background, "light" those with a light one, and "no-color" leaves
+// all text in the terminal's colors.
+var Themes = map[string]Theme{
+	"dark": {
+		Title:         "#FF6B6B",
+		Subtitle:      "#4ECDC4",
+		Album:         "#F8B500",
+		Border:        "#4ECDC4",
+		Success:       "#95E1A3",
+		Error:         "#FF6B6B",
+		Warning:       "#FFE66D",
+		Info:          "#A8DADC",
+		Dim:           "#6C757D",
+		ProgressStart: "#5A56E0",
+		ProgressEnd:   "#EE6FF8",
+	},
+	"light": {
+		Title:         "#C0392B",
+		Subtitle:      "#00796B",
+		Album:         "#A04000",
+		Border:        "#00796B",
+		Success:       "#2E7D32",
+		Error:         "#C62828",
+		Warning:       "#8D6E00",
+		Info:          "#1565C0",
+		Dim:           "#616161",
+		ProgressStart: "#3949AB",
+		ProgressEnd:   "#8E24AA",
+	},
+	"no-color": {},
+}
+
+// DefaultTheme is the theme used when none is configured, unless the
+// NO_COLOR environment variable is set (see NewTheme).
+const DefaultTheme = "dark"
+
+// themeColorPattern matches the colors of a Theme: hex codes or ANSI
+// color numbers.
+var themeColorPattern = regexp.MustCompile(`^(#[0-9A-Fa-f]{3}|#[0-9A-Fa-f]{6}|\d{1,3})$`)
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeColorNames returns the names of the colors of a Theme that can be
+// overridden, sorted.
+func ThemeColorNames() []string {
+	names := make([]string, 0, len((&Theme{}).colors()))
+	for name := range (&Theme{}).colors() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTheme returns the built-in theme name, with colors overriding some
+// of its colors by name (e.g. "title" or "progress_start"; see
+// ThemeColorNames). An empty name is "no-color" if the NO_COLOR
+// environment variable is set (https://no-color.org), and DefaultTheme
+// otherwise.
+//
+// Returns an error if the theme, a color name or a color is unknown.
+//
+// Example:
+//
+//	theme, err := console.NewTheme("light", map[string]string{"title": "#000080"})
+func NewTheme(name string, colors map[string]string) (Theme, error) {
+	if name == "" {
+		name = DefaultTheme
+		if os.Getenv("NO_COLOR") != "" {
+			name = "no-color"
+		}
+	}
+	theme, ok := Themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, must be one of %s", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	fields := theme.colors()
+	for key, color := range colors {
+		field, ok := fields[key]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown theme color %q, must be one of %s", key, strings.Join(ThemeColorNames(), ", "))
+		}
+		if color != "" && !validColor(color) {
+			return Theme{}, fmt.Errorf("invalid %s color %q, must be a hex code or an ANSI color number", key, color)
+		}
+		*field = color
+	}
+	return theme, nil
+}
+
+// colors returns the colors of t by the names used in config files.
+func (t *Theme) colors() map[string]*string {
+	return map[string]*string{
+		"title":          &t.Title,
+		"subtitle":       &t.Subtitle,
+		"album":          &t.Album,
+		"border":         &t.Border,
+		"success":        &t.Success,
+		"error":          &t.Error,
+		"warning":        &t.Warning,
+		"info":           &t.Info,
+		"dim":            &t.Dim,
+		"progress_start": &t.ProgressStart,
+		"progress_end":   &t.ProgressEnd,
+	}
+}
+
+// validColor reports whether color is a hex code or an ANSI color number.
+func validColor(color string) bool {
+	if !themeColorPattern.MatchString(color) {
+		return false
+	}
+	if color[0] == '#' {
+		return true
+	}
+	n, _ := strconv.Atoi(color)
+	return n <= 255
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Client sends requests to a daemon listening on a Unix socket.
+type Client struct {
+	path string
+}
+
+// NewClient creates a Client for the daemon listening on the socket at path.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Add queues a download job for urls and returns it.
+func (c *Client) Add(ctx context.Context, urls []string) (*Job, error) {
+	resp, err := c.do(ctx, request{Command: commandAdd, URLs: urls})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Job == nil {
+		return nil, errors.New("daemon returned no job")
+	}
+	return resp.Job, nil
+}
+
+// Status returns the daemon's queued, running and recently finished jobs.
+func (c *Client) Status(ctx context.Context) ([]Job, error) {
+	resp, err := c.do(ctx, request{Command: commandStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Jobs, nil
+}
+
+// Cancel cancels the job whose ID is id, queued or running, and returns it.
+func (c *Client) Cancel(ctx context.Context, id int) (*Job, error) {
+	resp, err := c.do(ctx, request{Command: commandCancel, JobID: id})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Job == nil {
+		return nil, errors.New("daemon returned no job")
+	}
+	return resp.Job, nil
+}
+
+// Watch calls fn with the events of the job whose ID is id, or of every
+// job if id is 0, until the job finishes or ctx is done (see
+// Server.Subscribe).
+func (c *Client) Watch(ctx context.Context, id int, fn func(Event)) error {
+	conn, err := c.dial(ctx, request{Command: commandWatch, JobID: id})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	dec := json.NewDecoder(conn)
+	for {
+		var resp response
+		if err := dec.Decode(&resp); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("invalid response from daemon: %w", err)
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if resp.Event != nil {
+			fn(*resp.Event)
+		}
+	}
+}
+
+// do sends req and decodes the response.
+func (c *Client) do(ctx context.Context, req request) (*response, error) {
+	conn, err := c.dial(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid response from daemon: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// dial connects to the daemon and sends req.
+func (c *Client) dial(ctx context.Context, req request) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the daemon (is it running?): %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,353 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// startServer serves s on a temporary socket and returns a client for it.
+func startServer(t *testing.T, s *Server) *Client {
+	t.Helper()
+
+	// Socket paths are limited to ~100 bytes, too short for t.TempDir()
+	dir, err := os.MkdirTemp("", "bcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "d.sock")
+
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Serve(ctx, l)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return NewClient(path)
+}
+
+func TestServer_AddAndStatus(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	ran := make(chan []string, 2)
+	s.run = func(ctx context.Context, job *Job) error {
+		ran <- job.URLs
+		return nil
+	}
+	client := startServer(t, s)
+	ctx := context.Background()
+
+	job, err := client.Add(ctx, []string{" https://artist.bandcamp.com/album/one ", ""})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if job.ID != 1 || len(job.URLs) != 1 || job.URLs[0] != "https://artist.bandcamp.com/album/one" {
+		t.Errorf("Add returned %+v", job)
+	}
+
+	select {
+	case urls := <-ran:
+		if len(urls) != 1 {
+			t.Errorf("ran %q", urls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not run")
+	}
+
+	// The state is updated after run returns
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		jobs, err := client.Status(ctx)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+		if len(jobs) == 1 && jobs[0].State == JobDone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("jobs = %+v, want one done job", jobs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_AddInvalid(t *testing.T) {
+	client := startServer(t, NewServer(config.DefaultSettings(), nil))
+	ctx := context.Background()
+
+	if _, err := client.Add(ctx, nil); err == nil {
+		t.Error("Add without URLs succeeded")
+	}
+	if _, err := client.Add(ctx, []string{"ftp://example.com"}); err == nil || !strings.Contains(err.Error(), "not an http(s) URL") {
+		t.Errorf("Add with invalid URL: err = %v", err)
+	}
+}
+
+func TestListen_AlreadyRunning(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	client := startServer(t, s)
+
+	if _, err := Listen(client.path); err == nil {
+		t.Error("Listen succeeded while a daemon is listening")
+	}
+}
+
+func TestServer_PruneFinished(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	for i := 0; i < maxFinishedJobs+5; i++ {
+		s.jobs = append(s.jobs, &Job{ID: i + 1, State: JobDone})
+	}
+	s.jobs = append(s.jobs, &Job{ID: 1000, State: JobQueued})
+	s.pruneLocked()
+
+	if len(s.jobs) != maxFinishedJobs+1 {
+		t.Fatalf("len(jobs) = %d, want %d", len(s.jobs), maxFinishedJobs+1)
+	}
+	if s.jobs[0].ID != 6 || s.jobs[len(s.jobs)-1].ID != 1000 {
+		t.Errorf("kept jobs %d..%d, want 6..1000", s.jobs[0].ID, s.jobs[len(s.jobs)-1].ID)
+	}
+}
+
+func TestServer_Handler(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	s.run = func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, _ := get("/healthz"); code != 503 {
+		t.Errorf("/healthz before Serve = %d, want 503", code)
+	}
+
+	startServer(t, s)
+	s.Add([]string{"https://artist.bandcamp.com/album/one"})
+	s.Add([]string{"https://artist.bandcamp.com/album/two"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, body := get("/metrics")
+		if code == 200 && strings.Contains(body, "bandcamp_dl_jobs_running 1\n") {
+			for _, want := range []string{"bandcamp_dl_jobs_added_total 2\n", "bandcamp_dl_jobs_queued 1\n"} {
+				if !strings.Contains(body, want) {
+					t.Errorf("/metrics does not contain %q:\n%s", want, body)
+				}
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("/metrics = %d\n%s", code, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code, body := get("/healthz"); code != 200 || body != "ok\n" {
+		t.Errorf("/healthz = %d %q, want 200 ok", code, body)
+	}
+}
+
+func TestServer_DownloadWindow(t *testing.T) {
+	settings := config.DefaultSettings()
+	settings.DownloadWindow = "01:00-07:00"
+	messages := make(chan string, 10)
+	s := NewServer(settings, func(job *Job, event download.ProgressEvent) {
+		messages <- event.Message
+	})
+
+	// The job starts within the window, which closes while it runs (its
+	// end is already past), then waits for the window to open again
+	s.now = func() time.Time { return time.Date(2020, 1, 1, 2, 0, 0, 0, time.Local) }
+	s.run = func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		s.now = func() time.Time { return time.Date(2020, 1, 1, 12, 0, 0, 0, time.Local) }
+		return ctx.Err()
+	}
+	startServer(t, s)
+	s.Add([]string{"https://artist.bandcamp.com/album/one"})
+
+	for _, want := range []string{"Download window 01:00-07:00 closed", "Outside the download window 01:00-07:00, waiting until Jan 2 01:00"} {
+		select {
+		case got := <-messages:
+			if !strings.HasPrefix(got, want) {
+				t.Errorf("message = %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no message %q", want)
+		}
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 1 || jobs[0].State != JobQueued {
+		t.Errorf("jobs = %+v, want the job queued again", jobs)
+	}
+}
+
+func TestServer_Cancel(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	started := make(chan struct{})
+	s.run = func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	startServer(t, s)
+	running, _ := s.Add([]string{"https://artist.bandcamp.com/album/one"})
+	queued, _ := s.Add([]string{"https://artist.bandcamp.com/album/two"})
+	<-started
+
+	events, stop, err := s.Subscribe(running.ID)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer stop()
+
+	if job, err := s.Cancel(queued.ID); err != nil || job.State != JobCancelled {
+		t.Errorf("Cancel(queued) = %+v, %v, want a cancelled job", job, err)
+	}
+	if _, err := s.Cancel(queued.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("Cancel(cancelled) err = %v, want ErrJobFinished", err)
+	}
+	if _, err := s.Cancel(42); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Cancel(42) err = %v, want ErrUnknownJob", err)
+	}
+	if _, err := s.Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel(running) failed: %v", err)
+	}
+
+	var last Event
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				done = true
+				break
+			}
+			last = event
+		case <-timeout:
+			t.Fatal("events were not closed")
+		}
+	}
+	if last.JobID != running.ID || last.State != JobCancelled {
+		t.Errorf("last event = %+v, want job %d cancelled", last, running.ID)
+	}
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	release := make(chan struct{})
+	s.run = func(ctx context.Context, job *Job) error {
+		<-release
+		s.report(job, "working")
+		return nil
+	}
+
+	all, stopAll, err := s.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Subscribe(0) failed: %v", err)
+	}
+	defer stopAll()
+	if _, _, err := s.Subscribe(1); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Subscribe(1) before Add: err = %v, want ErrUnknownJob", err)
+	}
+
+	startServer(t, s)
+	job, _ := s.Add([]string{"https://artist.bandcamp.com/album/one"})
+	close(release)
+
+	want := []Event{
+		{JobID: job.ID, State: JobQueued},
+		{JobID: job.ID, State: JobRunning},
+		{JobID: job.ID, State: JobRunning, Message: "working"},
+		{JobID: job.ID, State: JobDone},
+	}
+	for _, w := range want {
+		select {
+		case got := <-all:
+			if got != w {
+				t.Errorf("event = %+v, want %+v", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event %+v", w)
+		}
+	}
+
+	// Subscribing to a finished job returns its final state
+	events, stop, err := s.Subscribe(job.ID)
+	if err != nil {
+		t.Fatalf("Subscribe(finished) failed: %v", err)
+	}
+	defer stop()
+	if event := <-events; event.State != JobDone {
+		t.Errorf("event = %+v, want done", event)
+	}
+	if _, ok := <-events; ok {
+		t.Error("events of a finished job were not closed")
+	}
+}
+
+func TestClient_WatchAndCancel(t *testing.T) {
+	s := NewServer(config.DefaultSettings(), nil)
+	s.run = func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	client := startServer(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Watch(ctx, 42, func(Event) {}); err == nil || !strings.Contains(err.Error(), "unknown job") {
+		t.Errorf("Watch(42) err = %v, want unknown job", err)
+	}
+
+	job, err := client.Add(ctx, []string{"https://artist.bandcamp.com/album/one"})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// Cancelled once watched
+	go func() {
+		for {
+			s.subs.mu.Lock()
+			n := len(s.subs.subs)
+			s.subs.mu.Unlock()
+			if n > 0 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if _, err := client.Cancel(ctx, job.ID); err != nil {
+			t.Errorf("Cancel failed: %v", err)
+		}
+	}()
+
+	var last Event
+	if err := client.Watch(ctx, job.ID, func(event Event) { last = event }); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if last.JobID != job.ID || last.State != JobCancelled {
+		t.Errorf("last event = %+v, want job %d cancelled", last, job.ID)
+	}
+}
@@ -0,0 +1,64 @@
+// Package daemon runs a long-lived download queue controlled over a local
+// socket, so a single persistent process (e.g. a systemd service) performs
+// all downloads while short-lived clients submit jobs.
+//
+// # Server
+//
+// The Server owns the queue and downloads one job at a time, each with its
+// own download.Manager:
+//
+//	l, err := daemon.Listen(daemon.DefaultSocketPath())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	server := daemon.NewServer(settings, func(job *daemon.Job, event download.ProgressEvent) {
+//	    log.Printf("[job %d] %s", job.ID, event.Message)
+//	})
+//	err = server.Serve(ctx, l) // returns when ctx is cancelled
+//
+// With a config.DownloadWindow, jobs are only downloaded during those
+// hours of the day: outside of them the queue waits, and a job still
+// running when the window closes is stopped and queued again, to continue
+// when it opens.
+//
+// Jobs can be cancelled with Cancel, and Subscribe streams the events of
+// a job (its state changes and progress) to frontends such as the gRPC API
+// of the grpcapi package.
+//
+// # Client
+//
+//	client := daemon.NewClient(daemon.DefaultSocketPath())
+//	job, err := client.Add(ctx, []string{"https://artist.bandcamp.com/album/name"})
+//	jobs, err := client.Status(ctx)
+//	err = client.Watch(ctx, job.ID, func(event daemon.Event) {
+//	    fmt.Println(event.Message)
+//	}) // returns when the job finished
+//	job, err = client.Cancel(ctx, job.ID)
+//
+// # Protocol
+//
+// Each connection carries a single JSON request followed by a single JSON
+// response, except for "watch":
+//
+//	→ {"command": "add", "urls": ["https://artist.bandcamp.com/album/name"]}
+//	← {"job": {"id": 3, "state": "queued", ...}}
+//
+//	→ {"command": "status"}
+//	← {"jobs": [{"id": 1, "state": "done", ...}, ...]}
+//
+//	→ {"command": "cancel", "job_id": 3}
+//	← {"job": {"id": 3, "state": "cancelled", ...}}
+//
+// "watch" streams one response per event of the job, or of every job if
+// job_id is 0, until the job finishes:
+//
+//	→ {"command": "watch", "job_id": 3}
+//	← {"event": {"job_id": 3, "state": "running", "message": "Downloading ...", "level": 0}}
+//	← ...
+//	← {"event": {"job_id": 3, "state": "done", "level": 0}}
+//
+// Errors are reported in the "error" field of the response.
+//
+// The socket is a Unix domain socket, which Windows 10 (1803) and later
+// support as well. It is only accessible to the user running the daemon.
+package daemon
@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// eventBuffer is the number of events a subscriber may fall behind by
+// before events are dropped for it.
+const eventBuffer = 256
+
+// Event is a progress event of a job, or a change of its state.
+type Event struct {
+	// JobID is the ID of the job the event is about.
+	JobID int `json:"job_id"`
+
+	// State is the job's state when the event was sent. The last event of
+	// a job carries its finished state.
+	State JobState `json:"state"`
+
+	// Message and Level are those of the job's download.ProgressEvent.
+	// State changes have no Message.
+	Message string                 `json:"message,omitempty"`
+	Level   download.ProgressLevel `json:"level"`
+
+	// Album and Track are the titles of the album and track of
+	// download.LevelProgress events, of which Written bytes of Total were
+	// received.
+	Album   string `json:"album,omitempty"`
+	Track   string `json:"track,omitempty"`
+	Written int64  `json:"written,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+// subscriber receives the events of one job, or of every job if jobID is 0.
+type subscriber struct {
+	jobID int
+	ch    chan Event
+}
+
+// subscribers tracks the channels returned by Server.Subscribe.
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// Subscribe returns a channel receiving the events of the job whose ID is
+// jobID, or of every job if jobID is 0, and a function to stop receiving
+// them. The channel of a job is closed after the event of its finished
+// state, straight away if it already finished.
+//
+// Events are dropped rather than blocking the download when the receiver
+// falls behind, so the final state should be checked with Jobs if it
+// matters.
+//
+// Example:
+//
+//	events, stop, err := server.Subscribe(job.ID)
+//	if err != nil {
+//	    return err
+//	}
+//	defer stop()
+//	for event := range events {
+//	    fmt.Println(event.State, event.Message)
+//	}
+func (s *Server) Subscribe(jobID int) (<-chan Event, func(), error) {
+	sub := &subscriber{jobID: jobID, ch: make(chan Event, eventBuffer)}
+
+	// s.mu is held while subscribing, so no state change of the job can
+	// be missed between checking it and subscribing
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if jobID != 0 {
+		job := s.findLocked(jobID)
+		if job == nil {
+			return nil, nil, fmt.Errorf("%w %d", ErrUnknownJob, jobID)
+		}
+		if job.State.Finished() {
+			sub.ch <- stateEvent(job)
+			close(sub.ch)
+			return sub.ch, func() {}, nil
+		}
+	}
+
+	s.subs.mu.Lock()
+	if s.subs.subs == nil {
+		s.subs.subs = make(map[*subscriber]struct{})
+	}
+	s.subs.subs[sub] = struct{}{}
+	s.subs.mu.Unlock()
+
+	stop := func() {
+		s.subs.mu.Lock()
+		defer s.subs.mu.Unlock()
+		if _, ok := s.subs.subs[sub]; ok {
+			delete(s.subs.subs, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, stop, nil
+}
+
+// publish sends event to the subscribers of its job, and closes the
+// channels of the job's subscribers if its state is finished.
+func (s *Server) publish(event Event) {
+	s.subs.mu.Lock()
+	defer s.subs.mu.Unlock()
+
+	for sub := range s.subs.subs {
+		if sub.jobID != 0 && sub.jobID != event.JobID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+		if sub.jobID != 0 && event.State.Finished() {
+			delete(s.subs.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// publishStateLocked sends the current state of job to its subscribers.
+// s.mu must be held.
+func (s *Server) publishStateLocked(job *Job) {
+	s.publish(stateEvent(job))
+}
+
+// stateEvent returns the event of the current state of job, with the
+// error of failed jobs.
+func stateEvent(job *Job) Event {
+	event := Event{JobID: job.ID, State: job.State, Message: job.Error}
+	if job.Error != "" {
+		event.Level = download.LevelError
+	}
+	return event
+}
+
+// publishProgress sends a progress event of job to its subscribers.
+func (s *Server) publishProgress(job *Job, event download.ProgressEvent) {
+	s.mu.Lock()
+	e := Event{JobID: job.ID, State: job.State, Message: event.Message, Level: event.Level, Written: event.Written, Total: event.Total}
+	s.mu.Unlock()
+	if event.Album != nil {
+		e.Album = event.Album.Title
+	}
+	if event.Track != nil {
+		e.Track = event.Track.Title
+	}
+	s.publish(e)
+}
+
+// findLocked returns the job whose ID is id, or nil. s.mu must be held.
+func (s *Server) findLocked(id int) *Job {
+	for _, job := range s.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package daemon
+
+import "time"
+
+// JobState indicates where a job is in the queue.
+type JobState string
+
+const (
+	// JobQueued means the job waits for the jobs before it.
+	JobQueued JobState = "queued"
+
+	// JobRunning means the job's albums are being downloaded.
+	JobRunning JobState = "running"
+
+	// JobDone means every album of the job was downloaded.
+	JobDone JobState = "done"
+
+	// JobPartial means the job finished but some albums or tracks failed.
+	JobPartial JobState = "partial"
+
+	// JobFailed means nothing of the job could be downloaded.
+	JobFailed JobState = "failed"
+
+	// JobCancelled means the job was cancelled, or the daemon stopped
+	// before it finished.
+	JobCancelled JobState = "cancelled"
+)
+
+// Job is a set of URLs submitted to the daemon, downloaded together.
+type Job struct {
+	// ID identifies the job within the daemon's lifetime, starting at 1.
+	ID int `json:"id"`
+
+	// URLs are the Bandcamp URLs to download.
+	URLs []string `json:"urls"`
+
+	// State is the job's current state.
+	State JobState `json:"state"`
+
+	// Added, Started and Finished are when the job was queued, started
+	// and finished. Started and Finished are zero until then.
+	Added    time.Time `json:"added"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+
+	// Albums is the number of albums found for the job's URLs.
+	Albums int `json:"albums"`
+
+	// FailedURLs lists the URLs to retry (see download.Manager.GetFailedURLs).
+	FailedURLs []string `json:"failed_urls,omitempty"`
+
+	// Error describes why the job failed, if it did.
+	Error string `json:"error,omitempty"`
+
+	// Progress is the progress of the job's downloads once its albums
+	// were found, nil before.
+	Progress *JobProgress `json:"progress,omitempty"`
+}
+
+// JobProgress counts the files, bytes and tracks of a job's downloads.
+type JobProgress struct {
+	// Files of TotalFiles were downloaded or already present, and
+	// Received of Total bytes were received (see
+	// download.Manager.GetProgress).
+	Files      int32 `json:"files"`
+	TotalFiles int32 `json:"total_files"`
+	Received   int64 `json:"received"`
+	Total      int64 `json:"total"`
+
+	// Downloaded, Skipped, Unavailable and Failed count the job's tracks
+	// by outcome (see download.RunStats).
+	Downloaded  int `json:"downloaded"`
+	Skipped     int `json:"skipped"`
+	Unavailable int `json:"unavailable"`
+	Failed      int `json:"failed"`
+}
+
+// Finished reports whether the job is no longer queued or running.
+func (s JobState) Finished() bool {
+	return s != JobQueued && s != JobRunning
+}
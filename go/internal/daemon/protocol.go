@@ -0,0 +1,25 @@
+package daemon
+
+// Commands of the daemon protocol.
+const (
+	commandAdd    = "add"
+	commandStatus = "status"
+	commandCancel = "cancel"
+	commandWatch  = "watch"
+)
+
+// request is sent by a client to the daemon.
+type request struct {
+	Command string   `json:"command"`
+	URLs    []string `json:"urls,omitempty"`
+	JobID   int      `json:"job_id,omitempty"`
+}
+
+// response is the daemon's answer to a request, or one of the events
+// streamed in answer to a watch request.
+type response struct {
+	Job   *Job   `json:"job,omitempty"`
+	Jobs  []Job  `json:"jobs,omitempty"`
+	Event *Event `json:"event,omitempty"`
+	Error string `json:"error,omitempty"`
+}
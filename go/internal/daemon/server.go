@@ -0,0 +1,601 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/metrics"
+	"github.com/handiism/bandcamp-downloader/internal/schedule"
+)
+
+// maxFinishedJobs is the number of finished jobs kept for Status.
+const maxFinishedJobs = 100
+
+// requestTimeout bounds how long a client connection may take.
+const requestTimeout = 10 * time.Second
+
+var (
+	// ErrUnknownJob is returned for the ID of a job the daemon does not
+	// know, or no longer keeps.
+	ErrUnknownJob = errors.New("unknown job")
+
+	// ErrJobFinished is returned by Cancel for jobs already finished.
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Server accepts jobs over a socket and downloads them one at a time.
+type Server struct {
+	settings   *config.Settings
+	onProgress func(*Job, download.ProgressEvent)
+
+	// run downloads a job; replaced in tests.
+	run func(ctx context.Context, job *Job) error
+
+	// window is when jobs are downloaded (see config.DownloadWindow); now
+	// is replaced in tests.
+	window schedule.Window
+	now    func() time.Time
+
+	mu     sync.Mutex
+	jobs   []*Job
+	nextID int
+	wake   chan struct{}
+
+	// cancelRunning stops the running job, and cancelled records that
+	// Cancel did, rather than the download window.
+	cancelRunning context.CancelFunc
+	cancelled     bool
+
+	// manager downloads the running job, once its albums were found, for
+	// the job's Progress.
+	manager *download.Manager
+
+	subs subscribers
+
+	// processing is true while the queue is being processed, for /healthz.
+	processing atomic.Bool
+
+	metrics      *metrics.Registry
+	jobsAdded    *metrics.Counter
+	jobsFinished *metrics.CounterVec
+	downloads    *download.Metrics
+}
+
+// NewServer creates a Server downloading with settings. onProgress, if not
+// nil, receives the progress events of every job.
+func NewServer(settings *config.Settings, onProgress func(*Job, download.ProgressEvent)) *Server {
+	s := &Server{
+		settings:   settings,
+		onProgress: onProgress,
+		nextID:     1,
+		wake:       make(chan struct{}, 1),
+	}
+	s.run = s.download
+	// The settings were validated
+	s.window, _ = schedule.Parse(settings.DownloadWindow)
+	s.now = time.Now
+
+	s.metrics = metrics.NewRegistry()
+	s.jobsAdded = s.metrics.Counter("bandcamp_dl_jobs_added_total", "Jobs submitted to the daemon.")
+	s.jobsFinished = s.metrics.CounterVec("bandcamp_dl_jobs_finished_total", "Jobs finished, by final state.", "state")
+	s.metrics.GaugeFunc("bandcamp_dl_jobs_queued", "Jobs waiting to start.", func() float64 {
+		return float64(s.count(JobQueued))
+	})
+	s.metrics.GaugeFunc("bandcamp_dl_jobs_running", "Jobs being downloaded.", func() float64 {
+		return float64(s.count(JobRunning))
+	})
+	s.downloads = download.NewMetrics(s.metrics)
+	return s
+}
+
+// Handler returns the HTTP handler of the daemon's monitoring endpoints:
+//
+//   - /healthz answers 200 "ok" while the queue is being processed, and
+//     503 otherwise, for container health checks.
+//   - /metrics exposes the daemon's metrics in the Prometheus text format.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		if !s.processing.Load() {
+			http.Error(w, "not serving", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("/metrics", s.metrics)
+	return mux
+}
+
+// count returns the number of jobs in state.
+func (s *Server) count(state JobState) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for _, job := range s.jobs {
+		if job.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+// DefaultSocketPath returns the socket location used when none is given:
+// inside $XDG_RUNTIME_DIR if set, or the temporary directory otherwise.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "bandcamp-downloader.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("bandcamp-downloader-%d.sock", os.Getuid()))
+}
+
+// Listen creates the daemon's Unix socket at path, only accessible to the
+// current user.
+//
+// A socket left behind by a daemon that did not shut down cleanly is
+// replaced, but Listen fails if another daemon is still listening on path.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve accepts requests on l and processes the queue until ctx is
+// cancelled, which also cancels the running job. l is closed on return.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.processQueue(ctx)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	var err error
+	for {
+		var conn net.Conn
+		conn, err = l.Accept()
+		if err != nil {
+			break
+		}
+		go s.handle(conn)
+	}
+
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// Add queues a job for urls and returns a copy of it.
+func (s *Server) Add(urls []string) (Job, error) {
+	var valid []string
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return Job{}, fmt.Errorf("not an http(s) URL: %q", u)
+		}
+		valid = append(valid, u)
+	}
+	if len(valid) == 0 {
+		return Job{}, errors.New("no URL given")
+	}
+
+	s.mu.Lock()
+	job := &Job{ID: s.nextID, URLs: valid, State: JobQueued, Added: time.Now()}
+	s.nextID++
+	s.jobs = append(s.jobs, job)
+	s.pruneLocked()
+	s.publishStateLocked(job)
+	snapshot := *job
+	s.mu.Unlock()
+	s.jobsAdded.Inc()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return snapshot, nil
+}
+
+// Jobs returns a copy of the queued, running and recently finished jobs,
+// oldest first.
+func (s *Server) Jobs() []Job {
+	s.mu.Lock()
+	jobs := make([]Job, len(s.jobs))
+	for i, job := range s.jobs {
+		jobs[i] = *job
+	}
+	manager := s.manager
+	s.mu.Unlock()
+
+	// The manager's progress is read without s.mu, which its progress
+	// events take
+	if manager != nil {
+		for i := range jobs {
+			if jobs[i].State == JobRunning {
+				progress := jobProgress(manager)
+				jobs[i].Progress = &progress
+			}
+		}
+	}
+	return jobs
+}
+
+// pruneLocked drops the oldest finished jobs beyond maxFinishedJobs.
+// s.mu must be held.
+func (s *Server) pruneLocked() {
+	var finished int
+	for _, job := range s.jobs {
+		if job.State.Finished() {
+			finished++
+		}
+	}
+
+	kept := s.jobs[:0]
+	for _, job := range s.jobs {
+		if job.State.Finished() && finished > maxFinishedJobs {
+			finished--
+			continue
+		}
+		kept = append(kept, job)
+	}
+	s.jobs = kept
+}
+
+// next returns the oldest queued job, marked as running, or nil.
+func (s *Server) next() *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.State == JobQueued {
+			job.State = JobRunning
+			job.Started = time.Now()
+			s.publishStateLocked(job)
+			return job
+		}
+	}
+	return nil
+}
+
+// processQueue runs the queued jobs one after the other until ctx is done.
+// Outside the download window, jobs wait, and the running job is stopped
+// and queued again.
+func (s *Server) processQueue(ctx context.Context) {
+	s.processing.Store(true)
+	defer s.processing.Store(false)
+
+	for {
+		if s.count(JobQueued) > 0 && !s.waitForWindow(ctx) {
+			s.cancelQueued()
+			return
+		}
+		job := s.next()
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				s.cancelQueued()
+				return
+			case <-s.wake:
+				continue
+			}
+		}
+
+		jobCtx, cancel := s.jobContext(ctx)
+		s.mu.Lock()
+		s.cancelRunning = cancel
+		s.cancelled = false
+		s.mu.Unlock()
+		err := s.run(jobCtx, job)
+		cancel()
+
+		s.mu.Lock()
+		s.cancelRunning = nil
+		switch {
+		case ctx.Err() != nil || s.cancelled:
+			job.State = JobCancelled
+		case errors.Is(jobCtx.Err(), context.DeadlineExceeded):
+			// The window closed: the job starts over when it opens again,
+			// skipping the tracks already downloaded
+			job.State = JobQueued
+			job.Started = time.Time{}
+			job.FailedURLs = nil
+			s.publishStateLocked(job)
+			s.mu.Unlock()
+			s.report(job, fmt.Sprintf("Download window %s closed, pausing the job", s.window))
+			continue
+		case err != nil:
+			job.State = JobFailed
+			job.Error = err.Error()
+		case job.State == JobRunning:
+			job.State = JobDone
+		}
+		job.Finished = time.Now()
+		s.jobsFinished.With(string(job.State)).Inc()
+		s.publishStateLocked(job)
+		s.pruneLocked()
+		s.mu.Unlock()
+	}
+}
+
+// Cancel cancels the job whose ID is id: a queued job will not start, and
+// a running job is stopped, keeping the tracks already downloaded. It
+// returns a copy of the job.
+func (s *Server) Cancel(id int) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.findLocked(id)
+	if job == nil {
+		return Job{}, fmt.Errorf("%w %d", ErrUnknownJob, id)
+	}
+	switch job.State {
+	case JobQueued:
+		job.State = JobCancelled
+		job.Finished = time.Now()
+		s.jobsFinished.With(string(job.State)).Inc()
+		s.publishStateLocked(job)
+	case JobRunning:
+		// processQueue marks the job as cancelled once it stopped
+		s.cancelled = true
+		if s.cancelRunning != nil {
+			s.cancelRunning()
+		}
+	default:
+		return Job{}, fmt.Errorf("%w: job %d is %s", ErrJobFinished, id, job.State)
+	}
+	return *job, nil
+}
+
+// jobContext returns the context of a job starting now, which is done
+// when the download window closes.
+func (s *Server) jobContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	end := s.window.End(s.now())
+	if end.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, end)
+}
+
+// waitForWindow waits until the download window is open, and reports
+// whether it is, or false if ctx is done first.
+func (s *Server) waitForWindow(ctx context.Context) bool {
+	now := s.now()
+	if s.window.Contains(now) {
+		return true
+	}
+	start := s.window.Next(now)
+	if job := s.firstQueued(); job != nil {
+		s.report(job, fmt.Sprintf("Outside the download window %s, waiting until %s", s.window, start.Format("Jan 2 15:04")))
+	}
+
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// firstQueued returns the oldest queued job, or nil.
+func (s *Server) firstQueued() *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.State == JobQueued {
+			return job
+		}
+	}
+	return nil
+}
+
+// report sends an informational message about job to onProgress and the
+// job's subscribers.
+func (s *Server) report(job *Job, message string) {
+	event := download.ProgressEvent{Message: message, Level: download.LevelInfo}
+	if s.onProgress != nil {
+		s.onProgress(job, event)
+	}
+	s.publishProgress(job, event)
+}
+
+// cancelQueued marks the jobs that never started as cancelled.
+func (s *Server) cancelQueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.State == JobQueued {
+			job.State = JobCancelled
+			s.publishStateLocked(job)
+		}
+	}
+}
+
+// download downloads the albums of job with a new Manager, and sets the
+// job's results and state.
+func (s *Server) download(ctx context.Context, job *Job) error {
+	manager := download.NewManager(s.settings, func(event download.ProgressEvent) {
+		if s.onProgress != nil {
+			s.onProgress(job, event)
+		}
+		s.publishProgress(job, event)
+	})
+	manager.SetMetrics(s.downloads)
+
+	if err := manager.Initialize(ctx, strings.Join(job.URLs, "\n")); err != nil {
+		return err
+	}
+	albums := len(manager.GetAlbumNames())
+	s.mu.Lock()
+	s.manager = manager
+	job.Albums = albums
+	s.mu.Unlock()
+	err := manager.StartDownloads(ctx)
+
+	snapshot := manager.GetProgressSnapshot()
+	failed := manager.GetFailedURLs()
+	progress := jobProgress(manager)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.manager = nil
+	job.Albums = len(snapshot)
+	job.FailedURLs = failed
+	job.Progress = &progress
+	switch {
+	case err != nil:
+		return err
+	case len(failed) == 0:
+		job.State = JobDone
+	case hasCompleted(snapshot):
+		job.State = JobPartial
+	default:
+		job.State = JobFailed
+		job.Error = "nothing could be downloaded"
+	}
+	return nil
+}
+
+// jobProgress returns the progress of the downloads of manager.
+func jobProgress(manager *download.Manager) JobProgress {
+	received, total, files, totalFiles := manager.GetProgress()
+	stats := manager.GetRunStats()
+	return JobProgress{
+		Files:       files,
+		TotalFiles:  totalFiles,
+		Received:    received,
+		Total:       total,
+		Downloaded:  stats.Downloaded,
+		Skipped:     stats.Skipped,
+		Unavailable: stats.Unavailable,
+		Failed:      stats.Failed,
+	}
+}
+
+// hasCompleted reports whether any album was at least partially downloaded.
+func hasCompleted(snapshot []download.AlbumProgress) bool {
+	for _, p := range snapshot {
+		if p.State == download.AlbumCompleted || p.State == download.AlbumPartial {
+			return true
+		}
+	}
+	return false
+}
+
+// handle answers the single request of conn.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req request
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("invalid request: %v", err)
+	} else {
+		switch req.Command {
+		case commandAdd:
+			job, err := s.Add(req.URLs)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Job = &job
+			}
+		case commandStatus:
+			resp.Jobs = s.Jobs()
+		case commandCancel:
+			job, err := s.Cancel(req.JobID)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Job = &job
+			}
+		case commandWatch:
+			if err := s.watch(conn, req.JobID); err != nil {
+				resp.Error = err.Error()
+				break
+			}
+			return
+		default:
+			resp.Error = fmt.Sprintf("unknown command %q", req.Command)
+		}
+	}
+
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// watch streams the events of the job whose ID is jobID, or of every job
+// if jobID is 0, to conn, one response per event, until the job finishes
+// or the client disconnects. Only an error subscribing is returned.
+func (s *Server) watch(conn net.Conn, jobID int) error {
+	events, stop, err := s.Subscribe(jobID)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// The stream lasts as long as the job; the client closing the
+	// connection, which ends the read, stops it
+	conn.SetDeadline(time.Time{})
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	enc := json.NewEncoder(conn)
+	for {
+		select {
+		case <-closed:
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+			if enc.Encode(response{Event: &event}) != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+)
+
+// albumCacheIndex is the name of the file mapping the URLs of the cached
+// pages to their item IDs.
+const albumCacheIndex = "index.json"
+
+// albumCache stores the data read from release pages on disk, so runs
+// within its TTL of the last fetch, e.g. verify runs or a label queued
+// again, neither fetch nor parse the pages again.
+//
+// Each page is stored as <item ID>.json, and index.json maps the URLs it
+// was requested with to the ID. A nil *albumCache is valid and caches
+// nothing.
+type albumCache struct {
+	dir string
+	ttl time.Duration
+
+	mu    sync.Mutex
+	index map[string]int64 // normalized URL → item ID, read on first use
+}
+
+// albumCacheEntry is a cached page.
+type albumCacheEntry struct {
+	// URL is the URL of the page after redirects.
+	URL     string              `json:"url"`
+	Fetched time.Time           `json:"fetched"`
+	Page    *bandcamp.AlbumPage `json:"page"`
+}
+
+// newAlbumCache returns a cache rooted at dir keeping pages for ttl, or
+// nil if dir is empty or ttl is not positive.
+func newAlbumCache(dir string, ttl time.Duration) *albumCache {
+	if dir == "" || ttl <= 0 {
+		return nil
+	}
+	return &albumCache{dir: dir, ttl: ttl}
+}
+
+// loadIndex reads the index on first use. Called with c.mu held.
+func (c *albumCache) loadIndex() {
+	if c.index != nil {
+		return
+	}
+	c.index = make(map[string]int64)
+	if raw, err := os.ReadFile(filepath.Join(c.dir, albumCacheIndex)); err == nil {
+		json.Unmarshal(raw, &c.index)
+	}
+}
+
+// load returns the cached page of url, or nil if it is not cached, older
+// than the TTL or unreadable.
+func (c *albumCache) load(url string) *albumCacheEntry {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.loadIndex()
+	id, ok := c.index[normalizeURL(url)]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	raw, err := os.ReadFile(filepath.Join(c.dir, fmt.Sprintf("%d.json", id)))
+	if err != nil {
+		return nil
+	}
+	var entry albumCacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || entry.Page == nil || time.Since(entry.Fetched) > c.ttl {
+		return nil
+	}
+	return &entry
+}
+
+// store saves the page fetched from url under its item ID. Pages without
+// an ID are not cached.
+func (c *albumCache) store(url string, entry *albumCacheEntry) error {
+	if c == nil || entry.Page.Album.ID == 0 {
+		return nil
+	}
+	id := entry.Page.Album.ID
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, fmt.Sprintf("%d.json", id)), raw, 0644); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadIndex()
+	c.index[normalizeURL(url)] = id
+	c.index[normalizeURL(entry.URL)] = id
+	if raw, err = json.Marshal(c.index); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, albumCacheIndex), raw, 0644)
+}
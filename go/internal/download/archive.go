@@ -0,0 +1,153 @@
+package download
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// Values of settings.AlbumArchive.
+const (
+	archiveNone    = "none"
+	archiveZip     = "zip"
+	archiveZipKeep = "zip_keep"
+)
+
+// archivePath returns the path of the zip archive of album.
+func archivePath(album *model.Album) string {
+	return filepath.Clean(album.Path) + ".zip"
+}
+
+// archiving reports whether album is packaged into a zip once completed.
+func (m *Manager) archiving(album *model.Album) bool {
+	mode := m.albumSettings(album).AlbumArchive
+	return mode == archiveZip || mode == archiveZipKeep
+}
+
+// archiveAlbum packages the folder of a completed album into a zip next to
+// it, if settings.AlbumArchive is set. With "zip", the folder is removed
+// once the archive is written.
+func (m *Manager) archiveAlbum(album *model.Album) {
+	if !m.archiving(album) {
+		return
+	}
+
+	metadata, err := json.MarshalIndent(newAlbumMetadata(album), "", "  ")
+	if err == nil {
+		err = writeAlbumZip(album.Path, archivePath(album), append(metadata, '\n'))
+	}
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error archiving %s: %v", album.Title, err), Level: LevelWarning})
+		return
+	}
+
+	if m.albumSettings(album).AlbumArchive == archiveZip {
+		if err := os.RemoveAll(album.Path); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error removing %s after archiving: %v", album.Path, err), Level: LevelWarning})
+		}
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Archived %s to %s", album.Title, filepath.Base(archivePath(album))), Level: LevelSuccess})
+}
+
+// writeAlbumZip writes the files under dir to a zip at zipPath, inside a
+// folder named after dir. Entries are sorted by path, so the same folder
+// always produces the same archive. The metadata.json is added from
+// metadata unless the folder already has one. The archive is written to a
+// temporary file first, so an interrupted run never leaves a truncated zip.
+func writeAlbumZip(dir, zipPath string, metadata []byte) error {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	tmp, err := os.CreateTemp(filepath.Dir(zipPath), ".archive-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	root := filepath.Base(dir) + "/"
+	zw := zip.NewWriter(tmp)
+
+	hasMetadata := false
+	for _, name := range files {
+		if name == metadataFileName {
+			hasMetadata = true
+		}
+		if err := addZipFile(zw, filepath.Join(dir, filepath.FromSlash(name)), root+name); err != nil {
+			return err
+		}
+	}
+	if !hasMetadata && metadata != nil {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: root + metadataFileName, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(metadata); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), zipPath)
+}
+
+// addZipFile adds the file at path to zw under name. Audio and images,
+// which do not compress, are stored as is.
+func addZipFile(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp3", ".flac", ".m4a", ".ogg", ".jpg", ".jpeg", ".png":
+		header.Method = zip.Store
+	}
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,77 @@
+package download
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteAlbumZip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Album")
+	files := map[string]string{
+		"02 Second.mp3":    "second",
+		"01 First.mp3":     "first",
+		"Album.jpg":        "cover",
+		"extras/notes.txt": "notes",
+	}
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zipPath := dir + ".zip"
+	if err := writeAlbumZip(dir, zipPath, []byte("{}\n")); err != nil {
+		t.Fatalf("writeAlbumZip failed: %v", err)
+	}
+	first, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "Album/01 First.mp3" && f.Method != zip.Store {
+			t.Errorf("MP3 compressed with method %d, want stored", f.Method)
+		}
+	}
+	want := []string{
+		"Album/01 First.mp3",
+		"Album/02 Second.mp3",
+		"Album/Album.jpg",
+		"Album/extras/notes.txt",
+		"Album/metadata.json",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %q, want %q", names, want)
+	}
+
+	// The same folder gives the same archive
+	if err := writeAlbumZip(dir, zipPath, []byte("{}\n")); err != nil {
+		t.Fatalf("writeAlbumZip failed: %v", err)
+	}
+	second, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("archiving the same folder twice gave different archives")
+	}
+}
@@ -0,0 +1,207 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// artworkCache stores downloaded artwork on disk along with its HTTP
+// validators, so re-runs over an existing library only download artwork
+// again when it changed on Bandcamp.
+//
+// Each image is stored as two files named after its key (see artworkKey):
+// the original image bytes (.img) and an entry with the URL and validators
+// (.json). A nil *artworkCache is valid and caches nothing.
+type artworkCache struct {
+	dir string
+}
+
+// artworkEntry is the JSON metadata stored next to a cached image.
+type artworkEntry struct {
+	URL string `json:"url"`
+	http.Validators
+}
+
+// newArtworkCache returns a cache rooted at dir, or nil if dir is empty.
+func newArtworkCache(dir string) *artworkCache {
+	if dir == "" {
+		return nil
+	}
+	return &artworkCache{dir: dir}
+}
+
+// artworkKey identifies an album's artwork in the caches: its Bandcamp art
+// ID, shared by all releases using the same image, or the SHA-1 of the
+// artwork URL if the ID is unknown.
+func artworkKey(album *model.Album) string {
+	if album.ArtID > 0 {
+		return fmt.Sprintf("a%010d", album.ArtID)
+	}
+	sum := sha1.Sum([]byte(album.ArtworkURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// path returns the cache file path for key with the given extension.
+func (c *artworkCache) path(key, ext string) string {
+	return filepath.Join(c.dir, key+ext)
+}
+
+// load returns the cached image and validators for url under key. ok is
+// false if it is not cached or the entry is unreadable.
+func (c *artworkCache) load(key, url string) (data []byte, validators http.Validators, ok bool) {
+	if c == nil {
+		return nil, http.Validators{}, false
+	}
+
+	raw, err := os.ReadFile(c.path(key, ".json"))
+	if err != nil {
+		return nil, http.Validators{}, false
+	}
+	var entry artworkEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || entry.URL != url {
+		return nil, http.Validators{}, false
+	}
+
+	data, err = os.ReadFile(c.path(key, ".img"))
+	if err != nil {
+		return nil, http.Validators{}, false
+	}
+
+	return data, entry.Validators, true
+}
+
+// store saves the image and its validators for url under key.
+func (c *artworkCache) store(key, url string, data []byte, validators http.Validators) error {
+	if c == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(artworkEntry{URL: url, Validators: validators})
+	if err != nil {
+		return err
+	}
+
+	// Write the image first so an entry never points to a missing image
+	if err := os.WriteFile(c.path(key, ".img"), data, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(key, ".json"), raw, 0644)
+}
+
+// artworkFetch is the shared result of fetching one artwork during a run.
+// done is closed once the other fields are set.
+type artworkFetch struct {
+	done chan struct{}
+
+	// data is the original image, from the network or the disk cache.
+	data []byte
+
+	// notModified is true if the server confirmed the cached copy.
+	notModified bool
+
+	// changed is true if a cached copy existed and the image differs.
+	changed bool
+
+	err error
+
+	// processed holds the resized/converted variants of data, keyed by
+	// purpose, so albums sharing the artwork process it only once.
+	processedMu sync.Mutex
+	processed   map[string]processedArtwork
+}
+
+// processedArtwork is one processed variant of an artwork.
+type processedArtwork struct {
+	data []byte
+	err  error
+}
+
+// variant returns the variant of the artwork for purpose, computing it
+// with process the first time it is requested.
+func (f *artworkFetch) variant(purpose string, process func() ([]byte, error)) ([]byte, error) {
+	f.processedMu.Lock()
+	defer f.processedMu.Unlock()
+
+	if p, ok := f.processed[purpose]; ok {
+		return p.data, p.err
+	}
+	data, err := process()
+	if f.processed == nil {
+		f.processed = make(map[string]processedArtwork)
+	}
+	f.processed[purpose] = processedArtwork{data: data, err: err}
+	return data, err
+}
+
+// fetchArtwork returns the original artwork of album, downloading it at
+// most once per run for all albums that share the same art ID.
+//
+// The first album to ask for an artwork fetches it (conditionally, if it
+// is in the disk cache); concurrent and later albums wait for and reuse
+// that result without any request. A failed fetch is not remembered, so
+// the next album tries again.
+func (m *Manager) fetchArtwork(ctx context.Context, album *model.Album) (*artworkFetch, error) {
+	key := artworkKey(album)
+
+	m.artworkMu.Lock()
+	fetch, shared := m.artworkFetches[key]
+	if !shared {
+		fetch = &artworkFetch{done: make(chan struct{})}
+		m.artworkFetches[key] = fetch
+	}
+	m.artworkMu.Unlock()
+
+	if shared {
+		select {
+		case <-fetch.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if fetch.err != nil {
+			return nil, fetch.err
+		}
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Reusing artwork for %s", album.Title), Level: LevelVerbose})
+		return fetch, nil
+	}
+
+	defer close(fetch.done)
+
+	cached, validators, hasCached := m.artworkCache.load(key, album.ArtworkURL)
+
+	res, err := m.httpClient.GetConditional(http.WithLabel(ctx, "artwork of "+album.Title), album.ArtworkURL, validators)
+	if err != nil {
+		fetch.err = err
+		m.artworkMu.Lock()
+		delete(m.artworkFetches, key)
+		m.artworkMu.Unlock()
+		return nil, err
+	}
+
+	if res.NotModified {
+		fetch.data = cached
+		fetch.notModified = true
+		return fetch, nil
+	}
+
+	fetch.data = res.Body
+	fetch.changed = hasCached && !bytes.Equal(cached, res.Body)
+	m.addReceivedBytes(album, int64(len(res.Body)))
+	if err := m.artworkCache.store(key, album.ArtworkURL, res.Body, res.Validators); err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error caching artwork: %v", err), Level: LevelVerbose})
+	}
+	return fetch, nil
+}
@@ -0,0 +1,155 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// beetsManifestFileName is the name of the import manifest written in the
+// library root.
+const beetsManifestFileName = "beets-import.json"
+
+// beetsManifest is the content of beets-import.json: the albums waiting in
+// the staging directory to be imported with beets.
+type beetsManifest struct {
+	Albums []beetsAlbum `json:"albums"`
+}
+
+// beetsAlbum describes one staged album. SearchID is the value to pass to
+// "beet import --search-id", which the bandcamp plugin (beetcamp) resolves
+// to the exact release instead of searching for it.
+type beetsAlbum struct {
+	Path       string `json:"path"`
+	URL        string `json:"url"`
+	SearchID   string `json:"search_id"`
+	BandcampID int64  `json:"bandcamp_id,omitempty"`
+	Artist     string `json:"artist"`
+	Album      string `json:"album"`
+	Tracks     int    `json:"tracks"`
+	Added      string `json:"added"`
+}
+
+// newBeetsAlbum returns the manifest entry of album.
+func newBeetsAlbum(album *model.Album, added time.Time) beetsAlbum {
+	return beetsAlbum{
+		Path:       album.Path,
+		URL:        album.URL,
+		SearchID:   album.URL,
+		BandcampID: album.ID,
+		Artist:     album.Artist,
+		Album:      album.Title,
+		Tracks:     len(album.Tracks),
+		Added:      added.UTC().Format(time.RFC3339),
+	}
+}
+
+// mergeBeetsAlbums returns the entries of existing whose folder still exists
+// (beets moves the albums it imports) and is not in added, followed by added.
+func mergeBeetsAlbums(existing, added []beetsAlbum) []beetsAlbum {
+	replaced := make(map[string]struct{}, len(added))
+	for _, a := range added {
+		replaced[a.Path] = struct{}{}
+	}
+
+	merged := make([]beetsAlbum, 0, len(existing)+len(added))
+	for _, a := range existing {
+		if _, ok := replaced[a.Path]; ok {
+			continue
+		}
+		if _, err := os.Stat(a.Path); err != nil {
+			continue
+		}
+		merged = append(merged, a)
+	}
+	return append(merged, added...)
+}
+
+// saveBeetsManifest adds the albums downloaded by this run to the
+// beets-import.json of the library root, if settings.BeetsManifest is
+// enabled. Albums that failed entirely are left out.
+func (m *Manager) saveBeetsManifest() {
+	if !m.settings.BeetsManifest {
+		return
+	}
+
+	now := time.Now()
+	var added []beetsAlbum
+	for _, album := range m.albums {
+		switch AlbumState(atomic.LoadInt32(&m.albumProgress[album].state)) {
+		case AlbumCompleted, AlbumPartial:
+			if _, err := os.Stat(album.Path); err == nil {
+				added = append(added, newBeetsAlbum(album, now))
+			}
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	path := filepath.Join(m.settings.LibraryRoot(), beetsManifestFileName)
+	var manifest beetsManifest
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Ignoring invalid beets manifest %s: %v", path, err), Level: LevelWarning})
+		}
+	}
+	manifest.Albums = mergeBeetsAlbums(manifest.Albums, added)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error saving beets manifest: %v", err), Level: LevelWarning})
+		return
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Beets manifest updated: %s (%d album(s) staged)", path, len(manifest.Albums)), Level: LevelInfo})
+}
+
+// beetsImportArgs returns the command line importing album: the words of
+// command followed by "--search-id <album URL> <album folder>".
+func beetsImportArgs(command string, album *model.Album) []string {
+	args := strings.Fields(command)
+	if album.URL != "" {
+		args = append(args, "--search-id", album.URL)
+	}
+	return append(args, album.Path)
+}
+
+// runBeetsImport runs settings.BeetsImportCommand on a downloaded album, if
+// set. Imports are run one at a time, as beets locks its library database.
+func (m *Manager) runBeetsImport(ctx context.Context, album *model.Album) {
+	command := m.albumSettings(album).BeetsImportCommand
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+
+	args := beetsImportArgs(command, album)
+
+	m.beetsMu.Lock()
+	defer m.beetsMu.Unlock()
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Running: %s", strings.Join(args, " ")), Level: LevelVerbose})
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		detail := strings.TrimSpace(string(output))
+		if i := strings.LastIndex(detail, "\n"); i >= 0 {
+			detail = detail[i+1:]
+		}
+		if detail != "" {
+			err = fmt.Errorf("%w: %s", err, detail)
+		}
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error importing %s into beets: %v", album.Title, err), Level: LevelWarning})
+		return
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Imported into beets: %s - %s", album.Artist, album.Title), Level: LevelSuccess})
+}
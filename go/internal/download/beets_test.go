@@ -0,0 +1,53 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestMergeBeetsAlbums(t *testing.T) {
+	dir := t.TempDir()
+	staged := filepath.Join(dir, "Artist", "Staged")
+	redownloaded := filepath.Join(dir, "Artist", "Again")
+	for _, path := range []string{staged, redownloaded} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	existing := []beetsAlbum{
+		{Path: staged, Album: "Staged"},
+		{Path: filepath.Join(dir, "Artist", "Imported"), Album: "Imported"},
+		{Path: redownloaded, Album: "Again", Added: "old"},
+	}
+	added := []beetsAlbum{
+		{Path: redownloaded, Album: "Again", Added: "new"},
+	}
+
+	got := mergeBeetsAlbums(existing, added)
+	want := []beetsAlbum{existing[0], added[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeBeetsAlbums() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBeetsImportArgs(t *testing.T) {
+	album := &model.Album{URL: "https://artist.bandcamp.com/album/foo", Path: "/staging/Artist/Foo"}
+
+	got := beetsImportArgs("beet -c /etc/beets.yaml  import -q", album)
+	want := []string{"beet", "-c", "/etc/beets.yaml", "import", "-q", "--search-id", album.URL, album.Path}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("beetsImportArgs() = %q, want %q", got, want)
+	}
+
+	album.URL = ""
+	got = beetsImportArgs("beet import", album)
+	want = []string{"beet", "import", album.Path}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("beetsImportArgs() without URL = %q, want %q", got, want)
+	}
+}
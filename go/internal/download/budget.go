@@ -0,0 +1,37 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// errBudgetExhausted is returned by downloadTrack for a track that was not
+// started because MaxTotalBytes were received.
+var errBudgetExhausted = errors.New("byte budget of the run exhausted")
+
+// budgetExhausted reports whether the run received MaxTotalBytes, after
+// which no new track is started. Tracks already downloading finish, so the
+// run may go over the budget by their size.
+func (m *Manager) budgetExhausted() bool {
+	limit := m.settings.MaxTotalBytes
+	return limit > 0 && atomic.LoadInt64(&m.receivedBytes) >= limit
+}
+
+// leaveForNextRun counts n tracks of album left undownloaded by the byte
+// budget. Their album is not completed, so its URL is among the
+// GetFailedURLs of the run, which bandcamp-dl writes to its resume file.
+func (m *Manager) leaveForNextRun(album *model.Album, n int) {
+	atomic.AddInt32(&m.budgetLeft, int32(n))
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Byte budget reached, leaving %d track(s) of %s for the next run", n, album.Title), Level: LevelVerbose})
+}
+
+// reportBudget warns about the tracks left undownloaded by the byte budget
+// once the downloads are over.
+func (m *Manager) reportBudget() {
+	if n := atomic.LoadInt32(&m.budgetLeft); n > 0 {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Byte budget of %.1f MB reached, %d track(s) left for the next run", float64(m.settings.MaxTotalBytes)/1024/1024, n), Level: LevelWarning})
+	}
+}
@@ -0,0 +1,67 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+)
+
+func TestStartDownloads_MaxTotalBytes(t *testing.T) {
+	// Albums a and b, of 3 tracks each
+	var releases []testRelease
+	for i, name := range []string{"a", "b"} {
+		release := testRelease{path: "/album/" + name, title: name, id: int64(i + 1)}
+		for j := 1; j <= 3; j++ {
+			release.tracks = append(release.tracks, testTrack{title: fmt.Sprintf("Song %d", j), stream: fmt.Sprintf("/%s%d.mp3", name, j)})
+		}
+		releases = append(releases, release)
+	}
+	server := releaseServer(t, audioFile("0123456789"), releases...)
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	settings.SaveCoverArtInTags = false
+	settings.ModifyTags = false
+	settings.MaxConcurrentAlbumsDownload = 1
+	settings.MaxConcurrentTracksDownload = 1
+	settings.MaxTotalBytes = 15
+	m := NewManager(settings, nil)
+	if err := m.Initialize(context.Background(), server.URL+"/album/a\n"+server.URL+"/album/b"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := m.StartDownloads(context.Background()); err != nil {
+		t.Fatalf("StartDownloads failed: %v", err)
+	}
+
+	// The second track crosses the budget; the rest is left for the next run
+	if stats := m.GetByteStats(); stats.Received != 20 {
+		t.Errorf("received %d bytes, want 20", stats.Received)
+	}
+	albums := m.GetAlbums()
+	if got := albums[0].TrackStates; got[0] != TrackDownloaded || got[1] != TrackDownloaded || got[2] != TrackPending {
+		t.Errorf("track states of the first album = %v, want downloaded, downloaded, pending", got)
+	}
+	if got := albums[0].Progress.State; got != AlbumPartial {
+		t.Errorf("first album is %v, want partial", got)
+	}
+	if got := albums[1].Progress.State; got != AlbumPending {
+		t.Errorf("second album is %v, want pending", got)
+	}
+	if failed := m.GetFailedURLs(); len(failed) != 2 {
+		t.Errorf("failed URLs = %q, want both albums", failed)
+	}
+	var left int
+	for _, album := range albums {
+		for _, state := range album.TrackStates {
+			if state == TrackPending {
+				left++
+			}
+		}
+	}
+	if left != 4 {
+		t.Errorf("%d tracks left, want 4", left)
+	}
+}
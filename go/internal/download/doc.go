@@ -3,14 +3,17 @@
 //
 // # Manager
 //
-// The Manager coordinates the entire download process:
+// The Manager coordinates the entire download process, in the stages of
+// its Pipeline:
 //
-//  1. Parse input URLs
-//  2. Fetch album information from Bandcamp
-//  3. Download cover art
-//  4. Download tracks concurrently
-//  5. Tag MP3 files with ID3 metadata
-//  6. Generate playlists (optional)
+//  1. Discover: find the releases of the input URLs
+//  2. Parse: fetch album information from Bandcamp
+//  3. Plan: filter and limit the albums, and pick their folders
+//  4. Fetch: download cover art and tracks concurrently, tagged
+//  5. PostProcess: replace the streams of the completed albums bought by
+//     the account with their purchase (DownloadFormat) and convert their
+//     tracks (TranscodeFormat), save liner notes, metadata and playlists,
+//     then archive, link and import the completed albums
 //
 // # Basic Usage
 //
@@ -28,6 +31,31 @@
 //	    log.Fatal(err)
 //	}
 //
+// A Manager can run again: Initialize clears the albums, progress and
+// totals of the previous run first (see Reset). Runs cannot overlap:
+// Initialize, StartDownloads, Retag, Verify, Fix and Reset return
+// ErrRunning while another of them is running.
+// The getters (GetProgress, GetAlbums and the like) can be polled during a
+// run: they return no albums until Initialize, Retag or Verify has read
+// those of the run.
+//
+// # Pipeline
+//
+// Each stage is an interface (Discoverer, Parser, Planner, Fetcher and
+// PostProcessor), with Func adapters. Pipeline returns the stages of a
+// Manager and SetPipeline replaces them, so a stage can be wrapped, e.g.
+// to enrich the albums parsed, or added, e.g. to convert the tracks
+// fetched, before the Manager is used:
+//
+//	p := manager.Pipeline()
+//	parse := p.Parse
+//	p.Parse = download.ParseFunc(func(ctx context.Context, url string) (*model.Album, error) {
+//	    album, err := parse.Parse(ctx, url)
+//	    // enrich album
+//	    return album, err
+//	})
+//	manager.SetPipeline(p)
+//
 // # Concurrency
 //
 // The Manager uses configurable concurrency limits:
@@ -40,11 +68,103 @@
 //
 //	type ProgressEvent struct {
 //	    Message string
-//	    Level   ProgressLevel // Info, Verbose, Warning, Error, Success
+//	    Level   ProgressLevel // Info, Verbose, Warning, Error, Success, Progress
 //	}
 //
+// LevelProgress events are structured rather than textual: they report the
+// byte progress of one track transfer through the Album, Track, Written and
+// Total fields, throttled to about one event per percent. Printers that only
+// display messages should ignore them.
+//
+// Aggregate counters are available from GetProgress. For per-album
+// progress bars, GetProgressSnapshot returns one AlbumProgress per album
+// (bytes, files and AlbumState), and GetAlbumProgress looks up a single
+// album by its Bandcamp item ID:
+//
+//	for _, p := range manager.GetProgressSnapshot() {
+//	    fmt.Printf("%s - %s: %d/%d files (%s)\n", p.Artist, p.Title, p.DownloadedFiles, p.TotalFiles, p.State)
+//	}
+//
+// GetByteStats separates the bytes received from the network (tracks and
+// artwork, as they stream) from the size of existing files that were skipped,
+// so a re-run over a complete library reports ~0 MB downloaded:
+//
+//	stats := manager.GetByteStats()
+//	fmt.Printf("%d received, %d skipped of %d\n", stats.Received, stats.Skipped, stats.Total)
+//
+// GetAlbums returns copies of the albums themselves, with their progress
+// and the TrackState of each track, for UIs that render structured
+// information or let users pick what to download:
+//
+//	for _, a := range manager.GetAlbums() {
+//	    for i, track := range a.Album.Tracks {
+//	        fmt.Printf("%02d %s (%s)\n", track.Number, track.Title, a.TrackStates[i])
+//	    }
+//	}
+//
+// # Existing Files
+//
+// Tracks are tagged with their Bandcamp track ID, so an existing file is
+// skipped when its embedded ID is the track's, whatever its size. Files
+// without one are matched by size, within AllowedFileSizeDifference. The
+// files of a renamed album, found by ID next to its new folder, are moved
+// to it rather than downloaded again.
+//
+// Albums whose folder is another release's, in the same run or tagged on
+// disk, get the release year or album ID appended to their folder name.
+//
+// # Archives
+//
+// With settings.AlbumArchive set to "zip" or "zip_keep", each completed
+// album folder is packaged into a zip next to it, with sorted entries and
+// its metadata.json. Albums whose archive exists are skipped.
+//
+// # Link Views
+//
+// settings.LinkViews are other layouts of the library, such as by-year or
+// by-genre trees. Each completed album is symlinked there, or hardlinked
+// file by file with settings.LinkMode "hardlink"; an album replaced by its
+// zip is linked as the archive.
+//
+// # Beets
+//
+// With settings.BeetsManifest, StartDownloads lists the downloaded albums
+// in a beets-import.json at the library root, with the Bandcamp URL to use
+// as beets' search ID. settings.BeetsImportCommand runs an import command,
+// e.g. "beet import -q", on each completed album instead.
+//
 // # Retry Logic
 //
-// Failed downloads are automatically retried with exponential backoff,
-// configurable via settings.DownloadMaxRetries and settings.DownloadRetryCooldown.
+// Failed requests are retried by the HTTP client with exponential backoff
+// and jitter (see http.RetryPolicy), configured by settings.DownloadMaxRetries,
+// settings.DownloadRetryCooldown, settings.DownloadRetryExponent and the
+// other DownloadRetry settings. Each retry is reported as a LevelWarning
+// event naming the track or artwork, through http.WithLabel.
+//
+// With settings.CircuitBreakerThreshold, a host failing repeatedly has its
+// requests paused for settings.CircuitBreakerCooldown, reported with a
+// LevelWarning event, rather than every queued track exhausting its retries.
+//
+// A track whose stream host still answers 403 or a 5xx error after its
+// retries is tried on the other stream hosts (see bandcamp.StreamMirrors).
+//
+// Stream URLs carry time-limited tokens, so tracks queued for a long time
+// can fail with 410 Gone. The album page is then fetched again and the
+// tracks' stream URLs replaced, once per album, without using up a retry.
+//
+// Streams can also end early without an error. With settings.CheckDuration,
+// the duration of each downloaded MP3 is compared with the one of its
+// metadata; tracks that are off are reported, downloaded once more with
+// "redownload", and counted in RunStats.DurationMismatches.
+//
+// # Metrics
+//
+// Long-running modes attach Metrics to their Managers to expose the
+// tracks, albums, bytes and retries, and the HTTP client's requests, in
+// the Prometheus text format:
+//
+//	reg := metrics.NewRegistry()
+//	dm := download.NewMetrics(reg)
+//	manager.SetMetrics(dm)
+//	http.Handle("/metrics", reg)
 package download
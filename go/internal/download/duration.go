@@ -0,0 +1,76 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// Values of settings.CheckDuration.
+const (
+	durationOff        = "off"
+	durationWarn       = "warn"
+	durationRedownload = "redownload"
+)
+
+// A downloaded track is off when its duration differs from the one of its
+// metadata by more than durationTolerance seconds, or durationRatio of it
+// for long tracks: encoders pad streams by a fraction of a second, while
+// truncated streams miss whole minutes.
+const (
+	durationTolerance = 2.0
+	durationRatio     = 0.02
+)
+
+// durationMismatch returns the duration of the downloaded MP3 of track,
+// and whether it is off the duration of its metadata. Tracks of unknown
+// duration, of other formats or whose file cannot be read are never off.
+func durationMismatch(track *model.Track) (float64, bool) {
+	if track.Duration <= 0 || track.Extension() != model.DefaultExt {
+		return 0, false
+	}
+	actual, err := audio.ReadMP3Duration(track.Path)
+	if err != nil {
+		return 0, false
+	}
+	return actual, math.Abs(actual-track.Duration) > max(durationTolerance, track.Duration*durationRatio)
+}
+
+// checkDuration checks the duration of the just downloaded track, if
+// settings.CheckDuration asks for it. With "redownload", a track that is
+// off is downloaded once more; one still off is kept, reported and counted
+// in RunStats.DurationMismatches. Only returns the error of a cancelled
+// download.
+func (m *Manager) checkDuration(ctx context.Context, track *model.Track, album *model.Album) error {
+	mode := m.albumSettings(album).CheckDuration
+	if mode != durationWarn && mode != durationRedownload {
+		return nil
+	}
+	actual, mismatch := durationMismatch(track)
+	if !mismatch {
+		return nil
+	}
+
+	if mode == durationRedownload {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("%s lasts %.1fs instead of %.1fs, downloading it again", filepath.Base(track.Path), actual, track.Duration), Level: LevelWarning})
+		if err := m.fetchTrack(ctx, track, album); err != nil {
+			m.discardPartial(track)
+			if ctx.Err() != nil {
+				return err
+			}
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading %s again: %v", track.Title, err), Level: LevelWarning})
+		}
+		if actual, mismatch = durationMismatch(track); !mismatch {
+			return nil
+		}
+	}
+
+	atomic.AddInt32(&m.durationMismatches, 1)
+	m.progress(ProgressEvent{Message: fmt.Sprintf("%s lasts %.1fs but %.1fs in the release's metadata, the stream may be truncated", filepath.Base(track.Path), actual, track.Duration), Level: LevelWarning})
+	return nil
+}
@@ -0,0 +1,181 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// trackIndexDepth is how deep below the parent folder of an album the
+// files of its tracks are looked for: the album folders next to it and
+// their disc subfolders.
+const trackIndexDepth = 3
+
+// trackIndex maps the Bandcamp track IDs embedded in the MP3 files under
+// a folder to the files, built once per run.
+type trackIndex struct {
+	once  sync.Once
+	files map[int64]trackFile
+}
+
+// trackFile is an MP3 file found by indexTrackFiles.
+type trackFile struct {
+	path string
+
+	// albumURL is the Bandcamp URL of the file's release.
+	albumURL string
+}
+
+// existingTrack reports whether the track is already downloaded, and
+// returns the size of its file.
+//
+// A file at the track's path, with the extension of any audio format (see
+// locateTrackFile), whose tags hold a Bandcamp track ID is the track if the
+// ID is the track's; files without one, downloaded by older versions or
+// tagged without ModifyTags, are the track if their size is within
+// AllowedFileSizeDifference of the stream's, or if they are in the
+// TranscodeFormat the tracks are converted to. Without a file at the
+// track's path, a file of the track and release elsewhere, e.g. in the
+// folder of the album before it was renamed, is moved to it. Files of the
+// track on another release (a single and its album) are left alone.
+func (m *Manager) existingTrack(ctx context.Context, track *model.Track, album *model.Album) (bool, int64) {
+	if m.locateTrackFile(track, album) {
+		info, err := os.Stat(track.Path)
+		if err != nil {
+			return false, 0
+		}
+		if track.ID > 0 {
+			if tags, err := audio.ReadTagInfo(track.Path); err == nil && tags.BandcampTrackID != 0 {
+				return tags.BandcampTrackID == track.ID, info.Size()
+			}
+		}
+		// Files converted by transcodeAlbum differ from the stream
+		if ext := audio.TranscodeExtension(m.albumSettings(album).TranscodeFormat); ext != "" && strings.EqualFold(track.Extension(), ext) {
+			return true, info.Size()
+		}
+		ok, _ := m.sizeMatches(ctx, info.Size(), m.streamURL(track))
+		return ok, info.Size()
+	}
+
+	if track.ID <= 0 || !strings.EqualFold(filepath.Ext(track.Path), ".mp3") {
+		return false, 0
+	}
+	path := m.takeTrackFile(m.trackIndexDir(album), track.ID, album.URL)
+	if path == "" {
+		return false, 0
+	}
+	info, err := os.Stat(path)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(track.Path), 0755)
+	}
+	if err == nil {
+		err = os.Rename(path, track.Path)
+	}
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error moving existing %s: %v", path, err), Level: LevelWarning})
+		return false, 0
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Moved existing %s to %s", path, track.Path), Level: LevelInfo})
+	return true, info.Size()
+}
+
+// locateTrackFile reports whether the file of track exists. Without a file
+// at its path, the paths with the extensions of the other audio formats are
+// tried, since moveStream saves streams in another format than DefaultExt
+// with their own extension when the file name format uses {ext}; the
+// track's Ext and Path are then set to those of the file found.
+func (m *Manager) locateTrackFile(track *model.Track, album *model.Album) bool {
+	if _, err := os.Stat(track.Path); err == nil {
+		return true
+	}
+	cfg := m.albumSettings(album).ToTrackConfig()
+	for _, ext := range http.AudioExtensions() {
+		if ext == track.Extension() {
+			continue
+		}
+		other := *track
+		other.Ext = ext
+		other.ComputePath(cfg)
+		if other.Path == track.Path {
+			// The file name format has no {ext}
+			return false
+		}
+		if _, err := os.Stat(other.Path); err == nil {
+			track.Ext, track.Path = other.Ext, other.Path
+			return true
+		}
+	}
+	return false
+}
+
+// trackIndexDir returns the folder whose files are looked for tracks of
+// album missing from their path: the parent of the album's folder, or the
+// album's folder if it is the library root, so no folder outside the
+// library is searched.
+func (m *Manager) trackIndexDir(album *model.Album) string {
+	root := m.albumSettings(album).LibraryRoot()
+	dir := filepath.Dir(album.Path)
+	if rel, err := filepath.Rel(root, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return album.Path
+	}
+	return dir
+}
+
+// takeTrackFile returns the path of the file of the track whose ID is id
+// on the release at albumURL under dir, or "" if there is none, and
+// removes it from the index, since the file is about to be moved.
+func (m *Manager) takeTrackFile(dir string, id int64, albumURL string) string {
+	m.trackIndexesMu.Lock()
+	index := m.trackIndexes[dir]
+	if index == nil {
+		index = &trackIndex{}
+		m.trackIndexes[dir] = index
+	}
+	m.trackIndexesMu.Unlock()
+
+	index.once.Do(func() {
+		index.files = indexTrackFiles(dir)
+	})
+
+	m.trackIndexesMu.Lock()
+	defer m.trackIndexesMu.Unlock()
+	file, ok := index.files[id]
+	if !ok || albumURL == "" || file.albumURL != albumURL {
+		return ""
+	}
+	delete(index.files, id)
+	return file.path
+}
+
+// indexTrackFiles reads the track IDs of the MP3 files under dir, up to
+// trackIndexDepth folders deep.
+func indexTrackFiles(dir string) map[int64]trackFile {
+	files := make(map[int64]trackFile)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if rel, _ := filepath.Rel(dir, path); rel != "." && strings.Count(rel, string(filepath.Separator)) >= trackIndexDepth-1 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".mp3") {
+			return nil
+		}
+		if tags, err := audio.ReadTagInfo(path); err == nil && tags.BandcampTrackID != 0 {
+			files[tags.BandcampTrackID] = trackFile{path: path, albumURL: tags.BandcampURL}
+		}
+		return nil
+	})
+	return files
+}
@@ -0,0 +1,52 @@
+package download
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+)
+
+// tagAlbumURLs lists the first TagLimit releases of tag from the tag feed,
+// in the TagSort order (see feedAlbumURLs).
+func (m *Manager) tagAlbumURLs(ctx context.Context, tag string) ([]string, error) {
+	releases, err := bandcamp.NewTagFeed(m.httpClient, "").Releases(ctx, tag, m.settings.TagSort, m.settings.TagLimit)
+	if err != nil {
+		return nil, err
+	}
+	return m.feedAlbumURLs(releases, fmt.Sprintf("tagged %q", tag)), nil
+}
+
+// discoverAlbumURLs lists the DiscoverLimit best-selling releases of query
+// from the Discover feed, in the DiscoverFormat if query has none (see
+// feedAlbumURLs).
+func (m *Manager) discoverAlbumURLs(ctx context.Context, query bandcamp.DiscoverQuery) ([]string, error) {
+	if query.Format == "" {
+		query.Format = m.settings.DiscoverFormat
+	}
+	releases, err := bandcamp.NewDiscoverFeed(m.httpClient, "").Releases(ctx, query, m.settings.DiscoverLimit)
+	if err != nil {
+		return nil, err
+	}
+	what := "in " + query.Genre
+	if query.SubGenre != "" {
+		what += "/" + query.SubGenre
+	}
+	if query.Format != "" && query.Format != "all" {
+		what += " on " + query.Format
+	}
+	return m.feedAlbumURLs(releases, what), nil
+}
+
+// feedAlbumURLs returns the URLs of the releases of a feed, described by
+// what in the progress messages, sampled like a discography (see
+// sampleURLs). The filter and limits of the run apply to them like to any
+// release.
+func (m *Manager) feedAlbumURLs(releases []bandcamp.FeedRelease, what string) []string {
+	urls := make([]string, len(releases))
+	for i, release := range releases {
+		urls[i] = release.URL
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Found %d releases %s", len(urls), what), Level: LevelInfo})
+	return m.sampleURLs(urls)
+}
@@ -0,0 +1,40 @@
+package download
+
+import (
+	"fmt"
+
+	"github.com/handiism/bandcamp-downloader/internal/filter"
+)
+
+// applyFilter drops the albums the Filter expression is not true for.
+// Albums it cannot be evaluated for, e.g. comparing a title with a
+// number, are dropped with an error.
+func (m *Manager) applyFilter() {
+	if m.settings.Filter == "" {
+		return
+	}
+	expr, err := filter.Parse(m.settings.Filter)
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Invalid filter %q: %v", m.settings.Filter, err), Level: LevelError})
+		return
+	}
+
+	kept := m.albums[:0]
+	for _, album := range m.albums {
+		ok, err := expr.Match(album)
+		switch {
+		case err != nil:
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error filtering %s - %s: %v", album.Artist, album.Title, err), Level: LevelError})
+		case !ok:
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Filtered out %s - %s", album.Artist, album.Title), Level: LevelVerbose})
+		default:
+			kept = append(kept, album)
+			continue
+		}
+		m.forgetAlbum(album)
+	}
+	if n := len(m.albums) - len(kept); n > 0 {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Filter %q left out %d of %d album(s)", m.settings.Filter, n, len(m.albums)), Level: LevelInfo})
+	}
+	m.albums = kept
+}
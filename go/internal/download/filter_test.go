@@ -0,0 +1,44 @@
+package download
+
+import (
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestApplyFilter(t *testing.T) {
+	settings := config.DefaultSettings()
+	settings.Filter = "release_year >= 2020 && track_count > 1"
+	m := NewManager(settings, nil)
+
+	for _, a := range []struct {
+		title  string
+		year   int
+		tracks int
+	}{
+		{"Recent", 2021, 5},
+		{"Old", 2015, 5},
+		{"Recent Single", 2022, 1},
+		{"Also Recent", 2020, 2},
+	} {
+		album := &model.Album{Title: a.title, ReleaseDate: time.Date(a.year, 1, 1, 0, 0, 0, 0, time.UTC)}
+		for i := 0; i < a.tracks; i++ {
+			album.Tracks = append(album.Tracks, &model.Track{Album: album})
+		}
+		m.albums = append(m.albums, album)
+		m.albumProgress[album] = &albumProgress{album: album}
+	}
+
+	m.applyFilter()
+	m.publish()
+
+	names := m.GetAlbumNames()
+	if len(names) != 2 || m.albums[0].Title != "Recent" || m.albums[1].Title != "Also Recent" {
+		t.Errorf("albums = %q, want Recent and Also Recent", names)
+	}
+	if len(m.albumProgress) != 2 {
+		t.Errorf("%d albums with progress, want 2", len(m.albumProgress))
+	}
+}
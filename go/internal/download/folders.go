@@ -0,0 +1,88 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// dedupAlbumFolders gives a folder of their own to the albums whose folder
+// is already the folder of another album of the run, or holds the files of
+// another release (e.g. the original of a remastered reissue with the same
+// title), instead of merging their tracks into one folder. The folder name
+// gets the release year, or the album ID if the year does not tell them
+// apart, then a counter.
+func (m *Manager) dedupAlbumFolders() {
+	taken := make(map[string]bool)
+	for _, album := range m.albums {
+		if !taken[folderKey(album.Path)] && !folderOfOtherRelease(album.Path, album.URL) {
+			taken[folderKey(album.Path)] = true
+			continue
+		}
+
+		path := uniqueAlbumFolder(album, taken)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Folder %s is used by another release, downloading %s - %s to %s", album.Path, album.Artist, album.Title, filepath.Base(path)), Level: LevelWarning})
+		album.Relocate(album.Path, path)
+		taken[folderKey(path)] = true
+	}
+}
+
+// uniqueAlbumFolder returns the first folder for album, after its own, that
+// is neither taken nor holds files of another release.
+func uniqueAlbumFolder(album *model.Album, taken map[string]bool) string {
+	base := filepath.Clean(album.Path)
+	var suffixes []string
+	if !album.ReleaseDate.IsZero() {
+		suffixes = append(suffixes, album.ReleaseDate.Format("2006"))
+	}
+	if album.ID != 0 {
+		suffixes = append(suffixes, strconv.FormatInt(album.ID, 10))
+	}
+
+	for i := 0; ; i++ {
+		suffix := strconv.Itoa(i - len(suffixes) + 2) // (2), (3)...
+		if i < len(suffixes) {
+			suffix = suffixes[i]
+		}
+		path := base + " (" + suffix + ")"
+		if !taken[folderKey(path)] && !folderOfOtherRelease(path, album.URL) {
+			return path
+		}
+	}
+}
+
+// folderKey identifies a folder case-insensitively, as file systems of
+// Windows and macOS do.
+func folderKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// folderOfOtherRelease reports whether the folder at path holds a track,
+// of any format the Tagger writes, tagged with the URL of a release other
+// than albumURL. Folders without tagged tracks are not taken to be another
+// release's.
+func folderOfOtherRelease(path, albumURL string) bool {
+	if albumURL == "" {
+		return false
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !audio.CanTag(filepath.Ext(entry.Name())) {
+			continue
+		}
+		tags, err := audio.ReadTagInfo(filepath.Join(path, entry.Name()))
+		if err != nil || tags.BandcampURL == "" {
+			continue
+		}
+		return normalizeURL(tags.BandcampURL) != normalizeURL(albumURL)
+	}
+	return false
+}
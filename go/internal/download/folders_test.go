@@ -0,0 +1,86 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestDedupAlbumFolders(t *testing.T) {
+	dir := t.TempDir()
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(dir, "{artist}", "{album}")
+	m := NewManager(settings, nil)
+
+	newAlbum := func(id int64, year int, url string) *model.Album {
+		album := model.NewAlbum("Artist", "Album", "", time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), settings.ToPathConfig())
+		album.ID = id
+		album.URL = url
+		album.Tracks = []*model.Track{model.NewTrack(album, 1, 1, "Title", 180, "", "", settings.ToTrackConfig())}
+		return album
+	}
+
+	// Files of the original, downloaded by an earlier run
+	original := newAlbum(1, 1995, "https://artist.bandcamp.com/album/album")
+	if err := os.MkdirAll(original.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(original.Tracks[0].Path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := audio.NewTagger(nil).SaveTags(original.Tracks[0], original, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	m.albums = []*model.Album{
+		newAlbum(1, 1995, "https://artist.bandcamp.com/album/album"),
+		newAlbum(2, 2020, "https://artist.bandcamp.com/album/album-remastered"),
+		newAlbum(3, 2020, "https://artist.bandcamp.com/album/album-deluxe"),
+		newAlbum(0, 2020, "https://artist.bandcamp.com/album/album-live"),
+	}
+	m.dedupAlbumFolders()
+
+	base := filepath.Join(dir, "Artist", "Album")
+	want := []string{base, base + " (2020)", base + " (3)", base + " (2)"}
+	for i, album := range m.albums {
+		if album.Path != want[i] {
+			t.Errorf("album %d folder = %q, want %q", i, album.Path, want[i])
+		}
+		if filepath.Dir(album.Tracks[0].Path) != want[i] {
+			t.Errorf("album %d track in %q, want %q", i, filepath.Dir(album.Tracks[0].Path), want[i])
+		}
+	}
+
+	// The reissue alone is not downloaded into the folder of the original
+	m.albums = []*model.Album{newAlbum(2, 2020, "https://artist.bandcamp.com/album/album-remastered")}
+	m.dedupAlbumFolders()
+	if m.albums[0].Path != base+" (2020)" {
+		t.Errorf("reissue folder = %q, want %q", m.albums[0].Path, base+" (2020)")
+	}
+	// Nor into that of an original downloaded as FLAC
+	flacSettings := *settings
+	flacSettings.DownloadsPath = filepath.Join(dir, "FLAC", "{album}")
+	flac := model.NewAlbum("Artist", "Album", "", time.Date(1995, 1, 1, 0, 0, 0, 0, time.UTC), flacSettings.ToPathConfig())
+	flac.URL = "https://artist.bandcamp.com/album/album"
+	flacTrack := model.NewTrack(flac, 1, 1, "Title", 180, "", "", &model.TrackConfig{FileNameFormat: "{tracknum} {title}.flac"})
+	if err := os.MkdirAll(flac.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(flacTrack.Path, flacStream(16), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := audio.NewTagger(nil).SaveTags(flacTrack, flac, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !folderOfOtherRelease(flac.Path, "https://artist.bandcamp.com/album/album-remastered") {
+		t.Error("the folder of the FLAC files of the original is not taken to be another release's")
+	}
+	if folderOfOtherRelease(flac.Path, flac.URL) {
+		t.Error("the folder of the FLAC files of the album is taken to be another release's")
+	}
+}
@@ -0,0 +1,59 @@
+package download
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// applyLimits drops the albums past LimitAlbums, then the tracks past
+// LimitTracks, in the order they were found, and reports what was left
+// out. Albums left without tracks are dropped.
+func (m *Manager) applyLimits() {
+	if limit := m.settings.LimitAlbums; limit > 0 && len(m.albums) > limit {
+		dropped := m.albums[limit:]
+		m.albums = m.albums[:limit]
+		names := make([]string, 0, len(dropped))
+		for _, album := range dropped {
+			m.forgetAlbum(album)
+			names = append(names, fmt.Sprintf("%s - %s", album.Artist, album.Title))
+		}
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Album limit of %d reached, skipping %d album(s): %s", limit, len(dropped), strings.Join(names, ", ")), Level: LevelWarning})
+	}
+
+	limit := m.settings.LimitTracks
+	if limit <= 0 {
+		return
+	}
+	remaining := limit
+	kept := m.albums[:0]
+	var skipped int
+	for _, album := range m.albums {
+		if len(album.Tracks) <= remaining {
+			remaining -= len(album.Tracks)
+			kept = append(kept, album)
+			continue
+		}
+		skipped += len(album.Tracks) - remaining
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Track limit: skipping %d of the %d track(s) of %s - %s", len(album.Tracks)-remaining, len(album.Tracks), album.Artist, album.Title), Level: LevelVerbose})
+		if remaining == 0 {
+			m.forgetAlbum(album)
+			continue
+		}
+		album.Tracks = album.Tracks[:remaining]
+		remaining = 0
+		kept = append(kept, album)
+	}
+	m.albums = kept
+	if skipped > 0 {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Track limit of %d reached, skipping %d track(s)", limit, skipped), Level: LevelWarning})
+	}
+}
+
+// forgetAlbum removes the state kept for an album that will not be
+// downloaded.
+func (m *Manager) forgetAlbum(album *model.Album) {
+	delete(m.albumProgress, album)
+	delete(m.albumConfigs, album)
+}
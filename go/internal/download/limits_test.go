@@ -0,0 +1,60 @@
+package download
+
+import (
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestApplyLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		limitAlbums int
+		limitTracks int
+		want        []int // tracks kept per album
+	}{
+		{name: "no limits", want: []int{3, 2, 4}},
+		{name: "albums", limitAlbums: 2, want: []int{3, 2}},
+		{name: "tracks within an album", limitTracks: 4, want: []int{3, 1}},
+		{name: "tracks at an album boundary", limitTracks: 5, want: []int{3, 2}},
+		{name: "both", limitAlbums: 1, limitTracks: 2, want: []int{2}},
+		{name: "above the totals", limitAlbums: 10, limitTracks: 100, want: []int{3, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := config.DefaultSettings()
+			settings.LimitAlbums = tt.limitAlbums
+			settings.LimitTracks = tt.limitTracks
+			m := NewManager(settings, nil)
+			for _, n := range []int{3, 2, 4} {
+				album := &model.Album{}
+				for i := 0; i < n; i++ {
+					album.Tracks = append(album.Tracks, &model.Track{Album: album, Number: i + 1})
+				}
+				m.albums = append(m.albums, album)
+				m.albumProgress[album] = &albumProgress{album: album}
+			}
+
+			m.applyLimits()
+
+			var got []int
+			for _, album := range m.albums {
+				got = append(got, len(album.Tracks))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("tracks per album = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("tracks per album = %v, want %v", got, tt.want)
+					break
+				}
+			}
+			if len(m.albumProgress) != len(m.albums) {
+				t.Errorf("%d albums with progress, want %d", len(m.albumProgress), len(m.albums))
+			}
+		})
+	}
+}
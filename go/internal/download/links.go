@@ -0,0 +1,137 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ioutils "github.com/handiism/bandcamp-downloader/internal/io"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// Link modes of LinkViews.
+const (
+	linkSymlink  = "symlink"
+	linkHardlink = "hardlink"
+)
+
+// linkAlbum links the completed album into the folders of the LinkViews
+// setting. An album archived with "zip" is linked as its archive, since
+// its folder was removed.
+func (m *Manager) linkAlbum(album *model.Album) {
+	settings := m.albumSettings(album)
+	if len(settings.LinkViews) == 0 {
+		return
+	}
+
+	target, ext := album.Path, ""
+	if _, err := os.Stat(target); err != nil {
+		target, ext = archivePath(album), ".zip"
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error linking %s: %v", album.Title, err), Level: LevelWarning})
+		return
+	}
+
+	var linked int
+	for _, view := range settings.LinkViews {
+		for _, path := range linkPaths(album, view, settings.ToPathConfig()) {
+			if err := link(target, path+ext, settings.LinkMode); err != nil {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Error linking %s to %s: %v", album.Title, path+ext, err), Level: LevelWarning})
+				continue
+			}
+			linked++
+		}
+	}
+	if linked > 0 {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Linked %s in %d view folder(s)", album.Title, linked), Level: LevelVerbose})
+	}
+}
+
+// linkPaths returns the folders of album in the layout view, one per tag
+// of the release if view has a {genre} placeholder, and none if it has no
+// tags.
+func linkPaths(album *model.Album, view string, cfg *model.PathConfig) []string {
+	views := []string{view}
+	if strings.Contains(view, "{genre}") {
+		views = nil
+		for _, tag := range album.Tags {
+			views = append(views, strings.ReplaceAll(view, "{genre}", ioutils.SanitizeFileName(tag)))
+		}
+	}
+
+	paths := make([]string, len(views))
+	for i, v := range views {
+		viewCfg := *cfg
+		viewCfg.DownloadsPath = v
+		paths[i] = album.FolderPath(&viewCfg)
+	}
+	return paths
+}
+
+// link links path to target, a folder or a file: with mode "hardlink", a
+// folder becomes a folder of hardlinks to its files, since folders cannot
+// be hardlinked. Links already pointing to target are left as they are.
+func link(target, path, mode string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	if mode == linkHardlink {
+		if !info.IsDir() {
+			return hardlink(target, path)
+		}
+		return filepath.WalkDir(target, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(target, file)
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return os.MkdirAll(filepath.Join(path, rel), 0755)
+			}
+			return hardlink(file, filepath.Join(path, rel))
+		})
+	}
+
+	if dest, err := os.Readlink(path); err == nil {
+		if dest == target {
+			return nil
+		}
+		// A link of an earlier version of the album, e.g. before it moved
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	} else if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	return os.Symlink(target, path)
+}
+
+// hardlink links path to the file target, replacing a file at path that
+// is not already target.
+func hardlink(target, path string) error {
+	err := os.Link(target, path)
+	if !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	targetInfo, terr := os.Stat(target)
+	pathInfo, perr := os.Lstat(path)
+	if terr == nil && perr == nil && os.SameFile(targetInfo, pathInfo) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return os.Link(target, path)
+}
@@ -0,0 +1,73 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestLinkPaths(t *testing.T) {
+	cfg := &model.PathConfig{}
+	album := &model.Album{
+		Artist:      "Artist",
+		Title:       "Album",
+		ReleaseDate: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+		Tags:        []string{"ambient", "drone/noise"},
+	}
+
+	got := linkPaths(album, "/music/by-year/{year}/{artist} - {album}", cfg)
+	if want := []string{"/music/by-year/2021/Artist - Album"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("linkPaths(by-year) = %v, want %v", got, want)
+	}
+
+	got = linkPaths(album, "/music/by-genre/{genre}/{album}", cfg)
+	if want := []string{"/music/by-genre/ambient/Album", "/music/by-genre/drone_noise/Album"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("linkPaths(by-genre) = %v, want %v", got, want)
+	}
+
+	album.Tags = nil
+	if got := linkPaths(album, "/music/by-genre/{genre}/{album}", cfg); len(got) != 0 {
+		t.Errorf("linkPaths(by-genre) without tags = %v, want none", got)
+	}
+}
+
+func TestLink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "library", "Artist", "Album")
+	if err := os.MkdirAll(filepath.Join(target, "CD2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"01 One.mp3", filepath.Join("CD2", "01 Two.mp3")} {
+		if err := os.WriteFile(filepath.Join(target, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	symlink := filepath.Join(dir, "by-year", "2021", "Album")
+	for i := 0; i < 2; i++ { // linking again is a no-op
+		if err := link(target, symlink, linkSymlink); err != nil {
+			t.Fatalf("link(symlink): %v", err)
+		}
+	}
+	if dest, err := os.Readlink(symlink); err != nil || dest != target {
+		t.Errorf("symlink points to %q (%v), want %q", dest, err, target)
+	}
+
+	hardlinks := filepath.Join(dir, "by-genre", "ambient", "Album")
+	for i := 0; i < 2; i++ {
+		if err := link(target, hardlinks, linkHardlink); err != nil {
+			t.Fatalf("link(hardlink): %v", err)
+		}
+	}
+	for _, name := range []string{"01 One.mp3", filepath.Join("CD2", "01 Two.mp3")} {
+		original, _ := os.Stat(filepath.Join(target, name))
+		linked, err := os.Stat(filepath.Join(hardlinks, name))
+		if err != nil || !os.SameFile(original, linked) {
+			t.Errorf("%s is not a hardlink of the album's file: %v", name, err)
+		}
+	}
+}
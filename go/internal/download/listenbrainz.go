@@ -0,0 +1,29 @@
+package download
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/handiism/bandcamp-downloader/internal/listenbrainz"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// checkListenBrainz looks up the first track of album on ListenBrainz, if
+// settings.ListenBrainzLookup is enabled, and warns if its listens would
+// not be mapped to the release on MusicBrainz.
+func (m *Manager) checkListenBrainz(ctx context.Context, album *model.Album) {
+	if !m.albumSettings(album).ListenBrainzLookup || len(album.Tracks) == 0 {
+		return
+	}
+
+	track := album.Tracks[0]
+	match, err := listenbrainz.NewClient(m.httpClient, "").Lookup(ctx, track.ArtistName(), track.Title, album.Title)
+	switch {
+	case err != nil:
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error looking up %s on ListenBrainz: %v", album.Title, err), Level: LevelWarning})
+	case !match.SameRelease(album.Title):
+		m.progress(ProgressEvent{Message: fmt.Sprintf("ListenBrainz cannot map listens of %s - %s to MusicBrainz; add the release at https://musicbrainz.org/release/add", album.Artist, album.Title), Level: LevelWarning})
+	default:
+		m.progress(ProgressEvent{Message: fmt.Sprintf("ListenBrainz maps %s to https://musicbrainz.org/release/%s", album.Title, match.ReleaseMBID), Level: LevelVerbose})
+	}
+}
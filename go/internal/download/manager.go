@@ -2,11 +2,16 @@ package download
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"io/fs"
+	"maps"
 	"math"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -30,12 +35,45 @@ const (
 	LevelWarning
 	LevelError
 	LevelSuccess
+
+	// LevelProgress events report the byte progress of a track transfer.
+	// They carry no Message; use Album, Track, Written and Total instead.
+	LevelProgress
 )
 
+// String returns a lowercase name for the level, e.g. for log files.
+func (l ProgressLevel) String() string {
+	switch l {
+	case LevelInfo:
+		return "info"
+	case LevelVerbose:
+		return "verbose"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	case LevelSuccess:
+		return "success"
+	case LevelProgress:
+		return "progress"
+	default:
+		return "unknown"
+	}
+}
+
 // ProgressEvent represents a download progress update.
 type ProgressEvent struct {
 	Message string
 	Level   ProgressLevel
+
+	// Album and Track identify the transfer of a LevelProgress event.
+	Album *model.Album
+	Track *model.Track
+
+	// Written and Total are the bytes written so far and the expected size
+	// of a LevelProgress event's transfer. Total is -1 if unknown.
+	Written int64
+	Total   int64
 }
 
 // Manager coordinates album downloads.
@@ -43,92 +81,403 @@ type Manager struct {
 	settings     *config.Settings
 	httpClient   *http.Client
 	parser       *bandcamp.Parser
+	pages        *bandcamp.StrategyChain
+	mobileAPI    *bandcamp.MobileAPI // nil unless settings.MobileAPI
 	discography  *bandcamp.Discography
 	tagger       *audio.Tagger
 	playlist     *audio.PlaylistCreator
 	imageService *ioutils.ImageService
+	artworkCache *artworkCache
+	albumCache   *albumCache
+	metrics      *Metrics
+	pipeline     Pipeline
+
+	// artworkFetches shares downloaded artwork between albums of a run,
+	// keyed by artworkKey.
+	artworkMu      sync.Mutex
+	artworkFetches map[string]*artworkFetch
+
+	// extraPictures shares the pictures of extra_picture_url between the
+	// albums of a run, keyed by URL and processing options.
+	extraPicturesMu sync.Mutex
+	extraPictures   map[string]*extraPicture
+
+	// trackIndexes holds the track IDs of the files under the folders
+	// searched for tracks missing from their path, keyed by folder.
+	trackIndexesMu sync.Mutex
+	trackIndexes   map[string]*trackIndex
+
+	// purchases are those of the account of the cookies_file, read once
+	// per run by accountPurchases; nil until then.
+	purchasesMu sync.Mutex
+	purchases   *[]bandcamp.Purchase
+
+	// mobilePages are the releases of discographies read from the mobile
+	// API, keyed by URL, whose pages are not fetched.
+	mobilePages map[string]*bandcamp.AlbumPage
 
 	albums          []*model.Album
+	albumProgress   map[*model.Album]*albumProgress
+	albumConfigs    map[*model.Album]*albumConfig // albums matched by overrides
+	fetchFailures   []string
 	totalBytes      int64
 	receivedBytes   int64
+	skippedBytes    int64
 	totalFiles      int32
 	downloadedFiles int32
+	budgetLeft      int32 // tracks not started because of MaxTotalBytes
+
+	// durationMismatches counts the tracks kept although their duration
+	// is off, see checkDuration.
+	durationMismatches int32
+
+	// streamMu guards the tracks' Mp3URL, which refreshStreamURLs
+	// rewrites while other tracks of the album are downloading.
+	streamMu        sync.Mutex
+	streamRefreshed map[*model.Album]time.Time
+
+	// beetsMu serializes the beets imports, see runBeetsImport.
+	beetsMu sync.Mutex
+
+	// running is true while StartDownloads or Reset runs.
+	running atomic.Bool
 
 	onProgress func(ProgressEvent)
-	mu         sync.RWMutex
+
+	// mu guards started and finished, the times StartDownloads started
+	// and returned, and view.
+	mu       sync.RWMutex
+	started  time.Time
+	finished time.Time
+	view     runView
+}
+
+// runView is what the getters read of the albums of a run, published by
+// publish once they are known. The fields of the Manager they come from
+// are rewritten by the next Initialize, Retag or Verify while the getters
+// may be polled, e.g. by a UI.
+type runView struct {
+	albums        []*model.Album
+	progress      map[*model.Album]*albumProgress
+	fetchFailures []string
+	totalBytes    int64
+	totalFiles    int32
+}
+
+// publish makes the albums, progress, failures and totals of the run
+// those read by the getters.
+func (m *Manager) publish() {
+	view := runView{
+		albums:        slices.Clone(m.albums),
+		progress:      maps.Clone(m.albumProgress),
+		fetchFailures: slices.Clone(m.fetchFailures),
+		totalBytes:    m.totalBytes,
+		totalFiles:    m.totalFiles,
+	}
+	m.mu.Lock()
+	m.view = view
+	m.mu.Unlock()
 }
 
+// currentView returns the view last published.
+func (m *Manager) currentView() runView {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.view
+}
+
+// streamRefreshInterval is the minimum time between two refreshes of an
+// album's stream URLs, so concurrent tracks hitting the same expiry share
+// a single page fetch.
+const streamRefreshInterval = time.Minute
+
 // NewManager creates a new download Manager.
 func NewManager(settings *config.Settings, onProgress func(ProgressEvent)) *Manager {
 	pathCfg := settings.ToPathConfig()
 	trackCfg := settings.ToTrackConfig()
 
-	var playlistFormat audio.PlaylistFormat
-	switch settings.PlaylistFormat {
-	case "pls":
-		playlistFormat = audio.FormatPLS
-	case "wpl":
-		playlistFormat = audio.FormatWPL
-	case "zpl":
-		playlistFormat = audio.FormatZPL
-	default:
-		playlistFormat = audio.FormatM3U
+	imageService := ioutils.NewImageService()
+	imageService.JPEGQuality = settings.CoverArtJPEGQuality
+
+	m := &Manager{
+		settings:      settings,
+		parser:        bandcamp.NewParser(pathCfg, trackCfg),
+		discography:   bandcamp.NewDiscography(),
+		tagger:        newTagger(settings),
+		playlist:      newPlaylistCreator(settings),
+		imageService:  imageService,
+		artworkCache:  newArtworkCache(settings.ArtworkCacheDir),
+		albumCache:    newAlbumCache(settings.AlbumCacheDir, time.Duration(settings.AlbumCacheTTL*float64(time.Hour))),
+		albumProgress: make(map[*model.Album]*albumProgress),
+		albumConfigs:  make(map[*model.Album]*albumConfig),
+		onProgress:    onProgress,
+
+		streamRefreshed: make(map[*model.Album]time.Time),
+		artworkFetches:  make(map[string]*artworkFetch),
+		extraPictures:   make(map[string]*extraPicture),
+		trackIndexes:    make(map[string]*trackIndex),
 	}
 
-	return &Manager{
-		settings:     settings,
-		httpClient:   http.NewClient(),
-		parser:       bandcamp.NewParser(pathCfg, trackCfg),
-		discography:  bandcamp.NewDiscography(),
-		tagger:       audio.NewTagger(audio.DefaultTagConfig()),
-		playlist:     audio.NewPlaylistCreator(playlistFormat, settings.M3UExtended),
-		imageService: ioutils.NewImageService(),
-		onProgress:   onProgress,
+	clientCfg := settings.ToClientConfig()
+	clientCfg.OnRetry = m.onRetry
+	clientCfg.OnBreaker = m.onBreaker
+	m.httpClient = http.NewClient(clientCfg)
+
+	// Settings are validated: unknown parsers only come from code
+	strategies, _ := bandcamp.NewStrategies(settings.PageParsers...)
+	if settings.MobileAPI {
+		m.mobileAPI = bandcamp.NewMobileAPI(m.httpClient, "")
+		strategies = append([]bandcamp.Strategy{bandcamp.MobileStrategy{API: m.mobileAPI}}, strategies...)
 	}
+	m.pages = bandcamp.NewStrategyChain(strategies...)
+	m.pages.OnResult = m.onParserResult
+	m.pipeline = m.defaultPipeline()
+	return m
+}
+
+// SetTracer records the requests of the Manager with t, for debugging. It
+// must be called before the Manager is used.
+func (m *Manager) SetTracer(t *http.Tracer) {
+	m.httpClient.SetTracer(t)
 }
 
-// Initialize fetches album info from the input URLs.
+// ErrRunning is returned by Initialize, StartDownloads, Retag, Verify, Fix
+// and Reset while one of them is running: a Manager runs one at a time.
+var ErrRunning = errors.New("downloads are in progress")
+
+// Initialize fetches album info from the input URLs, running the Discover,
+// Parse and Plan stages of the Pipeline. The albums, progress
+// and totals of a previous run are cleared first (see Reset), so the same
+// Manager can run several times in a row, but not concurrently.
 func (m *Manager) Initialize(ctx context.Context, inputURLs string) error {
+	if !m.running.CompareAndSwap(false, true) {
+		return ErrRunning
+	}
+	defer m.running.Store(false)
+
+	m.reset()
+	defer m.publish()
+	m.fetchAlbums(ctx, inputURLs)
+	if err := m.planAlbums(ctx); err != nil {
+		return err
+	}
+
+	// Calculate total bytes to download
+	m.calculateTotals(ctx)
+
+	return nil
+}
+
+// fetchAlbums runs the Discover stage on the input URLs and the Parse stage
+// on the releases found, parsing them into m.albums.
+func (m *Manager) fetchAlbums(ctx context.Context, inputURLs string) {
 	urls := m.parseInputURLs(inputURLs)
 
 	var allAlbumURLs []string
+	// seenURLs maps the normalized URLs to their index in allAlbumURLs
+	seenURLs := make(map[string]int)
+	// crawled are the URLs found on a music page rather than given
+	crawled := make(map[string]bool)
 	for _, inputURL := range urls {
-		albumURLs, err := m.getAlbumURLs(ctx, inputURL)
+		albumURLs, err := m.pipeline.Discover.Discover(ctx, inputURL)
 		if err != nil {
 			m.progress(ProgressEvent{Message: fmt.Sprintf("Error getting albums from %s: %v", inputURL, err), Level: LevelError})
+			m.fetchFailures = append(m.fetchFailures, inputURL)
 			continue
 		}
-		allAlbumURLs = append(allAlbumURLs, albumURLs...)
+		for _, albumURL := range albumURLs {
+			key := normalizeURL(albumURL)
+			if i, ok := seenURLs[key]; ok {
+				// Keep the URL with a query, which may hold the access
+				// token of an unlisted release
+				if kept := allAlbumURLs[i]; !strings.Contains(kept, "?") && strings.Contains(albumURL, "?") {
+					allAlbumURLs[i] = albumURL
+					crawled[albumURL] = crawled[kept] && albumURL != inputURL
+					delete(crawled, kept)
+					albumURL = kept
+				}
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping duplicate URL: %s", albumURL), Level: LevelVerbose})
+				continue
+			}
+			seenURLs[key] = len(allAlbumURLs)
+			allAlbumURLs = append(allAlbumURLs, albumURL)
+			if albumURL != inputURL {
+				crawled[albumURL] = true
+			}
+		}
 	}
 
-	// Fetch album info
+	// Read the releases
+	seenIDs := make(map[int64]struct{})
 	for _, albumURL := range allAlbumURLs {
-		m.progress(ProgressEvent{Message: fmt.Sprintf("Fetching album info: %s", albumURL), Level: LevelVerbose})
-
-		html, err := m.httpClient.GetString(ctx, albumURL)
-		if err != nil {
-			m.progress(ProgressEvent{Message: fmt.Sprintf("Error fetching %s: %v", albumURL, err), Level: LevelError})
+		album, err := m.pipeline.Parse.Parse(ctx, albumURL)
+		if errors.Is(err, bandcamp.ErrNoTracks) {
+			// Music pages also list items without audio, e.g. merch
+			// bundles, which would only be empty albums
+			level := LevelWarning
+			if crawled[albumURL] {
+				level = LevelVerbose
+			}
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping %s: no tracks (e.g. a merch bundle)", albumURL), Level: level})
 			continue
 		}
-
-		album, err := m.parser.ParseAlbumPage(html)
 		if err != nil {
-			m.progress(ProgressEvent{Message: fmt.Sprintf("Error parsing %s: %v", albumURL, err), Level: LevelError})
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error reading %s: %v", albumURL, err), Level: LevelError})
+			m.fetchFailures = append(m.fetchFailures, albumURL)
 			continue
 		}
 
+		// The same release can be reachable through different URLs
+		// (e.g. the artist page and a label page)
+		if album.ID != 0 {
+			if _, ok := seenIDs[album.ID]; ok {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping duplicate album: %s - %s", album.Artist, album.Title), Level: LevelVerbose})
+				m.forgetAlbum(album)
+				continue
+			}
+			seenIDs[album.ID] = struct{}{}
+		}
+
+		if len(album.Restrictions) > 0 {
+			reasons := strings.Join(album.Restrictions, ", ")
+			if !m.albumSettings(album).IgnoreArtistRestrictions {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping %s - %s: %s. Use -force to download it anyway", album.Artist, album.Title, reasons), Level: LevelWarning})
+				m.forgetAlbum(album)
+				continue
+			}
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Downloading %s - %s despite artist restrictions: %s", album.Artist, album.Title, reasons), Level: LevelWarning})
+		}
+
 		m.albums = append(m.albums, album)
+		m.albumProgress[album] = &albumProgress{album: album}
 		m.progress(ProgressEvent{Message: fmt.Sprintf("Found album: %s - %s (%d tracks)", album.Artist, album.Title, len(album.Tracks)), Level: LevelInfo})
+		if n := len(album.SkippedVideos); n > 0 {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping %d video item(s) of %s: %s", n, album.Title, strings.Join(album.SkippedVideos, ", ")), Level: LevelWarning})
+		}
+		if n := len(album.UnavailableTracks); n > 0 {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping %d unavailable track(s) of %s: %s", n, album.Title, strings.Join(album.UnavailableTracks, ", ")), Level: LevelWarning})
+		}
 	}
+}
 
-	// Calculate total bytes to download
-	m.calculateTotals(ctx)
+// parseRelease is the default Parse stage. It reads the release at
+// albumURL from the album cache, the releases of the discographies read
+// from the mobile API, or its page, and makes it an album with the
+// settings of the overrides matching it.
+func (m *Manager) parseRelease(ctx context.Context, albumURL string) (*model.Album, error) {
+	entry := m.albumCache.load(albumURL)
+	if entry != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Using cached album info: %s", albumURL), Level: LevelVerbose})
+	} else if albumPage := m.mobilePages[albumURL]; albumPage != nil {
+		entry = &albumCacheEntry{URL: albumURL, Fetched: time.Now(), Page: albumPage}
+		if err := m.albumCache.store(albumURL, entry); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error caching album info: %v", err), Level: LevelVerbose})
+		}
+	} else {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Fetching album info: %s", albumURL), Level: LevelVerbose})
+
+		page, err := m.httpClient.GetPage(ctx, albumURL)
+		if err != nil {
+			return nil, err
+		}
+		m.reportRedirects(page)
 
+		albumPage, err := m.readAlbumPage(ctx, page.URL, page.HTML)
+		if err != nil {
+			return nil, err
+		}
+		entry = &albumCacheEntry{URL: page.URL, Fetched: time.Now(), Page: albumPage}
+		if err := m.albumCache.store(albumURL, entry); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error caching album info: %v", err), Level: LevelVerbose})
+		}
+	}
+
+	// Overrides may be keyed by the canonical URL or the one given
+	cfg := m.overrideFor(entry.URL)
+	if cfg == nil && entry.URL != albumURL {
+		cfg = m.overrideFor(albumURL)
+	}
+	parser := m.parser
+	if cfg != nil {
+		parser = bandcamp.NewParser(cfg.settings.ToPathConfig(), cfg.settings.ToTrackConfig())
+	}
+
+	album := parser.ToAlbum(entry.Page)
+	album.URL = keepQuery(entry.URL, albumURL)
+	if cfg != nil {
+		m.albumConfigs[album] = cfg
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Using overridden settings for %s", album.URL), Level: LevelVerbose})
+	}
+	return album, nil
+}
+
+// Reset clears the albums, progress, totals and failures of the previous
+// run, and the artwork and files it shared between albums. The settings,
+// caches on disk and metrics are kept. It returns ErrRunning while the
+// Manager runs.
+func (m *Manager) Reset() error {
+	if !m.running.CompareAndSwap(false, true) {
+		return ErrRunning
+	}
+	defer m.running.Store(false)
+
+	m.reset()
 	return nil
 }
 
-// StartDownloads begins downloading all initialized albums.
+// reset is Reset, for Initialize, Retag and Verify, which call it while
+// they hold the running flag.
+func (m *Manager) reset() {
+	m.albums = nil
+	m.mobilePages = nil
+	m.albumProgress = make(map[*model.Album]*albumProgress)
+	m.albumConfigs = make(map[*model.Album]*albumConfig)
+	m.fetchFailures = nil
+	m.totalBytes, m.totalFiles = 0, 0
+	atomic.StoreInt64(&m.receivedBytes, 0)
+	atomic.StoreInt64(&m.skippedBytes, 0)
+	atomic.StoreInt32(&m.downloadedFiles, 0)
+	atomic.StoreInt32(&m.budgetLeft, 0)
+	atomic.StoreInt32(&m.durationMismatches, 0)
+	m.mu.Lock()
+	m.started, m.finished = time.Time{}, time.Time{}
+	m.mu.Unlock()
+
+	m.streamMu.Lock()
+	m.streamRefreshed = make(map[*model.Album]time.Time)
+	m.streamMu.Unlock()
+	m.artworkMu.Lock()
+	m.artworkFetches = make(map[string]*artworkFetch)
+	m.artworkMu.Unlock()
+	m.extraPicturesMu.Lock()
+	m.extraPictures = make(map[string]*extraPicture)
+	m.extraPicturesMu.Unlock()
+	m.trackIndexesMu.Lock()
+	m.trackIndexes = make(map[string]*trackIndex)
+	m.trackIndexesMu.Unlock()
+	m.purchasesMu.Lock()
+	m.purchases = nil
+	m.purchasesMu.Unlock()
+	m.publish()
+}
+
+// StartDownloads begins downloading all initialized albums. It returns
+// ErrRunning if it is already running.
 func (m *Manager) StartDownloads(ctx context.Context) error {
+	if !m.running.CompareAndSwap(false, true) {
+		return ErrRunning
+	}
+	defer m.running.Store(false)
+
+	m.mu.Lock()
+	m.started, m.finished = time.Now(), time.Time{}
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.finished = time.Now()
+		m.mu.Unlock()
+	}()
+
 	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(m.settings.MaxConcurrentAlbumsDownload)
 
@@ -139,24 +488,185 @@ func (m *Manager) StartDownloads(ctx context.Context) error {
 		})
 	}
 
-	return g.Wait()
+	err := g.Wait()
+	m.reportBudget()
+	m.saveBeetsManifest()
+	return err
 }
 
 // GetProgress returns current download progress.
 func (m *Manager) GetProgress() (received, total int64, filesReceived, filesTotal int32) {
-	return atomic.LoadInt64(&m.receivedBytes), m.totalBytes,
-		atomic.LoadInt32(&m.downloadedFiles), m.totalFiles
+	view := m.currentView()
+	return atomic.LoadInt64(&m.receivedBytes), view.totalBytes,
+		atomic.LoadInt32(&m.downloadedFiles), view.totalFiles
+}
+
+// GetByteStats returns the received, skipped and expected byte counts.
+func (m *Manager) GetByteStats() ByteStats {
+	return ByteStats{
+		Received: atomic.LoadInt64(&m.receivedBytes),
+		Skipped:  atomic.LoadInt64(&m.skippedBytes),
+		Total:    m.currentView().totalBytes,
+	}
+}
+
+// GetRunStats returns how many tracks of the initialized albums were
+// downloaded, skipped, unavailable or failed, and the time and speed of
+// the downloads, for the summary of a run:
+//
+//	stats := manager.GetRunStats()
+//	fmt.Printf("%d downloaded, %d failed in %s (%.2f MB/s)\n",
+//	    stats.Downloaded, stats.Failed, stats.Elapsed, stats.Speed()/1024/1024)
+func (m *Manager) GetRunStats() RunStats {
+	stats := RunStats{
+		Received:           atomic.LoadInt64(&m.receivedBytes),
+		DurationMismatches: int(atomic.LoadInt32(&m.durationMismatches)),
+	}
+	view := m.currentView()
+	for _, album := range view.albums {
+		ap := view.progress[album]
+		stats.Unavailable += len(album.UnavailableTracks)
+		for _, track := range album.Tracks {
+			switch ap.trackState(track) {
+			case TrackDownloaded:
+				stats.Downloaded++
+			case TrackSkipped:
+				stats.Skipped++
+			case TrackFailed:
+				stats.Failed++
+			default:
+				stats.Pending++
+			}
+		}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	switch {
+	case !m.finished.IsZero():
+		stats.Elapsed = m.finished.Sub(m.started)
+	case !m.started.IsZero():
+		stats.Elapsed = time.Since(m.started)
+	}
+	return stats
+}
+
+// GetAlbumProgress returns a snapshot of the progress of the album with the
+// given Bandcamp item ID. The boolean is false if no such album was initialized.
+func (m *Manager) GetAlbumProgress(albumID int64) (AlbumProgress, bool) {
+	view := m.currentView()
+	for _, album := range view.albums {
+		if album.ID == albumID {
+			return view.progress[album].snapshot(), true
+		}
+	}
+	return AlbumProgress{}, false
+}
+
+// GetProgressSnapshot returns the progress of every initialized album,
+// in the same order as GetAlbumNames.
+func (m *Manager) GetProgressSnapshot() []AlbumProgress {
+	view := m.currentView()
+	snapshot := make([]AlbumProgress, len(view.albums))
+	for i, album := range view.albums {
+		snapshot[i] = view.progress[album].snapshot()
+	}
+	return snapshot
+}
+
+// GetAlbums returns a copy of every initialized album with its progress
+// and the state of its tracks, in the same order as GetAlbumNames, so UIs
+// and integrations can render structured information and select albums
+// or tracks (see AlbumSnapshot).
+func (m *Manager) GetAlbums() []AlbumSnapshot {
+	// The tracks' stream URLs change when they are refreshed
+	m.streamMu.Lock()
+	defer m.streamMu.Unlock()
+
+	view := m.currentView()
+	snapshots := make([]AlbumSnapshot, len(view.albums))
+	for i, album := range view.albums {
+		ap := view.progress[album]
+		states := make([]TrackState, len(album.Tracks))
+		for j, track := range album.Tracks {
+			states[j] = ap.trackState(track)
+		}
+		snapshots[i] = AlbumSnapshot{
+			Album:       copyAlbum(album),
+			Progress:    ap.snapshot(),
+			TrackStates: states,
+		}
+	}
+	return snapshots
+}
+
+// GetFailedURLs returns the URLs to retry after a run: input URLs whose
+// releases could not be listed, album pages that could not be fetched or
+// parsed, and albums that were not completely downloaded (failed, partial,
+// or never finished because the run was cancelled).
+//
+// Releases skipped on purpose, such as duplicates or restricted releases,
+// are not included. Tracks already downloaded are skipped when the URLs are
+// downloaded again, so only the missing files are fetched.
+//
+// Example:
+//
+//	manager.StartDownloads(ctx)
+//	if failed := manager.GetFailedURLs(); len(failed) > 0 {
+//	    os.WriteFile("failed.txt", []byte(strings.Join(failed, "\n")+"\n"), 0644)
+//	}
+func (m *Manager) GetFailedURLs() []string {
+	view := m.currentView()
+	urls := slices.Clone(view.fetchFailures)
+	for _, album := range view.albums {
+		if AlbumState(atomic.LoadInt32(&view.progress[album].state)) != AlbumCompleted {
+			urls = append(urls, album.URL)
+		}
+	}
+	return urls
+}
+
+// ExceedsSizeThreshold reports whether the total size of the initialized
+// albums is above ConfirmIfLargerThanMB, so that the user should confirm
+// the download before StartDownloads, and returns the size in MB. It is
+// always false with a threshold of 0.
+func (m *Manager) ExceedsSizeThreshold() (bool, float64) {
+	mb := float64(m.currentView().totalBytes) / 1024 / 1024
+	return m.settings.ConfirmIfLargerThanMB > 0 && mb > float64(m.settings.ConfirmIfLargerThanMB), mb
 }
 
 // GetAlbumNames returns the names of all initialized albums.
 func (m *Manager) GetAlbumNames() []string {
-	names := make([]string, len(m.albums))
-	for i, album := range m.albums {
+	albums := m.currentView().albums
+	names := make([]string, len(albums))
+	for i, album := range albums {
 		names[i] = fmt.Sprintf("%s - %s (%d tracks)", album.Artist, album.Title, len(album.Tracks))
 	}
 	return names
 }
 
+// GetAlbumTracks returns the tracks of the album at index i of
+// GetAlbumNames, to preview them before downloading, or nil if there is
+// no such album.
+func (m *Manager) GetAlbumTracks(i int) []TrackSummary {
+	albums := m.currentView().albums
+	if i < 0 || i >= len(albums) {
+		return nil
+	}
+	tracks := make([]TrackSummary, len(albums[i].Tracks))
+	for j, track := range albums[i].Tracks {
+		tracks[j] = TrackSummary{
+			Number:        track.Number,
+			DiscNumber:    track.DiscNumber,
+			Title:         track.Title,
+			Artist:        track.ArtistName(),
+			Duration:      time.Duration(track.Duration * float64(time.Second)),
+			EstimatedSize: track.EstimatedSize(),
+		}
+	}
+	return tracks
+}
+
 func (m *Manager) parseInputURLs(input string) []string {
 	lines := strings.Split(input, "\n")
 	var urls []string
@@ -169,12 +679,86 @@ func (m *Manager) parseInputURLs(input string) []string {
 	return urls
 }
 
+// normalizeURL returns a canonical form of a Bandcamp URL, used as a key to
+// detect the same page pasted with a different scheme, host case, "www."
+// prefix, trailing slash, query string or fragment.
+func normalizeURL(rawURL string) string {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsedURL.Host), "www.")
+	path := strings.TrimRight(strings.ToLower(parsedURL.Path), "/")
+
+	return host + path
+}
+
+// resolveURL resolves ref against base like url.URL.ResolveReference, but
+// carries the query string of base over when ref has none of its own.
+//
+// Links to unlisted releases only work with the access token (e.g.
+// "?from=..." or a secret link parameter) of the page they were found on,
+// which a plain resolution drops.
+//
+// Example:
+//
+//	base, _ := url.Parse("https://artist.bandcamp.com/music?secret=abc")
+//	resolveURL(base, "/album/hidden")
+//	// "https://artist.bandcamp.com/album/hidden?secret=abc"
+func resolveURL(base *url.URL, ref string) (string, error) {
+	refURL, err := url.Parse(html.UnescapeString(ref))
+	if err != nil {
+		return "", err
+	}
+
+	resolved := base.ResolveReference(refURL)
+	if refURL.RawQuery == "" && !refURL.ForceQuery {
+		resolved.RawQuery = base.RawQuery
+	}
+	return resolved.String(), nil
+}
+
+// keepQuery returns finalURL with the query string of requestedURL if the
+// redirects leading to finalURL dropped it.
+func keepQuery(finalURL, requestedURL string) string {
+	final, err := url.Parse(finalURL)
+	if err != nil || final.RawQuery != "" {
+		return finalURL
+	}
+	requested, err := url.Parse(requestedURL)
+	if err != nil || requested.RawQuery == "" {
+		return finalURL
+	}
+
+	final.RawQuery = requested.RawQuery
+	return final.String()
+}
+
+// getAlbumURLs is the default Discover stage. It returns the releases of
+// tag and Discover pages, inputURL itself for releases, and the releases
+// of the discography of an artist (sampled, see sampleURLs) if
+// DownloadArtistDiscography is set.
 func (m *Manager) getAlbumURLs(ctx context.Context, inputURL string) ([]string, error) {
 	parsedURL, err := url.Parse(inputURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// Reject merch, community and other non-music pages early
+	if err := bandcamp.CheckPageURL(inputURL); err != nil {
+		return nil, err
+	}
+
+	// Tag and Discover pages list releases of many accounts, regardless of
+	// the discography setting
+	if tag, ok := bandcamp.TagFromURL(inputURL); ok {
+		return m.tagAlbumURLs(ctx, tag)
+	}
+	if query, ok := bandcamp.DiscoverFromURL(inputURL); ok {
+		return m.discoverAlbumURLs(ctx, query)
+	}
+
 	// Check if it's already an album/track URL
 	if strings.Contains(parsedURL.Path, "/album/") || strings.Contains(parsedURL.Path, "/track/") {
 		return []string{inputURL}, nil
@@ -185,71 +769,200 @@ func (m *Manager) getAlbumURLs(ctx context.Context, inputURL string) ([]string,
 		return []string{inputURL}, nil
 	}
 
-	musicURL := fmt.Sprintf("%s://%s/music", parsedURL.Scheme, parsedURL.Host)
-	html, err := m.httpClient.GetString(ctx, musicURL)
+	// Keep the query string: unlisted releases are only reachable with the
+	// access token of the link they were shared with
+	musicURL := (&url.URL{Scheme: parsedURL.Scheme, Host: parsedURL.Host, Path: "/music", RawQuery: parsedURL.RawQuery}).String()
+	page, err := m.httpClient.GetPage(ctx, musicURL)
+	if err != nil {
+		return nil, err
+	}
+	m.reportRedirects(page)
+
+	if m.mobileAPI != nil {
+		urls, err := m.mobileDiscography(ctx, page.HTML)
+		if err == nil {
+			return urls, nil
+		}
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Could not read the discography of %s from the mobile API, reading its music page: %v", page.URL, err), Level: LevelWarning})
+	}
+
+	relativeURLs, err := m.discography.GetAlbumURLs(page.HTML)
 	if err != nil {
 		return nil, err
 	}
 
-	relativeURLs, err := m.discography.GetAlbumURLs(html)
+	// Resolve against the final URL, which may differ from the input
+	// (custom domain, http→https, ...)
+	baseURL, err := url.Parse(keepQuery(page.URL, musicURL))
 	if err != nil {
 		return nil, err
 	}
 
 	var absoluteURLs []string
 	for _, relURL := range relativeURLs {
-		absoluteURLs = append(absoluteURLs, fmt.Sprintf("%s://%s%s", parsedURL.Scheme, parsedURL.Host, relURL))
+		absURL, err := resolveURL(baseURL, relURL)
+		if err != nil {
+			continue
+		}
+		absoluteURLs = append(absoluteURLs, absURL)
 	}
 
-	return absoluteURLs, nil
+	return m.sampleURLs(absoluteURLs), nil
+}
+
+// reportRedirects emits a verbose event when a page was reached through redirects.
+func (m *Manager) reportRedirects(page *http.Page) {
+	if len(page.Redirects) > 0 {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Redirected: %s → %s", page.Redirects[0], page.URL), Level: LevelVerbose})
+	}
 }
 
 func (m *Manager) calculateTotals(ctx context.Context) {
 	for _, album := range m.albums {
+		ap := m.albumProgress[album]
 		for _, track := range album.Tracks {
-			m.totalFiles++
+			ap.totalFiles++
 			size, err := m.httpClient.GetFileSize(ctx, track.Mp3URL)
 			if err == nil {
-				m.totalBytes += size
+				ap.totalBytes += size
 			}
 		}
 		if album.HasArtwork() {
-			m.totalFiles++
+			ap.totalFiles++
 			size, err := m.httpClient.GetFileSize(ctx, album.ArtworkURL)
 			if err == nil {
-				m.totalBytes += size
+				ap.totalBytes += size
 			}
 		}
+		m.totalFiles += ap.totalFiles
+		m.totalBytes += ap.totalBytes
 	}
 }
 
+// addDownloadedFile counts one finished file for the album and globally.
+func (m *Manager) addDownloadedFile(album *model.Album) {
+	atomic.AddInt32(&m.downloadedFiles, 1)
+	atomic.AddInt32(&m.albumProgress[album].downloadedFiles, 1)
+}
+
+// addSkippedBytes counts n bytes of an existing, skipped file for the album and globally.
+func (m *Manager) addSkippedBytes(album *model.Album, n int64) {
+	atomic.AddInt64(&m.skippedBytes, n)
+	atomic.AddInt64(&m.albumProgress[album].skippedBytes, n)
+}
+
+// addReceivedBytes counts n downloaded bytes for the album and globally.
+func (m *Manager) addReceivedBytes(album *model.Album, n int64) {
+	atomic.AddInt64(&m.receivedBytes, n)
+	atomic.AddInt64(&m.albumProgress[album].receivedBytes, n)
+}
+
+// downloadAlbum runs the Fetch and PostProcess stages of the Pipeline on
+// album, unless the byte budget is exhausted or its archive was completed
+// by a previous run, and sets its final state.
 func (m *Manager) downloadAlbum(ctx context.Context, album *model.Album) error {
-	// Create directory
-	if err := os.MkdirAll(album.Path, 0755); err != nil {
-		m.progress(ProgressEvent{Message: fmt.Sprintf("Error creating directory: %v", err), Level: LevelError})
+	ap := m.albumProgress[album]
+	if m.budgetExhausted() {
+		m.leaveForNextRun(album, len(album.Tracks))
+		return nil
+	}
+	ap.setState(AlbumDownloading)
+
+	// An archived album was completed by a previous run
+	if m.archiving(album) {
+		if _, err := os.Stat(archivePath(album)); err == nil {
+			for _, track := range album.Tracks {
+				m.addDownloadedFile(album)
+				ap.setTrackState(track, TrackSkipped)
+			}
+			m.finishAlbum(ap, AlbumCompleted)
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping archived album: %s", album.Title), Level: LevelInfo})
+			return nil
+		}
+	}
+
+	result, err := m.pipeline.Fetch.Fetch(ctx, album)
+	if err != nil {
+		m.finishAlbum(ap, AlbumFailed)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading %s: %v", album.Title, err), Level: LevelError})
 		return err
 	}
+	if result.Deferred > 0 {
+		m.leaveForNextRun(album, result.Deferred)
+	}
+
+	// The album keeps downloading until its post-processing is over
+	state := result.state(len(album.Tracks))
+	for _, p := range m.pipeline.PostProcess {
+		if err := p.PostProcess(ctx, album, state); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error post-processing %s: %v", album.Title, err), Level: LevelWarning})
+		}
+	}
+
+	switch {
+	case state == AlbumPending:
+		// Not started: the album is downloaded by the next run
+		ap.setState(AlbumPending)
+	case state == AlbumCompleted:
+		m.finishAlbum(ap, AlbumCompleted)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Successfully downloaded album: %s", album.Title), Level: LevelSuccess})
+	case state == AlbumFailed:
+		m.finishAlbum(ap, AlbumFailed)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Failed to download album: %s", album.Title), Level: LevelError})
+	case result.Downloaded+result.Deferred == len(album.Tracks):
+		m.finishAlbum(ap, AlbumPartial)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Stopped %s at the byte budget", album.Title), Level: LevelWarning})
+	default:
+		m.finishAlbum(ap, AlbumPartial)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Finished %s, some tracks failed", album.Title), Level: LevelWarning})
+	}
 
+	return nil
+}
+
+// fetchAlbumFiles is the default Fetch stage. It creates the folder of
+// album, downloads its artwork, then its tracks, tagged.
+func (m *Manager) fetchAlbumFiles(ctx context.Context, album *model.Album) (FetchResult, error) {
+	if err := m.prepareAlbumFolder(album); err != nil {
+		return FetchResult{}, fmt.Errorf("creating directory: %w", err)
+	}
+
+	ap := m.albumProgress[album]
+	settings := m.albumSettings(album)
 	var artwork []byte
+	var refreshArtwork bool
 
 	// Download artwork
-	if (m.settings.SaveCoverArtInTags || m.settings.SaveCoverArtInFolder) && album.HasArtwork() {
+	if (settings.SaveCoverArtInTags || settings.SaveCoverArtInFolder) && album.HasArtwork() {
+		var changed bool
 		var err error
-		artwork, err = m.downloadArtwork(ctx, album)
+		artwork, changed, err = m.downloadArtwork(ctx, album)
 		if err != nil {
 			m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading artwork for %s: %v", album.Title, err), Level: LevelWarning})
 		}
+		refreshArtwork = changed && settings.RefreshEmbeddedArtwork && settings.SaveCoverArtInTags
 	}
 
 	// Download tracks
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(m.settings.MaxConcurrentTracksDownload)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(settings.MaxConcurrentTracksDownload)
 
-	var successCount int32
+	var successCount, budgetLeft int32
+	m.metrics.queue(len(album.Tracks))
 	for _, track := range album.Tracks {
 		track := track // capture
 		g.Go(func() error {
-			if err := m.downloadTrack(ctx, track, album, artwork); err != nil {
+			m.metrics.start()
+			defer m.metrics.stop()
+
+			err := m.downloadTrack(gctx, track, album, artwork, refreshArtwork)
+			if errors.Is(err, errBudgetExhausted) {
+				atomic.AddInt32(&budgetLeft, 1)
+				return nil
+			}
+			if err != nil {
+				ap.setTrackState(track, TrackFailed)
+				m.metrics.trackDone("failed")
 				m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading %s: %v", track.Title, err), Level: LevelError})
 				return nil // Continue with other tracks
 			}
@@ -258,128 +971,506 @@ func (m *Manager) downloadAlbum(ctx context.Context, album *model.Album) error {
 		})
 	}
 
-	if err := g.Wait(); err != nil {
-		return err
-	}
+	err := g.Wait()
+	return FetchResult{Downloaded: int(successCount), Deferred: int(budgetLeft)}, err
+}
+
+// saveAlbumFiles is the default PostProcess stage after transcodeAlbum,
+// saving the liner notes, metadata and playlist of album, whatever its
+// state.
+func (m *Manager) saveAlbumFiles(ctx context.Context, album *model.Album, _ AlbumState) error {
+	m.saveTrackInfo(ctx, album)
+	m.saveMetadata(album)
 
-	// Create playlist
-	if m.settings.CreatePlaylist {
-		content := m.playlist.CreatePlaylist(album)
-		if err := os.WriteFile(album.PlaylistPath, []byte(content), 0644); err != nil {
+	if m.albumSettings(album).CreatePlaylist {
+		if err := m.savePlaylist(album); err != nil {
 			m.progress(ProgressEvent{Message: fmt.Sprintf("Error creating playlist: %v", err), Level: LevelWarning})
 		} else {
 			m.progress(ProgressEvent{Message: fmt.Sprintf("Created playlist for %s", album.Title), Level: LevelSuccess})
 		}
 	}
+	return nil
+}
 
-	if int(successCount) == len(album.Tracks) {
-		m.progress(ProgressEvent{Message: fmt.Sprintf("Successfully downloaded album: %s", album.Title), Level: LevelSuccess})
-	} else {
-		m.progress(ProgressEvent{Message: fmt.Sprintf("Finished %s, some tracks failed", album.Title), Level: LevelWarning})
+// integrateAlbum is the last default PostProcess stage, archiving and
+// linking album, and handing it to ListenBrainz and beets, once it is
+// completed.
+func (m *Manager) integrateAlbum(ctx context.Context, album *model.Album, state AlbumState) error {
+	if state != AlbumCompleted {
+		return nil
 	}
-
+	m.archiveAlbum(album)
+	m.linkAlbum(album)
+	m.checkListenBrainz(ctx, album)
+	m.runBeetsImport(ctx, album)
 	return nil
 }
 
-func (m *Manager) downloadArtwork(ctx context.Context, album *model.Album) ([]byte, error) {
-	var artwork []byte
-	var err error
+// finishAlbum sets the final state of an album and counts it in the metrics.
+func (m *Manager) finishAlbum(ap *albumProgress, state AlbumState) {
+	ap.setState(state)
+	m.metrics.albumDone(state)
+}
 
-	for tries := 0; tries < m.settings.DownloadMaxRetries; tries++ {
-		artwork, err = m.httpClient.DownloadBytes(ctx, album.ArtworkURL)
-		if err == nil {
-			break
+// downloadArtwork fetches the album artwork, saves it to the album folder
+// if enabled, and returns it prepared for embedding in tags.
+//
+// The artwork is fetched through fetchArtwork, so it is downloaded once per
+// art ID and only if Bandcamp reports it changed since it was cached.
+// changed is true when a cached copy existed and the downloaded artwork
+// differs from it.
+func (m *Manager) downloadArtwork(ctx context.Context, album *model.Album) (artwork []byte, changed bool, err error) {
+	fetch, err := m.fetchArtwork(ctx, album)
+	if err != nil {
+		return nil, false, err
+	}
+
+	m.addDownloadedFile(album)
+	settings := m.albumSettings(album)
+
+	artwork = fetch.data
+	changed = fetch.changed
+	if fetch.notModified {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Artwork unchanged for %s", album.Title), Level: LevelVerbose})
+	} else if changed {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Artwork updated for %s", album.Title), Level: LevelInfo})
+	}
+
+	// Save to folder if requested, unless the saved copy is known to be current
+	_, statErr := os.Stat(album.ArtworkPath)
+	if settings.SaveCoverArtInFolder && (!fetch.notModified || statErr != nil) {
+		artworkToSave, err := fetch.variant(variantKey("folder", settings.CoverArtInFolderResize, settings.CoverArtInFolderMaxSize, settings.ConvertCoverArtToJPG), func() ([]byte, error) {
+			return m.processArtwork(ctx, fetch.data, settings.CoverArtInFolderResize, settings.CoverArtInFolderMaxSize, settings.ConvertCoverArtToJPG)
+		})
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error processing artwork for %s: %v", album.Title, err), Level: LevelWarning})
+		} else if err := os.WriteFile(album.ArtworkPath, artworkToSave, 0644); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error saving artwork: %v", err), Level: LevelWarning})
 		}
-		m.waitForRetry(ctx, tries)
 	}
 
-	if err != nil {
-		return nil, err
+	// Prepare for tags
+	if settings.SaveCoverArtInTags {
+		artwork, err = fetch.variant(variantKey("tags", settings.CoverArtInTagsResize, settings.CoverArtInTagsMaxSize, settings.ConvertCoverArtToJPG), func() ([]byte, error) {
+			return m.processArtwork(ctx, fetch.data, settings.CoverArtInTagsResize, settings.CoverArtInTagsMaxSize, settings.ConvertCoverArtToJPG)
+		})
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error processing artwork for %s, not embedding it: %v", album.Title, err), Level: LevelWarning})
+			artwork = nil
+		}
 	}
 
-	atomic.AddInt32(&m.downloadedFiles, 1)
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Downloaded artwork for %s", album.Title), Level: LevelVerbose})
+	return artwork, changed, nil
+}
+
+// prepareAlbumFolder creates the folder of album and checks that it can be
+// written to. If it cannot and FallbackDownloadsPath is set, the album is
+// relocated from the library root to the fallback folder instead.
+func (m *Manager) prepareAlbumFolder(album *model.Album) error {
+	err := ioutils.EnsureWritableDir(album.Path)
+	settings := m.albumSettings(album)
+	if err == nil || settings.FallbackDownloadsPath == "" {
+		return err
+	}
+
+	root, fallback := settings.LibraryRoot(), filepath.Clean(settings.FallbackDownloadsPath)
+	if settings.LongPaths {
+		fallback = ioutils.LongPath(fallback)
+	}
+	path := album.Path
+	album.Relocate(root, fallback)
+	if album.Path == path {
+		return err
+	}
+	if ferr := ioutils.EnsureWritableDir(album.Path); ferr != nil {
+		return fmt.Errorf("%w (fallback: %v)", err, ferr)
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Cannot write to %s (%v), downloading %s to %s", path, err, album.Title, album.Path), Level: LevelWarning})
+	return nil
+}
 
-	// Save to folder if requested
-	if m.settings.SaveCoverArtInFolder {
-		artworkToSave := artwork
+// variantKey identifies an artwork variant for purpose processed with the
+// given options, so albums with overridden artwork settings do not share
+// it with the others.
+func variantKey(purpose string, resize bool, maxSize int, toJPG bool) string {
+	return fmt.Sprintf("%s:%t:%d:%t", purpose, resize, maxSize, toJPG)
+}
 
-		if m.settings.CoverArtInFolderResize {
-			artworkToSave, _ = m.imageService.ResizeImage(ctx, artworkToSave, m.settings.CoverArtInFolderMaxSize, m.settings.CoverArtInFolderMaxSize)
+// processArtwork resizes the artwork to fit maxSize if resize is set, and
+// converts it to JPEG if toJPG is set. Resized images are always JPEG, so
+// they are not decoded a second time for the conversion.
+func (m *Manager) processArtwork(ctx context.Context, data []byte, resize bool, maxSize int, toJPG bool) ([]byte, error) {
+	switch {
+	case resize:
+		return m.imageService.ResizeImage(ctx, data, maxSize, maxSize)
+	case toJPG:
+		return m.imageService.ConvertToJPEG(ctx, data)
+	default:
+		return data, nil
+	}
+}
+
+// downloadTrack downloads and tags one track. Existing files of the track
+// (see existingTrack) are skipped; if refreshArtwork is set, their embedded
+// artwork is replaced with artwork instead.
+func (m *Manager) downloadTrack(ctx context.Context, track *model.Track, album *model.Album, artwork []byte, refreshArtwork bool) error {
+	ap := m.albumProgress[album]
+	if ok, size := m.existingTrack(ctx, track, album); ok {
+		ap.setTrackState(track, TrackSkipped)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping existing: %s", filepath.Base(track.Path)), Level: LevelVerbose})
+		m.addDownloadedFile(album)
+		m.addSkippedBytes(album, size)
+		m.metrics.trackDone("skipped")
+		if refreshArtwork && artwork != nil {
+			if err := m.albumTagger(album).SaveArtwork(track.Path, artwork, m.extraPicture(ctx, album)...); err != nil {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Error refreshing artwork of %s: %v", track.Title, err), Level: LevelWarning})
+			} else {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Refreshed artwork: %s", filepath.Base(track.Path)), Level: LevelVerbose})
+			}
 		}
+		return nil
+	}
 
-		if m.settings.ConvertCoverArtToJPG {
-			artworkToSave, _ = m.imageService.ConvertToJPEG(ctx, artworkToSave)
+	// Failed requests are retried by the HTTP client; an expired stream URL
+	// is refreshed and the download attempted once more.
+	if m.budgetExhausted() {
+		return errBudgetExhausted
+	}
+	ap.setTrackState(track, TrackDownloading)
+	ctx = http.WithLabel(ctx, track.Title)
+	err := m.fetchTrack(ctx, track, album)
+	if isStreamExpired(err) {
+		if rerr := m.refreshStreamURLs(ctx, album); rerr != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error refreshing stream URLs of %s: %v", album.Title, rerr), Level: LevelWarning})
+		} else {
+			err = m.fetchTrack(ctx, track, album)
 		}
+	}
 
-		if err := os.WriteFile(album.ArtworkPath, artworkToSave, 0644); err != nil {
-			m.progress(ProgressEvent{Message: fmt.Sprintf("Error saving artwork: %v", err), Level: LevelWarning})
+	if err != nil {
+		m.discardPartial(track)
+		return err
+	}
+	if err := m.checkDuration(ctx, track, album); err != nil {
+		return err
+	}
+
+	m.addDownloadedFile(album)
+	ap.setTrackState(track, TrackDownloaded)
+	m.metrics.trackDone("downloaded")
+
+	// Tag the file, unless the stream is in a format without tag support
+	settings := m.albumSettings(album)
+	if !audio.CanTag(track.Extension()) {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Not tagging %s: %s files cannot be tagged", filepath.Base(track.Path), track.Extension()), Level: LevelVerbose})
+	} else if settings.ModifyTags || settings.StripID3v1 || (settings.SaveCoverArtInTags && artwork != nil) {
+		if err := m.albumTagger(album).SaveTags(track, album, artwork, m.extraPicture(ctx, album)...); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error tagging %s: %v", track.Title, err), Level: LevelWarning})
 		}
 	}
 
-	// Prepare for tags
-	if m.settings.SaveCoverArtInTags {
-		if m.settings.CoverArtInTagsResize {
-			artwork, _ = m.imageService.ResizeImage(ctx, artwork, m.settings.CoverArtInTagsMaxSize, m.settings.CoverArtInTagsMaxSize)
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Downloaded: %s", filepath.Base(track.Path)), Level: LevelVerbose})
+	return nil
+}
+
+// fetchTrack makes one download attempt of track. If the stream host
+// refuses the request, the same stream is tried on the alternate hosts
+// returned by bandcamp.StreamMirrors before giving up. A refusal is only
+// retried by the HTTP client on the last host tried, so the others are
+// tried at once rather than after the whole retry policy.
+func (m *Manager) fetchTrack(ctx context.Context, track *model.Track, album *model.Album) error {
+	streamURL := m.streamURL(track)
+	streamURLs := append([]string{streamURL}, bandcamp.StreamMirrors(streamURL)...)
+
+	var err error
+	for i, u := range streamURLs {
+		if i > 0 {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("%v for %s, trying %s", err, track.Title, hostOf(u)), Level: LevelVerbose})
+		}
+		attemptCtx := ctx
+		if i < len(streamURLs)-1 {
+			attemptCtx = http.WithoutRetry(ctx, isHostRefusal)
 		}
-		if m.settings.ConvertCoverArtToJPG {
-			artwork, _ = m.imageService.ConvertToJPEG(ctx, artwork)
+		err = m.fetchStream(attemptCtx, u, track, album)
+		if !isHostRefusal(err) {
+			return err
 		}
 	}
 
-	m.progress(ProgressEvent{Message: fmt.Sprintf("Downloaded artwork for %s", album.Title), Level: LevelVerbose})
-	return artwork, nil
-}
-
-func (m *Manager) downloadTrack(ctx context.Context, track *model.Track, album *model.Album, artwork []byte) error {
-	// Check if file already exists with acceptable size
-	if info, err := os.Stat(track.Path); err == nil {
-		expectedSize, _ := m.httpClient.GetFileSize(ctx, track.Mp3URL)
-		diff := m.settings.AllowedFileSizeDifference
-		if expectedSize > 0 {
-			sizeDiff := float64(info.Size()-expectedSize) / float64(expectedSize)
-			if math.Abs(sizeDiff) <= diff {
-				m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping existing: %s", filepath.Base(track.Path)), Level: LevelVerbose})
-				atomic.AddInt32(&m.downloadedFiles, 1)
-				return nil
-			}
+	return err
+}
+
+// fetchStream downloads streamURL to the track's partial file, counting
+// the bytes as they arrive and emitting throttled LevelProgress events,
+// then moves it to the track's path. The bytes of a failed attempt are
+// discounted, since the file is rewritten on retry; those resumed from a
+// kept partial file count as skipped.
+func (m *Manager) fetchStream(ctx context.Context, streamURL string, track *model.Track, album *model.Album) error {
+	part := partialPath(track)
+	download := m.httpClient.DownloadFile
+	offset := int64(0)
+	if m.keepPartialFiles() {
+		download = m.httpClient.ResumeFile
+		if info, err := os.Stat(part); err == nil {
+			offset = info.Size()
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Resuming %s from %d bytes", filepath.Base(track.Path), offset), Level: LevelVerbose})
 		}
 	}
+	m.addSkippedBytes(album, offset)
 
-	var err error
-	for tries := 0; tries < m.settings.DownloadMaxRetries; tries++ {
-		err = m.httpClient.DownloadFile(ctx, track.Mp3URL, track.Path, func(written, total int64) {
-			// Progress tracking could be added here
-		})
-		if err == nil {
-			break
+	counted := offset  // bytes of this attempt added to receivedBytes, past offset
+	reported := offset // Written of the last LevelProgress event
+	var contentType string
+	err := download(http.WithContentType(ctx, &contentType), streamURL, part, func(written, total int64) {
+		m.addReceivedBytes(album, written-counted)
+		m.metrics.received(written - counted)
+		counted = written
+
+		// Throttle events to one per 1% (or per MiB when the size is unknown)
+		step := int64(1 << 20)
+		if total > 0 {
+			step = max(total/100, 1)
 		}
-		m.progress(ProgressEvent{Message: fmt.Sprintf("Retry %d/%d for %s", tries+1, m.settings.DownloadMaxRetries, track.Title), Level: LevelWarning})
-		m.waitForRetry(ctx, tries)
+		if written-reported >= step || written == total {
+			reported = written
+			m.progress(ProgressEvent{Level: LevelProgress, Album: album, Track: track, Written: written, Total: total})
+		}
+	})
+	if err == nil {
+		err = m.moveStream(part, track, album, http.Extension(contentType, streamURL))
+	}
+	if err != nil {
+		m.addReceivedBytes(album, offset-counted)
+		m.addSkippedBytes(album, -offset)
 	}
+	return err
+}
 
+// savePlaylist writes the playlist of album. A playlist whose encoding
+// lacks some of its characters is written in UTF-8 with a byte order mark
+// instead, which players reading non-UTF-8 playlists still recognize.
+func (m *Manager) savePlaylist(album *model.Album) error {
+	return m.writePlaylist(m.albumPlaylist(album), album)
+}
+
+// writePlaylist writes the playlist of album created by creator to
+// album.PlaylistPath, falling back to UTF-8 like savePlaylist.
+func (m *Manager) writePlaylist(creator *audio.PlaylistCreator, album *model.Album) error {
+	data, err := creator.CreatePlaylistFile(album)
+	if errors.Is(err, audio.ErrUnencodable) {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Playlist of %s: %v, writing UTF-8 instead", album.Title, err), Level: LevelWarning})
+		options := creator.Options()
+		options.Encoding = audio.EncodingUTF8BOM
+		data, err = options.Encode(creator.CreatePlaylist(album))
+	}
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(album.PlaylistPath, data, 0644)
+}
 
-	atomic.AddInt32(&m.downloadedFiles, 1)
+// moveStream moves the downloaded partial file of track to its path. If
+// ext, the extension of the stream's format, is known and not the one of
+// the track, the path is first recomputed with it, for file name formats
+// using {ext}.
+func (m *Manager) moveStream(part string, track *model.Track, album *model.Album, ext string) error {
+	if ext == "" || ext == track.Extension() {
+		return os.Rename(part, track.Path)
+	}
 
-	// Tag the file
-	if m.settings.ModifyTags || (m.settings.SaveCoverArtInTags && artwork != nil) {
-		if err := m.tagger.SaveTags(track, album, artwork); err != nil {
-			m.progress(ProgressEvent{Message: fmt.Sprintf("Error tagging %s: %v", track.Title, err), Level: LevelWarning})
+	moved := *track
+	moved.Ext = ext
+	moved.ComputePath(m.albumSettings(album).ToTrackConfig())
+	if err := os.Rename(part, moved.Path); err != nil {
+		return err
+	}
+	if moved.Path != track.Path {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("%s is a %s stream, saved as %s", track.Title, ext, filepath.Base(moved.Path)), Level: LevelVerbose})
+	}
+	track.Ext, track.Path = moved.Ext, moved.Path
+	return nil
+}
+
+// partialPath returns the path the track is downloaded to before it is
+// complete.
+func partialPath(track *model.Track) string {
+	return track.Path + ".part"
+}
+
+// keepPartialFiles reports whether partial files are kept and resumed,
+// see config.Download.KeepPartialFiles.
+func (m *Manager) keepPartialFiles() bool {
+	return m.settings.KeepPartialFiles && m.settings.ParallelSegments <= 1
+}
+
+// discardPartial deletes the partial file of a track whose download was
+// canceled or failed, unless it is kept for the next run to resume.
+func (m *Manager) discardPartial(track *model.Track) {
+	part := partialPath(track)
+	if m.keepPartialFiles() {
+		if _, err := os.Stat(part); err == nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Kept partial file %s", part), Level: LevelVerbose})
 		}
+		return
 	}
+	if err := os.Remove(part); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error deleting partial file %s: %v", part, err), Level: LevelWarning})
+	}
+}
 
-	m.progress(ProgressEvent{Message: fmt.Sprintf("Downloaded: %s", filepath.Base(track.Path)), Level: LevelVerbose})
+// streamURL returns the track's current stream URL.
+func (m *Manager) streamURL(track *model.Track) string {
+	m.streamMu.Lock()
+	defer m.streamMu.Unlock()
+	return track.Mp3URL
+}
+
+// refreshStreamURLs re-fetches the album page and replaces the stream URLs
+// of the album's tracks, whose time-limited tokens have expired.
+//
+// Tracks are matched by number, then by title. If the URLs were refreshed
+// less than streamRefreshInterval ago (by another track of the album), the
+// page is not fetched again.
+func (m *Manager) refreshStreamURLs(ctx context.Context, album *model.Album) error {
+	m.streamMu.Lock()
+	defer m.streamMu.Unlock()
+
+	if time.Since(m.streamRefreshed[album]) < streamRefreshInterval {
+		return nil
+	}
+
+	page, err := m.httpClient.GetPage(ctx, album.URL)
+	if err != nil {
+		return err
+	}
+	freshPage, err := m.readAlbumPage(ctx, page.URL, page.HTML)
+	if err != nil {
+		return err
+	}
+	fresh := m.parser.ToAlbum(freshPage)
+
+	byNumber := make(map[int]*model.Track)
+	byTitle := make(map[string]*model.Track)
+	for _, t := range fresh.Tracks {
+		byNumber[t.Number] = t
+		byTitle[strings.ToLower(t.Title)] = t
+	}
+
+	var updated int
+	for _, track := range album.Tracks {
+		match := byNumber[track.Number]
+		if match == nil || !strings.EqualFold(match.Title, track.Title) {
+			match = byTitle[strings.ToLower(track.Title)]
+		}
+		if match != nil && match.Mp3URL != "" {
+			track.Mp3URL = match.Mp3URL
+			updated++
+		}
+	}
+	if updated == 0 {
+		return fmt.Errorf("no stream URL found on %s", album.URL)
+	}
+
+	m.streamRefreshed[album] = time.Now()
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Stream URLs of %s expired, refreshed %d", album.Title, updated), Level: LevelInfo})
 	return nil
 }
 
-func (m *Manager) waitForRetry(ctx context.Context, tries int) {
-	cooldown := m.settings.DownloadRetryCooldown * math.Pow(m.settings.DownloadRetryExponent, float64(tries))
-	select {
-	case <-ctx.Done():
-	case <-time.After(time.Duration(cooldown * float64(time.Second))):
+// isStreamExpired reports whether err means the stream URL's token has
+// expired, which Bandcamp signals with 410 Gone.
+func isStreamExpired(err error) bool {
+	var statusErr *http.StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == 410
+}
+
+// isHostRefusal reports whether err is an HTTP status that another stream
+// host may not return: 403 Forbidden (regional blocks) or a 5xx error.
+func isHostRefusal(err error) bool {
+	var statusErr *http.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.Code == 403 || statusErr.Code >= 500
+}
+
+// hostOf returns the host of rawURL, or rawURL itself if it cannot be parsed.
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// sizeMatches reports whether a local file of the given size matches the
+// remote file at url, within the AllowedFileSizeDifference tolerance.
+// It also returns the expected size, which is zero if it is unknown;
+// an unknown size never matches.
+func (m *Manager) sizeMatches(ctx context.Context, size int64, url string) (bool, int64) {
+	expectedSize, _ := m.httpClient.GetFileSize(ctx, url)
+	if expectedSize <= 0 {
+		return false, 0
+	}
+	sizeDiff := float64(size-expectedSize) / float64(expectedSize)
+	return math.Abs(sizeDiff) <= m.settings.AllowedFileSizeDifference, expectedSize
+}
+
+// onRetry reports a request retried by the HTTP client.
+func (m *Manager) onRetry(event http.RetryEvent) {
+	name := event.Label
+	if name == "" {
+		name = event.URL
+	}
+	m.progress(ProgressEvent{
+		Message: fmt.Sprintf("Retry %d/%d for %s in %s: %v", event.Attempt, event.MaxAttempts-1, name, event.Delay.Round(10*time.Millisecond), event.Err),
+		Level:   LevelWarning,
+	})
+	m.metrics.retry()
+}
+
+// readAlbumPage reads the album or track page at pageURL with the page
+// parsers of the settings, reporting the use of a fallback parser: the
+// ones before it failing on a valid page likely means Bandcamp changed its
+// markup.
+func (m *Manager) readAlbumPage(ctx context.Context, pageURL, html string) (*bandcamp.AlbumPage, error) {
+	page, err := m.pages.Read(ctx, html)
+	if err == nil && page.FellBack {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Read %s with the %s page parser, as the ones before it failed", pageURL, page.Strategy), Level: LevelWarning})
+	}
+	return page, err
+}
+
+// onParserResult reports the failures of the page parsers, and counts
+// their results in the metrics.
+func (m *Manager) onParserResult(strategy string, err error) {
+	switch {
+	case err == nil:
+		m.metrics.parserResult(strategy, "read")
+	case errors.Is(err, bandcamp.ErrNoTracks):
+		m.metrics.parserResult(strategy, "no_tracks")
+	default:
+		m.metrics.parserResult(strategy, "failed")
+		m.progress(ProgressEvent{Message: fmt.Sprintf("The %s page parser failed: %v", strategy, err), Level: LevelVerbose})
+	}
+}
+
+// ParserStats returns the results of the page parsers since the Manager
+// was created, in the order they are tried.
+func (m *Manager) ParserStats() []bandcamp.StrategyStats {
+	return m.pages.Stats()
+}
+
+// onBreaker reports a host whose requests are paused, or resumed, by the
+// HTTP client's circuit breaker.
+func (m *Manager) onBreaker(event http.BreakerEvent) {
+	if event.Open {
+		m.progress(ProgressEvent{
+			Message: fmt.Sprintf("%s failed %d times in a row, pausing its requests for %s", event.Host, event.Failures, time.Until(event.Until).Round(time.Second)),
+			Level:   LevelWarning,
+		})
+		return
 	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("%s is answering again, resuming its requests", event.Host), Level: LevelInfo})
 }
 
 func (m *Manager) progress(event ProgressEvent) {
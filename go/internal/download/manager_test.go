@@ -0,0 +1,1013 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2"
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"https://artist.bandcamp.com/album/foo", "http://artist.bandcamp.com/album/foo"},
+		{"https://artist.bandcamp.com/album/foo", "https://Artist.Bandcamp.com/album/Foo/"},
+		{"https://artist.bandcamp.com/album/foo", "https://www.artist.bandcamp.com/album/foo"},
+		{"https://artist.bandcamp.com/album/foo", "https://artist.bandcamp.com/album/foo?from=label#about"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			if got, want := normalizeURL(tt.b), normalizeURL(tt.a); got != want {
+				t.Errorf("normalizeURL(%q) = %q, want %q", tt.b, got, want)
+			}
+		})
+	}
+
+	if normalizeURL("https://artist.bandcamp.com/album/foo") == normalizeURL("https://artist.bandcamp.com/album/bar") {
+		t.Error("different albums should not normalize to the same key")
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		base, ref, want string
+	}{
+		{"https://artist.bandcamp.com/music", "/album/foo", "https://artist.bandcamp.com/album/foo"},
+		{"https://artist.bandcamp.com/music?secret=abc", "/album/foo", "https://artist.bandcamp.com/album/foo?secret=abc"},
+		{"https://artist.bandcamp.com/music?secret=abc", "/album/foo?from=x", "https://artist.bandcamp.com/album/foo?from=x"},
+		{"https://artist.bandcamp.com/music?a=1", "/album/foo?b=2&amp;c=3", "https://artist.bandcamp.com/album/foo?b=2&c=3"},
+		{"https://artist.bandcamp.com/album/foo?secret=abc", "/track/bar", "https://artist.bandcamp.com/track/bar?secret=abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base+" "+tt.ref, func(t *testing.T) {
+			base, err := url.Parse(tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveURL(base, tt.ref)
+			if err != nil {
+				t.Fatalf("resolveURL failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveURL(%q, %q) = %q, want %q", tt.base, tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepQuery(t *testing.T) {
+	tests := []struct {
+		final, requested, want string
+	}{
+		{"https://artist.bandcamp.com/album/foo", "http://artist.bandcamp.com/album/foo?secret=abc", "https://artist.bandcamp.com/album/foo?secret=abc"},
+		{"https://artist.bandcamp.com/album/foo?secret=new", "http://artist.bandcamp.com/album/foo?secret=abc", "https://artist.bandcamp.com/album/foo?secret=new"},
+		{"https://artist.bandcamp.com/album/foo", "http://artist.bandcamp.com/album/foo", "https://artist.bandcamp.com/album/foo"},
+	}
+
+	for _, tt := range tests {
+		if got := keepQuery(tt.final, tt.requested); got != tt.want {
+			t.Errorf("keepQuery(%q, %q) = %q, want %q", tt.final, tt.requested, got, tt.want)
+		}
+	}
+}
+
+// testRelease is a release served by releaseServer, by the artist
+// "Artist".
+type testRelease struct {
+	path   string // e.g. "/album/songs"
+	title  string
+	id     int64
+	tracks []testTrack
+
+	// requests, if not nil, counts the requests of the release's page.
+	requests *atomic.Int32
+}
+
+// testTrack is a track of a testRelease.
+type testTrack struct {
+	title    string
+	duration float64
+	stream   string // path of the stream on the server, "" for none
+}
+
+// releaseServer serves the pages of releases, with their tracks streamed
+// from the server itself; the requests of other paths, e.g. the streams,
+// are handled by files, or answered with 404 Not Found if it is nil.
+func releaseServer(t *testing.T, files nethttp.Handler, releases ...testRelease) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		for _, release := range releases {
+			if r.URL.Path == release.path {
+				if release.requests != nil {
+					release.requests.Add(1)
+				}
+				w.Write([]byte(releasePage(t, server.URL, release)))
+				return
+			}
+		}
+		if files == nil {
+			nethttp.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// releasePage returns a release page holding the data-tralbum attribute
+// of release, whose streams are on baseURL.
+func releasePage(t *testing.T, baseURL string, release testRelease) string {
+	type trackInfo struct {
+		Number   int               `json:"track_num"`
+		Title    string            `json:"title"`
+		Duration float64           `json:"duration"`
+		File     map[string]string `json:"file"`
+	}
+	tralbum := struct {
+		ID      int64  `json:"id"`
+		Artist  string `json:"artist"`
+		Current struct {
+			Title string `json:"title"`
+		} `json:"current"`
+		TrackInfo []trackInfo `json:"trackinfo"`
+	}{ID: release.id, Artist: "Artist", TrackInfo: []trackInfo{}}
+	tralbum.Current.Title = release.title
+	for i, track := range release.tracks {
+		info := trackInfo{Number: i + 1, Title: track.title, Duration: track.duration}
+		if track.stream != "" {
+			info.File = map[string]string{"mp3-128": baseURL + track.stream}
+		}
+		tralbum.TrackInfo = append(tralbum.TrackInfo, info)
+	}
+
+	data, err := json.Marshal(tralbum)
+	if err != nil {
+		t.Error(err)
+	}
+	return `<script data-tralbum="` + html.EscapeString(string(data)) + `"></script>`
+}
+
+// audioFile returns a handler answering every request with content.
+func audioFile(content string) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte(content))
+	})
+}
+
+func TestFetchAlbums_SkipsTrackless(t *testing.T) {
+	music := nethttp.NewServeMux()
+	music.HandleFunc("/music", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte(`<a href="/album/songs">Songs</a><a href="/album/bundle">Bundle</a>`))
+	})
+	server := releaseServer(t, music,
+		testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}},
+		testRelease{path: "/album/bundle", title: "Bundle", id: 2},
+	)
+
+	settings := config.DefaultSettings()
+	settings.DownloadArtistDiscography = true
+	var verbose []string
+	m := NewManager(settings, func(e ProgressEvent) {
+		if e.Level == LevelVerbose {
+			verbose = append(verbose, e.Message)
+		}
+	})
+	m.fetchAlbums(context.Background(), server.URL)
+
+	if len(m.albums) != 1 || m.albums[0].Title != "Songs" {
+		t.Fatalf("albums = %v, want only Songs", m.GetAlbumNames())
+	}
+	if len(m.fetchFailures) != 0 {
+		t.Errorf("fetchFailures = %q, want none", m.fetchFailures)
+	}
+	want := "Skipping " + server.URL + "/album/bundle: no tracks (e.g. a merch bundle)"
+	if !slices.Contains(verbose, want) {
+		t.Errorf("verbose events = %q, want %q", verbose, want)
+	}
+}
+
+func TestFetchAlbums_KeepsTokenOfDuplicate(t *testing.T) {
+	var requests atomic.Int32
+	server := releaseServer(t, nil, testRelease{path: "/album/hidden", title: "Hidden", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}, requests: &requests})
+
+	// The unlisted release is given without its token first
+	m := NewManager(config.DefaultSettings(), nil)
+	m.fetchAlbums(context.Background(), server.URL+"/album/hidden\n"+server.URL+"/album/hidden?secret=abc")
+
+	if len(m.albums) != 1 {
+		t.Fatalf("albums = %v, want only Hidden", m.GetAlbumNames())
+	}
+	if got, want := m.albums[0].URL, server.URL+"/album/hidden?secret=abc"; got != want {
+		t.Errorf("album URL = %q, want %q", got, want)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("release page read %d times, want once", n)
+	}
+}
+
+func TestManager_Reuse(t *testing.T) {
+	server := releaseServer(t, audioFile("audio"),
+		testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}},
+	)
+
+	m := NewManager(config.DefaultSettings(), nil)
+	var totals [2]int64
+	for run := range totals {
+		if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+			t.Fatalf("run %d: Initialize failed: %v", run, err)
+		}
+		if names := m.GetAlbumNames(); len(names) != 1 {
+			t.Fatalf("run %d: albums = %q, want 1", run, names)
+		}
+		_, total, _, files := m.GetProgress()
+		totals[run] = total
+		if files != 1 {
+			t.Errorf("run %d: %d files, want 1", run, files)
+		}
+	}
+	if totals[0] != totals[1] {
+		t.Errorf("total bytes = %d then %d, want the same", totals[0], totals[1])
+	}
+
+	m.running.Store(true)
+	if err := m.Initialize(context.Background(), server.URL+"/album/songs"); !errors.Is(err, ErrRunning) {
+		t.Errorf("Initialize during downloads = %v, want ErrRunning", err)
+	}
+}
+
+func TestManager_Running(t *testing.T) {
+	releases := releaseServer(t, audioFile("audio"),
+		testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}},
+	)
+	// The release page is held until the other calls are made
+	requested, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		once.Do(func() { close(requested) })
+		<-release
+		releases.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	m := NewManager(settings, nil)
+	ctx := context.Background()
+	initialized := make(chan error)
+	go func() {
+		initialized <- m.Initialize(ctx, server.URL+"/album/songs")
+	}()
+	<-requested
+
+	if err := m.StartDownloads(ctx); !errors.Is(err, ErrRunning) {
+		t.Errorf("StartDownloads during Initialize = %v, want ErrRunning", err)
+	}
+	if err := m.Initialize(ctx, server.URL+"/album/songs"); !errors.Is(err, ErrRunning) {
+		t.Errorf("Initialize during Initialize = %v, want ErrRunning", err)
+	}
+	if _, err := m.Retag(ctx, server.URL+"/album/songs", ""); !errors.Is(err, ErrRunning) {
+		t.Errorf("Retag during Initialize = %v, want ErrRunning", err)
+	}
+	if _, err := m.Verify(ctx, server.URL+"/album/songs", ""); !errors.Is(err, ErrRunning) {
+		t.Errorf("Verify during Initialize = %v, want ErrRunning", err)
+	}
+	if err := m.Reset(); !errors.Is(err, ErrRunning) {
+		t.Errorf("Reset during Initialize = %v, want ErrRunning", err)
+	}
+
+	close(release)
+	if err := <-initialized; err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := m.StartDownloads(ctx); err != nil {
+		t.Errorf("StartDownloads after Initialize failed: %v", err)
+	}
+	if err := m.Reset(); err != nil {
+		t.Errorf("Reset after the run failed: %v", err)
+	}
+}
+
+func TestManager_GettersDuringInitialize(t *testing.T) {
+	server := releaseServer(t, audioFile("audio"),
+		testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}},
+	)
+
+	m := NewManager(config.DefaultSettings(), nil)
+	done := make(chan struct{})
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			m.GetProgress()
+			m.GetByteStats()
+			m.GetRunStats()
+			m.GetAlbumProgress(1)
+			m.GetProgressSnapshot()
+			m.GetAlbums()
+			m.GetAlbumNames()
+			m.GetAlbumTracks(0)
+			m.GetFailedURLs()
+			m.ExceedsSizeThreshold()
+		}
+	}()
+	for run := range 3 {
+		if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+			t.Fatalf("run %d: Initialize failed: %v", run, err)
+		}
+	}
+	close(done)
+	<-polled
+
+	if names := m.GetAlbumNames(); len(names) != 1 {
+		t.Errorf("albums = %q, want 1", names)
+	}
+}
+
+func TestManager_GetAlbums(t *testing.T) {
+	server := releaseServer(t, audioFile("audio"),
+		testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}, {title: "Bonus"}}},
+	)
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	settings.SaveCoverArtInFolder = false
+	settings.SaveCoverArtInTags = false
+	m := NewManager(settings, nil)
+	if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	albums := m.GetAlbums()
+	if len(albums) != 1 {
+		t.Fatalf("%d albums, want 1", len(albums))
+	}
+	got := albums[0]
+	if got.Album.Artist != "Artist" || got.Album.Title != "Songs" || len(got.Album.Tracks) != 1 {
+		t.Fatalf("album = %s - %s with %d tracks, want Artist - Songs with 1", got.Album.Artist, got.Album.Title, len(got.Album.Tracks))
+	}
+	if got.Album.Tracks[0].Album != got.Album {
+		t.Error("track of the snapshot does not point to the snapshot's album")
+	}
+	if got.Progress.State != AlbumPending || got.TrackStates[0] != TrackPending {
+		t.Errorf("states before downloading = %v, %v, want pending", got.Progress.State, got.TrackStates[0])
+	}
+
+	got.Album.Title = "Changed"
+	got.Album.Tracks[0].Title = "Changed"
+	if m.albums[0].Title != "Songs" || m.albums[0].Tracks[0].Title != "Song" {
+		t.Error("changing the snapshot changed the album")
+	}
+
+	if err := m.StartDownloads(context.Background()); err != nil {
+		t.Fatalf("StartDownloads failed: %v", err)
+	}
+	got = m.GetAlbums()[0]
+	if got.Progress.State != AlbumCompleted || got.TrackStates[0] != TrackDownloaded {
+		t.Errorf("states after downloading = %v, %v, want completed, downloaded", got.Progress.State, got.TrackStates[0])
+	}
+
+	stats := m.GetRunStats()
+	if stats.Downloaded != 1 || stats.Skipped != 0 || stats.Failed != 0 || stats.Unavailable != 1 || stats.Pending != 0 {
+		t.Errorf("stats = %+v, want 1 downloaded and 1 unavailable", stats)
+	}
+	if stats.Received != 5 || stats.Elapsed <= 0 {
+		t.Errorf("received %d bytes in %s, want 5 in more than 0", stats.Received, stats.Elapsed)
+	}
+}
+
+func TestDownloadTrack_Canceled(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	server := releaseServer(t, nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Method == nethttp.MethodHead || r.Header.Get("Range") != "" {
+			nethttp.ServeContent(w, r, "1.mp3", time.Time{}, bytes.NewReader(content))
+			return
+		}
+		// Send half the file, then stall until the download is canceled
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		w.Write(content[:10])
+		w.(nethttp.Flusher).Flush()
+		<-r.Context().Done()
+	}), testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}})
+
+	for _, keep := range []bool{false, true} {
+		t.Run(fmt.Sprintf("keep partial files %t", keep), func(t *testing.T) {
+			settings := config.DefaultSettings()
+			settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+			settings.SaveCoverArtInTags = false
+			settings.ModifyTags = false
+			settings.KeepPartialFiles = keep
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			m := NewManager(settings, func(e ProgressEvent) {
+				if e.Level == LevelProgress {
+					cancel()
+				}
+			})
+			if err := m.Initialize(ctx, server.URL+"/album/songs"); err != nil {
+				t.Fatalf("Initialize failed: %v", err)
+			}
+			m.StartDownloads(ctx)
+
+			track := m.albums[0].Tracks[0]
+			if _, err := os.Stat(track.Path); err == nil {
+				t.Errorf("partial file left at %s", track.Path)
+			}
+			_, err := os.Stat(partialPath(track))
+			if kept := err == nil; kept != keep {
+				t.Fatalf("partial file kept = %t, want %t", kept, keep)
+			}
+			if !keep {
+				return
+			}
+
+			m.onProgress = nil
+			if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+				t.Fatalf("Initialize failed: %v", err)
+			}
+			if err := m.StartDownloads(context.Background()); err != nil {
+				t.Fatalf("StartDownloads failed: %v", err)
+			}
+			got, err := os.ReadFile(track.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("resumed file = %q, want %q", got, content)
+			}
+			if stats := m.GetByteStats(); stats.Skipped != 10 || stats.Received != 10 {
+				t.Errorf("bytes received, skipped = %d, %d, want 10, 10", stats.Received, stats.Skipped)
+			}
+		})
+	}
+}
+
+func TestFetchTrack_MirrorAfterRefusal(t *testing.T) {
+	// The stream hosts are reached through the server, acting as a proxy
+	var refused atomic.Int32
+	proxy := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Hostname() == "t4.bcbits.com" {
+			refused.Add(1)
+			nethttp.Error(w, "unavailable", nethttp.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("audio"))
+	}))
+	defer proxy.Close()
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	settings.ProxyType = "manual"
+	settings.Proxies = []string{proxy.URL}
+	m := NewManager(settings, nil)
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), settings.ToPathConfig())
+	track := model.NewTrack(album, 1, 1, "Title", 180, "", "http://t4.bcbits.com/stream/1", settings.ToTrackConfig())
+	album.Tracks = []*model.Track{track}
+	m.albumProgress[album] = &albumProgress{album: album}
+	if err := os.MkdirAll(album.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.fetchTrack(context.Background(), track, album); err != nil {
+		t.Fatalf("fetchTrack failed: %v", err)
+	}
+	if n := refused.Load(); n != 1 {
+		t.Errorf("refusing host requested %d times, want 1 before the mirror", n)
+	}
+	if got, err := os.ReadFile(track.Path); err != nil || string(got) != "audio" {
+		t.Errorf("ReadFile(%s) = %q, %v", track.Path, got, err)
+	}
+}
+
+// flacStream returns a FLAC file of a STREAMINFO block and size bytes of
+// audio data.
+func flacStream(size int) []byte {
+	data := append([]byte("fLaC\x80\x00\x00\x22"), make([]byte, 34)...)
+	return append(data, bytes.Repeat([]byte{0xff}, size)...)
+}
+
+func TestDownloadTrack_StreamExtension(t *testing.T) {
+	stream := flacStream(256 << 10)
+	var requests atomic.Int32
+	server := releaseServer(t, nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Method == nethttp.MethodGet {
+			requests.Add(1)
+		}
+		w.Header().Set("Content-Type", "audio/flac")
+		w.Header().Set("Content-Length", strconv.Itoa(len(stream)))
+		w.Write(stream)
+	}), testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1"}}})
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	settings.SaveCoverArtInTags = false
+	run := func() *Manager {
+		m := NewManager(settings, nil)
+		if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		if err := m.StartDownloads(context.Background()); err != nil {
+			t.Fatalf("StartDownloads failed: %v", err)
+		}
+		return m
+	}
+
+	m := run()
+	track := m.albums[0].Tracks[0]
+	if filepath.Ext(track.Path) != ".flac" || track.Ext != "flac" {
+		t.Errorf("Track.Path = %q, Ext = %q, want a .flac file", track.Path, track.Ext)
+	}
+	got, err := os.ReadFile(track.Path)
+	if err != nil || !bytes.HasPrefix(got, []byte("fLaC")) || !bytes.HasSuffix(got, stream[42:]) {
+		t.Fatalf("ReadFile(%s) = %d bytes, %v, want the stream", track.Path, len(got), err)
+	}
+	if !bytes.Contains(got, []byte("TITLE=Song")) {
+		t.Error("the FLAC file is not tagged")
+	}
+
+	// The next run finds the .flac file rather than the .mp3 of the format
+	m = run()
+	if n := requests.Load(); n != 1 {
+		t.Errorf("stream requested %d times over two runs, want 1", n)
+	}
+	if track := m.albums[0].Tracks[0]; track.Ext != "flac" {
+		t.Errorf("Track.Ext = %q on the second run, want flac", track.Ext)
+	}
+	issues, err := NewManager(settings, nil).Verify(context.Background(), server.URL+"/album/songs", "")
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("Verify: %v %s %s", issue.Kind, issue.Path, issue.Detail)
+	}
+}
+
+func TestDownloadTrack_IdleTimeoutResumes(t *testing.T) {
+	stream := bytes.Repeat([]byte("0123456789"), 1000)
+	var mu sync.Mutex
+	var ranges []string // of the GET requests of the stream
+	server := releaseServer(t, nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		if r.Method != nethttp.MethodGet {
+			w.Header().Set("Content-Length", strconv.Itoa(len(stream)))
+			return
+		}
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		first := len(ranges) == 1
+		mu.Unlock()
+		if first {
+			// Half the stream, then nothing until the client gives up
+			w.Header().Set("Content-Length", strconv.Itoa(len(stream)))
+			w.Write(stream[:len(stream)/2])
+			w.(nethttp.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		nethttp.ServeContent(w, r, "", time.Time{}, bytes.NewReader(stream))
+	}), testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", stream: "/1"}}})
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	settings.SaveCoverArtInTags = false
+	settings.ModifyTags = false
+	settings.KeepPartialFiles = true
+	settings.DownloadIdleTimeout = 0.1
+	settings.DownloadRetryCooldown = 0.01
+	m := NewManager(settings, nil)
+	if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := m.StartDownloads(context.Background()); err != nil {
+		t.Fatalf("StartDownloads failed: %v", err)
+	}
+
+	// The stalled request is retried, from the bytes kept
+	want := []string{"", fmt.Sprintf("bytes=%d-", len(stream)/2)}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(ranges, want) {
+		t.Errorf("stream requested with ranges %q, want %q", ranges, want)
+	}
+	track := m.albums[0].Tracks[0]
+	if got, err := os.ReadFile(track.Path); err != nil || !bytes.Equal(got, stream) {
+		t.Errorf("ReadFile(%s) = %d bytes, %v, want the stream", track.Path, len(got), err)
+	}
+}
+
+func TestDownloadTrack_CheckDuration(t *testing.T) {
+	// MPEG-1 Layer III frame header at 128 kbps: 16000 bytes per second
+	stream := func(seconds int) []byte {
+		return append([]byte{0xff, 0xfb, 0x90, 0x00}, make([]byte, 16000*seconds-4)...)
+	}
+
+	for _, tt := range []struct {
+		mode           string
+		wantRequests   int
+		wantMismatches int
+		wantSize       int
+	}{
+		{mode: "warn", wantRequests: 1, wantMismatches: 1, wantSize: 16000},
+		{mode: "redownload", wantRequests: 2, wantMismatches: 0, wantSize: 160000},
+		{mode: "off", wantRequests: 1, wantMismatches: 0, wantSize: 16000},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			var requests int
+			server := releaseServer(t, nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+				// The first stream is cut short
+				w.Header().Set("Content-Type", "audio/mpeg")
+				if r.Method == nethttp.MethodGet && r.Header.Get("Range") == "" {
+					requests++
+				}
+				if requests <= 1 {
+					w.Write(stream(1))
+				} else {
+					w.Write(stream(10))
+				}
+			}), testRelease{path: "/album/songs", title: "Songs", id: 1, tracks: []testTrack{{title: "Song", duration: 10, stream: "/1"}}})
+
+			settings := config.DefaultSettings()
+			settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+			settings.SaveCoverArtInTags = false
+			settings.ModifyTags = false
+			settings.CheckDuration = tt.mode
+			m := NewManager(settings, nil)
+			if err := m.Initialize(context.Background(), server.URL+"/album/songs"); err != nil {
+				t.Fatalf("Initialize failed: %v", err)
+			}
+			if err := m.StartDownloads(context.Background()); err != nil {
+				t.Fatalf("StartDownloads failed: %v", err)
+			}
+
+			if requests != tt.wantRequests {
+				t.Errorf("%d stream requests, want %d", requests, tt.wantRequests)
+			}
+			if stats := m.GetRunStats(); stats.DurationMismatches != tt.wantMismatches || stats.Downloaded != 1 {
+				t.Errorf("GetRunStats() = %+v, want %d duration mismatch(es)", stats, tt.wantMismatches)
+			}
+			if info, err := os.Stat(m.albums[0].Tracks[0].Path); err != nil || info.Size() != int64(tt.wantSize) {
+				t.Errorf("Stat(track) = %v, %v; want %d bytes", info, err, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestSavePlaylist_Encoding(t *testing.T) {
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{album}")
+	settings.PlaylistEncoding = "windows-1252"
+	settings.PlaylistLineEndings = "crlf"
+	m := NewManager(settings, nil)
+
+	for _, tt := range []struct {
+		title  string
+		prefix string // a BOM when falling back to UTF-8
+		suffix string
+	}{
+		{title: "Café", prefix: "#EXTM3U", suffix: "Caf\xe9.mp3\r\n"},
+		{title: "東京", prefix: "\xef\xbb\xbf#EXTM3U", suffix: "東京.mp3\r\n"},
+	} {
+		album := model.NewAlbum("Artist", tt.title, "", time.Time{}, settings.ToPathConfig())
+		album.Tracks = []*model.Track{model.NewTrack(album, 1, 1, tt.title, 180, "", "", &model.TrackConfig{FileNameFormat: "{title}.mp3"})}
+		if err := os.MkdirAll(album.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.savePlaylist(album); err != nil {
+			t.Fatalf("savePlaylist(%s) failed: %v", tt.title, err)
+		}
+		got, err := os.ReadFile(album.PlaylistPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(got), tt.prefix) || !strings.HasSuffix(string(got), tt.suffix) {
+			t.Errorf("playlist of %s = %q, want %q...%q", tt.title, got, tt.prefix, tt.suffix)
+		}
+	}
+}
+
+func TestArtworkCache(t *testing.T) {
+	cache := newArtworkCache(t.TempDir())
+	const url = "https://f4.bcbits.com/img/a1234567890_10.jpg"
+	key := artworkKey(&model.Album{ArtID: 1234567890, ArtworkURL: url})
+
+	if _, _, ok := cache.load(key, url); ok {
+		t.Fatal("load on empty cache succeeded")
+	}
+
+	validators := http.Validators{ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}
+	if err := cache.store(key, url, []byte("image"), validators); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+
+	data, got, ok := cache.load(key, url)
+	if !ok {
+		t.Fatal("load after store failed")
+	}
+	if string(data) != "image" {
+		t.Errorf("data = %q, want %q", data, "image")
+	}
+	if got != validators {
+		t.Errorf("validators = %+v, want %+v", got, validators)
+	}
+
+	if _, _, ok := cache.load(key, "https://f4.bcbits.com/img/a9999999999_10.jpg"); ok {
+		t.Error("load with another URL under the same key succeeded")
+	}
+
+	var disabled *artworkCache
+	if err := disabled.store(key, url, []byte("image"), validators); err != nil {
+		t.Errorf("store on disabled cache: %v", err)
+	}
+	if _, _, ok := disabled.load(key, url); ok {
+		t.Error("load on disabled cache succeeded")
+	}
+}
+
+func TestAlbumCache(t *testing.T) {
+	var requests atomic.Int32
+	server := releaseServer(t, nil,
+		testRelease{path: "/album/songs", title: "Songs", id: 7, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}, requests: &requests},
+	)
+
+	settings := config.DefaultSettings()
+	settings.AlbumCacheDir = t.TempDir()
+	settings.AlbumCacheTTL = 1
+	albumURL := server.URL + "/album/songs"
+
+	for run := 0; run < 2; run++ {
+		m := NewManager(settings, nil)
+		m.fetchAlbums(context.Background(), albumURL)
+		if len(m.albums) != 1 || m.albums[0].Title != "Songs" || len(m.albums[0].Tracks) != 1 {
+			t.Fatalf("run %d: albums = %v, want Songs with 1 track", run, m.GetAlbumNames())
+		}
+		if m.albums[0].URL != albumURL {
+			t.Errorf("run %d: URL = %q, want %q", run, m.albums[0].URL, albumURL)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("page requested %d times, want 1", n)
+	}
+
+	// Entries older than the TTL are fetched again
+	cache := newAlbumCache(settings.AlbumCacheDir, time.Nanosecond)
+	if entry := cache.load(albumURL); entry != nil {
+		t.Errorf("load of an expired entry = %+v, want nil", entry)
+	}
+}
+
+func TestArtworkKey(t *testing.T) {
+	a := &model.Album{ArtID: 42, ArtworkURL: "https://f4.bcbits.com/img/a0000000042_0.jpg"}
+	b := &model.Album{ArtID: 42, ArtworkURL: "https://f4.bcbits.com/img/a0000000042_0.jpg"}
+	c := &model.Album{ArtworkURL: "https://example.com/cover.jpg"}
+
+	if artworkKey(a) != "a0000000042" {
+		t.Errorf("artworkKey = %q, want %q", artworkKey(a), "a0000000042")
+	}
+	if artworkKey(a) != artworkKey(b) {
+		t.Error("albums sharing an art ID have different keys")
+	}
+	if artworkKey(c) == artworkKey(a) || len(artworkKey(c)) != 40 {
+		t.Errorf("artworkKey without art ID = %q, want a SHA-1 of the URL", artworkKey(c))
+	}
+}
+
+func TestFetchArtwork_SharedByArtID(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	settings := config.DefaultSettings()
+	settings.ArtworkCacheDir = t.TempDir()
+	m := NewManager(settings, nil)
+
+	for i := 0; i < 3; i++ {
+		album := &model.Album{ArtID: 42, ArtworkURL: server.URL + "/a0000000042_0.jpg"}
+		m.albumProgress[album] = &albumProgress{album: album}
+
+		fetch, err := m.fetchArtwork(context.Background(), album)
+		if err != nil {
+			t.Fatalf("fetchArtwork %d failed: %v", i, err)
+		}
+		if string(fetch.data) != "image" {
+			t.Errorf("data = %q, want %q", fetch.data, "image")
+		}
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("artwork requested %d times, want 1", n)
+	}
+}
+
+func TestExtraPicture_SharedByURL(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("back"))
+	}))
+	defer server.Close()
+
+	settings := config.DefaultSettings()
+	settings.ExtraPictureURL = server.URL + "/back.jpg"
+	settings.CoverArtInTagsResize = false
+	settings.ConvertCoverArtToJPG = false
+	m := NewManager(settings, nil)
+
+	for i := 0; i < 3; i++ {
+		album := &model.Album{Title: "Album"}
+		m.albumProgress[album] = &albumProgress{album: album}
+
+		pictures := m.extraPicture(context.Background(), album)
+		if len(pictures) != 1 || string(pictures[0].Data) != "back" || pictures[0].Type != id3v2.PTBackCover {
+			t.Errorf("extraPicture %d = %+v, want the back cover", i, pictures)
+		}
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("picture requested %d times, want 1", n)
+	}
+}
+
+func TestExistingTrack_TrackID(t *testing.T) {
+	dir := t.TempDir()
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(dir, "{artist}", "{album}")
+	m := NewManager(settings, nil)
+
+	trackCfg := &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}
+	newTrack := func(albumTitle string, id int64) *model.Track {
+		album := model.NewAlbum("Artist", albumTitle, "", time.Now(), settings.ToPathConfig())
+		album.URL = "https://artist.bandcamp.com/album/album"
+		track := model.NewTrack(album, 1, 1, "Title", 180, "", "", trackCfg)
+		track.ID = id
+		return track
+	}
+	saveTrack := func(track *model.Track) {
+		if err := os.MkdirAll(filepath.Dir(track.Path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := audio.NewTagger(nil).SaveTags(track, track.Album, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The file of a renamed album is moved to the track's new path
+	old := newTrack("Old Title", 42)
+	saveTrack(old)
+	track := newTrack("New Title", 42)
+	if ok, _ := m.existingTrack(context.Background(), track, track.Album); !ok {
+		t.Fatal("existingTrack = false for the file of the renamed album")
+	}
+	if _, err := os.Stat(track.Path); err != nil {
+		t.Errorf("file not moved to %s: %v", track.Path, err)
+	}
+
+	// A file of another track at the track's path is not the track
+	other := newTrack("Other", 7)
+	saveTrack(other)
+	other.ID = 8
+	if ok, _ := m.existingTrack(context.Background(), other, other.Album); ok {
+		t.Error("existingTrack = true for the file of another track")
+	}
+}
+
+func TestExceedsSizeThreshold(t *testing.T) {
+	tests := []struct {
+		thresholdMB int
+		totalBytes  int64
+		want        bool
+	}{
+		{0, 1 << 40, false},
+		{100, 100 << 20, false},
+		{100, 100<<20 + 1, true},
+		{500, 1 << 30, true},
+	}
+
+	for _, tt := range tests {
+		settings := config.DefaultSettings()
+		settings.ConfirmIfLargerThanMB = tt.thresholdMB
+		m := NewManager(settings, nil)
+		m.totalBytes = tt.totalBytes
+		m.publish()
+		if got, mb := m.ExceedsSizeThreshold(); got != tt.want || mb != float64(tt.totalBytes)/1024/1024 {
+			t.Errorf("ExceedsSizeThreshold() of %d bytes over %d MB = %v, %v MB, want %v", tt.totalBytes, tt.thresholdMB, got, mb, tt.want)
+		}
+	}
+}
+
+func TestPrepareAlbumFolder_Fallback(t *testing.T) {
+	dir := t.TempDir()
+	// A file where the library should be, so that its folders cannot be created
+	if err := os.WriteFile(filepath.Join(dir, "library"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(dir, "library", "{artist}", "{album}")
+
+	newAlbum := func() *model.Album {
+		album := model.NewAlbum("Artist", "Album", "", time.Now(), settings.ToPathConfig())
+		album.Tracks = []*model.Track{model.NewTrack(album, 1, 1, "Title", 180, "", "", settings.ToTrackConfig())}
+		return album
+	}
+
+	if err := NewManager(settings, nil).prepareAlbumFolder(newAlbum()); err == nil {
+		t.Error("prepareAlbumFolder succeeded without a writable folder or fallback")
+	}
+
+	settings.FallbackDownloadsPath = filepath.Join(dir, "fallback")
+	album := newAlbum()
+	if err := NewManager(settings, nil).prepareAlbumFolder(album); err != nil {
+		t.Fatalf("prepareAlbumFolder with a fallback: %v", err)
+	}
+	want := filepath.Join(dir, "fallback", "Artist", "Album")
+	if album.Path != want || filepath.Dir(album.Tracks[0].Path) != want {
+		t.Errorf("album relocated to %q, track to %q, want both in %q", album.Path, album.Tracks[0].Path, want)
+	}
+	if info, err := os.Stat(want); err != nil || !info.IsDir() {
+		t.Errorf("fallback folder not created: %v", err)
+	}
+}
+
+func TestFormatAlbumReadme(t *testing.T) {
+	album := &model.Album{Artist: "Artist", Title: "Album", About: "About the album."}
+	album.Tracks = []*model.Track{
+		{Album: album, Number: 1, Title: "One", About: "First song.", Credits: "Written by A."},
+		{Album: album, Number: 2, Title: "Two"},
+	}
+
+	want := "Artist - Album\n\nAbout the album.\n\n01. One\n\nFirst song.\n\nCredits:\nWritten by A.\n"
+	if got := formatAlbumReadme(album); got != want {
+		t.Errorf("formatAlbumReadme =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := formatTrackInfo(album.Tracks[1]); got != "" {
+		t.Errorf("formatTrackInfo without info = %q, want empty", got)
+	}
+	if got := formatAlbumReadme(&model.Album{Artist: "A", Title: "B"}); got != "" {
+		t.Errorf("formatAlbumReadme without info = %q, want empty", got)
+	}
+}
+
+func TestNewAlbumMetadata(t *testing.T) {
+	album := &model.Album{
+		Artist:      "Artist",
+		Title:       "Album",
+		Tags:        []string{"ambient"},
+		ReleaseDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		Path:        filepath.Join("music", "Artist", "Album"),
+	}
+	album.Tracks = []*model.Track{{
+		Album:  album,
+		Number: 1,
+		Title:  "One",
+		Path:   filepath.Join("music", "Artist", "Album", "CD1", "01 One.mp3"),
+	}}
+
+	meta := newAlbumMetadata(album)
+	if meta.ReleaseDate != "2023-01-02" {
+		t.Errorf("ReleaseDate = %q, want %q", meta.ReleaseDate, "2023-01-02")
+	}
+	if len(meta.Tracks) != 1 || meta.Tracks[0].File != "CD1/01 One.mp3" {
+		t.Fatalf("Tracks = %+v, want one track with file CD1/01 One.mp3", meta.Tracks)
+	}
+	if meta.Tracks[0].Artist != "Artist" {
+		t.Errorf("track Artist = %q, want album artist", meta.Tracks[0].Artist)
+	}
+}
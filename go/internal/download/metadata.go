@@ -0,0 +1,104 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// metadataFileName is the name of the per-album metadata file.
+const metadataFileName = "metadata.json"
+
+// albumMetadata is the content of an album's metadata.json, describing the
+// release independently of the audio files' tags.
+type albumMetadata struct {
+	ID          int64           `json:"id,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	Artist      string          `json:"artist"`
+	Title       string          `json:"title"`
+	Label       string          `json:"label,omitempty"`
+	ReleaseDate string          `json:"release_date,omitempty"`
+	Compilation bool            `json:"compilation,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	About       string          `json:"about,omitempty"`
+	Credits     string          `json:"credits,omitempty"`
+	ArtworkURL  string          `json:"artwork_url,omitempty"`
+	Tracks      []trackMetadata `json:"tracks"`
+}
+
+// trackMetadata describes one track in metadata.json. File is relative to
+// the album folder.
+type trackMetadata struct {
+	Number     int     `json:"number"`
+	DiscNumber int     `json:"disc_number,omitempty"`
+	Title      string  `json:"title"`
+	Artist     string  `json:"artist"`
+	Duration   float64 `json:"duration"`
+	URL        string  `json:"url,omitempty"`
+	About      string  `json:"about,omitempty"`
+	Credits    string  `json:"credits,omitempty"`
+	Lyrics     string  `json:"lyrics,omitempty"`
+	File       string  `json:"file"`
+}
+
+// newAlbumMetadata builds the metadata.json content of album.
+func newAlbumMetadata(album *model.Album) *albumMetadata {
+	meta := &albumMetadata{
+		ID:          album.ID,
+		URL:         album.URL,
+		Artist:      album.Artist,
+		Title:       album.Title,
+		Label:       album.Label,
+		Compilation: album.Compilation,
+		Tags:        album.Tags,
+		About:       album.About,
+		Credits:     album.Credits,
+		ArtworkURL:  album.ArtworkURL,
+		Tracks:      make([]trackMetadata, 0, len(album.Tracks)),
+	}
+	if !album.ReleaseDate.IsZero() {
+		meta.ReleaseDate = album.ReleaseDate.Format("2006-01-02")
+	}
+
+	for _, track := range album.Tracks {
+		file := filepath.Base(track.Path)
+		if rel, err := filepath.Rel(album.Path, track.Path); err == nil {
+			file = filepath.ToSlash(rel)
+		}
+		meta.Tracks = append(meta.Tracks, trackMetadata{
+			Number:     track.Number,
+			DiscNumber: track.DiscNumber,
+			Title:      track.Title,
+			Artist:     track.ArtistName(),
+			Duration:   track.Duration,
+			URL:        track.URL,
+			About:      track.About,
+			Credits:    track.Credits,
+			Lyrics:     track.Lyrics,
+			File:       file,
+		})
+	}
+
+	return meta
+}
+
+// saveMetadata writes the album's metadata.json to the album folder if
+// settings.SaveMetadataJSON is enabled.
+func (m *Manager) saveMetadata(album *model.Album) {
+	if !m.albumSettings(album).SaveMetadataJSON {
+		return
+	}
+
+	data, err := json.MarshalIndent(newAlbumMetadata(album), "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(album.Path, metadataFileName), append(data, '\n'), 0644)
+	}
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error saving metadata of %s: %v", album.Title, err), Level: LevelWarning})
+		return
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Saved metadata for %s", album.Title), Level: LevelVerbose})
+}
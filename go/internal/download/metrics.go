@@ -0,0 +1,118 @@
+package download
+
+import (
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/metrics"
+)
+
+// Metrics are the Prometheus metrics updated by Managers in the
+// long-running modes, such as the daemon.
+//
+// Example:
+//
+//	reg := metrics.NewRegistry()
+//	dm := download.NewMetrics(reg)
+//
+//	manager := download.NewManager(settings, nil)
+//	manager.SetMetrics(dm)
+type Metrics struct {
+	tracks      *metrics.CounterVec
+	albums      *metrics.CounterVec
+	bytes       *metrics.Counter
+	retries     *metrics.Counter
+	parsers     *metrics.CounterVec
+	queued      *metrics.Gauge
+	downloading *metrics.Gauge
+	http        *http.Metrics
+}
+
+// NewMetrics registers the download metrics, and the request metrics of
+// the HTTP client (see http.NewMetrics), on reg:
+//
+//   - bandcamp_dl_tracks_total{result}: tracks processed, by result
+//     ("downloaded", "skipped" or "failed").
+//   - bandcamp_dl_albums_total{state}: albums finished, by final state.
+//   - bandcamp_dl_received_bytes_total: bytes downloaded, including
+//     failed attempts.
+//   - bandcamp_dl_retries_total: requests that were retried.
+//   - bandcamp_dl_page_parser_results_total{parser,result}: pages tried
+//     with each page parser, by result ("read", "no_tracks" or "failed").
+//   - bandcamp_dl_tracks_queued and bandcamp_dl_tracks_downloading: tracks
+//     waiting for a download slot, and being downloaded.
+//
+// Metrics can be shared by several Managers, e.g. one per daemon job.
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	return &Metrics{
+		tracks:      reg.CounterVec("bandcamp_dl_tracks_total", "Tracks processed, by result.", "result"),
+		albums:      reg.CounterVec("bandcamp_dl_albums_total", "Albums finished, by final state.", "state"),
+		bytes:       reg.Counter("bandcamp_dl_received_bytes_total", "Bytes downloaded, including failed attempts."),
+		retries:     reg.Counter("bandcamp_dl_retries_total", "Requests that were retried."),
+		parsers:     reg.CounterVec("bandcamp_dl_page_parser_results_total", "Pages tried with each page parser, by result.", "parser", "result"),
+		queued:      reg.Gauge("bandcamp_dl_tracks_queued", "Tracks waiting for a download slot."),
+		downloading: reg.Gauge("bandcamp_dl_tracks_downloading", "Tracks being downloaded."),
+		http:        http.NewMetrics(reg),
+	}
+}
+
+// SetMetrics records the Manager's activity, and the requests of its HTTP
+// client, in dm. It must be called before the Manager is used.
+func (m *Manager) SetMetrics(dm *Metrics) {
+	m.metrics = dm
+	if dm != nil {
+		m.httpClient.SetMetrics(dm.http)
+	}
+}
+
+// The methods below do nothing on nil Metrics, so the Manager can call them
+// unconditionally.
+
+func (dm *Metrics) trackDone(result string) {
+	if dm != nil {
+		dm.tracks.With(result).Inc()
+	}
+}
+
+func (dm *Metrics) albumDone(state AlbumState) {
+	if dm != nil {
+		dm.albums.With(state.String()).Inc()
+	}
+}
+
+func (dm *Metrics) received(n int64) {
+	if dm != nil && n > 0 {
+		dm.bytes.Add(float64(n))
+	}
+}
+
+func (dm *Metrics) retry() {
+	if dm != nil {
+		dm.retries.Inc()
+	}
+}
+
+func (dm *Metrics) parserResult(parser, result string) {
+	if dm != nil {
+		dm.parsers.With(parser, result).Inc()
+	}
+}
+
+// queue moves n tracks to the queue; start moves one from the queue to
+// the downloading tracks, and stop removes it.
+func (dm *Metrics) queue(n int) {
+	if dm != nil {
+		dm.queued.Add(float64(n))
+	}
+}
+
+func (dm *Metrics) start() {
+	if dm != nil {
+		dm.queued.Add(-1)
+		dm.downloading.Add(1)
+	}
+}
+
+func (dm *Metrics) stop() {
+	if dm != nil {
+		dm.downloading.Add(-1)
+	}
+}
@@ -0,0 +1,70 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+)
+
+// mobileDiscography lists the releases of the account of a music page
+// from the mobile API, after sampling (see sampleURLs). Each release is
+// read from the API too and kept in m.mobilePages, so its page is not
+// fetched. Releases the API fails to read are reported and skipped;
+// an error is only returned if the account cannot be read.
+func (m *Manager) mobileDiscography(ctx context.Context, musicPageHTML string) ([]string, error) {
+	ids, err := bandcamp.ReadPageIDs(musicPageHTML)
+	if err != nil {
+		return nil, err
+	}
+	band, err := m.mobileAPI.BandDetails(ctx, ids.BandID)
+	if err != nil {
+		return nil, err
+	}
+	if len(band.Discography) == 0 {
+		return nil, bandcamp.ErrNoAlbumFound
+	}
+
+	// Sample by item, before reading the releases
+	items := make(map[string]bandcamp.PageIDs, len(band.Discography))
+	keys := make([]string, 0, len(band.Discography))
+	for _, item := range band.Discography {
+		bandID := item.BandID
+		if bandID == 0 {
+			bandID = band.ID
+		}
+		key := item.ItemType + "/" + strconv.FormatInt(item.ItemID, 10)
+		if _, ok := items[key]; !ok {
+			items[key] = bandcamp.PageIDs{BandID: bandID, ItemID: item.ItemID, ItemType: item.ItemType}
+			keys = append(keys, key)
+		}
+	}
+
+	if m.mobilePages == nil {
+		m.mobilePages = make(map[string]*bandcamp.AlbumPage)
+	}
+	var urls []string
+	for _, key := range m.sampleURLs(keys) {
+		page, pageURL, err := m.mobileAPI.ReadRelease(ctx, items[key])
+		if ctx.Err() != nil {
+			return urls, nil
+		}
+		if errors.Is(err, bandcamp.ErrNoTracks) {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Skipping %s: no tracks (e.g. a merch bundle)", pageURL), Level: LevelVerbose})
+			continue
+		}
+		if err == nil && pageURL == "" {
+			err = errors.New("no URL")
+		}
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error reading %s from the mobile API: %v", key, err), Level: LevelError})
+			continue
+		}
+		m.mobilePages[pageURL] = page
+		urls = append(urls, pageURL)
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Read %d releases of %s from the mobile API", len(urls), band.Name), Level: LevelVerbose})
+	return urls, nil
+}
@@ -0,0 +1,89 @@
+package download
+
+import (
+	"fmt"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// albumConfig is what a release matched by overrides of the settings (see
+// config.Override) is downloaded with, instead of the Manager's own.
+type albumConfig struct {
+	settings *config.Settings
+	tagger   *audio.Tagger
+	playlist *audio.PlaylistCreator
+}
+
+// overrideFor returns the configuration of the release at albumURL, or nil
+// if no override matches it.
+func (m *Manager) overrideFor(albumURL string) *albumConfig {
+	settings, err := m.settings.For(albumURL)
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Ignoring the overrides of %s: %v", albumURL, err), Level: LevelWarning})
+		return nil
+	}
+	if settings == m.settings {
+		return nil
+	}
+	return &albumConfig{
+		settings: settings,
+		tagger:   newTagger(settings),
+		playlist: newPlaylistCreator(settings),
+	}
+}
+
+// albumSettings returns the settings album is downloaded with.
+func (m *Manager) albumSettings(album *model.Album) *config.Settings {
+	if cfg := m.albumConfigs[album]; cfg != nil {
+		return cfg.settings
+	}
+	return m.settings
+}
+
+// albumTagger returns the tagger of album's files.
+func (m *Manager) albumTagger(album *model.Album) *audio.Tagger {
+	if cfg := m.albumConfigs[album]; cfg != nil {
+		return cfg.tagger
+	}
+	return m.tagger
+}
+
+// albumPlaylist returns the playlist creator of album.
+func (m *Manager) albumPlaylist(album *model.Album) *audio.PlaylistCreator {
+	if cfg := m.albumConfigs[album]; cfg != nil {
+		return cfg.playlist
+	}
+	return m.playlist
+}
+
+// newTagger returns the tagger configured by settings.
+func newTagger(settings *config.Settings) *audio.Tagger {
+	return audio.NewTagger(settings.ToTagConfig())
+}
+
+// newPlaylistCreator returns the playlist creator configured by settings.
+func newPlaylistCreator(settings *config.Settings) *audio.PlaylistCreator {
+	var playlistFormat audio.PlaylistFormat
+	switch settings.PlaylistFormat {
+	case "pls":
+		playlistFormat = audio.FormatPLS
+	case "wpl":
+		playlistFormat = audio.FormatWPL
+	case "zpl":
+		playlistFormat = audio.FormatZPL
+	case "m3u8":
+		playlistFormat = audio.FormatM3U8
+	default:
+		playlistFormat = audio.FormatM3U
+	}
+	// An invalid encoding, which Validate reports, keeps UTF-8
+	encoding, _ := audio.ParsePlaylistEncoding(settings.PlaylistEncoding)
+	return audio.NewPlaylistCreator(playlistFormat, settings.M3UExtended).WithOptions(audio.PlaylistOptions{
+		Encoding:  encoding,
+		CRLF:      settings.PlaylistLineEndings == "crlf",
+		AlbumInfo: settings.M3UAlbumInfo,
+		Artwork:   settings.M3UArtwork && settings.SaveCoverArtInFolder,
+	})
+}
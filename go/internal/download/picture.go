@@ -0,0 +1,60 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/http"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// extraPicture is the picture of an extra_picture_url, downloaded once
+// per run. data is nil if the download failed.
+type extraPicture struct {
+	once sync.Once
+	data []byte
+}
+
+// extraPicture returns the picture to embed in the tracks of album in
+// addition to the cover art, set by extra_picture_url and prepared like the
+// cover art for tags, or nil if there is none. A failed download is
+// reported once, and the picture then left out of the album's tracks.
+func (m *Manager) extraPicture(ctx context.Context, album *model.Album) []audio.Picture {
+	settings := m.albumSettings(album)
+	if settings.ExtraPictureURL == "" || !settings.SaveCoverArtInTags {
+		return nil
+	}
+	pictureType, err := audio.ParsePictureType(settings.ExtraPictureType)
+	if err != nil {
+		return nil
+	}
+
+	key := settings.ExtraPictureURL + " " + variantKey("tags", settings.CoverArtInTagsResize, settings.CoverArtInTagsMaxSize, settings.ConvertCoverArtToJPG)
+	m.extraPicturesMu.Lock()
+	p := m.extraPictures[key]
+	if p == nil {
+		p = &extraPicture{}
+		m.extraPictures[key] = p
+	}
+	m.extraPicturesMu.Unlock()
+
+	p.once.Do(func() {
+		data, err := m.httpClient.Get(http.WithLabel(ctx, "picture of "+album.Title), settings.ExtraPictureURL)
+		if err == nil {
+			m.addReceivedBytes(album, int64(len(data)))
+			data, err = m.processArtwork(ctx, data, settings.CoverArtInTagsResize, settings.CoverArtInTagsMaxSize, settings.ConvertCoverArtToJPG)
+		}
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading %s, not embedding it: %v", settings.ExtraPictureURL, err), Level: LevelWarning})
+			return
+		}
+		p.data = data
+	})
+
+	if p.data == nil {
+		return nil
+	}
+	return []audio.Picture{{Type: pictureType, Data: p.data}}
+}
@@ -0,0 +1,218 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// Discoverer is the Discover stage of a Pipeline: it returns the URLs of
+// the releases of an input URL, e.g. those of the discography of an
+// artist. The URLs found for every input are deduplicated by the Manager.
+type Discoverer interface {
+	Discover(ctx context.Context, inputURL string) ([]string, error)
+}
+
+// Parser is the Parse stage of a Pipeline: it reads the release at
+// albumURL into an album, with the paths of its folder and tracks. An
+// error wrapping bandcamp.ErrNoTracks skips the release quietly, like the
+// merch bundles of music pages.
+type Parser interface {
+	Parse(ctx context.Context, albumURL string) (*model.Album, error)
+}
+
+// Planner is the Plan stage of a Pipeline: out of the albums parsed, it
+// returns those to download, in order, e.g. after filtering them or
+// moving their folders. An error fails Initialize.
+type Planner interface {
+	Plan(ctx context.Context, albums []*model.Album) ([]*model.Album, error)
+}
+
+// Fetcher is the Fetch stage of a Pipeline: it downloads the files of an
+// album to its folder, and counts its tracks by outcome. A track that
+// fails is counted as neither downloaded nor deferred; an error fails the
+// whole album and stops the downloads of the run.
+type Fetcher interface {
+	Fetch(ctx context.Context, album *model.Album) (FetchResult, error)
+}
+
+// FetchResult counts the tracks of an album fetched by a Fetcher.
+type FetchResult struct {
+	// Downloaded counts the tracks downloaded, or found already present.
+	Downloaded int
+
+	// Deferred counts the tracks not started because the byte budget of
+	// the run (MaxTotalBytes) was reached, left for the next run.
+	Deferred int
+}
+
+// state returns the state of an album of tracks tracks fetched with r.
+func (r FetchResult) state(tracks int) AlbumState {
+	switch {
+	case r.Downloaded == tracks:
+		return AlbumCompleted
+	case r.Deferred == tracks:
+		return AlbumPending
+	case r.Downloaded == 0:
+		return AlbumFailed
+	default:
+		return AlbumPartial
+	}
+}
+
+// PostProcessor is a PostProcess stage of a Pipeline, run on every album
+// fetched, e.g. to write files next to its tracks, convert them or move
+// the album elsewhere. state is the state the album gets once all of them
+// have run: processors that need its tracks should skip the albums not
+// AlbumCompleted. Errors are reported as warnings and do not change the
+// state.
+type PostProcessor interface {
+	PostProcess(ctx context.Context, album *model.Album, state AlbumState) error
+}
+
+// DiscoverFunc adapts a function to a Discoverer.
+type DiscoverFunc func(ctx context.Context, inputURL string) ([]string, error)
+
+// Discover calls f(ctx, inputURL).
+func (f DiscoverFunc) Discover(ctx context.Context, inputURL string) ([]string, error) {
+	return f(ctx, inputURL)
+}
+
+// ParseFunc adapts a function to a Parser.
+type ParseFunc func(ctx context.Context, albumURL string) (*model.Album, error)
+
+// Parse calls f(ctx, albumURL).
+func (f ParseFunc) Parse(ctx context.Context, albumURL string) (*model.Album, error) {
+	return f(ctx, albumURL)
+}
+
+// PlanFunc adapts a function to a Planner.
+type PlanFunc func(ctx context.Context, albums []*model.Album) ([]*model.Album, error)
+
+// Plan calls f(ctx, albums).
+func (f PlanFunc) Plan(ctx context.Context, albums []*model.Album) ([]*model.Album, error) {
+	return f(ctx, albums)
+}
+
+// FetchFunc adapts a function to a Fetcher.
+type FetchFunc func(ctx context.Context, album *model.Album) (FetchResult, error)
+
+// Fetch calls f(ctx, album).
+func (f FetchFunc) Fetch(ctx context.Context, album *model.Album) (FetchResult, error) {
+	return f(ctx, album)
+}
+
+// PostProcessFunc adapts a function to a PostProcessor.
+type PostProcessFunc func(ctx context.Context, album *model.Album, state AlbumState) error
+
+// PostProcess calls f(ctx, album, state).
+func (f PostProcessFunc) PostProcess(ctx context.Context, album *model.Album, state AlbumState) error {
+	return f(ctx, album, state)
+}
+
+// Pipeline holds the stages a Manager runs: Discover, Parse and Plan in
+// Initialize, then Fetch and the PostProcess stages, in order, on each
+// album in StartDownloads. Wrapping or replacing a stage changes what the
+// Manager does, e.g. a PostProcessor before the default ones converting
+// the tracks:
+//
+//	p := manager.Pipeline()
+//	p.PostProcess = append([]download.PostProcessor{transcoder}, p.PostProcess...)
+//	manager.SetPipeline(p)
+type Pipeline struct {
+	Discover    Discoverer
+	Parse       Parser
+	Plan        Planner
+	Fetch       Fetcher
+	PostProcess []PostProcessor
+}
+
+// defaultPipeline returns the stages of the Manager itself.
+func (m *Manager) defaultPipeline() Pipeline {
+	return Pipeline{
+		Discover: DiscoverFunc(m.getAlbumURLs),
+		Parse:    ParseFunc(m.parseRelease),
+		Plan:     PlanFunc(m.planRun),
+		Fetch:    FetchFunc(m.fetchAlbumFiles),
+		PostProcess: []PostProcessor{
+			PostProcessFunc(m.downloadPurchase),
+			PostProcessFunc(m.transcodeAlbum),
+			PostProcessFunc(m.saveAlbumFiles),
+			PostProcessFunc(m.integrateAlbum),
+		},
+	}
+}
+
+// Pipeline returns the stages of the Manager, to wrap or replace some of
+// them with SetPipeline.
+func (m *Manager) Pipeline() Pipeline {
+	p := m.pipeline
+	p.PostProcess = slices.Clone(p.PostProcess)
+	return p
+}
+
+// SetPipeline sets the stages of the Manager. The default stage is kept
+// for a nil stage, and the default PostProcess stages for a nil
+// PostProcess; an empty one runs none. It must be called before the
+// Manager is used.
+func (m *Manager) SetPipeline(p Pipeline) {
+	defaults := m.defaultPipeline()
+	if p.Discover == nil {
+		p.Discover = defaults.Discover
+	}
+	if p.Parse == nil {
+		p.Parse = defaults.Parse
+	}
+	if p.Plan == nil {
+		p.Plan = defaults.Plan
+	}
+	if p.Fetch == nil {
+		p.Fetch = defaults.Fetch
+	}
+	if p.PostProcess == nil {
+		p.PostProcess = defaults.PostProcess
+	}
+	m.pipeline = p
+}
+
+// planAlbums runs the Plan stage on the albums parsed, forgetting those it
+// leaves out.
+func (m *Manager) planAlbums(ctx context.Context) error {
+	parsed := slices.Clone(m.albums)
+	planned, err := m.pipeline.Plan.Plan(ctx, m.albums)
+	if err != nil {
+		planned = nil
+	}
+
+	kept := make(map[*model.Album]bool, len(planned))
+	for _, album := range planned {
+		kept[album] = true
+		// Albums may be added by the planner
+		if m.albumProgress[album] == nil {
+			m.albumProgress[album] = &albumProgress{album: album}
+		}
+	}
+	for _, album := range parsed {
+		if !kept[album] {
+			m.forgetAlbum(album)
+		}
+	}
+	m.albums = planned
+	if err != nil {
+		return fmt.Errorf("planning the downloads: %w", err)
+	}
+	return nil
+}
+
+// planRun is the default Plan stage: it applies the Filter expression,
+// then the LimitAlbums and LimitTracks limits, and moves the albums
+// sharing a folder to folders of their own.
+func (m *Manager) planRun(_ context.Context, albums []*model.Album) ([]*model.Album, error) {
+	m.albums = albums
+	m.applyFilter()
+	m.applyLimits()
+	m.dedupAlbumFolders()
+	return m.albums, nil
+}
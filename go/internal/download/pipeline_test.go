@@ -0,0 +1,87 @@
+package download
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestManager_Pipeline(t *testing.T) {
+	server := releaseServer(t, audioFile("audio"),
+		testRelease{path: "/album/kept", title: "kept", id: 1, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}},
+		testRelease{path: "/album/planned-out", title: "planned-out", id: 2, tracks: []testTrack{{title: "Song", stream: "/1.mp3"}}},
+	)
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+	settings.SaveCoverArtInFolder = false
+	settings.SaveCoverArtInTags = false
+	m := NewManager(settings, nil)
+
+	p := m.Pipeline()
+	defaults := p
+	p.Discover = DiscoverFunc(func(ctx context.Context, inputURL string) ([]string, error) {
+		return []string{inputURL + "/album/kept", inputURL + "/album/planned-out"}, nil
+	})
+	p.Parse = ParseFunc(func(ctx context.Context, albumURL string) (*model.Album, error) {
+		album, err := defaults.Parse.Parse(ctx, albumURL)
+		if err == nil {
+			album.Label = "Enriched"
+		}
+		return album, err
+	})
+	p.Plan = PlanFunc(func(ctx context.Context, albums []*model.Album) ([]*model.Album, error) {
+		var kept []*model.Album
+		for _, album := range albums {
+			if album.Title == "kept" {
+				kept = append(kept, album)
+			}
+		}
+		return defaults.Plan.Plan(ctx, kept)
+	})
+	var fetched int
+	p.Fetch = FetchFunc(func(ctx context.Context, album *model.Album) (FetchResult, error) {
+		fetched++
+		return defaults.Fetch.Fetch(ctx, album)
+	})
+	var states []AlbumState
+	p.PostProcess = append([]PostProcessor{PostProcessFunc(func(ctx context.Context, album *model.Album, state AlbumState) error {
+		states = append(states, state)
+		if _, err := os.Stat(album.Tracks[0].Path); err != nil {
+			t.Errorf("track not fetched before post-processing: %v", err)
+		}
+		return nil
+	})}, p.PostProcess...)
+	m.SetPipeline(p)
+
+	if err := m.Initialize(context.Background(), server.URL); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	albums := m.GetAlbums()
+	if len(albums) != 1 || albums[0].Album.Title != "kept" || albums[0].Album.Label != "Enriched" {
+		t.Fatalf("albums = %q, want only kept, enriched", m.GetAlbumNames())
+	}
+	if len(m.albumProgress) != 1 {
+		t.Errorf("%d albums in progress, want the planned-out one forgotten", len(m.albumProgress))
+	}
+
+	if err := m.StartDownloads(context.Background()); err != nil {
+		t.Fatalf("StartDownloads failed: %v", err)
+	}
+	if fetched != 1 || len(states) != 1 || states[0] != AlbumCompleted {
+		t.Errorf("fetched %d album(s), post-processed with states %v, want 1 completed", fetched, states)
+	}
+	if got := m.GetAlbums()[0].Progress.State; got != AlbumCompleted {
+		t.Errorf("state = %v, want completed", got)
+	}
+
+	// Nil stages are the defaults
+	m.SetPipeline(Pipeline{})
+	if got := m.Pipeline(); got.Fetch == nil || len(got.PostProcess) != len(defaults.PostProcess) {
+		t.Errorf("SetPipeline(Pipeline{}) = %+v, want the defaults", got)
+	}
+}
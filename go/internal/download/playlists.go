@@ -0,0 +1,160 @@
+package download
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/filter"
+	ioutils "github.com/handiism/bandcamp-downloader/internal/io"
+	"github.com/handiism/bandcamp-downloader/internal/library"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// coverArtExtensions are the extensions of the cover art files looked for
+// in the albums of a library.
+var coverArtExtensions = []string{".jpg", ".jpeg", ".png"}
+
+// RegeneratePlaylists writes the playlist of every album found under root
+// in the configured format, from the tags of its MP3 files, e.g. after the
+// files were renamed or the playlist format changed. Nothing is fetched
+// from Bandcamp. Existing playlists of the same name are replaced.
+//
+// Returns the number of playlists written.
+func (m *Manager) RegeneratePlaylists(ctx context.Context, root string) (int, error) {
+	albums, err := library.Scan(ctx, root)
+	if err != nil {
+		return 0, err
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Found %d album(s) in library %s", len(albums), root), Level: LevelInfo})
+
+	pathCfg := m.settings.ToPathConfig()
+	var written int
+	for _, la := range albums {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+
+		album := libraryAlbumModel(la, pathCfg)
+		if err := m.savePlaylist(album); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error creating playlist for %s: %v", album.Title, err), Level: LevelWarning})
+			continue
+		}
+		written++
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Created playlist for %s", album.Title), Level: LevelVerbose})
+	}
+
+	return written, nil
+}
+
+// libraryAlbumModel returns the album of the files of la, with its
+// playlist and cover art in its folder named by cfg. The cover art is only
+// set if a file of that name exists.
+func libraryAlbumModel(la *library.Album, cfg *model.PathConfig) *model.Album {
+	album := &model.Album{Artist: la.Artist, Title: la.Title, URL: la.BandcampURL}
+	if album.Title == "" {
+		album.Title = filepath.Base(la.Path)
+	}
+	if year := la.ReleaseYear(); year > 0 {
+		album.ReleaseDate = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	album.LocatePaths(la.Path, cfg)
+	for _, ext := range coverArtExtensions {
+		if path := album.CoverArtPath(cfg, ext); fileExists(path) {
+			album.ArtworkPath = path
+			break
+		}
+	}
+
+	for _, lt := range la.Tracks {
+		album.Tracks = append(album.Tracks, &model.Track{
+			Album:    album,
+			Number:   lt.Number,
+			Title:    lt.Title,
+			Artist:   lt.Artist,
+			Duration: lt.Duration,
+			Path:     lt.Path,
+		})
+	}
+	return album
+}
+
+// fileExists reports whether a file exists at path.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// WriteSmartPlaylists writes the playlists gathering the tracks found
+// under root that match the query of each of playlists, in the configured
+// playlist format, to the folder dir. Their files are listed by their path
+// relative to dir. Nothing is fetched from Bandcamp, and playlists without
+// any track are not written.
+//
+// Returns the number of playlists written.
+func (m *Manager) WriteSmartPlaylists(ctx context.Context, root string, playlists []config.SmartPlaylist, dir string) (int, error) {
+	queries := make([]*filter.Expr, len(playlists))
+	for i, playlist := range playlists {
+		query, err := library.ParseQuery(playlist.Query)
+		if err != nil {
+			return 0, fmt.Errorf("query %q of %s: %w", playlist.Query, playlist.Name, err)
+		}
+		queries[i] = query
+	}
+
+	albums, err := library.Scan(ctx, root)
+	if err != nil {
+		return 0, err
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Found %d album(s) in library %s", len(albums), root), Level: LevelInfo})
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	// Album directives and artwork do not apply to tracks of many albums
+	options := m.playlist.Options()
+	options.AlbumInfo, options.Artwork, options.Dir = false, false, dir
+	creator := newPlaylistCreator(m.settings).WithOptions(options)
+	ext := m.settings.ToPathConfig().PlaylistFormat.Extension()
+
+	var written int
+	for i, playlist := range playlists {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+
+		matches, err := library.Select(albums, queries[i])
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error selecting the tracks of %s: %v", playlist.Name, err), Level: LevelWarning})
+			continue
+		}
+		if len(matches) == 0 {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("No track matches %s (%s)", playlist.Name, playlist.Query), Level: LevelWarning})
+			continue
+		}
+
+		album := &model.Album{Title: playlist.Name, PlaylistPath: filepath.Join(dir, ioutils.SanitizeFileName(playlist.Name)+ext)}
+		for _, match := range matches {
+			album.Tracks = append(album.Tracks, &model.Track{
+				Album:    album,
+				Number:   match.Track.Number,
+				Title:    match.Track.Title,
+				Artist:   cmp.Or(match.Track.Artist, match.Album.Artist),
+				Duration: match.Track.Duration,
+				Path:     match.Track.Path,
+			})
+		}
+		if err := m.writePlaylist(creator, album); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error creating playlist %s: %v", playlist.Name, err), Level: LevelWarning})
+			continue
+		}
+		written++
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Created playlist %s with %d track(s)", filepath.Base(album.PlaylistPath), len(matches)), Level: LevelSuccess})
+	}
+
+	return written, nil
+}
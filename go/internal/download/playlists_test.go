@@ -0,0 +1,137 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2"
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestRegeneratePlaylists(t *testing.T) {
+	root := t.TempDir()
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(root, "{artist}", "{album}")
+	settings.PlaylistFormat = "m3u8"
+	settings.PlaylistFileNameFormat = "{year} {album}"
+	settings.M3UArtwork = true
+	settings.SaveCoverArtInFolder = true
+
+	album := model.NewAlbum("Artist", "Album", "", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), settings.ToPathConfig())
+	album.Tracks = []*model.Track{
+		model.NewTrack(album, 1, 2, "Second", 200, "", "", settings.ToTrackConfig()),
+		model.NewTrack(album, 1, 1, "First", 100, "", "", settings.ToTrackConfig()),
+	}
+	if err := os.MkdirAll(album.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tagger := audio.NewTagger(audio.DefaultTagConfig())
+	for _, track := range album.Tracks {
+		if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := tagger.SaveTags(track, album, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(album.Path, "Album.png"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(settings, nil)
+	written, err := m.RegeneratePlaylists(context.Background(), root)
+	if err != nil || written != 1 {
+		t.Fatalf("RegeneratePlaylists() = %d, %v; want 1 playlist", written, err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(album.Path, "2020 Album.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#EXTM3U\n#EXTIMG:Album.png\n" +
+		"#EXTINF:100,Artist - First\n01 Artist - First.mp3\n" +
+		"#EXTINF:200,Artist - Second\n02 Artist - Second.mp3\n"
+	if string(got) != want {
+		t.Errorf("playlist = %q, want %q", got, want)
+	}
+}
+
+func TestWriteSmartPlaylists(t *testing.T) {
+	root := t.TempDir()
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(root, "{artist}", "{album}")
+	settings.PlaylistFormat = "m3u8"
+	settings.M3UAlbumInfo = true
+
+	tagger := audio.NewTagger(audio.DefaultTagConfig())
+	addAlbum := func(artist string, year int, genres ...string) {
+		album := model.NewAlbum(artist, "Album", "", time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), settings.ToPathConfig())
+		for i := range genres {
+			album.Tracks = append(album.Tracks, model.NewTrack(album, 1, i+1, fmt.Sprintf("Track %d", i+1), 100, "", "", settings.ToTrackConfig()))
+		}
+		if err := os.MkdirAll(album.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i, track := range album.Tracks {
+			if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := tagger.SaveTags(track, album, nil); err != nil {
+				t.Fatal(err)
+			}
+			// SaveTags clears the genre, which other tools set
+			tag, err := id3v2.Open(track.Path, id3v2.Options{Parse: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag.SetGenre(genres[i])
+			if err := tag.Save(); err != nil {
+				t.Fatal(err)
+			}
+			tag.Close()
+		}
+	}
+	addAlbum("Old", 2012, "Ambient, Drone", "Techno")
+	addAlbum("New", 2020, "ambient")
+
+	dir := filepath.Join(root, "Playlists")
+	playlists := []config.SmartPlaylist{
+		{Name: "Early Ambient", Query: `"ambient" in genre && year >= 2010 && year <= 2015`},
+		{Name: "All Ambient", Query: `"ambient" in genre`},
+		{Name: "Jazz", Query: `genre == "Jazz"`},
+	}
+	m := NewManager(settings, nil)
+	written, err := m.WriteSmartPlaylists(context.Background(), root, playlists, dir)
+	if err != nil || written != 2 {
+		t.Fatalf("WriteSmartPlaylists() = %d, %v; want 2 playlists", written, err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"Early Ambient.m3u8", "#EXTM3U\n" +
+			"#EXTINF:100,Old - Track 1\n" + filepath.Join("..", "Old", "Album", "01 Old - Track 1.mp3") + "\n"},
+		{"All Ambient.m3u8", "#EXTM3U\n" +
+			"#EXTINF:100,New - Track 1\n" + filepath.Join("..", "New", "Album", "01 New - Track 1.mp3") + "\n" +
+			"#EXTINF:100,Old - Track 1\n" + filepath.Join("..", "Old", "Album", "01 Old - Track 1.mp3") + "\n"},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Jazz.m3u8")); !os.IsNotExist(err) {
+		t.Errorf("playlist of no track written: %v", err)
+	}
+}
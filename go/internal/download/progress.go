@@ -0,0 +1,281 @@
+package download
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// AlbumState indicates where an album is in the download lifecycle.
+type AlbumState int32
+
+const (
+	// AlbumPending means the album has not started downloading yet.
+	AlbumPending AlbumState = iota
+
+	// AlbumDownloading means the album's files are being downloaded.
+	AlbumDownloading
+
+	// AlbumCompleted means every track of the album was downloaded.
+	AlbumCompleted
+
+	// AlbumPartial means the album finished but some tracks failed.
+	AlbumPartial
+
+	// AlbumFailed means the album could not be downloaded at all.
+	AlbumFailed
+)
+
+// String returns a lowercase name for the state, suitable for display.
+func (s AlbumState) String() string {
+	switch s {
+	case AlbumPending:
+		return "pending"
+	case AlbumDownloading:
+		return "downloading"
+	case AlbumCompleted:
+		return "completed"
+	case AlbumPartial:
+		return "partial"
+	case AlbumFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// TrackState indicates where a track is in the download lifecycle.
+type TrackState int32
+
+const (
+	// TrackPending means the track has not started downloading yet.
+	TrackPending TrackState = iota
+
+	// TrackDownloading means the track's file is being downloaded.
+	TrackDownloading
+
+	// TrackDownloaded means the track was downloaded.
+	TrackDownloaded
+
+	// TrackSkipped means the track was already downloaded.
+	TrackSkipped
+
+	// TrackFailed means the track could not be downloaded.
+	TrackFailed
+)
+
+// String returns a lowercase name for the state, suitable for display.
+func (s TrackState) String() string {
+	switch s {
+	case TrackPending:
+		return "pending"
+	case TrackDownloading:
+		return "downloading"
+	case TrackDownloaded:
+		return "downloaded"
+	case TrackSkipped:
+		return "skipped"
+	case TrackFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// AlbumSnapshot is a point-in-time copy of an initialized album with the
+// state of its tracks, returned by Manager.GetAlbums.
+type AlbumSnapshot struct {
+	// Album is a copy of the album, whose tracks are copies too: changing
+	// them has no effect on the download.
+	Album *model.Album
+
+	// Progress is the album's progress, as returned by GetAlbumProgress.
+	Progress AlbumProgress
+
+	// TrackStates are the states of Album.Tracks, in the same order.
+	TrackStates []TrackState
+}
+
+// AlbumProgress is a point-in-time snapshot of a single album's progress.
+//
+// Snapshots are returned by Manager.GetAlbumProgress and
+// Manager.GetProgressSnapshot so UIs can render one progress bar per album.
+type AlbumProgress struct {
+	// ID is the Bandcamp item ID of the album.
+	ID int64
+
+	// Artist is the album artist name.
+	Artist string
+
+	// Title is the album title.
+	Title string
+
+	// Path is the folder the album is downloaded to.
+	Path string
+
+	// State is the album's current lifecycle state.
+	State AlbumState
+
+	// ReceivedBytes is the number of bytes downloaded so far for this album.
+	ReceivedBytes int64
+
+	// SkippedBytes is the size of the album's existing files that were
+	// skipped instead of downloaded.
+	SkippedBytes int64
+
+	// TotalBytes is the expected number of bytes for this album.
+	TotalBytes int64
+
+	// DownloadedFiles is the number of files (tracks and artwork) done so far.
+	DownloadedFiles int32
+
+	// TotalFiles is the number of files expected for this album.
+	TotalFiles int32
+
+	// SkippedVideos is the number of video items of the release, which
+	// are not downloaded.
+	SkippedVideos int
+}
+
+// TrackSummary describes a track of an initialized album, see
+// Manager.GetAlbumTracks.
+type TrackSummary struct {
+	Number     int
+	DiscNumber int
+	Title      string
+	Artist     string
+	Duration   time.Duration
+
+	// EstimatedSize is the size of the track's file estimated from its
+	// duration, in bytes, since the actual size is only known once the
+	// download starts.
+	EstimatedSize int64
+}
+
+// ByteStats breaks down the bytes accounted for by the Manager.
+//
+// Every transfer contributes to Received as it streams (tracks and artwork),
+// failed attempts that are retried are discounted, and existing files that
+// are skipped count towards Skipped rather than Received. Received+Skipped
+// is therefore comparable to Total.
+type ByteStats struct {
+	// Received is the number of bytes downloaded from the network.
+	Received int64
+
+	// Skipped is the size of the existing files that were not downloaded again.
+	Skipped int64
+
+	// Total is the expected number of bytes, from the servers' reported sizes.
+	Total int64
+}
+
+// RunStats breaks down the outcome of the tracks of a run, returned by
+// Manager.GetRunStats for completion summaries.
+type RunStats struct {
+	// Downloaded, Skipped and Failed are the numbers of tracks that were
+	// downloaded, already downloaded, and failed after their retries.
+	Downloaded int
+	Skipped    int
+	Failed     int
+
+	// Unavailable is the number of tracks of the releases without a
+	// stream, which cannot be downloaded (see model.Album.UnavailableTracks).
+	Unavailable int
+
+	// Pending is the number of tracks not attempted, because the run was
+	// canceled or reached its byte budget.
+	Pending int
+
+	// DurationMismatches is the number of tracks downloaded whose duration
+	// is off the one of their metadata, e.g. truncated streams (see
+	// settings.CheckDuration). They are also counted in Downloaded.
+	DurationMismatches int
+
+	// Received is the number of bytes downloaded from the network.
+	Received int64
+
+	// Elapsed is how long the downloads took, or have been running.
+	Elapsed time.Duration
+}
+
+// Speed returns the average download speed, in bytes per second.
+func (s RunStats) Speed() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Received) / s.Elapsed.Seconds()
+}
+
+// albumProgress holds the live counters for one album.
+//
+// Counters are updated atomically from the download goroutines and read
+// by snapshot calls from the UI goroutine.
+type albumProgress struct {
+	album           *model.Album
+	state           int32
+	totalBytes      int64
+	receivedBytes   int64
+	skippedBytes    int64
+	totalFiles      int32
+	downloadedFiles int32
+
+	// trackStates holds the states of the album's tracks that are not
+	// TrackPending.
+	trackStatesMu sync.Mutex
+	trackStates   map[*model.Track]TrackState
+}
+
+func (p *albumProgress) setState(state AlbumState) {
+	atomic.StoreInt32(&p.state, int32(state))
+}
+
+func (p *albumProgress) snapshot() AlbumProgress {
+	return AlbumProgress{
+		ID:              p.album.ID,
+		Artist:          p.album.Artist,
+		Title:           p.album.Title,
+		Path:            p.album.Path,
+		State:           AlbumState(atomic.LoadInt32(&p.state)),
+		ReceivedBytes:   atomic.LoadInt64(&p.receivedBytes),
+		SkippedBytes:    atomic.LoadInt64(&p.skippedBytes),
+		TotalBytes:      p.totalBytes,
+		DownloadedFiles: atomic.LoadInt32(&p.downloadedFiles),
+		TotalFiles:      p.totalFiles,
+		SkippedVideos:   len(p.album.SkippedVideos),
+	}
+}
+
+func (p *albumProgress) setTrackState(track *model.Track, state TrackState) {
+	p.trackStatesMu.Lock()
+	defer p.trackStatesMu.Unlock()
+	if p.trackStates == nil {
+		p.trackStates = make(map[*model.Track]TrackState)
+	}
+	p.trackStates[track] = state
+}
+
+func (p *albumProgress) trackState(track *model.Track) TrackState {
+	p.trackStatesMu.Lock()
+	defer p.trackStatesMu.Unlock()
+	return p.trackStates[track]
+}
+
+// copyAlbum returns a copy of album and its tracks, whose tracks point to
+// the copy.
+func copyAlbum(album *model.Album) *model.Album {
+	c := *album
+	c.Tags = slices.Clone(album.Tags)
+	c.SkippedVideos = slices.Clone(album.SkippedVideos)
+	c.UnavailableTracks = slices.Clone(album.UnavailableTracks)
+	c.Restrictions = slices.Clone(album.Restrictions)
+	c.Tracks = make([]*model.Track, len(album.Tracks))
+	for i, track := range album.Tracks {
+		t := *track
+		t.Album = &c
+		c.Tracks[i] = &t
+	}
+	return &c
+}
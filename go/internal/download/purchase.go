@@ -0,0 +1,69 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// downloadPurchase is the first default PostProcess stage: with the
+// DownloadFormat setting, it replaces the streams of a completed album
+// bought by the account of the cookies_file setting with the files of its
+// download page in that format. Albums the account did not buy keep their
+// streams, and so do the tracks of earlier runs already replaced.
+func (m *Manager) downloadPurchase(ctx context.Context, album *model.Album, state AlbumState) error {
+	format := m.albumSettings(album).DownloadFormat
+	if format == "" || state != AlbumCompleted {
+		return nil
+	}
+
+	var streams []VerifyIssue
+	for _, track := range album.Tracks {
+		if !strings.EqualFold(filepath.Ext(track.Path), ".mp3") {
+			continue
+		}
+		if kbps, err := audio.ReadMP3Bitrate(track.Path); err == nil && kbps <= model.StreamBitrate {
+			streams = append(streams, VerifyIssue{Kind: IssueLowQuality, Album: album, Track: track, Path: track.Path})
+		}
+	}
+	if len(streams) == 0 {
+		return nil
+	}
+
+	purchases, err := m.accountPurchases(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the purchases of the account for download_format: %w", err)
+	}
+	purchase := findPurchase(purchases, album)
+	if purchase == nil || purchase.DownloadPageURL == "" {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Keeping the streams of %s - %s: not bought by the account", album.Artist, album.Title), Level: LevelVerbose})
+		return nil
+	}
+	_, err = m.upgradeAlbum(ctx, album, streams, purchase.DownloadPageURL, format, nil)
+	return err
+}
+
+// accountPurchases returns the purchases of the account of the
+// cookies_file setting, read once per run.
+func (m *Manager) accountPurchases(ctx context.Context) ([]bandcamp.Purchase, error) {
+	if m.settings.CookiesFile == "" {
+		return nil, errors.New("set cookies_file to the cookies of the Bandcamp account that bought the releases")
+	}
+
+	m.purchasesMu.Lock()
+	defer m.purchasesMu.Unlock()
+	if m.purchases == nil {
+		purchases, err := bandcamp.NewCollection(m.httpClient, "").Purchases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.purchases = &purchases
+	}
+	return *m.purchases, nil
+}
@@ -0,0 +1,93 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestDownloadPurchase(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "download.zip")
+	writeZip(t, zipPath, map[string]string{"Artist - Bought - 01 Song.flac": "flac"})
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests to bandcamp.com go through the proxy, the test server
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/download":
+			blob, _ := json.Marshal(map[string]any{"digital_items": []map[string]any{{
+				"title": "Bought", "artist": "Artist", "type": "a",
+				"downloads": map[string]any{"flac": map[string]string{"url": "http://bandcamp.com/download/album?id=1", "size_mb": "1MB"}},
+			}}})
+			w.Write([]byte(`<div id="pagedata" data-blob="` + html.EscapeString(string(blob)) + `"></div>`))
+		case "/statdownload/album":
+			w.Write([]byte(`{"result": "ok"}`))
+		case "/download/album":
+			w.Write(data)
+		default:
+			nethttp.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{album}")
+	settings.ModifyTags = false
+	settings.CookiesFile = filepath.Join(t.TempDir(), "cookies.txt")
+	settings.DownloadFormat = "flac"
+	settings.ProxyType = "manual"
+	settings.Proxies = []string{server.URL}
+	m := NewManager(settings, nil)
+	m.purchases = &[]bandcamp.Purchase{{ItemType: "album", ItemID: 1, URL: "https://artist.bandcamp.com/album/bought", DownloadPageURL: "http://bandcamp.com/download?id=1"}}
+
+	// A 128 kbps MP3 frame, the quality of the streams
+	stream := append([]byte{0xff, 0xfb, 0x90, 0x00}, make([]byte, 16000-4)...)
+	trackCfg := settings.ToTrackConfig()
+	newAlbum := func(id int64, title string) *model.Album {
+		album := model.NewAlbum("Artist", title, "", time.Now(), settings.ToPathConfig())
+		album.ID, album.URL = id, "https://artist.bandcamp.com/album/"+strings.ToLower(title)
+		album.Tracks = []*model.Track{model.NewTrack(album, 1, 1, "Song", 1, "", "", trackCfg)}
+		if err := os.MkdirAll(album.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(album.Tracks[0].Path, stream, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return album
+	}
+
+	bought, other := newAlbum(1, "Bought"), newAlbum(2, "Other")
+	for _, album := range []*model.Album{bought, other} {
+		if err := m.downloadPurchase(context.Background(), album, AlbumCompleted); err != nil {
+			t.Fatalf("%s: downloadPurchase failed: %v", album.Title, err)
+		}
+	}
+
+	track := bought.Tracks[0]
+	if filepath.Ext(track.Path) != ".flac" {
+		t.Fatalf("bought track at %s, want the FLAC of the purchase", track.Path)
+	}
+	if got, err := os.ReadFile(track.Path); err != nil || string(got) != "flac" {
+		t.Errorf("ReadFile(%s) = %q, %v, want the download", track.Path, got, err)
+	}
+	if _, err := os.Stat(filepath.Join(bought.Path, "01 Artist - Song.mp3")); !os.IsNotExist(err) {
+		t.Errorf("the stream of the bought track was kept: %v", err)
+	}
+	if path := other.Tracks[0].Path; filepath.Ext(path) != ".mp3" {
+		t.Errorf("track not bought at %s, want its stream", path)
+	}
+}
@@ -0,0 +1,183 @@
+package download
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	ioutils "github.com/handiism/bandcamp-downloader/internal/io"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// RedeemResult is the release downloaded by Redeem.
+type RedeemResult struct {
+	Artist string
+	Title  string
+	Format string
+
+	// Path is the folder of the album, its zip archive, or the file of
+	// the track.
+	Path string
+
+	// Files counts the files extracted from the zip of an album.
+	Files int
+}
+
+// Redeem downloads the release of input, a download code, the URL of its
+// redemption page (bandcamp.com/yum?code=...) or a download page URL, in
+// format (see bandcamp.DownloadFormats), to its album folder. Codes are
+// redeemed to the account whose session is in the cookies_file setting.
+//
+// The zip of an album is extracted in its folder and removed, unless
+// AlbumArchive keeps it: "zip_keep" keeps both, and "zip" keeps only the
+// zip, next to where the folder would be. A folder that existed before is
+// left in place.
+func (m *Manager) Redeem(ctx context.Context, input, format string) (*RedeemResult, error) {
+	pageURL := strings.TrimSpace(input)
+	if !bandcamp.IsDownloadPageURL(pageURL) {
+		code, ok := bandcamp.CodeFromInput(input)
+		if !ok {
+			return nil, fmt.Errorf("%q is neither a download code nor a download page URL", input)
+		}
+		var err error
+		if pageURL, err = bandcamp.NewRedeemer(m.httpClient, "").Redeem(ctx, code); err != nil {
+			return nil, err
+		}
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Redeemed code %s", code), Level: LevelVerbose})
+	}
+
+	page, err := m.httpClient.GetPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	release, err := bandcamp.ReadDownloadPage(page.HTML)
+	if err != nil {
+		return nil, err
+	}
+	download, ok := release.Formats[format]
+	if !ok {
+		return nil, fmt.Errorf("%s is not offered in %s, only in %s", release.Title, format, strings.Join(release.FormatNames(), ", "))
+	}
+	fileURL, err := bandcamp.PrepareDownload(ctx, m.httpClient, download.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The download page has no release date: {year} and the like are
+	// left empty
+	album := &model.Album{Artist: release.Artist, Title: release.Title, Single: release.ItemType == "track"}
+	album.ComputePaths(m.settings.ToPathConfig())
+	_, err = os.Stat(album.Path)
+	created := errors.Is(err, fs.ErrNotExist)
+	if err := os.MkdirAll(album.Path, 0o755); err != nil {
+		return nil, err
+	}
+	fileName := ioutils.SanitizeFileName(release.Artist+" - "+release.Title) + release.Extension(format)
+	dest := filepath.Join(album.Path, fileName)
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Downloading %s - %s in %s (%s)", release.Artist, release.Title, format, download.Size), Level: LevelInfo})
+	if err := m.httpClient.DownloadFile(ctx, fileURL, dest, nil); err != nil {
+		return nil, err
+	}
+	result := &RedeemResult{Artist: release.Artist, Title: release.Title, Format: format, Path: dest}
+	if release.ItemType == "track" {
+		return result, nil
+	}
+
+	if m.settings.AlbumArchive == archiveZip {
+		// The zip replaces the folder, as for the albums archived, unless
+		// the folder was there before
+		result.Path = archivePath(album)
+		if err := os.Rename(dest, result.Path); err != nil {
+			return nil, err
+		}
+		if !created {
+			return result, nil
+		}
+		return result, os.Remove(album.Path)
+	}
+	if result.Files, err = extractZip(dest, album.Path); err != nil {
+		return nil, fmt.Errorf("extracting %s: %w", dest, err)
+	}
+	result.Path = album.Path
+	if m.settings.AlbumArchive == archiveZipKeep {
+		return result, os.Rename(dest, archivePath(album))
+	}
+	return result, os.Remove(dest)
+}
+
+// extractZip extracts the files of the zip at zipPath into dir, and returns
+// how many there are. The zips of albums have no folders; the folders of
+// their entries, if any, are dropped, so no file is written outside dir.
+// Entries whose names are then the same, ignoring case, would overwrite
+// each other: the archive is not extracted.
+func extractZip(zipPath, dir string) (int, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	seen := make(map[string]string) // entry names by lower-case file name
+	var entries []*zip.File
+	var names []string
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := filepath.Base(filepath.FromSlash(entry.Name))
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			continue
+		}
+		if other, ok := seen[strings.ToLower(name)]; ok {
+			return 0, fmt.Errorf("the archive has two files named %s: %s and %s", name, other, entry.Name)
+		}
+		seen[strings.ToLower(name)] = entry.Name
+		entries = append(entries, entry)
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return 0, errors.New("the archive has no file")
+	}
+
+	for i, name := range names {
+		if err := extractZipFile(entries[i], filepath.Join(dir, name)); err != nil {
+			return i, err
+		}
+	}
+	return len(names), nil
+}
+
+// extractZipFile writes the file of entry to path.
+func extractZipFile(entry *zip.File, path string) error {
+	r, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	modified := entry.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	return os.Chtimes(path, modified, modified)
+}
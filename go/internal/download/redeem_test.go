@@ -0,0 +1,141 @@
+package download
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"html"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+)
+
+// writeZip writes a zip of files, by name, to path. Names ending in "/"
+// are folders.
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "Various - Night.zip")
+	writeZip(t, zipPath, map[string]string{
+		"Various - Night - 01 Dusk.flac": "dusk",
+		"cover.jpg":                      "cover",
+		"../../escape.txt":               "escape",
+		"folder/":                        "",
+	})
+
+	albumDir := filepath.Join(dir, "Night")
+	if err := os.Mkdir(albumDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := extractZip(zipPath, albumDir)
+	if err != nil || files != 3 {
+		t.Fatalf("extractZip = %d, %v, want 3 files", files, err)
+	}
+	entries, _ := os.ReadDir(albumDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"Various - Night - 01 Dusk.flac", "cover.jpg", "escape.txt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("extracted %v, want %v", names, want)
+	}
+
+	// Entries of the same name in different folders would overwrite
+	// each other
+	writeZip(t, zipPath, map[string]string{"a/cover.jpg": "a", "b/Cover.jpg": "b"})
+	dupDir := filepath.Join(dir, "Duplicates")
+	if err := os.Mkdir(dupDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := extractZip(zipPath, dupDir); err == nil {
+		t.Errorf("extractZip of duplicate names = %d files, want an error", files)
+	}
+	if entries, _ := os.ReadDir(dupDir); len(entries) != 0 {
+		t.Errorf("extracted %d files of an archive with duplicate names, want none", len(entries))
+	}
+}
+
+func TestRedeem_ArchiveKeepsFolder(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "download.zip")
+	writeZip(t, zipPath, map[string]string{"Artist - Album - 01 Song.flac": "song"})
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests to bandcamp.com go through the proxy, the test server
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/download":
+			blob, _ := json.Marshal(map[string]any{"digital_items": []map[string]any{{
+				"title": "Album", "artist": "Artist", "type": "a",
+				"downloads": map[string]any{"flac": map[string]string{"url": "http://bandcamp.com/download/album?id=1", "size_mb": "1MB"}},
+			}}})
+			w.Write([]byte(`<div id="pagedata" data-blob="` + html.EscapeString(string(blob)) + `"></div>`))
+		case "/statdownload/album":
+			w.Write([]byte(`{"result": "ok"}`))
+		case "/download/album":
+			w.Write(data)
+		default:
+			nethttp.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, existing := range []bool{false, true} {
+		settings := config.DefaultSettings()
+		settings.DownloadsPath = filepath.Join(t.TempDir(), "{artist}", "{album}")
+		settings.AlbumArchive = archiveZip
+		settings.ProxyType = "manual"
+		settings.Proxies = []string{server.URL}
+		folder := filepath.Join(filepath.Dir(filepath.Dir(settings.DownloadsPath)), "Artist", "Album")
+		if existing {
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(folder, "notes.txt"), []byte("notes"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := NewManager(settings, nil).Redeem(context.Background(), "http://bandcamp.com/download?id=1", "flac")
+		if err != nil {
+			t.Fatalf("existing folder %v: Redeem failed: %v", existing, err)
+		}
+		if result.Path != folder+".zip" {
+			t.Errorf("existing folder %v: Path = %s, want %s.zip", existing, result.Path, folder)
+		}
+		_, err = os.Stat(folder)
+		if existing && err != nil {
+			t.Errorf("the existing folder was removed: %v", err)
+		}
+		if !existing && err == nil {
+			t.Error("the folder created for the download was kept")
+		}
+	}
+}
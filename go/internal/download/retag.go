@@ -0,0 +1,155 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/handiism/bandcamp-downloader/internal/library"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// libraryAlbum groups the local files of one release found in a library,
+// keyed by track number.
+type libraryAlbum struct {
+	url   string
+	files map[int]string
+}
+
+// Retag rewrites the tags and embedded artwork of already-downloaded tracks
+// without downloading the audio again.
+//
+// Albums are taken from inputURLs and, if libraryPath is not empty, from the
+// Bandcamp URLs embedded in the MP3 files found under libraryPath. For each
+// album, the metadata is re-fetched from Bandcamp and every track is matched
+// to a local file either by its computed path or, failing that, by the
+// embedded album URL and track number.
+//
+// Returns the number of files that were retagged.
+func (m *Manager) Retag(ctx context.Context, inputURLs, libraryPath string) (int, error) {
+	if !m.running.CompareAndSwap(false, true) {
+		return 0, ErrRunning
+	}
+	defer m.running.Store(false)
+
+	var library map[string]*libraryAlbum
+	if libraryPath != "" {
+		var err error
+		library, err = m.scanLibrary(ctx, libraryPath)
+		if err != nil {
+			return 0, err
+		}
+		for _, la := range library {
+			inputURLs += "\n" + la.url
+		}
+	}
+
+	m.reset()
+	m.fetchAlbums(ctx, inputURLs)
+	m.publish()
+
+	var retagged int
+	for _, album := range m.albums {
+		if ctx.Err() != nil {
+			return retagged, ctx.Err()
+		}
+		retagged += m.retagAlbum(ctx, album, library[normalizeURL(album.URL)])
+	}
+
+	return retagged, nil
+}
+
+// scanLibrary walks root and indexes the MP3 files tagged with a Bandcamp URL.
+func (m *Manager) scanLibrary(ctx context.Context, root string) (map[string]*libraryAlbum, error) {
+	albums, err := library.Scan(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*libraryAlbum)
+	for _, album := range albums {
+		if album.BandcampURL == "" {
+			continue
+		}
+
+		key := normalizeURL(album.BandcampURL)
+		la, ok := index[key]
+		if !ok {
+			la = &libraryAlbum{url: album.BandcampURL, files: make(map[int]string)}
+			index[key] = la
+		}
+		for _, track := range album.Tracks {
+			if track.Number > 0 {
+				la.files[track.Number] = track.Path
+			}
+		}
+	}
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Found %d album(s) in library %s", len(index), root), Level: LevelInfo})
+	return index, nil
+}
+
+// retagAlbum rewrites the tags of every local file matching a track of album
+// and returns how many files were retagged.
+func (m *Manager) retagAlbum(ctx context.Context, album *model.Album, local *libraryAlbum) int {
+	ap := m.albumProgress[album]
+	ap.setState(AlbumDownloading)
+
+	var artwork []byte
+	if m.settings.SaveCoverArtInTags && album.HasArtwork() {
+		var err error
+		artwork, _, err = m.downloadArtwork(ctx, album)
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading artwork for %s: %v", album.Title, err), Level: LevelWarning})
+		}
+	}
+
+	if !m.settings.ModifyTags && !m.settings.StripID3v1 && artwork == nil {
+		ap.setState(AlbumCompleted)
+		return 0
+	}
+
+	var retagged, failed int
+	for _, track := range album.Tracks {
+		path := m.findLocalTrack(track, album, local)
+		if path == "" {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Not found locally: %s", filepath.Base(track.Path)), Level: LevelVerbose})
+			continue
+		}
+		track.Path = path
+
+		if err := m.tagger.SaveTags(track, album, artwork, m.extraPicture(ctx, album)...); err != nil {
+			failed++
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error tagging %s: %v", track.Title, err), Level: LevelWarning})
+			continue
+		}
+		retagged++
+		m.addDownloadedFile(album)
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Retagged: %s", filepath.Base(path)), Level: LevelVerbose})
+	}
+
+	switch {
+	case failed > 0 && retagged == 0:
+		ap.setState(AlbumFailed)
+	case failed > 0:
+		ap.setState(AlbumPartial)
+	default:
+		ap.setState(AlbumCompleted)
+	}
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Retagged %d/%d tracks of %s - %s", retagged, len(album.Tracks), album.Artist, album.Title), Level: LevelSuccess})
+	return retagged
+}
+
+// findLocalTrack returns the path of the local file for track, trying the
+// computed path first (see locateTrackFile) and then the library index.
+// Returns "" if none exists.
+func (m *Manager) findLocalTrack(track *model.Track, album *model.Album, local *libraryAlbum) string {
+	if m.locateTrackFile(track, album) {
+		return track.Path
+	}
+	if local != nil {
+		return local.files[track.Number]
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package download
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+// sampleURLs returns SampleAlbums of the release URLs of a discography,
+// picked at random, or all of them if there are not more. The sample only
+// depends on SampleSeed when it is not 0; otherwise the seed drawn is
+// reported, so that a sample can be picked again.
+func (m *Manager) sampleURLs(urls []string) []string {
+	n := m.settings.SampleAlbums
+	if n <= 0 || len(urls) <= n {
+		return urls
+	}
+
+	seed := m.settings.SampleSeed
+	if seed == 0 {
+		seed = rand.Int64()
+	}
+	// Discography pages list releases in no stable order
+	sampled := slices.Clone(urls)
+	slices.Sort(sampled)
+	r := rand.New(rand.NewPCG(uint64(seed), 0))
+	r.Shuffle(len(sampled), func(i, j int) {
+		sampled[i], sampled[j] = sampled[j], sampled[i]
+	})
+	sampled = sampled[:n]
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Sampling %d of %d releases (seed %d)", n, len(urls), seed), Level: LevelInfo})
+	return sampled
+}
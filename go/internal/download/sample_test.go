@@ -0,0 +1,42 @@
+package download
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+)
+
+func TestSampleURLs(t *testing.T) {
+	urls := []string{"/album/a", "/album/b", "/album/c", "/album/d", "/album/e", "/track/f"}
+	newManager := func(n int, seed int64) *Manager {
+		settings := config.DefaultSettings()
+		settings.SampleAlbums = n
+		settings.SampleSeed = seed
+		return NewManager(settings, nil)
+	}
+
+	if got := newManager(0, 0).sampleURLs(urls); !slices.Equal(got, urls) {
+		t.Errorf("sampleURLs without sample = %q, want all", got)
+	}
+	if got := newManager(10, 0).sampleURLs(urls); !slices.Equal(got, urls) {
+		t.Errorf("sampleURLs above the count = %q, want all", got)
+	}
+
+	got := newManager(3, 42).sampleURLs(urls)
+	if len(got) != 3 {
+		t.Fatalf("sampleURLs = %q, want 3 URLs", got)
+	}
+	for _, u := range got {
+		if !slices.Contains(urls, u) {
+			t.Errorf("sampled %q, which is not a release", u)
+		}
+	}
+
+	// The same seed picks the same sample, whatever the page order
+	reversed := slices.Clone(urls)
+	slices.Reverse(reversed)
+	if again := newManager(3, 42).sampleURLs(reversed); !slices.Equal(again, got) {
+		t.Errorf("sampleURLs with the same seed = %q, want %q", again, got)
+	}
+}
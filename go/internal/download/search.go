@@ -0,0 +1,14 @@
+package download
+
+import (
+	"context"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+)
+
+// Search finds the artists, albums or tracks of kind named like query on
+// Bandcamp, with the network settings of the Manager. The URLs of the
+// results can be given to Initialize.
+func (m *Manager) Search(ctx context.Context, query string, kind bandcamp.SearchKind) ([]bandcamp.SearchResult, error) {
+	return bandcamp.NewSearcher(m.httpClient, "").Search(ctx, query, kind)
+}
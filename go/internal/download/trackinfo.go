@@ -0,0 +1,151 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// trackInfoReadmeName is the file name of the per-album liner notes
+// written with SaveTrackInfo set to "readme".
+const trackInfoReadmeName = "README.txt"
+
+// saveTrackInfo fetches the descriptions and credits of the album's tracks
+// and saves them according to settings.SaveTrackInfo: one <track>.txt file
+// per track ("sidecar"), or a single README.txt in the album folder that
+// also holds the album's own description and credits ("readme").
+//
+// Track descriptions are only on the track pages, so one page is fetched
+// per track that has any (see model.Track.HasInfo).
+func (m *Manager) saveTrackInfo(ctx context.Context, album *model.Album) {
+	mode := m.albumSettings(album).SaveTrackInfo
+	if mode != "sidecar" && mode != "readme" {
+		return
+	}
+
+	for _, track := range album.Tracks {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := m.fetchTrackInfo(ctx, album, track); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error fetching info of %s: %v", track.Title, err), Level: LevelWarning})
+		}
+	}
+
+	if mode == "sidecar" {
+		for _, track := range album.Tracks {
+			text := formatTrackInfo(track)
+			if text == "" {
+				continue
+			}
+			path := strings.TrimSuffix(track.Path, filepath.Ext(track.Path)) + ".txt"
+			if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Error saving info of %s: %v", track.Title, err), Level: LevelWarning})
+			}
+		}
+		return
+	}
+
+	text := formatAlbumReadme(album)
+	if text == "" {
+		return
+	}
+	if err := os.WriteFile(filepath.Join(album.Path, trackInfoReadmeName), []byte(text), 0644); err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error saving liner notes of %s: %v", album.Title, err), Level: LevelWarning})
+		return
+	}
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Saved liner notes for %s", album.Title), Level: LevelVerbose})
+}
+
+// fetchTrackInfo fills the track's About and Credits from its page, if the
+// album page reports it has any.
+func (m *Manager) fetchTrackInfo(ctx context.Context, album *model.Album, track *model.Track) error {
+	if !track.HasInfo || track.URL == "" || track.About != "" || track.Credits != "" {
+		return nil
+	}
+
+	base, err := url.Parse(album.URL)
+	if err != nil {
+		return err
+	}
+	trackURL, err := resolveURL(base, track.URL)
+	if err != nil {
+		return err
+	}
+
+	html, err := m.httpClient.GetString(ctx, trackURL)
+	if err != nil {
+		return err
+	}
+
+	// A track page describes a one-track release whose About and Credits
+	// are the track's own
+	albumPage, err := m.readAlbumPage(ctx, trackURL, html)
+	if err != nil {
+		return err
+	}
+	page := m.parser.ToAlbum(albumPage)
+	track.About = page.About
+	track.Credits = page.Credits
+	if len(page.Tracks) == 1 {
+		if t := page.Tracks[0]; t.Composer != "" || t.ISRC != "" {
+			track.Composer, track.ISRC = t.Composer, t.ISRC
+		}
+	}
+	return nil
+}
+
+// formatTrackInfo returns the sidecar text of a track, or "" if the track
+// has neither a description nor credits.
+func formatTrackInfo(track *model.Track) string {
+	if track.About == "" && track.Credits == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s - %s\n", track.ArtistName(), track.Title)
+	writeInfoSections(&sb, track.About, track.Credits)
+	return sb.String()
+}
+
+// formatAlbumReadme returns the README text of an album: its own
+// description and credits followed by those of each track. Returns "" if
+// there is nothing to write.
+func formatAlbumReadme(album *model.Album) string {
+	var tracks strings.Builder
+	for _, track := range album.Tracks {
+		if track.About == "" && track.Credits == "" {
+			continue
+		}
+		fmt.Fprintf(&tracks, "\n%02d. %s\n", track.Number, track.Title)
+		writeInfoSections(&tracks, track.About, track.Credits)
+	}
+
+	if album.About == "" && album.Credits == "" && tracks.Len() == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s - %s\n", album.Artist, album.Title)
+	if album.URL != "" {
+		fmt.Fprintf(&sb, "%s\n", album.URL)
+	}
+	writeInfoSections(&sb, album.About, album.Credits)
+	sb.WriteString(tracks.String())
+	return sb.String()
+}
+
+// writeInfoSections writes the non-empty description and credits.
+func writeInfoSections(sb *strings.Builder, about, credits string) {
+	if about != "" {
+		fmt.Fprintf(sb, "\n%s\n", about)
+	}
+	if credits != "" {
+		fmt.Fprintf(sb, "\nCredits:\n%s\n", credits)
+	}
+}
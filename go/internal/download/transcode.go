@@ -0,0 +1,72 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// transcodeAlbum is the PostProcess stage after downloadPurchase: with the
+// TranscodeFormat setting, it converts the tracks of a completed album to
+// that format, replacing their files, so the playlists and archives made
+// next list the converted ones.
+func (m *Manager) transcodeAlbum(ctx context.Context, album *model.Album, state AlbumState) error {
+	settings := m.albumSettings(album)
+	ext := audio.TranscodeExtension(settings.TranscodeFormat)
+	if ext == "" || state != AlbumCompleted {
+		return nil
+	}
+
+	for _, track := range album.Tracks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if strings.EqualFold(track.Extension(), ext) {
+			continue
+		}
+		if err := m.transcodeTrack(ctx, track, album, ext); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error transcoding %s: %v", filepath.Base(track.Path), err), Level: LevelWarning})
+		}
+	}
+	return nil
+}
+
+// transcodeTrack converts the file of track to the TranscodeFormat of
+// album, whose files have the extension ext, keeping its name but for the
+// extension. The file is converted to a temporary file next to it, which
+// replaces it once complete.
+func (m *Manager) transcodeTrack(ctx context.Context, track *model.Track, album *model.Album, ext string) error {
+	settings := m.albumSettings(album)
+	base := strings.TrimSuffix(track.Path, filepath.Ext(track.Path))
+	path := base + "." + ext
+	tmp := base + ".transcode." + ext
+
+	if err := audio.Transcode(ctx, settings.TranscodeCommand, track.Path, tmp, settings.TranscodeFormat, settings.TranscodeBitrate); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Remove(track.Path); err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error deleting %s: %v", track.Path, err), Level: LevelWarning})
+	}
+	track.Path, track.Ext = path, ext
+
+	// The tags copied by the command are rewritten in the tag format of
+	// the file, if the Tagger has one
+	if audio.CanTag(ext) && settings.ModifyTags {
+		if err := m.albumTagger(album).SaveTags(track, album, nil); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error tagging %s: %v", track.Title, err), Level: LevelWarning})
+		}
+	}
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Transcoded: %s (%s)", filepath.Base(path), settings.TranscodeFormat), Level: LevelVerbose})
+	return nil
+}
@@ -0,0 +1,77 @@
+package download
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestTranscodeAlbum(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake transcoder is a shell script")
+	}
+	// The fake ffmpeg copies its input (-i) to its last argument
+	command := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\nwhile [ $# -gt 1 ]; do [ \"$1\" = -i ] && in=$2; shift; done\ncp \"$in\" \"$1\"\n"
+	if err := os.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = filepath.Join(t.TempDir(), "{album}")
+	settings.ModifyTags = false
+	settings.TranscodeFormat = "opus"
+	settings.TranscodeBitrate = 96
+	settings.TranscodeCommand = command
+	m := NewManager(settings, nil)
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), settings.ToPathConfig())
+	trackCfg := settings.ToTrackConfig()
+	for i, title := range []string{"One", "Two"} {
+		track := model.NewTrack(album, i+1, i+1, title, 180, "", "", trackCfg)
+		album.Tracks = append(album.Tracks, track)
+		if err := os.MkdirAll(album.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(track.Path, []byte(title), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Partial albums are left as they are
+	if err := m.transcodeAlbum(context.Background(), album, AlbumPartial); err != nil {
+		t.Fatalf("transcodeAlbum(partial) failed: %v", err)
+	}
+	if ext := album.Tracks[0].Extension(); ext != "mp3" {
+		t.Errorf("track of a partial album transcoded to %s", ext)
+	}
+
+	if err := m.transcodeAlbum(context.Background(), album, AlbumCompleted); err != nil {
+		t.Fatalf("transcodeAlbum failed: %v", err)
+	}
+	for _, track := range album.Tracks {
+		if filepath.Ext(track.Path) != ".opus" || track.Extension() != "opus" {
+			t.Errorf("%s: track at %s (%s), want the .opus file", track.Title, track.Path, track.Extension())
+			continue
+		}
+		if got, err := os.ReadFile(track.Path); err != nil || string(got) != track.Title {
+			t.Errorf("%s: ReadFile = %q, %v, want the transcoded file", track.Title, got, err)
+		}
+		if _, err := os.Stat(strings.TrimSuffix(track.Path, ".opus") + ".mp3"); !os.IsNotExist(err) {
+			t.Errorf("%s: the original file was kept: %v", track.Title, err)
+		}
+
+		// The next run finds the converted file
+		next := model.NewTrack(album, track.Number, track.Number, track.Title, 180, "", "", trackCfg)
+		if ok, _ := m.existingTrack(context.Background(), next, album); !ok || next.Path != track.Path {
+			t.Errorf("%s: existingTrack = %v at %s, want the converted file", track.Title, ok, next.Path)
+		}
+	}
+}
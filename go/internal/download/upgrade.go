@@ -0,0 +1,216 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// defaultUpgradeFormat is the format tracks are upgraded to without
+// settings.UpgradeFormat.
+const defaultUpgradeFormat = "mp3-320"
+
+// Upgrade is a low-quality track replaced by Fix with the download of its
+// purchase.
+type Upgrade struct {
+	Album *model.Album
+	Track *model.Track
+
+	// OldPath is the path of the replaced file, and Path that of the
+	// upgraded one: the same one for the MP3 formats, with the extension
+	// of Format for the others.
+	OldPath string
+	Path    string
+	Format  string
+}
+
+// zipTrackNumberRegex matches the track number of the files of the zips
+// of albums, named "Artist - Album - 01 Title.flac".
+var zipTrackNumberRegex = regexp.MustCompile(` - (\d+) `)
+
+// zipTrackNumber returns the track number of the file name of an album's
+// zip, or 0 if it has none, e.g. for the cover.
+func zipTrackNumber(name string) int {
+	match := zipTrackNumberRegex.FindStringSubmatch(name)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
+
+// upgradeTracks replaces the files of the IssueLowQuality issues with the
+// downloads of the releases bought by the account of the cookies_file
+// setting, in UpgradeFormat, and returns the tracks upgraded. Tracks of
+// releases the account did not buy are left as they are.
+func (m *Manager) upgradeTracks(ctx context.Context, issues []VerifyIssue, artworks map[*model.Album][]byte) []Upgrade {
+	if len(issues) == 0 {
+		return nil
+	}
+	if m.settings.CookiesFile == "" {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("%d low-quality track(s) not upgraded: set cookies_file to the cookies of the Bandcamp account that bought them", len(issues)), Level: LevelWarning})
+		return nil
+	}
+	format := m.settings.UpgradeFormat
+	if format == "" {
+		format = defaultUpgradeFormat
+	}
+	purchases, err := bandcamp.NewCollection(m.httpClient, "").Purchases(ctx)
+	if err != nil {
+		m.progress(ProgressEvent{Message: fmt.Sprintf("Error reading the purchases of the account, %d low-quality track(s) not upgraded: %v", len(issues), err), Level: LevelError})
+		return nil
+	}
+
+	var albums []*model.Album
+	byAlbum := make(map[*model.Album][]VerifyIssue)
+	for _, issue := range issues {
+		if _, ok := byAlbum[issue.Album]; !ok {
+			albums = append(albums, issue.Album)
+		}
+		byAlbum[issue.Album] = append(byAlbum[issue.Album], issue)
+	}
+
+	var upgrades []Upgrade
+	for _, album := range albums {
+		if ctx.Err() != nil {
+			break
+		}
+		purchase := findPurchase(purchases, album)
+		if purchase == nil || purchase.DownloadPageURL == "" {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Not upgrading %s - %s: not bought by the account", album.Artist, album.Title), Level: LevelWarning})
+			continue
+		}
+		upgraded, err := m.upgradeAlbum(ctx, album, byAlbum[album], purchase.DownloadPageURL, format, artworks[album])
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error upgrading %s - %s: %v", album.Artist, album.Title, err), Level: LevelError})
+		}
+		upgrades = append(upgrades, upgraded...)
+	}
+	return upgrades
+}
+
+// findPurchase returns the purchase of album, matched by ID or URL, or nil
+// if the account did not buy it.
+func findPurchase(purchases []bandcamp.Purchase, album *model.Album) *bandcamp.Purchase {
+	itemType := "album"
+	if album.Single {
+		itemType = "track"
+	}
+	for i, p := range purchases {
+		if (album.ID != 0 && p.ItemID == album.ID && p.ItemType == itemType) || normalizeURL(p.URL) == normalizeURL(album.URL) {
+			return &purchases[i]
+		}
+	}
+	return nil
+}
+
+// upgradeAlbum downloads the release of album in format from its download
+// page at pageURL, and replaces the files of the issues' tracks with those
+// of the download. The download is extracted next to the files, so they
+// are replaced by renaming.
+func (m *Manager) upgradeAlbum(ctx context.Context, album *model.Album, issues []VerifyIssue, pageURL, format string, artwork []byte) ([]Upgrade, error) {
+	page, err := m.httpClient.GetPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	release, err := bandcamp.ReadDownloadPage(page.HTML)
+	if err != nil {
+		return nil, err
+	}
+	download, ok := release.Formats[format]
+	if !ok {
+		return nil, fmt.Errorf("not offered in %s, only in %s", format, strings.Join(release.FormatNames(), ", "))
+	}
+	fileURL, err := bandcamp.PrepareDownload(ctx, m.httpClient, download.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(issues[0].Path), ".upgrade-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	dest := filepath.Join(tmp, "download"+release.Extension(format))
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Downloading %s - %s in %s (%s) to upgrade %d track(s)", album.Artist, album.Title, format, download.Size, len(issues)), Level: LevelInfo})
+	if err := m.httpClient.DownloadFile(ctx, fileURL, dest, nil); err != nil {
+		return nil, err
+	}
+
+	// The download of a track is its file; those of an album are in its zip
+	files := make(map[int]string)
+	if release.ItemType == "track" {
+		for _, issue := range issues {
+			files[issue.Track.Number] = dest
+		}
+	} else {
+		dir := filepath.Join(tmp, "files")
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			return nil, err
+		}
+		if _, err := extractZip(dest, dir); err != nil {
+			return nil, fmt.Errorf("extracting the download: %w", err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if n := zipTrackNumber(entry.Name()); n > 0 {
+				files[n] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+
+	var upgrades []Upgrade
+	for _, issue := range issues {
+		src := files[issue.Track.Number]
+		if src == "" {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Not upgrading %s: no file of track %d in the download", issue.Path, issue.Track.Number), Level: LevelWarning})
+			continue
+		}
+		upgrade, err := m.replaceTrackFile(issue, src, format, artwork)
+		if err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error upgrading %s: %v", issue.Path, err), Level: LevelError})
+			continue
+		}
+		upgrades = append(upgrades, upgrade)
+	}
+	return upgrades, nil
+}
+
+// replaceTrackFile replaces the file of the issue's track with src, the
+// file of its download in format, keeping its name but for the extension,
+// and tags it as downloads are.
+func (m *Manager) replaceTrackFile(issue VerifyIssue, src, format string, artwork []byte) (Upgrade, error) {
+	ext := filepath.Ext(src)
+	path := strings.TrimSuffix(issue.Path, filepath.Ext(issue.Path)) + ext
+	if err := os.Rename(src, path); err != nil {
+		return Upgrade{}, err
+	}
+	if path != issue.Path {
+		if err := os.Remove(issue.Path); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error deleting %s: %v", issue.Path, err), Level: LevelWarning})
+		}
+	}
+
+	track, album := issue.Track, issue.Album
+	track.Path, track.Ext = path, strings.TrimPrefix(ext, ".")
+	settings := m.albumSettings(album)
+	if audio.CanTag(track.Extension()) && (settings.ModifyTags || settings.StripID3v1 || (settings.SaveCoverArtInTags && artwork != nil)) {
+		if err := m.albumTagger(album).SaveTags(track, album, artwork); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error tagging %s: %v", track.Title, err), Level: LevelWarning})
+		}
+	}
+
+	m.progress(ProgressEvent{Message: fmt.Sprintf("Upgraded: %s (%s)", filepath.Base(path), format), Level: LevelSuccess})
+	return Upgrade{Album: album, Track: track, OldPath: issue.Path, Path: path, Format: format}, nil
+}
@@ -0,0 +1,107 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestZipTrackNumber(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"Artist - Album - 01 Title.flac", 1},
+		{"Artist - Album - 12 Title - Remix.mp3", 12},
+		{"cover.jpg", 0},
+		{"Artist - Album.pdf", 0},
+	}
+	for _, tt := range tests {
+		if got := zipTrackNumber(tt.name); got != tt.want {
+			t.Errorf("zipTrackNumber(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindPurchase(t *testing.T) {
+	purchases := []bandcamp.Purchase{
+		{ItemType: "track", ItemID: 1, URL: "https://artist.bandcamp.com/track/single"},
+		{ItemType: "album", ItemID: 2, URL: "https://artist.bandcamp.com/album/other"},
+		{ItemType: "album", ItemID: 3, URL: "https://artist.bandcamp.com/album/name"},
+	}
+	tests := []struct {
+		name  string
+		album *model.Album
+		want  int64
+	}{
+		{"ID", &model.Album{ID: 2}, 2},
+		{"URL", &model.Album{URL: "https://artist.bandcamp.com/album/name?from=search"}, 3},
+		{"single", &model.Album{ID: 1, Single: true}, 1},
+		{"type", &model.Album{ID: 1}, 0},
+		{"not bought", &model.Album{ID: 4, URL: "https://artist.bandcamp.com/album/new"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findPurchase(purchases, tt.album)
+			switch {
+			case tt.want == 0 && got != nil:
+				t.Errorf("findPurchase = %+v, want nil", *got)
+			case tt.want != 0 && (got == nil || got.ItemID != tt.want):
+				t.Errorf("findPurchase = %+v, want item %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceTrackFile(t *testing.T) {
+	dir := t.TempDir()
+	settings := config.DefaultSettings()
+	settings.DownloadsPath = dir
+	settings.ModifyTags = false
+	m := NewManager(settings, nil)
+
+	album := model.NewAlbum("Artist", "Album", "", time.Now(), settings.ToPathConfig())
+	trackCfg := &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		format, src, want string
+	}{
+		{"mp3-320", "Artist - Album - 01 One.mp3", "01 One.mp3"},
+		{"flac", "Artist - Album - 02 Two.flac", "02 Two.flac"},
+	}
+	for i, tt := range tests {
+		track := model.NewTrack(album, i+1, i+1, []string{"One", "Two"}[i], 180, "", "", trackCfg)
+		oldPath := track.Path
+		write(oldPath, "128")
+		src := filepath.Join(t.TempDir(), tt.src)
+		write(src, tt.format)
+
+		issue := VerifyIssue{Kind: IssueLowQuality, Album: album, Track: track, Path: oldPath}
+		upgrade, err := m.replaceTrackFile(issue, src, tt.format, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		want := filepath.Join(filepath.Dir(oldPath), tt.want)
+		if upgrade.Path != want || upgrade.OldPath != oldPath || track.Path != want {
+			t.Errorf("%s: upgraded %s to %s (track at %s), want %s", tt.format, upgrade.OldPath, upgrade.Path, track.Path, want)
+		}
+		if data, err := os.ReadFile(want); err != nil || string(data) != tt.format {
+			t.Errorf("%s: %s = %q, %v, want the download", tt.format, want, data, err)
+		}
+		if want != oldPath {
+			if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+				t.Errorf("%s: old file %s not removed", tt.format, oldPath)
+			}
+		}
+	}
+}
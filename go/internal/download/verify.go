@@ -0,0 +1,225 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// IssueKind identifies the type of problem found by Verify.
+type IssueKind int
+
+const (
+	// IssueMissingTrack means a track of the album has no local file.
+	IssueMissingTrack IssueKind = iota
+
+	// IssueSizeMismatch means a local track's size differs from the
+	// Bandcamp stream by more than AllowedFileSizeDifference.
+	IssueSizeMismatch
+
+	// IssueMissingArtwork means the cover art file is missing from the
+	// album folder while SaveCoverArtInFolder is enabled.
+	IssueMissingArtwork
+
+	// IssueLowQuality means a local track is an MP3 of at most
+	// model.StreamBitrate kbps, reported with CheckQuality.
+	IssueLowQuality
+)
+
+// String returns a short description of the issue kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueMissingTrack:
+		return "missing track"
+	case IssueSizeMismatch:
+		return "size mismatch"
+	case IssueMissingArtwork:
+		return "missing artwork"
+	case IssueLowQuality:
+		return "low quality"
+	default:
+		return "unknown issue"
+	}
+}
+
+// VerifyIssue describes one problem found in a local album.
+type VerifyIssue struct {
+	// Kind is the type of problem.
+	Kind IssueKind
+
+	// Album is the album the problem belongs to.
+	Album *model.Album
+
+	// Track is the affected track, or nil for album-level issues.
+	Track *model.Track
+
+	// Path is the local path that is missing or mismatched.
+	Path string
+
+	// Detail is a human-readable explanation, e.g. the sizes compared.
+	Detail string
+}
+
+// Verify compares local files against the current Bandcamp metadata and
+// reports missing tracks, size mismatches and missing cover art, and with
+// settings.CheckQuality, the tracks at the quality of Bandcamp streams.
+//
+// Albums are taken from inputURLs and, if libraryPath is not empty, from the
+// Bandcamp URLs embedded in the MP3 files under libraryPath. Tracks are
+// matched to local files the same way as Retag.
+//
+// Use Fix to download the files reported by Verify.
+func (m *Manager) Verify(ctx context.Context, inputURLs, libraryPath string) ([]VerifyIssue, error) {
+	if !m.running.CompareAndSwap(false, true) {
+		return nil, ErrRunning
+	}
+	defer m.running.Store(false)
+
+	var library map[string]*libraryAlbum
+	if libraryPath != "" {
+		var err error
+		library, err = m.scanLibrary(ctx, libraryPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, la := range library {
+			inputURLs += "\n" + la.url
+		}
+	}
+
+	m.reset()
+	m.fetchAlbums(ctx, inputURLs)
+	m.publish()
+
+	var issues []VerifyIssue
+	for _, album := range m.albums {
+		if ctx.Err() != nil {
+			return issues, ctx.Err()
+		}
+		albumIssues := m.verifyAlbum(ctx, album, library[normalizeURL(album.URL)])
+		if len(albumIssues) == 0 {
+			m.albumProgress[album].setState(AlbumCompleted)
+			m.progress(ProgressEvent{Message: fmt.Sprintf("OK: %s - %s", album.Artist, album.Title), Level: LevelSuccess})
+		} else {
+			m.albumProgress[album].setState(AlbumPartial)
+			m.progress(ProgressEvent{Message: fmt.Sprintf("%d issue(s) in %s - %s", len(albumIssues), album.Artist, album.Title), Level: LevelWarning})
+		}
+		issues = append(issues, albumIssues...)
+	}
+
+	return issues, nil
+}
+
+// verifyAlbum checks the local files of one album.
+func (m *Manager) verifyAlbum(ctx context.Context, album *model.Album, local *libraryAlbum) []VerifyIssue {
+	var issues []VerifyIssue
+
+	for _, track := range album.Tracks {
+		path := m.findLocalTrack(track, album, local)
+		if path == "" {
+			issues = append(issues, VerifyIssue{Kind: IssueMissingTrack, Album: album, Track: track, Path: track.Path})
+			continue
+		}
+		track.Path = path
+
+		info, err := os.Stat(path)
+		if err != nil {
+			issues = append(issues, VerifyIssue{Kind: IssueMissingTrack, Album: album, Track: track, Path: path, Detail: err.Error()})
+			continue
+		}
+		if ok, expected := m.sizeMatches(ctx, info.Size(), m.streamURL(track)); !ok && expected > 0 {
+			issues = append(issues, VerifyIssue{
+				Kind:   IssueSizeMismatch,
+				Album:  album,
+				Track:  track,
+				Path:   path,
+				Detail: fmt.Sprintf("%d bytes, expected %d", info.Size(), expected),
+			})
+		}
+		if m.settings.CheckQuality && strings.EqualFold(filepath.Ext(path), ".mp3") {
+			if kbps, err := audio.ReadMP3Bitrate(path); err == nil && kbps <= model.StreamBitrate {
+				issues = append(issues, VerifyIssue{
+					Kind:   IssueLowQuality,
+					Album:  album,
+					Track:  track,
+					Path:   path,
+					Detail: fmt.Sprintf("%d kbps MP3", kbps),
+				})
+			}
+		}
+	}
+
+	if m.settings.SaveCoverArtInFolder && album.HasArtwork() {
+		if _, err := os.Stat(album.ArtworkPath); err != nil {
+			issues = append(issues, VerifyIssue{Kind: IssueMissingArtwork, Album: album, Path: album.ArtworkPath})
+		}
+	}
+
+	return issues
+}
+
+// Fix re-downloads the files reported by Verify. Tracks are downloaded to
+// the path they were found at (or expected at), and are tagged as usual.
+//
+// The streams are not of a higher quality than low-quality tracks: those
+// are upgraded from the downloads of the releases bought by the account
+// whose session is in the cookies_file setting, in the UpgradeFormat
+// setting, and returned. Those of other releases are left as they are.
+func (m *Manager) Fix(ctx context.Context, issues []VerifyIssue) ([]Upgrade, error) {
+	if !m.running.CompareAndSwap(false, true) {
+		return nil, ErrRunning
+	}
+	defer m.running.Store(false)
+
+	artworks := make(map[*model.Album][]byte)
+
+	var lowQuality []VerifyIssue
+	for _, issue := range issues {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if issue.Kind == IssueLowQuality {
+			lowQuality = append(lowQuality, issue)
+			continue
+		}
+
+		dir := filepath.Dir(issue.Path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			m.progress(ProgressEvent{Message: fmt.Sprintf("Error creating directory: %v", err), Level: LevelError})
+			continue
+		}
+
+		switch issue.Kind {
+		case IssueMissingArtwork:
+			artwork, _, err := m.downloadArtwork(ctx, issue.Album)
+			if err != nil {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading artwork for %s: %v", issue.Album.Title, err), Level: LevelError})
+				continue
+			}
+			artworks[issue.Album] = artwork
+
+		case IssueMissingTrack, IssueSizeMismatch:
+			artwork, ok := artworks[issue.Album]
+			if !ok && m.settings.SaveCoverArtInTags && issue.Album.HasArtwork() {
+				artwork, _, _ = m.downloadArtwork(ctx, issue.Album)
+				artworks[issue.Album] = artwork
+			}
+			if err := m.downloadTrack(ctx, issue.Track, issue.Album, artwork, false); err != nil {
+				m.progress(ProgressEvent{Message: fmt.Sprintf("Error downloading %s: %v", issue.Track.Title, err), Level: LevelError})
+			}
+		}
+	}
+
+	for _, issue := range lowQuality {
+		if _, ok := artworks[issue.Album]; !ok && m.settings.SaveCoverArtInTags && issue.Album.HasArtwork() {
+			artworks[issue.Album], _, _ = m.downloadArtwork(ctx, issue.Album)
+		}
+	}
+	upgrades := m.upgradeTracks(ctx, lowQuality, artworks)
+	return upgrades, ctx.Err()
+}
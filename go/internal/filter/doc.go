@@ -0,0 +1,30 @@
+// Package filter evaluates expressions over the metadata of a release,
+// which decide whether it is downloaded:
+//
+//	expr, err := filter.Parse(`release_year >= 2020 && track_count > 3`)
+//	if err != nil {
+//	    return err // syntax error or unknown field
+//	}
+//	ok, err := expr.Match(album)
+//
+// # Syntax
+//
+// Expressions compare the fields of Fields with numbers, "quoted" strings
+// and true/false, using == != < <= > >=, and combine them with &&, || and
+// ! and parentheses. The "in" operator tests whether a string is one of
+// the tags, or part of another string, ignoring case:
+//
+//	"ambient" in tags && !compilation
+//	"remix" in title || duration < 10
+//
+// Comparing values of different types, e.g. a string with a number, is an
+// error reported by Match, not a false result.
+//
+// # Other Fields
+//
+// ParseFields parses expressions over other fields, e.g. those of the
+// tracks of a library, which Eval evaluates for their values:
+//
+//	expr, err := filter.ParseFields(`"ambient" in genre`, map[string]string{"genre": "genre tag"})
+//	ok, err := expr.Eval(map[string]any{"genre": "Dark Ambient"})
+package filter
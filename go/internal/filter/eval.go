@@ -0,0 +1,179 @@
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+// Match evaluates the expression for album, and reports whether it is
+// true.
+func (e *Expr) Match(album *model.Album) (bool, error) {
+	return e.Eval(albumFields(album))
+}
+
+// Eval evaluates an expression parsed with ParseFields for values, which
+// has a value for each of its fields: a float64, string, []string (tags)
+// or bool. It reports whether the expression is true.
+func (e *Expr) Eval(values map[string]any) (bool, error) {
+	v, err := e.root.eval(values)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%q is not a condition", e.src)
+	}
+	return b, nil
+}
+
+// albumFields returns the values of the Fields of album.
+func albumFields(album *model.Album) map[string]any {
+	var duration float64
+	for _, track := range album.Tracks {
+		duration += track.Duration
+	}
+	year := 0
+	if !album.ReleaseDate.IsZero() {
+		year = album.ReleaseDate.Year()
+	}
+	return map[string]any{
+		"artist":       album.Artist,
+		"title":        album.Title,
+		"label":        album.LabelName(),
+		"url":          album.URL,
+		"release_year": float64(year),
+		"track_count":  float64(len(album.Tracks)),
+		"duration":     duration / 60,
+		"tags":         album.Tags,
+		"compilation":  album.Compilation,
+		"single":       album.Single,
+	}
+}
+
+// node is a node of the syntax tree of an expression.
+type node interface {
+	eval(fields map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	name string
+}
+
+func (n fieldNode) eval(fields map[string]any) (any, error) {
+	return fields[n.name], nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(fields map[string]any) (any, error) {
+	b, err := evalBool(n.operand, fields, "!")
+	return !b, err
+}
+
+// logicalNode is a && or || b, which only evaluates b when needed.
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicalNode) eval(fields map[string]any) (any, error) {
+	left, err := evalBool(n.left, fields, n.op)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&") != left {
+		return left, nil
+	}
+	return evalBool(n.right, fields, n.op)
+}
+
+// evalBool evaluates an operand of op that must be a boolean.
+func evalBool(operand node, fields map[string]any, op string) (bool, error) {
+	v, err := operand.eval(fields)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s needs conditions, got %v", op, v)
+	}
+	return b, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(fields map[string]any) (any, error) {
+	left, err := n.left.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "in" {
+		s, ok := left.(string)
+		if !ok {
+			return nil, fmt.Errorf("in needs a string on its left, got %v", left)
+		}
+		switch r := right.(type) {
+		case []string:
+			return slices.ContainsFunc(r, func(tag string) bool { return strings.EqualFold(tag, s) }), nil
+		case string:
+			return strings.Contains(strings.ToLower(r), strings.ToLower(s)), nil
+		}
+		return nil, fmt.Errorf("in needs tags or a string on its right, got %v", right)
+	}
+
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return compare(n.op, l, r)
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return compare(n.op, l, r)
+		}
+	case bool:
+		if r, ok := right.(bool); ok && (n.op == "==" || n.op == "!=") {
+			return (l == r) == (n.op == "=="), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot compare %v %s %v", left, n.op, right)
+}
+
+// compare applies a comparison operator to two ordered values.
+func compare[T float64 | string](op string, l, r T) (bool, error) {
+	switch op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
@@ -0,0 +1,265 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Fields are the fields of a release that expressions can use.
+var Fields = map[string]string{
+	"artist":       "artist name",
+	"title":        "release title",
+	"label":        "label name, or the artist name",
+	"url":          "release URL",
+	"release_year": "release year, 0 if unknown",
+	"track_count":  "number of tracks",
+	"duration":     "total duration in minutes",
+	"tags":         "genre and location tags",
+	"compilation":  "whether the tracks are by many artists",
+	"single":       "whether the release is a track page",
+}
+
+// Expr is a parsed filter expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// String returns the expression as written.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Parse parses a filter expression, and checks its fields are Fields.
+func Parse(src string) (*Expr, error) {
+	return ParseFields(src, Fields)
+}
+
+// ParseFields parses an expression over other values than those of a
+// release, e.g. those of a track, and checks its fields are keys of
+// fields. Such expressions are evaluated with Eval.
+func ParseFields(src string, fields map[string]string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, fields: fields}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// tokenKind is the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+// token is a lexical token, at byte offset pos of the expression.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are the operators, longest first so that "<=" is not read
+// as "<".
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!"}
+
+// lex splits an expression into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			s, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			tokens = append(tokens, token{tokenString, s, i})
+			i = end + 1
+		case unicode.IsDigit(c) || c == '.':
+			end := i
+			for end < len(src) && (unicode.IsDigit(rune(src[end])) || src[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, src[i:end], i})
+			i = end
+		case unicode.IsLetter(c) || c == '_':
+			end := i
+			for end < len(src) && (unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end])) || src[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, src[i:end], i})
+			i = end
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokenEOF, "end of expression", len(src)}), nil
+}
+
+// parser is a recursive descent parser of the tokens of an expression.
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// or parses a || b || ...
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+// and parses a && b && ...
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary parses !a and comparisons.
+func (p *parser) unary() (node, error) {
+	if t := p.peek(); t.kind == tokenOp && t.text == "!" {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses a, a op b and a in b.
+func (p *parser) comparison() (node, error) {
+	left, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	isComparison := t.kind == tokenOp && t.text != "&&" && t.text != "||" && t.text != "!"
+	if !isComparison && !(t.kind == tokenIdent && t.text == "in") {
+		return left, nil
+	}
+	p.next()
+	right, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{op: t.text, left: left, right: right}, nil
+}
+
+// primary parses literals, fields and parenthesized expressions.
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return literalNode{value: n}, nil
+	case tokenString:
+		return literalNode{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		if _, ok := p.fields[t.text]; !ok {
+			return nil, fmt.Errorf("unknown field %q at offset %d", t.text, t.pos)
+		}
+		return fieldNode{name: t.text}, nil
+	case tokenLParen:
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at offset %d, got %q", closing.pos, closing.text)
+		}
+		return inner, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
@@ -0,0 +1,109 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []string{
+		"",
+		"release_year >=",
+		"year > 2020",
+		`title == "unterminated`,
+		"(track_count > 3",
+		"track_count > 3 )",
+		"track_count $ 3",
+	}
+	for _, src := range tests {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestExpr_Match(t *testing.T) {
+	album := &model.Album{
+		Artist:      "Artist",
+		Title:       "Night Remixes",
+		ReleaseDate: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		Tags:        []string{"Ambient", "Berlin"},
+	}
+	for i := 0; i < 4; i++ {
+		album.Tracks = append(album.Tracks, &model.Track{Album: album, Duration: 300})
+	}
+
+	tests := []struct {
+		src     string
+		want    bool
+		wantErr bool
+	}{
+		{src: "release_year >= 2020 && track_count > 3", want: true},
+		{src: "release_year >= 2022 || track_count > 4", want: false},
+		{src: `"ambient" in tags && !compilation`, want: true},
+		{src: `"techno" in tags`, want: false},
+		{src: `"remix" in title`, want: true},
+		{src: `artist == "Artist" && label == "Artist"`, want: true},
+		{src: "duration == 20", want: true},
+		{src: "!(single || compilation)", want: true},
+		{src: "single == false", want: true},
+		{src: "false && title > 3", want: false},
+		{src: "title > 3", wantErr: true},
+		{src: "track_count", wantErr: true},
+		{src: "!artist", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			got, err := expr.Match(album)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Match() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Match failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	fields := map[string]string{"genre": "", "year": ""}
+	if _, err := ParseFields("release_year > 2020", fields); err == nil {
+		t.Error("ParseFields accepted a field not in fields")
+	}
+
+	expr, err := ParseFields(`"ambient" in genre && year >= 2010 && year <= 2015`, fields)
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	tests := []struct {
+		genre string
+		year  float64
+		want  bool
+	}{
+		{"Ambient, Drone", 2012, true},
+		{"Ambient", 2020, false},
+		{"Techno", 2012, false},
+	}
+	for _, tt := range tests {
+		got, err := expr.Eval(map[string]any{"genre": tt.genre, "year": tt.year})
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q, %v) = %v, want %v", tt.genre, tt.year, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Package grpcapi serves the daemon's queue over gRPC, with the Downloader
+// service of api/downloaderpb, so frontends (other Go programs, Tauri or
+// Electron apps) can submit and cancel jobs and receive typed progress
+// streams instead of polling the daemon's socket.
+//
+// The service wraps a daemon.Server:
+//
+//	server := daemon.NewServer(settings, nil)
+//	l, err := net.Listen("tcp", "127.0.0.1:50051")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	go grpcapi.NewServer(server).Serve(l)
+//
+// Clients use the generated stubs:
+//
+//	conn, err := grpc.NewClient("127.0.0.1:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	client := downloaderpb.NewDownloaderClient(conn)
+//	job, err := client.Submit(ctx, &downloaderpb.SubmitRequest{Urls: urls})
+//	stream, err := client.Watch(ctx, &downloaderpb.WatchRequest{JobId: job.Id})
+//	for {
+//	    event, err := stream.Recv()
+//	    if err == io.EOF {
+//	        break // the job finished
+//	    }
+//	    ...
+//	}
+//
+// The service has no authentication: listen on a loopback address, or put
+// it behind a proxy that authenticates clients.
+package grpcapi
@@ -0,0 +1,141 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/handiism/bandcamp-downloader/api/downloaderpb"
+	"github.com/handiism/bandcamp-downloader/internal/daemon"
+)
+
+// jobStates maps the daemon's job states to those of the API.
+var jobStates = map[daemon.JobState]downloaderpb.JobState{
+	daemon.JobQueued:    downloaderpb.JobState_JOB_STATE_QUEUED,
+	daemon.JobRunning:   downloaderpb.JobState_JOB_STATE_RUNNING,
+	daemon.JobDone:      downloaderpb.JobState_JOB_STATE_DONE,
+	daemon.JobPartial:   downloaderpb.JobState_JOB_STATE_PARTIAL,
+	daemon.JobFailed:    downloaderpb.JobState_JOB_STATE_FAILED,
+	daemon.JobCancelled: downloaderpb.JobState_JOB_STATE_CANCELLED,
+}
+
+// service implements downloaderpb.DownloaderServer on a daemon.Server.
+type service struct {
+	downloaderpb.UnimplementedDownloaderServer
+	daemon *daemon.Server
+}
+
+// NewServer creates a gRPC server with the Downloader service of d
+// registered. opts are passed to grpc.NewServer.
+func NewServer(d *daemon.Server, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	downloaderpb.RegisterDownloaderServer(s, &service{daemon: d})
+	return s
+}
+
+// Submit queues a job for the request's URLs.
+func (s *service) Submit(_ context.Context, req *downloaderpb.SubmitRequest) (*downloaderpb.Job, error) {
+	job, err := s.daemon.Add(req.GetUrls())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return toJob(job), nil
+}
+
+// ListJobs returns the daemon's jobs.
+func (s *service) ListJobs(context.Context, *downloaderpb.ListJobsRequest) (*downloaderpb.ListJobsResponse, error) {
+	jobs := s.daemon.Jobs()
+	resp := &downloaderpb.ListJobsResponse{Jobs: make([]*downloaderpb.Job, len(jobs))}
+	for i, job := range jobs {
+		resp.Jobs[i] = toJob(job)
+	}
+	return resp, nil
+}
+
+// Watch streams the events of a job, or of every job, until the job
+// finishes or the client goes away.
+func (s *service) Watch(req *downloaderpb.WatchRequest, stream grpc.ServerStreamingServer[downloaderpb.Event]) error {
+	events, stop, err := s.daemon.Subscribe(int(req.GetJobId()))
+	if err != nil {
+		return toStatus(err)
+	}
+	defer stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Cancel cancels a queued or running job.
+func (s *service) Cancel(_ context.Context, req *downloaderpb.CancelRequest) (*downloaderpb.Job, error) {
+	job, err := s.daemon.Cancel(int(req.GetJobId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toJob(job), nil
+}
+
+// toStatus converts an error of the daemon to a gRPC status.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, daemon.ErrUnknownJob):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, daemon.ErrJobFinished):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toJob converts a job of the daemon to the API's.
+func toJob(job daemon.Job) *downloaderpb.Job {
+	return &downloaderpb.Job{
+		Id:         int64(job.ID),
+		Urls:       job.URLs,
+		State:      jobStates[job.State],
+		Added:      toTimestamp(job.Added),
+		Started:    toTimestamp(job.Started),
+		Finished:   toTimestamp(job.Finished),
+		Albums:     int64(job.Albums),
+		FailedUrls: job.FailedURLs,
+		Error:      job.Error,
+	}
+}
+
+// toEvent converts an event of the daemon to the API's. The API's levels
+// have the values of download.ProgressLevel.
+func toEvent(event daemon.Event) *downloaderpb.Event {
+	return &downloaderpb.Event{
+		JobId:   int64(event.JobID),
+		State:   jobStates[event.State],
+		Message: event.Message,
+		Level:   downloaderpb.Level(event.Level),
+		Album:   event.Album,
+		Track:   event.Track,
+		Written: event.Written,
+		Total:   event.Total,
+	}
+}
+
+// toTimestamp converts t to a timestamp, or nil if t is zero.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,116 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/handiism/bandcamp-downloader/api/downloaderpb"
+	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/daemon"
+)
+
+// startServer serves d's queue and its gRPC API in memory, and returns a
+// client for it.
+func startServer(t *testing.T, d *daemon.Server) downloaderpb.DownloaderClient {
+	t.Helper()
+
+	// The daemon's socket is not used, but Serve processes the queue
+	dir := t.TempDir()
+	l, err := net.Listen("unix", dir+"/d.sock")
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Serve(ctx, l)
+	}()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(d)
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+		cancel()
+		<-done
+	})
+	return downloaderpb.NewDownloaderClient(conn)
+}
+
+func TestService(t *testing.T) {
+	d := daemon.NewServer(config.DefaultSettings(), nil)
+	client := startServer(t, d)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.Submit(ctx, &downloaderpb.SubmitRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Submit without URLs: err = %v, want InvalidArgument", err)
+	}
+	if _, err := client.Cancel(ctx, &downloaderpb.CancelRequest{JobId: 42}); status.Code(err) != codes.NotFound {
+		t.Errorf("Cancel(42): err = %v, want NotFound", err)
+	}
+
+	// The page has no album, so the job fails, which ends its stream
+	page := httptest.NewServer(http.NotFoundHandler())
+	defer page.Close()
+	job, err := client.Submit(ctx, &downloaderpb.SubmitRequest{Urls: []string{page.URL + "/album/one"}})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if job.GetId() != 1 || len(job.GetUrls()) != 1 || job.GetAdded() == nil {
+		t.Errorf("Submit returned %v", job)
+	}
+
+	stream, err := client.Watch(ctx, &downloaderpb.WatchRequest{JobId: job.GetId()})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	var last *downloaderpb.Event
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		last = event
+	}
+	if last == nil || last.GetState() != downloaderpb.JobState_JOB_STATE_FAILED || last.GetLevel() != downloaderpb.Level_LEVEL_ERROR {
+		t.Errorf("last event = %v, want a failed state", last)
+	}
+
+	if _, err := client.Cancel(ctx, &downloaderpb.CancelRequest{JobId: job.GetId()}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Cancel(finished): err = %v, want FailedPrecondition", err)
+	}
+
+	resp, err := client.ListJobs(ctx, &downloaderpb.ListJobsRequest{})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(resp.GetJobs()) != 1 || resp.GetJobs()[0].GetFinished() == nil {
+		t.Errorf("ListJobs = %v, want the finished job", resp.GetJobs())
+	}
+}
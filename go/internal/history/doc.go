@@ -0,0 +1,31 @@
+// Package history records completed download runs so users archiving
+// labels over long periods can see what was fetched and when.
+//
+// Runs are appended as JSON lines to a small history file, by default
+// located in the user's configuration directory:
+//
+//	path, _ := history.DefaultPath()
+//	err := history.Append(path, history.Run{
+//	    Date:  start,
+//	    URLs:  []string{"https://artist.bandcamp.com/album/name"},
+//	    Bytes: received,
+//	})
+//
+// # Listing Runs
+//
+//	runs, err := history.Load(path)
+//	for _, run := range runs {
+//	    fmt.Println(run.Date, run.Files, run.Bytes)
+//	}
+//
+// A missing history file is not an error; Load returns no runs.
+//
+// # URL History
+//
+// The URLs entered in the TUI are kept in a text file, one per line, next
+// to the config file (see URLsPath). AddURL moves a URL entered again to
+// the end and drops the oldest ones past a maximum:
+//
+//	path, _ := history.URLsPath(configPath)
+//	urls, err := history.AddURL(path, url, 50)
+package history
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Run describes one completed invocation of the downloader.
+type Run struct {
+	// Date is when the run started.
+	Date time.Time `json:"date"`
+
+	// DurationSeconds is how long the run took, in seconds.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// URLs are the input URLs given to the run.
+	URLs []string `json:"urls"`
+
+	// Albums is the number of albums that were initialized.
+	Albums int `json:"albums"`
+
+	// Files is the number of files downloaded (or skipped as existing).
+	Files int32 `json:"files"`
+
+	// TotalFiles is the number of files the run expected to download.
+	TotalFiles int32 `json:"total_files"`
+
+	// Bytes is the number of bytes received.
+	Bytes int64 `json:"bytes"`
+
+	// The tracks downloaded, already present, without a stream, and failed
+	// after their retries (see download.RunStats). Zero in runs recorded
+	// by older versions.
+	TracksDownloaded  int `json:"tracks_downloaded,omitempty"`
+	TracksSkipped     int `json:"tracks_skipped,omitempty"`
+	TracksUnavailable int `json:"tracks_unavailable,omitempty"`
+	TracksFailed      int `json:"tracks_failed,omitempty"`
+
+	// FailedAlbums lists the albums that failed or finished partially,
+	// formatted as "Artist - Title".
+	FailedAlbums []string `json:"failed_albums,omitempty"`
+
+	// Cancelled is true if the run was interrupted by the user.
+	Cancelled bool `json:"cancelled,omitempty"`
+}
+
+// Duration returns the run duration as a time.Duration.
+func (r Run) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds * float64(time.Second))
+}
+
+// Speed returns the average download speed of the run, in bytes per
+// second.
+func (r Run) Speed() float64 {
+	if r.DurationSeconds <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.DurationSeconds
+}
+
+// DefaultPath returns the default history file location inside the
+// user's configuration directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bandcamp-downloader", "history.jsonl"), nil
+}
+
+// Append adds a run to the end of the history file, creating the file
+// and its parent directories if needed.
+func Append(path string, run Run) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Load reads all runs from the history file, oldest first.
+//
+// Returns no runs and no error if the file does not exist.
+func Load(path string) ([]Run, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("history line %d: %w", line, err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, scanner.Err()
+}
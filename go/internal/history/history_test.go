@@ -0,0 +1,106 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+
+	first := Run{
+		Date:            time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		DurationSeconds: 12.5,
+		URLs:            []string{"https://artist.bandcamp.com/album/one"},
+		Albums:          1,
+		Files:           9,
+		TotalFiles:      9,
+		Bytes:           1024,
+
+		TracksDownloaded: 7,
+		TracksSkipped:    1,
+		TracksFailed:     1,
+	}
+	second := Run{
+		Date:         time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+		URLs:         []string{"https://label.bandcamp.com"},
+		FailedAlbums: []string{"Artist - Album"},
+		Cancelled:    true,
+	}
+
+	for _, run := range []Run{first, second} {
+		if err := Append(path, run); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	runs, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if !runs[0].Date.Equal(first.Date) || runs[0].Bytes != first.Bytes {
+		t.Errorf("runs[0] = %+v, want %+v", runs[0], first)
+	}
+	if runs[0].Duration() != 12500*time.Millisecond {
+		t.Errorf("Duration() = %v, want 12.5s", runs[0].Duration())
+	}
+	if runs[0].TracksDownloaded != 7 || runs[0].TracksSkipped != 1 || runs[0].TracksFailed != 1 {
+		t.Errorf("runs[0] tracks = %d downloaded, %d skipped, %d failed, want 7, 1, 1", runs[0].TracksDownloaded, runs[0].TracksSkipped, runs[0].TracksFailed)
+	}
+	if got := runs[0].Speed(); got != 1024/12.5 {
+		t.Errorf("Speed() = %v, want %v", got, 1024/12.5)
+	}
+	if !runs[1].Cancelled || len(runs[1].FailedAlbums) != 1 {
+		t.Errorf("runs[1] = %+v, want cancelled with one failed album", runs[1])
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	runs, err := Load(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("got %d runs, want 0", len(runs))
+	}
+}
+
+func TestAddURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "url_history.txt")
+
+	for _, url := range []string{
+		"https://a.bandcamp.com/album/one",
+		"https://b.bandcamp.com/album/two",
+		"https://a.bandcamp.com/album/one",
+		"https://c.bandcamp.com",
+		"https://d.bandcamp.com/track/four",
+	} {
+		if _, err := AddURL(path, url, 3); err != nil {
+			t.Fatalf("AddURL failed: %v", err)
+		}
+	}
+
+	urls, err := LoadURLs(path)
+	if err != nil {
+		t.Fatalf("LoadURLs failed: %v", err)
+	}
+	want := []string{"https://a.bandcamp.com/album/one", "https://c.bandcamp.com", "https://d.bandcamp.com/track/four"}
+	if strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("LoadURLs() = %v, want %v", urls, want)
+	}
+}
+
+func TestURLsPath(t *testing.T) {
+	path, err := URLsPath(filepath.Join("conf", "config.json"))
+	if err != nil {
+		t.Fatalf("URLsPath failed: %v", err)
+	}
+	if want := filepath.Join("conf", "url_history.txt"); path != want {
+		t.Errorf("URLsPath() = %q, want %q", path, want)
+	}
+}
@@ -0,0 +1,83 @@
+package history
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// urlsFileName is the name of the file of the URLs entered in the TUI.
+const urlsFileName = "url_history.txt"
+
+// URLsPath returns the location of the URL history: next to the config
+// file at configPath, or in the user's configuration directory if
+// configPath is empty.
+func URLsPath(configPath string) (string, error) {
+	if configPath != "" {
+		return filepath.Join(filepath.Dir(configPath), urlsFileName), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bandcamp-downloader", urlsFileName), nil
+}
+
+// LoadURLs reads the URL history, one URL per line, oldest first.
+//
+// Returns no URLs and no error if the file does not exist.
+func LoadURLs(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if url := strings.TrimSpace(scanner.Text()); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls, scanner.Err()
+}
+
+// AddURL adds url at the end of the URL history, moving it there if it
+// was already entered, and keeps only the last max URLs. The file and its
+// parent directories are created if needed.
+//
+// Returns the new history, oldest first.
+func AddURL(path, url string, max int) ([]string, error) {
+	urls, err := LoadURLs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := urls[:0]
+	for _, u := range urls {
+		if u != url {
+			kept = append(kept, u)
+		}
+	}
+	urls = append(kept, url)
+	if len(urls) > max {
+		urls = urls[len(urls)-max:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for _, u := range urls {
+		b.WriteString(u + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BreakerConfig configures the circuit breaker of a Client, which stops
+// sending requests to a host that keeps failing.
+//
+// Example:
+//
+//	client := NewClient(&ClientConfig{
+//	    Breaker: &BreakerConfig{Threshold: 10, Cooldown: time.Minute},
+//	})
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failures of a host (connection
+	// errors, interrupted transfers, 429 or 5xx answers) that opens its
+	// circuit. Zero disables the breaker.
+	Threshold int
+
+	// Cooldown is how long requests to a host whose circuit is open are
+	// held back. Zero means one minute.
+	Cooldown time.Duration
+}
+
+// BreakerEvent reports a change of the state of a host's circuit.
+type BreakerEvent struct {
+	// Host is the host name, e.g. "t4.bcbits.com".
+	Host string
+
+	// Open is true when the circuit opens, false when the host answers
+	// again and the circuit closes.
+	Open bool
+
+	// Until is the end of the cooldown of an open circuit.
+	Until time.Time
+
+	// Failures is the number of consecutive failures of the host.
+	Failures int
+}
+
+// breaker holds back requests to hosts with too many consecutive failures.
+//
+// While a host's circuit is open, its requests wait for the cooldown to end
+// instead of failing, so they do not use up their retries. Requests are
+// then let through again: a success closes the circuit, a failure opens it
+// for another cooldown.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(BreakerEvent)
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	failures  int
+	openUntil time.Time
+}
+
+func newBreaker(config *BreakerConfig, onChange func(BreakerEvent)) *breaker {
+	if config == nil || config.Threshold <= 0 {
+		return nil
+	}
+	cooldown := config.Cooldown
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	return &breaker{
+		threshold: config.Threshold,
+		cooldown:  cooldown,
+		onChange:  onChange,
+		hosts:     make(map[string]*hostState),
+	}
+}
+
+// wait blocks while the circuit of rawURL's host is open.
+func (b *breaker) wait(ctx context.Context, rawURL string) error {
+	if b == nil {
+		return nil
+	}
+	host := hostOf(rawURL)
+	for {
+		b.mu.Lock()
+		var until time.Time
+		if h := b.hosts[host]; h != nil {
+			until = h.openUntil
+		}
+		b.mu.Unlock()
+
+		d := time.Until(until)
+		if d <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// record counts the outcome of a request to rawURL.
+func (b *breaker) record(rawURL string, err error) {
+	if b == nil {
+		return
+	}
+	host := hostOf(rawURL)
+	failed := isHostFailure(err)
+
+	b.mu.Lock()
+	h := b.hosts[host]
+	if h == nil {
+		if !failed {
+			b.mu.Unlock()
+			return
+		}
+		h = &hostState{}
+		b.hosts[host] = h
+	}
+
+	var event *BreakerEvent
+	now := time.Now()
+	switch {
+	case !failed:
+		if h.failures >= b.threshold {
+			event = &BreakerEvent{Host: host, Failures: h.failures}
+		}
+		delete(b.hosts, host)
+	default:
+		h.failures++
+		// Failures of requests sent before the circuit opened do not
+		// extend the cooldown
+		if h.failures >= b.threshold && !now.Before(h.openUntil) {
+			h.openUntil = now.Add(b.cooldown)
+			event = &BreakerEvent{Host: host, Open: true, Until: h.openUntil, Failures: h.failures}
+		}
+	}
+	b.mu.Unlock()
+
+	if event != nil && b.onChange != nil {
+		b.onChange(*event)
+	}
+}
+
+// isHostFailure reports whether err shows the host is unavailable, as
+// opposed to a request it answered but refused, like a 404.
+func isHostFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrIdleTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == 429 || statusErr.Code >= 500
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// hostOf returns the host name of rawURL, or rawURL if it cannot be parsed.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	return u.Hostname()
+}
@@ -1,11 +1,18 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -13,13 +20,16 @@ import (
 //
 // Client provides:
 //   - Configured User-Agent header for Bandcamp compatibility
-//   - Timeout handling
+//   - Connection, TLS, response header and idle timeouts, and an overall
+//     timeout for requests other than file downloads
+//   - Retries of failed requests (see RetryPolicy)
+//   - A circuit breaker for failing hosts (see BreakerConfig)
 //   - File download with progress tracking
 //   - File size retrieval via HEAD requests
 //
 // Example usage:
 //
-//	client := NewClient()
+//	client := NewClient(nil)
 //
 //	// Fetch HTML content
 //	html, err := client.GetString(ctx, "https://artist.bandcamp.com/album/name")
@@ -31,21 +41,256 @@ import (
 //	})
 type Client struct {
 	httpClient *http.Client
+	transport  *http.Transport
+	proxies    *proxyPool
 	userAgent  string
+
+	// timeout bounds requests other than DownloadFile, idleTimeout the
+	// time without data of a DownloadFile, if not 0.
+	timeout     time.Duration
+	idleTimeout time.Duration
+
+	// segments and segmentMinSize configure ranged downloads, see
+	// ClientConfig.Segments.
+	segments       int
+	segmentMinSize int64
+
+	// retry and onRetry are ClientConfig.Retry and ClientConfig.OnRetry.
+	retry   *RetryPolicy
+	onRetry func(RetryEvent)
+
+	// breaker holds back requests to failing hosts, nil if disabled.
+	breaker *breaker
+
+	// headless holds the hosts that rejected a HEAD request of
+	// GetFileSize, which are sent ranged GET requests instead.
+	headless sync.Map
+}
+
+// ClientConfig holds the network options of a Client.
+//
+// Example:
+//
+//	socks, _ := ParseProxyURL("socks5://127.0.0.1:1080")
+//	backup, _ := ParseProxyURL("http://10.0.0.2:3128")
+//	client := NewClient(&ClientConfig{
+//	    Timeout:     60 * time.Second,
+//	    IdleTimeout: 2 * time.Minute,
+//	    Proxies:     []*url.URL{socks, backup},
+//	})
+type ClientConfig struct {
+	// Timeout is the overall timeout of the requests of every method but
+	// DownloadFile, whose transfers have no overall deadline so large files
+	// can download on slow links. Zero means 60 seconds.
+	Timeout time.Duration
+
+	// ConnectTimeout bounds establishing a connection, including name
+	// resolution. Zero means 30 seconds.
+	ConnectTimeout time.Duration
+
+	// TLSHandshakeTimeout bounds the TLS handshake. Zero means 10 seconds.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout bounds the wait for the response headers once
+	// the request is sent. Zero means 60 seconds.
+	ResponseHeaderTimeout time.Duration
+
+	// IdleTimeout aborts a DownloadFile transfer, with ErrIdleTimeout,
+	// when no data is received for this long. Zero means 60 seconds, and
+	// a negative value never aborts a transfer.
+	IdleTimeout time.Duration
+
+	// UseEnvironmentProxy routes requests through the proxy set by the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	// Ignored if Proxies is not empty.
+	UseEnvironmentProxy bool
+
+	// Proxies lists the proxies (see ParseProxyURL) requests are rotated
+	// through. A single entry acts as a static proxy.
+	Proxies []*url.URL
+
+	// ProxyCooldown is how long a proxy that failed is taken out of
+	// rotation. Zero means one minute.
+	ProxyCooldown time.Duration
+
+	// IPVersion forces connections over IPv4 (4) or IPv6 (6). Zero allows
+	// both, as chosen by the system.
+	IPVersion int
+
+	// DNSServers replaces the system resolver with the given servers (see
+	// ParseDNSServer), queried in rotation. Plain DNS, DNS over TLS and
+	// DNS over HTTPS are supported.
+	DNSServers []*url.URL
+
+	// RootCAs is the set of root certificates used to verify servers, for
+	// example the system roots plus a corporate proxy's CA (see LoadCAFile).
+	// Nil means the system roots.
+	RootCAs *x509.CertPool
+
+	// InsecureSkipVerify disables TLS certificate verification entirely.
+	// It makes connections vulnerable to interception and should only be
+	// used as a last resort; prefer RootCAs.
+	InsecureSkipVerify bool
+
+	// Jar holds the cookies sent with the requests and set by their
+	// responses, e.g. the session of a logged-in Bandcamp account (see
+	// LoadCookies). Nil sends no cookie.
+	Jar http.CookieJar
+
+	// Segments splits DownloadFile transfers of at least SegmentMinSize
+	// bytes into this many byte ranges downloaded in parallel, which is
+	// faster over high-latency connections. Servers that do not support
+	// ranges are downloaded as usual. 0 or 1 disables it.
+	Segments int
+
+	// SegmentMinSize is the smallest file split into segments. Zero means
+	// 50 MiB.
+	SegmentMinSize int64
+
+	// Retry is the policy applied to failed requests of every method. Nil
+	// means each request is attempted once.
+	Retry *RetryPolicy
+
+	// OnRetry, if set, is called before a failed request is retried, e.g.
+	// to report it. Use WithLabel to tell the requests apart.
+	OnRetry func(RetryEvent)
+
+	// Breaker pauses the requests to a host after repeated failures, nil
+	// to disable it. OnBreaker, if set, is called when a host's circuit
+	// opens or closes.
+	Breaker   *BreakerConfig
+	OnBreaker func(BreakerEvent)
+}
+
+// LoadCAFile returns the system root certificates plus the PEM
+// certificates found in path.
+//
+// Example:
+//
+//	pool, err := LoadCAFile("/etc/ssl/corp-root.pem")
+//	client := NewClient(&ClientConfig{RootCAs: pool})
+func LoadCAFile(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// DefaultClientConfig returns the default network options: the default
+// timeouts, the proxy from the environment and DefaultRetryPolicy.
+func DefaultClientConfig() *ClientConfig {
+	return &ClientConfig{
+		Timeout:             60 * time.Second,
+		UseEnvironmentProxy: true,
+		Retry:               DefaultRetryPolicy(),
+	}
 }
 
 // NewClient creates a new HTTP client configured for Bandcamp.
 //
-// The client is configured with:
-//   - 60 second timeout
+// If config is nil, DefaultClientConfig() is used. The client is
+// configured with:
+//   - The configured timeouts (see ClientConfig)
 //   - "BandcampDownloader" User-Agent header
-func NewClient() *Client {
-	return &Client{
+//   - Compressed pages (GetString, GetPage), but not downloads
+//   - No proxy, the environment's proxy, or rotation across config.Proxies
+//   - The system resolver, or config.DNSServers, over IPv4/IPv6 or both
+//   - The system root certificates, or config.RootCAs
+//   - No cookies, or those of config.Jar
+func NewClient(config *ClientConfig) *Client {
+	if config == nil {
+		config = DefaultClientConfig()
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	// Pages ask for compression themselves, downloads never do
+	transport.DisableCompression = true
+	transport.DialContext = newDialer(config.IPVersion, config.DNSServers, orDefault(config.ConnectTimeout, 30*time.Second))
+	transport.TLSHandshakeTimeout = orDefault(config.TLSHandshakeTimeout, 10*time.Second)
+	transport.ResponseHeaderTimeout = orDefault(config.ResponseHeaderTimeout, 60*time.Second)
+	if config.RootCAs != nil || config.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:            config.RootCAs,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+		}
+	}
+	if config.UseEnvironmentProxy {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	idleTimeout := orDefault(config.IdleTimeout, 60*time.Second)
+	if config.IdleTimeout < 0 {
+		idleTimeout = 0
+	}
+
+	client := &Client{
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Transport: transport,
+			Jar:       config.Jar,
 		},
+		transport: transport,
 		userAgent: "BandcampDownloader",
+
+		timeout:     orDefault(config.Timeout, 60*time.Second),
+		idleTimeout: idleTimeout,
+
+		segments:       config.Segments,
+		segmentMinSize: config.SegmentMinSize,
+
+		retry:   config.Retry,
+		onRetry: config.OnRetry,
+		breaker: newBreaker(config.Breaker, config.OnBreaker),
 	}
+	if client.segmentMinSize <= 0 {
+		client.segmentMinSize = 50 << 20
+	}
+
+	if len(config.Proxies) > 0 {
+		client.proxies = newProxyPool(config.Proxies, config.ProxyCooldown)
+		transport.Proxy = proxyFromContext
+		client.httpClient.Transport = &rotatingTransport{base: transport, pool: client.proxies}
+	}
+
+	return client
+}
+
+// orDefault returns d, or def if d is not positive.
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// StatusError is returned when a server answers with an unexpected HTTP
+// status code.
+//
+// Example:
+//
+//	var statusErr *http.StatusError
+//	if errors.As(err, &statusErr) && statusErr.Code == 403 {
+//	    // Try another host
+//	}
+type StatusError struct {
+	// Code is the HTTP status code, e.g. 403.
+	Code int
+
+	// Status is the status line, e.g. "403 Forbidden".
+	Status string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Status)
 }
 
 // ProgressWriter wraps a writer to track download progress.
@@ -97,15 +342,30 @@ func (pw *ProgressWriter) Write(p []byte) (int, error) {
 //   - The response status is not 200 OK
 //   - Reading the body fails
 //
+// Like every request of the client, it is retried according to
+// ClientConfig.Retry.
+//
 // Example:
 //
 //	data, err := client.Get(ctx, "https://example.com/image.jpg")
 func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	return withRetry(ctx, c, url, func() ([]byte, error) { return c.get(ctx, url, false) })
+}
+
+// get makes a single attempt of Get, or of GetString if compressed, which
+// accepts a compressed response.
+func (c *Client) get(ctx context.Context, url string, compressed bool) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("User-Agent", c.userAgent)
+	if compressed {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -114,42 +374,285 @@ func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, &StatusError{Code: resp.StatusCode, Status: resp.Status}
 	}
 
+	if compressed {
+		return readBody(resp)
+	}
 	return io.ReadAll(resp.Body)
 }
 
 // GetString performs a GET request and returns the response body as a string.
 //
-// This is a convenience wrapper around Get for fetching text content like HTML.
+// This is a convenience wrapper around Get for fetching text content like
+// HTML. Unlike Get, it accepts gzip or deflate compressed responses, which
+// are decompressed.
 //
 // Example:
 //
 //	html, err := client.GetString(ctx, "https://artist.bandcamp.com/album/name")
 func (c *Client) GetString(ctx context.Context, url string) (string, error) {
-	body, err := c.Get(ctx, url)
+	body, err := withRetry(ctx, c, url, func() ([]byte, error) { return c.get(ctx, url, true) })
 	if err != nil {
 		return "", err
 	}
 	return string(body), nil
 }
 
+// PostJSON performs a POST request with body encoded as JSON, and returns
+// the response body, decompressed like the one of GetString. Like every
+// request of the client, it is retried according to ClientConfig.Retry, so
+// it is meant for queries that can be repeated, such as API lookups.
+//
+// Example:
+//
+//	data, err := client.PostJSON(ctx, "https://bandcamp.com/api/mobile/24/band_details", map[string]int64{"band_id": 42})
+func (c *Client) PostJSON(ctx context.Context, url string, body any) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return withRetry(ctx, c, url, func() ([]byte, error) { return c.postJSON(ctx, url, data) })
+}
+
+// postJSON makes a single attempt of PostJSON.
+func (c *Client) postJSON(ctx context.Context, url string, data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+	return readBody(resp)
+}
+
+// Page is an HTML page fetched by GetPage, along with the URL it was
+// finally served from.
+type Page struct {
+	// HTML is the response body.
+	HTML string
+
+	// URL is the final URL after following redirects. Use it as the base
+	// for resolving relative links found in the page.
+	URL string
+
+	// Redirects lists the URLs that redirected, in order, starting with the
+	// requested URL. Empty if the page was served without redirection.
+	Redirects []string
+}
+
+// GetPage performs a GET request and returns the page along with its final
+// URL and the redirect chain that led to it.
+//
+// Redirects (http→https, custom domain→bandcamp.com subdomain, trailing
+// slash variants, ...) are followed automatically; GetPage records them so
+// callers can resolve relative links against the canonical URL rather than
+// the one the user typed. Like GetString, it accepts compressed responses.
+//
+// Example:
+//
+//	page, err := client.GetPage(ctx, "http://music.example.com/music")
+//	// page.URL == "https://artist.bandcamp.com/music"
+func (c *Client) GetPage(ctx context.Context, url string) (*Page, error) {
+	return withRetry(ctx, c, url, func() (*Page, error) { return c.getPage(ctx, url) })
+}
+
+// getPage makes a single attempt of GetPage.
+func (c *Client) getPage(ctx context.Context, url string) (*Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk back the chain of requests that led to the final response
+	var redirects []string
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		redirects = append([]string{r.Response.Request.URL.String()}, redirects...)
+	}
+
+	return &Page{
+		HTML:      string(body),
+		URL:       resp.Request.URL.String(),
+		Redirects: redirects,
+	}, nil
+}
+
+// Validators are the cache validators returned with a resource, used to
+// make conditional requests for it later.
+type Validators struct {
+	// ETag is the value of the ETag response header.
+	ETag string `json:"etag,omitempty"`
+
+	// LastModified is the value of the Last-Modified response header.
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// IsZero reports whether no validator is set.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// Resource is the result of a conditional request made by GetConditional.
+type Resource struct {
+	// Body is the response body. It is nil if NotModified is true.
+	Body []byte
+
+	// Validators are the validators of the returned resource, to be stored
+	// for the next request. They are the request's validators if NotModified.
+	Validators Validators
+
+	// NotModified is true if the server answered 304 Not Modified, meaning
+	// the copy described by the request's validators is still current.
+	NotModified bool
+}
+
+// GetConditional performs a GET request that only downloads the resource if
+// it changed since it was fetched with the given validators.
+//
+// The validators are sent as If-None-Match and If-Modified-Since headers.
+// With zero validators this is a plain GET that also returns the
+// resource's validators.
+//
+// Example:
+//
+//	res, err := client.GetConditional(ctx, artworkURL, cached.Validators)
+//	if err == nil && res.NotModified {
+//	    // Reuse the cached copy
+//	}
+func (c *Client) GetConditional(ctx context.Context, url string, v Validators) (*Resource, error) {
+	return withRetry(ctx, c, url, func() (*Resource, error) { return c.getConditional(ctx, url, v) })
+}
+
+// getConditional makes a single attempt of GetConditional.
+func (c *Client) getConditional(ctx context.Context, url string, v Validators) (*Resource, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return &Resource{Validators: v, NotModified: true}, nil
+	case http.StatusOK:
+	default:
+		return nil, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resource{
+		Body: body,
+		Validators: Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
 // GetFileSize returns the size of a file at the given URL via HEAD request.
 //
 // This is useful for:
 //   - Pre-calculating total download size
 //   - Checking if a local file matches the expected size
 //
+// Servers that reject HEAD requests (405, 501, or 400 and 403 from some
+// CDNs) or answer them without a Content-Length are asked for the first
+// byte of the file instead, whose Content-Range gives its size. Their
+// hosts are then sent the ranged request directly.
+//
 // Returns an error if:
 //   - The request fails
-//   - The server doesn't return a Content-Length header
+//   - The server tells the size neither way
 //
 // Example:
 //
 //	size, err := client.GetFileSize(ctx, mp3URL)
 //	fmt.Printf("File is %d bytes\n", size)
 func (c *Client) GetFileSize(ctx context.Context, url string) (int64, error) {
+	return withRetry(ctx, c, url, func() (int64, error) { return c.getFileSize(ctx, url) })
+}
+
+// errNoContentLength is returned by headFileSize when the response has no
+// Content-Length header.
+var errNoContentLength = errors.New("no Content-Length header")
+
+// getFileSize makes a single attempt of GetFileSize.
+func (c *Client) getFileSize(ctx context.Context, url string) (int64, error) {
+	host := hostOf(url)
+	if _, ok := c.headless.Load(host); ok {
+		return c.rangedFileSize(ctx, url)
+	}
+
+	size, err := c.headFileSize(ctx, url)
+	if !headRejected(err) {
+		return size, err
+	}
+	size, err = c.rangedFileSize(ctx, url)
+	if err == nil {
+		c.headless.Store(host, true)
+	}
+	return size, err
+}
+
+// headFileSize returns the Content-Length of a HEAD request of url.
+func (c *Client) headFileSize(ctx context.Context, url string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
 		return 0, err
@@ -162,18 +665,53 @@ func (c *Client) GetFileSize(ctx context.Context, url string) (int64, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return 0, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
 	if resp.ContentLength < 0 {
-		return 0, fmt.Errorf("no Content-Length header for %s", url)
+		return 0, fmt.Errorf("%w for %s", errNoContentLength, url)
 	}
 
 	return resp.ContentLength, nil
 }
 
+// headRejected reports whether a HEAD request failed with err because the
+// server does not support them, rather than because the file is missing
+// or the server is failing.
+func headRejected(err error) bool {
+	if errors.Is(err, errNoContentLength) {
+		return true
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.Code {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
 // DownloadFile downloads a file to the specified path with optional progress callback.
 //
 // The file is created (or truncated if it exists) and the content is streamed
 // directly to disk, avoiding loading the entire file into memory.
 //
+// With ClientConfig.Segments, the file is requested with a "Range: bytes=0-"
+// header; if the server answers with a partial response for a file of at
+// least ClientConfig.SegmentMinSize bytes, the rest of the file is split in
+// ranges downloaded in parallel and written at their offset. onProgress is
+// then called with the sum of the bytes of all segments.
+//
+// The transfer has no overall deadline: it is only aborted, and retried,
+// when no data is received for ClientConfig.IdleTimeout. A retried
+// download starts over, so onProgress may report fewer bytes than in the
+// previous call.
+//
+// The Content-Type of the response is stored for contexts returned by
+// WithContentType.
+//
 // Parameters:
 //   - ctx: Context for cancellation
 //   - url: URL to download from
@@ -189,21 +727,45 @@ func (c *Client) GetFileSize(ctx context.Context, url string) (int64, error) {
 //	    }
 //	})
 func (c *Client) DownloadFile(ctx context.Context, url, destPath string, onProgress func(written, total int64)) error {
+	_, err := withRetry(ctx, c, url, func() (struct{}, error) {
+		idle := newIdleTimer(ctx, c.idleTimeout)
+		defer idle.stop()
+		return struct{}{}, idle.err(c.downloadFile(idle.ctx, url, destPath, onProgress, idle))
+	})
+	return err
+}
+
+// downloadFile makes a single attempt of DownloadFile, whose context is
+// canceled by idle when the transfer stalls.
+func (c *Client) downloadFile(ctx context.Context, url, destPath string, onProgress func(written, total int64), idle *idleTimer) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("User-Agent", c.userAgent)
+	if c.segments > 1 {
+		req.Header.Set("Range", "bytes=0-")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		recordContentType(ctx, resp)
+	case http.StatusPartialContent:
+		recordContentType(ctx, resp)
+		if total, ok := contentRangeTotal(resp); ok && c.segments > 1 && total >= c.segmentMinSize {
+			return c.downloadSegments(ctx, url, destPath, resp, total, onProgress, idle)
+		}
+		// Small file: the partial response is the whole file
+	default:
+		resp.Body.Close()
+		return &StatusError{Code: resp.StatusCode, Status: resp.Status}
 	}
+	defer resp.Body.Close()
 
 	file, err := os.Create(destPath)
 	if err != nil {
@@ -220,7 +782,7 @@ func (c *Client) DownloadFile(ctx context.Context, url, destPath string, onProgr
 		}
 	}
 
-	_, err = io.Copy(writer, resp.Body)
+	_, err = io.Copy(writer, idle.reader(resp.Body))
 	return err
 }
 
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptEncoding is the Accept-Encoding header of the requests of pages,
+// whose compressed responses readBody decodes. Downloads are requested
+// without it, so files are written as served and their sizes match the
+// Content-Length.
+const acceptEncoding = "gzip, deflate"
+
+// readBody reads the body of resp, decompressed according to its
+// Content-Encoding: gzip, deflate or none.
+func readBody(resp *http.Response) ([]byte, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+		return io.ReadAll(resp.Body)
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case "deflate":
+		return io.ReadAll(deflateReader(resp.Body))
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", encoding)
+	}
+}
+
+// deflateReader returns a reader decompressing r, a zlib stream as the
+// deflate encoding is specified, or a raw DEFLATE stream as some servers
+// send instead.
+func deflateReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(br); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(br)
+}
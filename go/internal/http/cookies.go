@@ -0,0 +1,82 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadCookies returns a cookie jar holding the cookies of the file at path,
+// in the Netscape cookies.txt format that browser extensions export, e.g.
+// the session of a logged-in Bandcamp account. Expired cookies are left
+// out.
+//
+// Example:
+//
+//	jar, err := LoadCookies("bandcamp-cookies.txt")
+//	client := NewClient(&ClientConfig{Jar: jar})
+func LoadCookies(path string) (http.CookieJar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	jar, _ := cookiejar.New(nil)
+	byURL := make(map[string][]*http.Cookie)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		// HttpOnly cookies are written as comments by some exporters
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("%s:%d: invalid cookie line, must have 7 tab-separated fields", path, n)
+		}
+		domain, cookiePath, secure, name, value := fields[0], fields[2], fields[3], fields[5], fields[6]
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     cookiePath,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if strings.HasPrefix(domain, ".") {
+			// Sent to the subdomains too
+			cookie.Domain = domain
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		u := (&url.URL{Scheme: scheme, Host: strings.TrimPrefix(domain, "."), Path: "/"}).String()
+		byURL[u] = append(byURL[u], cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for rawURL, cookies := range byURL {
+		u, _ := url.Parse(rawURL)
+		jar.SetCookies(u, cookies)
+	}
+	return jar, nil
+}
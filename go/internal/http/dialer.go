@@ -0,0 +1,194 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ParseDNSServer parses a DNS server address for ClientConfig.DNSServers.
+//
+// Accepted forms are:
+//   - "1.1.1.1" or "1.1.1.1:53": plain DNS (port 53 by default)
+//   - "tls://1.1.1.1" or "tls://dns.google:853": DNS over TLS (port 853 by default)
+//   - "https://cloudflare-dns.com/dns-query": DNS over HTTPS
+//
+// Example:
+//
+//	u, err := ParseDNSServer("9.9.9.9") // udp://9.9.9.9:53
+func ParseDNSServer(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "udp://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS server %q: %w", raw, err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid DNS server %q: missing host", raw)
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "53")
+		}
+	case "tls":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "853")
+		}
+	case "https":
+	default:
+		return nil, fmt.Errorf("invalid DNS server %q: unsupported scheme %q", raw, u.Scheme)
+	}
+
+	return u, nil
+}
+
+// newDialer returns the DialContext function of a client's transport.
+//
+// ipVersion restricts connections to IPv4 (4) or IPv6 (6); any other value
+// allows both. If servers is not empty, host names are resolved by querying
+// them in turn instead of the system resolver. timeout bounds each
+// connection, including name resolution.
+func newDialer(ipVersion int, servers []*url.URL, timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if len(servers) > 0 {
+		dialer.Resolver = newResolver(servers)
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network == "tcp" {
+			switch ipVersion {
+			case 4:
+				network = "tcp4"
+			case 6:
+				network = "tcp6"
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// newResolver returns a pure Go resolver that sends its queries to servers,
+// rotating to the next server on each new connection.
+func newResolver(servers []*url.URL) *net.Resolver {
+	var next uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			server := servers[int(atomic.AddUint32(&next, 1)-1)%len(servers)]
+			var d net.Dialer
+
+			switch server.Scheme {
+			case "tls":
+				td := tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: server.Hostname()}}
+				return td.DialContext(ctx, "tcp", server.Host)
+			case "https":
+				return &dohConn{ctx: ctx, url: server.String()}, nil
+			case "tcp":
+				return d.DialContext(ctx, "tcp", server.Host)
+			default:
+				// Keep the resolver's choice: UDP, or TCP for truncated answers
+				return d.DialContext(ctx, network, server.Host)
+			}
+		},
+	}
+}
+
+// dohClient sends DNS-over-HTTPS queries. It uses the system resolver to
+// reach the DoH server itself.
+var dohClient = &http.Client{Timeout: 10 * time.Second}
+
+// dohConn is a net.Conn that carries DNS messages over HTTPS (RFC 8484).
+//
+// It is not a net.PacketConn, so the Go resolver frames messages as it
+// does for TCP: each message is prefixed with its 2-byte length. Writes
+// collect a full query, which is POSTed as application/dns-message; reads
+// return the length-prefixed answer.
+type dohConn struct {
+	ctx      context.Context
+	url      string
+	query    bytes.Buffer
+	response bytes.Buffer
+	deadline time.Time
+}
+
+func (c *dohConn) Write(p []byte) (int, error) {
+	c.query.Write(p)
+
+	// Wait for the complete length-prefixed query
+	buf := c.query.Bytes()
+	if len(buf) < 2 || len(buf) < 2+int(binary.BigEndian.Uint16(buf)) {
+		return len(p), nil
+	}
+	msg := buf[2 : 2+int(binary.BigEndian.Uint16(buf))]
+
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(msg))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := dohClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("DNS over HTTPS: HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 65535))
+	if err != nil {
+		return 0, err
+	}
+
+	c.query.Reset()
+	binary.Write(&c.response, binary.BigEndian, uint16(len(answer)))
+	c.response.Write(answer)
+	return len(p), nil
+}
+
+func (c *dohConn) Read(p []byte) (int, error) {
+	if c.response.Len() == 0 {
+		return 0, errors.New("DNS over HTTPS: read before query")
+	}
+	return c.response.Read(p)
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr{} }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+// dohAddr is the placeholder address of a dohConn.
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "https" }
+func (dohAddr) String() string  { return "doh" }
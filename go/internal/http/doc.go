@@ -3,21 +3,115 @@
 // The Client in this package handles:
 //   - User-Agent headers for Bandcamp compatibility
 //   - File downloads with progress tracking
-//   - File size retrieval via HEAD requests
-//   - Timeout handling
+//   - Gzip and deflate compressed pages (GetString, GetPage), decompressed;
+//     downloads are never compressed
+//   - File size retrieval via HEAD requests, or a ranged GET of the first
+//     byte on servers rejecting HEAD
+//   - Conditional requests (ETag / If-Modified-Since)
+//   - HTTP and SOCKS5 proxies, with rotation and health-checking
+//   - IPv4/IPv6 selection and custom DNS resolvers (plain, DoT, DoH)
+//   - Custom root CAs and (explicitly unsafe) disabled TLS verification
+//   - Connect, TLS handshake and response header timeouts, an overall
+//     timeout for pages, and an idle timeout for file downloads
+//   - Request tracing to HAR or JSON Lines files for debugging (Tracer)
+//   - The cookies of a logged-in session, loaded from a cookies.txt file
+//     (LoadCookies)
 //
 // # Basic Usage
 //
-//	client := http.NewClient()
+//	client := http.NewClient(nil)
 //
 //	// Fetch HTML page
 //	html, err := client.GetString(ctx, "https://artist.bandcamp.com/album/name")
 //
+//	// Fetch HTML page along with its final URL after redirects
+//	page, err := client.GetPage(ctx, "http://artist.bandcamp.com/music/")
+//	fmt.Println(page.URL, page.Redirects)
+//
 //	// Download file with progress callback
 //	client.DownloadFile(ctx, mp3URL, "/path/to/file.mp3", func(written, total int64) {
 //	    fmt.Printf("%.1f%%\n", float64(written)/float64(total)*100)
 //	})
 //
+// # Conditional Requests
+//
+// GetConditional only downloads a resource if it changed since the
+// validators were recorded, which avoids re-fetching unchanged artwork:
+//
+//	res, err := client.GetConditional(ctx, artworkURL, http.Validators{ETag: etag})
+//	if res.NotModified {
+//	    // Use the cached copy
+//	}
+//
+// # Proxies
+//
+// ClientConfig.Proxies rotates requests across several proxies. A proxy
+// whose connection fails is skipped for ClientConfig.ProxyCooldown, and
+// CheckProxies probes all of them at once:
+//
+//	var proxies []*url.URL
+//	for _, addr := range []string{"socks5://127.0.0.1:1080", "10.0.0.2:3128"} {
+//	    u, err := http.ParseProxyURL(addr)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    proxies = append(proxies, u)
+//	}
+//	client := http.NewClient(&http.ClientConfig{Proxies: proxies})
+//	client.CheckProxies(ctx, "https://bandcamp.com")
+//
+// # Network Options
+//
+// ClientConfig.IPVersion restricts connections to IPv4 or IPv6, and
+// ClientConfig.DNSServers resolves host names with specific servers
+// instead of the system resolver:
+//
+//	doh, _ := http.ParseDNSServer("https://cloudflare-dns.com/dns-query")
+//	client := http.NewClient(&http.ClientConfig{
+//	    IPVersion:  4,
+//	    DNSServers: []*url.URL{doh},
+//	})
+//
+// Behind a TLS-intercepting proxy, trust its root certificate with
+// ClientConfig.RootCAs rather than disabling verification:
+//
+//	pool, err := http.LoadCAFile("/etc/ssl/corp-root.pem")
+//	client := http.NewClient(&http.ClientConfig{RootCAs: pool})
+//
+// # Segmented Downloads
+//
+// ClientConfig.Segments makes DownloadFile fetch large files as several byte
+// ranges in parallel, when the server supports ranges:
+//
+//	client := http.NewClient(&http.ClientConfig{Segments: 4, SegmentMinSize: 50 << 20})
+//	err := client.DownloadFile(ctx, mixURL, "/music/mix.mp3", nil)
+//
+// # Stream Formats
+//
+// WithContentType gives access to the Content-Type of a downloaded file,
+// which Extension maps to the extension of its format:
+//
+//	var contentType string
+//	err := client.DownloadFile(http.WithContentType(ctx, &contentType), streamURL, path, nil)
+//	ext := http.Extension(contentType, streamURL) // "mp3", "m4a", "flac"...
+//
+// # Retries
+//
+// ClientConfig.Retry retries the requests of every method that fail with a
+// connection error, an interrupted transfer or a retryable status code,
+// with exponential backoff and jitter. OnRetry reports each retry, with
+// the label given to the request's context by WithLabel:
+//
+//	client := http.NewClient(&http.ClientConfig{
+//	    Retry:   http.DefaultRetryPolicy(),
+//	    OnRetry: func(e http.RetryEvent) { log.Printf("retry %d for %s: %v", e.Attempt, e.Label, e.Err) },
+//	})
+//	err := client.DownloadFile(http.WithLabel(ctx, track.Title), mp3URL, path, nil)
+//
+// ClientConfig.Breaker adds a circuit breaker: after a number of
+// consecutive failures of a host, its requests wait for a cooldown instead
+// of using up their retries, and OnBreaker is notified.
+//
 // # Progress Tracking
 //
 // The ProgressWriter type can be used to wrap any io.Writer for progress tracking:
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// audioExtensions maps the media types of audio streams to the extension
+// of their files, without the dot.
+var audioExtensions = map[string]string{
+	"audio/mpeg":   "mp3",
+	"audio/mp3":    "mp3",
+	"audio/mpeg3":  "mp3",
+	"audio/x-mpeg": "mp3",
+	"audio/mp4":    "m4a",
+	"audio/x-m4a":  "m4a",
+	"audio/m4a":    "m4a",
+	"audio/aac":    "aac",
+	"audio/flac":   "flac",
+	"audio/x-flac": "flac",
+	"audio/ogg":    "ogg",
+	"audio/opus":   "opus",
+	"audio/wav":    "wav",
+	"audio/x-wav":  "wav",
+	"audio/aiff":   "aiff",
+	"audio/x-aiff": "aiff",
+}
+
+// knownExtensions is the set of the extensions of audioExtensions.
+var knownExtensions = func() map[string]bool {
+	exts := make(map[string]bool, len(audioExtensions))
+	for _, ext := range audioExtensions {
+		exts[ext] = true
+	}
+	return exts
+}()
+
+// AudioExtensions returns the extensions Extension can return, without
+// the dot, sorted.
+func AudioExtensions() []string {
+	exts := make([]string, 0, len(knownExtensions))
+	for ext := range knownExtensions {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	return exts
+}
+
+type contentTypeKey struct{}
+
+// WithContentType returns a context whose DownloadFile and ResumeFile
+// calls store the Content-Type header of the response in *contentType,
+// to find the format of the file with Extension.
+//
+// Example:
+//
+//	var contentType string
+//	err := client.DownloadFile(http.WithContentType(ctx, &contentType), streamURL, path, nil)
+//	ext := http.Extension(contentType, streamURL) // "mp3"
+func WithContentType(ctx context.Context, contentType *string) context.Context {
+	return context.WithValue(ctx, contentTypeKey{}, contentType)
+}
+
+// recordContentType stores the Content-Type of resp where WithContentType
+// asked for it.
+func recordContentType(ctx context.Context, resp *http.Response) {
+	if p, ok := ctx.Value(contentTypeKey{}).(*string); ok && p != nil {
+		*p = resp.Header.Get("Content-Type")
+	}
+}
+
+// Extension returns the extension, without the dot, of an audio file
+// served with the given Content-Type from rawURL, e.g. "mp3" for
+// "audio/mpeg" or "flac" for "audio/flac". If the media type is missing
+// or not an audio format, the extension of the URL's path is used when it
+// is one of an audio format. Returns "" if neither tells the format.
+func Extension(contentType, rawURL string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if ext, ok := audioExtensions[strings.ToLower(mediaType)]; ok {
+			return ext
+		}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(u.Path), "."))
+	if knownExtensions[ext] {
+		return ext
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/metrics"
+)
+
+// Metrics are the request metrics of a Client, for the long-running modes.
+//
+// Example:
+//
+//	reg := metrics.NewRegistry()
+//	client := NewClient(nil)
+//	client.SetMetrics(NewMetrics(reg))
+type Metrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+// NewMetrics registers the request metrics on reg:
+//
+//   - bandcamp_dl_http_requests_total{method,code}: requests made, by
+//     status code, or "error" if no response was received.
+//   - bandcamp_dl_http_request_duration_seconds{method}: time until the
+//     response headers were received.
+//
+// Metrics can be shared by several clients.
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	return &Metrics{
+		requests: reg.CounterVec("bandcamp_dl_http_requests_total", "HTTP requests made, by method and status code.", "method", "code"),
+		duration: reg.HistogramVec("bandcamp_dl_http_request_duration_seconds", "Time until the response headers were received.", metrics.DefBuckets, "method"),
+	}
+}
+
+// SetMetrics records the client's requests in m. It must be called before
+// the client is used.
+func (c *Client) SetMetrics(m *Metrics) {
+	c.httpClient.Transport = &instrumentedTransport{next: c.httpClient.Transport, metrics: m}
+}
+
+// instrumentedTransport records the requests it forwards in metrics.
+type instrumentedTransport struct {
+	next    http.RoundTripper
+	metrics *Metrics
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.metrics.duration.With(req.Method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.metrics.requests.With(req.Method, code).Inc()
+	return resp, err
+}
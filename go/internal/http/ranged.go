@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// contentRangeTotal returns the complete length given by the Content-Range
+// header of a 206 response ("bytes 0-1023/146515"), if known.
+func contentRangeTotal(resp *http.Response) (int64, bool) {
+	cr := resp.Header.Get("Content-Range")
+	unit, rest, ok := strings.Cut(cr, " ")
+	if !ok || unit != "bytes" {
+		return 0, false
+	}
+	_, total, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// rangedFileSize returns the size of the file at url from a GET request of
+// its first byte: the complete length of the Content-Range of a 206
+// response, or the Content-Length of a 200 response from a server
+// ignoring the range, whose body is not read.
+func (c *Client) rangedFileSize(ctx context.Context, url string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if total, ok := contentRangeTotal(resp); ok {
+			return total, nil
+		}
+		return 0, fmt.Errorf("no complete length in Content-Range %q for %s", resp.Header.Get("Content-Range"), url)
+	case http.StatusOK:
+		if resp.ContentLength < 0 {
+			return 0, fmt.Errorf("%w for %s", errNoContentLength, url)
+		}
+		return resp.ContentLength, nil
+	default:
+		return 0, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+}
+
+// contentRangeStart returns the first byte position of the Content-Range
+// header of a 206 response, or -1 if it cannot be parsed.
+func contentRangeStart(resp *http.Response) int64 {
+	_, rest, ok := strings.Cut(resp.Header.Get("Content-Range"), " ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// segmentProgress sums the bytes written by concurrent segments and
+// reports them to onProgress, one call at a time.
+type segmentProgress struct {
+	mu         sync.Mutex
+	written    int64
+	total      int64
+	onProgress func(written, total int64)
+}
+
+func (p *segmentProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written += n
+	if p.onProgress != nil {
+		p.onProgress(p.written, p.total)
+	}
+}
+
+// segmentWriter writes a segment at its offset in the file and counts the
+// bytes towards the progress.
+type segmentWriter struct {
+	w        io.Writer
+	progress *segmentProgress
+}
+
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.progress.add(int64(n))
+	return n, err
+}
+
+// downloadSegments downloads a file of total bytes to destPath as
+// c.segments ranges fetched in parallel. first is the response to the
+// initial "bytes=0-" request, which provides the first segment. Data
+// received on any segment keeps idle from firing.
+func (c *Client) downloadSegments(ctx context.Context, url, destPath string, first *http.Response, total int64, onProgress func(written, total int64), idle *idleTimer) error {
+	file, err := os.Create(destPath)
+	if err != nil {
+		first.Body.Close()
+		return err
+	}
+	defer file.Close()
+	if err := file.Truncate(total); err != nil {
+		first.Body.Close()
+		return err
+	}
+
+	size := (total + int64(c.segments) - 1) / int64(c.segments)
+	progress := &segmentProgress{total: total, onProgress: onProgress}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		defer first.Body.Close()
+		// first was requested with ctx: close it when another segment
+		// fails, to not wait for the rest of the first segment
+		stop := context.AfterFunc(gctx, func() { first.Body.Close() })
+		defer stop()
+		return copySegment(file, 0, min(size, total), idle.reader(first.Body), progress)
+	})
+	for start := size; start < total; start += size {
+		start, end := start, min(start+size, total)
+		g.Go(func() error {
+			return c.fetchSegment(gctx, url, file, start, end, progress, idle)
+		})
+	}
+	return g.Wait()
+}
+
+// fetchSegment downloads the bytes [start, end) of url into file.
+func (c *Client) fetchSegment(ctx context.Context, url string, file *os.File, start, end int64, progress *segmentProgress, idle *idleTimer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end-1))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+	if got := contentRangeStart(resp); got != start {
+		return fmt.Errorf("server returned the range starting at %d instead of %d", got, start)
+	}
+	return copySegment(file, start, end-start, idle.reader(resp.Body), progress)
+}
+
+// copySegment copies length bytes of r to file at offset.
+func copySegment(file *os.File, offset, length int64, r io.Reader, progress *segmentProgress) error {
+	w := &segmentWriter{w: io.NewOffsetWriter(file, offset), progress: progress}
+	n, err := io.Copy(w, io.LimitReader(r, length))
+	if err != nil {
+		return err
+	}
+	if n != length {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// ResumeFile is DownloadFile for a file whose first bytes may already be
+// at destPath, e.g. kept from an interrupted download: the rest is
+// requested with a "Range: bytes=<size>-" header and appended to it. If
+// the server ignores the range, or the file is longer than the stream,
+// the file is downloaded again from the start. Retried attempts resume
+// from the bytes written so far.
+//
+// onProgress is called with the bytes of the whole file, including those
+// that were already on disk. Segments are not used.
+func (c *Client) ResumeFile(ctx context.Context, url, destPath string, onProgress func(written, total int64)) error {
+	_, err := withRetry(ctx, c, url, func() (struct{}, error) {
+		idle := newIdleTimer(ctx, c.idleTimeout)
+		defer idle.stop()
+		return struct{}{}, idle.err(c.resumeFile(idle.ctx, url, destPath, onProgress, idle))
+	})
+	return err
+}
+
+// resumeFile makes a single attempt of ResumeFile, whose context is
+// canceled by idle when the transfer stalls.
+func (c *Client) resumeFile(ctx context.Context, url, destPath string, onProgress func(written, total int64), idle *idleTimer) error {
+	info, err := os.Stat(destPath)
+	if err != nil || info.Size() == 0 {
+		return c.downloadFile(ctx, url, destPath, onProgress, idle)
+	}
+	offset := info.Size()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	total := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if contentRangeStart(resp) != offset {
+			return fmt.Errorf("server returned the range starting at %d instead of %d", contentRangeStart(resp), offset)
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+		recordContentType(ctx, resp)
+		if n, ok := contentRangeTotal(resp); ok {
+			total = n
+		} else if total >= 0 {
+			total += offset
+		}
+	case http.StatusOK:
+		// The range was ignored: the response is the whole file
+		offset = 0
+		recordContentType(ctx, resp)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// "bytes */146515": the file is complete, or not this stream
+		if n, ok := contentRangeTotal(resp); ok && n == offset {
+			if onProgress != nil {
+				onProgress(offset, offset)
+			}
+			return nil
+		}
+		resp.Body.Close()
+		return c.downloadFile(ctx, url, destPath, onProgress, idle)
+	default:
+		return &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	file, err := os.OpenFile(destPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var writer io.Writer = file
+	if onProgress != nil {
+		writer = &ProgressWriter{
+			Writer:   file,
+			Total:    total,
+			Written:  offset,
+			OnUpdate: onProgress,
+		}
+	}
+
+	_, err = io.Copy(writer, idle.reader(resp.Body))
+	return err
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy defines how failed requests are retried by a Client.
+//
+// The delay before retry n (starting at 0) is BaseDelay * Multiplier^n,
+// capped to MaxDelay, then randomized by ±Jitter so that concurrent
+// downloads failing together do not retry in lockstep.
+//
+// Example:
+//
+//	client := NewClient(&ClientConfig{
+//	    Retry: &RetryPolicy{
+//	        MaxAttempts:   5,
+//	        BaseDelay:     500 * time.Millisecond,
+//	        Multiplier:    2,
+//	        MaxDelay:      30 * time.Second,
+//	        Jitter:        0.2,
+//	        RetryStatuses: []string{"429", "5xx"},
+//	    },
+//	})
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts of a request, including
+	// the first one. 0 or 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+
+	// Multiplier is the factor applied to the delay after each retry.
+	// Values below 1 are treated as 1.
+	Multiplier float64
+
+	// MaxDelay caps the delay between two attempts. Zero means no cap.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction, between 0 and 1, by which each delay is
+	// randomly shortened or lengthened.
+	Jitter float64
+
+	// RetryStatuses lists the HTTP status codes that are retried, either
+	// exact ("429") or as a class ("5xx"). Connection errors and
+	// interrupted transfers are always retried.
+	RetryStatuses []string
+}
+
+// DefaultRetryPolicy returns the default retry policy: 7 attempts, starting
+// at 200ms and multiplied by 4, capped to a minute, with 20% jitter, for
+// timeouts (408), rate limiting (429) and server errors (5xx).
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:   7,
+		BaseDelay:     200 * time.Millisecond,
+		Multiplier:    4,
+		MaxDelay:      time.Minute,
+		Jitter:        0.2,
+		RetryStatuses: []string{"408", "429", "5xx"},
+	}
+}
+
+// ValidateRetryStatus checks that s is a status code ("503") or a status
+// class ("5xx") usable in RetryPolicy.RetryStatuses.
+func ValidateRetryStatus(s string) error {
+	if len(s) == 3 && s[1:] == "xx" && s[0] >= '1' && s[0] <= '5' {
+		return nil
+	}
+	if code, err := strconv.Atoi(s); err == nil && code >= 100 && code <= 599 {
+		return nil
+	}
+	return fmt.Errorf("invalid retry status %q, must be a status code (503) or class (5xx)", s)
+}
+
+// Delay returns the delay before retry n (starting at 0), for a random
+// value rnd in [0, 1).
+func (p *RetryPolicy) Delay(n int, rnd float64) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(max(p.Multiplier, 1), float64(n))
+	if p.MaxDelay > 0 {
+		delay = min(delay, float64(p.MaxDelay))
+	}
+	jitter := min(max(p.Jitter, 0), 1)
+	delay *= 1 - jitter + 2*jitter*rnd
+	return time.Duration(delay)
+}
+
+// retryableStatus reports whether code matches RetryStatuses.
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	s := strconv.Itoa(code)
+	for _, pattern := range p.RetryStatuses {
+		if pattern == s || (len(pattern) == 3 && pattern[1:] == "xx" && pattern[0] == s[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryable reports whether a request that failed with err may succeed
+// if attempted again.
+func (p *RetryPolicy) retryable(err error) bool {
+	var statusErr *StatusError
+	var pathErr *fs.PathError
+	var urlErr *url.Error
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrIdleTimeout):
+		return true
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		// The request's own timeout; the caller's deadline ends the retries
+		// before this is checked
+		return true
+	case errors.As(err, &statusErr):
+		return p.retryableStatus(statusErr.Code)
+	case errors.As(err, &pathErr):
+		// Local file errors do not go away by downloading again
+		return false
+	case errors.As(err, &urlErr), errors.As(err, &netErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	// Label is the label of the request's context (see WithLabel), or ""
+	Label string
+
+	// URL is the requested URL.
+	URL string
+
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+
+	// MaxAttempts is RetryPolicy.MaxAttempts.
+	MaxAttempts int
+
+	// Delay is the time until the next attempt.
+	Delay time.Duration
+
+	// Err is the error of the failed attempt.
+	Err error
+}
+
+type labelKey struct{}
+
+// WithLabel returns a context whose requests are reported with label in
+// RetryEvents, e.g. the title of the track being downloaded.
+func WithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+type noRetryKey struct{}
+
+// WithoutRetry returns a context whose requests failing with an error for
+// which skip returns true are not retried, e.g. the errors the caller
+// handles itself by trying another host.
+func WithoutRetry(ctx context.Context, skip func(error) bool) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, skip)
+}
+
+// withRetry calls attempt until it succeeds, fails with an error that is
+// not retryable, or the client's retry policy runs out of attempts. Each
+// attempt first waits for the circuit breaker of url's host to close.
+func withRetry[T any](ctx context.Context, c *Client, url string, attempt func() (T, error)) (T, error) {
+	policy := c.retry
+	for n := 1; ; n++ {
+		if err := c.breaker.wait(ctx, url); err != nil {
+			var zero T
+			return zero, err
+		}
+		result, err := attempt()
+		if ctx.Err() == nil {
+			c.breaker.record(url, err)
+		}
+		if err == nil || policy == nil || n >= policy.MaxAttempts || ctx.Err() != nil || !policy.retryable(err) {
+			return result, err
+		}
+		if skip, ok := ctx.Value(noRetryKey{}).(func(error) bool); ok && skip(err) {
+			return result, err
+		}
+
+		delay := policy.Delay(n-1, rand.Float64())
+		if c.onRetry != nil {
+			label, _ := ctx.Value(labelKey{}).(string)
+			c.onRetry(RetryEvent{Label: label, URL: url, Attempt: n, MaxAttempts: policy.MaxAttempts, Delay: delay, Err: err})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrIdleTimeout is returned by DownloadFile when no data is received for
+// ClientConfig.IdleTimeout.
+var ErrIdleTimeout = errors.New("no data received within the idle timeout")
+
+// idleTimer cancels its context when it is not reset for a timeout. Reads
+// through reader reset it, so a transfer is only aborted when it stalls,
+// however long it takes. A timer without a timeout never cancels it.
+type idleTimer struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func newIdleTimer(ctx context.Context, timeout time.Duration) *idleTimer {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &idleTimer{ctx: ctx, cancel: cancel, timeout: timeout}
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() { cancel(ErrIdleTimeout) })
+	}
+	return t
+}
+
+// reader returns r, resetting the timer whenever data is read from it.
+func (t *idleTimer) reader(r io.Reader) io.Reader {
+	if t.timer == nil {
+		return r
+	}
+	return &idleReader{r: r, idle: t}
+}
+
+// err returns ErrIdleTimeout if the timer canceled the request that failed
+// with err, err otherwise.
+func (t *idleTimer) err(err error) error {
+	if err != nil && errors.Is(context.Cause(t.ctx), ErrIdleTimeout) {
+		return ErrIdleTimeout
+	}
+	return err
+}
+
+// stop releases the timer and its context.
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.cancel(nil)
+}
+
+type idleReader struct {
+	r    io.Reader
+	idle *idleTimer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.idle.timer.Reset(r.idle.timeout)
+	}
+	return n, err
+}
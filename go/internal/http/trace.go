@@ -0,0 +1,426 @@
+package http
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceBodyLimit is how many bytes of each body a Tracer records.
+const traceBodyLimit = 64 << 10
+
+// redactedHeaders are the headers whose values a Tracer does not record,
+// so traces can be shared.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Tracer records the requests of clients and their responses, with their
+// headers, timings and the first traceBodyLimit bytes of their text
+// bodies, for debugging, e.g. to attach the page a parser failed on to a
+// bug report. Credentials and cookies are redacted.
+//
+// A file whose name ends with ".har" is an HTTP Archive, which browsers'
+// developer tools and HAR viewers open, written when the Tracer is closed.
+// Other files are JSON Lines, with one HAR entry per line written as each
+// request completes, so they are kept when the process is killed.
+//
+// Example:
+//
+//	tracer, err := http.NewTracer("debug.har")
+//	client.SetTracer(tracer)
+//	defer tracer.Close()
+type Tracer struct {
+	mu      sync.Mutex
+	file    *os.File
+	har     bool
+	entries []harEntry // of a HAR file, written by Close
+	err     error      // first error writing a JSON Lines entry
+}
+
+// NewTracer creates the trace file at path, replacing any existing one.
+func NewTracer(path string) (*Tracer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{file: f, har: strings.EqualFold(filepath.Ext(path), ".har")}, nil
+}
+
+// Close writes the HAR file, or reports the first error writing the JSON
+// Lines file, and closes it. Requests completing afterwards are not
+// recorded.
+func (t *Tracer) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return nil
+	}
+
+	err := t.err
+	if t.har {
+		slices.SortStableFunc(t.entries, func(a, b harEntry) int {
+			return strings.Compare(a.StartedDateTime, b.StartedDateTime)
+		})
+		var log harLog
+		log.Log.Version = "1.2"
+		log.Log.Creator = harCreator{Name: "bandcamp-downloader", Version: buildVersion()}
+		log.Log.Entries = t.entries
+		if log.Log.Entries == nil {
+			log.Log.Entries = []harEntry{}
+		}
+		enc := json.NewEncoder(t.file)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(log)
+	}
+	if cerr := t.file.Close(); err == nil {
+		err = cerr
+	}
+	t.file = nil
+	return err
+}
+
+// record adds a completed entry to the trace.
+func (t *Tracer) record(entry harEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.file == nil:
+	case t.har:
+		t.entries = append(t.entries, entry)
+	case t.err == nil:
+		t.err = json.NewEncoder(t.file).Encode(entry)
+	}
+}
+
+// SetTracer records the client's requests with t. It must be called
+// before the client is used.
+func (c *Client) SetTracer(t *Tracer) {
+	c.httpClient.Transport = &tracingTransport{next: c.httpClient.Transport, tracer: t}
+}
+
+// tracingTransport records the requests it forwards with tracer.
+type tracingTransport struct {
+	next   http.RoundTripper
+	tracer *Tracer
+}
+
+// RoundTrip implements http.RoundTripper. The entry of a response is
+// recorded once its body is closed, with the time taken to read it.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+		Cache: struct{}{},
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if req.GetBody != nil && req.ContentLength != 0 {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, traceBodyLimit))
+			body.Close()
+			entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(data)}
+		}
+	}
+
+	timings := &traceTimings{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timings.clientTrace()))
+	resp, err := t.next.RoundTrip(req)
+	headers := time.Now()
+	entry.Timings = timings.har(start, headers)
+
+	if err != nil {
+		entry.Time = ms(headers.Sub(start))
+		entry.Response = harResponse{Headers: []harNameValue{}, Cookies: []harNameValue{}, HeadersSize: -1, BodySize: -1, Content: harContent{Size: -1}}
+		entry.Comment = err.Error()
+		t.tracer.record(entry)
+		return nil, err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	entry.Request.HTTPVersion = resp.Proto
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)+" "),
+		HTTPVersion: resp.Proto,
+		Headers:     harHeaders(resp.Header),
+		Cookies:     []harNameValue{},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		Content:     harContent{MimeType: contentType},
+	}
+	if req.Method == http.MethodHead {
+		entry.Response.Content.Size = max(resp.ContentLength, 0)
+		entry.Time = ms(headers.Sub(start))
+		t.tracer.record(entry)
+		return resp, nil
+	}
+
+	resp.Body = &tracedBody{
+		ReadCloser: resp.Body,
+		text:       isText(contentType),
+		finish: func(body []byte, size int64, truncated bool) {
+			end := time.Now()
+			entry.Time = ms(end.Sub(start))
+			entry.Timings.Receive = ms(end.Sub(headers))
+			entry.Response.BodySize = size
+			entry.Response.Content.Size = size
+			if body != nil {
+				entry.Response.Content.Text = decodeTraced(resp.Header.Get("Content-Encoding"), body)
+				if truncated || len(entry.Response.Content.Text) > traceBodyLimit {
+					entry.Response.Content.Text = entry.Response.Content.Text[:min(len(entry.Response.Content.Text), traceBodyLimit)]
+					entry.Response.Content.Comment = "truncated"
+				}
+			}
+			t.tracer.record(entry)
+		},
+	}
+	return resp, nil
+}
+
+// tracedBody records the first traceBodyLimit bytes of a text body, and
+// calls finish when it is closed.
+type tracedBody struct {
+	io.ReadCloser
+	text   bool
+	buf    bytes.Buffer
+	size   int64
+	once   sync.Once
+	finish func(body []byte, size int64, truncated bool)
+}
+
+// Read implements io.Reader.
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if b.text && b.buf.Len() < traceBodyLimit {
+		b.buf.Write(p[:min(n, traceBodyLimit-b.buf.Len())])
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		var body []byte
+		if b.text {
+			body = b.buf.Bytes()
+		}
+		b.finish(body, b.size, b.size > int64(b.buf.Len()))
+	})
+	return err
+}
+
+// isText reports whether a body of type contentType is recorded: text,
+// JSON, XML and JavaScript, but not audio or images.
+func isText(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") || strings.HasSuffix(mediaType, "javascript")
+}
+
+// decodeTraced returns the start of a body compressed with encoding, as
+// far as it can be decompressed.
+func decodeTraced(encoding string, body []byte) string {
+	var r io.Reader
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return string(body)
+		}
+		r = zr
+	case "deflate":
+		r = deflateReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	// The body may be truncated: keep what was decompressed
+	data, _ := io.ReadAll(io.LimitReader(r, traceBodyLimit+1))
+	return string(data)
+}
+
+// harHeaders returns header as HAR name/value pairs, sorted by name, with
+// the values of redactedHeaders replaced.
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			if slices.Contains(redactedHeaders, http.CanonicalHeaderKey(name)) {
+				value = "[redacted]"
+			}
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b harNameValue) int { return strings.Compare(a.Name, b.Name) })
+	return pairs
+}
+
+// traceTimings are the times of the phases of a request, from httptrace.
+type traceTimings struct {
+	mu                   sync.Mutex
+	dnsStart, dnsDone    time.Time
+	connStart, connDone  time.Time
+	tlsStart, tlsDone    time.Time
+	wroteRequest, gotHdr time.Time
+}
+
+// clientTrace returns the hooks recording the timings.
+func (tt *traceTimings) clientTrace() *httptrace.ClientTrace {
+	set := func(t *time.Time) {
+		tt.mu.Lock()
+		*t = time.Now()
+		tt.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(&tt.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(&tt.dnsDone) },
+		ConnectStart:         func(string, string) { set(&tt.connStart) },
+		ConnectDone:          func(string, string, error) { set(&tt.connDone) },
+		TLSHandshakeStart:    func() { set(&tt.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(&tt.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&tt.wroteRequest) },
+		GotFirstResponseByte: func() { set(&tt.gotHdr) },
+	}
+}
+
+// har returns the HAR timings of a request started at start whose response
+// headers were received at headers. Phases that did not happen, like the
+// DNS lookup and connection of a reused connection, are -1.
+func (tt *traceTimings) har(start, headers time.Time) harTimings {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	phase := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return ms(to.Sub(from))
+	}
+	timings := harTimings{
+		DNS:     phase(tt.dnsStart, tt.dnsDone),
+		Connect: phase(tt.connStart, tt.connDone),
+		SSL:     phase(tt.tlsStart, tt.tlsDone),
+		Send:    0,
+		Wait:    phase(tt.wroteRequest, cmp.Or(tt.gotHdr, headers)),
+	}
+	if timings.Wait < 0 {
+		timings.Wait = ms(headers.Sub(start))
+	}
+	return timings
+}
+
+// ms converts d to milliseconds, the unit of HAR times.
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// buildVersion returns the module version of the binary, for the HAR
+// creator.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// The HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/.
+type (
+	harLog struct {
+		Log struct {
+			Version string     `json:"version"`
+			Creator harCreator `json:"creator"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		Comment         string      `json:"comment,omitempty"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		Cookies     []harNameValue `json:"cookies"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Headers     []harNameValue `json:"headers"`
+		Cookies     []harNameValue `json:"cookies"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+
+	harContent struct {
+		Size     int64  `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Comment  string `json:"comment,omitempty"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harTimings struct {
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		SSL     float64 `json:"ssl"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
@@ -0,0 +1,23 @@
+// Package i18n translates the messages of the CLI and TUI.
+//
+// Messages are looked up by key in the catalog of a language, and
+// formatted with fmt.Sprintf:
+//
+//	lang := i18n.New(settings.Language) // "" follows the environment
+//	fmt.Println(lang.T("cli.starting"))
+//	fmt.Println(lang.T("retag.done", 12))
+//
+// # Languages
+//
+// English (en), French (fr), German (de) and Spanish (es) are available,
+// see Languages. A language is given as a code, optionally followed by a
+// region and an encoding as in locales, e.g. "fr" or "fr_CA.UTF-8". When
+// no language is configured, it is taken from the first of LANGUAGE,
+// LC_ALL, LC_MESSAGES and LANG that is set. Unknown languages use English.
+//
+// # Catalogs
+//
+// The English catalog holds every key. Other catalogs may lack keys, e.g.
+// for messages added since they were translated: those fall back to
+// English. The log messages of downloads and errors are not translated.
+package i18n
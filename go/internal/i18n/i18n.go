@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fallback is the language of the messages missing from a catalog.
+const fallback = "en"
+
+// catalogs maps language codes to their messages, keyed by message key.
+var catalogs = map[string]map[string]string{
+	"en": english,
+	"fr": french,
+	"de": german,
+	"es": spanish,
+}
+
+// Catalog translates messages to one language.
+type Catalog struct {
+	lang     string
+	messages map[string]string
+}
+
+// New returns the catalog of lang, a language code such as "fr" or a
+// locale such as "fr_CA.UTF-8". If lang is empty, the language is taken
+// from the environment. Unknown languages get the English catalog.
+func New(lang string) *Catalog {
+	if lang == "" {
+		lang = envLanguage()
+	}
+	code := Normalize(lang)
+	messages, ok := catalogs[code]
+	if !ok {
+		code, messages = fallback, catalogs[fallback]
+	}
+	return &Catalog{lang: code, messages: messages}
+}
+
+// Language returns the code of the catalog's language, e.g. "fr".
+func (c *Catalog) Language() string {
+	return c.lang
+}
+
+// T returns the message of key in the catalog's language, formatted with
+// args. Messages missing from the catalog are taken from English, and
+// unknown keys are returned as-is.
+func (c *Catalog) T(key string, args ...any) string {
+	message, ok := c.messages[key]
+	if !ok {
+		if message, ok = english[key]; !ok {
+			message = key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Languages returns the codes of the available languages, sorted.
+func Languages() []string {
+	codes := make([]string, 0, len(catalogs))
+	for code := range catalogs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Supported reports whether lang, as accepted by New, has a catalog.
+func Supported(lang string) bool {
+	_, ok := catalogs[Normalize(lang)]
+	return ok
+}
+
+// Normalize returns the language code of lang, a language code or a
+// locale: "fr_CA.UTF-8" and "FR-ca" give "fr".
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// envLanguage returns the language of the environment, from the first of
+// LANGUAGE (a colon-separated list, of which the first is used), LC_ALL,
+// LC_MESSAGES and LANG that is set. The "C" and "POSIX" locales are not
+// languages.
+func envLanguage() string {
+	for _, name := range []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		value, _, _ := strings.Cut(os.Getenv(name), ":")
+		if value != "" && value != "C" && value != "POSIX" {
+			return value
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+// verbPattern matches the formatting verbs of a message.
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9.]*[a-zA-Z%]`)
+
+func TestCatalogs(t *testing.T) {
+	for code, messages := range catalogs {
+		for key, message := range messages {
+			want, ok := english[key]
+			if !ok {
+				t.Errorf("%s: key %q is not in the English catalog", code, key)
+				continue
+			}
+			got, wantVerbs := verbPattern.FindAllString(message, -1), verbPattern.FindAllString(want, -1)
+			if len(got) != len(wantVerbs) {
+				t.Errorf("%s: %q has verbs %q, want %q", code, key, got, wantVerbs)
+				continue
+			}
+			for i := range got {
+				if got[i] != wantVerbs[i] {
+					t.Errorf("%s: %q has verbs %q, want %q", code, key, got, wantVerbs)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		lang string
+		env  string
+		want string
+	}{
+		{"fr", "", "fr"},
+		{"de_AT.UTF-8", "", "de"},
+		{"ES-mx", "", "es"},
+		{"xx", "", "en"},
+		{"", "fr_FR.UTF-8", "fr"},
+		{"", "C", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.env, func(t *testing.T) {
+			t.Setenv("LANGUAGE", "")
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_MESSAGES", "")
+			t.Setenv("LANG", tt.env)
+			if got := New(tt.lang).Language(); got != tt.want {
+				t.Errorf("New(%q).Language() with LANG=%q = %q, want %q", tt.lang, tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalog_T(t *testing.T) {
+	fr := New("fr")
+	if got := fr.T("retag.done", 3); got != "Tags réécrits pour 3 fichier(s)" {
+		t.Errorf("T(retag.done) = %q", got)
+	}
+
+	// Keys missing from a catalog are taken from English
+	fr.messages = map[string]string{}
+	if got := fr.T("verify.issues", 2); got != "2 issue(s) found" {
+		t.Errorf("T(verify.issues) = %q, want the English message", got)
+	}
+	if got := fr.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("T(no.such.key) = %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package i18n
+
+var german = map[string]string{
+	"app.title": "Bandcamp Downloader",
+
+	"cli.dry_run":         "[Probelauf - kein Download]",
+	"cli.starting":        "Downloads werden gestartet...",
+	"cli.cancelled":       "Download abgebrochen.",
+	"cli.confirm_size":    "%d Album/Alben herunterladen, %.2f MB? [j/N] ",
+	"cli.yes":             "j",
+	"cli.too_large":       "Der Download von %.2f MB überschreitet confirm_if_larger_than_mb; mit -yes trotzdem herunterladen",
+	"cli.aborted":         "Download abgebrochen.",
+	"cli.imported":        "%d Release-URL(s) aus %s importiert",
+	"cli.complete":        "Fertig! %d/%d Dateien heruntergeladen (%.2f MB)",
+	"cli.already_present": "(%.2f MB bereits vorhanden, übersprungen)",
+	"cli.videos_skipped":  "(%d Video(s) übersprungen, nicht als Audio herunterladbar)",
+	"cli.expected":        "(%.2f MB erwartet)",
+	"cli.failed":          "(%d Veröffentlichung(en) fehlgeschlagen)",
+	"cli.resume":          "(verbleibende Veröffentlichungen in %s gespeichert, weiter mit -url \"$(cat %[1]s)\")",
+	"cli.tracks":          "Titel: %d heruntergeladen, %d bereits vorhanden, %d nicht verfügbar, %d fehlgeschlagen",
+	"cli.truncated":       "(%d Titel kürzer oder länger als in den Metadaten, möglicherweise abgeschnitten)",
+	"cli.elapsed":         "Dauer: %s (durchschnittlich %.2f MB/s)",
+
+	"retag.cancelled": "Neu-Taggen abgebrochen.",
+	"retag.done":      "%d Datei(en) neu getaggt",
+
+	"playlists.cancelled": "Playlist-Erstellung abgebrochen.",
+	"playlists.done":      "%d Playlist(s) geschrieben",
+
+	"verify.cancelled":     "Prüfung abgebrochen.",
+	"verify.ok":            "Alle Dateien geprüft",
+	"verify.issues":        "%d Problem(e) gefunden",
+	"verify.fixing":        "Wird behoben...",
+	"verify.fix_cancelled": "Behebung abgebrochen.",
+	"verify.upgraded":      "%d Titel verbessert",
+
+	"tui.subtitle":        "Musik von Bandcamp herunterladen",
+	"tui.enter_url":       "Bandcamp-URL oder Suchbegriff eingeben:",
+	"tui.options":         "Optionen:",
+	"tui.opt_discography": "Diskografie herunterladen (d)",
+	"tui.opt_playlist":    "Playlist erstellen (p)",
+	"tui.opt_verbose":     "Ausführliche Ausgabe (v)",
+	"tui.download_path":   "Download-Ordner: %s",
+	"tui.fetching":        "Albuminfos werden abgerufen...",
+	"tui.found_albums":    "%d Album/Alben gefunden:",
+	"tui.estimated":       "Geschätzte Größe: %.1f MB",
+	"tui.too_large":       "%.2f MB überschreiten die Bestätigungsgrenze von %d MB, Enter drücken, um trotzdem herunterzuladen",
+	"tui.downloading":     "Wird heruntergeladen...",
+	"tui.progress":        "Dateien: %d/%d | Heruntergeladen: %.2f MB",
+	"tui.complete":        "Download abgeschlossen!",
+	"tui.albums":          "Alben: %d",
+	"tui.files":           "Dateien: %d",
+	"tui.size":            "Größe: %.2f MB",
+	"tui.tracks":          "Titel: %d heruntergeladen, %d bereits vorhanden, %d nicht verfügbar, %d fehlgeschlagen",
+	"tui.truncated":       "Abweichende Dauer: %d Titel, möglicherweise abgeschnitten",
+	"tui.elapsed":         "Dauer: %s (%.2f MB/s)",
+	"tui.error":           "Ein Fehler ist aufgetreten:",
+	"tui.cancelled":       "vom Benutzer abgebrochen",
+	"tui.status":          "Status: %s",
+	"tui.opened":          "%s geöffnet",
+	"tui.open_failed":     "%s kann nicht geöffnet werden: %v",
+	"tui.remote":          "Daemon: %s",
+	"tui.remote_job":      "Auftrag %d",
+	"tui.jobs":            "Aufträge:",
+	"tui.no_jobs":         "Keine Aufträge",
+	"tui.remote_error":    "Daemon nicht erreichbar: %v",
+	"tui.submitting":      "Wird an den Daemon gesendet...",
+	"tui.searching":       "Suche läuft...",
+	"tui.results":         "%d Ergebnis(se) für %q:",
+	"tui.no_results":      "Keine Ergebnisse für %q",
+
+	"tui.key_start":       "Enter: starten",
+	"tui.key_history":     "hoch/runter: letzte URLs",
+	"tui.key_discography": "d: Diskografie",
+	"tui.key_playlist":    "p: Playlist",
+	"tui.key_verbose":     "v: ausführlich",
+	"tui.key_exit":        "Esc: beenden",
+	"tui.key_download":    "Enter: herunterladen",
+	"tui.key_select":      "hoch/runter: auswählen",
+	"tui.key_tracks":      "Leertaste: Titel",
+	"tui.key_cancel":      "Esc: abbrechen",
+	"tui.key_back":        "Esc: zurück",
+	"tui.key_open":        "o: Ordner öffnen",
+	"tui.key_new":         "r: neuer Download",
+	"tui.key_quit":        "q: beenden",
+	"tui.key_attach":      "Tab: aktuellen Auftrag verfolgen",
+	"tui.key_detach":      "Strg+C: trennen",
+}
@@ -0,0 +1,89 @@
+package i18n
+
+// english holds every message key, and is the fallback of the others.
+var english = map[string]string{
+	"app.title": "Bandcamp Downloader",
+
+	"cli.dry_run":         "[Dry run - not downloading]",
+	"cli.starting":        "Starting downloads...",
+	"cli.cancelled":       "Download cancelled.",
+	"cli.confirm_size":    "Download %d album(s), %.2f MB? [y/N] ",
+	"cli.yes":             "y",
+	"cli.too_large":       "Download of %.2f MB is over confirm_if_larger_than_mb; use -yes to download it anyway",
+	"cli.aborted":         "Download aborted.",
+	"cli.imported":        "Imported %d release URL(s) from %s",
+	"cli.complete":        "Complete! Downloaded %d/%d files (%.2f MB)",
+	"cli.already_present": "(%.2f MB already present, skipped)",
+	"cli.videos_skipped":  "(%d video item(s) skipped, not downloadable as audio)",
+	"cli.expected":        "(%.2f MB expected)",
+	"cli.failed":          "(%d release(s) failed)",
+	"cli.resume":          "(releases left saved to %s, continue with -url \"$(cat %[1]s)\")",
+	"cli.tracks":          "Tracks: %d downloaded, %d already present, %d unavailable, %d failed",
+	"cli.truncated":       "(%d track(s) shorter or longer than their metadata, possibly truncated)",
+	"cli.elapsed":         "Took %s (%.2f MB/s on average)",
+
+	"retag.cancelled": "Retag cancelled.",
+	"retag.done":      "Retagged %d file(s)",
+
+	"playlists.cancelled": "Playlists cancelled.",
+	"playlists.done":      "Wrote %d playlist(s)",
+
+	"verify.cancelled":     "Verify cancelled.",
+	"verify.ok":            "All files verified",
+	"verify.issues":        "%d issue(s) found",
+	"verify.fixing":        "Fixing...",
+	"verify.fix_cancelled": "Fix cancelled.",
+	"verify.upgraded":      "%d track(s) upgraded",
+
+	"tui.subtitle":        "Download music from Bandcamp",
+	"tui.enter_url":       "Enter a Bandcamp URL, or a name to search for:",
+	"tui.options":         "Options:",
+	"tui.opt_discography": "Download discography (d)",
+	"tui.opt_playlist":    "Create playlist (p)",
+	"tui.opt_verbose":     "Verbose/debug output (v)",
+	"tui.download_path":   "Download path: %s",
+	"tui.fetching":        "Fetching album info...",
+	"tui.found_albums":    "Found %d album(s):",
+	"tui.estimated":       "Estimated size: %.1f MB",
+	"tui.too_large":       "%.2f MB is over the %d MB confirmation threshold, press enter to download anyway",
+	"tui.downloading":     "Downloading...",
+	"tui.progress":        "Files: %d/%d | Downloaded: %.2f MB",
+	"tui.complete":        "Download Complete!",
+	"tui.albums":          "Albums: %d",
+	"tui.files":           "Files: %d",
+	"tui.size":            "Size: %.2f MB",
+	"tui.tracks":          "Tracks: %d downloaded, %d already present, %d unavailable, %d failed",
+	"tui.truncated":       "Duration mismatches: %d track(s), possibly truncated",
+	"tui.elapsed":         "Time: %s (%.2f MB/s)",
+	"tui.error":           "Error occurred:",
+	"tui.cancelled":       "cancelled by user",
+	"tui.status":          "Status: %s",
+	"tui.opened":          "Opened %s",
+	"tui.open_failed":     "Cannot open %s: %v",
+	"tui.remote":          "Daemon: %s",
+	"tui.remote_job":      "Job %d",
+	"tui.jobs":            "Jobs:",
+	"tui.no_jobs":         "No jobs",
+	"tui.remote_error":    "Cannot reach the daemon: %v",
+	"tui.submitting":      "Submitting to the daemon...",
+	"tui.searching":       "Searching...",
+	"tui.results":         "%d result(s) for %q:",
+	"tui.no_results":      "No results for %q",
+
+	"tui.key_start":       "enter: start",
+	"tui.key_history":     "up/down: recent URLs",
+	"tui.key_discography": "d: discography",
+	"tui.key_playlist":    "p: playlist",
+	"tui.key_verbose":     "v: verbose",
+	"tui.key_exit":        "esc: quit",
+	"tui.key_download":    "enter: download",
+	"tui.key_select":      "up/down: select",
+	"tui.key_tracks":      "space: tracks",
+	"tui.key_cancel":      "esc: cancel",
+	"tui.key_back":        "esc: back",
+	"tui.key_open":        "o: open folder",
+	"tui.key_new":         "r: new download",
+	"tui.key_quit":        "q: quit",
+	"tui.key_attach":      "tab: watch current job",
+	"tui.key_detach":      "ctrl+c: detach",
+}
@@ -0,0 +1,88 @@
+package i18n
+
+var spanish = map[string]string{
+	"app.title": "Bandcamp Downloader",
+
+	"cli.dry_run":         "[Simulación - sin descargar]",
+	"cli.starting":        "Iniciando descargas...",
+	"cli.cancelled":       "Descarga cancelada.",
+	"cli.confirm_size":    "¿Descargar %d álbum(es), %.2f MB? [s/N] ",
+	"cli.yes":             "s",
+	"cli.too_large":       "La descarga de %.2f MB supera confirm_if_larger_than_mb; use -yes para descargarla igualmente",
+	"cli.aborted":         "Descarga cancelada.",
+	"cli.imported":        "%d URL(s) de lanzamientos importada(s) desde %s",
+	"cli.complete":        "¡Completado! %d/%d archivos descargados (%.2f MB)",
+	"cli.already_present": "(%.2f MB ya presentes, omitidos)",
+	"cli.videos_skipped":  "(%d vídeo(s) omitido(s), no descargables como audio)",
+	"cli.expected":        "(%.2f MB esperados)",
+	"cli.failed":          "(%d lanzamiento(s) fallido(s))",
+	"cli.resume":          "(lanzamientos restantes guardados en %s, continúa con -url \"$(cat %[1]s)\")",
+	"cli.tracks":          "Pistas: %d descargada(s), %d ya presente(s), %d no disponible(s), %d fallida(s)",
+	"cli.truncated":       "(%d pista(s) más corta(s) o más larga(s) que sus metadatos, posiblemente truncada(s))",
+	"cli.elapsed":         "Duración: %s (%.2f MB/s de media)",
+
+	"retag.cancelled": "Reetiquetado cancelado.",
+	"retag.done":      "%d archivo(s) reetiquetado(s)",
+
+	"playlists.cancelled": "Creación de listas cancelada.",
+	"playlists.done":      "%d lista(s) de reproducción escrita(s)",
+
+	"verify.cancelled":     "Verificación cancelada.",
+	"verify.ok":            "Todos los archivos verificados",
+	"verify.issues":        "%d problema(s) encontrado(s)",
+	"verify.fixing":        "Corrigiendo...",
+	"verify.fix_cancelled": "Corrección cancelada.",
+	"verify.upgraded":      "%d pista(s) mejorada(s)",
+
+	"tui.subtitle":        "Descarga música de Bandcamp",
+	"tui.enter_url":       "Introduce una URL de Bandcamp o un nombre para buscar:",
+	"tui.options":         "Opciones:",
+	"tui.opt_discography": "Descargar la discografía (d)",
+	"tui.opt_playlist":    "Crear lista de reproducción (p)",
+	"tui.opt_verbose":     "Salida detallada (v)",
+	"tui.download_path":   "Carpeta de descarga: %s",
+	"tui.fetching":        "Obteniendo información de los álbumes...",
+	"tui.found_albums":    "%d álbum(es) encontrado(s):",
+	"tui.estimated":       "Tamaño estimado: %.1f MB",
+	"tui.too_large":       "%.2f MB supera el umbral de confirmación de %d MB, pulse intro para descargar igualmente",
+	"tui.downloading":     "Descargando...",
+	"tui.progress":        "Archivos: %d/%d | Descargado: %.2f MB",
+	"tui.complete":        "¡Descarga completada!",
+	"tui.albums":          "Álbumes: %d",
+	"tui.files":           "Archivos: %d",
+	"tui.size":            "Tamaño: %.2f MB",
+	"tui.tracks":          "Pistas: %d descargada(s), %d ya presente(s), %d no disponible(s), %d fallida(s)",
+	"tui.truncated":       "Duraciones incoherentes: %d pista(s), posiblemente truncada(s)",
+	"tui.elapsed":         "Duración: %s (%.2f MB/s)",
+	"tui.error":           "Se produjo un error:",
+	"tui.cancelled":       "cancelado por el usuario",
+	"tui.status":          "Estado: %s",
+	"tui.opened":          "%s abierta",
+	"tui.open_failed":     "No se puede abrir %s: %v",
+	"tui.remote":          "Demonio: %s",
+	"tui.remote_job":      "Tarea %d",
+	"tui.jobs":            "Tareas:",
+	"tui.no_jobs":         "No hay tareas",
+	"tui.remote_error":    "No se puede contactar con el demonio: %v",
+	"tui.submitting":      "Enviando al demonio...",
+	"tui.searching":       "Buscando...",
+	"tui.results":         "%d resultado(s) para %q:",
+	"tui.no_results":      "Sin resultados para %q",
+
+	"tui.key_start":       "intro: iniciar",
+	"tui.key_history":     "arriba/abajo: URL recientes",
+	"tui.key_discography": "d: discografía",
+	"tui.key_playlist":    "p: lista",
+	"tui.key_verbose":     "v: detallado",
+	"tui.key_exit":        "esc: salir",
+	"tui.key_download":    "intro: descargar",
+	"tui.key_select":      "arriba/abajo: elegir",
+	"tui.key_tracks":      "espacio: pistas",
+	"tui.key_cancel":      "esc: cancelar",
+	"tui.key_back":        "esc: volver",
+	"tui.key_open":        "o: abrir carpeta",
+	"tui.key_new":         "r: nueva descarga",
+	"tui.key_quit":        "q: salir",
+	"tui.key_attach":      "tab: seguir la tarea actual",
+	"tui.key_detach":      "ctrl+c: desconectar",
+}
@@ -0,0 +1,88 @@
+package i18n
+
+var french = map[string]string{
+	"app.title": "Bandcamp Downloader",
+
+	"cli.dry_run":         "[Simulation - aucun téléchargement]",
+	"cli.starting":        "Début des téléchargements...",
+	"cli.cancelled":       "Téléchargement annulé.",
+	"cli.confirm_size":    "Télécharger %d album(s), %.2f Mo ? [o/N] ",
+	"cli.yes":             "o",
+	"cli.too_large":       "Le téléchargement de %.2f Mo dépasse confirm_if_larger_than_mb ; utilisez -yes pour le lancer quand même",
+	"cli.aborted":         "Téléchargement abandonné.",
+	"cli.imported":        "%d URL(s) de sorties importée(s) depuis %s",
+	"cli.complete":        "Terminé ! %d/%d fichiers téléchargés (%.2f Mo)",
+	"cli.already_present": "(%.2f Mo déjà présents, ignorés)",
+	"cli.videos_skipped":  "(%d vidéo(s) ignorée(s), non téléchargeables en audio)",
+	"cli.expected":        "(%.2f Mo attendus)",
+	"cli.failed":          "(%d sortie(s) en échec)",
+	"cli.resume":          "(sorties restantes enregistrées dans %s, reprendre avec -url \"$(cat %[1]s)\")",
+	"cli.tracks":          "Pistes : %d téléchargée(s), %d déjà présente(s), %d indisponible(s), %d en échec",
+	"cli.truncated":       "(%d piste(s) plus courte(s) ou plus longue(s) que leurs métadonnées, peut-être tronquée(s))",
+	"cli.elapsed":         "Durée : %s (%.2f Mo/s en moyenne)",
+
+	"retag.cancelled": "Réécriture des tags annulée.",
+	"retag.done":      "Tags réécrits pour %d fichier(s)",
+
+	"playlists.cancelled": "Création des playlists annulée.",
+	"playlists.done":      "%d playlist(s) écrite(s)",
+
+	"verify.cancelled":     "Vérification annulée.",
+	"verify.ok":            "Tous les fichiers sont vérifiés",
+	"verify.issues":        "%d problème(s) trouvé(s)",
+	"verify.fixing":        "Correction...",
+	"verify.fix_cancelled": "Correction annulée.",
+	"verify.upgraded":      "%d piste(s) améliorée(s)",
+
+	"tui.subtitle":        "Téléchargez de la musique depuis Bandcamp",
+	"tui.enter_url":       "Adresse Bandcamp, ou nom à rechercher :",
+	"tui.options":         "Options :",
+	"tui.opt_discography": "Télécharger la discographie (d)",
+	"tui.opt_playlist":    "Créer une playlist (p)",
+	"tui.opt_verbose":     "Affichage détaillé (v)",
+	"tui.download_path":   "Dossier de téléchargement : %s",
+	"tui.fetching":        "Récupération des albums...",
+	"tui.found_albums":    "%d album(s) trouvé(s) :",
+	"tui.estimated":       "Taille estimée : %.1f Mo",
+	"tui.too_large":       "%.2f Mo dépasse le seuil de confirmation de %d Mo, appuyez sur entrée pour télécharger quand même",
+	"tui.downloading":     "Téléchargement...",
+	"tui.progress":        "Fichiers : %d/%d | Téléchargé : %.2f Mo",
+	"tui.complete":        "Téléchargement terminé !",
+	"tui.albums":          "Albums : %d",
+	"tui.files":           "Fichiers : %d",
+	"tui.size":            "Taille : %.2f Mo",
+	"tui.tracks":          "Pistes : %d téléchargée(s), %d déjà présente(s), %d indisponible(s), %d en échec",
+	"tui.truncated":       "Durées incohérentes : %d piste(s), peut-être tronquée(s)",
+	"tui.elapsed":         "Durée : %s (%.2f Mo/s)",
+	"tui.error":           "Une erreur s'est produite :",
+	"tui.cancelled":       "annulé par l'utilisateur",
+	"tui.status":          "État : %s",
+	"tui.opened":          "%s ouvert",
+	"tui.open_failed":     "Impossible d'ouvrir %s : %v",
+	"tui.remote":          "Démon : %s",
+	"tui.remote_job":      "Tâche %d",
+	"tui.jobs":            "Tâches :",
+	"tui.no_jobs":         "Aucune tâche",
+	"tui.remote_error":    "Impossible de joindre le démon : %v",
+	"tui.submitting":      "Envoi au démon...",
+	"tui.searching":       "Recherche...",
+	"tui.results":         "%d résultat(s) pour %q :",
+	"tui.no_results":      "Aucun résultat pour %q",
+
+	"tui.key_start":       "entrée : démarrer",
+	"tui.key_history":     "haut/bas : URL récentes",
+	"tui.key_discography": "d : discographie",
+	"tui.key_playlist":    "p : playlist",
+	"tui.key_verbose":     "v : détaillé",
+	"tui.key_exit":        "échap : quitter",
+	"tui.key_download":    "entrée : télécharger",
+	"tui.key_select":      "haut/bas : choisir",
+	"tui.key_tracks":      "espace : pistes",
+	"tui.key_cancel":      "échap : annuler",
+	"tui.key_back":        "échap : retour",
+	"tui.key_open":        "o : ouvrir le dossier",
+	"tui.key_new":         "r : nouveau téléchargement",
+	"tui.key_quit":        "q : quitter",
+	"tui.key_attach":      "tab : suivre la tâche en cours",
+	"tui.key_detach":      "ctrl+c : détacher",
+}
@@ -4,7 +4,9 @@
 //   - File copying and writing
 //   - Filename sanitization for cross-platform compatibility
 //   - Directory creation
+//   - Extended-length paths on Windows
 //   - Image resizing and format conversion
+//   - Opening folders in the system file manager
 //
 // # File Operations
 //
@@ -17,12 +19,30 @@
 //	// Ensure directory exists
 //	err := ioutils.EnsureDir("/path/to/new/directory")
 //
+//	// Ensure directory exists and files can be written in it
+//	err := ioutils.EnsureWritableDir("/path/to/new/directory")
+//
 // # Filename Sanitization
 //
 // Use SanitizeFileName to remove invalid characters from filenames:
 //
 //	safe := ioutils.SanitizeFileName("Song: Part 1/2") // Returns "Song_ Part 1_2"
 //
+// # Long Paths
+//
+// On Windows, LongPath returns the \\?\ form of a path, which is not
+// limited to MAX_PATH (260 characters); elsewhere it returns the path
+// unchanged. config.Paths.LongPaths applies it to the album folders:
+//
+//	dir := ioutils.LongPath(`C:\Music\Artist\Album`) // `\\?\C:\Music\Artist\Album`
+//
+// # File Manager
+//
+// OpenFolder shows a folder in the system file manager (xdg-open, open or
+// explorer, depending on the platform) and returns without waiting:
+//
+//	err := ioutils.OpenFolder("/music/Artist/Album")
+//
 // # Image Processing
 //
 // The ImageService handles cover art manipulation:
@@ -34,4 +54,12 @@
 //
 //	// Convert to JPEG
 //	jpeg, _ := svc.ConvertToJPEG(ctx, pngData)
+//
+// Images larger than ImageService.MaxPixels (DefaultMaxPixels by default)
+// are rejected with ErrImageTooLarge after reading only their header, so a
+// huge or malicious image cannot exhaust memory:
+//
+//	if _, err := svc.ResizeImage(ctx, data, 1000, 1000); errors.Is(err, ioutils.ErrImageTooLarge) {
+//	    // skip the artwork
+//	}
 package ioutils
@@ -14,6 +14,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -117,3 +118,42 @@ func SanitizeFileName(name string) string {
 func EnsureDir(path string) error {
 	return os.MkdirAll(path, 0755)
 }
+
+// EnsureWritableDir creates a directory like EnsureDir, and checks that
+// files can be written in it by creating and removing an empty file, since
+// folders of read-only mounts or without write permission can exist.
+//
+// If the check fails, the directories created are removed again, so no
+// empty folders are left behind.
+//
+// Example:
+//
+//	if err := EnsureWritableDir(album.Path); err != nil {
+//	    // download the album elsewhere
+//	}
+func EnsureWritableDir(path string) error {
+	// The first existing ancestor, up to which folders are removed
+	existing := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(existing); err == nil || filepath.Dir(existing) == existing {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	err := os.MkdirAll(path, 0755)
+	if err == nil {
+		var probe *os.File
+		if probe, err = os.CreateTemp(path, ".write-check-*"); err == nil {
+			probe.Close()
+			return os.Remove(probe.Name())
+		}
+	}
+
+	for dir := filepath.Clean(path); dir != existing && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return err
+}
@@ -3,6 +3,8 @@ package ioutils
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"image"
 	"image/jpeg"
 	_ "image/png" // PNG decoder registration
@@ -26,11 +28,61 @@ import (
 //	// Resize to max 500x500 and convert to JPEG
 //	resized, _ := svc.ResizeImage(ctx, imageData, 500, 500)
 //	jpeg, _ := svc.ConvertToJPEG(ctx, resized)
-type ImageService struct{}
+type ImageService struct {
+	// MaxPixels is the largest image (width × height) that will be decoded.
+	// Larger images are rejected with ErrImageTooLarge before any pixel
+	// memory is allocated. Zero means no limit.
+	MaxPixels int
 
-// NewImageService creates a new ImageService.
+	// JPEGQuality is the quality (1-100) of encoded JPEG images. Lower
+	// values give smaller files, e.g. for embedded art. Zero means
+	// DefaultJPEGQuality.
+	//
+	// Images are always written as baseline JPEG: the standard library
+	// encoder does not support progressive encoding.
+	JPEGQuality int
+}
+
+// DefaultJPEGQuality is the JPEG quality used when ImageService.JPEGQuality is zero.
+const DefaultJPEGQuality = 90
+
+// DefaultMaxPixels is the default decode limit: 6000×6000 pixels, about
+// 144 MB once decoded. Bandcamp's own full-size art is at most 3000×3000.
+const DefaultMaxPixels = 6000 * 6000
+
+// ErrImageTooLarge is returned when an image exceeds ImageService.MaxPixels.
+var ErrImageTooLarge = errors.New("image too large")
+
+// NewImageService creates a new ImageService limited to DefaultMaxPixels
+// and encoding at DefaultJPEGQuality.
 func NewImageService() *ImageService {
-	return &ImageService{}
+	return &ImageService{MaxPixels: DefaultMaxPixels, JPEGQuality: DefaultJPEGQuality}
+}
+
+// jpegOptions returns the encoder options for the configured quality.
+func (s *ImageService) jpegOptions() *jpeg.Options {
+	quality := s.JPEGQuality
+	if quality <= 0 || quality > 100 {
+		quality = DefaultJPEGQuality
+	}
+	return &jpeg.Options{Quality: quality}
+}
+
+// decode decodes an image after checking its dimensions against MaxPixels,
+// which only requires reading the image header.
+func (s *ImageService) decode(data []byte) (image.Image, error) {
+	if s.MaxPixels > 0 {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Width*cfg.Height > s.MaxPixels {
+			return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrImageTooLarge, cfg.Width, cfg.Height, s.MaxPixels)
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
 }
 
 // ResizeImage resizes an image to fit within the specified maximum dimensions.
@@ -44,7 +96,8 @@ func NewImageService() *ImageService {
 //   - maxWidth: Maximum width in pixels
 //   - maxHeight: Maximum height in pixels
 //
-// Returns the resized image as JPEG-encoded bytes.
+// Returns the resized image as JPEG-encoded bytes, or ErrImageTooLarge if
+// the image exceeds MaxPixels.
 //
 // The Catmull-Rom algorithm is used for high-quality resizing.
 //
@@ -55,7 +108,7 @@ func NewImageService() *ImageService {
 //	// A 1500x1000 image becomes 1000x667
 //	// A 800x600 image remains 800x600 (but re-encoded)
 func (s *ImageService) ResizeImage(ctx context.Context, data []byte, maxWidth, maxHeight int) ([]byte, error) {
-	img, _, err := image.Decode(bytes.NewReader(data))
+	img, err := s.decode(data)
 	if err != nil {
 		return nil, err
 	}
@@ -84,9 +137,9 @@ func (s *ImageService) ResizeImage(ctx context.Context, data []byte, maxWidth, m
 	// Use Catmull-Rom for high-quality scaling
 	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
 
-	// Encode to JPEG with high quality
+	// Encode to JPEG at the configured quality
 	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+	if err := jpeg.Encode(&buf, dst, s.jpegOptions()); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +157,8 @@ func (s *ImageService) ResizeImage(ctx context.Context, data []byte, maxWidth, m
 //   - ctx: Context for cancellation (currently unused)
 //   - data: Original image data (JPEG, PNG, GIF, etc.)
 //
-// Returns the image as JPEG-encoded bytes with 90% quality.
+// Returns the image as JPEG-encoded bytes at JPEGQuality (90 by default), or
+// ErrImageTooLarge if the image exceeds MaxPixels.
 //
 // Note: If the input is already JPEG, it will be re-encoded, which may
 // slightly change file size but ensures consistent encoding.
@@ -114,13 +168,13 @@ func (s *ImageService) ResizeImage(ctx context.Context, data []byte, maxWidth, m
 //	pngData, _ := downloadImage("cover.png")
 //	jpegData, err := svc.ConvertToJPEG(ctx, pngData)
 func (s *ImageService) ConvertToJPEG(ctx context.Context, data []byte) ([]byte, error) {
-	img, _, err := image.Decode(bytes.NewReader(data))
+	img, err := s.decode(data)
 	if err != nil {
 		return nil, err
 	}
 
 	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+	if err := jpeg.Encode(&buf, img, s.jpegOptions()); err != nil {
 		return nil, err
 	}
 
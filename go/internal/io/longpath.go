@@ -0,0 +1,22 @@
+package ioutils
+
+import "strings"
+
+// windowsLongPath returns the extended-length form of the absolute Windows
+// path: \\?\C:\... for drive paths and \\?\UNC\server\share\... for network
+// shares. Other paths, and paths already in that form, are returned
+// unchanged. The path must be clean, since Windows does not normalize
+// extended-length paths.
+func windowsLongPath(path string) string {
+	path = strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case strings.HasPrefix(path, `\\?\`), strings.HasPrefix(path, `\\.\`):
+		return path
+	case strings.HasPrefix(path, `\\`):
+		return `\\?\UNC\` + path[2:]
+	case len(path) >= 3 && path[1] == ':' && path[2] == '\\':
+		return `\\?\` + path
+	default:
+		return path
+	}
+}
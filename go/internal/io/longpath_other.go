@@ -0,0 +1,8 @@
+//go:build !windows
+
+package ioutils
+
+// LongPath returns path unchanged: only Windows limits paths to MAX_PATH.
+func LongPath(path string) string {
+	return path
+}
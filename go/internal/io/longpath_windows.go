@@ -0,0 +1,21 @@
+//go:build windows
+
+package ioutils
+
+import "path/filepath"
+
+// LongPath returns path in the extended-length \\?\ form, made absolute,
+// so Windows does not limit it to MAX_PATH (260 characters). Paths that
+// cannot be made absolute are returned unchanged.
+//
+// Example:
+//
+//	LongPath(`C:\Music\Artist\Album`) // Returns `\\?\C:\Music\Artist\Album`
+//	LongPath(`\\nas\music\Artist`)    // Returns `\\?\UNC\nas\music\Artist`
+func LongPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return windowsLongPath(abs)
+}
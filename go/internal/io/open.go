@@ -0,0 +1,39 @@
+package ioutils
+
+import (
+	"fmt"
+	"os"
+)
+
+// OpenFolder opens the folder at path in the system file manager, without
+// waiting for it to be closed.
+//
+// The file manager is started with xdg-open on Linux and the BSDs, open on
+// macOS and explorer on Windows (see openCommand).
+//
+// Returns an error if path is not a folder or the file manager cannot be
+// started.
+//
+// Example:
+//
+//	if err := OpenFolder(album.Path); err != nil {
+//	    log.Printf("cannot open %s: %v", album.Path, err)
+//	}
+func OpenFolder(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a folder", path)
+	}
+
+	cmd := openCommand(path)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", cmd.Path, err)
+	}
+	// Reap the process once the file manager has taken over; explorer
+	// exits with 1 even on success, so its status is not checked.
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package ioutils
+
+import "os/exec"
+
+func openCommand(path string) *exec.Cmd {
+	return exec.Command("explorer", path)
+}
@@ -0,0 +1,29 @@
+// Package library scans an already-downloaded music folder and builds a
+// catalog of its albums and tracks from their ID3 tags.
+//
+// # Scanning
+//
+//	albums, err := library.Scan(ctx, "/home/user/Music/Bandcamp")
+//	for _, album := range albums {
+//	    fmt.Printf("%s - %s (%d tracks)\n", album.Artist, album.Title, len(album.Tracks))
+//	}
+//
+// Tracks are grouped into albums by folder and album tag. Files whose
+// tags cannot be read are skipped.
+//
+// # Exporting
+//
+// A catalog can be written as CSV (one row per track, for spreadsheets)
+// or as JSON (albums with nested tracks, for other tools):
+//
+//	err := library.WriteCSV(os.Stdout, albums)
+//	err = library.WriteJSON(os.Stdout, albums)
+//
+// # Querying
+//
+// The tracks of a catalog are selected with expressions of package filter
+// over their TrackFields, e.g. to write smart playlists across albums:
+//
+//	query, err := library.ParseQuery(`"ambient" in genre && year >= 2010`)
+//	matches, err := library.Select(albums, query)
+package library
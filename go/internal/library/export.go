@@ -0,0 +1,49 @@
+package library
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// csvHeader lists the columns written by WriteCSV.
+var csvHeader = []string{"album_artist", "album", "year", "track_number", "track_artist", "title", "duration", "path", "bandcamp_url"}
+
+// WriteCSV writes the catalog as CSV, with a header row and one row per track.
+func WriteCSV(w io.Writer, albums []*Album) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, album := range albums {
+		for _, track := range album.Tracks {
+			record := []string{
+				album.Artist,
+				album.Title,
+				album.Year,
+				strconv.Itoa(track.Number),
+				track.Artist,
+				track.Title,
+				strconv.FormatFloat(track.Duration, 'f', 2, 64),
+				track.Path,
+				album.BandcampURL,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the catalog as an indented JSON array of albums.
+func WriteJSON(w io.Writer, albums []*Album) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(albums)
+}
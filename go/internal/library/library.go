@@ -0,0 +1,126 @@
+package library
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+)
+
+// Album is a downloaded album found in the library.
+type Album struct {
+	Artist      string   `json:"artist"`
+	Title       string   `json:"title"`
+	Year        string   `json:"year,omitempty"`
+	BandcampURL string   `json:"bandcamp_url,omitempty"`
+	Path        string   `json:"path"`
+	Tracks      []*Track `json:"tracks"`
+}
+
+// Track is a downloaded track found in the library.
+type Track struct {
+	Number   int     `json:"number"`
+	Title    string  `json:"title"`
+	Artist   string  `json:"artist"`
+	Genre    string  `json:"genre,omitempty"`
+	Duration float64 `json:"duration"`
+	Path     string  `json:"path"`
+}
+
+// Duration returns the total length of the album's tracks in seconds.
+func (a *Album) Duration() float64 {
+	var total float64
+	for _, track := range a.Tracks {
+		total += track.Duration
+	}
+	return total
+}
+
+// ReleaseYear returns the year of the album's year tag, which may be a
+// whole date, or 0 if it has none.
+func (a *Album) ReleaseYear() int {
+	year, _, _ := strings.Cut(a.Year, "-")
+	n, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Scan walks root and returns the albums made of the MP3 files it contains,
+// sorted by artist, title and path. Tracks are sorted by number.
+func Scan(ctx context.Context, root string) ([]*Album, error) {
+	type albumKey struct{ dir, album string }
+	albums := make(map[albumKey]*Album)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".mp3") {
+			return nil
+		}
+
+		info, err := audio.ReadTagInfo(path)
+		if err != nil {
+			return nil
+		}
+
+		key := albumKey{dir: filepath.Dir(path), album: info.Album}
+		album, ok := albums[key]
+		if !ok {
+			artist := info.AlbumArtist
+			if artist == "" {
+				artist = info.Artist
+			}
+			album = &Album{
+				Artist:      artist,
+				Title:       info.Album,
+				Year:        info.Year,
+				BandcampURL: info.BandcampURL,
+				Path:        key.dir,
+			}
+			albums[key] = album
+		}
+
+		album.Tracks = append(album.Tracks, &Track{
+			Number:   info.TrackNumber,
+			Title:    info.Title,
+			Artist:   info.Artist,
+			Genre:    info.Genre,
+			Duration: info.Duration,
+			Path:     path,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Album, 0, len(albums))
+	for _, album := range albums {
+		sort.SliceStable(album.Tracks, func(i, j int) bool {
+			return album.Tracks[i].Number < album.Tracks[j].Number
+		})
+		result = append(result, album)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Artist != b.Artist {
+			return a.Artist < b.Artist
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.Path < b.Path
+	})
+
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/audio"
+	"github.com/handiism/bandcamp-downloader/internal/model"
+)
+
+func TestScanAndExport(t *testing.T) {
+	root := t.TempDir()
+
+	albumCfg := &model.PathConfig{
+		DownloadsPath:          filepath.Join(root, "{artist}", "{album}"),
+		CoverArtFileNameFormat: "{album}",
+		PlaylistFileNameFormat: "{album}",
+	}
+	trackCfg := &model.TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}
+
+	album := model.NewAlbum("Artist", "Album", "", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), albumCfg)
+	album.URL = "https://artist.bandcamp.com/album/album"
+	album.Tracks = []*model.Track{
+		model.NewTrack(album, 1, 2, "Second", 200, "", "", trackCfg),
+		model.NewTrack(album, 1, 1, "First", 100, "", "", trackCfg),
+	}
+
+	if err := os.MkdirAll(album.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tagger := audio.NewTagger(audio.DefaultTagConfig())
+	for _, track := range album.Tracks {
+		if err := os.WriteFile(track.Path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := tagger.SaveTags(track, album, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	albums, err := Scan(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(albums) != 1 {
+		t.Fatalf("got %d albums, want 1", len(albums))
+	}
+	got := albums[0]
+	if got.Artist != "Artist" || got.Title != "Album" || got.BandcampURL != album.URL {
+		t.Errorf("album = %+v", got)
+	}
+	if len(got.Tracks) != 2 || got.Tracks[0].Title != "First" {
+		t.Fatalf("tracks not sorted by number: %+v", got.Tracks)
+	}
+	if got.Duration() != 300 {
+		t.Errorf("Duration() = %v, want 300", got.Duration())
+	}
+
+	var csvBuf bytes.Buffer
+	if err := WriteCSV(&csvBuf, albums); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(&csvBuf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Errorf("got %d CSV rows, want 3 (header + 2 tracks)", len(records))
+	}
+
+	var jsonBuf bytes.Buffer
+	if err := WriteJSON(&jsonBuf, albums); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded []Album
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded) != 1 || len(decoded[0].Tracks) != 2 {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
@@ -0,0 +1,66 @@
+package library
+
+import "github.com/handiism/bandcamp-downloader/internal/filter"
+
+// TrackFields are the fields of a track that queries can use.
+var TrackFields = map[string]string{
+	"artist":       "track artist",
+	"album_artist": "album artist",
+	"album":        "album title",
+	"title":        "track title",
+	"genre":        "genre tag",
+	"year":         "release year, 0 if unknown",
+	"track_number": "track number, 0 if unknown",
+	"duration":     "duration in seconds, 0 if unknown",
+	"bandcamp_url": "Bandcamp URL of the album",
+	"path":         "file path",
+}
+
+// Match is a track selected by a query, with its album.
+type Match struct {
+	Album *Album
+	Track *Track
+}
+
+// ParseQuery parses a query over the TrackFields of the tracks of a
+// library, written like the expressions of package filter:
+//
+//	query, err := library.ParseQuery(`"ambient" in genre && year >= 2010 && year <= 2015`)
+func ParseQuery(src string) (*filter.Expr, error) {
+	return filter.ParseFields(src, TrackFields)
+}
+
+// Select returns the tracks of albums the query is true for, in the order
+// of albums and of their tracks. A query that cannot be evaluated for a
+// track, e.g. comparing a title with a number, is an error.
+func Select(albums []*Album, query *filter.Expr) ([]Match, error) {
+	var matches []Match
+	for _, album := range albums {
+		for _, track := range album.Tracks {
+			ok, err := query.Eval(trackFields(album, track))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				matches = append(matches, Match{Album: album, Track: track})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// trackFields returns the values of the TrackFields of track.
+func trackFields(album *Album, track *Track) map[string]any {
+	return map[string]any{
+		"artist":       track.Artist,
+		"album_artist": album.Artist,
+		"album":        album.Title,
+		"title":        track.Title,
+		"genre":        track.Genre,
+		"year":         float64(album.ReleaseYear()),
+		"track_number": float64(track.Number),
+		"duration":     track.Duration,
+		"bandcamp_url": album.BandcampURL,
+		"path":         track.Path,
+	}
+}
@@ -0,0 +1,18 @@
+// Package listenbrainz queries the ListenBrainz metadata lookup API, which
+// maps listens (artist, track and release names) to MusicBrainz entities.
+//
+// Scrobblers submit listens by name, and ListenBrainz links them to
+// MusicBrainz recordings with this mapping. Releases that MusicBrainz does
+// not know, common on Bandcamp, stay unlinked. Lookup tells whether a
+// release is mapped:
+//
+//	client := listenbrainz.NewClient(http.NewClient(nil), "")
+//	match, err := client.Lookup(ctx, "Artist", "First Track", "Album")
+//	if err == nil && match == nil {
+//	    // Not mapped: add the release to MusicBrainz
+//	}
+//
+// The ListenBrainz API has no endpoint to submit releases for mapping; the
+// mapping is built from MusicBrainz data, so adding the release there is
+// the way to get it matched.
+package listenbrainz
@@ -0,0 +1,77 @@
+package listenbrainz
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/http"
+)
+
+// DefaultAPIURL is the base URL of the ListenBrainz API.
+const DefaultAPIURL = "https://api.listenbrainz.org"
+
+// Client queries the ListenBrainz API.
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+// NewClient creates a Client making its requests with httpClient. If
+// baseURL is empty, DefaultAPIURL is used.
+func NewClient(httpClient *http.Client, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{http: httpClient, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Match is the MusicBrainz recording and release a listen is mapped to.
+type Match struct {
+	ArtistCreditName string   `json:"artist_credit_name"`
+	ArtistMBIDs      []string `json:"artist_mbids"`
+	RecordingMBID    string   `json:"recording_mbid"`
+	RecordingName    string   `json:"recording_name"`
+	ReleaseMBID      string   `json:"release_mbid"`
+	ReleaseName      string   `json:"release_name"`
+}
+
+// Lookup returns the mapping of a listen of recording by artist, from
+// release (which may be empty), or nil if ListenBrainz cannot map it.
+//
+// Example:
+//
+//	match, err := client.Lookup(ctx, "Artist", "Track", "Album")
+//	if match != nil {
+//	    fmt.Println("https://musicbrainz.org/release/" + match.ReleaseMBID)
+//	}
+func (c *Client) Lookup(ctx context.Context, artist, recording, release string) (*Match, error) {
+	query := url.Values{}
+	query.Set("artist_name", artist)
+	query.Set("recording_name", recording)
+	if release != "" {
+		query.Set("release_name", release)
+	}
+
+	body, err := c.http.Get(ctx, c.baseURL+"/1/metadata/lookup/?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var match Match
+	if err := json.Unmarshal(body, &match); err != nil {
+		return nil, err
+	}
+	// An unmapped listen is answered with an empty object
+	if match.RecordingMBID == "" {
+		return nil, nil
+	}
+	return &match, nil
+}
+
+// SameRelease reports whether the match is on a release named release,
+// ignoring case, rather than on another release of the same recording.
+func (m *Match) SameRelease(release string) bool {
+	return m != nil && strings.EqualFold(strings.TrimSpace(m.ReleaseName), strings.TrimSpace(release))
+}
@@ -0,0 +1,53 @@
+package listenbrainz
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/http"
+)
+
+func TestClient_Lookup(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path != "/1/metadata/lookup/" {
+			nethttp.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("artist_name") != "Artist" || q.Get("release_name") != "Album" {
+			t.Errorf("unexpected query %v", q)
+		}
+		if q.Get("recording_name") == "Unknown" {
+			w.Write([]byte("{}"))
+			return
+		}
+		json.NewEncoder(w).Encode(Match{
+			RecordingMBID: "rec-mbid",
+			RecordingName: q.Get("recording_name"),
+			ReleaseMBID:   "rel-mbid",
+			ReleaseName:   "ALBUM",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(http.NewClient(nil), server.URL+"/")
+
+	match, err := client.Lookup(context.Background(), "Artist", "Track", "Album")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if match == nil || match.ReleaseMBID != "rel-mbid" {
+		t.Fatalf("Lookup() = %+v, want release rel-mbid", match)
+	}
+	if !match.SameRelease("Album") || match.SameRelease("Other") {
+		t.Error("SameRelease does not compare the release name")
+	}
+
+	match, err = client.Lookup(context.Background(), "Artist", "Unknown", "Album")
+	if err != nil || match != nil {
+		t.Errorf("Lookup() of an unmapped listen = %+v, %v; want nil, nil", match, err)
+	}
+}
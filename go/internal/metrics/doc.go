@@ -0,0 +1,33 @@
+// Package metrics provides counters, gauges and histograms exposed in the Prometheus
+// text format, for the long-running modes of the downloader.
+//
+// Metrics are registered on a Registry, which writes them all on request:
+//
+//	reg := metrics.NewRegistry()
+//	jobs := reg.Counter("bandcamp_dl_jobs_total", "Jobs submitted.")
+//	queue := reg.Gauge("bandcamp_dl_queue_depth", "Jobs waiting to start.")
+//
+//	jobs.Inc()
+//	queue.Set(3)
+//
+//	http.Handle("/metrics", reg)
+//
+// # Labels
+//
+// Metrics with labels are created with the Vec variants, and their series
+// with With, passing one value per label name:
+//
+//	finished := reg.CounterVec("bandcamp_dl_jobs_finished_total", "Jobs finished.", "state")
+//	finished.With("done").Inc()
+//
+// # Histograms
+//
+// Histograms count observations in buckets, and are written with the
+// cumulative _bucket series and the _sum and _count of the observations:
+//
+//	latency := reg.HistogramVec("bandcamp_dl_http_request_duration_seconds", "Request latency.", metrics.DefBuckets, "method")
+//	latency.With("GET").Observe(elapsed.Seconds())
+//
+// The package has no dependency beyond the standard library; only the
+// subset of the exposition format used by the downloader is implemented.
+package metrics
@@ -0,0 +1,339 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds metrics and writes them in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]struct{}
+}
+
+// metric is a metric family that can write its samples.
+type metric interface {
+	write(w io.Writer)
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+// register adds m, panicking if name is already registered, as this is a
+// programming error.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.names[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = struct{}{}
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes every metric of the registry to w, in registration order.
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves the metrics, so that a Registry can be used as the
+// handler of a /metrics endpoint.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	bits uint64
+}
+
+// Inc adds 1 to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds v, which must not be negative, to the counter.
+func (c *Counter) Add(v float64) {
+	addFloat(&c.bits, v)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits uint64
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// Add adds v, which may be negative, to the gauge.
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// addFloat atomically adds v to the float64 stored in bits.
+func addFloat(bits *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(bits)
+		if atomic.CompareAndSwapUint64(bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// DefBuckets are bucket upper bounds suited to request latencies in seconds.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations, e.g. request durations, in buckets.
+type Histogram struct {
+	upper  []float64
+	counts []uint64
+	sum    uint64
+	count  uint64
+}
+
+func newHistogram(upper []float64) *Histogram {
+	return &Histogram{upper: upper, counts: make([]uint64, len(upper))}
+}
+
+// Observe adds one observation of v to the histogram.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+	if i < len(h.counts) {
+		atomic.AddUint64(&h.counts[i], 1)
+	}
+	addFloat(&h.sum, v)
+	atomic.AddUint64(&h.count, 1)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// family is a metric with a fixed set of label names and one series per
+// combination of label values.
+type family[T any] struct {
+	name, help, kind string
+	labels           []string
+	newSeries        func() *T
+	writeSeries      func(w io.Writer, name, labels string, s *T)
+
+	mu     sync.Mutex
+	series map[string]*T
+}
+
+// With returns the series for the given label values, one per label name,
+// creating it if needed.
+func (f *family[T]) With(values ...string) *T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = f.newSeries()
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family[T]) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]*T, len(keys))
+	for i, key := range keys {
+		series[i] = f.series[key]
+	}
+	f.mu.Unlock()
+
+	for i, key := range keys {
+		f.writeSeries(w, f.name, formatLabels(f.labels, key), series[i])
+	}
+}
+
+// CounterVec is a counter with labels.
+type CounterVec = family[Counter]
+
+// GaugeVec is a gauge with labels.
+type GaugeVec = family[Gauge]
+
+// HistogramVec is a histogram with labels.
+type HistogramVec = family[Histogram]
+
+// Counter registers and returns a counter without labels.
+func (r *Registry) Counter(name, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+// CounterVec registers and returns a counter with the given label names.
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	f := &CounterVec{name: name, help: help, kind: "counter", labels: labels, newSeries: func() *Counter { return new(Counter) }, writeSeries: writeCounter, series: make(map[string]*Counter)}
+	r.register(name, f)
+	return f
+}
+
+// Gauge registers and returns a gauge without labels.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+// GaugeVec registers and returns a gauge with the given label names.
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	f := &GaugeVec{name: name, help: help, kind: "gauge", labels: labels, newSeries: func() *Gauge { return new(Gauge) }, writeSeries: writeGauge, series: make(map[string]*Gauge)}
+	r.register(name, f)
+	return f
+}
+
+// Histogram registers and returns a histogram without labels, counting
+// observations in the given bucket upper bounds (see DefBuckets).
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	return r.HistogramVec(name, help, buckets).With()
+}
+
+// HistogramVec registers and returns a histogram with the given bucket
+// upper bounds and label names.
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	f := &HistogramVec{
+		name:        name,
+		help:        help,
+		kind:        "histogram",
+		labels:      labels,
+		newSeries:   func() *Histogram { return newHistogram(buckets) },
+		writeSeries: writeHistogram,
+		series:      make(map[string]*Histogram),
+	}
+	r.register(name, f)
+	return f
+}
+
+// GaugeFunc registers a gauge whose value is computed by fn on every write,
+// e.g. the length of a queue owned by another component.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, gaugeFunc{name: name, help: help, fn: fn})
+}
+
+type gaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+func (g gaugeFunc) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, escapeHelp(g.help), g.name, g.name, formatValue(g.fn()))
+}
+
+func writeCounter(w io.Writer, name, labels string, c *Counter) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(c.Value()))
+}
+
+func writeGauge(w io.Writer, name, labels string, g *Gauge) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(g.Value()))
+}
+
+// writeHistogram writes the cumulative _bucket series of h, ending with
+// le="+Inf", followed by its _sum and _count.
+func writeHistogram(w io.Writer, name, labels string, h *Histogram) {
+	var cumulative uint64
+	for i, upper := range h.upper {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", formatValue(upper)), cumulative)
+	}
+	count := atomic.LoadUint64(&h.count)
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", "+Inf"), count)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatValue(math.Float64frombits(atomic.LoadUint64(&h.sum))))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, count)
+}
+
+// withLabel adds name="value" to formatted labels.
+func withLabel(labels, name, value string) string {
+	label := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + label + "}"
+	}
+	return labels[:len(labels)-1] + "," + label + "}"
+}
+
+// formatLabels returns the {name="value",...} part of a sample, or "" for
+// a metric without labels.
+func formatLabels(names []string, key string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	values := strings.Split(key, "\xff")
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatValue formats a sample value like the Prometheus client libraries.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	reg := NewRegistry()
+	jobs := reg.Counter("jobs_total", "Jobs submitted.")
+	finished := reg.CounterVec("jobs_finished_total", "Jobs finished.", "state")
+	queue := reg.Gauge("queue_depth", "Jobs waiting\nto start.")
+	reg.GaugeFunc("up", "Always 1.", func() float64 { return 1 })
+
+	jobs.Inc()
+	jobs.Add(2)
+	finished.With("failed").Inc()
+	finished.With(`do"ne`).Add(4)
+	queue.Set(5)
+	queue.Add(-1.5)
+
+	var b strings.Builder
+	reg.WriteText(&b)
+
+	want := `# HELP jobs_total Jobs submitted.
+# TYPE jobs_total counter
+jobs_total 3
+# HELP jobs_finished_total Jobs finished.
+# TYPE jobs_finished_total counter
+jobs_finished_total{state="do\"ne"} 4
+jobs_finished_total{state="failed"} 1
+# HELP queue_depth Jobs waiting\nto start.
+# TYPE queue_depth gauge
+queue_depth 3.5
+# HELP up Always 1.
+# TYPE up gauge
+up 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistry_DuplicatePanics(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("x", "")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate metric did not panic")
+		}
+	}()
+	reg.Gauge("x", "")
+}
+
+func TestRegistry_Histogram(t *testing.T) {
+	reg := NewRegistry()
+	latency := reg.HistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "code")
+
+	latency.With("200").Observe(0.05)
+	latency.With("200").Observe(0.1)
+	latency.With("200").Observe(0.5)
+	latency.With("200").Observe(3)
+
+	var b strings.Builder
+	reg.WriteText(&b)
+
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{code="200",le="0.1"} 2
+latency_seconds_bucket{code="200",le="1"} 3
+latency_seconds_bucket{code="200",le="+Inf"} 4
+latency_seconds_sum{code="200"} 3.65
+latency_seconds_count{code="200"} 4
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
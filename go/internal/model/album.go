@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	ioutils "github.com/handiism/bandcamp-downloader/internal/io"
 )
 
 // Album represents a Bandcamp album with its metadata and tracks.
@@ -28,22 +30,73 @@ import (
 //	album := NewAlbum("The Beatles", "Abbey Road", artURL, releaseDate, cfg)
 //	// album.Path = "/music/The Beatles/Abbey Road"
 type Album struct {
+	// ID is the Bandcamp item ID of the album (or track, for single releases).
+	// Zero if the page did not expose one.
+	ID int64
+
+	// URL is the Bandcamp page the album was fetched from.
+	URL string
+
 	// Artist is the album artist name.
 	Artist string
 
 	// Title is the album title.
 	Title string
 
+	// Label is the name of the Bandcamp site hosting the release, which is
+	// the label for label releases and the artist for self-released ones.
+	// Empty if unknown, in which case the {label} placeholder uses Artist.
+	Label string
+
 	// ArtworkURL is the URL to download the album cover art from.
 	// Empty string means no artwork is available.
 	ArtworkURL string
 
+	// ArtID is Bandcamp's ID of the cover art, shared by releases that use
+	// the same image. Zero if unknown.
+	ArtID int64
+
 	// ReleaseDate is when the album was released.
 	ReleaseDate time.Time
 
+	// Compilation is true if the album's tracks are by many different
+	// artists (see IsCompilation).
+	Compilation bool
+
+	// Single is true for releases of a track page (/track/) rather than an
+	// album page, whose paths use the singles templates of PathConfig and
+	// TrackConfig when set.
+	Single bool
+
+	// About and Credits are the album's description and credits, as shown
+	// on its page. Empty if the artist did not provide them.
+	About   string
+	Credits string
+
+	// Publisher is the publisher named in the credits or, for releases of
+	// a label's site, the label. Empty if unknown.
+	Publisher string
+
+	// Tags are the genre/location tags the artist attached to the release.
+	Tags []string
+
 	// Tracks contains all tracks in this album.
 	Tracks []*Track
 
+	// SkippedVideos lists the titles of the video items of the release,
+	// which are not downloaded.
+	SkippedVideos []string
+
+	// UnavailableTracks lists the titles of the tracks of the release
+	// without a stream, e.g. those only available to buyers, which cannot
+	// be downloaded.
+	UnavailableTracks []string
+
+	// Restrictions lists the signals by which the artist asked for the
+	// release not to be indexed or streamed (e.g. a "noindex" robots meta
+	// tag). Empty if there are none.
+	Restrictions []string
+
 	// Path is the computed local directory path where album files will be saved.
 	// This is automatically set by NewAlbum based on PathConfig.DownloadsPath.
 	Path string
@@ -61,12 +114,14 @@ type Album struct {
 // The pathConfig determines how file paths are constructed using placeholders:
 //   - {artist} - Artist name
 //   - {album} - Album title
+//   - {label} - Label name (falls back to the artist name)
 //   - {year} - Release year (4 digits)
 //   - {month} - Release month (2 digits, zero-padded)
 //   - {day} - Release day (2 digits, zero-padded)
 //
 // Invalid filename characters are automatically replaced with underscores.
-// Paths are truncated if they exceed Windows path length limits (248 for folders, 260 for files).
+// Paths are truncated if they exceed Windows path length limits (248 for folders, 260 for files),
+// unless cfg.LongPaths is set.
 func NewAlbum(artist, title, artworkURL string, releaseDate time.Time, cfg *PathConfig) *Album {
 	album := &Album{
 		Artist:      artist,
@@ -75,13 +130,59 @@ func NewAlbum(artist, title, artworkURL string, releaseDate time.Time, cfg *Path
 		ReleaseDate: releaseDate,
 	}
 
-	album.Path = album.parseFolderPath(cfg)
-	album.PlaylistPath = album.parsePlaylistPath(cfg)
-	album.ArtworkPath = album.parseArtworkPath(cfg)
+	album.ComputePaths(cfg)
 
 	return album
 }
 
+// ComputePaths (re)computes Path, PlaylistPath and ArtworkPath from the config.
+//
+// NewAlbum calls this automatically. Call it again after changing fields
+// that affect paths (such as Label), before creating the album's tracks.
+func (a *Album) ComputePaths(cfg *PathConfig) {
+	a.Path = a.parseFolderPath(cfg)
+	a.PlaylistPath = a.parsePlaylistPath(cfg)
+	a.ArtworkPath = a.parseArtworkPath(cfg)
+}
+
+// LocatePaths sets Path to folder, where the album was found on disk,
+// e.g. in a library, and computes PlaylistPath in it from the config.
+// ArtworkPath is left for CoverArtPath, since the extension of the cover
+// art is not known without its URL.
+func (a *Album) LocatePaths(folder string, cfg *PathConfig) {
+	a.Path = folder
+	a.PlaylistPath = a.parsePlaylistPath(cfg)
+}
+
+// LabelName returns the label name, falling back to the artist name when
+// the label is unknown.
+func (a *Album) LabelName() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return a.Artist
+}
+
+// Relocate moves the album's paths, and those of its tracks, from under
+// the folder from to the same place under the folder to, e.g. to download
+// it to another library root. Paths outside from are left unchanged.
+func (a *Album) Relocate(from, to string) {
+	move := func(path string) string {
+		rel, err := filepath.Rel(from, path)
+		if path == "" || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return path
+		}
+		return filepath.Join(to, rel)
+	}
+
+	a.Path = move(a.Path)
+	a.ArtworkPath = move(a.ArtworkPath)
+	a.PlaylistPath = move(a.PlaylistPath)
+	for _, track := range a.Tracks {
+		track.Path = move(track.Path)
+	}
+}
+
 // HasArtwork returns true if the album has cover art available for download.
 func (a *Album) HasArtwork() bool {
 	return a.ArtworkURL != ""
@@ -92,6 +193,7 @@ func (a *Album) HasArtwork() bool {
 // All path fields support placeholders that are replaced with actual values:
 //   - {artist} - Artist name
 //   - {album} - Album title
+//   - {label} - Label name (falls back to the artist name)
 //   - {year}, {month}, {day} - Release date components
 //
 // Example configuration:
@@ -117,6 +219,35 @@ type PathConfig struct {
 
 	// PlaylistFormat determines the playlist file type and extension.
 	PlaylistFormat PlaylistFormat
+
+	// VariousArtistsName, when non-empty, replaces {artist} in the folder
+	// path of compilation albums, e.g. "Various Artists".
+	VariousArtistsName string
+
+	// SinglesDownloadsPath, when non-empty, replaces DownloadsPath for
+	// singles (see Album.Single), e.g. "/music/{artist}/Singles".
+	SinglesDownloadsPath string
+
+	// LongPaths lifts the Windows path length limits: paths are not
+	// truncated, and album folders are in the \\?\ form on Windows (see
+	// ioutils.LongPath).
+	LongPaths bool
+}
+
+// CompilationMinArtists is the number of distinct track artists from which
+// an album is considered a compilation.
+const CompilationMinArtists = 3
+
+// IsCompilation reports whether the given track artists make an album a
+// compilation, i.e. at least CompilationMinArtists distinct non-empty names.
+func IsCompilation(trackArtists []string) bool {
+	distinct := make(map[string]struct{})
+	for _, artist := range trackArtists {
+		if artist = strings.ToLower(strings.TrimSpace(artist)); artist != "" {
+			distinct[artist] = struct{}{}
+		}
+	}
+	return len(distinct) >= CompilationMinArtists
 }
 
 // PlaylistFormat represents supported playlist file formats.
@@ -134,6 +265,9 @@ const (
 
 	// PlaylistFormatZPL creates .zpl playlist files (Zune Media Player).
 	PlaylistFormatZPL
+
+	// PlaylistFormatM3U8 creates .m3u8 playlist files (UTF-8 M3U).
+	PlaylistFormatM3U8
 )
 
 // Extension returns the file extension for the playlist format, including the dot.
@@ -143,6 +277,7 @@ const (
 //   - ".pls" for PlaylistFormatPLS
 //   - ".wpl" for PlaylistFormatWPL
 //   - ".zpl" for PlaylistFormatZPL
+//   - ".m3u8" for PlaylistFormatM3U8
 func (pf PlaylistFormat) Extension() string {
 	switch pf {
 	case PlaylistFormatM3U:
@@ -153,19 +288,40 @@ func (pf PlaylistFormat) Extension() string {
 		return ".wpl"
 	case PlaylistFormatZPL:
 		return ".zpl"
+	case PlaylistFormatM3U8:
+		return ".m3u8"
 	default:
 		return ".m3u"
 	}
 }
 
+// FolderPath returns the folder of the album for the DownloadsPath of cfg,
+// computed like Path, e.g. for other layouts of the same library.
+func (a *Album) FolderPath(cfg *PathConfig) string {
+	return a.parseFolderPath(cfg)
+}
+
 // parseFolderPath computes the album folder path from the config template.
 func (a *Album) parseFolderPath(cfg *PathConfig) string {
-	path := cfg.DownloadsPath
+	template := cfg.DownloadsPath
+	if a.Single && cfg.SinglesDownloadsPath != "" {
+		template = cfg.SinglesDownloadsPath
+	}
+	path := optionalSections(template, a.emptyPlaceholder)
 	path = strings.ReplaceAll(path, "{year}", sanitizeFileName(a.ReleaseDate.Format("2006")))
 	path = strings.ReplaceAll(path, "{month}", sanitizeFileName(a.ReleaseDate.Format("01")))
 	path = strings.ReplaceAll(path, "{day}", sanitizeFileName(a.ReleaseDate.Format("02")))
-	path = strings.ReplaceAll(path, "{artist}", sanitizeFileName(a.Artist))
+	artist := a.Artist
+	if a.Compilation && cfg.VariousArtistsName != "" {
+		artist = cfg.VariousArtistsName
+	}
+	path = strings.ReplaceAll(path, "{artist}", sanitizeFileName(artist))
 	path = strings.ReplaceAll(path, "{album}", sanitizeFileName(a.Title))
+	path = strings.ReplaceAll(path, "{label}", sanitizeFileName(a.LabelName()))
+
+	if cfg.LongPaths {
+		return ioutils.LongPath(path)
+	}
 
 	// Limit path length for cross-platform compatibility (Windows MAX_PATH)
 	if len(path) >= 248 {
@@ -182,7 +338,7 @@ func (a *Album) parsePlaylistPath(cfg *PathConfig) string {
 	filePath := filepath.Join(a.Path, fileName+ext)
 
 	// Limit total path length for Windows compatibility
-	if len(filePath) >= 260 {
+	if len(filePath) >= 260 && !cfg.LongPaths {
 		maxLen := 11 - len(ext)
 		if maxLen > 0 && maxLen < len(fileName) {
 			filePath = filepath.Join(a.Path, fileName[:maxLen]+ext)
@@ -194,12 +350,13 @@ func (a *Album) parsePlaylistPath(cfg *PathConfig) string {
 
 // parsePlaylistFileName computes the playlist filename from the config template.
 func (a *Album) parsePlaylistFileName(cfg *PathConfig) string {
-	fileName := cfg.PlaylistFileNameFormat
+	fileName := optionalSections(cfg.PlaylistFileNameFormat, a.emptyPlaceholder)
 	fileName = strings.ReplaceAll(fileName, "{year}", a.ReleaseDate.Format("2006"))
 	fileName = strings.ReplaceAll(fileName, "{month}", a.ReleaseDate.Format("01"))
 	fileName = strings.ReplaceAll(fileName, "{day}", a.ReleaseDate.Format("02"))
 	fileName = strings.ReplaceAll(fileName, "{album}", a.Title)
 	fileName = strings.ReplaceAll(fileName, "{artist}", a.Artist)
+	fileName = strings.ReplaceAll(fileName, "{label}", a.LabelName())
 	return sanitizeFileName(fileName)
 }
 
@@ -208,13 +365,17 @@ func (a *Album) parseArtworkPath(cfg *PathConfig) string {
 	if !a.HasArtwork() {
 		return ""
 	}
+	return a.CoverArtPath(cfg, filepath.Ext(a.ArtworkURL))
+}
 
-	ext := filepath.Ext(a.ArtworkURL)
+// CoverArtPath returns the path of the cover art file in the album folder
+// for the config, with the extension ext (e.g. ".jpg").
+func (a *Album) CoverArtPath(cfg *PathConfig, ext string) string {
 	fileName := a.parseCoverArtFileName(cfg)
 	artworkPath := filepath.Join(a.Path, fileName+ext)
 
 	// Limit total path length for Windows compatibility
-	if len(artworkPath) >= 260 {
+	if len(artworkPath) >= 260 && !cfg.LongPaths {
 		maxLen := 11 - len(ext)
 		if maxLen > 0 && maxLen < len(fileName) {
 			artworkPath = filepath.Join(a.Path, fileName[:maxLen]+ext)
@@ -226,12 +387,13 @@ func (a *Album) parseArtworkPath(cfg *PathConfig) string {
 
 // parseCoverArtFileName computes the cover art filename from the config template.
 func (a *Album) parseCoverArtFileName(cfg *PathConfig) string {
-	fileName := cfg.CoverArtFileNameFormat
+	fileName := optionalSections(cfg.CoverArtFileNameFormat, a.emptyPlaceholder)
 	fileName = strings.ReplaceAll(fileName, "{year}", a.ReleaseDate.Format("2006"))
 	fileName = strings.ReplaceAll(fileName, "{month}", a.ReleaseDate.Format("01"))
 	fileName = strings.ReplaceAll(fileName, "{day}", a.ReleaseDate.Format("02"))
 	fileName = strings.ReplaceAll(fileName, "{album}", a.Title)
 	fileName = strings.ReplaceAll(fileName, "{artist}", a.Artist)
+	fileName = strings.ReplaceAll(fileName, "{label}", a.LabelName())
 	return sanitizeFileName(fileName)
 }
 
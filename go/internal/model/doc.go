@@ -27,5 +27,12 @@
 //	    PlaylistFormat:         model.PlaylistFormatM3U,
 //	}
 //
-// Available placeholders: {artist}, {album}, {title}, {tracknum}, {year}, {month}, {day}
+// Available placeholders: {artist}, {album}, {label}, {title}, {tracknum}, {disc}, {year}, {month}, {day},
+// and {ext} in track file names
+//
+// A section "{?...}" is dropped when one of its placeholders is empty,
+// e.g. "{album}{? ({year})}" for albums without a release date.
+//
+// Singles (Album.Single) use PathConfig.SinglesDownloadsPath and
+// TrackConfig.SinglesFileNameFormat instead, when they are set.
 package model
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -57,6 +59,66 @@ func TestAlbum_PathComputation(t *testing.T) {
 	}
 }
 
+func TestAlbum_Relocate(t *testing.T) {
+	cfg := &PathConfig{
+		DownloadsPath:          "/music/{artist}/{album}",
+		CoverArtFileNameFormat: "{album}",
+		PlaylistFileNameFormat: "{album}",
+		PlaylistFormat:         PlaylistFormatM3U,
+	}
+	album := NewAlbum("Artist", "Album", "https://example.com/art.jpg", time.Now(), cfg)
+	track := NewTrack(album, 1, 1, "Song", 60, "", "", &TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"})
+	album.Tracks = []*Track{track}
+	album.PlaylistPath = "/playlists/Album.m3u"
+
+	album.Relocate("/music", "/fallback")
+	if want := filepath.Join("/fallback", "Artist", "Album"); album.Path != want {
+		t.Errorf("Path = %q, want %q", album.Path, want)
+	}
+	if want := filepath.Join("/fallback", "Artist", "Album", "01 Song.mp3"); track.Path != want {
+		t.Errorf("track Path = %q, want %q", track.Path, want)
+	}
+	if !strings.HasPrefix(album.ArtworkPath, filepath.Join("/fallback", "Artist", "Album")) {
+		t.Errorf("ArtworkPath = %q, want it under the fallback folder", album.ArtworkPath)
+	}
+	if album.PlaylistPath != "/playlists/Album.m3u" {
+		t.Errorf("PlaylistPath = %q, want it unchanged outside /music", album.PlaylistPath)
+	}
+}
+
+func TestOptionalSections(t *testing.T) {
+	empty := func(name string) bool { return name == "year" }
+	tests := []struct {
+		template, want string
+	}{
+		{"{artist}/{album}", "{artist}/{album}"},
+		{"{album}{? ({year})}", "{album}"},
+		{"{album}{? [{label}]}", "{album} [{label}]"},
+		{"{?{year} - }{album}{? ({label})}", "{album} ({label})"},
+		{"{? no placeholder}", " no placeholder"},
+		{"{album}{? ({year}", "{album}{? ({year}"},
+	}
+
+	for _, tt := range tests {
+		if got := optionalSections(tt.template, empty); got != tt.want {
+			t.Errorf("optionalSections(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestAlbum_OptionalSections(t *testing.T) {
+	cfg := &PathConfig{DownloadsPath: "/music/{artist}/{album}{? ({year})}"}
+
+	dated := NewAlbum("Artist", "Album", "", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), cfg)
+	if want := "/music/Artist/Album (2020)"; dated.Path != want {
+		t.Errorf("Path with a release date = %q, want %q", dated.Path, want)
+	}
+	undated := NewAlbum("Artist", "Album", "", time.Time{}, cfg)
+	if want := "/music/Artist/Album"; undated.Path != want {
+		t.Errorf("Path without release date = %q, want %q", undated.Path, want)
+	}
+}
+
 func TestAlbum_NoArtwork(t *testing.T) {
 	cfg := &PathConfig{
 		DownloadsPath:          "/music/{artist}/{album}",
@@ -77,6 +139,109 @@ func TestAlbum_NoArtwork(t *testing.T) {
 	}
 }
 
+func TestAlbum_LabelPlaceholder(t *testing.T) {
+	cfg := &PathConfig{
+		DownloadsPath:          "/music/{label}/{artist}/{album}",
+		CoverArtFileNameFormat: "{album}",
+		PlaylistFileNameFormat: "{album}",
+		PlaylistFormat:         PlaylistFormatM3U,
+	}
+
+	releaseDate := time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC)
+	album := NewAlbum("Artist", "Album", "", releaseDate, cfg)
+
+	// Without a label, {label} falls back to the artist
+	if album.Path != "/music/Artist/Artist/Album" {
+		t.Errorf("Album.Path = %q, want %q", album.Path, "/music/Artist/Artist/Album")
+	}
+
+	album.Label = "Some/Label"
+	album.ComputePaths(cfg)
+
+	if album.Path != "/music/Some_Label/Artist/Album" {
+		t.Errorf("Album.Path = %q, want %q", album.Path, "/music/Some_Label/Artist/Album")
+	}
+}
+
+func TestIsCompilation(t *testing.T) {
+	tests := []struct {
+		name    string
+		artists []string
+		want    bool
+	}{
+		{"no track artists", []string{"", "", ""}, false},
+		{"split release", []string{"A", "B", "A", "B"}, false},
+		{"case-insensitive duplicates", []string{"A", "a", "B", "b "}, false},
+		{"various artists", []string{"A", "B", "C"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCompilation(tt.artists); got != tt.want {
+				t.Errorf("IsCompilation(%v) = %v, want %v", tt.artists, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompilation_VariousArtistsPaths(t *testing.T) {
+	albumCfg := &PathConfig{
+		DownloadsPath:          "/music/{artist}/{album}",
+		CoverArtFileNameFormat: "{album}",
+		PlaylistFileNameFormat: "{album}",
+		PlaylistFormat:         PlaylistFormatM3U,
+		VariousArtistsName:     "Various Artists",
+	}
+	trackCfg := &TrackConfig{
+		FileNameFormat:         "{tracknum} {artist} - {title}.mp3",
+		CompilationTrackArtist: true,
+	}
+
+	album := &Album{Artist: "Some Label", Title: "Sampler", Compilation: true}
+	album.ComputePaths(albumCfg)
+
+	if album.Path != "/music/Various Artists/Sampler" {
+		t.Errorf("Album.Path = %q, want %q", album.Path, "/music/Various Artists/Sampler")
+	}
+
+	track := &Track{Album: album, Number: 3, Title: "Song", Artist: "Guest"}
+	track.ComputePath(trackCfg)
+
+	if want := "/music/Various Artists/Sampler/03 Guest - Song.mp3"; track.Path != want {
+		t.Errorf("Track.Path = %q, want %q", track.Path, want)
+	}
+}
+
+func TestSingles_Paths(t *testing.T) {
+	albumCfg := &PathConfig{
+		DownloadsPath:        "/music/{artist}/{album}",
+		SinglesDownloadsPath: "/music/{artist}/Singles",
+	}
+	trackCfg := &TrackConfig{
+		FileNameFormat:        "{tracknum} {title}.mp3",
+		SinglesFileNameFormat: "{title}.mp3",
+	}
+
+	tests := []struct {
+		name   string
+		single bool
+		want   string
+	}{
+		{name: "album", single: false, want: "/music/Artist/Song/01 Song.mp3"},
+		{name: "single", single: true, want: "/music/Artist/Singles/Song.mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			album := &Album{Artist: "Artist", Title: "Song", Single: tt.single}
+			album.ComputePaths(albumCfg)
+			track := NewTrack(album, 1, 1, "Song", 180, "", "", trackCfg)
+			if track.Path != tt.want {
+				t.Errorf("Track.Path = %q, want %q", track.Path, tt.want)
+			}
+		})
+	}
+}
+
 func TestTrack_PathComputation(t *testing.T) {
 	albumCfg := &PathConfig{
 		DownloadsPath:          "/music/{artist}/{album}",
@@ -96,6 +261,88 @@ func TestTrack_PathComputation(t *testing.T) {
 	if track.Path != expectedPath {
 		t.Errorf("Track.Path = %q, want %q", track.Path, expectedPath)
 	}
+
+	trackCfg.FileNameFormat = "{disc}-{tracknum} {title}.mp3"
+	track = NewTrack(album, 2, 3, "Track Title", 180.5, "", "http://example.com/track.mp3", trackCfg)
+	if want := "/music/Artist/Album/2-03 Track Title.mp3"; track.Path != want {
+		t.Errorf("Track.Path = %q, want %q", track.Path, want)
+	}
+}
+
+func TestTrack_ExtPlaceholder(t *testing.T) {
+	album := NewAlbum("Artist", "Album", "", time.Time{}, &PathConfig{DownloadsPath: "/music/{album}"})
+	trackCfg := &TrackConfig{FileNameFormat: "{tracknum} {title}.{ext}"}
+	track := NewTrack(album, 1, 1, "Title", 180, "", "http://example.com/stream", trackCfg)
+	if want := "/music/Album/01 Title.mp3"; track.Path != want {
+		t.Errorf("Track.Path = %q, want %q", track.Path, want)
+	}
+
+	track.Ext = "flac"
+	track.ComputePath(trackCfg)
+	if want := "/music/Album/01 Title.flac"; track.Path != want {
+		t.Errorf("Track.Path = %q, want %q", track.Path, want)
+	}
+}
+
+func TestTrack_LongPaths(t *testing.T) {
+	title := strings.Repeat("Long Title ", 30) + "End"
+	for _, longPaths := range []bool{false, true} {
+		albumCfg := &PathConfig{DownloadsPath: "/music/{artist}/{album}", LongPaths: longPaths}
+		trackCfg := &TrackConfig{FileNameFormat: "{title}.mp3", LongPaths: longPaths}
+		album := NewAlbum("Artist", title, "", time.Time{}, albumCfg)
+		track := NewTrack(album, 1, 1, title, 180, "", "http://example.com/track.mp3", trackCfg)
+
+		// Without long paths, the folder and file names are truncated
+		truncated := len(track.Path) < 260
+		if truncated == longPaths {
+			t.Errorf("LongPaths %t: len(Track.Path) = %d", longPaths, len(track.Path))
+		}
+		if longPaths && !strings.HasSuffix(track.Path, title+".mp3") {
+			t.Errorf("LongPaths: Track.Path = %q, want the whole title", track.Path)
+		}
+	}
+}
+
+func TestTrack_PageURL(t *testing.T) {
+	album := &Album{URL: "https://artist.bandcamp.com/album/foo?secret=abc"}
+	tests := []struct {
+		url, want string
+	}{
+		{"", ""},
+		{"/track/bar", "https://artist.bandcamp.com/track/bar"},
+		{"/track/bar?secret=abc", "https://artist.bandcamp.com/track/bar?secret=abc"},
+		{"https://other.bandcamp.com/track/baz", "https://other.bandcamp.com/track/baz"},
+	}
+
+	for _, tt := range tests {
+		track := &Track{Album: album, URL: tt.url}
+		if got := track.PageURL(); got != tt.want {
+			t.Errorf("PageURL() of %q = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	orphan := &Track{Album: &Album{}, URL: "/track/bar"}
+	if got := orphan.PageURL(); got != "" {
+		t.Errorf("PageURL() without album URL = %q, want empty", got)
+	}
+}
+
+func TestTrack_EstimatedSize(t *testing.T) {
+	tests := []struct {
+		duration float64
+		want     int64
+	}{
+		{0, 0},
+		{1, 16000},
+		{180.5, 2888000},
+	}
+
+	for _, tt := range tests {
+		track := &Track{Duration: tt.duration}
+		if got := track.EstimatedSize(); got != tt.want {
+			t.Errorf("EstimatedSize() of %vs = %d, want %d", tt.duration, got, tt.want)
+		}
+	}
 }
 
 func TestPlaylistFormat_Extension(t *testing.T) {
@@ -107,6 +354,7 @@ func TestPlaylistFormat_Extension(t *testing.T) {
 		{PlaylistFormatPLS, ".pls"},
 		{PlaylistFormatWPL, ".wpl"},
 		{PlaylistFormatZPL, ".zpl"},
+		{PlaylistFormatM3U8, ".m3u8"},
 	}
 
 	for _, tt := range tests {
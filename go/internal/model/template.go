@@ -0,0 +1,97 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches the placeholders of a path template.
+var placeholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// optionalSections resolves the conditional sections of a path template:
+// each "{?...}" is replaced by its content if none of the placeholders in
+// it is empty, as reported by empty, and removed otherwise. Placeholders
+// are left for the caller to replace.
+//
+// Example:
+//
+//	optionalSections("{album}{? ({year})}", func(string) bool { return true })
+//	// "{album}"
+func optionalSections(template string, empty func(placeholder string) bool) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(template, "{?")
+		if start < 0 {
+			break
+		}
+		end := sectionEnd(template, start+2)
+		if end < 0 {
+			// Not closed: kept as it is
+			break
+		}
+
+		b.WriteString(template[:start])
+		content := template[start+2 : end]
+		keep := true
+		for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+			if empty(match[1]) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			b.WriteString(content)
+		}
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+	return b.String()
+}
+
+// sectionEnd returns the index of the brace closing the section whose
+// content starts at start, skipping the placeholders in it, or -1.
+func sectionEnd(template string, start int) int {
+	depth := 1
+	for i := start; i < len(template); i++ {
+		switch template[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// emptyPlaceholder reports whether the album placeholder name has no
+// value: a missing release date, artist, title or label.
+func (a *Album) emptyPlaceholder(name string) bool {
+	switch name {
+	case "year", "month", "day":
+		return a.ReleaseDate.IsZero()
+	case "artist":
+		return a.Artist == ""
+	case "album":
+		return a.Title == ""
+	case "label":
+		return a.LabelName() == ""
+	}
+	return false
+}
+
+// emptyPlaceholder reports whether the track placeholder name has no
+// value, like the album placeholders, or a missing title or number.
+func (t *Track) emptyPlaceholder(name string) bool {
+	switch name {
+	case "title":
+		return t.Title == ""
+	case "tracknum":
+		return t.Number == 0
+	case "disc":
+		return t.DiscNumber == 0
+	}
+	return t.Album.emptyPlaceholder(name)
+}
@@ -2,10 +2,16 @@ package model
 
 import (
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+// StreamBitrate is the bitrate of the MP3 streams served by Bandcamp, in
+// kbps.
+const StreamBitrate = 128
+
 // Track represents a single track within an album.
 //
 // Track contains metadata for one song including:
@@ -20,13 +26,17 @@ import (
 //
 // Example:
 //
-//	cfg := &TrackConfig{FileNameFormat: "{tracknum} {title}.mp3"}
+//	cfg := &TrackConfig{FileNameFormat: "{tracknum} {title}.{ext}"}
 //	track := NewTrack(album, 1, 1, "Song Title", 180.5, "", mp3URL, cfg)
 //	// track.Path = "/music/Artist/Album/01 Song Title.mp3"
 type Track struct {
 	// Album is a reference to the parent album.
 	Album *Album
 
+	// ID is the Bandcamp track ID, or zero if unknown. It is embedded in
+	// the tags, so existing files can be recognized as the track.
+	ID int64
+
 	// Number is the track number (1-indexed).
 	Number int
 
@@ -36,6 +46,10 @@ type Track struct {
 	// Title is the track title.
 	Title string
 
+	// Artist is the track artist. For most albums this is the album artist;
+	// on compilations each track can have its own artist.
+	Artist string
+
 	// Duration is the track length in seconds.
 	Duration float64
 
@@ -43,9 +57,32 @@ type Track struct {
 	// Empty string if no lyrics are available.
 	Lyrics string
 
+	// URL is the address of the track's own page, which may be relative to
+	// the album URL (e.g. "/track/name"). Empty if unknown.
+	URL string
+
+	// HasInfo is true if the track page has a description or credits.
+	// They are not part of the album page; see About and Credits.
+	HasInfo bool
+
+	// About and Credits are the track's description and credits (liner
+	// notes). They are only filled once the track page has been fetched.
+	About   string
+	Credits string
+
+	// Composer and ISRC are found in the credits of the album or of the
+	// track, if the artist listed them. Empty otherwise.
+	Composer string
+	ISRC     string
+
 	// Mp3URL is the URL to download the MP3 file from.
 	Mp3URL string
 
+	// Ext is the extension of the track's file, without the dot, used for
+	// the {ext} placeholder. Empty means DefaultExt; it is set from the
+	// format of the stream once downloaded.
+	Ext string
+
 	// Path is the computed local file path where the track will be saved.
 	// Includes the full path and filename with extension.
 	Path string
@@ -55,23 +92,55 @@ type Track struct {
 //
 // The FileNameFormat supports placeholders that are replaced with actual values:
 //   - {tracknum} - Track number (2 digits, zero-padded)
+//   - {disc} - Disc number
 //   - {title} - Track title
-//   - {artist} - Artist name (from album)
+//   - {artist} - Artist name (from album, or from the track on compilations
+//     when CompilationTrackArtist is set)
 //   - {album} - Album title
+//   - {label} - Label name (from album)
 //   - {year}, {month}, {day} - Release date components
+//   - {ext} - Extension of the file's format, without the dot (see Track.Ext)
 //
 // Example:
 //
 //	cfg := &TrackConfig{
-//	    FileNameFormat: "{tracknum} {artist} - {title}.mp3",
+//	    FileNameFormat: "{tracknum} {artist} - {title}.{ext}",
 //	}
 //	// Results in filenames like "01 The Beatles - Come Together.mp3"
 type TrackConfig struct {
 	// FileNameFormat is the template for track filenames.
-	// Must include the file extension (typically ".mp3").
+	// Must include the file extension, typically ".{ext}" to follow the
+	// format of the stream.
 	FileNameFormat string
+
+	// SinglesFileNameFormat, when non-empty, replaces FileNameFormat for
+	// the tracks of singles (see Album.Single), e.g. "{title}.{ext}".
+	SinglesFileNameFormat string
+
+	// CompilationTrackArtist makes {artist} resolve to the track's own artist
+	// instead of the album artist for tracks of compilation albums.
+	CompilationTrackArtist bool
+
+	// Numbering is how the tracks of multi-disc albums are numbered:
+	// NumberingDisc (the default) restarts at 1 on each disc, and
+	// NumberingSequential numbers them across discs.
+	Numbering string
+
+	// LongPaths keeps file names of paths over MAX_PATH whole (see
+	// PathConfig.LongPaths).
+	LongPaths bool
 }
 
+// DefaultExt is the extension of tracks whose stream format is not known
+// yet: Bandcamp streams MP3 files.
+const DefaultExt = "mp3"
+
+// Track numberings of TrackConfig.Numbering.
+const (
+	NumberingDisc       = "disc"
+	NumberingSequential = "sequential"
+)
+
 // NewTrack creates a new Track with computed path.
 //
 // Parameters:
@@ -92,23 +161,79 @@ func NewTrack(album *Album, discNumber, number int, title string, duration float
 		DiscNumber: discNumber,
 		Number:     number,
 		Title:      title,
+		Artist:     album.Artist,
 		Duration:   duration,
 		Lyrics:     lyrics,
 		Mp3URL:     mp3URL,
 	}
 
-	track.Path = track.parseFilePath(cfg)
+	track.ComputePath(cfg)
 
 	return track
 }
 
+// ComputePath (re)computes Path from the album path and the config.
+//
+// NewTrack calls this automatically. Call it again after changing fields
+// that affect the file name (such as Artist) or the album path.
+func (t *Track) ComputePath(cfg *TrackConfig) {
+	t.Path = t.parseFilePath(cfg)
+}
+
+// Extension returns Ext, or DefaultExt if it is empty.
+func (t *Track) Extension() string {
+	if t.Ext != "" {
+		return t.Ext
+	}
+	return DefaultExt
+}
+
+// ArtistName returns the track artist, falling back to the album artist.
+func (t *Track) ArtistName() string {
+	if t.Artist != "" {
+		return t.Artist
+	}
+	return t.Album.Artist
+}
+
+// PageURL returns the absolute URL of the track's page, resolving a
+// relative URL against the album URL. Returns "" if it cannot be determined.
+func (t *Track) PageURL() string {
+	if t.URL == "" {
+		return ""
+	}
+	ref, err := url.Parse(t.URL)
+	if err != nil {
+		return ""
+	}
+	if ref.IsAbs() {
+		return t.URL
+	}
+	if t.Album == nil || t.Album.URL == "" {
+		return ""
+	}
+	base, err := url.Parse(t.Album.URL)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	resolved.RawQuery = ref.RawQuery
+	return resolved.String()
+}
+
+// EstimatedSize returns the size of the track's MP3 stream estimated from
+// its duration at StreamBitrate, in bytes, without requesting the stream.
+func (t *Track) EstimatedSize() int64 {
+	return int64(t.Duration * StreamBitrate * 1000 / 8)
+}
+
 // parseFilePath computes the full file path for this track.
 func (t *Track) parseFilePath(cfg *TrackConfig) string {
 	fileName := t.parseFileName(cfg)
 	filePath := filepath.Join(t.Album.Path, fileName)
 
 	// Limit total path length for Windows compatibility (MAX_PATH = 260)
-	if len(filePath) >= 260 {
+	if len(filePath) >= 260 && !cfg.LongPaths {
 		ext := filepath.Ext(filePath)
 		maxLen := 11 - len(ext) // Leave room for path separator and extension
 		if maxLen > 0 && maxLen < len(fileName) {
@@ -121,13 +246,24 @@ func (t *Track) parseFilePath(cfg *TrackConfig) string {
 
 // parseFileName computes the filename from the config template.
 func (t *Track) parseFileName(cfg *TrackConfig) string {
-	fileName := cfg.FileNameFormat
+	template := cfg.FileNameFormat
+	if t.Album.Single && cfg.SinglesFileNameFormat != "" {
+		template = cfg.SinglesFileNameFormat
+	}
+	fileName := optionalSections(template, t.emptyPlaceholder)
 	fileName = strings.ReplaceAll(fileName, "{year}", t.Album.ReleaseDate.Format("2006"))
 	fileName = strings.ReplaceAll(fileName, "{month}", t.Album.ReleaseDate.Format("01"))
 	fileName = strings.ReplaceAll(fileName, "{day}", t.Album.ReleaseDate.Format("02"))
 	fileName = strings.ReplaceAll(fileName, "{album}", t.Album.Title)
-	fileName = strings.ReplaceAll(fileName, "{artist}", t.Album.Artist)
+	artist := t.Album.Artist
+	if cfg.CompilationTrackArtist && t.Album.Compilation {
+		artist = t.ArtistName()
+	}
+	fileName = strings.ReplaceAll(fileName, "{artist}", artist)
+	fileName = strings.ReplaceAll(fileName, "{label}", t.Album.LabelName())
 	fileName = strings.ReplaceAll(fileName, "{title}", t.Title)
 	fileName = strings.ReplaceAll(fileName, "{tracknum}", fmt.Sprintf("%02d", t.Number))
+	fileName = strings.ReplaceAll(fileName, "{disc}", strconv.Itoa(t.DiscNumber))
+	fileName = strings.ReplaceAll(fileName, "{ext}", t.Extension())
 	return sanitizeFileName(fileName)
 }
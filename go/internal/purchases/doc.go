@@ -0,0 +1,13 @@
+// Package purchases reads the URLs of the releases bought on Bandcamp from
+// what Bandcamp gives buyers: the CSV export of a purchase history and the
+// purchase receipt e-mails, saved as .eml files.
+//
+//	urls, err := purchases.ReadURLs("bandcamp-purchases.csv")
+//	urls, err = purchases.ReadURLs("receipts/") // every .eml file in it
+//
+// Only release pages (/album/ and /track/ URLs, on bandcamp.com or on a
+// custom domain) are kept, without their query string (e.g. the tracking
+// parameters of e-mail links), in the order they were found and without
+// duplicates. Download links of purchases need a logged-in account and
+// are ignored.
+package purchases
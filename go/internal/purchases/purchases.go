@@ -0,0 +1,178 @@
+package purchases
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// releaseURLPattern matches the URLs of release pages in text.
+var releaseURLPattern = regexp.MustCompile(`https?://[^\s"'<>()]+?/(?:album|track)/[^\s"'<>()]+`)
+
+// ReadURLs returns the release URLs of the purchases export at path: a CSV
+// file, a receipt e-mail with the .eml extension, or a folder of receipts.
+func ReadURLs(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readFile(path)
+	}
+
+	receipts, err := filepath.Glob(filepath.Join(path, "*.eml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) == 0 {
+		return nil, fmt.Errorf("no .eml receipt in %s", path)
+	}
+	var urls []string
+	for _, receipt := range receipts {
+		found, err := readFile(receipt)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, found...)
+	}
+	return dedup(urls), nil
+}
+
+// readFile reads a CSV export or, with the .eml extension, a receipt.
+func readFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	parse := ParseCSV
+	if strings.EqualFold(filepath.Ext(path), ".eml") {
+		parse = ParseEmail
+	}
+	urls, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return urls, nil
+}
+
+// ParseCSV returns the release URLs found in the fields of a CSV export,
+// whatever their column.
+func ParseCSV(r io.Reader) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	var urls []string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range record {
+			urls = append(urls, FindURLs(field)...)
+		}
+	}
+	return dedup(urls), nil
+}
+
+// ParseEmail returns the release URLs linked from a receipt e-mail, in its
+// plain text and HTML parts.
+func ParseEmail(r io.Reader) ([]string, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := partText(msg.Header, msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	return FindURLs(text), nil
+}
+
+// header is the common method of mail.Header and textproto.MIMEHeader.
+type header interface {
+	Get(key string) string
+}
+
+// partText returns the decoded text of a MIME part and its subparts.
+func partText(h header, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") {
+		var b strings.Builder
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return b.String(), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := partText(part.Header, part)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(text)
+			b.WriteString("\n")
+		}
+	}
+
+	// multipart.Reader already decodes quoted-printable parts
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// FindURLs returns the release URLs in text, which may be HTML, without
+// their query string and fragment.
+func FindURLs(text string) []string {
+	var urls []string
+	for _, match := range releaseURLPattern.FindAllString(html.UnescapeString(text), -1) {
+		u, err := url.Parse(strings.TrimRight(match, ".,;:!"))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		u.RawQuery, u.Fragment = "", ""
+		urls = append(urls, u.String())
+	}
+	return dedup(urls)
+}
+
+// dedup removes the repeated URLs, keeping the first of each.
+func dedup(urls []string) []string {
+	seen := make(map[string]struct{}, len(urls))
+	kept := urls[:0]
+	for _, u := range urls {
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		kept = append(kept, u)
+	}
+	return kept
+}
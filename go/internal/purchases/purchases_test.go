@@ -0,0 +1,121 @@
+package purchases
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "plain text",
+			text: "You bought https://artist.bandcamp.com/album/first-album. Enjoy!",
+			want: []string{"https://artist.bandcamp.com/album/first-album"},
+		},
+		{
+			name: "HTML with tracking parameters",
+			text: `<a href="https://artist.bandcamp.com/track/song?from=email&amp;utm_source=receipt">Song</a>`,
+			want: []string{"https://artist.bandcamp.com/track/song"},
+		},
+		{
+			name: "custom domain and duplicates",
+			text: "https://music.example.com/album/x https://music.example.com/album/x#top",
+			want: []string{"https://music.example.com/album/x"},
+		},
+		{
+			name: "no release",
+			text: "https://bandcamp.com/download?id=123 https://artist.bandcamp.com/merch/shirt",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindURLs(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("FindURLs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "date,artist,item title,item url\n" +
+		"2024-01-02,Artist,First Album,https://artist.bandcamp.com/album/first-album\n" +
+		"2024-02-03,\"Other, Artist\",Song,https://other.bandcamp.com/track/song\n" +
+		"2024-03-04,Artist,First Album,https://artist.bandcamp.com/album/first-album\n"
+
+	got, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+	want := []string{"https://artist.bandcamp.com/album/first-album", "https://other.bandcamp.com/track/song"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseCSV() = %q, want %q", got, want)
+	}
+}
+
+func TestParseEmail(t *testing.T) {
+	eml := "From: Bandcamp <noreply@bandcamp.com>\r\n" +
+		"Subject: Thank you!\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Listen: https://artist.bandcamp.com/album/a-very-long-album-title-that-wraps-=\r\n" +
+		"around?from=3Demail\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"PGEgaHJlZj0iaHR0cHM6Ly9vdGhlci5iYW5kY2FtcC5jb20vdHJhY2svc29uZyI+U29uZzwvYT4=\r\n" +
+		"--b1--\r\n"
+
+	got, err := ParseEmail(strings.NewReader(eml))
+	if err != nil {
+		t.Fatalf("ParseEmail failed: %v", err)
+	}
+	want := []string{
+		"https://artist.bandcamp.com/album/a-very-long-album-title-that-wraps-around",
+		"https://other.bandcamp.com/track/song",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseEmail() = %q, want %q", got, want)
+	}
+}
+
+func TestReadURLs_Folder(t *testing.T) {
+	dir := t.TempDir()
+	receipts := map[string]string{
+		"1.eml":     "Subject: Receipt\r\n\r\nhttps://artist.bandcamp.com/album/one\r\n",
+		"2.eml":     "Subject: Receipt\r\n\r\nhttps://artist.bandcamp.com/album/two https://artist.bandcamp.com/album/one\r\n",
+		"notes.txt": "https://artist.bandcamp.com/album/ignored\n",
+	}
+	for name, content := range receipts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReadURLs(dir)
+	if err != nil {
+		t.Fatalf("ReadURLs failed: %v", err)
+	}
+	want := []string{"https://artist.bandcamp.com/album/one", "https://artist.bandcamp.com/album/two"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReadURLs() = %q, want %q", got, want)
+	}
+
+	if _, err := ReadURLs(t.TempDir()); err == nil {
+		t.Error("ReadURLs of a folder without receipts succeeded, want an error")
+	}
+}
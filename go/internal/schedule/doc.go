@@ -0,0 +1,14 @@
+// Package schedule restricts downloads to a daily time window, e.g. the
+// off-peak hours of a metered connection:
+//
+//	w, err := schedule.Parse("01:00-07:00")
+//	if err != nil {
+//	    return err
+//	}
+//	if !w.Contains(time.Now()) {
+//	    time.Sleep(time.Until(w.Next(time.Now())))
+//	}
+//
+// Windows are in local time and may span midnight ("22:00-06:00"). The
+// zero Window, parsed from "", is always open.
+package schedule
@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time window, see Parse.
+type Window struct {
+	// start and end are the times of day the window opens and closes, as
+	// offsets from midnight; the window spans midnight if end < start.
+	start, end time.Duration
+	set        bool
+}
+
+// Parse parses a window written "HH:MM-HH:MM", e.g. "01:00-07:00". An
+// empty string is the zero Window, which is always open.
+func Parse(s string) (Window, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Window{}, nil
+	}
+	from, to, ok := strings.Cut(strings.ReplaceAll(s, "–", "-"), "-")
+	if !ok {
+		return Window{}, errors.New("must be HH:MM-HH:MM")
+	}
+	start, err := parseTime(from)
+	if err != nil {
+		return Window{}, err
+	}
+	end, err := parseTime(to)
+	if err != nil {
+		return Window{}, err
+	}
+	if start == end {
+		return Window{}, errors.New("starts and ends at the same time")
+	}
+	return Window{start: start, end: end, set: true}, nil
+}
+
+// parseTime parses a time of day written "HH:MM" into an offset from
+// midnight.
+func parseTime(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", strings.TrimSpace(s))
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsZero reports whether w is the zero Window, which is always open.
+func (w Window) IsZero() bool {
+	return !w.set
+}
+
+// String returns the window as written for Parse, or "" for the zero
+// Window.
+func (w Window) String() string {
+	if !w.set {
+		return ""
+	}
+	return fmt.Sprintf("%s-%s", clock(w.start), clock(w.end))
+}
+
+// Contains reports whether the window is open at t.
+func (w Window) Contains(t time.Time) bool {
+	if !w.set {
+		return true
+	}
+	now := sinceMidnight(t)
+	if w.start < w.end {
+		return now >= w.start && now < w.end
+	}
+	return now >= w.start || now < w.end
+}
+
+// Next returns when the window next opens after t, or t if it is open.
+func (w Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	next := at(t, 0, w.start)
+	if next.Before(t) {
+		next = at(t, 1, w.start)
+	}
+	return next
+}
+
+// End returns when the window open at t closes, or the zero time if it is
+// the zero Window, which never closes. t must be within the window.
+func (w Window) End(t time.Time) time.Time {
+	if !w.set {
+		return time.Time{}
+	}
+	if w.end < w.start && sinceMidnight(t) >= w.start {
+		return at(t, 1, w.end)
+	}
+	return at(t, 0, w.end)
+}
+
+// sinceMidnight returns the time of day of t, as an offset from midnight.
+func sinceMidnight(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
+
+// at returns the time of day offset of the day days after that of t, in
+// the location of t.
+func at(t time.Time, days int, offset time.Duration) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+days, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+// clock formats an offset from midnight as "HH:MM".
+func clock(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset/time.Hour), int(offset%time.Hour/time.Minute))
+}
@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{src: "", want: ""},
+		{src: "01:00-07:00", want: "01:00-07:00"},
+		{src: " 1:30 – 7:05 ", want: "01:30-07:05"},
+		{src: "22:00-06:00", want: "22:00-06:00"},
+		{src: "01:00", wantErr: true},
+		{src: "01:00-25:00", wantErr: true},
+		{src: "night-morning", wantErr: true},
+		{src: "03:00-03:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		w, err := Parse(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if err == nil && w.String() != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.src, w, tt.want)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	day := func(d, h, m int) time.Time {
+		return time.Date(2026, 3, d, h, m, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		window       string
+		t            time.Time
+		wantContains bool
+		wantNext     time.Time // when not open
+		wantEnd      time.Time // when open
+	}{
+		{window: "01:00-07:00", t: day(10, 3, 0), wantContains: true, wantEnd: day(10, 7, 0)},
+		{window: "01:00-07:00", t: day(10, 0, 30), wantNext: day(10, 1, 0)},
+		{window: "01:00-07:00", t: day(10, 7, 0), wantNext: day(11, 1, 0)},
+		{window: "22:00-06:00", t: day(10, 23, 0), wantContains: true, wantEnd: day(11, 6, 0)},
+		{window: "22:00-06:00", t: day(10, 2, 0), wantContains: true, wantEnd: day(10, 6, 0)},
+		{window: "22:00-06:00", t: day(10, 12, 0), wantNext: day(10, 22, 0)},
+		{window: "", t: day(10, 12, 0), wantContains: true},
+	}
+	for _, tt := range tests {
+		w, err := Parse(tt.window)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.window, err)
+		}
+		if got := w.Contains(tt.t); got != tt.wantContains {
+			t.Errorf("%q.Contains(%s) = %t, want %t", tt.window, tt.t.Format("Jan 2 15:04"), got, tt.wantContains)
+		}
+		if tt.wantContains {
+			if got := w.End(tt.t); !got.Equal(tt.wantEnd) {
+				t.Errorf("%q.End(%s) = %s, want %s", tt.window, tt.t.Format("Jan 2 15:04"), got, tt.wantEnd)
+			}
+			continue
+		}
+		if got := w.Next(tt.t); !got.Equal(tt.wantNext) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.window, tt.t.Format("Jan 2 15:04"), got, tt.wantNext)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Package sink sends download progress messages to destinations other
+// than the console: a log file, syslog, or a webhook.
+//
+// Sinks are described by the strings of the "progress_sinks" setting and
+// opened together with OpenAll. The returned Set wraps the progress
+// callback given to download.NewManager, so every event is printed as
+// before and also sent to each sink:
+//
+//	sinks, err := sink.OpenAll(settings.ProgressSinks)
+//	if err != nil {
+//	    return err
+//	}
+//	defer sinks.Close()
+//
+//	manager := download.NewManager(settings, sinks.Wrap(printer))
+//
+// # Sink Specifications
+//
+//   - "/var/log/bandcamp-dl.log" or "file:/var/log/bandcamp-dl.log"
+//     appends one line per message to the file.
+//   - "syslog:" logs to the local syslog daemon, and "syslog://host:514"
+//     (UDP) or "syslog+tcp://host:514" to a remote one. Syslog is not
+//     available on Windows.
+//   - "https://..." (or "http://...") POSTs a JSON object per message.
+//
+// LevelProgress events, which only carry byte counts, are never sent.
+// Verbose messages go to files and syslog, while webhooks only receive
+// successes, warnings and errors, to keep chat channels readable.
+//
+// # Webhooks
+//
+// Each message is posted as:
+//
+//	{
+//	  "time": "2026-01-02T15:04:05Z",
+//	  "level": "error",
+//	  "message": "Error downloading Track: HTTP 404: 404 Not Found",
+//	  "content": "❌ Error downloading Track: HTTP 404: 404 Not Found",
+//	  "text": "❌ Error downloading Track: HTTP 404: 404 Not Found"
+//	}
+//
+// "content" and "text" duplicate the message so the URL of a Discord or
+// Slack incoming webhook can be used as is. Messages are posted in the
+// background; if the webhook cannot keep up, messages are dropped rather
+// than slowing the downloads down.
+package sink
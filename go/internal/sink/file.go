@@ -0,0 +1,45 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// fileSink appends one line per event to a log file:
+//
+//	2026-01-02T15:04:05Z warning Retry 1/7 for Track
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	now  func() time.Time
+}
+
+func openFile(path string) (Sink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty log file path")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file, now: time.Now}, nil
+}
+
+func (s *fileSink) Send(event download.ProgressEvent) {
+	line := fmt.Sprintf("%s %s %s\n", s.now().UTC().Format(time.RFC3339), event.Level, strings.ReplaceAll(event.Message, "\n", " "))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file.WriteString(line)
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// Sink receives progress events.
+type Sink interface {
+	// Send handles one event. It must not block for long, as it is called
+	// from the download goroutines.
+	Send(event download.ProgressEvent)
+
+	// Close flushes pending events and releases the sink's resources.
+	Close() error
+}
+
+// Open opens the sink described by spec (see the package documentation).
+//
+// Example:
+//
+//	s, err := sink.Open("syslog:")
+func Open(spec string) (Sink, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, errors.New("empty progress sink")
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL %q", spec)
+		}
+		return newWebhookSink(u.String()), nil
+	case strings.HasPrefix(spec, "syslog:"), strings.HasPrefix(spec, "syslog+tcp:"):
+		return openSyslog(spec)
+	default:
+		return openFile(strings.TrimPrefix(spec, "file:"))
+	}
+}
+
+// Set is a group of sinks opened by OpenAll. A nil or empty Set is valid
+// and does nothing.
+type Set []Sink
+
+// OpenAll opens the sinks described by specs. If one cannot be opened,
+// those already opened are closed and the error is returned.
+func OpenAll(specs []string) (Set, error) {
+	var set Set
+	for _, spec := range specs {
+		s, err := Open(spec)
+		if err != nil {
+			set.Close()
+			return nil, fmt.Errorf("progress sink %q: %w", spec, err)
+		}
+		set = append(set, s)
+	}
+	return set, nil
+}
+
+// Send sends event to every sink of the set.
+func (s Set) Send(event download.ProgressEvent) {
+	if event.Level == download.LevelProgress {
+		return
+	}
+	for _, sink := range s {
+		sink.Send(event)
+	}
+}
+
+// Wrap returns a progress callback that calls next, if not nil, then
+// sends the event to the sinks.
+func (s Set) Wrap(next func(download.ProgressEvent)) func(download.ProgressEvent) {
+	if len(s) == 0 {
+		return next
+	}
+	return func(event download.ProgressEvent) {
+		if next != nil {
+			next(event)
+		}
+		s.Send(event)
+	}
+}
+
+// Close closes every sink of the set and returns the first error.
+func (s Set) Close() error {
+	var first error
+	for _, sink := range s {
+		if err := sink.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
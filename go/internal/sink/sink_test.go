@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+func TestOpen_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "  ", "https://", "syslog://localhost", filepath.Join(t.TempDir(), "missing", "log.txt")} {
+		if s, err := Open(spec); err == nil {
+			s.Close()
+			t.Errorf("Open(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.log")
+	sinks, err := OpenAll([]string{"file:" + path})
+	if err != nil {
+		t.Fatalf("OpenAll failed: %v", err)
+	}
+
+	var printed int
+	progress := sinks.Wrap(func(download.ProgressEvent) { printed++ })
+	progress(download.ProgressEvent{Message: "Retry 1/7 for Track", Level: download.LevelWarning})
+	progress(download.ProgressEvent{Level: download.LevelProgress, Written: 10, Total: 100})
+	progress(download.ProgressEvent{Message: "multi\nline", Level: download.LevelVerbose})
+	if err := sinks.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if printed != 3 {
+		t.Errorf("wrapped callback called %d times, want 3", printed)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log has %d lines, want 2:\n%s", len(lines), data)
+	}
+	if !strings.HasSuffix(lines[0], " warning Retry 1/7 for Track") {
+		t.Errorf("line 1 = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], " verbose multi line") {
+		t.Errorf("line 2 = %q", lines[1])
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg webhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		mu.Lock()
+		received = append(received, msg)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	s, err := Open(server.URL + "/hook")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	s.Send(download.ProgressEvent{Message: "Fetching album info", Level: download.LevelVerbose})
+	s.Send(download.ProgressEvent{Message: "Downloading Album", Level: download.LevelInfo})
+	s.Send(download.ProgressEvent{Message: "Failed to download album: Album", Level: download.LevelError})
+	s.Close()
+	s.Send(download.ProgressEvent{Message: "after close", Level: download.LevelError})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("received %d messages, want 1: %+v", len(received), received)
+	}
+	msg := received[0]
+	if msg.Level != "error" || msg.Message != "Failed to download album: Album" {
+		t.Errorf("message = %+v", msg)
+	}
+	if msg.Content != "❌ Failed to download album: Album" || msg.Text != msg.Content {
+		t.Errorf("content = %q, text = %q", msg.Content, msg.Text)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package sink
+
+import "errors"
+
+// openSyslog fails, as the log/syslog package is not available on this
+// platform.
+func openSyslog(spec string) (Sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
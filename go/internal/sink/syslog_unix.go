@@ -0,0 +1,66 @@
+//go:build !windows && !plan9
+
+package sink
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// syslogTag is the program name of the syslog messages.
+const syslogTag = "bandcamp-dl"
+
+// syslogSink logs events with a priority matching their level.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// openSyslog connects to the local syslog for "syslog:", or to the remote
+// one of "syslog://host:port" (UDP) or "syslog+tcp://host:port".
+func openSyslog(spec string) (Sink, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var network string
+	switch u.Scheme {
+	case "syslog":
+		if u.Host != "" {
+			network = "udp"
+		}
+	case "syslog+tcp":
+		network = "tcp"
+	}
+	if network != "" && u.Port() == "" {
+		return nil, fmt.Errorf("missing port in %q", spec)
+	}
+
+	w, err := syslog.Dial(network, u.Host, syslog.LOG_INFO|syslog.LOG_USER, syslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Send(event download.ProgressEvent) {
+	switch event.Level {
+	case download.LevelError:
+		s.w.Err(event.Message)
+	case download.LevelWarning:
+		s.w.Warning(event.Message)
+	case download.LevelSuccess:
+		s.w.Notice(event.Message)
+	case download.LevelVerbose:
+		s.w.Debug(event.Message)
+	default:
+		s.w.Info(event.Message)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
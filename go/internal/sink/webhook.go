@@ -0,0 +1,131 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+const (
+	// webhookQueueSize is the number of messages waiting to be posted
+	// beyond which new messages are dropped.
+	webhookQueueSize = 100
+
+	// webhookTimeout bounds each POST, so a slow webhook cannot hold the
+	// queue forever.
+	webhookTimeout = 10 * time.Second
+)
+
+// webhookMessage is the JSON body posted for each event.
+type webhookMessage struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+
+	// Content (Discord) and Text (Slack) repeat the message, prefixed with
+	// an emoji of its level.
+	Content string `json:"content"`
+	Text    string `json:"text"`
+}
+
+// webhookSink posts successes, warnings and errors to a URL from a
+// background goroutine.
+type webhookSink struct {
+	url    string
+	client *http.Client
+	done   chan struct{}
+
+	mu     sync.Mutex // guards queue against sends after Close
+	queue  chan webhookMessage
+	closed bool
+}
+
+func newWebhookSink(url string) *webhookSink {
+	s := &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan webhookMessage, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *webhookSink) Send(event download.ProgressEvent) {
+	var prefix string
+	switch event.Level {
+	case download.LevelSuccess:
+		prefix = "✅ "
+	case download.LevelWarning:
+		prefix = "⚠️ "
+	case download.LevelError:
+		prefix = "❌ "
+	default:
+		return
+	}
+
+	msg := webhookMessage{
+		Time:    time.Now().UTC(),
+		Level:   event.Level.String(),
+		Message: event.Message,
+		Content: prefix + event.Message,
+		Text:    prefix + event.Message,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		// The webhook is not keeping up: drop the message
+	}
+}
+
+func (s *webhookSink) run() {
+	defer close(s.done)
+	for msg := range s.queue {
+		s.post(msg)
+	}
+}
+
+// post sends one message. Errors are ignored, as there is nowhere left to
+// report them.
+func (s *webhookSink) post(msg webhookMessage) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BandcampDownloader")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// Close posts the queued messages and stops the sink.
+func (s *webhookSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/handiism/bandcamp-downloader/internal/daemon"
+	"github.com/handiism/bandcamp-downloader/internal/download"
+)
+
+// remoteTimeout bounds the requests to the daemon, except watching a job.
+const remoteTimeout = 10 * time.Second
+
+// pollInterval is how often the daemon's jobs are fetched in remote mode.
+const pollInterval = time.Second
+
+// queueRows is how many of the daemon's jobs the input screen lists.
+const queueRows = 5
+
+// Messages of the remote mode.
+type (
+	// JobSubmittedMsg is sent when the URL entered was queued in the
+	// daemon, or the job to watch was chosen.
+	JobSubmittedMsg struct {
+		Job *daemon.Job
+		Err error
+	}
+
+	// JobsMsg is sent with the daemon's jobs, fetched every pollInterval.
+	JobsMsg struct {
+		Jobs []daemon.Job
+		Err  error
+	}
+)
+
+// WithRemote returns the model submitting downloads to the daemon of
+// client, listening at addr, rather than downloading them itself. The
+// daemon's settings apply to its downloads, so the discography and
+// playlist options are not offered.
+func (m Model) WithRemote(client *daemon.Client, addr string) Model {
+	m.remote = client
+	m.remoteAddr = addr
+	return m
+}
+
+// fetchJobs returns a command fetching the daemon's jobs after delay.
+func (m Model) fetchJobs(delay time.Duration) tea.Cmd {
+	client := m.remote
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+		defer cancel()
+		jobs, err := client.Status(ctx)
+		return JobsMsg{Jobs: jobs, Err: err}
+	})
+}
+
+// submitJob returns a command queueing the URL entered in the daemon.
+func (m Model) submitJob() tea.Cmd {
+	client, ctx, url := m.remote, m.ctx, m.textInput.Value()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+		defer cancel()
+		job, err := client.Add(ctx, []string{url})
+		return JobSubmittedMsg{Job: job, Err: err}
+	}
+}
+
+// attachJob returns a command watching the daemon's running job, or its
+// oldest queued one, or nil if there is neither.
+func (m Model) attachJob() tea.Cmd {
+	var current *daemon.Job
+	for i := range m.jobs {
+		job := m.jobs[i]
+		if job.State == daemon.JobRunning || (job.State == daemon.JobQueued && current == nil) {
+			current = &job
+		}
+	}
+	if current == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return JobSubmittedMsg{Job: current}
+	}
+}
+
+// cancelJob returns a command cancelling the watched job in the daemon.
+// The job's stream reports when it stopped.
+func (m Model) cancelJob() tea.Cmd {
+	client, id, events := m.remote, m.job.ID, m.events
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+		defer cancel()
+		if _, err := client.Cancel(ctx, id); err != nil {
+			select {
+			case events <- download.ProgressEvent{Message: err.Error(), Level: download.LevelError}:
+			default:
+			}
+		}
+		return nil
+	}
+}
+
+// watchJob returns a command passing the messages of the watched job to
+// the UI until it finishes, then returning its results as a
+// DownloadDoneMsg.
+func (m Model) watchJob() tea.Cmd {
+	client, ctx, id, events := m.remote, m.ctx, m.job.ID, m.events
+	cancelled := m.lang.T("tui.cancelled")
+	return func() tea.Msg {
+		err := client.Watch(ctx, id, func(event daemon.Event) {
+			// Byte progress is polled with the jobs; state changes have
+			// no message
+			if event.Level == download.LevelProgress || event.Message == "" {
+				return
+			}
+			select {
+			case events <- download.ProgressEvent{Message: event.Message, Level: event.Level}:
+			default:
+			}
+		})
+		if err != nil {
+			return DownloadDoneMsg{Err: err}
+		}
+
+		statusCtx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+		defer cancel()
+		jobs, err := client.Status(statusCtx)
+		if err != nil {
+			return DownloadDoneMsg{Err: err}
+		}
+		for _, job := range jobs {
+			if job.ID == id {
+				return jobDoneMsg(job, cancelled)
+			}
+		}
+		return DownloadDoneMsg{Err: fmt.Errorf("%w %d", daemon.ErrUnknownJob, id)}
+	}
+}
+
+// jobDoneMsg returns the results of a finished job. cancelled is the error
+// message of cancelled jobs.
+func jobDoneMsg(job daemon.Job, cancelled string) DownloadDoneMsg {
+	var msg DownloadDoneMsg
+	if p := job.Progress; p != nil {
+		msg.Received, msg.Total, msg.Files, msg.TotalF = p.Received, p.Total, p.Files, p.TotalFiles
+		msg.Stats = download.RunStats{Downloaded: p.Downloaded, Skipped: p.Skipped, Unavailable: p.Unavailable, Failed: p.Failed, Received: p.Received}
+	}
+	if !job.Started.IsZero() {
+		msg.Stats.Elapsed = job.Finished.Sub(job.Started)
+	}
+	switch job.State {
+	case daemon.JobFailed:
+		msg.Err = errors.New(job.Error)
+	case daemon.JobCancelled:
+		msg.Err = errors.New(cancelled)
+	}
+	return msg
+}
+
+// updateJob updates the watched job, and the progress, from the jobs.
+func (m *Model) updateJob(jobs []daemon.Job) []tea.Cmd {
+	for _, job := range jobs {
+		if job.ID != m.job.ID {
+			continue
+		}
+		m.job = &job
+		if p := job.Progress; p != nil {
+			return m.updateProgress(p.Received, p.Total, p.Files, p.TotalFiles)
+		}
+	}
+	return nil
+}
+
+// viewQueue lists the daemon's latest jobs.
+func (m Model) viewQueue() string {
+	var b strings.Builder
+
+	b.WriteString(m.styles.dim.Render(m.lang.T("tui.remote", m.remoteAddr)))
+	b.WriteString("\n")
+	if m.remoteErr != nil {
+		b.WriteString(m.styles.error.Render(m.lang.T("tui.remote_error", m.remoteErr)))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.styles.info.Render(m.lang.T("tui.jobs")))
+	b.WriteString("\n")
+	if len(m.jobs) == 0 {
+		b.WriteString(m.styles.dim.Render("  " + m.lang.T("tui.no_jobs")))
+		b.WriteString("\n")
+	}
+	for _, job := range m.jobs[max(0, len(m.jobs)-queueRows):] {
+		line := fmt.Sprintf("  #%d %-9s %s", job.ID, job.State, jobURLs(job))
+		if job.State == daemon.JobRunning {
+			b.WriteString(m.styles.album.Render(line))
+		} else {
+			b.WriteString(m.styles.dim.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// jobURLs returns the first URL of job, followed by the number of others.
+func jobURLs(job daemon.Job) string {
+	if len(job.URLs) == 0 {
+		return ""
+	}
+	if len(job.URLs) == 1 {
+		return job.URLs[0]
+	}
+	return fmt.Sprintf("%s (+%d)", job.URLs[0], len(job.URLs)-1)
+}
+
+// remoteHelpText returns the help of the keys of the current screen in
+// remote mode.
+func (m Model) remoteHelpText() string {
+	switch m.state {
+	case StateInput:
+		keys := []string{"tui.key_start"}
+		if len(m.urls) > 0 {
+			keys = append(keys, "tui.key_history")
+		}
+		if m.hasCurrentJob() {
+			keys = append(keys, "tui.key_attach")
+		}
+		return m.keys(append(keys, "tui.key_verbose", "tui.key_exit")...)
+	case StateInitializing:
+		return m.keys("tui.key_cancel")
+	case StateDownloading:
+		return m.keys("tui.key_cancel", "tui.key_detach")
+	case StateComplete, StateError:
+		return m.keys("tui.key_new", "tui.key_quit")
+	}
+	return ""
+}
+
+// hasCurrentJob reports whether the daemon has a running or queued job to
+// watch.
+func (m Model) hasCurrentJob() bool {
+	for _, job := range m.jobs {
+		if !job.State.Finished() {
+			return true
+		}
+	}
+	return false
+}
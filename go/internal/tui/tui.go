@@ -3,52 +3,61 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/handiism/bandcamp-downloader/internal/bandcamp"
 	"github.com/handiism/bandcamp-downloader/internal/config"
+	"github.com/handiism/bandcamp-downloader/internal/console"
+	"github.com/handiism/bandcamp-downloader/internal/daemon"
 	"github.com/handiism/bandcamp-downloader/internal/download"
+	"github.com/handiism/bandcamp-downloader/internal/history"
+	"github.com/handiism/bandcamp-downloader/internal/i18n"
+	ioutils "github.com/handiism/bandcamp-downloader/internal/io"
 )
 
-// Styles for the TUI
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FF6B6B")).
-			MarginBottom(1)
-
-	subtitleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#4ECDC4"))
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#95E1A3"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF6B6B"))
-
-	warningStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFE66D"))
-
-	infoStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#A8DADC"))
+// styles are the lipgloss styles of the TUI, in the colors of a theme.
+type styles struct {
+	title, subtitle, success, error, warning, info, dim, box, album lipgloss.Style
+}
 
-	dimStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6C757D"))
+// newStyles returns the styles of theme.
+func newStyles(theme console.Theme) styles {
+	color := func(c string) lipgloss.TerminalColor {
+		if c == "" {
+			return lipgloss.NoColor{}
+		}
+		return lipgloss.Color(c)
+	}
+	fg := func(c string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(color(c))
+	}
 
-	boxStyle = lipgloss.NewStyle().
+	return styles{
+		title:    fg(theme.Title).Bold(true).MarginBottom(1),
+		subtitle: fg(theme.Subtitle),
+		success:  fg(theme.Success),
+		error:    fg(theme.Error),
+		warning:  fg(theme.Warning),
+		info:     fg(theme.Info),
+		dim:      fg(theme.Dim),
+		box: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#4ECDC4")).
-			Padding(1, 2)
-
-	albumStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F8B500"))
-)
+			BorderForeground(color(theme.Border)).
+			Padding(1, 2),
+		album: fg(theme.Album),
+	}
+}
 
 // State represents the current UI state.
 type State int
@@ -56,9 +65,11 @@ type State int
 const (
 	StateInput State = iota
 	StateInitializing
+	StatePreview
 	StateDownloading
 	StateComplete
 	StateError
+	StateResults
 )
 
 // LogEntry represents a log message in the UI.
@@ -85,35 +96,116 @@ type Model struct {
 	// Download manager reference
 	manager *download.Manager
 
+	// Preview of the albums before downloading: the tracks of each album,
+	// the selected album and the albums whose tracks are shown
+	tracks   [][]download.TrackSummary
+	selected int
+	expanded map[int]bool
+
+	// Search of a name entered instead of a URL: the query, whether it is
+	// running, and its results, picked from like the albums of the
+	// preview
+	query     string
+	searching bool
+	results   []bandcamp.SearchResult
+
 	// Download progress
 	totalFiles      int32
 	downloadedFiles int32
 	totalBytes      int64
 	receivedBytes   int64
+	stats           download.RunStats // of the finished run
 
 	// Options
 	discography bool
 	playlist    bool
 	verbose     bool
 
+	// sym are the decorations, plain ASCII if the terminal cannot
+	// display Unicode
+	sym   console.Symbols
+	ascii bool
+
+	// styles color the UI, see config.UI.Theme
+	styles styles
+
+	// lang translates the messages of the UI
+	lang *i18n.Catalog
+
+	// accessible is the screen-reader friendly mode: no animation, log
+	// lines printed one after another, and state changes announced.
+	// announced is the last quarter of the files announced as done.
+	accessible bool
+	announced  int
+
+	// opened is the result of opening the download folder, shown on the
+	// complete screen.
+	opened *LogEntry
+
+	// urls are the URLs last entered, oldest first, saved to urlsPath.
+	// urlIndex is the one shown while browsing them with the arrows, or
+	// len(urls) for the URL being typed, kept in draft meanwhile.
+	urls     []string
+	urlsPath string
+	urlIndex int
+	draft    string
+
+	// events receives the download progress events, see waitForEvent.
+	events chan download.ProgressEvent
+
+	// remote is the daemon the downloads are submitted to in remote mode,
+	// listening at remoteAddr (see WithRemote). job is the daemon's job
+	// being watched, and jobs its queue, polled along with remoteErr.
+	remote     *daemon.Client
+	remoteAddr string
+	job        *daemon.Job
+	jobs       []daemon.Job
+	remoteErr  error
+
 	width  int
 	height int
 }
 
-// NewModel creates a new TUI model.
-func NewModel() Model {
+// eventBuffer is how many progress events are kept until the UI reads
+// them; more are dropped rather than slowing downloads down.
+const eventBuffer = 256
+
+// NewModel creates a new TUI model. Downloads use settings, or the
+// defaults if nil.
+func NewModel(settings *config.Settings) Model {
+	if settings == nil {
+		settings = config.DefaultSettings()
+	}
+
 	ti := textinput.New()
 	ti.Placeholder = "https://artist.bandcamp.com/album/name"
 	ti.Focus()
 	ti.CharLimit = 500
 	ti.Width = 60
+	if settings.Accessible {
+		ti.Cursor.SetMode(cursor.CursorStatic)
+	}
+
+	caps := console.Detect(os.Stdout)
+	// Validated with the settings; an invalid theme falls back to no colors
+	theme, _ := console.NewTheme(settings.Theme, settings.ThemeColors)
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
-	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6B6B"))
+	sty := newStyles(theme)
+	sp.Style = lipgloss.NewStyle().Foreground(sty.title.GetForeground())
 
-	prog := progress.New(progress.WithDefaultGradient())
+	progressOptions := []progress.Option{progress.WithGradient(theme.ProgressStart, theme.ProgressEnd)}
+	if theme.ProgressStart == "" || theme.ProgressEnd == "" {
+		progressOptions = []progress.Option{progress.WithSolidFill(theme.ProgressStart + theme.ProgressEnd)}
+	}
+	if !caps.Unicode {
+		sp.Spinner = spinner.Line
+		progressOptions = append(progressOptions, progress.WithFillCharacters('#', '-'))
+	}
+	prog := progress.New(progressOptions...)
 	prog.Width = 50
+	prog.EmptyColor = theme.Dim
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -122,16 +214,30 @@ func NewModel() Model {
 		textInput: ti,
 		spinner:   sp,
 		progress:  prog,
-		settings:  config.DefaultSettings(),
-		logs:      make([]LogEntry, 0),
-		ctx:       ctx,
-		cancel:    cancel,
+		settings:  settings,
+		styles:    sty,
+		lang:      i18n.New(settings.Language),
+
+		accessible: settings.Accessible,
+		events:     make(chan download.ProgressEvent, eventBuffer),
+		logs:       make([]LogEntry, 0),
+		ctx:        ctx,
+		cancel:     cancel,
+		sym:        caps.Symbols(),
+		ascii:      !caps.Unicode,
 	}
 }
 
 // Init initializes the model.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(textinput.Blink, m.spinner.Tick)
+	cmds := []tea.Cmd{m.waitForEvent()}
+	if !m.accessible {
+		cmds = append(cmds, textinput.Blink, m.spinner.Tick)
+	}
+	if m.remote != nil {
+		cmds = append(cmds, m.fetchJobs(0))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Message types
@@ -157,11 +263,25 @@ type (
 		Total    int64
 		Files    int32
 		TotalF   int32
+		Stats    download.RunStats
 		Err      error
 	}
 
+	// SearchDoneMsg is sent when a search completes.
+	SearchDoneMsg struct {
+		Results []bandcamp.SearchResult
+		Err     error
+	}
+
 	// TickMsg is for periodic progress updates.
 	TickMsg struct{}
+
+	// FolderOpenedMsg is sent when the download folder was opened in the
+	// file manager, or could not be.
+	FolderOpenedMsg struct {
+		Folder string
+		Err    error
+	}
 )
 
 // Update handles messages and updates the model.
@@ -191,25 +311,85 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.state == StateInput {
 				return m, tea.Quit
 			}
-			if m.state == StateDownloading || m.state == StateInitializing {
+			if m.state == StateResults {
+				m.state = StateInput
+				return m, nil
+			}
+			if m.state == StateDownloading && m.remote != nil {
+				// Stopped in the daemon, then reported by the job's stream
+				return m, m.cancelJob()
+			}
+			if m.state == StateDownloading || m.state == StateInitializing || m.state == StatePreview {
 				m.cancel()
 				m.state = StateError
-				m.err = fmt.Errorf("cancelled by user")
+				m.err = errors.New(m.lang.T("tui.cancelled"))
+				cmds = append(cmds, m.announce(m.lang.T("tui.error")+" "+m.err.Error()))
+			}
+
+		case "up", "down":
+			if m.state == StateInput {
+				m.browseURLs(msg.String() == "up")
+			}
+			if m.state == StatePreview {
+				return m, m.selectAlbum(msg.String() == "up")
+			}
+			if m.state == StateResults {
+				return m, m.selectResult(msg.String() == "up")
+			}
+
+		case " ":
+			if m.state == StatePreview {
+				return m, m.toggleTracks()
 			}
 
 		case "enter":
+			if m.state == StatePreview {
+				m.state = StateDownloading
+				return m, tea.Batch(m.startDownload(), m.tickProgress(), m.announce(m.lang.T("tui.downloading")))
+			}
+			if m.state == StateInput && isSearchQuery(m.textInput.Value()) {
+				m.query = strings.TrimSpace(m.textInput.Value())
+				m.searching = true
+				m.state = StateInitializing
+				if m.accessible {
+					return m, tea.Batch(m.search(), m.announce(m.initializingText()))
+				}
+				return m, tea.Batch(m.search(), m.spinner.Tick)
+			}
+			if m.state == StateResults && m.selected < len(m.results) {
+				// Downloaded as if its URL was entered
+				result := m.results[m.selected]
+				m.textInput.SetValue(result.URL)
+				if result.Kind == bandcamp.SearchArtist && m.remote == nil {
+					m.discography = true
+				}
+				m.state = StateInput
+			}
 			if m.state == StateInput && m.textInput.Value() != "" {
+				m.addURL(m.textInput.Value())
 				m.state = StateInitializing
-				return m, tea.Batch(m.initializeDownload(), m.spinner.Tick)
+				initialize := m.initializeDownload()
+				if m.remote != nil {
+					initialize = m.submitJob()
+				}
+				if m.accessible {
+					return m, tea.Batch(initialize, m.announce(m.initializingText()))
+				}
+				return m, tea.Batch(initialize, m.spinner.Tick)
+			}
+
+		case "tab":
+			if m.state == StateInput && m.remote != nil {
+				return m, m.attachJob()
 			}
 
 		case "d":
-			if m.state == StateInput {
+			if m.state == StateInput && m.remote == nil {
 				m.discography = !m.discography
 			}
 
 		case "p":
-			if m.state == StateInput {
+			if m.state == StateInput && m.remote == nil {
 				m.playlist = !m.playlist
 			}
 
@@ -223,18 +403,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 
+		case "o":
+			if m.state == StateComplete && m.remote == nil {
+				cmds = append(cmds, m.openFolder())
+			}
+
 		case "r":
 			if m.state == StateComplete || m.state == StateError {
 				// Reset for new download
 				m.state = StateInput
 				m.logs = nil
 				m.albums = nil
+				m.tracks = nil
 				m.err = nil
 				m.downloadedFiles = 0
 				m.totalFiles = 0
 				m.receivedBytes = 0
 				m.totalBytes = 0
+				m.stats = download.RunStats{}
 				m.manager = nil
+				m.query = ""
+				m.searching = false
+				m.results = nil
+				m.job = nil
+				m.announced = 0
+				m.opened = nil
+				m.urlIndex = len(m.urls)
 				m.ctx, m.cancel = context.WithCancel(context.Background())
 				m.textInput.SetValue("")
 				m.textInput.Focus()
@@ -242,15 +436,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case spinner.TickMsg:
+		if m.accessible {
+			return m, nil
+		}
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		cmds = append(cmds, cmd)
 
 	case ProgressMsg:
-		// Filter verbose messages if not in verbose mode
-		if msg.Event.Level == download.LevelVerbose && !m.verbose {
-			return m, nil
+		// Filter byte progress, and verbose messages if not in verbose mode
+		if msg.Event.Level == download.LevelProgress || (msg.Event.Level == download.LevelVerbose && !m.verbose) {
+			return m, m.waitForEvent()
 		}
+		if m.accessible {
+			// Printed above the view, so the log reads as a sequence
+			return m, tea.Batch(tea.Println(m.logPrefix(msg.Event.Level)+" "+msg.Event.Message), m.waitForEvent())
+		}
+		cmds = append(cmds, m.waitForEvent())
 		m.logs = append(m.logs, LogEntry{
 			Message: msg.Event.Message,
 			Level:   msg.Event.Level,
@@ -260,16 +462,58 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.logs = m.logs[len(m.logs)-10:]
 		}
 
+	case FolderOpenedMsg:
+		entry := LogEntry{Message: m.lang.T("tui.opened", msg.Folder), Level: download.LevelInfo}
+		if msg.Err != nil {
+			entry = LogEntry{Message: m.lang.T("tui.open_failed", msg.Folder, msg.Err), Level: download.LevelError}
+		}
+		if m.accessible {
+			return m, tea.Println(m.logPrefix(entry.Level) + " " + entry.Message)
+		}
+		m.opened = &entry
+
 	case InitDoneMsg:
 		if msg.Err != nil {
 			m.state = StateError
 			m.err = msg.Err
+			cmds = append(cmds, m.announce(m.lang.T("tui.error")+" "+m.err.Error()))
 		} else {
 			m.albums = msg.Albums
 			m.manager = msg.Manager
-			m.state = StateDownloading
-			// Start the actual download and tick for progress updates
-			cmds = append(cmds, m.startDownload(), m.tickProgress())
+			m.tracks = make([][]download.TrackSummary, len(m.albums))
+			for i := range m.albums {
+				m.tracks[i] = m.manager.GetAlbumTracks(i)
+			}
+			m.selected = 0
+			m.expanded = make(map[int]bool)
+			// The download starts once confirmed from the preview
+			m.state = StatePreview
+			cmds = append(cmds, m.announce(m.lang.T("tui.found_albums", len(m.albums))+" "+strings.Join(m.albums, ", ")))
+			cmds = append(cmds, m.announce(m.lang.T("tui.estimated", m.estimatedMB(-1))))
+			if exceeds, mb := m.manager.ExceedsSizeThreshold(); exceeds {
+				cmds = append(cmds, m.announce(m.lang.T("tui.too_large", mb, m.settings.ConfirmIfLargerThanMB)))
+			}
+		}
+
+	case SearchDoneMsg:
+		if !m.searching || m.state != StateInitializing {
+			// Cancelled meanwhile
+			break
+		}
+		m.searching = false
+		if msg.Err != nil {
+			m.state = StateError
+			m.err = msg.Err
+			cmds = append(cmds, m.announce(m.lang.T("tui.error")+" "+m.err.Error()))
+			break
+		}
+		m.results = msg.Results
+		m.selected = 0
+		m.state = StateResults
+		if len(m.results) == 0 {
+			cmds = append(cmds, m.announce(m.lang.T("tui.no_results", m.query)))
+		} else {
+			cmds = append(cmds, m.announce(m.lang.T("tui.results", len(m.results), m.query)+" "+m.results[0].String()))
 		}
 
 	case DownloadDoneMsg:
@@ -277,33 +521,49 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.totalBytes = msg.Total
 		m.downloadedFiles = msg.Files
 		m.totalFiles = msg.TotalF
+		m.stats = msg.Stats
 		if msg.Err != nil && m.ctx.Err() == nil {
 			m.state = StateError
 			m.err = msg.Err
+			cmds = append(cmds, m.announce(m.lang.T("tui.error")+" "+m.err.Error()))
 		} else if m.ctx.Err() != nil {
-			m.state = StateError
-			m.err = fmt.Errorf("cancelled by user")
+			if m.state != StateError {
+				m.state = StateError
+				m.err = errors.New(m.lang.T("tui.cancelled"))
+				cmds = append(cmds, m.announce(m.lang.T("tui.error")+" "+m.err.Error()))
+			}
 		} else {
 			m.state = StateComplete
+			cmds = append(cmds, m.announce(m.lang.T("tui.complete")+" "+m.summary(", ")))
 		}
 
 	case TickMsg:
 		// Update progress from manager
 		if m.manager != nil && m.state == StateDownloading {
-			received, total, files, totalFiles := m.manager.GetProgress()
-			m.receivedBytes = received
-			m.totalBytes = total
-			m.downloadedFiles = files
-			m.totalFiles = totalFiles
-
-			// Calculate percentage and animate progress bar
-			var percent float64
-			if totalFiles > 0 {
-				percent = float64(files) / float64(totalFiles)
+			cmds = append(cmds, m.tickProgress())
+			cmds = append(cmds, m.updateProgress(m.manager.GetProgress())...)
+		}
+
+	case JobSubmittedMsg:
+		if msg.Err != nil {
+			m.state = StateError
+			m.err = msg.Err
+			cmds = append(cmds, m.announce(m.lang.T("tui.error")+" "+m.err.Error()))
+		} else {
+			m.job = msg.Job
+			m.state = StateDownloading
+			cmds = append(cmds, m.watchJob(), m.announce(m.lang.T("tui.downloading")))
+		}
+
+	case JobsMsg:
+		m.remoteErr = msg.Err
+		if msg.Err == nil {
+			m.jobs = msg.Jobs
+			if m.job != nil && m.state == StateDownloading {
+				cmds = append(cmds, m.updateJob(msg.Jobs)...)
 			}
-			progressCmd := m.progress.SetPercent(percent)
-			cmds = append(cmds, progressCmd, m.tickProgress())
 		}
+		cmds = append(cmds, m.fetchJobs(pollInterval))
 
 	case progress.FrameMsg:
 		progressModel, cmd := m.progress.Update(msg)
@@ -321,6 +581,132 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// updateProgress sets the files and bytes downloaded, and returns the
+// commands animating the progress bar, or announcing each quarter of the
+// files in accessible mode.
+func (m *Model) updateProgress(received, total int64, files, totalFiles int32) []tea.Cmd {
+	m.receivedBytes = received
+	m.totalBytes = total
+	m.downloadedFiles = files
+	m.totalFiles = totalFiles
+
+	var percent float64
+	if totalFiles > 0 {
+		percent = float64(files) / float64(totalFiles)
+	}
+	if !m.accessible {
+		return []tea.Cmd{m.progress.SetPercent(percent)}
+	}
+	// Announce each quarter of the files, rather than every tick
+	if quarter := int(percent * 4); quarter > m.announced && quarter < 4 {
+		m.announced = quarter
+		return []tea.Cmd{m.announce(m.lang.T("tui.progress", files, totalFiles, float64(received)/1024/1024))}
+	}
+	return nil
+}
+
+// WithURLHistory returns the model with the URL history saved at path
+// (see history.URLsPath), recalled with the up and down arrows.
+func (m Model) WithURLHistory(path string) Model {
+	m.urlsPath = path
+	if m.settings.URLHistorySize > 0 {
+		// The history is a convenience; without it the TUI works the same
+		m.urls, _ = history.LoadURLs(path)
+	}
+	m.urlIndex = len(m.urls)
+	return m
+}
+
+// addURL saves url in the URL history.
+func (m *Model) addURL(url string) {
+	if m.urlsPath != "" && m.settings.URLHistorySize > 0 {
+		if urls, err := history.AddURL(m.urlsPath, url, m.settings.URLHistorySize); err == nil {
+			m.urls = urls
+		}
+	}
+	m.urlIndex = len(m.urls)
+}
+
+// browseURLs shows the previous URL of the history in the input if back,
+// or the next one, back to the URL being typed.
+func (m *Model) browseURLs(back bool) {
+	switch {
+	case back && m.urlIndex > 0:
+		if m.urlIndex == len(m.urls) {
+			m.draft = m.textInput.Value()
+		}
+		m.urlIndex--
+	case !back && m.urlIndex < len(m.urls):
+		m.urlIndex++
+	default:
+		return
+	}
+
+	if m.urlIndex == len(m.urls) {
+		m.textInput.SetValue(m.draft)
+	} else {
+		m.textInput.SetValue(m.urls[m.urlIndex])
+	}
+	m.textInput.CursorEnd()
+}
+
+// waitForEvent returns a command reading the next progress event of the
+// downloads as a ProgressMsg.
+func (m Model) waitForEvent() tea.Cmd {
+	return func() tea.Msg {
+		return ProgressMsg{Event: <-m.events}
+	}
+}
+
+// announce returns a command printing a state change in accessible mode,
+// so screen readers read it, or nil otherwise.
+func (m Model) announce(text string) tea.Cmd {
+	if !m.accessible {
+		return nil
+	}
+	return tea.Println(m.lang.T("tui.status", text))
+}
+
+// openFolder returns a command opening the folder of the downloaded album
+// in the file manager, or the folder holding them all for several, and
+// logging the error if it cannot be opened.
+func (m Model) openFolder() tea.Cmd {
+	var paths []string
+	if m.manager != nil {
+		for _, album := range m.manager.GetProgressSnapshot() {
+			paths = append(paths, album.Path)
+		}
+	}
+	folder := commonFolder(paths)
+	if folder == "" {
+		folder = m.settings.LibraryRoot()
+	}
+
+	return func() tea.Msg {
+		return FolderOpenedMsg{Folder: folder, Err: ioutils.OpenFolder(folder)}
+	}
+}
+
+// commonFolder returns the deepest folder holding all of paths, or "" if
+// there are none.
+func commonFolder(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	common := filepath.Clean(paths[0])
+	for _, path := range paths[1:] {
+		path = filepath.Clean(path)
+		for common != path && !strings.HasPrefix(path, common+string(filepath.Separator)) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
 // tickProgress returns a command to tick progress updates.
 func (m Model) tickProgress() tea.Cmd {
 	return tea.Tick(200*time.Millisecond, func(_ time.Time) tea.Msg {
@@ -333,9 +719,9 @@ func (m Model) View() string {
 	var b strings.Builder
 
 	// Header
-	b.WriteString(titleStyle.Render("🎵 Bandcamp Downloader"))
+	b.WriteString(m.styles.title.Render(m.sym.Title + m.lang.T("app.title")))
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("Download music from Bandcamp"))
+	b.WriteString(m.styles.dim.Render(m.lang.T("tui.subtitle")))
 	b.WriteString("\n\n")
 
 	switch m.state {
@@ -343,6 +729,10 @@ func (m Model) View() string {
 		b.WriteString(m.viewInput())
 	case StateInitializing:
 		b.WriteString(m.viewInitializing())
+	case StatePreview:
+		b.WriteString(m.viewPreview())
+	case StateResults:
+		b.WriteString(m.viewResults())
 	case StateDownloading:
 		b.WriteString(m.viewDownloading())
 	case StateComplete:
@@ -353,7 +743,7 @@ func (m Model) View() string {
 
 	// Footer
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render(m.getHelpText()))
+	b.WriteString(m.styles.dim.Render(m.getHelpText()))
 
 	return b.String()
 }
@@ -361,32 +751,29 @@ func (m Model) View() string {
 func (m Model) viewInput() string {
 	var b strings.Builder
 
-	b.WriteString(subtitleStyle.Render("Enter Bandcamp URL:"))
+	b.WriteString(m.styles.subtitle.Render(m.lang.T("tui.enter_url")))
 	b.WriteString("\n\n")
 	b.WriteString(m.textInput.View())
 	b.WriteString("\n\n")
 
 	// Options
-	discographyCheck := "[ ]"
-	if m.discography {
-		discographyCheck = "[×]"
-	}
-	playlistCheck := "[ ]"
-	if m.playlist {
-		playlistCheck = "[×]"
-	}
-	verboseCheck := "[ ]"
-	if m.verbose {
-		verboseCheck = "[×]"
-	}
+	discographyCheck := m.checkbox(m.discography)
+	playlistCheck := m.checkbox(m.playlist)
+	verboseCheck := m.checkbox(m.verbose)
 
-	b.WriteString(infoStyle.Render("Options:"))
+	b.WriteString(m.styles.info.Render(m.lang.T("tui.options")))
 	b.WriteString("\n")
-	b.WriteString(fmt.Sprintf("  %s Download discography (d)\n", discographyCheck))
-	b.WriteString(fmt.Sprintf("  %s Create playlist (p)\n", playlistCheck))
-	b.WriteString(fmt.Sprintf("  %s Verbose/debug output (v)\n", verboseCheck))
+	if m.remote == nil {
+		b.WriteString(fmt.Sprintf("  %s %s\n", discographyCheck, m.lang.T("tui.opt_discography")))
+		b.WriteString(fmt.Sprintf("  %s %s\n", playlistCheck, m.lang.T("tui.opt_playlist")))
+	}
+	b.WriteString(fmt.Sprintf("  %s %s\n", verboseCheck, m.lang.T("tui.opt_verbose")))
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render(fmt.Sprintf("Download path: %s", m.settings.DownloadsPath)))
+	if m.remote != nil {
+		b.WriteString(m.viewQueue())
+		return b.String()
+	}
+	b.WriteString(m.styles.dim.Render(m.lang.T("tui.download_path", m.settings.DownloadsPath)))
 	b.WriteString("\n")
 
 	return b.String()
@@ -395,9 +782,11 @@ func (m Model) viewInput() string {
 func (m Model) viewInitializing() string {
 	var b strings.Builder
 
-	b.WriteString(m.spinner.View())
-	b.WriteString(" ")
-	b.WriteString(subtitleStyle.Render("Fetching album info..."))
+	if !m.accessible {
+		b.WriteString(m.spinner.View())
+		b.WriteString(" ")
+	}
+	b.WriteString(m.styles.subtitle.Render(m.initializingText()))
 	b.WriteString("\n\n")
 
 	// Show logs
@@ -406,15 +795,192 @@ func (m Model) viewInitializing() string {
 	return b.String()
 }
 
+// initializingText returns the status shown while the albums are fetched,
+// or while the job is submitted in remote mode.
+func (m Model) initializingText() string {
+	if m.searching {
+		return m.lang.T("tui.searching")
+	}
+	if m.remote != nil {
+		return m.lang.T("tui.submitting")
+	}
+	return m.lang.T("tui.fetching")
+}
+
+// previewRows is how many albums the preview lists at once, scrolling to
+// keep the selected one visible.
+const previewRows = 10
+
+func (m Model) viewPreview() string {
+	var b strings.Builder
+
+	b.WriteString(m.styles.success.Render(m.lang.T("tui.found_albums", len(m.albums))))
+	b.WriteString("\n")
+
+	first := max(0, min(m.selected-previewRows/2, len(m.albums)-previewRows))
+	for i := first; i < len(m.albums) && i < first+previewRows; i++ {
+		marker := " "
+		if i == m.selected {
+			marker = m.sym.Arrow
+		}
+		line := fmt.Sprintf("%s %s  ~%.1f MB", marker, m.albums[i], m.estimatedMB(i))
+		if i == m.selected {
+			b.WriteString(m.styles.album.Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+		if m.expanded[i] {
+			for _, track := range m.tracks[i] {
+				b.WriteString(m.styles.dim.Render("    " + trackLine(track)))
+				b.WriteString("\n")
+			}
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(m.styles.info.Render(m.lang.T("tui.estimated", m.estimatedMB(-1))))
+	b.WriteString("\n")
+	if exceeds, mb := m.manager.ExceedsSizeThreshold(); exceeds {
+		b.WriteString(m.styles.warning.Render(m.lang.T("tui.too_large", mb, m.settings.ConfirmIfLargerThanMB)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(m.renderLogs())
+
+	return b.String()
+}
+
+// selectAlbum selects the previous album of the preview if up, or the next
+// one, announcing it in accessible mode.
+func (m *Model) selectAlbum(up bool) tea.Cmd {
+	switch {
+	case up && m.selected > 0:
+		m.selected--
+	case !up && m.selected < len(m.albums)-1:
+		m.selected++
+	default:
+		return nil
+	}
+	return m.announce(m.albums[m.selected])
+}
+
+// toggleTracks shows or hides the tracks of the selected album. In
+// accessible mode they are printed instead.
+func (m *Model) toggleTracks() tea.Cmd {
+	if m.selected >= len(m.tracks) {
+		return nil
+	}
+	if m.accessible {
+		lines := make([]string, len(m.tracks[m.selected]))
+		for i, track := range m.tracks[m.selected] {
+			lines[i] = trackLine(track)
+		}
+		return tea.Println(strings.Join(lines, "\n"))
+	}
+	m.expanded[m.selected] = !m.expanded[m.selected]
+	return nil
+}
+
+// isSearchQuery returns whether the input is a name to search for rather
+// than URLs: it has no scheme and, if a single word, no dot of a domain.
+func isSearchQuery(input string) bool {
+	input = strings.TrimSpace(input)
+	if input == "" || strings.Contains(input, "://") {
+		return false
+	}
+	return strings.ContainsAny(input, " \t") || !strings.Contains(input, ".")
+}
+
+func (m Model) viewResults() string {
+	var b strings.Builder
+
+	if len(m.results) == 0 {
+		b.WriteString(m.styles.warning.Render(m.lang.T("tui.no_results", m.query)))
+		b.WriteString("\n")
+		return b.String()
+	}
+	b.WriteString(m.styles.success.Render(m.lang.T("tui.results", len(m.results), m.query)))
+	b.WriteString("\n")
+
+	first := max(0, min(m.selected-previewRows/2, len(m.results)-previewRows))
+	for i := first; i < len(m.results) && i < first+previewRows; i++ {
+		marker := " "
+		if i == m.selected {
+			marker = m.sym.Arrow
+		}
+		line := fmt.Sprintf("%s %s", marker, m.results[i])
+		if i == m.selected {
+			b.WriteString(m.styles.album.Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+		b.WriteString(m.styles.dim.Render("    " + m.results[i].URL))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// selectResult selects the previous search result if up, or the next one,
+// announcing it in accessible mode.
+func (m *Model) selectResult(up bool) tea.Cmd {
+	switch {
+	case up && m.selected > 0:
+		m.selected--
+	case !up && m.selected < len(m.results)-1:
+		m.selected++
+	default:
+		return nil
+	}
+	return m.announce(m.results[m.selected].String())
+}
+
+// estimatedMB returns the estimated size of the album at index i of the
+// preview, or of all albums if i is negative, in MB.
+func (m Model) estimatedMB(i int) float64 {
+	var size int64
+	for j, tracks := range m.tracks {
+		if i >= 0 && j != i {
+			continue
+		}
+		for _, track := range tracks {
+			size += track.EstimatedSize
+		}
+	}
+	return float64(size) / 1024 / 1024
+}
+
+// trackLine formats a track of the preview with its duration and
+// estimated size.
+func trackLine(track download.TrackSummary) string {
+	number := fmt.Sprintf("%2d.", track.Number)
+	if track.DiscNumber > 1 {
+		number = fmt.Sprintf("%d-%02d.", track.DiscNumber, track.Number)
+	}
+	seconds := int(track.Duration.Seconds())
+	return fmt.Sprintf("%s %s (%d:%02d, ~%.1f MB)", number, track.Title, seconds/60, seconds%60, float64(track.EstimatedSize)/1024/1024)
+}
+
 func (m Model) viewDownloading() string {
 	var b strings.Builder
 
-	// Albums found
-	if len(m.albums) > 0 {
-		b.WriteString(successStyle.Render(fmt.Sprintf("Found %d album(s):", len(m.albums))))
+	// Albums found, announced in accessible mode
+	if len(m.albums) > 0 && !m.accessible {
+		b.WriteString(m.styles.success.Render(m.lang.T("tui.found_albums", len(m.albums))))
 		b.WriteString("\n")
 		for _, album := range m.albums {
-			b.WriteString(albumStyle.Render(fmt.Sprintf("  ♪ %s", album)))
+			b.WriteString(m.styles.album.Render(fmt.Sprintf("  %s %s", m.sym.Note, album)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if m.job != nil && !m.accessible {
+		b.WriteString(m.styles.success.Render(m.lang.T("tui.remote_job", m.job.ID)))
+		b.WriteString("\n")
+		for _, url := range m.job.URLs {
+			b.WriteString(m.styles.album.Render(fmt.Sprintf("  %s %s", m.sym.Note, url)))
 			b.WriteString("\n")
 		}
 		b.WriteString("\n")
@@ -425,11 +991,13 @@ func (m Model) viewDownloading() string {
 	if m.totalFiles > 0 {
 		percent = float64(m.downloadedFiles) / float64(m.totalFiles)
 	}
-	b.WriteString(m.progress.ViewAs(percent))
-	b.WriteString("\n")
+	if !m.accessible {
+		b.WriteString(m.progress.ViewAs(percent))
+		b.WriteString("\n")
+	}
 
-	b.WriteString(infoStyle.Render(fmt.Sprintf(
-		"Files: %d/%d | Downloaded: %.2f MB",
+	b.WriteString(m.styles.info.Render(m.lang.T(
+		"tui.progress",
 		m.downloadedFiles,
 		m.totalFiles,
 		float64(m.receivedBytes)/1024/1024,
@@ -445,24 +1013,50 @@ func (m Model) viewDownloading() string {
 func (m Model) viewComplete() string {
 	var b strings.Builder
 
-	box := boxStyle.Render(fmt.Sprintf(
-		"✨ Download Complete!\n\n"+
-			"Albums: %d\n"+
-			"Files: %d\n"+
-			"Size: %.2f MB",
-		len(m.albums),
-		m.downloadedFiles,
-		float64(m.receivedBytes)/1024/1024,
-	))
-	b.WriteString(box)
+	text := m.sym.Done + m.lang.T("tui.complete") + "\n\n" + m.summary("\n")
+	if m.accessible {
+		// A border would be read out as characters
+		b.WriteString(text + "\n")
+		return b.String()
+	}
+
+	style := m.styles.box
+	if m.ascii {
+		style = style.Border(lipgloss.ASCIIBorder())
+	}
+	b.WriteString(style.Render(text))
+	if m.opened != nil {
+		b.WriteString("\n\n")
+		b.WriteString(m.renderLog(*m.opened))
+	}
 
 	return b.String()
 }
 
+// summary returns the albums, files and size downloaded, the outcome of
+// the tracks and the time taken, separated by sep.
+func (m Model) summary(sep string) string {
+	albums := len(m.albums)
+	if m.job != nil {
+		albums = m.job.Albums
+	}
+	lines := []string{
+		m.lang.T("tui.albums", albums),
+		m.lang.T("tui.files", m.downloadedFiles),
+		m.lang.T("tui.size", float64(m.receivedBytes)/1024/1024),
+		m.lang.T("tui.tracks", m.stats.Downloaded, m.stats.Skipped, m.stats.Unavailable, m.stats.Failed),
+	}
+	if m.stats.DurationMismatches > 0 {
+		lines = append(lines, m.lang.T("tui.truncated", m.stats.DurationMismatches))
+	}
+	lines = append(lines, m.lang.T("tui.elapsed", m.stats.Elapsed.Round(time.Second), m.stats.Speed()/1024/1024))
+	return strings.Join(lines, sep)
+}
+
 func (m Model) viewError() string {
 	var b strings.Builder
 
-	b.WriteString(errorStyle.Render("❌ Error occurred:"))
+	b.WriteString(m.styles.error.Render(m.sym.Error + m.lang.T("tui.error")))
 	b.WriteString("\n\n")
 	if m.err != nil {
 		b.WriteString(fmt.Sprintf("  %s", m.err.Error()))
@@ -475,50 +1069,100 @@ func (m Model) renderLogs() string {
 	var b strings.Builder
 
 	for _, log := range m.logs {
-		var style lipgloss.Style
-		prefix := "•"
-		switch log.Level {
-		case download.LevelError:
-			style = errorStyle
-			prefix = "✗"
-		case download.LevelWarning:
-			style = warningStyle
-			prefix = "!"
-		case download.LevelSuccess:
-			style = successStyle
-			prefix = "✓"
-		case download.LevelInfo:
-			style = infoStyle
-			prefix = "›"
-		default:
-			style = dimStyle
-		}
-		b.WriteString(style.Render(prefix + " " + log.Message))
+		b.WriteString(m.renderLog(log))
 		b.WriteString("\n")
 	}
 
 	return b.String()
 }
 
+// renderLog renders a log line in the style of its level.
+func (m Model) renderLog(log LogEntry) string {
+	var style lipgloss.Style
+	switch log.Level {
+	case download.LevelError:
+		style = m.styles.error
+	case download.LevelWarning:
+		style = m.styles.warning
+	case download.LevelSuccess:
+		style = m.styles.success
+	case download.LevelInfo:
+		style = m.styles.info
+	default:
+		style = m.styles.dim
+	}
+	return style.Render(m.logPrefix(log.Level) + " " + log.Message)
+}
+
+// logPrefix returns the symbol before the log messages of level.
+func (m Model) logPrefix(level download.ProgressLevel) string {
+	switch level {
+	case download.LevelError:
+		return m.sym.Cross
+	case download.LevelWarning:
+		return "!"
+	case download.LevelSuccess:
+		return m.sym.Check
+	case download.LevelInfo:
+		return m.sym.Arrow
+	default:
+		return m.sym.Bullet
+	}
+}
+
 func (m Model) getHelpText() string {
+	if m.state == StateResults {
+		// The same in remote mode
+		if len(m.results) == 0 {
+			return m.keys("tui.key_back")
+		}
+		return m.keys("tui.key_download", "tui.key_select", "tui.key_back")
+	}
+	if m.remote != nil {
+		return m.remoteHelpText()
+	}
 	switch m.state {
 	case StateInput:
-		return "enter: start • d: discography • p: playlist • v: verbose • esc: quit"
+		if len(m.urls) > 0 {
+			return m.keys("tui.key_start", "tui.key_history", "tui.key_discography", "tui.key_playlist", "tui.key_verbose", "tui.key_exit")
+		}
+		return m.keys("tui.key_start", "tui.key_discography", "tui.key_playlist", "tui.key_verbose", "tui.key_exit")
+	case StatePreview:
+		return m.keys("tui.key_download", "tui.key_select", "tui.key_tracks", "tui.key_cancel")
 	case StateInitializing, StateDownloading:
-		return "esc: cancel"
-	case StateComplete, StateError:
-		return "r: new download • q: quit"
+		return m.keys("tui.key_cancel")
+	case StateComplete:
+		return m.keys("tui.key_open", "tui.key_new", "tui.key_quit")
+	case StateError:
+		return m.keys("tui.key_new", "tui.key_quit")
 	}
 	return ""
 }
 
+// keys joins the translated help of the keys of the current screen.
+func (m Model) keys(keys ...string) string {
+	help := make([]string, len(keys))
+	for i, key := range keys {
+		help[i] = m.lang.T(key)
+	}
+	return strings.Join(help, m.sym.Separator)
+}
+
+// checkbox renders the box of an option.
+func (m Model) checkbox(checked bool) string {
+	if checked {
+		return "[" + m.sym.Checked + "]"
+	}
+	return "[ ]"
+}
+
 // initializeDownload fetches album info and creates the manager.
 func (m *Model) initializeDownload() tea.Cmd {
 	return func() tea.Msg {
 		url := m.textInput.Value()
 
 		// Apply options
-		settings := config.DefaultSettings()
+		settings := *m.settings
 		if m.discography {
 			settings.DownloadArtistDiscography = true
 		}
@@ -528,10 +1172,17 @@ func (m *Model) initializeDownload() tea.Cmd {
 
 		var albumNames []string
 
-		// Create manager with progress callback
-		manager := download.NewManager(settings, func(event download.ProgressEvent) {
-			// Progress events are collected but not sent directly
-			// The TUI polls for progress via TickMsg
+		// Create manager with progress callback. Byte progress is polled
+		// via TickMsg; messages are passed to the UI, or dropped if it
+		// falls behind.
+		manager := download.NewManager(&settings, func(event download.ProgressEvent) {
+			if event.Level == download.LevelProgress {
+				return
+			}
+			select {
+			case m.events <- event:
+			default:
+			}
 		})
 
 		// Initialize - this fetches album info
@@ -550,6 +1201,15 @@ func (m *Model) initializeDownload() tea.Cmd {
 	}
 }
 
+// search searches for the name entered, with the network settings.
+func (m *Model) search() tea.Cmd {
+	query := m.query
+	return func() tea.Msg {
+		results, err := download.NewManager(m.settings, nil).Search(m.ctx, query, bandcamp.SearchAll)
+		return SearchDoneMsg{Results: results, Err: err}
+	}
+}
+
 // startDownload starts the actual download in background.
 func (m *Model) startDownload() tea.Cmd {
 	return func() tea.Msg {
@@ -565,14 +1225,34 @@ func (m *Model) startDownload() tea.Cmd {
 			Total:    total,
 			Files:    files,
 			TotalF:   totalFiles,
+			Stats:    m.manager.GetRunStats(),
 			Err:      err,
 		}
 	}
 }
 
-// Run starts the TUI application.
-func Run() error {
-	p := tea.NewProgram(NewModel(), tea.WithAltScreen())
+// Run starts the TUI application with settings, or the defaults if nil.
+// In accessible mode, the UI stays in the terminal's main screen so that
+// the printed lines remain readable. The URLs entered are kept in the
+// history file at urlsPath, if not empty.
+func Run(settings *config.Settings, urlsPath string) error {
+	return run(NewModel(settings).WithURLHistory(urlsPath))
+}
+
+// RunRemote starts the TUI like Run, submitting the downloads to the
+// daemon listening on the socket at socketPath and following their
+// progress, rather than downloading them itself.
+func RunRemote(settings *config.Settings, urlsPath, socketPath string) error {
+	return run(NewModel(settings).WithURLHistory(urlsPath).WithRemote(daemon.NewClient(socketPath), socketPath))
+}
+
+// run runs the program of model.
+func run(model Model) error {
+	var options []tea.ProgramOption
+	if !model.accessible {
+		options = append(options, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(model, options...)
 	_, err := p.Run()
 	return err
 }